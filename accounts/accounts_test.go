@@ -0,0 +1,35 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+)
+
+func TestValidateLocation(t *testing.T) {
+	zone := common.Location{0, 0}
+
+	var inside, outside *Account
+	for inside == nil || outside == nil {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		account := Account{Address: crypto.PubkeyToAddress(key.PublicKey)}
+		if common.DefaultTopology.ContainsAddress(zone, account.Address) {
+			inside = &account
+		} else {
+			outside = &account
+		}
+	}
+	if err := ValidateLocation(*inside, zone, common.DefaultTopology); err != nil {
+		t.Errorf("account inside of %s rejected: %v", zone.Name(), err)
+	}
+	if err := ValidateLocation(*outside, zone, common.DefaultTopology); err == nil {
+		t.Errorf("account outside of %s accepted", zone.Name())
+	}
+	if err := ValidateLocation(*inside, common.Location{0}, common.DefaultTopology); err == nil {
+		t.Errorf("account accepted in a region context")
+	}
+}
@@ -0,0 +1,174 @@
+package accounts
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+)
+
+var (
+	// ErrGrindLocation is returned if keys are ground for a location which is
+	// not a zone. Only zones own a range of the address space.
+	ErrGrindLocation = errors.New("addresses can only be ground for a zone location")
+
+	// ErrGrindPrefix is returned if the requested address prefix can never be
+	// satisfied by an address inside of the requested zone.
+	ErrGrindPrefix = errors.New("address prefix is outside of the zone's address space")
+)
+
+// GrindConfig specifies the address a grinder is searching for.
+type GrindConfig struct {
+	Location common.Location  // Zone the address has to belong to
+	Topology *common.Topology // Topology the zone is part of, defaults to common.DefaultTopology
+	Prefix   string           // Optional hex prefix the address has to start with (odd lengths allowed)
+	Threads  int              // Number of keys generated in parallel, defaults to the CPU count
+}
+
+// GrindResult is a key whose address satisfies a GrindConfig.
+type GrindResult struct {
+	Key      *ecdsa.PrivateKey
+	Address  common.Address
+	Attempts uint64 // Number of keys generated across all threads until a match was found
+}
+
+// addressMatcher checks raw addresses against the first byte ranges owned by a
+// zone, together with any additional prefix nibbles.
+type addressMatcher struct {
+	first   [256]bool // First address bytes both inside of the zone and matching the prefix
+	nibbles []byte    // Prefix nibbles which need checking beyond the first byte
+}
+
+// newAddressMatcher validates a grind config and precomputes the checks needed
+// to match generated addresses against it.
+func newAddressMatcher(location common.Location, topology *common.Topology, prefix string) (*addressMatcher, error) {
+	if topology == nil {
+		topology = common.DefaultTopology
+	}
+	if len(location) != common.HierarchyDepth-1 || !topology.Contains(location) {
+		return nil, ErrGrindLocation
+	}
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(prefix, "0x"), "0X"))
+	if len(prefix) > 2*common.AddressLength {
+		return nil, fmt.Errorf("address prefix too long: %d nibbles, max %d", len(prefix), 2*common.AddressLength)
+	}
+	nibbles := make([]byte, len(prefix))
+	for i, c := range prefix {
+		switch {
+		case c >= '0' && c <= '9':
+			nibbles[i] = byte(c - '0')
+		case c >= 'a' && c <= 'f':
+			nibbles[i] = byte(c-'a') + 10
+		default:
+			return nil, fmt.Errorf("invalid hex character %q in address prefix", c)
+		}
+	}
+	m := &addressMatcher{}
+	if len(nibbles) > 2 {
+		m.nibbles = nibbles[2:]
+	}
+	reachable := false
+	for b := 0; b < 256; b++ {
+		if len(nibbles) > 0 && byte(b>>4) != nibbles[0] {
+			continue
+		}
+		if len(nibbles) > 1 && byte(b&0x0f) != nibbles[1] {
+			continue
+		}
+		var probe common.AddressBytes
+		probe[0] = byte(b)
+		if topology.ContainsAddress(location, common.Bytes20ToAddress(probe)) {
+			m.first[b], reachable = true, true
+		}
+	}
+	if !reachable {
+		return nil, ErrGrindPrefix
+	}
+	return m, nil
+}
+
+// match reports whether a raw address satisfies the matcher.
+func (m *addressMatcher) match(addr []byte) bool {
+	if !m.first[addr[0]] {
+		return false
+	}
+	for i, n := range m.nibbles {
+		b := addr[1+i/2]
+		if i%2 == 0 {
+			b >>= 4
+		}
+		if b&0x0f != n {
+			return false
+		}
+	}
+	return true
+}
+
+// Grind generates keys on multiple threads until one is found whose address
+// belongs to the requested zone and starts with the requested prefix. Each
+// additional prefix nibble makes the search 16 times longer, so callers should
+// pass a context which can be cancelled.
+func Grind(ctx context.Context, config GrindConfig) (*GrindResult, error) {
+	matcher, err := newAddressMatcher(config.Location, config.Topology, config.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	threads := config.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		attempts uint64
+		once     sync.Once
+		result   *GrindResult
+		failure  error
+		pend     sync.WaitGroup
+	)
+	for i := 0; i < threads; i++ {
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				key, err := crypto.GenerateKey()
+				if err != nil {
+					once.Do(func() { failure = err })
+					cancel()
+					return
+				}
+				n := atomic.AddUint64(&attempts, 1)
+				addr := crypto.PubkeyToAddress(key.PublicKey)
+				if !matcher.match(addr.Bytes()) {
+					continue
+				}
+				once.Do(func() { result = &GrindResult{Key: key, Address: addr, Attempts: n} })
+				cancel()
+				return
+			}
+		}()
+	}
+	pend.Wait()
+
+	switch {
+	case result != nil:
+		return result, nil
+	case failure != nil:
+		return nil, failure
+	default:
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,88 @@
+package accounts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+)
+
+func TestGrind(t *testing.T) {
+	tests := []struct {
+		location common.Location
+		prefix   string
+	}{
+		{common.Location{0, 0}, ""},
+		{common.Location{1, 2}, ""},
+		{common.Location{2, 2}, "0xff"},
+		{common.Location{0, 1}, "2a"},
+		{common.Location{1, 0}, "0x6"},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		res, err := Grind(ctx, GrindConfig{Location: tt.location, Prefix: tt.prefix, Threads: 2})
+		cancel()
+		if err != nil {
+			t.Fatalf("zone %s, prefix %q: grind failed: %v", tt.location.Name(), tt.prefix, err)
+		}
+		if !common.DefaultTopology.ContainsAddress(tt.location, res.Address) {
+			t.Errorf("zone %s: address %x outside of zone", tt.location.Name(), res.Address.Bytes())
+		}
+		if !crypto.PubkeyToAddress(res.Key.PublicKey).Equal(res.Address) {
+			t.Errorf("zone %s: key doesn't match address %x", tt.location.Name(), res.Address.Bytes())
+		}
+		if hex := res.Address.Hex(); !matchesPrefix(hex, tt.prefix) {
+			t.Errorf("zone %s: address %s doesn't start with %s", tt.location.Name(), hex, tt.prefix)
+		}
+		if res.Attempts == 0 {
+			t.Errorf("zone %s: no attempts reported", tt.location.Name())
+		}
+	}
+}
+
+func TestGrindInvalid(t *testing.T) {
+	tests := []struct {
+		location common.Location
+		prefix   string
+		err      error
+	}{
+		{common.Location{}, "", ErrGrindLocation},
+		{common.Location{1}, "", ErrGrindLocation},
+		{common.Location{0, 0}, "ff", ErrGrindPrefix}, // cyprus1 owns 0x00-0x1d
+		{common.Location{2, 2}, "0x0", ErrGrindPrefix},
+	}
+	for _, tt := range tests {
+		if _, err := Grind(context.Background(), GrindConfig{Location: tt.location, Prefix: tt.prefix}); err != tt.err {
+			t.Errorf("location %v, prefix %q: error mismatch: have %v, want %v", tt.location, tt.prefix, err, tt.err)
+		}
+	}
+	if _, err := Grind(context.Background(), GrindConfig{Location: common.Location{0, 0}, Prefix: "0xzz"}); err == nil {
+		t.Errorf("invalid hex prefix accepted")
+	}
+}
+
+func TestGrindCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A 40 nibble prefix will never be found, so only cancellation can end it
+	prefix := "0x0000000000000000000000000000000000000001"
+	if _, err := Grind(ctx, GrindConfig{Location: common.Location{0, 0}, Prefix: prefix}); err != context.Canceled {
+		t.Fatalf("error mismatch: have %v, want %v", err, context.Canceled)
+	}
+}
+
+func matchesPrefix(hex, prefix string) bool {
+	if len(prefix) >= 2 && prefix[:2] == "0x" {
+		prefix = prefix[2:]
+	}
+	hex = hex[2:]
+	for i := range prefix {
+		if hex[i]|0x20 != prefix[i]|0x20 {
+			return false
+		}
+	}
+	return true
+}
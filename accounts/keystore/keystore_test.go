@@ -0,0 +1,258 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/accounts"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+)
+
+var testSigData = make([]byte, 32)
+
+func TestKeyStore(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	a, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(a.URL.Path, dir) {
+		t.Errorf("account file %s doesn't have dir prefix", a.URL)
+	}
+	stat, err := os.Stat(a.URL.Path)
+	if err != nil {
+		t.Fatalf("account file %s doesn't exist (%v)", a.URL, err)
+	}
+	if stat.Mode()&0077 != 0 {
+		t.Fatalf("account file has wrong mode: got %o, want %o", stat.Mode(), 0600)
+	}
+	if !ks.HasAddress(a.Address) {
+		t.Errorf("HasAccount(%x) should've returned true", a.Address.Bytes())
+	}
+	if err := ks.Update(a, "foo", "bar"); err != nil {
+		t.Errorf("Update error: %v", err)
+	}
+	if err := ks.Delete(a, "foo"); err == nil {
+		t.Errorf("Delete succeeded with wrong passphrase")
+	}
+	if err := ks.Delete(a, "bar"); err != nil {
+		t.Errorf("Delete error: %v", err)
+	}
+	if common.FileExist(a.URL.Path) {
+		t.Errorf("account file %s should be gone after Delete", a.URL)
+	}
+	if ks.HasAddress(a.Address) {
+		t.Errorf("HasAccount(%x) should've returned false after Delete", a.Address.Bytes())
+	}
+}
+
+func TestSign(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	pass := "" // not used but required by API
+	a1, err := ks.NewAccount(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.SignHash(accounts.Account{Address: a1.Address}, testSigData); err != ErrLocked {
+		t.Fatalf("signing with a locked key: have %v, want %v", err, ErrLocked)
+	}
+	if err := ks.Unlock(a1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.SignHash(accounts.Account{Address: a1.Address}, testSigData); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignWithPassphrase(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	pass := "passwd"
+	acc, err := ks.NewAccount(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, unlocked := ks.unlocked[acc.Address.Bytes20()]; unlocked {
+		t.Fatal("expected account to be locked")
+	}
+	sig, err := ks.SignHashWithPassphrase(acc, pass, testSigData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, unlocked := ks.unlocked[acc.Address.Bytes20()]; unlocked {
+		t.Fatal("expected account to be locked")
+	}
+	pub, err := crypto.SigToPub(testSigData, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crypto.PubkeyToAddress(*pub).Equal(acc.Address) {
+		t.Fatalf("signature recovered wrong address")
+	}
+	if _, err = ks.SignHashWithPassphrase(acc, "invalid passwd", testSigData); err == nil {
+		t.Fatal("expected SignHashWithPassphrase to fail with invalid password")
+	}
+}
+
+func TestTimedUnlock(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	pass := "foo"
+	a1, err := ks.NewAccount(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signing without passphrase fails because account is locked
+	if _, err = ks.SignHash(accounts.Account{Address: a1.Address}, testSigData); err != ErrLocked {
+		t.Fatal("Signing should've failed with ErrLocked before unlocking, got ", err)
+	}
+	// Signing with passphrase works
+	if err = ks.TimedUnlock(a1, pass, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// Signing without passphrase works because account is temp unlocked
+	if _, err = ks.SignHash(accounts.Account{Address: a1.Address}, testSigData); err != nil {
+		t.Fatal("Signing shouldn't return an error after unlocking, got ", err)
+	}
+	// Signing fails again after automatic locking
+	time.Sleep(250 * time.Millisecond)
+	if _, err = ks.SignHash(accounts.Account{Address: a1.Address}, testSigData); err != ErrLocked {
+		t.Fatal("Signing should've failed with ErrLocked timeout expired, got ", err)
+	}
+}
+
+func TestImportECDSA(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", key)
+	}
+	if _, err = ks.ImportECDSA(key, "old"); err != nil {
+		t.Errorf("importing failed: %v", err)
+	}
+	if _, err = ks.ImportECDSA(key, "old"); err == nil {
+		t.Errorf("importing same key twice succeeded")
+	}
+	if _, err = ks.ImportECDSA(key, "new"); err == nil {
+		t.Errorf("importing same key twice succeeded")
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	acc, err := ks.NewAccount("old")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", acc)
+	}
+	json, err := ks.Export(acc, "old", "new")
+	if err != nil {
+		t.Fatalf("failed to export account: %v", acc)
+	}
+	dir2, ks2 := tmpKeyStore(t)
+	defer os.RemoveAll(dir2)
+	if _, err = ks2.Import(json, "old", "old"); err == nil {
+		t.Errorf("importing with invalid password succeeded")
+	}
+	acc2, err := ks2.Import(json, "new", "new")
+	if err != nil {
+		t.Errorf("importing failed: %v", err)
+	}
+	if !acc.Address.Equal(acc2.Address) {
+		t.Error("imported account does not match exported account")
+	}
+	if _, err = ks2.Import(json, "new", "new"); err == nil {
+		t.Errorf("importing a key twice succeeded")
+	}
+}
+
+// Tests that the key files shipped in testdata are picked up, and the broken
+// ones next to them are skipped.
+func TestKeyStoreTestdata(t *testing.T) {
+	keydir, err := filepath.Abs(filepath.Join("testdata", "keystore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := NewKeyStore(keydir, LightScryptN, LightScryptP)
+
+	want := []accounts.Account{
+		{
+			Address: common.HexToAddress("7ef5a6135f1fd6a02593eedc869c6d41d934aef8"),
+			URL:     accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(keydir, "UTC--2016-03-22T12-57-55.920751759Z--7ef5a6135f1fd6a02593eedc869c6d41d934aef8")},
+		},
+		{
+			Address: common.HexToAddress("f466859ead1932d743d622cb74fc058882e8648a"),
+			URL:     accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(keydir, "aaa")},
+		},
+		{
+			Address: common.HexToAddress("289d485d9771714cce91d3393d764e1311907acc"),
+			URL:     accounts.URL{Scheme: KeyStoreScheme, Path: filepath.Join(keydir, "zzz")},
+		},
+	}
+	have := ks.Accounts()
+	if len(have) != len(want) {
+		t.Fatalf("account count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if !sameAccount(have[i], want[i]) {
+			t.Errorf("account %d mismatch: have %x %s, want %x %s", i, have[i].Address.Bytes(), have[i].URL, want[i].Address.Bytes(), want[i].URL)
+		}
+	}
+	for _, a := range want {
+		if err := ks.Unlock(a, "foobar"); err != nil {
+			t.Errorf("failed to unlock %s: %v", a.URL, err)
+		}
+	}
+}
+
+func TestKeyStoreAmbiguous(t *testing.T) {
+	ks := NewKeyStore(filepath.Join("testdata", "dupes"), LightScryptN, LightScryptP)
+
+	_, err := ks.Find(accounts.Account{Address: common.HexToAddress("f466859ead1932d743d622cb74fc058882e8648a")})
+	if aerr, ok := err.(*AmbiguousAddrError); !ok || len(aerr.Matches) != 2 {
+		t.Fatalf("expected ambiguous address error with two matches, got %v", err)
+	}
+}
+
+func tmpKeyStore(t *testing.T) (string, *KeyStore) {
+	d, err := ioutil.TempDir("", "quai-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, NewKeyStore(d, veryLightScryptN, veryLightScryptP)
+}
+
+const (
+	veryLightScryptN = 2
+	veryLightScryptP = 1
+)
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dominant-strategies/go-quai/cmd/utils"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/dominant-strategies/go-quai/eth/abi/bind"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	abigenABIFlag = cli.StringFlag{
+		Name:  "abi",
+		Usage: "Path to the contract ABI json to bind, - for STDIN",
+	}
+	abigenBinFlag = cli.StringFlag{
+		Name:  "bin",
+		Usage: "Path to the contract bytecode (generate deploy method)",
+	}
+	abigenTypeFlag = cli.StringFlag{
+		Name:  "type",
+		Usage: "Struct name for the binding (default = package name)",
+	}
+	abigenCombinedJSONFlag = cli.StringFlag{
+		Name:  "combined-json",
+		Usage: "Path to the combined-json file generated by solc (--combined-json abi,bin,hashes), - for STDIN",
+	}
+	abigenExcFlag = cli.StringFlag{
+		Name:  "exc",
+		Usage: "Comma separated types to exclude from binding",
+	}
+	abigenPkgFlag = cli.StringFlag{
+		Name:  "pkg",
+		Usage: "Package name to generate the binding into",
+	}
+	abigenOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "Output file for the generated binding (default = stdout)",
+	}
+	abigenAliasFlag = cli.StringFlag{
+		Name:  "alias",
+		Usage: "Comma separated aliases for function and event renaming, e.g. original1=alias1, original2=alias2",
+	}
+
+	abigenCommand = cli.Command{
+		Action: utils.MigrateFlags(abigen),
+		Name:   "abigen",
+		Usage:  "Generate Go bindings for Quai contracts",
+		Flags: []cli.Flag{
+			abigenABIFlag,
+			abigenBinFlag,
+			abigenTypeFlag,
+			abigenCombinedJSONFlag,
+			abigenExcFlag,
+			abigenPkgFlag,
+			abigenOutFlag,
+			abigenAliasFlag,
+		},
+		Category: "DEVELOPER COMMANDS",
+		Description: `
+The abigen command generates typed Go bindings from a contract ABI, or from the
+combined-json output of solc. The bindings target the quaiclient/ethclient
+backend and, besides the regular call and transact methods, contain External
+variants of every paid method which send the call from another zone as an
+external transaction with the given ETX gas parameters.`,
+	}
+)
+
+// solcContract is the subset of a contract in the solc combined-json output
+// the binding generator needs.
+type solcContract struct {
+	Abi    interface{}       `json:"abi"` // Older solc versions encode the ABI as a string
+	Bin    string            `json:"bin"`
+	Hashes map[string]string `json:"hashes"`
+}
+
+// abigen generates the Go bindings of the contracts given on the command line.
+func abigen(c *cli.Context) error {
+	if c.String(abigenPkgFlag.Name) == "" {
+		utils.Fatalf("No destination package specified (--pkg)")
+	}
+	if c.String(abigenABIFlag.Name) == "" && c.String(abigenCombinedJSONFlag.Name) == "" {
+		utils.Fatalf("Either contract ABI source (--abi) or combined-json (--combined-json) are required")
+	}
+	var (
+		abis    []string
+		bins    []string
+		types   []string
+		sigs    []map[string]string
+		libs    = make(map[string]string)
+		aliases = make(map[string]string)
+	)
+	if input := c.String(abigenABIFlag.Name); input != "" {
+		// Load up the ABI and optional bytecode of a single contract
+		abi, err := readInput(input)
+		if err != nil {
+			utils.Fatalf("Failed to read input ABI: %v", err)
+		}
+		abis = append(abis, string(abi))
+
+		var bin []byte
+		if binFile := c.String(abigenBinFlag.Name); binFile != "" {
+			if bin, err = ioutil.ReadFile(binFile); err != nil {
+				utils.Fatalf("Failed to read input bytecode: %v", err)
+			}
+			if strings.Contains(string(bin), "//") {
+				utils.Fatalf("Contract has additional library references, please use --combined-json instead")
+			}
+		}
+		bins = append(bins, strings.TrimSpace(string(bin)))
+
+		kind := c.String(abigenTypeFlag.Name)
+		if kind == "" {
+			kind = c.String(abigenPkgFlag.Name)
+		}
+		types = append(types, kind)
+	} else {
+		// Generate the list of types to exclude from binding
+		exclude := make(map[string]bool)
+		for _, kind := range strings.Split(c.String(abigenExcFlag.Name), ",") {
+			exclude[strings.ToLower(kind)] = true
+		}
+		input, err := readInput(c.String(abigenCombinedJSONFlag.Name))
+		if err != nil {
+			utils.Fatalf("Failed to read combined-json: %v", err)
+		}
+		var output struct {
+			Contracts map[string]*solcContract `json:"contracts"`
+		}
+		if err := json.Unmarshal(input, &output); err != nil {
+			utils.Fatalf("Failed to parse combined-json: %v", err)
+		}
+		names := make([]string, 0, len(output.Contracts))
+		for name := range output.Contracts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			contract := output.Contracts[name]
+			nameParts := strings.Split(name, ":")
+			typeName := nameParts[len(nameParts)-1]
+			if exclude[strings.ToLower(typeName)] {
+				continue
+			}
+			abi, err := contractABI(contract.Abi)
+			if err != nil {
+				utils.Fatalf("Failed to parse ABI of %s: %v", name, err)
+			}
+			abis = append(abis, abi)
+			bins = append(bins, contract.Bin)
+			types = append(types, typeName)
+
+			// Function signatures are keyed by the 4-byte selector in the bindings
+			fsigs := make(map[string]string)
+			for sig, id := range contract.Hashes {
+				fsigs[id] = sig
+			}
+			sigs = append(sigs, fsigs)
+
+			// Library references are replaced by the hash of their fully qualified name
+			libPattern := crypto.Keccak256Hash([]byte(name)).String()[2:36]
+			libs[libPattern] = typeName
+		}
+	}
+	// Extract all aliases from the flags
+	if aliasFlag := c.String(abigenAliasFlag.Name); aliasFlag != "" {
+		re := regexp.MustCompile(`(?:(\w+)\s*=\s*(\w+))`)
+		for _, match := range re.FindAllStringSubmatch(aliasFlag, -1) {
+			aliases[match[1]] = match[2]
+		}
+	}
+	// Generate the contract binding
+	code, err := bind.Bind(types, abis, bins, sigs, c.String(abigenPkgFlag.Name), libs, aliases)
+	if err != nil {
+		utils.Fatalf("Failed to generate ABI binding: %v", err)
+	}
+	// Either flush it out to a file or display on the standard output
+	out := c.String(abigenOutFlag.Name)
+	if out == "" {
+		fmt.Printf("%s\n", code)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		utils.Fatalf("Failed to create output directory: %v", err)
+	}
+	if err := ioutil.WriteFile(out, []byte(code), 0600); err != nil {
+		utils.Fatalf("Failed to write ABI binding: %v", err)
+	}
+	return nil
+}
+
+// readInput reads the file at path, or the standard input if path is "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// contractABI returns the JSON encoding of a contract ABI, which solc either
+// embeds as a string or as a JSON array depending on its version.
+func contractABI(abi interface{}) (string, error) {
+	if s, ok := abi.(string); ok {
+		return s, nil
+	}
+	blob, err := json.Marshal(abi)
+	if err != nil {
+		return "", err
+	}
+	return string(blob), nil
+}
@@ -88,6 +88,32 @@ Path of the secret key file: .*UTC--.+--[0-9a-f]{40}
 `)
 }
 
+func TestAccountGrind(t *testing.T) {
+	quai := runQuai(t, "account", "grind", "--lightkdf", "--zone", "1-2")
+	defer quai.ExpectExit()
+	quai.Expect(`
+Your new account is locked with a password. Please give a password. Do not forget this password.
+!! Unsupported terminal, password will be echoed.
+Password: {{.InputLine "foobar"}}
+Repeat password: {{.InputLine "foobar"}}
+
+Your new key was generated
+`)
+	quai.ExpectRegexp(`
+Public address of the key:   0x[0-9a-fA-F]{40}
+Zone of the address:         paxos3
+Path of the secret key file: .*UTC--.+--[0-9a-f]{40}
+`)
+}
+
+func TestAccountGrindBadPrefix(t *testing.T) {
+	quai := runQuai(t, "account", "grind", "--zone", "0-0", "--prefix", "ff")
+	defer quai.ExpectExit()
+	quai.Expect(`
+Fatal: Failed to grind address: address prefix is outside of the zone's address space
+`)
+}
+
 func TestAccountImport(t *testing.T) {
 	tests := []struct{ name, key, output string }{
 		{
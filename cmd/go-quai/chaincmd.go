@@ -124,6 +124,50 @@ Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
 be gzipped.`,
+	}
+	importArchiveCommand = cli.Command{
+		Action:    utils.MigrateFlags(importArchive),
+		Name:      "import-archive",
+		Usage:     "Replay the chain from hierarchy archive files",
+		ArgsUsage: "<filename> (<filename 2> ... <filename N>) ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			utils.GCModeFlag,
+			utils.SnapshotFlag,
+			utils.CacheDatabaseFlag,
+			utils.CacheGCFlag,
+			utils.TxLookupLimitFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The import-archive command replays the chain of the node from hierarchy archives
+written by export-archive. Besides the sections of the node's own chain, the
+sections of its dom chains have to be given, as they carry the ETXs handed down
+with every coincident block. Each chain of a hierarchy has to be imported by its
+own node, starting with prime. The dom and sub chains are not dialed during the
+import.`,
+	}
+	exportArchiveCommand = cli.Command{
+		Action:    utils.MigrateFlags(exportArchive),
+		Name:      "export-archive",
+		Usage:     "Export the chain into a hierarchy archive file",
+		ArgsUsage: "<filename> [<blockNumFirst> <blockNumLast>]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Requires a first argument of the file to write to. Optional second and
+third arguments control the first and last block to write, the whole
+canonical chain is written otherwise. Along with the blocks, the archive
+holds the termini, manifests, pending ETXs and ETX set checkpoints needed
+to replay them. The file is always appended to, so the archives of every
+chain in a hierarchy can be collected into one file. If the file ends
+with .gz, the output will be gzipped.`,
 	}
 	importPreimagesCommand = cli.Command{
 		Action:    utils.MigrateFlags(importPreimages),
@@ -334,6 +378,55 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
+// importArchive replays the chain from the specified hierarchy archives.
+func importArchive(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeDetachedChain(ctx, stack)
+	defer db.Close()
+
+	start := time.Now()
+	err := utils.ImportArchive(chain, ctx.Args()...)
+	chain.Stop()
+	if err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	head := chain.CurrentBlock()
+	fmt.Printf("Import done in %v, head #%d [%x]\n", time.Since(start), head.NumberU64(chain.NodeCtx()), head.Hash())
+	return nil
+}
+
+// exportArchive exports the chain into the specified hierarchy archive.
+func exportArchive(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeDetachedChain(ctx, stack)
+	start := time.Now()
+
+	first, last := uint64(0), chain.CurrentBlock().NumberU64(chain.NodeCtx())
+	if len(ctx.Args()) >= 3 {
+		var ferr, lerr error
+		first, ferr = strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		last, lerr = strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+		if ferr != nil || lerr != nil {
+			utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+		}
+	}
+	if err := utils.ExportArchive(chain, ctx.Args().First(), first, last); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
 // importPreimages imports preimage data from the specified file.
 func importPreimages(ctx *cli.Context) error {
 	if len(ctx.Args()) < 1 {
@@ -424,7 +517,7 @@ func parseDumpConfig(ctx *cli.Context, stack *node.Node) (*state.DumpConfig, eth
 		Start:             start.Bytes(),
 		Max:               ctx.Uint64(utils.DumpLimitFlag.Name),
 	}
-	log.Info("State dump configured", "block", header.Number(), "hash", header.Hash().Hex(),
+	log.Info("State dump configured", "block", header.Number(common.ZONE_CTX), "hash", header.Hash().Hex(),
 		"skipcode", conf.SkipCode, "skipstorage", conf.SkipStorage,
 		"start", hexutil.Encode(conf.Start), "limit", conf.Max)
 	return conf, db, header.Root(), nil
@@ -28,13 +28,15 @@ import (
 
 	"github.com/dominant-strategies/go-quai/cmd/utils"
 	"github.com/dominant-strategies/go-quai/common"
-	"github.com/dominant-strategies/go-quai/core/vm"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/eth"
 	"github.com/dominant-strategies/go-quai/eth/ethconfig"
 	"github.com/dominant-strategies/go-quai/internal/quaiapi"
 	"github.com/dominant-strategies/go-quai/log"
 	"github.com/dominant-strategies/go-quai/metrics"
 	"github.com/dominant-strategies/go-quai/node"
 	"github.com/dominant-strategies/go-quai/params"
+	"github.com/dominant-strategies/go-quai/stratum"
 	"github.com/naoina/toml"
 )
 
@@ -85,6 +87,7 @@ type quaiConfig struct {
 	Eth      ethconfig.Config
 	Node     node.Config
 	Ethstats quaistatsConfig
+	Stratum  stratum.Config
 	Metrics  metrics.Config
 }
 
@@ -107,6 +110,7 @@ func defaultNodeConfig() node.Config {
 	cfg := node.DefaultConfig
 	cfg.Name = clientIdentifier
 	cfg.Version = params.VersionWithCommit(gitCommit, gitDate)
+	cfg.IPCPath = clientIdentifier + ".ipc"
 	cfg.HTTPModules = append(cfg.HTTPModules, "eth")
 	cfg.WSModules = append(cfg.WSModules, "eth")
 	return cfg
@@ -114,11 +118,17 @@ func defaultNodeConfig() node.Config {
 
 // makeConfigNode loads quai configuration and creates a blank node instance.
 func makeConfigNode(ctx *cli.Context) (*node.Node, quaiConfig) {
-	nodeCtx := common.NodeLocation.Context()
+	return makeSliceConfigNode(ctx, utils.NodeLocation(ctx))
+}
+
+// makeSliceConfigNode loads quai configuration and creates a blank node
+// instance for the chain at the given location.
+func makeSliceConfigNode(ctx *cli.Context, location common.Location) (*node.Node, quaiConfig) {
 	// Load defaults.
 	cfg := quaiConfig{
 		Eth:     ethconfig.Defaults,
 		Node:    defaultNodeConfig(),
+		Stratum: stratum.DefaultConfig,
 		Metrics: metrics.DefaultConfig,
 	}
 
@@ -130,34 +140,81 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, quaiConfig) {
 	}
 
 	// Apply flags.
-	utils.SetGlobalVars(ctx)
-	utils.SetNodeConfig(ctx, &cfg.Node)
+	utils.SetSliceNodeConfig(ctx, &cfg.Node, location)
 	stack, err := node.New(&cfg.Node)
 	if err != nil {
 		utils.Fatalf("Failed to create the protocol stack: %v", err)
 	}
-	utils.SetEthConfig(ctx, stack, &cfg.Eth)
+	utils.SetSliceEthConfig(ctx, stack, &cfg.Eth, location)
 	if ctx.GlobalIsSet(utils.QuaiStatsURLFlag.Name) {
 		cfg.Ethstats.URL = ctx.GlobalString(utils.QuaiStatsURLFlag.Name)
 	}
+	utils.SetSliceStratumConfig(ctx, &cfg.Stratum, location)
 	applyMetricConfig(ctx, &cfg)
-	// Onlt initialize the precompile for the zone chain
-	if nodeCtx == common.ZONE_CTX {
-		vm.InitializePrecompiles()
-	}
 	return stack, cfg
 }
 
 // makeFullNode loads quai configuration and creates the Quai backend.
 func makeFullNode(ctx *cli.Context) (*node.Node, quaiapi.Backend) {
-	stack, cfg := makeConfigNode(ctx)
-	backend, _ := utils.RegisterEthService(stack, &cfg.Eth)
+	stack, backend, _ := makeSliceNode(ctx, utils.NodeLocation(ctx))
+	return stack, backend
+}
+
+// makeSliceNode loads quai configuration and creates the Quai backend of the
+// chain at the given location.
+func makeSliceNode(ctx *cli.Context, location common.Location) (*node.Node, quaiapi.Backend, *eth.Quai) {
+	stack, cfg := makeSliceConfigNode(ctx, location)
+	backend, service := utils.RegisterEthService(stack, &cfg.Eth)
 
 	// Add the Quai Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterQuaiStatsService(stack, backend, cfg.Ethstats.URL)
 	}
-	return stack, backend
+	// Add the Stratum mining server if requested.
+	if cfg.Stratum.Enabled {
+		utils.RegisterStratumService(stack, backend, cfg.Stratum)
+	}
+	return stack, backend, service
+}
+
+// makeFullNodes creates a node for every chain hosted by this process. When
+// the slices run in process, the cores of the chains are linked to their dom
+// and subordinates in memory.
+func makeFullNodes(ctx *cli.Context) ([]*node.Node, []quaiapi.Backend) {
+	locations := utils.SliceLocations(ctx)
+	stacks := make([]*node.Node, len(locations))
+	backends := make([]quaiapi.Backend, len(locations))
+	cores := make(map[string]*core.Core, len(locations))
+	for i, location := range locations {
+		var service *eth.Quai
+		stacks[i], backends[i], service = makeSliceNode(ctx, location)
+		cores[string(location)] = service.Core()
+	}
+	if !ctx.GlobalBool(utils.SlicesInProcessFlag.Name) {
+		return stacks, backends
+	}
+	// Link every chain to its dom, and the dom to the chain as its subordinate
+	for _, location := range locations {
+		if location.Context() == common.PRIME_CTX {
+			continue
+		}
+		sub, dom := cores[string(location)], cores[string(location.DomLocation())]
+		sub.SetDomClient(core.NewLocalClient(dom))
+		dom.SetSubClient(location.SubIndex(dom.NodeCtx()), core.NewLocalClient(sub))
+	}
+	// The startup repair of a chain could not check its head against the other
+	// chains before they were linked. Do it now, from prime down, so that each
+	// chain is checked against a repaired dom.
+	for _, location := range locations {
+		cores[string(location)].RepairHead()
+	}
+	// A fresh prime hands the genesis pending header down before its
+	// subordinates are linked, hand it down again now that they are
+	prime := cores[string(common.Location{})]
+	if prime.CurrentHeader().Hash() == prime.Config().GenesisHash {
+		prime.NewGenesisPendigHeader(nil)
+	}
+	return stacks, backends
 }
 
 // dumpConfig is the dumpconfig command.
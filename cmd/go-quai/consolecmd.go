@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+
+	"github.com/dominant-strategies/go-quai/cmd/utils"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/console"
+	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/node"
+	"github.com/dominant-strategies/go-quai/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	consoleFlags = []cli.Flag{utils.JSpathFlag, utils.ExecFlag, utils.PreloadJSFlag}
+
+	consoleCommand = cli.Command{
+		Action:   utils.MigrateFlags(localConsole),
+		Name:     "console",
+		Usage:    "Start an interactive JavaScript environment",
+		Flags:    append(append(nodeFlags, rpcFlags...), consoleFlags...),
+		Category: "CONSOLE COMMANDS",
+		Description: `
+The Quai console is an interactive shell for the JavaScript runtime environment
+which exposes a node admin interface as well as the Ðapp JavaScript API.
+The console starts a node for the chain at --region and --zone, and attaches
+to it in process.`,
+	}
+
+	attachCommand = cli.Command{
+		Action:    utils.MigrateFlags(remoteConsole),
+		Name:      "attach",
+		Usage:     "Start an interactive JavaScript environment (connect to node)",
+		ArgsUsage: "[endpoint]",
+		Flags: append(consoleFlags,
+			utils.DataDirFlag,
+			utils.RegionFlag,
+			utils.ZoneFlag,
+			utils.JWTSecretFlag,
+		),
+		Category: "CONSOLE COMMANDS",
+		Description: `
+The Quai console is an interactive shell for the JavaScript runtime environment
+which exposes a node admin interface as well as the Ðapp JavaScript API.
+This command allows to open a console on a running quai node.
+
+The endpoint is an IPC path, or an HTTP or WebSocket URL. It defaults to the
+IPC endpoint of the chain at --region and --zone inside --datadir. The
+authenticated endpoint of a node is reached with its --authrpc.jwtsecret.`,
+	}
+
+	javascriptCommand = cli.Command{
+		Action:    utils.MigrateFlags(ephemeralConsole),
+		Name:      "js",
+		Usage:     "Execute the specified JavaScript files",
+		ArgsUsage: "<jsfile> [jsfile...]",
+		Flags:     append(nodeFlags, consoleFlags...),
+		Category:  "CONSOLE COMMANDS",
+		Description: `
+The JavaScript VM exposes a node admin interface as well as the Ðapp
+JavaScript API.`,
+	}
+)
+
+// localConsole starts a new quai node, attaching a JavaScript console to it at the
+// same time.
+func localConsole(ctx *cli.Context) error {
+	// Create and start the node based on the CLI flags
+	log.ConfigureLogger(ctx)
+	prepare(ctx)
+	stack, backend := makeFullNode(ctx)
+	startNode(ctx, stack, backend)
+	defer stack.Close()
+
+	// Attach to the newly started node and start the JavaScript console
+	client, err := stack.Attach()
+	if err != nil {
+		utils.Fatalf("Failed to attach to the inproc quai: %v", err)
+	}
+	config := console.Config{
+		DataDir: utils.MakeDataDir(ctx),
+		DocRoot: ctx.GlobalString(utils.JSpathFlag.Name),
+		Client:  client,
+		Preload: utils.MakeConsolePreloads(ctx),
+	}
+
+	console, err := console.New(config)
+	if err != nil {
+		utils.Fatalf("Failed to start the JavaScript console: %v", err)
+	}
+	defer console.Stop(false)
+
+	// If only a short execution was requested, evaluate and return
+	if script := ctx.GlobalString(utils.ExecFlag.Name); script != "" {
+		console.Evaluate(script)
+		return nil
+	}
+	// Otherwise print the welcome screen and enter interactive mode
+	console.Welcome()
+	console.Interactive()
+
+	return nil
+}
+
+// remoteConsole will connect to a remote quai instance, attaching a JavaScript
+// console to it.
+func remoteConsole(ctx *cli.Context) error {
+	endpoint := ctx.Args().First()
+	if endpoint == "" {
+		cfg := &node.Config{DataDir: utils.MakeDataDir(ctx), IPCPath: clientIdentifier + ".ipc"}
+		endpoint = cfg.IPCEndpoint()
+	}
+	var secret []byte
+	if path := ctx.GlobalString(utils.JWTSecretFlag.Name); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			utils.Fatalf("Failed to read JWT secret: %v", err)
+		}
+		secret = common.FromHex(strings.TrimSpace(string(data)))
+	}
+	client, err := dialRPC(endpoint, secret)
+	if err != nil {
+		utils.Fatalf("Unable to attach to remote quai: %v", err)
+	}
+	config := console.Config{
+		DataDir: utils.MakeDataDir(ctx),
+		DocRoot: ctx.GlobalString(utils.JSpathFlag.Name),
+		Client:  client,
+		Preload: utils.MakeConsolePreloads(ctx),
+	}
+
+	console, err := console.New(config)
+	if err != nil {
+		utils.Fatalf("Failed to start the JavaScript console: %v", err)
+	}
+	defer console.Stop(false)
+
+	if script := ctx.GlobalString(utils.ExecFlag.Name); script != "" {
+		console.Evaluate(script)
+		return nil
+	}
+
+	// Otherwise print the welcome screen and enter interactive mode
+	console.Welcome()
+	console.Interactive()
+
+	return nil
+}
+
+// dialRPC returns a RPC client which connects to the given endpoint. The
+// endpoint is an IPC path or an HTTP or WebSocket URL. Given a secret, only the
+// latter can be dialed, authenticating every request with it.
+func dialRPC(endpoint string, jwtSecret []byte) (*rpc.Client, error) {
+	if strings.HasPrefix(endpoint, "ipc:") {
+		endpoint = endpoint[4:]
+	}
+	if jwtSecret != nil {
+		return rpc.DialContextWithAuth(context.Background(), endpoint, rpc.NewJWTAuth(jwtSecret))
+	}
+	return rpc.Dial(endpoint)
+}
+
+// ephemeralConsole starts a new quai node, attaches an ephemeral JavaScript
+// console to it, executes each of the files specified as arguments and tears
+// everything down.
+func ephemeralConsole(ctx *cli.Context) error {
+	// Create and start the node based on the CLI flags
+	log.ConfigureLogger(ctx)
+	stack, backend := makeFullNode(ctx)
+	startNode(ctx, stack, backend)
+	defer stack.Close()
+
+	// Attach to the newly started node and start the JavaScript console
+	client, err := stack.Attach()
+	if err != nil {
+		utils.Fatalf("Failed to attach to the inproc quai: %v", err)
+	}
+	config := console.Config{
+		DataDir: utils.MakeDataDir(ctx),
+		DocRoot: ctx.GlobalString(utils.JSpathFlag.Name),
+		Client:  client,
+		Preload: utils.MakeConsolePreloads(ctx),
+	}
+
+	console, err := console.New(config)
+	if err != nil {
+		utils.Fatalf("Failed to start the JavaScript console: %v", err)
+	}
+	defer console.Stop(false)
+
+	// Evaluate each of the specified JavaScript files
+	for _, file := range ctx.Args() {
+		if err = console.Execute(file); err != nil {
+			utils.Fatalf("Failed to execute %s: %v", file, err)
+		}
+	}
+
+	// Wait for pending callbacks, but stop for Ctrl-C.
+	go func() {
+		stack.Wait()
+		console.Stop(false)
+	}()
+	console.Stop(true)
+
+	return nil
+}
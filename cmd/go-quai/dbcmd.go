@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/cmd/utils"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/ethdb"
+	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbFlags = []cli.Flag{
+		utils.DataDirFlag,
+		utils.AncientFlag,
+		utils.ColosseumFlag,
+		utils.GardenFlag,
+		utils.OrchardFlag,
+		utils.LighthouseFlag,
+		utils.LocalFlag,
+	}
+	dbMigrateToFlag = cli.StringFlag{
+		Name:  "to",
+		Usage: "Database engine to migrate to ('leveldb' or 'pebble')",
+		Value: "pebble",
+	}
+
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Low level database operations",
+		ArgsUsage: "",
+		Category:  "DATABASE COMMANDS",
+		Subcommands: []cli.Command{
+			dbInspectCmd,
+			dbGetCmd,
+			dbDeleteCmd,
+			dbCompactCmd,
+			dbVerifyCmd,
+			dbMigrateCmd,
+		},
+	}
+	dbInspectCmd = cli.Command{
+		Action:    utils.MigrateFlags(inspect),
+		Name:      "inspect",
+		ArgsUsage: "<prefix> <start>",
+		Flags:     dbFlags,
+		Usage:     "Inspect the storage size for each type of data in the database",
+		Description: `
+The inspect command iterates over the entire database, or the keys with the given
+hex prefix starting from the given hex key, and reports the size taken by every
+type of data, including the hierarchy data of the chain.`,
+	}
+	dbGetCmd = cli.Command{
+		Action:      utils.MigrateFlags(dbGet),
+		Name:        "get",
+		Usage:       "Show the value of a database key",
+		ArgsUsage:   "<hex-encoded key>",
+		Flags:       dbFlags,
+		Description: "This command looks up the specified database key from the database.",
+	}
+	dbDeleteCmd = cli.Command{
+		Action:    utils.MigrateFlags(dbDelete),
+		Name:      "delete",
+		Usage:     "Delete a database key (WARNING: may corrupt your database)",
+		ArgsUsage: "<hex-encoded key>",
+		Flags:     dbFlags,
+		Description: `
+This command deletes the specified database key from the database.
+WARNING: This is a low-level operation which may cause database corruption!`,
+	}
+	dbCompactCmd = cli.Command{
+		Action: utils.MigrateFlags(dbCompact),
+		Name:   "compact",
+		Usage:  "Compact the key-value store (WARNING: may take a very long time)",
+		Flags: append([]cli.Flag{
+			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+		}, dbFlags...),
+		Description: `
+This command performs a database compaction of the leveldb or pebble store of the
+node, which must not be running.
+WARNING: This operation may take a very long time to finish, and may cause database
+corruption if it is aborted during execution!`,
+	}
+	dbVerifyCmd = cli.Command{
+		Action: utils.MigrateFlags(dbVerify),
+		Name:   "verify",
+		Usage:  "Verify the hierarchy data of the canonical chain",
+		Flags:  dbFlags,
+		Description: `
+The verify command walks the canonical chain of the node and checks that the
+termini, the manifest and the pending etxs and rollups stored for every block
+match its header. Every mismatch is reported, and the command fails if any is
+found.`,
+	}
+	dbMigrateCmd = cli.Command{
+		Action: utils.MigrateFlags(dbMigrate),
+		Name:   "migrate",
+		Usage:  "Migrate the key-value store to another database engine",
+		Flags: append([]cli.Flag{
+			dbMigrateToFlag,
+			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+		}, dbFlags...),
+		Description: `
+This command copies the key-value store of the node, which must not be running,
+into a new store of the engine given by --to. The copy is verified against the
+original with a checksum per key prefix, before the new store takes the place of
+the original one, which is kept in the chaindata.old directory. An ancient store
+inside the chaindata directory is moved into the new store.
+
+The migration can be interrupted and resumed by running the command again.`,
+	}
+)
+
+// inspect reports the size of every type of data in the database.
+func inspect(ctx *cli.Context) error {
+	var (
+		prefix []byte
+		start  []byte
+	)
+	if ctx.NArg() > 2 {
+		return fmt.Errorf("max 2 arguments: %v", ctx.Command.ArgsUsage)
+	}
+	if ctx.NArg() >= 1 {
+		d, err := hexutil.Decode(ctx.Args().Get(0))
+		if err != nil {
+			return fmt.Errorf("failed to hex-decode 'prefix': %v", err)
+		}
+		prefix = d
+	}
+	if ctx.NArg() >= 2 {
+		d, err := hexutil.Decode(ctx.Args().Get(1))
+		if err != nil {
+			return fmt.Errorf("failed to hex-decode 'start': %v", err)
+		}
+		start = d
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	return rawdb.InspectDatabase(db, prefix, start)
+}
+
+// parseHexOrString tries to hex-decode the key, falling back to its raw bytes
+// for the keys of the singletons, such as "LastBlock".
+func parseHexOrString(str string) ([]byte, error) {
+	b, err := hexutil.Decode(str)
+	if errors.Is(err, hexutil.ErrMissingPrefix) {
+		return []byte(str), nil
+	}
+	return b, err
+}
+
+// dbGet shows the value of a given database key.
+func dbGet(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	key, err := parseHexOrString(ctx.Args().Get(0))
+	if err != nil {
+		log.Info("Could not decode the key", "error", err)
+		return err
+	}
+	data, err := db.Get(key)
+	if err != nil {
+		log.Info("Get operation failed", "key", fmt.Sprintf("%#x", key), "error", err)
+		return err
+	}
+	fmt.Printf("key %#x: %#x\n", key, data)
+	return nil
+}
+
+// dbDelete deletes a given database key.
+func dbDelete(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	key, err := parseHexOrString(ctx.Args().Get(0))
+	if err != nil {
+		log.Info("Could not decode the key", "error", err)
+		return err
+	}
+	data, err := db.Get(key)
+	if err == nil {
+		fmt.Printf("Previous value: %#x\n", data)
+	}
+	if err = db.Delete(key); err != nil {
+		log.Info("Delete operation returned an error", "key", fmt.Sprintf("%#x", key), "error", err)
+		return err
+	}
+	return nil
+}
+
+// dbCompact compacts the whole key-value store.
+func dbCompact(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	log.Info("Triggering compaction")
+	start := time.Now()
+	if err := db.Compact(nil, nil); err != nil {
+		log.Info("Compact err", "error", err)
+		return err
+	}
+	log.Info("Compaction done", "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// dbMigrate moves the key-value store to another database engine.
+func dbMigrate(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	start := time.Now()
+	err := rawdb.MigrateDatabase(rawdb.MigrationOptions{
+		Directory:         stack.ResolvePath("chaindata"),
+		AncientsDirectory: stack.ResolveAncient("chaindata", ctx.GlobalString(utils.AncientFlag.Name)),
+		Type:              ctx.String(dbMigrateToFlag.Name),
+		Cache:             ctx.GlobalInt(utils.CacheFlag.Name) * ctx.GlobalInt(utils.CacheDatabaseFlag.Name) / 100,
+		Handles:           utils.MakeDatabaseHandles(),
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("Database migration done", "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// dbVerify checks the hierarchy data of the canonical chain against the headers.
+func dbVerify(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	config, err := storedChainConfig(db)
+	if err != nil {
+		return err
+	}
+	if err := config.GetTopology().Validate(); err != nil {
+		return err
+	}
+	var (
+		start  = time.Now()
+		faults int
+	)
+	checked, err := core.VerifyDatabase(db, config, func(fault core.DatabaseFault) error {
+		faults++
+		fmt.Println(fault)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("Verified hierarchy data", "location", config.Location.Name(), "blocks", checked, "faults", faults, "elapsed", common.PrettyDuration(time.Since(start)))
+	if faults > 0 {
+		return fmt.Errorf("found %d faults", faults)
+	}
+	return nil
+}
+
+// storedChainConfig returns the chain configuration the database was created
+// with.
+func storedChainConfig(db ethdb.Reader) (*params.ChainConfig, error) {
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return nil, errors.New("genesis block missing, database not initialized")
+	}
+	config := rawdb.ReadChainConfig(db, genesis)
+	if config == nil {
+		return nil, fmt.Errorf("chain config missing for genesis %x", genesis)
+	}
+	config.GenesisHash = genesis
+	return config, nil
+}
@@ -22,12 +22,17 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/dominant-strategies/go-quai/accounts"
+	"github.com/dominant-strategies/go-quai/accounts/keystore"
 	"github.com/dominant-strategies/go-quai/cmd/utils"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/eth"
 	"github.com/dominant-strategies/go-quai/eth/downloader"
+	// Force-load the native tracers, to trigger registration
+	_ "github.com/dominant-strategies/go-quai/eth/tracers/native"
 	"github.com/dominant-strategies/go-quai/internal/debug"
 	"github.com/dominant-strategies/go-quai/internal/flags"
 	"github.com/dominant-strategies/go-quai/internal/quaiapi"
@@ -52,6 +57,7 @@ var (
 	nodeFlags = []cli.Flag{
 		configFileFlag,
 		utils.AncientFlag,
+		utils.AncientDepthFlag,
 		utils.BloomFilterSizeFlag,
 		utils.BootnodesFlag,
 		utils.CacheDatabaseFlag,
@@ -107,8 +113,15 @@ var (
 		utils.QuaiStatsURLFlag,
 		utils.RegionFlag,
 		utils.ShowColorsFlag,
+		utils.SlicesInProcessFlag,
 		utils.SlicesRunningFlag,
 		utils.SnapshotFlag,
+		utils.StratumDifficultyFlag,
+		utils.StratumDomURLsFlag,
+		utils.StratumEnabledFlag,
+		utils.StratumListenAddrFlag,
+		utils.StratumMaxSessionsFlag,
+		utils.StratumPortFlag,
 		utils.SubUrls,
 		utils.SyncModeFlag,
 		utils.TxLookupLimitFlag,
@@ -131,6 +144,9 @@ var (
 	}
 
 	rpcFlags = []cli.Flag{
+		utils.AuthListenFlag,
+		utils.AuthPortFlag,
+		utils.AuthVirtualHostsFlag,
 		utils.HTTPApiFlag,
 		utils.HTTPCORSDomainFlag,
 		utils.HTTPEnabledFlag,
@@ -138,7 +154,10 @@ var (
 		utils.HTTPPathPrefixFlag,
 		utils.HTTPPortFlag,
 		utils.HTTPVirtualHostsFlag,
+		utils.IPCDisabledFlag,
+		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
+		utils.JWTSecretFlag,
 		utils.LegacyRPCApiFlag,
 		utils.LegacyRPCCORSDomainFlag,
 		utils.LegacyRPCEnabledFlag,
@@ -175,10 +194,15 @@ func init() {
 	app.HideVersion = true // we have a command to print the version
 	app.Copyright = "Copyright 2013-2021 The go-ethereum Authors"
 	app.Commands = []cli.Command{
+		// See accountcmd.go:
+		accountCommand,
+		walletCommand,
 		// See chaincmd.go:
 		initCommand,
 		importCommand,
 		exportCommand,
+		importArchiveCommand,
+		exportArchiveCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
 		dumpCommand,
@@ -187,10 +211,18 @@ func init() {
 		versionCommand,
 		versionCheckCommand,
 		licenseCommand,
+		// See consolecmd.go:
+		consoleCommand,
+		attachCommand,
+		javascriptCommand,
+		// See abigencmd.go:
+		abigenCommand,
 		// See config.go
 		dumpConfigCommand,
 		// See snapshot.go
 		snapshotCommand,
+		// See dbcmd.go
+		dbCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -271,11 +303,14 @@ func quai(ctx *cli.Context) error {
 	log.ConfigureLogger(ctx)
 
 	prepare(ctx)
-	stack, backend := makeFullNode(ctx)
-	defer stack.Close()
-
-	startNode(ctx, stack, backend)
-	stack.Wait()
+	stacks, backends := makeFullNodes(ctx)
+	for i, stack := range stacks {
+		defer stack.Close()
+		startNode(ctx, stack, backends[i])
+	}
+	for _, stack := range stacks {
+		stack.Wait()
+	}
 	return nil
 }
 
@@ -288,6 +323,9 @@ func startNode(ctx *cli.Context, stack *node.Node, backend quaiapi.Backend) {
 	// Start up the node itself
 	utils.StartNode(ctx, stack)
 
+	// Unlock any account specifically requested
+	unlockAccounts(ctx, stack, backend.ChainConfig().Location, backend.ChainConfig().GetTopology())
+
 	// Spawn a standalone goroutine for status synchronization monitoring,
 	// close the node when synchronization is complete if user required.
 	if ctx.GlobalBool(utils.ExitWhenSyncedFlag.Name) {
@@ -304,7 +342,7 @@ func startNode(ctx *cli.Context, stack *node.Node, backend quaiapi.Backend) {
 					continue
 				}
 				if timestamp := time.Unix(int64(done.Latest.Time()), 0); time.Since(timestamp) < 10*time.Minute {
-					log.Info("Synchronisation completed", "latestnum", done.Latest.Number(), "latesthash", done.Latest.Hash(),
+					log.Info("Synchronisation completed", "latestnum", done.Latest.Number(backend.ChainConfig().Location.Context()), "latesthash", done.Latest.Hash(),
 						"age", common.PrettyAge(timestamp))
 					stack.Close()
 				}
@@ -320,7 +358,7 @@ func startNode(ctx *cli.Context, stack *node.Node, backend quaiapi.Backend) {
 		if !ok {
 			utils.Fatalf("Quai service not running: %v", err)
 		}
-		nodeCtx := common.NodeLocation.Context()
+		nodeCtx := backend.ChainConfig().Location.Context()
 		if nodeCtx == common.ZONE_CTX {
 			// Set the gas price to the limits from the CLI and start mining
 			gasprice := utils.GlobalBig(ctx, utils.MinerGasPriceFlag.Name)
@@ -328,3 +366,34 @@ func startNode(ctx *cli.Context, stack *node.Node, backend quaiapi.Backend) {
 		}
 	}
 }
+
+// unlockAccounts unlocks any account specifically requested.
+func unlockAccounts(ctx *cli.Context, stack *node.Node, location common.Location, topology *common.Topology) {
+	var unlocks []string
+	inputs := strings.Split(ctx.GlobalString(utils.UnlockedAccountFlag.Name), ",")
+	for _, input := range inputs {
+		if trimmed := strings.TrimSpace(input); trimmed != "" {
+			unlocks = append(unlocks, trimmed)
+		}
+	}
+	// Short circuit if there is no account to unlock.
+	if len(unlocks) == 0 {
+		return
+	}
+	// If insecure account unlocking is not allowed if node's APIs are exposed to external.
+	// Print warning log to user and skip unlocking.
+	if !stack.Config().InsecureUnlockAllowed && stack.Config().ExtRPCEnabled() {
+		utils.Fatalf("Account unlock with HTTP access is forbidden!")
+	}
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	passwords := utils.MakePasswordList(ctx)
+	for i, account := range unlocks {
+		unlocked, _ := unlockAccount(ks, account, i, passwords)
+		// Keys outside of the zone stay unlocked, but can't sign anything here
+		if location.Context() == common.ZONE_CTX {
+			if err := accounts.ValidateLocation(unlocked, location, topology); err != nil {
+				log.Warn("Unlocked account cannot send transactions from this zone", "err", err)
+			}
+		}
+	}
+}
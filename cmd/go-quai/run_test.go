@@ -99,6 +99,16 @@ func runQuai(t *testing.T, args ...string) *testquai {
 	return tt
 }
 
+// runMinimalQuai spawns quai with the given command line args, on a private
+// network without peers, so that the node starts up as fast as possible.
+func runMinimalQuai(t *testing.T, args ...string) *testquai {
+	// --networkid=1337 to avoid cache bump
+	// --syncmode=full to avoid allocating fast sync bloom
+	allArgs := []string{"--networkid", "1337", "--syncmode=full", "--port", "0",
+		"--nat", "none", "--nodiscover", "--maxpeers", "0", "--cache", "64"}
+	return runQuai(t, append(allArgs, args...)...)
+}
+
 // waitForEndpoint attempts to connect to an RPC endpoint until it succeeds.
 func waitForEndpoint(t *testing.T, endpoint string, timeout time.Duration) {
 	probe := func() bool {
@@ -36,6 +36,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			configFileFlag,
 			utils.DataDirFlag,
 			utils.AncientFlag,
+			utils.AncientDepthFlag,
 			utils.MinFreeDiskSpaceFlag,
 			utils.KeyStoreDirFlag,
 			utils.USBFlag,
@@ -105,6 +106,8 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 	{
 		Name: "API AND CONSOLE",
 		Flags: []cli.Flag{
+			utils.IPCDisabledFlag,
+			utils.IPCPathFlag,
 			utils.HTTPEnabledFlag,
 			utils.HTTPListenAddrFlag,
 			utils.HTTPPortFlag,
@@ -118,6 +121,10 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.WSApiFlag,
 			utils.WSPathPrefixFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.AuthListenFlag,
+			utils.AuthPortFlag,
+			utils.AuthVirtualHostsFlag,
+			utils.JWTSecretFlag,
 			utils.RPCGlobalGasCapFlag,
 			utils.RPCGlobalTxFeeCapFlag,
 			utils.JSpathFlag,
@@ -150,6 +157,17 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.MinerEtherbaseFlag,
 		},
 	},
+	{
+		Name: "STRATUM",
+		Flags: []cli.Flag{
+			utils.StratumEnabledFlag,
+			utils.StratumListenAddrFlag,
+			utils.StratumPortFlag,
+			utils.StratumDifficultyFlag,
+			utils.StratumMaxSessionsFlag,
+			utils.StratumDomURLsFlag,
+		},
+	},
 	{
 		Name: "CONSENSUS",
 		Flags: []cli.Flag{
@@ -207,6 +225,8 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Flags: []cli.Flag{
 			utils.RegionFlag,
 			utils.ZoneFlag,
+			utils.SlicesRunningFlag,
+			utils.SlicesInProcessFlag,
 		},
 	},
 }
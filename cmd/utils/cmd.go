@@ -118,6 +118,7 @@ func monitorFreeDiskSpace(sigc chan os.Signal, path string, freeDiskSpaceCritica
 }
 
 func ImportChain(chain *core.Core, fn string) error {
+	nodeCtx := chain.NodeCtx()
 	// Watch for Ctrl-C while the import is running.
 	// If a signal is received, the import will stop at the next batch.
 	interrupt := make(chan os.Signal, 1)
@@ -174,7 +175,7 @@ func ImportChain(chain *core.Core, fn string) error {
 				return fmt.Errorf("at block %d: %v", n, err)
 			}
 			// don't import first block
-			if b.NumberU64() == 0 {
+			if b.NumberU64(nodeCtx) == 0 {
 				i--
 				continue
 			}
@@ -201,17 +202,18 @@ func ImportChain(chain *core.Core, fn string) error {
 }
 
 func missingBlocks(chain *core.Core, blocks []*types.Block) []*types.Block {
+	nodeCtx := chain.NodeCtx()
 	head := chain.CurrentBlock()
 	for i, block := range blocks {
 		// If we're behind the chain head, only check block, state is available at head
-		if head.NumberU64() > block.NumberU64() {
-			if !chain.HasBlock(block.Hash(), block.NumberU64()) {
+		if head.NumberU64(nodeCtx) > block.NumberU64(nodeCtx) {
+			if !chain.HasBlock(block.Hash(), block.NumberU64(nodeCtx)) {
 				return blocks[i:]
 			}
 			continue
 		}
 		// If we're above the chain head, state availability is a must
-		if !chain.HasBlockAndState(block.Hash(), block.NumberU64()) {
+		if !chain.HasBlockAndState(block.Hash(), block.NumberU64(nodeCtx)) {
 			return blocks[i:]
 		}
 	}
@@ -269,6 +271,122 @@ func ExportAppendChain(chain *core.Core, fn string, first uint64, last uint64) e
 	return nil
 }
 
+// ExportArchive exports the blocks first to last of the chain as a section of
+// a hierarchy archive, appending to the file if data already exists in it. The
+// archives of every chain in a hierarchy may be exported into the same file.
+func ExportArchive(chain *core.Core, fn string, first uint64, last uint64) error {
+	log.Info("Exporting hierarchy archive", "file", fn)
+
+	// Open the file handle and potentially wrap with a gzip stream
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	if err := chain.ExportArchive(writer, first, last); err != nil {
+		return err
+	}
+	log.Info("Exported hierarchy archive", "file", fn)
+	return nil
+}
+
+// openArchive opens a hierarchy archive file, potentially unwrapping the gzip
+// stream.
+func openArchive(fn string) (*core.ArchiveReader, io.Closer, error) {
+	fh, err := os.Open(fn)
+	if err != nil {
+		return nil, nil, err
+	}
+	var reader io.Reader = fh
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			fh.Close()
+			return nil, nil, err
+		}
+	}
+	return core.NewArchiveReader(reader), fh, nil
+}
+
+// ImportArchive replays the chain of the node from a set of hierarchy archive
+// files. The sections of the dom chains are indexed first, so the files may be
+// given in any order. Sections of unrelated chains are skipped.
+func ImportArchive(chain *core.Core, fns ...string) error {
+	// Watch for Ctrl-C while the import is running.
+	// If a signal is received, the import will stop at the next block.
+	interrupt := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during import, stopping at next block")
+		}
+		close(stop)
+	}()
+
+	importer := chain.NewArchiveImporter()
+
+	// Index the dom sections across all files first
+	for _, fn := range fns {
+		log.Info("Indexing hierarchy archive", "file", fn)
+		ar, closer, err := openArchive(fn)
+		if err != nil {
+			return err
+		}
+		for {
+			section, err := ar.NextSection()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				closer.Close()
+				return fmt.Errorf("%s: %v", fn, err)
+			}
+			if importer.IsDom(section) {
+				if err := importer.Index(section, ar); err != nil {
+					closer.Close()
+					return fmt.Errorf("%s: %v", fn, err)
+				}
+			}
+		}
+		closer.Close()
+	}
+	// Replay the local sections in the order they were given
+	for _, fn := range fns {
+		log.Info("Importing hierarchy archive", "file", fn)
+		ar, closer, err := openArchive(fn)
+		if err != nil {
+			return err
+		}
+		for {
+			section, err := ar.NextSection()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				closer.Close()
+				return fmt.Errorf("%s: %v", fn, err)
+			}
+			if !importer.IsLocal(section) {
+				continue
+			}
+			n, err := importer.Import(section, ar, stop)
+			log.Info("Imported hierarchy archive section", "location", section.Location.Name(), "blocks", n)
+			if err != nil {
+				closer.Close()
+				return fmt.Errorf("%s: %v", fn, err)
+			}
+		}
+		closer.Close()
+	}
+	return nil
+}
+
 // ImportPreimages imports a batch of exported hash preimages into the database.
 func ImportPreimages(db ethdb.Database, fn string) error {
 	log.Info("Importing preimages", "file", fn)
@@ -26,6 +26,7 @@ import (
 	"io/ioutil"
 	"math"
 	"math/big"
+	"net"
 	"net/http"
 	"path/filepath"
 	"runtime"
@@ -36,6 +37,8 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/dominant-strategies/go-quai/accounts"
+	"github.com/dominant-strategies/go-quai/accounts/keystore"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/common/fdlimit"
 	"github.com/dominant-strategies/go-quai/consensus"
@@ -43,12 +46,14 @@ import (
 	"github.com/dominant-strategies/go-quai/consensus/progpow"
 	"github.com/dominant-strategies/go-quai/core"
 	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/core/vm"
 	"github.com/dominant-strategies/go-quai/crypto"
 	"github.com/dominant-strategies/go-quai/eth"
 	"github.com/dominant-strategies/go-quai/eth/downloader"
 	"github.com/dominant-strategies/go-quai/eth/ethconfig"
 	"github.com/dominant-strategies/go-quai/eth/gasprice"
+	"github.com/dominant-strategies/go-quai/eth/tracers"
 	"github.com/dominant-strategies/go-quai/ethdb"
 	"github.com/dominant-strategies/go-quai/internal/flags"
 	"github.com/dominant-strategies/go-quai/internal/quaiapi"
@@ -63,6 +68,7 @@ import (
 	"github.com/dominant-strategies/go-quai/p2p/netutil"
 	"github.com/dominant-strategies/go-quai/params"
 	"github.com/dominant-strategies/go-quai/quaistats"
+	"github.com/dominant-strategies/go-quai/stratum"
 	gopsutil "github.com/shirou/gopsutil/mem"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -113,6 +119,11 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	AncientDepthFlag = cli.Uint64Flag{
+		Name:  "datadir.ancient.depth",
+		Usage: "Number of blocks below the last prime coincident block to keep out of the ancient store",
+		Value: ethconfig.Defaults.AncientDepth,
+	}
 	MinFreeDiskSpaceFlag = DirectoryFlag{
 		Name:  "datadir.minfreedisk",
 		Usage: "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -143,6 +154,10 @@ var (
 		Name:  "slices",
 		Usage: "All the slices that are running on this node",
 	}
+	SlicesInProcessFlag = cli.BoolFlag{
+		Name:  "slices.inprocess",
+		Usage: "Run prime and every region and zone of the slices in this process, linked in memory",
+	}
 	PprofFlag = cli.BoolFlag{
 		Name:  "pprof",
 		Usage: "Enable the pprof HTTP server",
@@ -221,7 +236,7 @@ var (
 	defaultSyncMode = ethconfig.Defaults.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", "snap" or "light")`,
+		Usage: `Blockchain sync mode ("full" or "snap")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -363,9 +378,39 @@ var (
 	}
 	MinerEtherbaseFlag = cli.StringFlag{
 		Name:  "miner.etherbase",
-		Usage: "Public address for block mining rewards (default = first account)",
+		Usage: "Public address for block mining rewards, one per zone when the slices run in process (default = first account)",
 		Value: "0",
 	}
+	// Stratum settings
+	StratumEnabledFlag = cli.BoolFlag{
+		Name:  "stratum",
+		Usage: "Enable the Stratum mining server",
+	}
+	StratumListenAddrFlag = cli.StringFlag{
+		Name:  "stratum.addr",
+		Usage: "Stratum server listening interface",
+		Value: stratum.DefaultConfig.Host,
+	}
+	StratumPortFlag = cli.IntFlag{
+		Name:  "stratum.port",
+		Usage: "Stratum server listening port",
+		Value: stratum.DefaultConfig.Port,
+	}
+	StratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "stratum.diff",
+		Usage: "Difficulty a share has to meet to be accepted by the Stratum server",
+		Value: stratum.DefaultConfig.ShareDifficulty,
+	}
+	StratumMaxSessionsFlag = cli.IntFlag{
+		Name:  "stratum.maxsessions",
+		Usage: "Maximum number of miner connections served by the Stratum server",
+		Value: stratum.DefaultConfig.MaxSessions,
+	}
+	StratumDomURLsFlag = cli.StringFlag{
+		Name:  "stratum.domurls",
+		Usage: "Comma separated authenticated endpoints of the region and prime nodes, dom coincident blocks are forwarded to",
+		Value: "",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -477,6 +522,34 @@ var (
 		Usage: "HTTP path prefix on which JSON-RPC is served. Use '/' to serve on all paths.",
 		Value: "",
 	}
+	AuthListenFlag = cli.StringFlag{
+		Name:  "authrpc.addr",
+		Usage: "Listening address for the authenticated coordination RPC of the dom and sub chains",
+		Value: node.DefaultAuthHost,
+	}
+	AuthPortFlag = cli.IntFlag{
+		Name:  "authrpc.port",
+		Usage: "Listening port for the authenticated coordination RPC of the dom and sub chains",
+		Value: node.DefaultAuthPort,
+	}
+	AuthVirtualHostsFlag = cli.StringFlag{
+		Name:  "authrpc.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.AuthVirtualHosts, ","),
+	}
+	JWTSecretFlag = cli.StringFlag{
+		Name:  "authrpc.jwtsecret",
+		Usage: "Path to a JWT secret shared by the chains of the node (default = inside the datadir)",
+		Value: "",
+	}
+	IPCDisabledFlag = cli.BoolFlag{
+		Name:  "ipcdisable",
+		Usage: "Disable the IPC-RPC server",
+	}
+	IPCPathFlag = DirectoryFlag{
+		Name:  "ipcpath",
+		Usage: "Filename for IPC socket/pipe within the datadir (explicit paths escape it)",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -694,15 +767,16 @@ func MakeDataDir(ctx *cli.Context) string {
 			path = filepath.Join(path, "lighthouse")
 		}
 		// Set specific directory for node location within the hierarchy
-		switch common.NodeLocation.Context() {
+		location := NodeLocation(ctx)
+		switch location.Context() {
 		case common.PRIME_CTX:
 			path = filepath.Join(path, "prime")
 		case common.REGION_CTX:
-			regionNum := strconv.Itoa(common.NodeLocation.Region())
+			regionNum := strconv.Itoa(location.Region())
 			path = filepath.Join(path, "region-"+regionNum)
 		case common.ZONE_CTX:
-			regionNum := strconv.Itoa(common.NodeLocation.Region())
-			zoneNum := strconv.Itoa(common.NodeLocation.Zone())
+			regionNum := strconv.Itoa(location.Region())
+			zoneNum := strconv.Itoa(location.Zone())
 			path = filepath.Join(path, "zone-"+regionNum+"-"+zoneNum)
 		}
 		return path
@@ -912,6 +986,39 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setIPC creates an IPC path configuration from the set command line flags,
+// returning an empty string if IPC was explicitly disabled, or the set path.
+func setIPC(ctx *cli.Context, cfg *node.Config) {
+	CheckExclusive(ctx, IPCDisabledFlag, IPCPathFlag)
+	switch {
+	case ctx.GlobalBool(IPCDisabledFlag.Name):
+		cfg.IPCPath = ""
+	case ctx.GlobalIsSet(IPCPathFlag.Name):
+		cfg.IPCPath = ctx.GlobalString(IPCPathFlag.Name)
+	}
+}
+
+// setAuthRPC configures the authenticated RPC server from the set command line
+// flags. Unless given, the secret is kept next to the datadirs of the chains, so
+// that all chains of the node share it.
+func setAuthRPC(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(AuthListenFlag.Name) {
+		cfg.AuthAddr = ctx.GlobalString(AuthListenFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthPortFlag.Name) {
+		cfg.AuthPort = ctx.GlobalInt(AuthPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthVirtualHostsFlag.Name) {
+		cfg.AuthVirtualHosts = SplitAndTrim(ctx.GlobalString(AuthVirtualHostsFlag.Name))
+	}
+	switch {
+	case ctx.GlobalIsSet(JWTSecretFlag.Name):
+		cfg.JWTSecret = ctx.GlobalString(JWTSecretFlag.Name)
+	case cfg.JWTSecret == "" && cfg.DataDir != "":
+		cfg.JWTSecret = filepath.Join(filepath.Dir(cfg.DataDir), "jwtsecret")
+	}
+}
+
 // setDomUrl sets the dominant chain websocket url.
 func setDomUrl(ctx *cli.Context, cfg *ethconfig.Config) {
 	// only set the dom url if the node is not prime
@@ -985,20 +1092,63 @@ func makeSubUrls(ctx *cli.Context) []string {
 
 // setSlicesRunning sets the slices running flag
 func setSlicesRunning(ctx *cli.Context, cfg *ethconfig.Config) {
+	cfg.SlicesRunning = makeSlicesRunning(ctx)
+}
+
+// makeSlicesRunning returns the slices given by the slices running flag
+func makeSlicesRunning(ctx *cli.Context) []common.Location {
 	slices := strings.Split(ctx.GlobalString(SlicesRunningFlag.Name), ",")
 
 	// Sanity checks
 	if len(slices) == 0 {
 		Fatalf("no slices are specified")
 	}
-	if len(slices) > common.NumRegionsInPrime*common.NumZonesInRegion {
+	if topology := common.DefaultTopology; len(slices) > topology.Regions*topology.Zones {
 		Fatalf("number of slices exceed the current ontology")
 	}
 	slicesRunning := []common.Location{}
 	for _, slice := range slices {
 		slicesRunning = append(slicesRunning, common.Location{slice[1] - 48, slice[3] - 48})
 	}
-	cfg.SlicesRunning = slicesRunning
+	return slicesRunning
+}
+
+// SliceLocations returns the locations of the chains hosted by this process.
+// Unless the slices run in process, that is the single chain selected by the
+// region and zone flags. Otherwise it is prime followed by every region and
+// zone of the running slices.
+func SliceLocations(ctx *cli.Context) []common.Location {
+	if !ctx.GlobalBool(SlicesInProcessFlag.Name) {
+		return []common.Location{NodeLocation(ctx)}
+	}
+	slices := makeSlicesRunning(ctx)
+	locations := []common.Location{{}}
+	seen := make(map[string]bool)
+	for _, slice := range slices {
+		if region := slice.DomLocation(); !seen[string(region)] {
+			seen[string(region)] = true
+			locations = append(locations, region)
+		}
+	}
+	for _, slice := range slices {
+		if !seen[string(slice)] {
+			seen[string(slice)] = true
+			locations = append(locations, slice)
+		}
+	}
+	return locations
+}
+
+// sliceIndex returns the position of the given chain among the chains hosted
+// by this process.
+func sliceIndex(ctx *cli.Context, location common.Location) int {
+	for i, hosted := range SliceLocations(ctx) {
+		if hosted.Equal(location) {
+			return i
+		}
+	}
+	Fatalf("Chain %s is not hosted by this node", location.Name())
+	return -1
 }
 
 // MakeDatabaseHandles raises out the number of allowed file handles per process
@@ -1025,21 +1175,56 @@ func HexAddress(account string) (common.Address, error) {
 	return common.Address{}, errors.New("invalid account address")
 }
 
+// MakeAddress converts an account specified directly as a hex encoded string or
+// a key index in the key store to an internal account representation.
+func MakeAddress(ks *keystore.KeyStore, account string) (accounts.Account, error) {
+	// If the specified account is a valid address, return it
+	if common.IsHexAddress(account) {
+		return accounts.Account{Address: common.HexToAddress(account)}, nil
+	}
+	// Otherwise try to interpret the account as a keystore index
+	index, err := strconv.Atoi(account)
+	if err != nil || index < 0 {
+		return accounts.Account{}, fmt.Errorf("invalid account address or index %q", account)
+	}
+	log.Warn("-------------------------------------------------------------------")
+	log.Warn("Referring to accounts by order in the keystore folder is dangerous!")
+	log.Warn("This functionality is deprecated and will be removed in the future!")
+	log.Warn("Please use explicit addresses! (can search via `go-quai account list`)")
+	log.Warn("-------------------------------------------------------------------")
+
+	accs := ks.Accounts()
+	if len(accs) <= index {
+		return accounts.Account{}, fmt.Errorf("index %d higher than number of accounts %d", index, len(accs))
+	}
+	return accs[index], nil
+}
+
 // setEtherbase retrieves the etherbase either from the directly specified
 // command line flags or from the keystore if CLI indexed.
 func setEtherbase(ctx *cli.Context, cfg *ethconfig.Config) {
 	// Extract the current etherbase
-	var etherbase string
+	var etherbases []string
 	if ctx.GlobalIsSet(MinerEtherbaseFlag.Name) {
-		etherbase = ctx.GlobalString(MinerEtherbaseFlag.Name)
+		etherbases = SplitAndTrim(ctx.GlobalString(MinerEtherbaseFlag.Name))
 	}
-	// Convert the etherbase into an address and configure it
-	if etherbase != "" {
+	// Convert the etherbase into an address and configure it. A node running
+	// several zones takes one etherbase per zone and keeps the one belonging
+	// to the zone being configured.
+	for _, etherbase := range etherbases {
 		account, err := HexAddress(etherbase)
 		if err != nil {
 			Fatalf("Invalid miner etherbase: %v", err)
 		}
-		cfg.Miner.Etherbase = account
+		// The chain config is only loaded by the node, so the etherbases are
+		// matched against the default topology
+		if common.DefaultTopology.IsInChainScope(account.Bytes(), cfg.NodeLocation) {
+			cfg.Miner.Etherbase = account
+			return
+		}
+	}
+	if len(etherbases) > 0 {
+		Fatalf("No miner etherbase given for %s, addresses must be in the scope of the zone", cfg.NodeLocation.Name())
 	}
 }
 
@@ -1109,11 +1294,22 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 
 // SetNodeConfig applies node-related command line flags to the config.
 func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
+	SetSliceNodeConfig(ctx, cfg, NodeLocation(ctx))
+}
+
+// SetSliceNodeConfig applies node-related command line flags to the config of
+// the node serving the chain at the given location.
+func SetSliceNodeConfig(ctx *cli.Context, cfg *node.Config, location common.Location) {
 	SetP2PConfig(ctx, &cfg.P2P)
+	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
 	setWS(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
-	setDataDir(ctx, cfg)
+	setDataDir(ctx, cfg, location)
+	setAuthRPC(ctx, cfg)
+	if ctx.GlobalBool(SlicesInProcessFlag.Name) {
+		setSlicePorts(ctx, cfg, location)
+	}
 
 	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
@@ -1122,6 +1318,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
+	if ctx.GlobalIsSet(LightKDFFlag.Name) {
+		cfg.UseLightweightKDF = ctx.GlobalBool(LightKDFFlag.Name)
+	}
 	if ctx.GlobalIsSet(DeveloperFlag.Name) {
 		cfg.UseLightweightKDF = true
 	}
@@ -1144,7 +1343,7 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
-func setDataDir(ctx *cli.Context, cfg *node.Config) {
+func setDataDir(ctx *cli.Context, cfg *node.Config, location common.Location) {
 	switch {
 	case ctx.GlobalIsSet(DataDirFlag.Name):
 		cfg.DataDir = ctx.GlobalString(DataDirFlag.Name)
@@ -1160,19 +1359,51 @@ func setDataDir(ctx *cli.Context, cfg *node.Config) {
 		cfg.DataDir = filepath.Join(node.DefaultDataDir(), "local")
 	}
 	// Set specific directory for node location within the hierarchy
-	switch common.NodeLocation.Context() {
+	switch location.Context() {
 	case common.PRIME_CTX:
 		cfg.DataDir = filepath.Join(cfg.DataDir, "prime")
 	case common.REGION_CTX:
-		regionNum := strconv.Itoa(common.NodeLocation.Region())
+		regionNum := strconv.Itoa(location.Region())
 		cfg.DataDir = filepath.Join(cfg.DataDir, "region-"+regionNum)
 	case common.ZONE_CTX:
-		regionNum := strconv.Itoa(common.NodeLocation.Region())
-		zoneNum := strconv.Itoa(common.NodeLocation.Zone())
+		regionNum := strconv.Itoa(location.Region())
+		zoneNum := strconv.Itoa(location.Zone())
 		cfg.DataDir = filepath.Join(cfg.DataDir, "zone-"+regionNum+"-"+zoneNum)
 	}
 }
 
+// slicePortStride is the distance between the ports of two chains hosted by
+// the same process.
+const slicePortStride = 10
+
+// setSlicePorts moves the listeners of the chain at the given location away
+// from the ones of the other chains hosted by this process. The first chain
+// keeps the configured ports, the next ones are offset by slicePortStride.
+func setSlicePorts(ctx *cli.Context, cfg *node.Config, location common.Location) {
+	offset := sliceIndex(ctx, location) * slicePortStride
+	if offset == 0 {
+		return
+	}
+	if cfg.HTTPPort != 0 {
+		cfg.HTTPPort += offset
+	}
+	if cfg.WSPort != 0 {
+		cfg.WSPort += offset
+	}
+	if cfg.AuthPort != 0 {
+		cfg.AuthPort += offset
+	}
+	if cfg.P2P.ListenAddr != "" {
+		host, port, err := net.SplitHostPort(cfg.P2P.ListenAddr)
+		if err != nil {
+			Fatalf("Invalid listen address %q: %v", cfg.P2P.ListenAddr, err)
+		}
+		if p, err := strconv.Atoi(port); err == nil && p != 0 {
+			cfg.P2P.ListenAddr = net.JoinHostPort(host, strconv.Itoa(p+offset))
+		}
+	}
+}
+
 func setGPO(ctx *cli.Context, cfg *gasprice.Config, light bool) {
 	// If we are running the light client, apply another group
 	// settings for gas oracle.
@@ -1193,14 +1424,14 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config, light bool) {
 	}
 }
 
-func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
+func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig, location common.Location) {
 	if ctx.GlobalIsSet(TxPoolLocalsFlag.Name) {
 		locals := strings.Split(ctx.GlobalString(TxPoolLocalsFlag.Name), ",")
 		for _, account := range locals {
 			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
 				Fatalf("Invalid account in --txpool.locals: %s", trimmed)
 			} else {
-				internal, err := common.HexToAddress(account).InternalAddress()
+				internal, err := common.HexToAddress(account).InternalAddress(location, common.DefaultTopology)
 				if err != nil {
 					Fatalf("Invalid account in --txpool.locals: %s", account)
 				}
@@ -1346,26 +1577,27 @@ func CheckExclusive(ctx *cli.Context, args ...interface{}) {
 	}
 }
 
-func SetGlobalVars(ctx *cli.Context) {
-	// Configure global NodeLocation
+// NodeLocation returns the location of the chain selected by the region and
+// zone flags.
+func NodeLocation(ctx *cli.Context) common.Location {
 	if !ctx.GlobalIsSet(RegionFlag.Name) && ctx.GlobalIsSet(ZoneFlag.Name) {
 		log.Fatal("zone idx given, but missing region idx!")
 	}
+	location := common.Location{}
 	if ctx.GlobalIsSet(RegionFlag.Name) {
-		region := ctx.GlobalInt(RegionFlag.Name)
-		common.NodeLocation = append(common.NodeLocation, byte(region))
+		location = append(location, byte(ctx.GlobalInt(RegionFlag.Name)))
 	}
 	if ctx.GlobalIsSet(ZoneFlag.Name) {
-		zone := ctx.GlobalInt(ZoneFlag.Name)
-		common.NodeLocation = append(common.NodeLocation, byte(zone))
+		location = append(location, byte(ctx.GlobalInt(ZoneFlag.Name)))
 	}
+	return location
 }
 
-func EnablePprof() {
+func EnablePprof(location common.Location) {
 	runtime.SetBlockProfileRate(1)
 	runtime.SetMutexProfileFraction(1)
 	var port string
-	myContext := common.NodeLocation
+	myContext := location
 	switch {
 	case bytes.Equal(myContext, []byte{}): // PRIME
 		port = "8081"
@@ -1403,6 +1635,12 @@ func EnablePprof() {
 
 // SetEthConfig applies eth-related command line flags to the config.
 func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
+	SetSliceEthConfig(ctx, stack, cfg, NodeLocation(ctx))
+}
+
+// SetSliceEthConfig applies eth-related command line flags to the config of
+// the chain at the given location.
+func SetSliceEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config, location common.Location) {
 	// Avoid conflicting network flags
 	CheckExclusive(ctx, ColosseumFlag, DeveloperFlag, GardenFlag, OrchardFlag, LocalFlag, LighthouseFlag)
 	CheckExclusive(ctx, DeveloperFlag, ExternalSignerFlag) // Can't use both ephemeral unlocked and external signer
@@ -1412,12 +1650,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		log.Warn("Disable transaction unindexing for archive node")
 	}
 
+	// set the location of the slice served by this instance
+	cfg.NodeLocation = location
+
 	// only set etherbase if its a zone chain
-	if ctx.GlobalIsSet(RegionFlag.Name) && ctx.GlobalIsSet(ZoneFlag.Name) {
+	if location.Context() == common.ZONE_CTX {
 		setEtherbase(ctx, cfg)
 	}
 	setGPO(ctx, &cfg.GPO, ctx.GlobalString(SyncModeFlag.Name) == "light")
-	setTxPool(ctx, &cfg.TxPool)
+	setTxPool(ctx, &cfg.TxPool, cfg.NodeLocation)
 
 	// If blake3 consensus engine is specifically asked use the blake3 engine
 	if ctx.GlobalString(ConsensusEngineFlag.Name) == "blake3" {
@@ -1429,11 +1670,16 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 
 	setWhitelist(ctx, cfg)
 
-	// set the dominant chain websocket url
-	setDomUrl(ctx, cfg)
+	// chains running in process are linked in memory instead of websockets
+	if ctx.GlobalBool(SlicesInProcessFlag.Name) {
+		cfg.DomUrl, cfg.SubUrls = "", nil
+	} else {
+		// set the dominant chain websocket url
+		setDomUrl(ctx, cfg)
 
-	// set the subordinate chain websocket urls
-	setSubUrls(ctx, cfg)
+		// set the subordinate chain websocket urls
+		setSubUrls(ctx, cfg)
+	}
 
 	// set the gas limit ceil
 	setGasLimitCeil(ctx, cfg)
@@ -1443,7 +1689,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 
 	if ctx.GlobalBool(PprofFlag.Name) {
 		log.Info("Starting pprof server")
-		EnablePprof()
+		EnablePprof(cfg.NodeLocation)
 	}
 
 	// Cap the cache allowance and tune the garbage collector
@@ -1475,10 +1721,14 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheDatabaseFlag.Name) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 	}
-	cfg.DatabaseHandles = MakeDatabaseHandles()
+	// the chains hosted by this process share the file handle allowance
+	cfg.DatabaseHandles = MakeDatabaseHandles() / len(SliceLocations(ctx))
 	if ctx.GlobalIsSet(AncientFlag.Name) {
 		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
 	}
+	if ctx.GlobalIsSet(AncientDepthFlag.Name) {
+		cfg.AncientDepth = ctx.GlobalUint64(AncientDepthFlag.Name)
+	}
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1633,7 +1883,7 @@ func SetDNSDiscoveryDefaults(cfg *ethconfig.Config, genesis common.Hash) {
 		return // already set through flags/config
 	}
 	protocol := "all"
-	if url := params.KnownDNSNetwork(genesis, protocol); url != "" {
+	if url := params.KnownDNSNetwork(genesis, cfg.NodeLocation, protocol); url != "" {
 		cfg.EthDiscoveryURLs = []string{url}
 		cfg.SnapDiscoveryURLs = cfg.EthDiscoveryURLs
 	}
@@ -1647,9 +1897,62 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (quaiapi.Backen
 	if err != nil {
 		Fatalf("Failed to register the Quai service: %v", err)
 	}
+	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
 	return backend.APIBackend, backend
 }
 
+// SetSliceStratumConfig applies Stratum server related command line flags to
+// the config of the server mining the chain at the given location.
+func SetSliceStratumConfig(ctx *cli.Context, cfg *stratum.Config, location common.Location) {
+	SetStratumConfig(ctx, cfg)
+	if ctx.GlobalBool(SlicesInProcessFlag.Name) && cfg.Port != 0 {
+		cfg.Port += sliceIndex(ctx, location) * slicePortStride
+	}
+}
+
+// SetStratumConfig applies Stratum server related command line flags to the config.
+func SetStratumConfig(ctx *cli.Context, cfg *stratum.Config) {
+	if ctx.GlobalIsSet(StratumEnabledFlag.Name) {
+		cfg.Enabled = ctx.GlobalBool(StratumEnabledFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumListenAddrFlag.Name) {
+		cfg.Host = ctx.GlobalString(StratumListenAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumPortFlag.Name) {
+		cfg.Port = ctx.GlobalInt(StratumPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumDifficultyFlag.Name) {
+		cfg.ShareDifficulty = ctx.GlobalUint64(StratumDifficultyFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumMaxSessionsFlag.Name) {
+		cfg.MaxSessions = ctx.GlobalInt(StratumMaxSessionsFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumDomURLsFlag.Name) {
+		cfg.DomURLs = SplitAndTrim(ctx.GlobalString(StratumDomURLsFlag.Name))
+	}
+}
+
+// stratumBackend adapts the API backend to the Stratum server, which hands
+// its solutions to the node like the coordination API does.
+type stratumBackend struct {
+	quaiapi.Backend
+}
+
+func (b stratumBackend) ReceiveMinedHeader(header *types.Header) error {
+	return quaiapi.ReceiveMinedHeader(b.Backend, header)
+}
+
+// RegisterStratumService configures the Stratum mining server and adds it to
+// the given node.
+func RegisterStratumService(stack *node.Node, backend quaiapi.Backend, cfg stratum.Config) {
+	server, err := stratum.New(stratumBackend{backend}, backend.Engine(), cfg, stack.JWTSecret())
+	if err != nil {
+		Fatalf("Failed to register the Stratum service: %v", err)
+	}
+	stack.RegisterAPIs(server.APIs())
+	stack.RegisterLifecycle(server)
+}
+
 // RegisterQuaiStatsService configures the Quai Stats daemon and adds it to
 // the given node.
 func RegisterQuaiStatsService(stack *node.Node, backend quaiapi.Backend, url string) {
@@ -1741,22 +2044,33 @@ func MakeGenesis(ctx *cli.Context) *core.Genesis {
 
 // MakeChain creates a chain manager from set command line flags.
 func MakeChain(ctx *cli.Context, stack *node.Node) (*core.Core, ethdb.Database) {
+	return makeChain(ctx, stack, ctx.GlobalString(DomUrl.Name), makeSubUrls(ctx))
+}
+
+// MakeDetachedChain creates a chain manager from set command line flags, without
+// dialing the dom and sub chains of the node.
+func MakeDetachedChain(ctx *cli.Context, stack *node.Node) (*core.Core, ethdb.Database) {
+	return makeChain(ctx, stack, "", nil)
+}
+
+func makeChain(ctx *cli.Context, stack *node.Node, domUrl string, subUrls []string) (*core.Core, ethdb.Database) {
 	var err error
 	chainDb := MakeChainDatabase(ctx, stack, false) // TODO(rjl493456442) support read-only database
 	config, _, err := core.SetupGenesisBlock(chainDb, MakeGenesis(ctx))
 	if err != nil {
 		Fatalf("%v", err)
 	}
+	config = config.WithLocation(NodeLocation(ctx))
 	var engine consensus.Engine
 
 	engine = progpow.NewFaker()
 	if !ctx.GlobalBool(FakePoWFlag.Name) {
-		engine = progpow.New(progpow.Config{}, nil, false)
+		engine = progpow.New(progpow.Config{NodeLocation: config.Location}, nil, false)
 	}
 
 	// If blake3 consensus engine is selected use the blake3 engine
 	if ctx.GlobalString(ConsensusEngineFlag.Name) == "blake3" {
-		engine = blake3pow.New(blake3pow.Config{}, nil, false)
+		engine = blake3pow.New(blake3pow.Config{NodeLocation: config.Location}, nil, false)
 	}
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
@@ -1788,7 +2102,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (*core.Core, ethdb.Database)
 
 	// TODO(rjl493456442) disable snapshot generation/wiping if the chain is read only.
 	// Disable transaction indexing/unindexing by default.
-	protocol, err := core.NewCore(chainDb, nil, nil, nil, nil, config, ctx.GlobalString(DomUrl.Name), makeSubUrls(ctx), engine, cache, vmcfg, &core.Genesis{})
+	protocol, err := core.NewCore(chainDb, nil, nil, nil, nil, config, domUrl, subUrls, stack.JWTSecret(), engine, cache, vmcfg, &core.Genesis{})
 	if err != nil {
 		Fatalf("Can't create BlockChain: %v", err)
 	}
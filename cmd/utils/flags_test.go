@@ -18,8 +18,14 @@
 package utils
 
 import (
+	"flag"
 	"reflect"
 	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/node"
+	"github.com/dominant-strategies/go-quai/p2p"
+	"gopkg.in/urfave/cli.v1"
 )
 
 func Test_SplitTagsFlag(t *testing.T) {
@@ -62,3 +68,69 @@ func Test_SplitTagsFlag(t *testing.T) {
 		})
 	}
 }
+
+func newSlicesContext(t *testing.T, slices string, inProcess bool) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	SlicesRunningFlag.Apply(set)
+	SlicesInProcessFlag.Apply(set)
+	args := []string{"--" + SlicesRunningFlag.Name, slices}
+	if inProcess {
+		args = append(args, "--"+SlicesInProcessFlag.Name)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func TestSliceLocations(t *testing.T) {
+	tests := []struct {
+		name      string
+		slices    string
+		inProcess bool
+		want      []common.Location
+	}{
+		{
+			"single chain",
+			"[0 0],[1 2]",
+			false,
+			[]common.Location{{}},
+		},
+		{
+			"one slice",
+			"[1 2]",
+			true,
+			[]common.Location{{}, {1}, {1, 2}},
+		},
+		{
+			"shared region",
+			"[0 0],[2 1],[0 2]",
+			true,
+			[]common.Location{{}, {0}, {2}, {0, 0}, {2, 1}, {0, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SliceLocations(newSlicesContext(t, tt.slices, tt.inProcess)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SliceLocations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetSlicePorts(t *testing.T) {
+	ctx := newSlicesContext(t, "[0 0],[0 1]", true)
+
+	cfg := node.Config{HTTPPort: 8546, WSPort: 0, AuthPort: 8550, P2P: p2p.Config{ListenAddr: ":30303"}}
+	setSlicePorts(ctx, &cfg, common.Location{})
+	if cfg.HTTPPort != 8546 || cfg.AuthPort != 8550 || cfg.P2P.ListenAddr != ":30303" {
+		t.Errorf("prime ports moved: http %d, auth %d, p2p %s", cfg.HTTPPort, cfg.AuthPort, cfg.P2P.ListenAddr)
+	}
+	setSlicePorts(ctx, &cfg, common.Location{0, 1})
+	if cfg.HTTPPort != 8576 || cfg.AuthPort != 8580 || cfg.P2P.ListenAddr != ":30333" {
+		t.Errorf("zone ports not offset: http %d, auth %d, p2p %s", cfg.HTTPPort, cfg.AuthPort, cfg.P2P.ListenAddr)
+	}
+	if cfg.WSPort != 0 {
+		t.Errorf("random websocket port offset to %d", cfg.WSPort)
+	}
+}
@@ -33,19 +33,19 @@ type AddressData interface {
 	UnmarshalJSON(input []byte) error
 	Scan(src interface{}) error
 	Value() (driver.Value, error)
-	Location() *Location
 	setBytes(b []byte)
 }
 
-func (a Address) InternalAddress() (InternalAddress, error) {
+// InternalAddress returns the address as an address of the chain at the given
+// location of the topology, or ErrInvalidScope if it belongs to another chain.
+func (a Address) InternalAddress(location Location, topology *Topology) (InternalAddress, error) {
 	if a.inner == nil {
 		return InternalAddress{}, nil
 	}
-	internal, ok := a.inner.(*InternalAddress)
-	if !ok {
+	if !topology.IsInChainScope(a.Bytes(), location) {
 		return InternalAddress{}, ErrInvalidScope
 	}
-	return *internal, nil
+	return InternalAddress(a.Bytes20()), nil
 }
 
 func (a Address) Equal(b Address) bool {
@@ -58,17 +58,12 @@ func (a Address) Equal(b Address) bool {
 }
 
 // BytesToAddress returns Address with value b.
-// If b is larger than len(h), b will be cropped from the left.
+// If b is larger than len(h), b will be cropped from the left. Whether the
+// address is internal depends on the chain looking at it, see InternalAddress.
 func BytesToAddress(b []byte) Address {
-	if IsInChainScope(b) {
-		var i InternalAddress
-		i.setBytes(b)
-		return Address{&i}
-	} else {
-		var e ExternalAddress
-		e.setBytes(b)
-		return Address{&e}
-	}
+	var e ExternalAddress
+	e.setBytes(b)
+	return Address{&e}
 }
 
 func Bytes20ToAddress(b [20]byte) Address {
@@ -209,12 +204,13 @@ func (a Address) Value() (driver.Value, error) {
 	return a.inner.Value()
 }
 
-// Location looks up the chain location which contains this address
-func (a Address) Location() *Location {
+// Location looks up the location of the zone of the topology which contains
+// this address
+func (a Address) Location(topology *Topology) *Location {
 	if a.inner == nil {
-		return &NodeLocation
+		return topology.AddressLocation(ZeroInternal[:])
 	}
-	return a.inner.Location()
+	return topology.AddressLocation(a.Bytes())
 }
 
 // BigToAddress returns Address with byte values of b.
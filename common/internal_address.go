@@ -129,7 +129,3 @@ func (a *InternalAddress) Scan(src interface{}) error {
 func (a InternalAddress) Value() (driver.Value, error) {
 	return a[:], nil
 }
-
-func (a InternalAddress) Location() *Location {
-	return &NodeLocation
-}
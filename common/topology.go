@@ -0,0 +1,180 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dominant-strategies/go-quai/log"
+)
+
+// AddressSpace is the part of the address space owned by a zone. An address
+// belongs to the zone if its first byte is within the range, bounds included.
+type AddressSpace struct {
+	Lo uint8 `json:"lo"`
+	Hi uint8 `json:"hi"`
+
+	// Precompile is the first byte of the addresses of the precompiled
+	// contracts of the zone, which must be within the range.
+	Precompile uint8 `json:"precompile"`
+}
+
+// Contains reports whether the given address prefix belongs to the space.
+func (s AddressSpace) Contains(prefix byte) bool {
+	return prefix >= s.Lo && prefix <= s.Hi
+}
+
+// Topology is the shape of the hierarchy: the number of regions below prime,
+// the number of zones below every region and how the address space is split
+// between the zones.
+type Topology struct {
+	Regions int `json:"regions"`
+	Zones   int `json:"zones"`
+
+	// AddressSpaces are the address spaces of the zones, indexed by region and
+	// zone. If empty, the address space is split evenly between the zones in
+	// the order of their locations.
+	AddressSpaces [][]AddressSpace `json:"addressSpaces,omitempty"`
+}
+
+// DefaultTopology is the 3x3 hierarchy of the Quai network.
+var DefaultTopology = &Topology{
+	Regions: 3,
+	Zones:   3,
+	AddressSpaces: [][]AddressSpace{
+		{{0, 29, 0x14}, {30, 58, 0x20}, {59, 87, 0x3E}},
+		{{88, 115, 0x5A}, {116, 143, 0x78}, {144, 171, 0x96}},
+		{{172, 199, 0xB4}, {200, 227, 0xD2}, {228, 255, 0xF0}},
+	},
+}
+
+// Width returns the largest number of subordinate chains of a chain.
+func (t *Topology) Width() int {
+	if t.Regions > t.Zones {
+		return t.Regions
+	}
+	return t.Zones
+}
+
+// Contains reports whether the given location is a chain of the hierarchy.
+func (t *Topology) Contains(loc Location) bool {
+	if len(loc) >= HierarchyDepth {
+		return false
+	}
+	return loc.Region() < t.Regions && loc.Zone() < t.Zones
+}
+
+// Validate checks that the topology describes a usable hierarchy.
+func (t *Topology) Validate() error {
+	if t.Regions < 1 || t.Zones < 1 {
+		return errors.New("topology needs at least one region and one zone")
+	}
+	// Every zone needs at least one address prefix
+	if t.Regions*t.Zones > 256 {
+		return fmt.Errorf("topology has too many zones: %d", t.Regions*t.Zones)
+	}
+	if len(t.AddressSpaces) == 0 {
+		return nil
+	}
+	if len(t.AddressSpaces) != t.Regions {
+		return fmt.Errorf("address spaces given for %d regions, want %d", len(t.AddressSpaces), t.Regions)
+	}
+	var owners [256]Location
+	for r, zones := range t.AddressSpaces {
+		if len(zones) != t.Zones {
+			return fmt.Errorf("address spaces given for %d zones of region %d, want %d", len(zones), r, t.Zones)
+		}
+		for z, space := range zones {
+			loc := Location{byte(r), byte(z)}
+			if space.Lo > space.Hi {
+				return fmt.Errorf("address space of zone %d-%d is empty", r, z)
+			}
+			if !space.Contains(space.Precompile) {
+				return fmt.Errorf("precompiles of zone %d-%d are outside of its address space", r, z)
+			}
+			for prefix := int(space.Lo); prefix <= int(space.Hi); prefix++ {
+				if owner := owners[prefix]; owner != nil {
+					return fmt.Errorf("address spaces of zones %d-%d and %d-%d overlap", owner.Region(), owner.Zone(), r, z)
+				}
+				owners[prefix] = loc
+			}
+		}
+	}
+	return nil
+}
+
+// ZoneAddressSpace returns the address space of the given zone, or false if the
+// location is not a zone of the topology. Without configured address spaces,
+// the address space is split into contiguous ranges of nearly equal size, one
+// per zone in the order of their locations, with the precompiles of a zone at
+// the start of its range.
+func (t *Topology) ZoneAddressSpace(loc Location) (AddressSpace, bool) {
+	if len(loc) != ZONE_CTX || !t.Contains(loc) {
+		return AddressSpace{}, false
+	}
+	if len(t.AddressSpaces) == 0 {
+		var (
+			count = t.Regions * t.Zones
+			index = loc.Region()*t.Zones + loc.Zone()
+			size  = 256 / count
+			extra = 256 % count
+			lo    = index*size + index
+			n     = size + 1
+		)
+		if index >= extra {
+			lo, n = index*size+extra, size
+		}
+		return AddressSpace{Lo: uint8(lo), Hi: uint8(lo + n - 1), Precompile: uint8(lo)}, true
+	}
+	if loc.Region() >= len(t.AddressSpaces) || loc.Zone() >= len(t.AddressSpaces[loc.Region()]) {
+		return AddressSpace{}, false
+	}
+	return t.AddressSpaces[loc.Region()][loc.Zone()], true
+}
+
+// AddressLocation returns the location of the zone whose address space contains
+// the given address, or nil if no zone of the topology owns it.
+func (t *Topology) AddressLocation(b []byte) *Location {
+	if len(b) == 0 {
+		return nil
+	}
+	for r := 0; r < t.Regions; r++ {
+		for z := 0; z < t.Zones; z++ {
+			loc := Location{byte(r), byte(z)}
+			if space, ok := t.ZoneAddressSpace(loc); ok && space.Contains(b[0]) {
+				return &loc
+			}
+		}
+	}
+	return nil
+}
+
+// IsInChainScope reports whether the given address belongs to the chain at the
+// given location. Only zone chains hold accounts.
+func (t *Topology) IsInChainScope(b []byte, location Location) bool {
+	// IsInChainScope only be called for a zone chain
+	if location.Context() != ZONE_CTX {
+		return false
+	}
+	if BytesToHash(b) == ZeroAddr.Hash() {
+		return true
+	}
+	space, ok := t.ZoneAddressSpace(location)
+	if !ok {
+		log.Fatal("unable to get address prefix range for location")
+	}
+	return space.Contains(b[0])
+}
+
+// ContainsAddress reports whether the given address is in the address space of
+// the zone at the given location.
+func (t *Topology) ContainsAddress(location Location, a Address) bool {
+	// ContainAddress can only be called for a zone chain
+	if location.Context() != ZONE_CTX {
+		return false
+	}
+	space, ok := t.ZoneAddressSpace(location)
+	if !ok {
+		log.Fatal("unable to get address prefix range for location")
+	}
+	return space.Contains(a.Bytes()[0])
+}
@@ -46,14 +46,7 @@ const (
 	ZONE_CTX   = 2
 
 	// Depth of the hierarchy of chains
-	NumRegionsInPrime = 3
-	NumZonesInRegion  = 3
-	HierarchyDepth    = 3
-)
-
-var (
-	// Default to prime node, but changed at startup by config.
-	NodeLocation = Location{}
+	HierarchyDepth = 3
 )
 
 var (
@@ -201,34 +194,6 @@ func (h UnprefixedHash) MarshalText() ([]byte, error) {
 
 /////////// Address
 
-type addrPrefixRange struct {
-	lo uint8
-	hi uint8
-}
-
-func NewRange(l, h uint8) addrPrefixRange {
-	return addrPrefixRange{
-		lo: l,
-		hi: h,
-	}
-}
-
-var (
-	locationToPrefixRange = make(map[string]addrPrefixRange)
-)
-
-func init() {
-	locationToPrefixRange["cyprus1"] = NewRange(0, 29)
-	locationToPrefixRange["cyprus2"] = NewRange(30, 58)
-	locationToPrefixRange["cyprus3"] = NewRange(59, 87)
-	locationToPrefixRange["paxos1"] = NewRange(88, 115)
-	locationToPrefixRange["paxos2"] = NewRange(116, 143)
-	locationToPrefixRange["paxos3"] = NewRange(144, 171)
-	locationToPrefixRange["hydra1"] = NewRange(172, 199)
-	locationToPrefixRange["hydra2"] = NewRange(200, 227)
-	locationToPrefixRange["hydra3"] = NewRange(228, 255)
-}
-
 // UnprefixedAddress allows marshaling an Address without 0x prefix.
 type UnprefixedAddress InternalAddress
 
@@ -338,11 +303,8 @@ func (loc Location) AssertValid() {
 	if !loc.HasRegion() && loc.HasZone() {
 		log.Fatal("cannot specify zone without also specifying region.")
 	}
-	if loc.Region() >= NumRegionsInPrime {
-		log.Fatal("region index is not valid.")
-	}
-	if loc.Zone() >= NumZonesInRegion {
-		log.Fatal("zone index is not valid.")
+	if len(loc) >= HierarchyDepth {
+		log.Fatal("location is deeper than the hierarchy.")
 	}
 }
 
@@ -366,9 +328,10 @@ func (loc Location) DomLocation() Location {
 	}
 }
 
-// SubIndex returns the index of the subordinate chain for a given location
-func (loc Location) SubIndex() int {
-	switch NodeLocation.Context() {
+// SubIndex returns the index of the subordinate chain for a given location, as
+// seen from a chain of the given context
+func (loc Location) SubIndex(nodeCtx int) int {
+	switch nodeCtx {
 	case PRIME_CTX:
 		return loc.Region()
 	case REGION_CTX:
@@ -417,7 +380,7 @@ func (loc Location) Name() string {
 	case 2:
 		regionName = "hydra"
 	default:
-		regionName = "unknownregion"
+		regionName = "region" + strconv.Itoa(loc.Region())
 	}
 	zoneNum := strconv.Itoa(loc.Zone() + 1)
 	switch loc.Context() {
@@ -456,20 +419,6 @@ func (loc Location) CommonDom(cmp Location) Location {
 	return common
 }
 
-func (l Location) ContainsAddress(a Address) bool {
-	// ContainAddress can only be called for a zone chain
-	if l.Context() != ZONE_CTX {
-		return false
-	}
-	prefix := a.Bytes()[0]
-	prefixRange, ok := locationToPrefixRange[l.Name()]
-	if !ok {
-		log.Fatal("unable to get address prefix range for location")
-	}
-	// Ranges are fully inclusive
-	return uint8(prefix) >= prefixRange.lo && uint8(prefix) <= prefixRange.hi
-}
-
 func (l Location) RPCMarshal() []hexutil.Uint64 {
 	res := make([]hexutil.Uint64, 0)
 	for _, i := range l {
@@ -479,24 +428,6 @@ func (l Location) RPCMarshal() []hexutil.Uint64 {
 	return res
 }
 
-func IsInChainScope(b []byte) bool {
-	nodeCtx := NodeLocation.Context()
-	// IsInChainScope only be called for a zone chain
-	if nodeCtx != ZONE_CTX {
-		return false
-	}
-	if BytesToHash(b) == ZeroAddr.Hash() {
-		return true
-	}
-	prefix := b[0]
-	prefixRange, ok := locationToPrefixRange[NodeLocation.Name()]
-	if !ok {
-		log.Fatal("unable to get address prefix range for location")
-	}
-	// Ranges are fully inclusive
-	return uint8(prefix) >= prefixRange.lo && uint8(prefix) <= prefixRange.hi
-}
-
 func OrderToString(order int) string {
 	switch order {
 	case PRIME_CTX:
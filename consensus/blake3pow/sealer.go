@@ -240,6 +240,7 @@ func startRemoteSealer(blake3pow *Blake3pow, urls []string, noverify bool) *remo
 }
 
 func (s *remoteSealer) loop() {
+	nodeCtx := s.blake3pow.config.NodeLocation.Context()
 	defer func() {
 		s.blake3pow.config.Log.Trace("Blake3pow remote sealer is exiting")
 		s.cancelNotify()
@@ -299,7 +300,7 @@ func (s *remoteSealer) loop() {
 			// Clear stale pending blocks
 			if s.currentHeader != nil {
 				for hash, header := range s.works {
-					if header.NumberU64()+staleThreshold <= s.currentHeader.NumberU64() {
+					if header.NumberU64(nodeCtx)+staleThreshold <= s.currentHeader.NumberU64(nodeCtx) {
 						delete(s.works, hash)
 					}
 				}
@@ -320,9 +321,10 @@ func (s *remoteSealer) loop() {
 //	result[2], 32 bytes hex encoded boundary condition ("target"), 2^256/difficulty
 //	result[3], hex encoded header number
 func (s *remoteSealer) makeWork(header *types.Header) {
+	nodeCtx := s.blake3pow.config.NodeLocation.Context()
 	hash := header.SealHash()
 	s.currentWork[0] = hash.Hex()
-	s.currentWork[1] = hexutil.EncodeBig(header.Number())
+	s.currentWork[1] = hexutil.EncodeBig(header.Number(nodeCtx))
 	s.currentWork[2] = common.BytesToHash(new(big.Int).Div(big2e256, header.Difficulty()).Bytes()).Hex()
 
 	// Trace the seal work fetched by remote sealer.
@@ -376,6 +378,7 @@ func (s *remoteSealer) sendNotification(ctx context.Context, url string, json []
 // whether the solution was accepted or not (not can be both a bad pow as well as
 // any other error, like no pending work or stale mining result).
 func (s *remoteSealer) submitWork(nonce types.BlockNonce, sealhash common.Hash) bool {
+	nodeCtx := s.blake3pow.config.NodeLocation.Context()
 	if s.currentHeader == nil {
 		s.blake3pow.config.Log.Error("Pending work without block", "sealhash", sealhash)
 		return false
@@ -383,7 +386,7 @@ func (s *remoteSealer) submitWork(nonce types.BlockNonce, sealhash common.Hash)
 	// Make sure the work submitted is present
 	header := s.works[sealhash]
 	if header == nil {
-		s.blake3pow.config.Log.Warn("Work submitted but none pending", "sealhash", sealhash, "curnumber", s.currentHeader.NumberU64())
+		s.blake3pow.config.Log.Warn("Work submitted but none pending", "sealhash", sealhash, "curnumber", s.currentHeader.NumberU64(nodeCtx))
 		return false
 	}
 	// Verify the correctness of submitted result.
@@ -401,10 +404,10 @@ func (s *remoteSealer) submitWork(nonce types.BlockNonce, sealhash common.Hash)
 	solution := header
 
 	// The submitted solution is within the scope of acceptance.
-	if solution.NumberU64()+staleThreshold > s.currentHeader.NumberU64() {
+	if solution.NumberU64(nodeCtx)+staleThreshold > s.currentHeader.NumberU64(nodeCtx) {
 		select {
 		case s.results <- solution:
-			s.blake3pow.config.Log.Debug("Work submitted is acceptable", "number", solution.NumberU64(), "sealhash", sealhash, "hash", solution.Hash())
+			s.blake3pow.config.Log.Debug("Work submitted is acceptable", "number", solution.NumberU64(nodeCtx), "sealhash", sealhash, "hash", solution.Hash())
 			return true
 		default:
 			s.blake3pow.config.Log.Warn("Sealing result is not read by miner", "mode", "remote", "sealhash", sealhash)
@@ -412,6 +415,6 @@ func (s *remoteSealer) submitWork(nonce types.BlockNonce, sealhash common.Hash)
 		}
 	}
 	// The submitted block is too old to accept, drop it.
-	s.blake3pow.config.Log.Warn("Work submitted is too old", "number", solution.NumberU64(), "sealhash", sealhash, "hash", solution.Hash())
+	s.blake3pow.config.Log.Warn("Work submitted is too old", "number", solution.NumberU64(nodeCtx), "sealhash", sealhash, "hash", solution.Hash())
 	return false
 }
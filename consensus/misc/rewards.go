@@ -5,41 +5,49 @@ import (
 	"math/big"
 
 	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/params"
 )
 
 // CalculateReward calculates the coinbase rewards depending on the type of the block
+// The reward is split between the levels of the hierarchy by the shares of the
+// rewards config, and within a level between its chains, as given by the
+// topology of the hierarchy:
 // regions = # of regions
-// zones = # of zones
-// For each prime = Reward/3
-// For each region = Reward/(3*regions*time-factor)
-// For each zone = Reward/(3*regions*zones*time-factor^2)
-func CalculateReward() *big.Int {
-	reward := big.NewInt(5e18)
-	reward.Mul(reward, big.NewInt(1000))
-	timeFactor := big.NewInt(10)
-	regions := big.NewInt(3)
-	zones := big.NewInt(3)
-	context := common.NodeLocation.Context()
-	if context == common.PRIME_CTX {
-		primeReward := big.NewInt(3)
-		primeReward.Div(reward, primeReward)
-		return primeReward
-	} else if context == common.REGION_CTX {
-		regionReward := big.NewInt(3)
-		regionReward.Mul(regionReward, regions)
-		regionReward.Mul(regionReward, timeFactor)
-		regionReward.Div(reward, regionReward)
-		return regionReward
-	} else if context == common.ZONE_CTX {
-		zoneReward := big.NewInt(3)
-		zoneReward.Mul(zoneReward, regions)
-		zoneReward.Mul(zoneReward, zones)
-		zoneReward.Mul(zoneReward, timeFactor)
-		zoneReward.Mul(zoneReward, timeFactor)
-		zoneReward.Div(reward, zoneReward)
-		return zoneReward
-	} else {
+// zones = # of zones in each region
+// share = share of the level / sum of the shares
+// For each prime = Reward*share
+// For each region = Reward*share/(regions*time-factor)
+// For each zone = Reward*share/(regions*zones*time-factor^2)
+// Until the rewards fork activates, the launch rewards are paid instead of the
+// ones of the chain config.
+func CalculateReward(config *params.ChainConfig, header *types.Header) *big.Int {
+	context := config.Location.Context()
+	if context < common.PRIME_CTX || context > common.ZONE_CTX {
 		log.Fatal("unknown node context")
 		return nil
 	}
+	rewards := params.DefaultRewards
+	if config.Rules(header.Number(context), header.Number(common.PRIME_CTX)).IsRewards {
+		rewards = config.GetRewards()
+	}
+	var (
+		topology   = config.GetTopology()
+		timeFactor = new(big.Int).SetUint64(rewards.TimeFactor)
+	)
+	total := new(big.Int)
+	for _, share := range rewards.Shares {
+		total.Add(total, new(big.Int).SetUint64(share))
+	}
+	reward := new(big.Int).Mul(rewards.Reward, new(big.Int).SetUint64(rewards.Shares[context]))
+	divisor := total
+	if context >= common.REGION_CTX {
+		divisor.Mul(divisor, big.NewInt(int64(topology.Regions)))
+		divisor.Mul(divisor, timeFactor)
+	}
+	if context == common.ZONE_CTX {
+		divisor.Mul(divisor, big.NewInt(int64(topology.Zones)))
+		divisor.Mul(divisor, timeFactor)
+	}
+	return reward.Div(reward, divisor)
 }
@@ -52,6 +52,7 @@ var (
 // error types into the consensus package.
 var (
 	errOlderBlockTime      = errors.New("timestamp older than parent")
+	errOutOfScopeCoinbase  = errors.New("coinbase out of scope")
 	errTooManyUncles       = errors.New("too many uncles")
 	errDuplicateUncle      = errors.New("duplicate uncle")
 	errUncleIsAncestor     = errors.New("uncle is ancestor")
@@ -72,16 +73,17 @@ func (progpow *Progpow) Author(header *types.Header) (common.Address, error) {
 // VerifyHeader checks whether a header conforms to the consensus rules of the
 // stock Quai progpow engine.
 func (progpow *Progpow) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Header) error {
+	nodeCtx := progpow.config.NodeLocation.Context()
 	// If we're running a full engine faking, accept any input as valid
 	if progpow.config.PowMode == ModeFullFake {
 		return nil
 	}
 	// Short circuit if the header is known, or its parent not
-	number := header.NumberU64()
+	number := header.NumberU64(nodeCtx)
 	if chain.GetHeader(header.Hash(), number) != nil {
 		return nil
 	}
-	parent := chain.GetHeader(header.ParentHash(), number-1)
+	parent := chain.GetHeader(header.ParentHash(nodeCtx), number-1)
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
@@ -156,10 +158,11 @@ func (progpow *Progpow) VerifyHeaders(chain consensus.ChainHeaderReader, headers
 }
 
 func (progpow *Progpow) verifyHeaderWorker(chain consensus.ChainHeaderReader, headers []*types.Header, index int, unixNow int64) error {
+	nodeCtx := progpow.config.NodeLocation.Context()
 	var parent *types.Header
 	if index == 0 {
-		parent = chain.GetHeader(headers[0].ParentHash(), headers[0].NumberU64()-1)
-	} else if headers[index-1].Hash() == headers[index].ParentHash() {
+		parent = chain.GetHeader(headers[0].ParentHash(nodeCtx), headers[0].NumberU64(nodeCtx)-1)
+	} else if headers[index-1].Hash() == headers[index].ParentHash(nodeCtx) {
 		parent = headers[index-1]
 	}
 	if parent == nil {
@@ -171,6 +174,7 @@ func (progpow *Progpow) verifyHeaderWorker(chain consensus.ChainHeaderReader, he
 // VerifyUncles verifies that the given block's uncles conform to the consensus
 // rules of the stock Quai progpow engine.
 func (progpow *Progpow) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	nodeCtx := progpow.config.NodeLocation.Context()
 	// If we're running a full engine faking, accept any input as valid
 	if progpow.config.PowMode == ModeFullFake {
 		return nil
@@ -185,7 +189,7 @@ func (progpow *Progpow) VerifyUncles(chain consensus.ChainReader, block *types.B
 	// Gather the set of past uncles and ancestors
 	uncles, ancestors := mapset.NewSet(), make(map[common.Hash]*types.Header)
 
-	number, parent := block.NumberU64()-1, block.ParentHash()
+	number, parent := block.NumberU64(nodeCtx)-1, block.ParentHash(nodeCtx)
 	for i := 0; i < 7; i++ {
 		ancestorHeader := chain.GetHeader(parent, number)
 		if ancestorHeader == nil {
@@ -203,7 +207,7 @@ func (progpow *Progpow) VerifyUncles(chain consensus.ChainReader, block *types.B
 				uncles.Add(uncle.Hash())
 			}
 		}
-		parent, number = ancestorHeader.ParentHash(), number-1
+		parent, number = ancestorHeader.ParentHash(nodeCtx), number-1
 	}
 	ancestors[block.Hash()] = block.Header()
 	uncles.Add(block.Hash())
@@ -221,10 +225,10 @@ func (progpow *Progpow) VerifyUncles(chain consensus.ChainReader, block *types.B
 		if ancestors[hash] != nil {
 			return errUncleIsAncestor
 		}
-		if ancestors[uncle.ParentHash()] == nil || uncle.ParentHash() == block.ParentHash() {
+		if ancestors[uncle.ParentHash(nodeCtx)] == nil || uncle.ParentHash(nodeCtx) == block.ParentHash(nodeCtx) {
 			return errDanglingUncle
 		}
-		if err := progpow.verifyHeader(chain, uncle, ancestors[uncle.ParentHash()], true, time.Now().Unix()); err != nil {
+		if err := progpow.verifyHeader(chain, uncle, ancestors[uncle.ParentHash(nodeCtx)], true, time.Now().Unix()); err != nil {
 			return err
 		}
 	}
@@ -233,7 +237,7 @@ func (progpow *Progpow) VerifyUncles(chain consensus.ChainReader, block *types.B
 
 // verifyHeader checks whether a header conforms to the consensus rules
 func (progpow *Progpow) verifyHeader(chain consensus.ChainHeaderReader, header, parent *types.Header, uncle bool, unixNow int64) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := progpow.config.NodeLocation.Context()
 	// Ensure that the header's extra-data section is of a reasonable size
 	if uint64(len(header.Extra())) > params.MaximumExtraDataSize {
 		return fmt.Errorf("extra-data too long: %d > %d", len(header.Extra()), params.MaximumExtraDataSize)
@@ -264,26 +268,26 @@ func (progpow *Progpow) verifyHeader(chain consensus.ChainHeaderReader, header,
 		return fmt.Errorf("order of the block is greater than the context")
 	}
 
-	if !common.NodeLocation.InSameSliceAs(header.Location()) {
+	if !progpow.config.NodeLocation.InSameSliceAs(header.Location()) {
 		return fmt.Errorf("block location is not in the same slice as the node location")
 	}
 	// Verify that the parent entropy is calculated correctly on the header
 	parentEntropy := progpow.TotalLogS(parent)
-	if parentEntropy.Cmp(header.ParentEntropy()) != 0 {
-		return fmt.Errorf("invalid parent entropy: have %v, want %v", header.ParentEntropy(), parentEntropy)
+	if parentEntropy.Cmp(header.ParentEntropy(nodeCtx)) != 0 {
+		return fmt.Errorf("invalid parent entropy: have %v, want %v", header.ParentEntropy(nodeCtx), parentEntropy)
 	}
 	// If not prime, verify the parentDeltaS field as well
 	if nodeCtx > common.PRIME_CTX {
 		_, parentOrder, _ := progpow.CalcOrder(parent)
 		// If parent was dom, deltaS is zero and otherwise should be the calc delta s on the parent
 		if parentOrder < nodeCtx {
-			if common.Big0.Cmp(header.ParentDeltaS()) != 0 {
-				return fmt.Errorf("invalid parent delta s: have %v, want %v", header.ParentDeltaS(), common.Big0)
+			if common.Big0.Cmp(header.ParentDeltaS(nodeCtx)) != 0 {
+				return fmt.Errorf("invalid parent delta s: have %v, want %v", header.ParentDeltaS(nodeCtx), common.Big0)
 			}
 		} else {
 			parentDeltaS := progpow.DeltaLogS(parent)
-			if parentDeltaS.Cmp(header.ParentDeltaS()) != 0 {
-				return fmt.Errorf("invalid parent delta s: have %v, want %v", header.ParentDeltaS(), parentDeltaS)
+			if parentDeltaS.Cmp(header.ParentDeltaS(nodeCtx)) != 0 {
+				return fmt.Errorf("invalid parent delta s: have %v, want %v", header.ParentDeltaS(nodeCtx), parentDeltaS)
 			}
 		}
 	}
@@ -312,9 +316,15 @@ func (progpow *Progpow) verifyHeader(chain consensus.ChainHeaderReader, header,
 			return fmt.Errorf("invalid baseFee: have %s, want %s, parentBaseFee %s, parentGasUsed %d",
 				expectedBaseFee, header.BaseFee(), parent.BaseFee(), parent.GasUsed())
 		}
+		// From the scope fork, the coinbase has to be an account of the zone
+		if chain.Config().Rules(header.Number(nodeCtx), header.Number(common.PRIME_CTX)).IsScope {
+			if _, err := chain.Config().InternalAddress(header.Coinbase()); err != nil {
+				return fmt.Errorf("%w: %v", errOutOfScopeCoinbase, header.Coinbase())
+			}
+		}
 	}
 	// Verify that the block number is parent's +1
-	if diff := new(big.Int).Sub(header.Number(), parent.Number()); diff.Cmp(big.NewInt(1)) != 0 {
+	if diff := new(big.Int).Sub(header.Number(nodeCtx), parent.Number(nodeCtx)); diff.Cmp(big.NewInt(1)) != 0 {
 		return consensus.ErrInvalidNumber
 	}
 	return nil
@@ -324,7 +334,7 @@ func (progpow *Progpow) verifyHeader(chain consensus.ChainHeaderReader, header,
 // the difficulty that a new block should have when created at time
 // given the parent block's time and difficulty.
 func (progpow *Progpow) CalcDifficulty(chain consensus.ChainHeaderReader, parent *types.Header) *big.Int {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := progpow.config.NodeLocation.Context()
 
 	if nodeCtx != common.ZONE_CTX {
 		log.Error("Cannot CalcDifficulty for", "context", nodeCtx)
@@ -341,7 +351,7 @@ func (progpow *Progpow) CalcDifficulty(chain consensus.ChainHeaderReader, parent
 		return parent.Difficulty()
 	}
 
-	parentOfParent := chain.GetHeaderByHash(parent.ParentHash())
+	parentOfParent := chain.GetHeaderByHash(parent.ParentHash(nodeCtx))
 
 	bigTime := new(big.Int).SetUint64(time)
 	bigParentTime := new(big.Int).SetUint64(parentOfParent.Time())
@@ -380,10 +390,11 @@ func (progpow *Progpow) IsDomCoincident(chain consensus.ChainHeaderReader, heade
 	if err != nil {
 		return false
 	}
-	return order < common.NodeLocation.Context()
+	return order < progpow.config.NodeLocation.Context()
 }
 
 func (progpow *Progpow) ComputePowLight(header *types.Header) (mixHash, powHash common.Hash) {
+	nodeCtx := progpow.config.NodeLocation.Context()
 	powLight := func(size uint64, cache []uint32, hash []byte, nonce uint64, blockNumber uint64) ([]byte, []byte) {
 		ethashCache := progpow.cache(blockNumber)
 		if ethashCache.cDag == nil {
@@ -393,8 +404,8 @@ func (progpow *Progpow) ComputePowLight(header *types.Header) (mixHash, powHash
 		}
 		return progpowLight(size, cache, hash, nonce, blockNumber, ethashCache.cDag)
 	}
-	cache := progpow.cache(header.NumberU64())
-	size := datasetSize(header.NumberU64())
+	cache := progpow.cache(header.NumberU64(nodeCtx))
+	size := datasetSize(header.NumberU64(nodeCtx))
 	digest, result := powLight(size, cache.cache, header.SealHash().Bytes(), header.NonceU64(), header.NumberU64(common.ZONE_CTX))
 	mixHash = common.BytesToHash(digest)
 	powHash = common.BytesToHash(result)
@@ -412,10 +423,11 @@ func (progpow *Progpow) ComputePowLight(header *types.Header) (mixHash, powHash
 // either using the usual progpow cache for it, or alternatively using a full DAG
 // to make remote mining fast.
 func (progpow *Progpow) verifySeal(header *types.Header) (common.Hash, error) {
+	nodeCtx := progpow.config.NodeLocation.Context()
 	// If we're running a fake PoW, accept any seal as valid
 	if progpow.config.PowMode == ModeFake || progpow.config.PowMode == ModeFullFake {
 		time.Sleep(progpow.fakeDelay)
-		if progpow.fakeFail == header.Number().Uint64() {
+		if progpow.fakeFail == header.Number(nodeCtx).Uint64() {
 			return common.Hash{}, errInvalidPoW
 		}
 		return common.Hash{}, nil
@@ -455,16 +467,17 @@ func (progpow *Progpow) Prepare(chain consensus.ChainHeaderReader, header *types
 // Finalize implements consensus.Engine, accumulating the block and uncle rewards,
 // setting the final state on the header
 func (progpow *Progpow) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header) {
+	nodeCtx := progpow.config.NodeLocation.Context()
 	// Accumulate any block and uncle rewards and commit the final state root
 	accumulateRewards(chain.Config(), state, header, uncles)
 
-	if common.NodeLocation.Context() == common.ZONE_CTX && header.ParentHash() == chain.Config().GenesisHash {
-		alloc := core.ReadGenesisAlloc("genallocs/gen_alloc_" + common.NodeLocation.Name() + ".json")
+	if progpow.config.NodeLocation.Context() == common.ZONE_CTX && header.ParentHash(nodeCtx) == chain.Config().GenesisHash {
+		alloc := core.ReadGenesisAlloc("genallocs/gen_alloc_" + progpow.config.NodeLocation.Name() + ".json")
 		log.Info("Allocating genesis accounts", "num", len(alloc))
 
 		for addressString, account := range alloc {
 			addr := common.HexToAddress(addressString)
-			internal, err := addr.InternalAddress()
+			internal, err := chain.Config().InternalAddress(addr)
 			if err != nil {
 				log.Error("Provided address in genesis block is out of scope")
 			}
@@ -483,24 +496,25 @@ func (progpow *Progpow) Finalize(chain consensus.ChainHeaderReader, header *type
 // FinalizeAndAssemble implements consensus.Engine, accumulating the block and
 // uncle rewards, setting the final state and assembling the block.
 func (progpow *Progpow) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, etxs []*types.Transaction, subManifest types.BlockManifest, receipts []*types.Receipt) (*types.Block, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := progpow.config.NodeLocation.Context()
 	if nodeCtx == common.ZONE_CTX {
 		// Finalize block
 		progpow.Finalize(chain, header, state, txs, uncles)
 	}
 
 	// Header seems complete, assemble into a block and return
-	return types.NewBlock(header, txs, uncles, etxs, subManifest, receipts, trie.NewStackTrie(nil)), nil
+	return types.NewBlock(header, txs, uncles, etxs, subManifest, receipts, trie.NewStackTrie(nil), nodeCtx), nil
 }
 
 // AccumulateRewards credits the coinbase of the given block with the mining
 // reward. The total reward consists of the static block reward and rewards for
 // included uncles. The coinbase of each uncle block is also rewarded.
 func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	nodeCtx := config.Location.Context()
 	// Select the correct block reward based on chain progression
-	blockReward := misc.CalculateReward()
+	blockReward := misc.CalculateReward(config, header)
 
-	coinbase, err := header.Coinbase().InternalAddress()
+	coinbase, err := config.InternalAddress(header.Coinbase())
 	if err != nil {
 		fmt.Println("Block has out-of-scope coinbase, skipping block reward: " + header.Hash().String())
 		return
@@ -510,13 +524,13 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 	reward := new(big.Int).Set(blockReward)
 	r := new(big.Int)
 	for _, uncle := range uncles {
-		coinbase, err := uncle.Coinbase().InternalAddress()
+		coinbase, err := config.InternalAddress(uncle.Coinbase())
 		if err != nil {
 			fmt.Println("Found uncle with out-of-scope coinbase, skipping reward: " + uncle.Hash().String())
 			continue
 		}
-		r.Add(uncle.Number(), big8)
-		r.Sub(r, header.Number())
+		r.Add(uncle.Number(nodeCtx), big8)
+		r.Sub(r, header.Number(nodeCtx))
 		r.Mul(r, blockReward)
 		r.Div(r, big8)
 		state.AddBalance(coinbase, r)
@@ -162,6 +162,9 @@ type Config struct {
 	// be block header JSON objects instead of work package arrays.
 	NotifyFull bool
 
+	// NodeLocation is the location of the chain this engine is validating
+	NodeLocation common.Location `toml:"-"`
+
 	Log *log.Logger `toml:"-"`
 }
 
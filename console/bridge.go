@@ -0,0 +1,148 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/internal/jsre"
+	"github.com/dominant-strategies/go-quai/rpc"
+	"github.com/dop251/goja"
+)
+
+// bridge is a collection of JavaScript utility methods to bridge the .js runtime
+// environment and the Go RPC connection backing the remote method calls.
+type bridge struct {
+	client *rpc.Client // RPC client to execute Quai requests through
+}
+
+// newBridge creates a new JavaScript wrapper around an RPC client.
+func newBridge(client *rpc.Client) *bridge {
+	return &bridge{client: client}
+}
+
+// jsonrpcCall is a request decoded from the JSON-RPC payload built in JS.
+type jsonrpcCall struct {
+	ID     int64
+	Method string
+	Params []interface{}
+}
+
+// Send implements the provider "send" method, executing a request or a batch of
+// requests. If a callback is given as second argument the responses are passed
+// to it, otherwise they are returned.
+func (b *bridge) Send(call jsre.Call) (goja.Value, error) {
+	// Remarshal the request into a Go value.
+	reqVal, err := call.Argument(0).ToObject(call.VM).MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		rawReq = string(reqVal)
+		dec    = json.NewDecoder(strings.NewReader(rawReq))
+		reqs   []jsonrpcCall
+		batch  bool
+	)
+	dec.UseNumber() // avoid float64s
+	if rawReq[0] == '[' {
+		batch = true
+		err = dec.Decode(&reqs)
+	} else {
+		reqs = make([]jsonrpcCall, 1)
+		err = dec.Decode(&reqs[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+
+	// Execute the requests.
+	var resps []*goja.Object
+	for _, req := range reqs {
+		resp := call.VM.NewObject()
+		resp.Set("jsonrpc", "2.0")
+		resp.Set("id", req.ID)
+
+		var result json.RawMessage
+		if err = b.client.Call(&result, req.Method, req.Params...); err == nil {
+			if result == nil {
+				// Special case null because it is decoded as an empty
+				// raw message for some reason.
+				resp.Set("result", goja.Null())
+			} else {
+				resultVal, err := parseJSON(call.VM, string(result))
+				if err != nil {
+					setError(resp, -32603, err.Error(), nil)
+				} else {
+					resp.Set("result", resultVal)
+				}
+			}
+		} else {
+			code := -32603
+			var data interface{}
+			if err, ok := err.(rpc.Error); ok {
+				code = err.ErrorCode()
+			}
+			if err, ok := err.(rpc.DataError); ok {
+				data = err.ErrorData()
+			}
+			setError(resp, code, err.Error(), data)
+		}
+		resps = append(resps, resp)
+	}
+	// Return the responses either to the callback (if supplied)
+	// or directly as the return value.
+	var result goja.Value
+	if batch {
+		result = call.VM.ToValue(resps)
+	} else {
+		result = resps[0]
+	}
+	if fn, isFunc := goja.AssertFunction(call.Argument(1)); isFunc {
+		fn(goja.Null(), goja.Null(), result)
+		return goja.Undefined(), nil
+	}
+	return result, nil
+}
+
+// Sleep will block the console for the specified number of seconds.
+func (b *bridge) Sleep(call jsre.Call) (goja.Value, error) {
+	if nArgs := len(call.Arguments); nArgs < 1 {
+		return nil, fmt.Errorf("usage: sleep(<number of seconds>)")
+	}
+	sleepObj := call.Argument(0)
+	if goja.IsUndefined(sleepObj) || goja.IsNull(sleepObj) || !isNumber(sleepObj) {
+		return nil, fmt.Errorf("usage: sleep(<number of seconds>)")
+	}
+	sleep := sleepObj.ToFloat()
+	time.Sleep(time.Duration(sleep * float64(time.Second)))
+	return call.VM.ToValue(true), nil
+}
+
+// parseJSON parses a JSON document with the JSON.parse of the runtime.
+func parseJSON(vm *goja.Runtime, doc string) (goja.Value, error) {
+	JSON := vm.Get("JSON").ToObject(vm)
+	parse, callable := goja.AssertFunction(JSON.Get("parse"))
+	if !callable {
+		return nil, fmt.Errorf("JSON.parse is not a function")
+	}
+	return parse(goja.Null(), vm.ToValue(doc))
+}
+
+func setError(resp *goja.Object, code int, msg string, data interface{}) {
+	err := make(map[string]interface{})
+	err["code"] = code
+	err["message"] = msg
+	if data != nil {
+		err["data"] = data
+	}
+	resp.Set("error", err)
+}
+
+// isNumber returns true if input value is a JS number.
+func isNumber(v goja.Value) bool {
+	k := v.ExportType().Kind()
+	return k >= reflect.Int && k <= reflect.Float64
+}
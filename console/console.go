@@ -0,0 +1,421 @@
+// Package console implements the interactive JavaScript console of go-quai,
+// attached to a node over IPC, HTTP or WebSocket.
+package console
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/dominant-strategies/go-quai/cmd/utils"
+	"github.com/dominant-strategies/go-quai/internal/jsre"
+	"github.com/dominant-strategies/go-quai/internal/jsre/deps"
+	"github.com/dominant-strategies/go-quai/internal/web3ext"
+	"github.com/dominant-strategies/go-quai/rpc"
+	"github.com/dop251/goja"
+	"github.com/peterh/liner"
+)
+
+var (
+	// u: unlock, s: signXX, sendXX, n: newAccount, i: importXX
+	passwordRegexp = regexp.MustCompile(`personal.[nusi]`)
+	onlyWhitespace = regexp.MustCompile(`^\s*$`)
+	exit           = regexp.MustCompile(`^\s*exit\s*;*\s*$`)
+)
+
+// HistoryFile is the file within the data directory to store input scrollback.
+const HistoryFile = "history"
+
+// DefaultPrompt is the default prompt line prefix to use for user input querying.
+const DefaultPrompt = "> "
+
+// Config is the collection of configurations to fine tune the behavior of the
+// JavaScript console.
+type Config struct {
+	DataDir  string             // Data directory to store the console history at
+	DocRoot  string             // Filesystem path from where to load JavaScript files from
+	Client   *rpc.Client        // RPC client to execute Quai requests through
+	Prompt   string             // Input prompt prefix string (defaults to DefaultPrompt)
+	Prompter utils.UserPrompter // Input prompter to allow interactive user feedback (defaults to utils.Stdin)
+	Printer  io.Writer          // Output writer to serialize any display strings to (defaults to os.Stdout)
+	Preload  []string           // Absolute paths to JavaScript files to preload
+}
+
+// Console is a JavaScript interpreted runtime environment. It is a fully fledged
+// JavaScript console attached to a running node via an external or in-process RPC
+// client.
+type Console struct {
+	client   *rpc.Client        // RPC client to execute Quai requests through
+	jsre     *jsre.JSRE         // JavaScript runtime environment running the interpreter
+	prompt   string             // Input prompt prefix string
+	prompter utils.UserPrompter // Input prompter to allow interactive user feedback
+	histPath string             // Absolute path to the console scrollback history
+	history  []string           // Scroll history maintained by the console
+	printer  io.Writer          // Output writer to serialize any display strings to
+}
+
+// New initializes a JavaScript interpreted runtime environment and sets defaults
+// with the config struct.
+func New(config Config) (*Console, error) {
+	// Handle unset config values gracefully
+	if config.Prompter == nil {
+		config.Prompter = utils.Stdin
+	}
+	if config.Prompt == "" {
+		config.Prompt = DefaultPrompt
+	}
+	if config.Printer == nil {
+		config.Printer = os.Stdout
+	}
+
+	// Initialize the console and return
+	console := &Console{
+		client:   config.Client,
+		jsre:     jsre.New(config.DocRoot, config.Printer),
+		prompt:   config.Prompt,
+		prompter: config.Prompter,
+		printer:  config.Printer,
+		histPath: filepath.Join(config.DataDir, HistoryFile),
+	}
+	if err := os.MkdirAll(config.DataDir, 0700); err != nil {
+		return nil, err
+	}
+	if err := console.init(config.Preload); err != nil {
+		return nil, err
+	}
+	return console, nil
+}
+
+func (c *Console) init(preload []string) error {
+	c.initConsoleObject()
+
+	// Initialize the JavaScript <-> Go RPC bridge.
+	bridge := newBridge(c.client)
+	if err := c.initQuai(bridge); err != nil {
+		return err
+	}
+	if err := c.initExtensions(); err != nil {
+		return err
+	}
+	c.jsre.Do(func(vm *goja.Runtime) {
+		vm.Set("sleep", jsre.MakeCallback(vm, bridge.Sleep))
+	})
+
+	// Preload JavaScript files.
+	for _, path := range preload {
+		if err := c.jsre.Exec(path); err != nil {
+			failure := err.Error()
+			if gojaErr, ok := err.(*goja.Exception); ok {
+				failure = gojaErr.String()
+			}
+			return fmt.Errorf("%s: %v", path, failure)
+		}
+	}
+
+	// Configure the input prompter for history and tab completion.
+	if c.prompter != nil {
+		if content, err := ioutil.ReadFile(c.histPath); err != nil {
+			c.prompter.SetHistory(nil)
+		} else {
+			c.history = strings.Split(string(content), "\n")
+			c.prompter.SetHistory(c.history)
+		}
+		c.prompter.SetWordCompleter(c.AutoCompleteInput)
+	}
+	return nil
+}
+
+// initConsoleObject binds console.log and console.error to the output writer.
+func (c *Console) initConsoleObject() {
+	c.jsre.Do(func(vm *goja.Runtime) {
+		console := vm.NewObject()
+		console.Set("log", c.consoleOutput)
+		console.Set("error", c.consoleOutput)
+		vm.Set("console", console)
+	})
+}
+
+// initQuai loads the console library and creates the web3 instance sending
+// its requests through the bridge.
+func (c *Console) initQuai(bridge *bridge) error {
+	if err := c.jsre.Compile("quai.js", deps.QuaiJS); err != nil {
+		return fmt.Errorf("quai.js: %v", err)
+	}
+	var err error
+	c.jsre.Do(func(vm *goja.Runtime) {
+		transport := vm.NewObject()
+		transport.Set("send", jsre.MakeCallback(vm, bridge.Send))
+		transport.Set("sendAsync", jsre.MakeCallback(vm, bridge.Send))
+		vm.Set("_consoleTransport", transport)
+		_, err = vm.RunString("var web3 = new Quai(_consoleTransport)")
+	})
+	return err
+}
+
+// initExtensions loads the modules served by the node and exposes each of
+// them as a global variable.
+func (c *Console) initExtensions() error {
+	apis, err := c.client.SupportedModules()
+	if err != nil {
+		return fmt.Errorf("api modules: %v", err)
+	}
+	var names []string
+	for api := range apis {
+		if file, ok := web3ext.Modules[api]; ok {
+			if err = c.jsre.Compile(api+".js", file); err != nil {
+				return fmt.Errorf("%s.js: %v", api, err)
+			}
+			names = append(names, api)
+		}
+	}
+	c.jsre.Do(func(vm *goja.Runtime) {
+		web3 := vm.Get("web3").ToObject(vm)
+		for _, name := range names {
+			if v := web3.Get(name); v != nil {
+				vm.Set(name, v)
+			}
+		}
+	})
+	return nil
+}
+
+// consoleOutput is an override for the console.log and console.error methods to
+// stream the output into the configured output stream instead of stdout.
+func (c *Console) consoleOutput(call goja.FunctionCall) goja.Value {
+	var output []string
+	for _, argument := range call.Arguments {
+		output = append(output, fmt.Sprintf("%v", argument))
+	}
+	fmt.Fprintln(c.printer, strings.Join(output, " "))
+	return goja.Null()
+}
+
+// AutoCompleteInput is a pre-assembled word completer to be used by the user
+// input prompter to provide hints to the user about the methods available.
+func (c *Console) AutoCompleteInput(line string, pos int) (string, []string, string) {
+	// No completions can be provided for empty inputs
+	if len(line) == 0 || pos == 0 {
+		return "", nil, ""
+	}
+	// Chunk data to relevant part for autocompletion
+	// E.g. in case of nested lines quai.getBalance(quai.loc<tab><tab>
+	start := pos - 1
+	for ; start > 0; start-- {
+		// Skip all methods and namespaces (i.e. including the dot)
+		if line[start] == '.' || (line[start] >= 'a' && line[start] <= 'z') || (line[start] >= 'A' && line[start] <= 'Z') {
+			continue
+		}
+		// Handle web3 in a special way (i.e. other numbers aren't auto completed)
+		if start >= 3 && line[start-3:start] == "web3" {
+			start -= 3
+			continue
+		}
+		// We've hit an unexpected character, autocomplete form here
+		start++
+		break
+	}
+	return line[:start], c.jsre.CompleteKeywords(line[start:pos]), line[pos:]
+}
+
+// Welcome shows a summary of the attached node: its version, location, head
+// and the modules it serves.
+func (c *Console) Welcome() {
+	message := "Welcome to the Quai JavaScript console!\n\n"
+
+	// Print some generic node metadata
+	if res, err := c.jsre.Run(`
+		var message = "";
+		try {
+			message += "instance: " + web3.send("web3_clientVersion") + "\n";
+		} catch (err) {}
+		try {
+			message += "location: " + quai.location.name + " (" + quai.location.contextName + ")\n";
+		} catch (err) {}
+		try {
+			var head = quai.getHeader("latest");
+			message += "at block: " + head.number + " (" + new Date(1000 * head.timestamp) + ")\n";
+		} catch (err) {}
+		try {
+			message += " datadir: " + admin.datadir + "\n";
+		} catch (err) {}
+		message
+	`); err == nil {
+		message += res.String()
+	}
+	// List all the supported modules for the user to call
+	if apis, err := c.client.SupportedModules(); err == nil {
+		modules := make([]string, 0, len(apis))
+		for api, version := range apis {
+			modules = append(modules, fmt.Sprintf("%s:%s", api, version))
+		}
+		sort.Strings(modules)
+		message += " modules: " + strings.Join(modules, " ") + "\n"
+	}
+	message += "\nTo exit, press ctrl-d or type exit"
+	fmt.Fprintln(c.printer, message)
+}
+
+// Evaluate executes code and pretty prints the result to the specified output
+// stream.
+func (c *Console) Evaluate(statement string) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(c.printer, "[native] error: %v\n", r)
+		}
+	}()
+	c.jsre.Evaluate(statement, c.printer)
+}
+
+// Interactive starts an interactive user session, where input is prompted from
+// the configured user prompter.
+func (c *Console) Interactive() {
+	var (
+		prompt      = c.prompt             // the current prompt line (used for multi-line inputs)
+		indents     = 0                    // the current number of input indents (used for multi-line inputs)
+		input       = ""                   // the current user input
+		inputLine   = make(chan string, 1) // receives user input
+		inputErr    = make(chan error, 1)  // receives liner errors
+		requestLine = make(chan string)    // requests a line of input
+		interrupt   = make(chan os.Signal, 1)
+	)
+
+	// Monitor Ctrl-C. While liner does turn on the relevant terminal mode bits to avoid
+	// the signal, a signal can still be received for unsupported terminals. Unfortunately
+	// there is no way to cancel the line reader when this happens. The readLines
+	// goroutine will just hang around until another line is read.
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	// The line reader runs in a separate goroutine.
+	go c.readLines(inputLine, inputErr, requestLine)
+	defer close(requestLine)
+
+	for {
+		// Send the next prompt, triggering an input read.
+		requestLine <- prompt
+
+		select {
+		case <-interrupt:
+			fmt.Fprintln(c.printer, "caught interrupt, exiting")
+			return
+
+		case err := <-inputErr:
+			if err == liner.ErrPromptAborted {
+				// Ctrl-C discards the current input, including any multi-line
+				// state, while Ctrl-D ends the session.
+				prompt, indents, input = c.prompt, 0, ""
+				continue
+			}
+			return
+
+		case line := <-inputLine:
+			// User input was returned by the prompter, handle special cases.
+			if indents <= 0 && exit.MatchString(line) {
+				return
+			}
+			if onlyWhitespace.MatchString(line) {
+				continue
+			}
+			// Append the line to the input and check for multi-line interpretation.
+			input += line + "\n"
+			indents = countIndents(input)
+			if indents <= 0 {
+				prompt = c.prompt
+			} else {
+				prompt = strings.Repeat(".", indents*3) + " "
+			}
+			// If all the needed lines are present, save the command and run it.
+			if indents <= 0 {
+				if len(input) > 0 && input[0] != ' ' && !passwordRegexp.MatchString(input) {
+					if command := strings.TrimSpace(input); len(c.history) == 0 || command != c.history[len(c.history)-1] {
+						c.history = append(c.history, command)
+						if c.prompter != nil {
+							c.prompter.AppendHistory(command)
+						}
+					}
+				}
+				c.Evaluate(input)
+				input = ""
+			}
+		}
+	}
+}
+
+// readLines runs in its own goroutine, prompting for input.
+func (c *Console) readLines(input chan<- string, errc chan<- error, prompt <-chan string) {
+	for p := range prompt {
+		line, err := c.prompter.PromptInput(p)
+		if err != nil {
+			errc <- err
+		} else {
+			input <- line
+		}
+	}
+}
+
+// countIndents returns the number of indentations for the given input.
+// In case of invalid input such as var a = } the result can be negative.
+func countIndents(input string) int {
+	var (
+		indents     = 0
+		inString    = false
+		strOpenChar = ' '   // keep track of the string open char to allow var str = "I'm ....";
+		charEscaped = false // keep track if the previous char was the '\' char, allow var str = "abc\"def";
+	)
+
+	for _, c := range input {
+		switch c {
+		case '\\':
+			// indicate next char as escaped when in string and previous char isn't escaping this backslash
+			if !charEscaped && inString {
+				charEscaped = true
+			}
+		case '\'', '"':
+			if inString && !charEscaped && strOpenChar == c { // end string
+				inString = false
+			} else if !inString && !charEscaped { // begin string
+				inString = true
+				strOpenChar = c
+			}
+			charEscaped = false
+		case '{', '(':
+			if !inString { // ignore brackets when in string, allow var str = "a{"; without indenting
+				indents++
+			}
+			charEscaped = false
+		case '}', ')':
+			if !inString {
+				indents--
+			}
+			charEscaped = false
+		default:
+			charEscaped = false
+		}
+	}
+
+	return indents
+}
+
+// Execute runs the JavaScript file specified as the argument.
+func (c *Console) Execute(path string) error {
+	return c.jsre.Exec(path)
+}
+
+// Stop cleans up the console and terminates the runtime environment.
+func (c *Console) Stop(graceful bool) error {
+	if err := ioutil.WriteFile(c.histPath, []byte(strings.Join(c.history, "\n")), 0600); err != nil {
+		return err
+	}
+	if err := os.Chmod(c.histPath, 0600); err != nil { // Force 0600, even if it was different previously
+		return err
+	}
+	c.jsre.Stop(graceful)
+	return nil
+}
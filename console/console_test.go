@@ -0,0 +1,231 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/cmd/utils"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/dominant-strategies/go-quai/rpc"
+)
+
+// hookedPrompter implements UserPrompter to simulate use input via channels.
+type hookedPrompter struct {
+	scheduler chan string
+}
+
+func (p *hookedPrompter) PromptInput(prompt string) (string, error) {
+	// Send the prompt to the tester
+	select {
+	case p.scheduler <- prompt:
+	default:
+		return "", errors.New("aborted by tester")
+	}
+	// Retrieve the response and feed to the console
+	select {
+	case input := <-p.scheduler:
+		return input, nil
+	default:
+		return "", errors.New("aborted by tester")
+	}
+}
+
+func (p *hookedPrompter) PromptPassword(prompt string) (string, error) {
+	return "", errors.New("not implemented")
+}
+func (p *hookedPrompter) PromptConfirm(prompt string) (bool, error) {
+	return false, errors.New("not implemented")
+}
+func (p *hookedPrompter) SetHistory(history []string)                    {}
+func (p *hookedPrompter) AppendHistory(command string)                   {}
+func (p *hookedPrompter) ClearHistory()                                  {}
+func (p *hookedPrompter) SetWordCompleter(completer utils.WordCompleter) {}
+
+// testQuaiAPI is a stand-in for the quai namespace of a zone node.
+type testQuaiAPI struct{}
+
+func (testQuaiAPI) NodeLocation() []hexutil.Uint64 { return []hexutil.Uint64{0, 1} }
+func (testQuaiAPI) ChainId() hexutil.Uint64        { return 9000 }
+func (testQuaiAPI) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(hexutil.MustDecodeBig("0x3b9aca00"))
+}
+
+// tester is a console test environment for the console tests to operate on.
+type tester struct {
+	server   *rpc.Server
+	console  *Console
+	output   *bytes.Buffer
+	prompter *hookedPrompter
+}
+
+// newTester creates a test environment serving the quai namespace over an
+// in-process RPC client, with a console attached to it.
+func newTester(t *testing.T) *tester {
+	server := rpc.NewServer()
+	if err := server.RegisterName("quai", testQuaiAPI{}); err != nil {
+		t.Fatalf("failed to register quai API: %v", err)
+	}
+	prompter := &hookedPrompter{scheduler: make(chan string)}
+	printer := new(bytes.Buffer)
+
+	console, err := New(Config{
+		DataDir:  t.TempDir(),
+		DocRoot:  "testdata",
+		Client:   rpc.DialInProc(server),
+		Prompter: prompter,
+		Printer:  printer,
+	})
+	if err != nil {
+		t.Fatalf("failed to create JavaScript console: %v", err)
+	}
+	return &tester{
+		server:   server,
+		console:  console,
+		output:   printer,
+		prompter: prompter,
+	}
+}
+
+// Close cleans up any temporary data folders and held resources.
+func (env *tester) Close(t *testing.T) {
+	if err := env.console.Stop(false); err != nil {
+		t.Errorf("failed to stop embedded console: %v", err)
+	}
+	env.server.Stop()
+}
+
+// Tests that the node lists the correct welcome message, notably that it
+// contains the location of the node and the modules it serves.
+func TestWelcome(t *testing.T) {
+	tester := newTester(t)
+	defer tester.Close(t)
+
+	tester.console.Welcome()
+
+	output := tester.output.String()
+	if want := "Welcome"; !strings.Contains(output, want) {
+		t.Fatalf("console output missing welcome message: have\n%s\nwant also %s", output, want)
+	}
+	if want := "location: cyprus2 (zone)"; !strings.Contains(output, want) {
+		t.Fatalf("console output missing location: have\n%s\nwant also %s", output, want)
+	}
+	if want := "modules: quai:1.0"; !strings.Contains(output, want) {
+		t.Fatalf("console output missing modules: have\n%s\nwant also %s", output, want)
+	}
+}
+
+// Tests that JavaScript statement evaluation works as intended and that the
+// hierarchy helpers decode the values returned by the node.
+func TestEvaluate(t *testing.T) {
+	tester := newTester(t)
+	defer tester.Close(t)
+
+	tester.console.Evaluate("2 + 2")
+	if output := tester.output.String(); !strings.Contains(output, "4") {
+		t.Fatalf("statement evaluation failed: have %s, want %s", output, "4")
+	}
+	tester.output.Reset()
+
+	tester.console.Evaluate("quai.chainId")
+	if output := tester.output.String(); !strings.Contains(output, "9000") {
+		t.Fatalf("property evaluation failed: have %s, want %s", output, "9000")
+	}
+	tester.output.Reset()
+
+	tester.console.Evaluate("web3.fromWei(quai.gasPrice, 'gwei')")
+	if output := tester.output.String(); !strings.Contains(output, `"1"`) {
+		t.Fatalf("unit conversion failed: have %s, want %s", output, `"1"`)
+	}
+}
+
+// Tests that the JavaScript objects returned by statement executions are properly
+// pretty printed instead of just displaying "[object]".
+func TestPrettyPrint(t *testing.T) {
+	tester := newTester(t)
+	defer tester.Close(t)
+
+	tester.console.Evaluate("obj = {int: 1, string: 'two', list: [3, 3], obj: {null: null, func: function(){}}}")
+
+	// Define some specially formatted fields
+	var (
+		one   = "1"
+		two   = `"two"`
+		three = "3"
+		null  = "null"
+		fun   = "function()"
+	)
+	// Assemble the actual output we're after and verify
+	want := `{
+  int: ` + one + `,
+  string: ` + two + `,
+  list: [` + three + `, ` + three + `],
+  obj: {
+    null: ` + null + `,
+    func: ` + fun + `
+  }
+}
+`
+	if output := tester.output.String(); output != want {
+		t.Fatalf("pretty print mismatch: have %s, want %s", output, want)
+	}
+}
+
+// Tests that the JavaScript exceptions are properly formatted.
+func TestPrettyError(t *testing.T) {
+	tester := newTester(t)
+	defer tester.Close(t)
+	tester.console.Evaluate("throw 'hello'")
+
+	want := "hello"
+	if output := tester.output.String(); !strings.HasPrefix(output, want) {
+		t.Fatalf("pretty error mismatch: have %s, want %s", output, want)
+	}
+}
+
+// Tests that tests if the number of indents for JS input is calculated correct.
+func TestIndenting(t *testing.T) {
+	testCases := []struct {
+		input               string
+		expectedIndentCount int
+	}{
+		{`var a = 1;`, 0},
+		{`"some string"`, 0},
+		{`"some string with (parenthesis`, 0},
+		{`"some string with newline
+		("`, 0},
+		{`function v(a,b) {}`, 0},
+		{`function f(a,b) { var str = "asd("; };`, 0},
+		{`function f(a) {`, 1},
+		{`function f(a, function(b) {`, 2},
+		{`function f(a, function(b) {
+		     var str = "a)}";
+		  });`, 0},
+		{`function f(a,b) {
+		   var str = "a{b(" + a, ", " + b;
+		   }`, 0},
+		{`var str = "\"{"`, 0},
+		{`var str = "'("`, 0},
+		{`var str = "\\{"`, 0},
+		{`var str = "\\\\{"`, 0},
+		{`var str = 'a"{`, 0},
+		{`var obj = {`, 1},
+		{`var obj = { {a:1`, 2},
+		{`var obj = { {a:1}`, 1},
+		{`var obj = { {a:1}, b:2}`, 0},
+		{`var obj = {}`, 0},
+		{`var obj = {
+			a: 1, b: 2
+		}`, 0},
+		{`var test = }`, -1},
+		{`var str = "a\""; var obj = {`, 1},
+	}
+
+	for i, tt := range testCases {
+		counted := countIndents(tt.input)
+		if counted != tt.expectedIndentCount {
+			t.Errorf("test %d: invalid indenting: have %d, want %d", i, counted, tt.expectedIndentCount)
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	interfaces "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/rlp"
+	"github.com/dominant-strategies/go-quai/trie"
+)
+
+const (
+	// ArchiveVersion is the version of the hierarchy archive format written by
+	// ExportArchive. Archives with a newer version are rejected on import.
+	ArchiveVersion = 1
+
+	archiveMagic = "quai-archive"
+)
+
+var (
+	// ErrArchiveMagic is returned when reading a stream which doesn't start with
+	// a hierarchy archive section.
+	ErrArchiveMagic = errors.New("not a hierarchy archive")
+
+	// ErrArchiveVersion is returned when reading an archive written by a newer
+	// version of the format.
+	ErrArchiveVersion = errors.New("unsupported hierarchy archive version")
+)
+
+// ArchiveSection opens the blocks exported by one chain of the hierarchy. An
+// archive is a sequence of sections, so the exports of every node of a
+// hierarchy can simply be concatenated into a single archive.
+type ArchiveSection struct {
+	Magic      string
+	Version    uint64
+	Location   common.Location     // Location of the chain which exported the section
+	Genesis    common.Hash         // Genesis of the exporting chain
+	Count      uint64              // Number of blocks following the section header
+	Checkpoint []rawdb.EtxSetEntry // EtxSet at the parent of the first block, zones only
+}
+
+// ArchiveBlock is a block along with the data of its slice that is not committed
+// to by the block itself, but is needed to replay it.
+type ArchiveBlock struct {
+	Block       *types.Block
+	Termini     []common.Hash             // Termini computed by the exporting chain
+	Manifest    types.BlockManifest       // Manifest of the block, empty in prime
+	InboundEtxs types.Transactions        // ETXs handed to the subs with the block, only if the exporting chain originated its append
+	PendingEtxs []types.PendingEtxs       // Pending ETXs of the block and of the sub blocks it references, prime and regions only
+	Rollups     []types.PendingEtxsRollup // Pending ETX rollups of the sub blocks referenced by the block, prime only
+	EtxSetRoot  common.Hash               // Root of the EtxSet after the block, zones only
+	EtxSet      []rawdb.EtxSetEntry       // Full EtxSet after the block on snapshot heights, zones only
+}
+
+// ArchiveWriter writes hierarchy archive sections into a stream.
+type ArchiveWriter struct {
+	w io.Writer
+}
+
+// NewArchiveWriter creates a writer emitting archive sections into w.
+func NewArchiveWriter(w io.Writer) *ArchiveWriter {
+	return &ArchiveWriter{w: w}
+}
+
+// WriteSection starts a new section. It must be followed by exactly
+// section.Count blocks.
+func (aw *ArchiveWriter) WriteSection(section *ArchiveSection) error {
+	section.Magic, section.Version = archiveMagic, ArchiveVersion
+	return rlp.Encode(aw.w, section)
+}
+
+// WriteBlock writes the next block of the current section.
+func (aw *ArchiveWriter) WriteBlock(block *ArchiveBlock) error {
+	return rlp.Encode(aw.w, block)
+}
+
+// ArchiveReader reads the sections of a hierarchy archive from a stream.
+type ArchiveReader struct {
+	stream    *rlp.Stream
+	remaining uint64 // Number of unread blocks in the current section
+}
+
+// NewArchiveReader creates a reader for the archive in r.
+func NewArchiveReader(r io.Reader) *ArchiveReader {
+	return &ArchiveReader{stream: rlp.NewStream(r, 0)}
+}
+
+// NextSection skips the unread blocks of the current section and returns the
+// header of the next one, or io.EOF at the end of the archive.
+func (ar *ArchiveReader) NextSection() (*ArchiveSection, error) {
+	for ; ar.remaining > 0; ar.remaining-- {
+		if _, err := ar.stream.Raw(); err != nil {
+			return nil, err
+		}
+	}
+	section := new(ArchiveSection)
+	if err := ar.stream.Decode(section); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrArchiveMagic, err)
+	}
+	if section.Magic != archiveMagic {
+		return nil, ErrArchiveMagic
+	}
+	if section.Version > ArchiveVersion {
+		return nil, fmt.Errorf("%w: have %d, support %d", ErrArchiveVersion, section.Version, ArchiveVersion)
+	}
+	ar.remaining = section.Count
+	return section, nil
+}
+
+// NextBlock returns the next block of the current section, or io.EOF at the
+// end of the section.
+func (ar *ArchiveReader) NextBlock() (*ArchiveBlock, error) {
+	if ar.remaining == 0 {
+		return nil, io.EOF
+	}
+	block := new(ArchiveBlock)
+	if err := ar.stream.Decode(block); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	ar.remaining--
+	return block, nil
+}
+
+// etxSetEntries flattens an EtxSet into its storage form, sorted by hash so
+// that exports are deterministic.
+func etxSetEntries(set types.EtxSet) []rawdb.EtxSetEntry {
+	entries := make([]rawdb.EtxSetEntry, 0, len(set))
+	for hash, entry := range set {
+		entries = append(entries, rawdb.EtxSetEntry{EtxHash: hash, EtxHeight: entry.Height, Etx: entry.ETX})
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].EtxHash[:], entries[j].EtxHash[:]) < 0 })
+	return entries
+}
+
+// etxSetFromEntries rebuilds an EtxSet from its storage form.
+func etxSetFromEntries(entries []rawdb.EtxSetEntry) types.EtxSet {
+	set := types.NewEtxSet()
+	for _, entry := range entries {
+		set[entry.EtxHash] = types.EtxSetEntry{Height: entry.EtxHeight, ETX: entry.Etx}
+	}
+	return set
+}
+
+// ExportArchive writes the canonical blocks first to last of this slice as a
+// hierarchy archive section.
+func (c *Core) ExportArchive(w io.Writer, first uint64, last uint64) error {
+	if first > last {
+		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
+	}
+	var (
+		hc      = c.sl.hc
+		nodeCtx = c.NodeCtx()
+		section = &ArchiveSection{
+			Location: c.NodeLocation(),
+			Genesis:  c.sl.config.GenesisHash,
+			Count:    last - first + 1,
+		}
+	)
+	if nodeCtx == common.ZONE_CTX && first > 0 {
+		parent := hc.GetBlockByNumber(first - 1)
+		if parent == nil {
+			return fmt.Errorf("export failed on #%d: not found", first-1)
+		}
+		set := hc.GetEtxSet(parent.Hash(), parent.NumberU64(nodeCtx))
+		if set == nil {
+			return fmt.Errorf("export failed on #%d: etx set not found", first-1)
+		}
+		section.Checkpoint = etxSetEntries(set)
+	}
+	log.Info("Exporting archive section", "location", section.Location.Name(), "count", section.Count)
+
+	aw := NewArchiveWriter(w)
+	if err := aw.WriteSection(section); err != nil {
+		return err
+	}
+	start, reported := time.Now(), time.Now()
+	for nr := first; nr <= last; nr++ {
+		block := hc.GetBlockByNumber(nr)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: not found", nr)
+		}
+		entry, err := c.archiveBlock(block)
+		if err != nil {
+			return fmt.Errorf("export failed on #%d: %v", nr, err)
+		}
+		if err := aw.WriteBlock(entry); err != nil {
+			return err
+		}
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Exporting archive", "exported", nr-first, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	return nil
+}
+
+// archiveBlock gathers the slice data needed to replay the given block.
+func (c *Core) archiveBlock(block *types.Block) (*ArchiveBlock, error) {
+	var (
+		hc      = c.sl.hc
+		nodeCtx = c.NodeCtx()
+		hash    = block.Hash()
+		number  = block.NumberU64(nodeCtx)
+	)
+	entry := &ArchiveBlock{
+		Block:    block,
+		Termini:  hc.GetTerminiByHash(hash),
+		Manifest: rawdb.ReadManifest(c.sl.sliceDb, hash),
+	}
+	if number == 0 {
+		return entry, nil
+	}
+	if nodeCtx == common.ZONE_CTX {
+		diff := rawdb.ReadEtxSetDiff(c.sl.sliceDb, hash, number)
+		if diff == nil {
+			return nil, errors.New("etx set diff not found")
+		}
+		entry.EtxSetRoot = diff.Root
+		if number%c_etxSetSnapshotInterval == 0 {
+			if set := hc.GetEtxSet(hash, number); set != nil {
+				entry.EtxSet = etxSetEntries(set)
+			}
+		}
+		return entry, nil
+	}
+	// Record the ETXs handed down to the subs, if this chain originated the
+	// append of the block. Otherwise they were handed down by our own dom.
+	_, order, err := c.CalcOrder(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	if order == nodeCtx {
+		inbound, _, err := c.sl.CollectNewlyConfirmedEtxs(block, block.Location())
+		if err != nil {
+			return nil, err
+		}
+		entry.InboundEtxs = inbound
+	}
+	// Record the pending ETXs of the block itself, and of every sub block it
+	// references. Prime references the zone blocks through region rollups.
+	pending := append([]common.Hash{hash}, block.SubManifest()...)
+	if nodeCtx == common.PRIME_CTX {
+		for _, subHash := range block.SubManifest() {
+			if rollup, err := hc.GetPendingEtxsRollup(subHash); err == nil {
+				entry.Rollups = append(entry.Rollups, *rollup)
+				pending = append(pending, rollup.Manifest...)
+			}
+		}
+	}
+	seen := make(map[common.Hash]struct{})
+	for _, pendingHash := range pending {
+		if _, ok := seen[pendingHash]; ok {
+			continue
+		}
+		seen[pendingHash] = struct{}{}
+		if pEtxs, err := hc.GetPendingEtxs(pendingHash); err == nil {
+			entry.PendingEtxs = append(entry.PendingEtxs, *pEtxs)
+		}
+	}
+	return entry, nil
+}
+
+// ArchiveImporter replays the chain of this node from the sections of a
+// hierarchy archive. The sections of the dominant chains have to be indexed
+// before the local sections are imported, as they carry the ETXs handed down
+// with every dom coincident block.
+type ArchiveImporter struct {
+	core    *Core
+	inbound map[common.Hash]types.Transactions // Inbound ETXs of dom coincident blocks, by block hash
+}
+
+// NewArchiveImporter creates an importer replaying archives into this slice.
+func (c *Core) NewArchiveImporter() *ArchiveImporter {
+	return &ArchiveImporter{
+		core:    c,
+		inbound: make(map[common.Hash]types.Transactions),
+	}
+}
+
+// IsLocal reports whether the section was exported by a chain at the location
+// of this node, and thus has to be imported.
+func (ai *ArchiveImporter) IsLocal(section *ArchiveSection) bool {
+	return section.Location.Equal(ai.core.NodeLocation())
+}
+
+// IsDom reports whether the section was exported by one of the dominant chains
+// of this node, and thus has to be indexed.
+func (ai *ArchiveImporter) IsDom(section *ArchiveSection) bool {
+	location := ai.core.NodeLocation()
+	return len(section.Location) < len(location) && location.InSameSliceAs(section.Location)
+}
+
+// Index records the data of a dom section needed to replay the local chain.
+func (ai *ArchiveImporter) Index(section *ArchiveSection, ar *ArchiveReader) error {
+	nodeCtx := ai.core.NodeCtx()
+	if !ai.IsDom(section) {
+		return fmt.Errorf("section of %s is not a dom of %s", section.Location.Name(), ai.core.NodeLocation().Name())
+	}
+	if section.Genesis != ai.core.sl.config.GenesisHash {
+		return fmt.Errorf("genesis mismatch in section of %s: have %x, want %x", section.Location.Name(), section.Genesis, ai.core.sl.config.GenesisHash)
+	}
+	for {
+		entry, err := ar.NextBlock()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// Only the chain which originated an append records the inbound ETXs
+		_, order, err := ai.core.CalcOrder(entry.Block.Header())
+		if err != nil {
+			return err
+		}
+		if order == section.Location.Context() && entry.Block.NumberU64(nodeCtx) > 0 {
+			ai.inbound[entry.Block.Hash()] = entry.InboundEtxs
+		}
+	}
+}
+
+// Import replays the blocks of a local section, and checks that the replayed
+// termini and EtxSets match the ones of the exporting chain. It returns the
+// number of blocks appended. The import stops early if stop is closed.
+func (ai *ArchiveImporter) Import(section *ArchiveSection, ar *ArchiveReader, stop <-chan struct{}) (int, error) {
+	var (
+		c       = ai.core
+		hc      = c.sl.hc
+		nodeCtx = c.NodeCtx()
+	)
+	if !ai.IsLocal(section) {
+		return 0, fmt.Errorf("section of %s cannot be imported into %s", section.Location.Name(), c.NodeLocation().Name())
+	}
+	if section.Genesis != c.sl.config.GenesisHash {
+		return 0, fmt.Errorf("genesis mismatch: have %x, want %x", section.Genesis, c.sl.config.GenesisHash)
+	}
+	restore := ai.attachTransports()
+	defer restore()
+
+	var (
+		imported int
+		checked  bool
+	)
+	for {
+		select {
+		case <-stop:
+			return imported, errors.New("interrupted")
+		default:
+		}
+		entry, err := ar.NextBlock()
+		if err == io.EOF {
+			return imported, nil
+		} else if err != nil {
+			return imported, err
+		}
+		block := entry.Block
+		if block.NumberU64(nodeCtx) == 0 {
+			if block.Hash() != c.sl.config.GenesisHash {
+				return imported, fmt.Errorf("genesis mismatch: have %x, want %x", block.Hash(), c.sl.config.GenesisHash)
+			}
+			continue
+		}
+		// Make sure the EtxSet we build on is the one the archive was built on
+		if !checked && nodeCtx == common.ZONE_CTX && section.Checkpoint != nil {
+			want := etxSetFromEntries(section.Checkpoint).Root(trie.NewStackTrie(nil))
+			set := hc.GetEtxSet(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
+			if set == nil {
+				return imported, fmt.Errorf("missing etx set of parent of #%d", block.NumberU64(nodeCtx))
+			}
+			if have := set.Root(trie.NewStackTrie(nil)); have != want {
+				return imported, fmt.Errorf("etx set checkpoint mismatch at #%d: have %x, want %x", block.NumberU64(nodeCtx)-1, have, want)
+			}
+		}
+		checked = true
+
+		if err := ai.replay(entry); err != nil {
+			return imported, fmt.Errorf("block #%d [%x]: %v", block.NumberU64(nodeCtx), block.Hash().Bytes()[:4], err)
+		}
+		imported++
+	}
+}
+
+// replay appends a single archived block and checks the result against the
+// archive.
+func (ai *ArchiveImporter) replay(entry *ArchiveBlock) error {
+	var (
+		c       = ai.core
+		hc      = c.sl.hc
+		nodeCtx = c.NodeCtx()
+		block   = entry.Block
+		hash    = block.Hash()
+		number  = block.NumberU64(nodeCtx)
+	)
+	// Restore the slice data which is not part of the block
+	for _, rollup := range entry.Rollups {
+		if err := c.sl.AddPendingEtxsRollup(rollup); err != nil {
+			return err
+		}
+	}
+	for _, pEtxs := range entry.PendingEtxs {
+		if err := hc.AddPendingEtxs(pEtxs); err != nil && err != ErrPendingEtxAlreadyKnown {
+			return err
+		}
+	}
+	if nodeCtx > common.PRIME_CTX && len(entry.Manifest) > 0 {
+		rawdb.WriteManifest(c.sl.sliceDb, hash, entry.Manifest)
+	}
+	if !rawdb.HasBody(c.sl.sliceDb, hash, number) {
+		c.sl.WriteBlock(block)
+	}
+	// Append the block, either on our own or as if our dom handed it down
+	_, order, err := c.CalcOrder(block.Header())
+	if err != nil {
+		return err
+	}
+	if order == nodeCtx {
+		_, _, err = c.sl.Append(block.Header(), types.EmptyHeader(), common.Hash{}, false, nil)
+	} else {
+		inbound, ok := ai.inbound[hash]
+		if !ok {
+			return fmt.Errorf("dom coincident block missing from the %s section", common.Location(block.Location()[:order]).Name())
+		}
+		parentTermini := hc.GetTerminiByHash(block.ParentHash(nodeCtx))
+		if len(parentTermini) != terminusIndex(c.sl.config)+1 {
+			return ErrSubNotSyncedToDom
+		}
+		_, _, err = c.sl.Append(block.Header(), types.EmptyHeader(), parentTermini[terminusIndex(c.sl.config)], true, inbound)
+	}
+	if err != nil && err != ErrKnownBlock {
+		return err
+	}
+	// Verify the replay ended up where the exporting chain did
+	if termini := hc.GetTerminiByHash(hash); !equalHashes(termini, entry.Termini) {
+		return fmt.Errorf("termini mismatch: have %x, want %x", termini, entry.Termini)
+	}
+	if nodeCtx == common.ZONE_CTX {
+		diff := rawdb.ReadEtxSetDiff(c.sl.sliceDb, hash, number)
+		if diff == nil || diff.Root != entry.EtxSetRoot {
+			return fmt.Errorf("etx set root mismatch: want %x", entry.EtxSetRoot)
+		}
+		if entry.EtxSet != nil {
+			want := etxSetFromEntries(entry.EtxSet).Root(trie.NewStackTrie(nil))
+			if want != diff.Root {
+				return fmt.Errorf("etx set checkpoint mismatch: have %x, want %x", diff.Root, want)
+			}
+		}
+	}
+	return nil
+}
+
+// attachTransports stands in for the missing dom and sub transports of the
+// slice for the duration of an import, and returns a function detaching them.
+func (ai *ArchiveImporter) attachTransports() func() {
+	var (
+		sl      = ai.core.sl
+		nodeCtx = sl.NodeCtx()
+		client  = &archiveClient{core: ai.core}
+		subs    []int
+		dom     bool
+	)
+	if nodeCtx != common.PRIME_CTX && sl.domLink() == nil {
+		sl.SetDomClient(client)
+		dom = true
+	}
+	if nodeCtx != common.ZONE_CTX {
+		for i, subClient := range sl.subLinks() {
+			if subClient == nil {
+				sl.SetSubClient(i, client)
+				subs = append(subs, i)
+			}
+		}
+	}
+	return func() {
+		if dom {
+			sl.SetDomClient(nil)
+		}
+		for _, i := range subs {
+			sl.SetSubClient(i, nil)
+		}
+	}
+}
+
+// archiveClient stands in for the dom and sub transports of a slice replaying
+// an archive. The other chains replay their own sections, so nothing needs to
+// be sent to them. A sub append returns the pending ETXs stored in the archive
+// for the block, and always reports a reorg, as archives only hold canonical
+// blocks.
+type archiveClient struct {
+	core *Core
+}
+
+func (c *archiveClient) Append(ctx context.Context, header *types.Header, domPendingHeader *types.Header, domTerminus common.Hash, domOrigin bool, newInboundEtxs types.Transactions) (types.Transactions, bool, error) {
+	pEtxs, err := c.core.sl.hc.GetPendingEtxs(header.Hash())
+	if err != nil {
+		return nil, true, nil
+	}
+	return pEtxs.Etxs, true, nil
+}
+
+func (c *archiveClient) SubRelayPendingHeader(ctx context.Context, pendingHeader types.PendingHeader, location common.Location) error {
+	return nil
+}
+
+func (c *archiveClient) NewGenesisPendingHeader(ctx context.Context, header *types.Header) error {
+	return nil
+}
+
+func (c *archiveClient) GetManifest(ctx context.Context, blockHash common.Hash) (types.BlockManifest, error) {
+	return nil, errors.New("sub manifests are not available while replaying an archive")
+}
+
+func (c *archiveClient) GetTerminiByHash(ctx context.Context, hash common.Hash) ([]common.Hash, error) {
+	return nil, errors.New("termini of other chains are not available while replaying an archive")
+}
+
+func (c *archiveClient) GenerateRecoveryPendingHeader(ctx context.Context, pendingHeader *types.Header, checkpointHashes []common.Hash) error {
+	return nil
+}
+
+func (c *archiveClient) UpdateBlockLists(ctx context.Context, update types.BlockListUpdate) error {
+	return errors.New("block lists cannot be updated while replaying an archive")
+}
+
+func (c *archiveClient) SendPendingEtxsToDom(ctx context.Context, pEtxs types.PendingEtxs) error {
+	return nil
+}
+
+func (c *archiveClient) SendPendingEtxsRollupToDom(ctx context.Context, pEtxsRollup types.PendingEtxsRollup) error {
+	return nil
+}
+
+func (c *archiveClient) EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error) {
+	return 0, errors.New("external gas estimates are not available while replaying an archive")
+}
+
+// equalHashes reports whether two hash lists are identical.
+func equalHashes(a, b []common.Hash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
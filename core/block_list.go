@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/log"
+)
+
+// badHashes returns the hashes listed as bad at runtime.
+func (sl *Slice) badHashes() []common.Hash {
+	sl.badHashesMu.RLock()
+	defer sl.badHashesMu.RUnlock()
+
+	hashes := make([]common.Hash, 0, len(sl.badHashesCache))
+	for hash := range sl.badHashesCache {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// conflictsWithCheckpoint reports whether the chain has another block trusted
+// at the number of the given header.
+func (sl *Slice) conflictsWithCheckpoint(header *types.Header) bool {
+	nodeCtx := sl.NodeCtx()
+	sl.badHashesMu.RLock()
+	defer sl.badHashesMu.RUnlock()
+
+	hash, ok := sl.checkpoints[header.NumberU64(nodeCtx)]
+	return ok && hash != header.Hash()
+}
+
+// UpdateBlockLists applies the entries of the update which target this chain,
+// and forwards the entries targeting the chains below it to the subordinates
+// if propagate is set. The subordinates are updated first, so that a failure
+// leaves this chain unchanged. Listing a block of the canonical chain as bad,
+// or trusting another block at its number, rewinds the chain below it.
+func (sl *Slice) UpdateBlockLists(update types.BlockListUpdate, propagate bool) error {
+	var (
+		nodeCtx  = sl.NodeCtx()
+		location = sl.NodeLocation()
+		subs     = make(map[int]bool)
+	)
+	for _, target := range update.Locations() {
+		switch {
+		case target.Equal(location):
+		case !propagate:
+			return fmt.Errorf("location %v is not the location of the node %v", target, location)
+		case len(target) <= len(location) || !target[:len(location)].Equal(location) || target.SubIndex(nodeCtx) >= len(sl.subLinks()):
+			return fmt.Errorf("location %v is not below the node %v", target, location)
+		case sl.subLink(target.SubIndex(nodeCtx)) == nil:
+			return fmt.Errorf("subordinate of location %v is not running", target)
+		default:
+			subs[target.SubIndex(nodeCtx)] = true
+		}
+	}
+	for index := range subs {
+		subUpdate := update.Filter(func(target common.Location) bool {
+			return !target.Equal(location) && target.SubIndex(nodeCtx) == index
+		})
+		if err := sl.subLink(index).UpdateBlockLists(context.Background(), subUpdate); err != nil {
+			return err
+		}
+	}
+	sl.applyBlockListUpdate(update.Filter(location.Equal))
+	return nil
+}
+
+// dropConflict forgets that a checkpoint listed the given block as bad, once
+// the block is explicitly listed or unlisted. The caller must hold badHashesMu.
+func (sl *Slice) dropConflict(hash common.Hash) {
+	for number, hashes := range sl.conflicts {
+		kept := hashes[:0]
+		for _, conflict := range hashes {
+			if conflict != hash {
+				kept = append(kept, conflict)
+			}
+		}
+		if len(kept) == 0 {
+			delete(sl.conflicts, number)
+		} else {
+			sl.conflicts[number] = kept
+		}
+	}
+}
+
+// applyBlockListUpdate changes the bad hashes and the checkpoints of this
+// chain and stores them, then rewinds the chain if it contains a bad block.
+// Removing a checkpoint also removes the blocks it listed as bad, unless they
+// were listed or unlisted explicitly since.
+func (sl *Slice) applyBlockListUpdate(update types.BlockListUpdate) {
+	if update.Empty() {
+		return
+	}
+	var bad []common.Hash
+	for _, entry := range update.AddBadHashes {
+		bad = append(bad, entry.Hash)
+	}
+	sl.badHashesMu.Lock()
+	for _, entry := range update.AddBadHashes {
+		sl.dropConflict(entry.Hash)
+	}
+	for _, entry := range update.RemoveCheckpoints {
+		for _, hash := range sl.conflicts[uint64(entry.Number)] {
+			delete(sl.badHashesCache, hash)
+		}
+		delete(sl.conflicts, uint64(entry.Number))
+		delete(sl.checkpoints, uint64(entry.Number))
+	}
+	for _, entry := range update.RemoveBadHashes {
+		delete(sl.badHashesCache, entry.Hash)
+		sl.dropConflict(entry.Hash)
+	}
+	for _, entry := range update.AddCheckpoints {
+		number := uint64(entry.Number)
+		sl.checkpoints[number] = entry.Hash
+		// The canonical block conflicting with the checkpoint is bad
+		if hash := sl.hc.GetCanonicalHash(number); hash != (common.Hash{}) && hash != entry.Hash {
+			bad = append(bad, hash)
+			if !sl.badHashesCache[hash] {
+				sl.conflicts[number] = append(sl.conflicts[number], hash)
+			}
+		}
+	}
+	checkpoints := make([]types.HashAndNumber, 0, len(sl.checkpoints))
+	for number, hash := range sl.checkpoints {
+		checkpoints = append(checkpoints, types.HashAndNumber{Hash: hash, Number: number})
+	}
+	var conflicts []types.HashAndNumber
+	for number, hashes := range sl.conflicts {
+		for _, hash := range hashes {
+			conflicts = append(conflicts, types.HashAndNumber{Hash: hash, Number: number})
+		}
+	}
+	sl.badHashesMu.Unlock()
+
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Number < checkpoints[j].Number
+	})
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Number < conflicts[j].Number
+	})
+	sl.AddToBadHashesList(bad)
+	rawdb.WriteBadHashesList(sl.sliceDb, sl.badHashes())
+	rawdb.WriteCheckpoints(sl.sliceDb, checkpoints)
+	rawdb.WriteCheckpointConflicts(sl.sliceDb, conflicts)
+	log.Info("Updated block lists", "bad", len(update.AddBadHashes), "unbanned", len(update.RemoveBadHashes), "checkpoints", len(update.AddCheckpoints), "removed", len(update.RemoveCheckpoints))
+
+	if len(bad) > 0 {
+		sl.CheckForBadHashAndRecover()
+	}
+}
@@ -53,10 +53,10 @@ func NewBlockValidator(config *params.ChainConfig, headerChain *HeaderChain, eng
 // header's transaction and uncle roots. The headers are assumed to be already
 // validated at this point.
 func (v *BlockValidator) ValidateBody(block *types.Block) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := v.hc.NodeCtx()
 	// Check whether the block's known, and if not, that it's linkable
 	if nodeCtx == common.ZONE_CTX {
-		if v.hc.bc.processor.HasBlockAndState(block.Hash(), block.NumberU64()) {
+		if v.hc.bc.processor.HasBlockAndState(block.Hash(), block.NumberU64(nodeCtx)) {
 			return ErrKnownBlock
 		}
 	}
@@ -93,8 +93,8 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 		if hash := types.DeriveSha(block.ExtTransactions(), trie.NewStackTrie(nil)); hash != header.EtxHash() {
 			return fmt.Errorf("external transaction root hash mismatch: have %x, want %x", hash, header.EtxHash())
 		}
-		if !v.hc.bc.processor.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
-			if !v.hc.bc.HasBlock(block.ParentHash(), block.NumberU64()-1) {
+		if !v.hc.bc.processor.HasBlockAndState(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1) {
+			if !v.hc.bc.HasBlock(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1) {
 				return consensus.ErrUnknownAncestor
 			}
 			return consensus.ErrPrunedAncestor
@@ -42,18 +42,20 @@ type BloomIndexer struct {
 	gen     *bloombits.Generator // generator to rotate the bloom bits crating the bloom index
 	section uint64               // Section is the section number being processed currently
 	head    common.Hash          // Head is the hash of the last header processed
+	nodeCtx int                  // Context of the chain being indexed
 }
 
 // NewBloomIndexer returns a chain indexer that generates bloom bits data for the
 // canonical chain for fast logs filtering.
-func NewBloomIndexer(db ethdb.Database, size, confirms uint64) *ChainIndexer {
+func NewBloomIndexer(db ethdb.Database, size, confirms uint64, nodeCtx int) *ChainIndexer {
 	backend := &BloomIndexer{
-		db:   db,
-		size: size,
+		db:      db,
+		size:    size,
+		nodeCtx: nodeCtx,
 	}
 	table := rawdb.NewTable(db, string(rawdb.BloomBitsIndexPrefix))
 
-	return NewChainIndexer(db, table, backend, size, confirms, bloomThrottling, "bloombits")
+	return NewChainIndexer(db, table, backend, size, confirms, bloomThrottling, "bloombits", nodeCtx)
 }
 
 // Reset implements core.ChainIndexerBackend, starting a new bloombits index
@@ -67,7 +69,7 @@ func (b *BloomIndexer) Reset(ctx context.Context, section uint64, lastSectionHea
 // Process implements core.ChainIndexerBackend, adding a new header's bloom into
 // the index.
 func (b *BloomIndexer) Process(ctx context.Context, header *types.Header, bloom types.Bloom) error {
-	b.gen.AddBloom(uint(header.Number().Uint64()-b.section*b.size), bloom)
+	b.gen.AddBloom(uint(header.Number(b.nodeCtx).Uint64()-b.section*b.size), bloom)
 	b.head = header.Hash()
 	return nil
 }
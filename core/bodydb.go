@@ -43,7 +43,7 @@ type BodyDb struct {
 }
 
 func NewBodyDb(db ethdb.Database, engine consensus.Engine, hc *HeaderChain, chainConfig *params.ChainConfig, cacheConfig *CacheConfig, txLookupLimit *uint64, vmConfig vm.Config) (*BodyDb, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := chainConfig.Location.Context()
 	blockCache, _ := lru.New(blockCacheLimit)
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
@@ -66,33 +66,34 @@ func NewBodyDb(db ethdb.Database, engine consensus.Engine, hc *HeaderChain, chai
 }
 
 // Append
-func (bc *BodyDb) Append(batch ethdb.Batch, block *types.Block, newInboundEtxs types.Transactions) ([]*types.Log, error) {
+func (bc *BodyDb) Append(batch ethdb.Batch, block *types.Block, newInboundEtxs types.Transactions, etxStatuses *etxStatusBatch) ([]*types.Log, error) {
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
 
 	stateApply := time.Now()
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := bc.chainConfig.Location.Context()
 	var logs []*types.Log
 	var err error
 	if nodeCtx == common.ZONE_CTX {
 		// Process our block
-		logs, err = bc.processor.Apply(batch, block, newInboundEtxs)
+		logs, err = bc.processor.Apply(batch, block, newInboundEtxs, etxStatuses)
 		if err != nil {
 			return nil, err
 		}
-		rawdb.WriteTxLookupEntriesByBlock(batch, block)
+		rawdb.WriteTxLookupEntriesByBlock(batch, block, nodeCtx)
 	}
 	log.Info("Time taken to", "apply state:", common.PrettyDuration(time.Since(stateApply)))
 
-	rawdb.WriteBlock(batch, block)
+	rawdb.WriteBlock(batch, block, nodeCtx)
 	return logs, nil
 }
 
 // WriteBlock write the block to the bodydb database
 func (bc *BodyDb) WriteBlock(block *types.Block) {
+	nodeCtx := bc.chainConfig.Location.Context()
 	// add the block to the cache as well
 	bc.blockCache.Add(block.Hash(), block)
-	rawdb.WriteBlock(bc.db, block)
+	rawdb.WriteBlock(bc.db, block, nodeCtx)
 }
 
 // HasBlock checks if a block is fully present in the database or not.
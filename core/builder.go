@@ -0,0 +1,108 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+// TransactionSet is a set of transactions the worker commits one at a time.
+// Peek returns the next transaction, Shift replaces it with the next one of the
+// same account and Pop drops it together with the rest of the account.
+// TransactionsByPriceAndNonce is the set used by the default builder.
+type TransactionSet interface {
+	Peek() *types.Transaction
+	Shift(acc common.AddressBytes, sort bool)
+	Pop()
+}
+
+// BlockBuilder is the policy the worker fills the pending blocks of a zone
+// with. It decides which senders may be included, in which order the pending
+// transactions are committed and how much of the ETX capacity of a block they
+// may use.
+type BlockBuilder interface {
+	// ETXLimits returns the number of cross-region and cross-prime ETXs the
+	// pool transactions of a block on top of parent may emit, given the limits
+	// of the protocol. Limits above the protocol ones are capped, limits below
+	// them reserve the remaining capacity for bundles.
+	ETXLimits(parent *types.Block, region int, prime int) (int, int)
+
+	// AllowSender reports whether the transactions of an account of the zone
+	// may be included, from the pool as well as from bundles. Inbound ETXs are
+	// not subject to it.
+	AllowSender(addr common.Address) bool
+
+	// Order arranges the pending transactions of the allowed senders into the
+	// sets the worker commits in turn, until the block is full.
+	Order(signer types.Signer, baseFee *big.Int, locals, remotes map[common.AddressBytes]types.Transactions) []TransactionSet
+}
+
+// DefaultBlockBuilder is the block building policy of the worker if none is
+// configured. Local transactions are committed ahead of the remote ones, both
+// ordered by price and nonce, and all the ETX capacity is given to the pool.
+type DefaultBlockBuilder struct{}
+
+// ETXLimits implements BlockBuilder, granting the limits of the protocol.
+func (DefaultBlockBuilder) ETXLimits(parent *types.Block, region int, prime int) (int, int) {
+	return region, prime
+}
+
+// AllowSender implements BlockBuilder, allowing every sender.
+func (DefaultBlockBuilder) AllowSender(addr common.Address) bool {
+	return true
+}
+
+// Order implements BlockBuilder, ordering the locals and then the remotes by
+// price and nonce.
+func (DefaultBlockBuilder) Order(signer types.Signer, baseFee *big.Int, locals, remotes map[common.AddressBytes]types.Transactions) []TransactionSet {
+	var sets []TransactionSet
+	if len(locals) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(signer, locals, baseFee, false))
+	}
+	if len(remotes) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(signer, remotes, baseFee, false))
+	}
+	return sets
+}
+
+// allowlistBuilder restricts the senders of another builder.
+type allowlistBuilder struct {
+	BlockBuilder
+	senders map[common.AddressBytes]struct{}
+}
+
+// NewAllowlistBuilder wraps a builder so that only the transactions of the
+// given senders are included.
+func NewAllowlistBuilder(builder BlockBuilder, senders []common.Address) BlockBuilder {
+	allowed := make(map[common.AddressBytes]struct{}, len(senders))
+	for _, sender := range senders {
+		allowed[sender.Bytes20()] = struct{}{}
+	}
+	return &allowlistBuilder{BlockBuilder: builder, senders: allowed}
+}
+
+// AllowSender implements BlockBuilder, allowing the listed senders which are
+// also allowed by the wrapped builder.
+func (b *allowlistBuilder) AllowSender(addr common.Address) bool {
+	if _, ok := b.senders[addr.Bytes20()]; !ok {
+		return false
+	}
+	return b.BlockBuilder.AllowSender(addr)
+}
+
+// CalcETXLimits returns the number of cross-region and cross-prime ETXs a block
+// on top of parent may emit in a hierarchy of the given topology, which grows
+// with the transactions of the parent.
+func CalcETXLimits(parent *types.Block, topology *common.Topology) (int, int) {
+	etxRLimit := len(parent.Transactions()) / params.ETXRegionMaxFraction(topology)
+	if etxRLimit < params.ETXRLimitMin {
+		etxRLimit = params.ETXRLimitMin
+	}
+	etxPLimit := len(parent.Transactions()) / params.ETXPrimeMaxFraction(topology)
+	if etxPLimit < params.ETXPLimitMin {
+		etxPLimit = params.ETXPLimitMin
+	}
+	return etxRLimit, etxPLimit
+}
@@ -0,0 +1,327 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/dominant-strategies/go-quai/log"
+)
+
+const (
+	// maxBundleSize is the maximum number of transactions in a bundle.
+	maxBundleSize = 64
+
+	// maxPendingBundles is the maximum number of bundles kept by the worker.
+	maxPendingBundles = 1024
+
+	// maxBundlesPerSender is the maximum number of pending bundles a sender
+	// may have transactions in.
+	maxBundlesPerSender = 16
+
+	// maxBundleDistance is how many blocks past the current head a bundle may
+	// target.
+	maxBundleDistance = 8
+
+	// bundleLifetime is how long a bundle is kept after its arrival, whether
+	// or not its target block was reached.
+	bundleLifetime = 5 * time.Minute
+)
+
+var (
+	// ErrBundleEmpty is returned if a bundle has no transactions.
+	ErrBundleEmpty = errors.New("bundle has no transactions")
+
+	// ErrBundleTooLarge is returned if a bundle has more transactions than
+	// allowed.
+	ErrBundleTooLarge = errors.New("bundle has too many transactions")
+
+	// ErrBundleStale is returned if a bundle targets a block which is already
+	// part of the chain.
+	ErrBundleStale = errors.New("bundle targets a past block")
+
+	// ErrBundleTooFar is returned if a bundle targets a block too far past the
+	// current head.
+	ErrBundleTooFar = errors.New("bundle targets a block too far in the future")
+
+	// ErrBundlePoolFull is returned if the worker keeps too many bundles to
+	// accept another one.
+	ErrBundlePoolFull = errors.New("too many pending bundles")
+
+	// ErrBundleSenderLimit is returned if a sender of a bundle already has too
+	// many pending bundles.
+	ErrBundleSenderLimit = errors.New("too many pending bundles of the sender")
+
+	// ErrBundleKnown is returned if the same bundle was already submitted.
+	ErrBundleKnown = errors.New("bundle already known")
+
+	// ErrBundleReverted is returned if a transaction of a bundle reverts, which
+	// excludes the whole bundle.
+	ErrBundleReverted = errors.New("bundle transaction reverted")
+
+	// ErrSenderNotAllowed is returned if the block builder does not include
+	// the transactions of a sender.
+	ErrSenderNotAllowed = errors.New("sender not allowed by the block builder")
+)
+
+// Bundle is an ordered list of transactions which is included atomically: all
+// of its transactions are committed in a row at the top of the target block,
+// or none of them is.
+type Bundle struct {
+	Txs          types.Transactions
+	BlockNumber  *big.Int // Number of the zone block the bundle is included in
+	MinTimestamp uint64   // Earliest timestamp of the including block, 0 for any
+	MaxTimestamp uint64   // Latest timestamp of the including block, 0 for any
+
+	senders []common.AddressBytes // Distinct senders of the transactions, set once queued
+	arrival time.Time             // Time the bundle was queued
+}
+
+// Hash returns the identifier of the bundle, the hash of its transaction
+// hashes.
+func (b *Bundle) Hash() common.Hash {
+	blob := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		blob = append(blob, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(blob)
+}
+
+// validate checks the bundle is well formed.
+func (b *Bundle) validate() error {
+	if len(b.Txs) == 0 {
+		return ErrBundleEmpty
+	}
+	if len(b.Txs) > maxBundleSize {
+		return ErrBundleTooLarge
+	}
+	if b.BlockNumber == nil {
+		return errors.New("bundle has no target block")
+	}
+	if b.MaxTimestamp != 0 && b.MaxTimestamp < b.MinTimestamp {
+		return errors.New("bundle timestamp range is empty")
+	}
+	for _, tx := range b.Txs {
+		if tx.Type() == types.ExternalTxType {
+			return fmt.Errorf("bundle transaction %x: %w", tx.Hash(), ErrTxTypeNotSupported)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the bundle may be included in a block with the given
+// header.
+func (b *Bundle) matches(header *types.Header, nodeCtx int) bool {
+	if b.BlockNumber.Cmp(header.Number(nodeCtx)) != 0 {
+		return false
+	}
+	if b.MinTimestamp != 0 && header.Time() < b.MinTimestamp {
+		return false
+	}
+	if b.MaxTimestamp != 0 && header.Time() > b.MaxTimestamp {
+		return false
+	}
+	return true
+}
+
+// BundleResult is the outcome of simulating a bundle on top of the current
+// head, as its transactions would execute at the top of the next block.
+type BundleResult struct {
+	BlockNumber *big.Int         // Number of the block the bundle was simulated in
+	PrimeNumber *big.Int         // Number in prime of the block the bundle was simulated in
+	Receipts    []*types.Receipt // Receipts of the executed transactions
+	Err         error            // Reason the bundle would not be included, if any
+}
+
+// addBundle queues a bundle for inclusion in its target block, which has to be
+// within a few blocks of the current head. Every sender of the bundle is
+// charged for it against the per sender limit.
+func (w *worker) addBundle(bundle *Bundle) error {
+	nodeCtx := w.hc.NodeCtx()
+	if err := bundle.validate(); err != nil {
+		return err
+	}
+	head := w.hc.CurrentHeader()
+	if bundle.BlockNumber.Cmp(head.Number(nodeCtx)) <= 0 {
+		return ErrBundleStale
+	}
+	if new(big.Int).Sub(bundle.BlockNumber, head.Number(nodeCtx)).Cmp(big.NewInt(maxBundleDistance)) > 0 {
+		return ErrBundleTooFar
+	}
+	signer := types.MakeSigner(w.chainConfig, bundle.BlockNumber, head.Number(common.PRIME_CTX))
+	senders := make(map[common.AddressBytes]struct{})
+	for _, tx := range bundle.Txs {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return fmt.Errorf("bundle transaction %x: %w", tx.Hash(), err)
+		}
+		if _, ok := senders[from.Bytes20()]; !ok {
+			senders[from.Bytes20()] = struct{}{}
+			bundle.senders = append(bundle.senders, from.Bytes20())
+		}
+	}
+	w.bundleMu.Lock()
+	defer w.bundleMu.Unlock()
+
+	now := time.Now()
+	w.dropBundles(head.Number(nodeCtx), now)
+
+	hash := bundle.Hash()
+	counts := make(map[common.AddressBytes]int)
+	for _, known := range w.bundles {
+		if known.BlockNumber.Cmp(bundle.BlockNumber) == 0 && known.Hash() == hash {
+			return ErrBundleKnown
+		}
+		for _, from := range known.senders {
+			counts[from]++
+		}
+	}
+	for _, from := range bundle.senders {
+		if counts[from] >= maxBundlesPerSender {
+			return ErrBundleSenderLimit
+		}
+	}
+	if len(w.bundles) >= maxPendingBundles {
+		return ErrBundlePoolFull
+	}
+	bundle.arrival = now
+	w.bundles = append(w.bundles, bundle)
+	log.Debug("Queued bundle", "hash", hash, "txs", len(bundle.Txs), "number", bundle.BlockNumber)
+	return nil
+}
+
+// dropBundles drops the bundles targeting blocks up to the given number and
+// the ones which outlived the bundle lifetime. The bundle lock must be held.
+func (w *worker) dropBundles(number *big.Int, now time.Time) {
+	kept := w.bundles[:0]
+	for _, bundle := range w.bundles {
+		if bundle.BlockNumber.Cmp(number) <= 0 || now.Sub(bundle.arrival) > bundleLifetime {
+			continue
+		}
+		kept = append(kept, bundle)
+	}
+	for i := len(kept); i < len(w.bundles); i++ {
+		w.bundles[i] = nil
+	}
+	w.bundles = kept
+}
+
+// pendingBundles drops the bundles targeting blocks before the given one or
+// which expired, and returns those which may be included in it, in the order
+// of their arrival.
+func (w *worker) pendingBundles(header *types.Header) []*Bundle {
+	nodeCtx := w.hc.NodeCtx()
+	w.bundleMu.Lock()
+	defer w.bundleMu.Unlock()
+
+	w.dropBundles(new(big.Int).Sub(header.Number(nodeCtx), common.Big1), time.Now())
+
+	var matches []*Bundle
+	for _, bundle := range w.bundles {
+		if bundle.matches(header, nodeCtx) {
+			matches = append(matches, bundle)
+		}
+	}
+	return matches
+}
+
+// commitBundle commits all the transactions of a bundle to the environment, or
+// none of them if any fails or reverts. The receipts of the transactions are
+// returned, up to and including the failing one.
+func (w *worker) commitBundle(env *environment, bundle *Bundle) ([]*types.Receipt, error) {
+	// The journal of the state is flushed after every transaction, so the state
+	// before the bundle is kept as a copy rather than as a snapshot
+	var (
+		state     = env.state.Copy()
+		gas       = env.gasPool.Gas()
+		gasUsed   = env.header.GasUsed()
+		etxRLimit = env.etxRLimit
+		etxPLimit = env.etxPLimit
+		txs       = len(env.txs)
+		receipts  = len(env.receipts)
+		etxs      = len(env.etxs)
+		tcount    = env.tcount
+	)
+	revert := func() {
+		env.state = state
+		*env.gasPool = GasPool(gas)
+		env.header.SetGasUsed(gasUsed)
+		env.etxRLimit, env.etxPLimit = etxRLimit, etxPLimit
+		env.txs, env.receipts, env.etxs = env.txs[:txs], env.receipts[:receipts], env.etxs[:etxs]
+		env.tcount = tcount
+	}
+	w.mu.RLock()
+	builder := w.builder
+	w.mu.RUnlock()
+
+	var applied []*types.Receipt
+	for _, tx := range bundle.Txs {
+		from, err := types.Sender(env.signer, tx)
+		if err == nil && !builder.AllowSender(from) {
+			err = ErrSenderNotAllowed
+		}
+		if err == nil {
+			env.state.Prepare(tx.Hash(), env.tcount)
+			_, err = w.commitTransaction(env, tx)
+		}
+		if err == nil {
+			env.tcount++
+			receipt := env.receipts[len(env.receipts)-1]
+			applied = append(applied, receipt)
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				err = ErrBundleReverted
+			}
+		}
+		if err != nil {
+			revert()
+			return applied, fmt.Errorf("bundle transaction %x: %w", tx.Hash(), err)
+		}
+	}
+	return applied, nil
+}
+
+// commitBundles commits the bundles targeting the block of the environment, in
+// the order they were received, skipping the ones which cannot be included.
+func (w *worker) commitBundles(env *environment) {
+	for _, bundle := range w.pendingBundles(env.header) {
+		if _, err := w.commitBundle(env, bundle); err != nil {
+			log.Debug("Skipping bundle", "hash", bundle.Hash(), "err", err)
+		}
+	}
+}
+
+// simulateBundle executes a bundle at the top of a block on top of the current
+// head, which is the pending state bundles are included against.
+func (w *worker) simulateBundle(bundle *Bundle) (*BundleResult, error) {
+	nodeCtx := w.hc.NodeCtx()
+	if len(bundle.Txs) == 0 {
+		return nil, ErrBundleEmpty
+	}
+	if len(bundle.Txs) > maxBundleSize {
+		return nil, ErrBundleTooLarge
+	}
+	if w.hc.NodeCtx() != common.ZONE_CTX {
+		return nil, errors.New("bundles can only be simulated in a zone")
+	}
+	parent := w.hc.CurrentBlock()
+	env, err := w.prepareWork(&generateParams{coinbase: w.coinbase}, parent)
+	if err != nil {
+		return nil, err
+	}
+	defer env.discard()
+
+	w.adjustGasLimit(nil, env, parent)
+	env.gasPool = new(GasPool).AddGas(env.header.GasLimit())
+
+	receipts, err := w.commitBundle(env, bundle)
+	return &BundleResult{
+		BlockNumber: env.header.Number(nodeCtx),
+		PrimeNumber: env.header.Number(common.PRIME_CTX),
+		Receipts:    receipts,
+		Err:         err,
+	}, nil
+}
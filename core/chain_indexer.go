@@ -90,6 +90,7 @@ type ChainIndexer struct {
 	cascadedHead   uint64 // Block number of the last completed section cascaded to subindexers
 
 	throttling time.Duration // Disk throttling to prevent a heavy upgrade from hogging resources
+	nodeCtx    int           // Context of the chain being indexed
 
 	log  log.Logger
 	lock sync.Mutex
@@ -98,7 +99,7 @@ type ChainIndexer struct {
 // NewChainIndexer creates a new chain indexer to do background processing on
 // chain segments of a given size after certain number of confirmations passed.
 // The throttling parameter might be used to prevent database thrashing.
-func NewChainIndexer(chainDb ethdb.Database, indexDb ethdb.Database, backend ChainIndexerBackend, section, confirm uint64, throttling time.Duration, kind string) *ChainIndexer {
+func NewChainIndexer(chainDb ethdb.Database, indexDb ethdb.Database, backend ChainIndexerBackend, section, confirm uint64, throttling time.Duration, kind string, nodeCtx int) *ChainIndexer {
 	c := &ChainIndexer{
 		chainDb:     chainDb,
 		indexDb:     indexDb,
@@ -108,6 +109,7 @@ func NewChainIndexer(chainDb ethdb.Database, indexDb ethdb.Database, backend Cha
 		sectionSize: section,
 		confirmsReq: confirm,
 		throttling:  throttling,
+		nodeCtx:     nodeCtx,
 		log:         log.Log,
 	}
 	// Initialize database dependent fields and start the updater
@@ -172,13 +174,14 @@ func (c *ChainIndexer) Close() error {
 // started for the outermost indexer to push chain head events into a processing
 // queue.
 func (c *ChainIndexer) eventLoop(currentHeader *types.Header, events chan ChainHeadEvent, sub event.Subscription) {
+	nodeCtx := c.nodeCtx
 	// Mark the chain indexer as active, requiring an additional teardown
 	atomic.StoreUint32(&c.active, 1)
 
 	defer sub.Unsubscribe()
 
 	// Fire the initial new head event to start any outstanding processing
-	c.newHead(currentHeader.Number().Uint64(), false)
+	c.newHead(currentHeader.Number(nodeCtx).Uint64(), false)
 
 	var (
 		prevHeader = currentHeader
@@ -199,17 +202,17 @@ func (c *ChainIndexer) eventLoop(currentHeader *types.Header, events chan ChainH
 				return
 			}
 			header := ev.Block.Header()
-			if header.ParentHash() != prevHash {
+			if header.ParentHash(nodeCtx) != prevHash {
 				// Reorg to the common ancestor if needed (might not exist in light sync mode, skip reorg then)
 				// TODO: This seems a bit brittle, can we detect this case explicitly?
 
-				if rawdb.ReadCanonicalHash(c.chainDb, prevHeader.Number().Uint64()) != prevHash {
-					if h := rawdb.FindCommonAncestor(c.chainDb, prevHeader, header); h != nil {
-						c.newHead(h.Number().Uint64(), true)
+				if rawdb.ReadCanonicalHash(c.chainDb, prevHeader.Number(nodeCtx).Uint64()) != prevHash {
+					if h := rawdb.FindCommonAncestor(c.chainDb, prevHeader, header, nodeCtx); h != nil {
+						c.newHead(h.Number(nodeCtx).Uint64(), true)
 					}
 				}
 			}
-			c.newHead(header.Number().Uint64(), false)
+			c.newHead(header.Number(nodeCtx).Uint64(), false)
 
 			prevHeader, prevHash = header, header.Hash()
 		}
@@ -345,6 +348,7 @@ func (c *ChainIndexer) updateLoop() {
 // held while processing, the continuity can be broken by a long reorg, in which
 // case the function returns with an error.
 func (c *ChainIndexer) processSection(section uint64, lastHead common.Hash) (common.Hash, error) {
+	nodeCtx := c.nodeCtx
 	c.log.Trace("Processing new chain section", "section", section)
 
 	// Reset and partial processing
@@ -361,7 +365,7 @@ func (c *ChainIndexer) processSection(section uint64, lastHead common.Hash) (com
 		header := rawdb.ReadHeader(c.chainDb, hash, number)
 		if header == nil {
 			return common.Hash{}, fmt.Errorf("block #%d [%x..] not found", number, hash[:4])
-		} else if header.ParentHash() != lastHead {
+		} else if header.ParentHash(nodeCtx) != lastHead {
 			return common.Hash{}, fmt.Errorf("chain reorged during section processing")
 		}
 		bloom, err := c.GetBloom(header.Hash())
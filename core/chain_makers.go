@@ -117,7 +117,7 @@ func (b *BlockGen) AddTxWithChain(hc *HeaderChain, tx *types.Transaction, etxRLi
 
 // GetBalance returns the balance of the given address at the generated block.
 func (b *BlockGen) GetBalance(addr common.Address) *big.Int {
-	internal, err := addr.InternalAddress()
+	internal, err := b.config.InternalAddress(addr)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -135,7 +135,8 @@ func (b *BlockGen) AddUncheckedTx(tx *types.Transaction) {
 
 // Number returns the block number of the block being generated.
 func (b *BlockGen) Number() *big.Int {
-	return new(big.Int).Set(b.header.Number())
+	nodeCtx := b.config.Location.Context()
+	return new(big.Int).Set(b.header.Number(nodeCtx))
 }
 
 // BaseFee returns the base fee of the block being generated.
@@ -155,7 +156,7 @@ func (b *BlockGen) AddUncheckedReceipt(receipt *types.Receipt) {
 // TxNonce returns the next valid transaction nonce for the
 // account at addr. It panics if the account does not exist.
 func (b *BlockGen) TxNonce(addr common.Address) uint64 {
-	internal, err := addr.InternalAddress()
+	internal, err := b.config.InternalAddress(addr)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -251,6 +252,7 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 }
 
 func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.StateDB, engine consensus.Engine) *types.Header {
+	nodeCtx := chain.Config().Location.Context()
 	var time uint64
 	if parent.Time() == 0 {
 		time = 10
@@ -261,18 +263,18 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 	// Temporary header values just to calc difficulty
 	diffheader := types.EmptyHeader()
 	diffheader.SetDifficulty(parent.Difficulty())
-	diffheader.SetNumber(parent.Number())
+	diffheader.SetNumber(parent.Number(nodeCtx), nodeCtx)
 	diffheader.SetTime(time - 10)
 	diffheader.SetUncleHash(parent.UncleHash())
 
 	// Make new header
 	header := types.EmptyHeader()
 	header.SetRoot(state.IntermediateRoot(true))
-	header.SetParentHash(parent.Hash())
+	header.SetParentHash(parent.Hash(), nodeCtx)
 	header.SetCoinbase(parent.Coinbase())
 	header.SetDifficulty(engine.CalcDifficulty(chain, diffheader))
 	header.SetGasLimit(parent.GasLimit())
-	header.SetNumber(new(big.Int).Add(parent.Number(), common.Big1))
+	header.SetNumber(new(big.Int).Add(parent.Number(nodeCtx), common.Big1), nodeCtx)
 	header.SetTime(time)
 	header.SetBaseFee(misc.CalcBaseFee(chain.Config(), parent.Header()))
 	return header
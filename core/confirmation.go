@@ -0,0 +1,112 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+)
+
+// ConfirmationStatus describes how final a block is within the hierarchy. A
+// block is much harder to revert once a coincident block of a dom chain is
+// built on top of it, as reverting it then requires a reorg of the dom chain
+// too.
+type ConfirmationStatus struct {
+	Hash      common.Hash
+	Number    uint64
+	Order     int      // Order of the block itself
+	Canonical bool     // Whether the block is part of the canonical chain
+	Depth     uint64   // Number of canonical blocks built on top of the block
+	Region    bool     // Whether a canonical region coincident block covers the block
+	Prime     bool     // Whether a canonical prime coincident block covers the block
+	Safe      bool     // Whether the block is at or below the current safe header
+	Finalized bool     // Whether the block is at or below the current finalized header
+	Entropy   *big.Int // Entropy accumulated by the canonical chain on top of the block
+}
+
+// CurrentCoincidentHeader returns the most recent canonical block which is
+// coincident with the chain of the given order, i.e. whose order is at most the
+// given one. The chain is walked back through the termini, which link every
+// block to the last dom coincident block before it. For orders at or below the
+// context of the node, every block qualifies and the current header is
+// returned.
+func (hc *HeaderChain) CurrentCoincidentHeader(order int) *types.Header {
+	nodeCtx := hc.NodeCtx()
+	head := hc.CurrentHeader()
+	if order >= hc.NodeCtx() {
+		return head
+	}
+	termini := hc.GetTerminiByHash(head.Hash())
+	for {
+		if len(termini) <= terminusIndex(hc.config) {
+			return nil
+		}
+		hash := termini[terminusIndex(hc.config)]
+		if hash == hc.config.GenesisHash {
+			return hc.genesisHeader
+		}
+		header := hc.GetHeaderByHash(hash)
+		if header == nil {
+			return nil
+		}
+		_, blockOrder, err := hc.engine.CalcOrder(header)
+		if err != nil {
+			return nil
+		}
+		if blockOrder <= order {
+			return header
+		}
+		termini = hc.GetTerminiByHash(header.ParentHash(nodeCtx))
+	}
+}
+
+// CurrentSafeHeader returns the most recent canonical block covered by a block
+// of the dom chain directly above the node.
+func (hc *HeaderChain) CurrentSafeHeader() *types.Header {
+	order := hc.NodeCtx() - 1
+	if order < common.PRIME_CTX {
+		order = common.PRIME_CTX
+	}
+	return hc.CurrentCoincidentHeader(order)
+}
+
+// CurrentFinalizedHeader returns the most recent canonical block covered by a
+// prime block.
+func (hc *HeaderChain) CurrentFinalizedHeader() *types.Header {
+	return hc.CurrentCoincidentHeader(common.PRIME_CTX)
+}
+
+// ConfirmationStatus returns the confirmation status of a block.
+func (hc *HeaderChain) ConfirmationStatus(header *types.Header) (*ConfirmationStatus, error) {
+	nodeCtx := hc.NodeCtx()
+	_, order, err := hc.engine.CalcOrder(header)
+	if err != nil && header.Hash() != hc.config.GenesisHash {
+		return nil, err
+	}
+	status := &ConfirmationStatus{
+		Hash:      header.Hash(),
+		Number:    header.NumberU64(nodeCtx),
+		Order:     order,
+		Canonical: hc.GetCanonicalHash(header.NumberU64(nodeCtx)) == header.Hash(),
+		Entropy:   new(big.Int),
+	}
+	if !status.Canonical {
+		return status, nil
+	}
+	head := hc.CurrentHeader()
+	if head.NumberU64(nodeCtx) < status.Number {
+		return nil, errors.New("block is ahead of the current header")
+	}
+	status.Depth = head.NumberU64(nodeCtx) - status.Number
+	status.Entropy.Sub(hc.engine.TotalLogS(head), hc.engine.TotalLogS(header))
+
+	covered := func(coincident *types.Header) bool {
+		return coincident != nil && coincident.NumberU64(nodeCtx) >= status.Number
+	}
+	status.Region = covered(hc.CurrentCoincidentHeader(common.REGION_CTX))
+	status.Prime = covered(hc.CurrentCoincidentHeader(common.PRIME_CTX))
+	status.Safe = covered(hc.CurrentSafeHeader())
+	status.Finalized = covered(hc.CurrentFinalizedHeader())
+	return status, nil
+}
@@ -1,12 +1,14 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math/big"
 	"sort"
 	"time"
 
+	interfaces "github.com/dominant-strategies/go-quai"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/consensus"
 	"github.com/dominant-strategies/go-quai/core/rawdb"
@@ -39,8 +41,8 @@ type Core struct {
 	quit chan struct{} // core quit channel
 }
 
-func NewCore(db ethdb.Database, config *Config, isLocalBlock func(block *types.Header) bool, txConfig *TxPoolConfig, txLookupLimit *uint64, chainConfig *params.ChainConfig, domClientUrl string, subClientUrls []string, engine consensus.Engine, cacheConfig *CacheConfig, vmConfig vm.Config, genesis *Genesis) (*Core, error) {
-	slice, err := NewSlice(db, config, txConfig, txLookupLimit, isLocalBlock, chainConfig, domClientUrl, subClientUrls, engine, cacheConfig, vmConfig, genesis)
+func NewCore(db ethdb.Database, config *Config, isLocalBlock func(block *types.Header) bool, txConfig *TxPoolConfig, txLookupLimit *uint64, chainConfig *params.ChainConfig, domClientUrl string, subClientUrls []string, jwtSecret []byte, engine consensus.Engine, cacheConfig *CacheConfig, vmConfig vm.Config, genesis *Genesis) (*Core, error) {
+	slice, err := NewSlice(db, config, txConfig, txLookupLimit, isLocalBlock, chainConfig, domClientUrl, subClientUrls, jwtSecret, engine, cacheConfig, vmConfig, genesis)
 	if err != nil {
 		return nil, err
 	}
@@ -54,16 +56,43 @@ func NewCore(db ethdb.Database, config *Config, isLocalBlock func(block *types.H
 	appendQueue, _ := lru.New(c_maxAppendQueue)
 	c.appendQueue = appendQueue
 
-	go c.updateAppendQueue()
+	// Append again the blocks rewound by the startup repair of the slice
+	c.enqueueReplay()
+
+	// Synchronous cores are driven by their caller, so blocks which could not be
+	// appended yet are not retried in the background
+	if config == nil || !config.Synchronous {
+		go c.updateAppendQueue()
+	}
 	return c, nil
 }
 
+// enqueueReplay queues the blocks rewound by a repair of the slice to be
+// appended again.
+func (c *Core) enqueueReplay() {
+	for _, entry := range c.sl.replay {
+		c.appendQueue.ContainsOrAdd(entry.Hash, entry.Number)
+	}
+	c.sl.replay = nil
+}
+
+// RepairHead repairs the head block again, now checking it against the dom and
+// the subs linked after the core was created, and appends the rewound blocks
+// again. Synchronous cores append them right away, others in the background.
+func (c *Core) RepairHead() {
+	c.sl.repairHead()
+	c.enqueueReplay()
+	if c.sl.synchronous {
+		c.procAppendQueue()
+	}
+}
+
 // InsertChain attempts to append a list of blocks to the slice, optionally
 // caching any pending blocks which cannot yet be appended. InsertChain return
 // the number of blocks which were successfully consumed (either appended, or
 // cached), and an error.
 func (c *Core) InsertChain(blocks types.Blocks) (int, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := c.NodeCtx()
 	for idx, block := range blocks {
 		// Only attempt to append a block, if it is not coincident with our dominant
 		// chain. If it is dom coincident, then the dom chain node in our slice needs
@@ -146,23 +175,25 @@ func (c *Core) procAppendQueue() {
 }
 
 func (c *Core) serviceFutureBlock(block *types.Block) {
-	parentBlock := c.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	nodeCtx := c.NodeCtx()
+	parentBlock := c.GetBlock(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
 	if parentBlock != nil {
 		c.InsertChain([]*types.Block{block})
 	} else {
-		if !c.HasHeader(block.ParentHash(), block.NumberU64()-1) {
-			c.sl.missingParentFeed.Send(block.ParentHash())
+		if !c.HasHeader(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1) {
+			c.sl.missingParentFeed.Send(block.ParentHash(nodeCtx))
 		}
 	}
 }
 
 // addToAppendQueue adds a block to the append queue
 func (c *Core) addToAppendQueue(block *types.Block) error {
+	nodeCtx := c.NodeCtx()
 	max := uint64(time.Now().Unix() + c_maxFutureTime)
 	if block.Time() > max {
 		return fmt.Errorf("future block timestamp %v > allowed %v", block.Time(), max)
 	}
-	c.appendQueue.ContainsOrAdd(block.Hash(), block.NumberU64())
+	c.appendQueue.ContainsOrAdd(block.Hash(), block.NumberU64(nodeCtx))
 	return nil
 }
 
@@ -186,7 +217,7 @@ func (c *Core) updateAppendQueue() {
 }
 
 func (c *Core) BadHashExistsInChain() bool {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := c.NodeCtx()
 	// Lookup the bad hashes list to see if we have it in the database
 	for _, fork := range BadHashes {
 		switch nodeCtx {
@@ -195,11 +226,11 @@ func (c *Core) BadHashExistsInChain() bool {
 				return true
 			}
 		case common.REGION_CTX:
-			if c.GetBlockByHash(fork.RegionContext[common.NodeLocation.Region()]) != nil {
+			if c.GetBlockByHash(fork.RegionContext[c.NodeLocation().Region()]) != nil {
 				return true
 			}
 		case common.ZONE_CTX:
-			if c.GetBlockByHash(fork.ZoneContext[common.NodeLocation.Region()][common.NodeLocation.Zone()]) != nil {
+			if c.GetBlockByHash(fork.ZoneContext[c.NodeLocation().Region()][c.NodeLocation().Zone()]) != nil {
 				return true
 			}
 		}
@@ -235,6 +266,16 @@ func (c *Core) Slice() *Slice {
 	return c.sl
 }
 
+// NodeLocation returns the location of the chain served by this core.
+func (c *Core) NodeLocation() common.Location {
+	return c.sl.NodeLocation()
+}
+
+// NodeCtx returns the context of the chain served by this core.
+func (c *Core) NodeCtx() int {
+	return c.sl.NodeCtx()
+}
+
 func (c *Core) TxPool() *TxPool {
 	return c.sl.txPool
 }
@@ -261,7 +302,7 @@ func (c *Core) WriteBlock(block *types.Block) {
 		if err != nil {
 			return
 		}
-		if order == common.NodeLocation.Context() {
+		if order == c.NodeCtx() {
 			c.addToAppendQueue(block)
 		}
 		c.sl.WriteBlock(block)
@@ -269,13 +310,14 @@ func (c *Core) WriteBlock(block *types.Block) {
 }
 
 func (c *Core) Append(header *types.Header, domPendingHeader *types.Header, domTerminus common.Hash, domOrigin bool, newInboundEtxs types.Transactions) (types.Transactions, bool, error) {
+	nodeCtx := c.NodeCtx()
 	newPendingEtxs, subReorg, err := c.sl.Append(header, domPendingHeader, domTerminus, domOrigin, newInboundEtxs)
 	if err != nil {
 		if err.Error() == ErrBodyNotFound.Error() {
 			c.sl.missingBodyFeed.Send(header)
 		}
 		if err.Error() == consensus.ErrUnknownAncestor.Error() {
-			c.sl.missingParentFeed.Send(header.ParentHash())
+			c.sl.missingParentFeed.Send(header.ParentHash(nodeCtx))
 		}
 	}
 	return newPendingEtxs, subReorg, err
@@ -294,10 +336,33 @@ func (c *Core) NewGenesisPendigHeader(pendingHeader *types.Header) {
 	c.sl.NewGenesisPendingHeader(pendingHeader)
 }
 
+// SetDomClient attaches the transport used to reach the dominant chain.
+func (c *Core) SetDomClient(client DomClient) {
+	c.sl.SetDomClient(client)
+}
+
+// SetSubClient attaches the transport used to reach the subordinate chain at
+// the given index.
+func (c *Core) SetSubClient(index int, client SubClient) {
+	c.sl.SetSubClient(index, client)
+}
+
+func (c *Core) TransportHealth() TransportHealth {
+	return c.sl.TransportHealth()
+}
+
+func (c *Core) EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error) {
+	return c.sl.EstimateExternalGas(ctx, msg)
+}
+
 func (c *Core) GetPendingHeader() (*types.Header, error) {
 	return c.sl.GetPendingHeader()
 }
 
+func (c *Core) FillPendingHeader() error {
+	return c.sl.FillPendingHeader()
+}
+
 func (c *Core) GetManifest(blockHash common.Hash) (types.BlockManifest, error) {
 	return c.sl.GetManifest(blockHash)
 }
@@ -346,6 +411,10 @@ func (c *Core) GenerateRecoveryPendingHeader(pendingHeader *types.Header, checkp
 	return c.sl.GenerateRecoveryPendingHeader(pendingHeader, checkpointHashes)
 }
 
+func (c *Core) UpdateBlockLists(update types.BlockListUpdate, propagate bool) error {
+	return c.sl.UpdateBlockLists(update, propagate)
+}
+
 func (c *Core) IsBlockHashABadHash(hash common.Hash) bool {
 	return c.sl.IsBlockHashABadHash(hash)
 }
@@ -423,6 +492,23 @@ func (c *Core) CurrentLogEntropy() *big.Int {
 }
 
 // TotalLogS returns the total entropy reduction if the chain since genesis to the given header
+// CurrentSafeHeader returns the most recent canonical block covered by a block
+// of the dom chain directly above the node.
+func (c *Core) CurrentSafeHeader() *types.Header {
+	return c.sl.hc.CurrentSafeHeader()
+}
+
+// CurrentFinalizedHeader returns the most recent canonical block covered by a
+// prime block.
+func (c *Core) CurrentFinalizedHeader() *types.Header {
+	return c.sl.hc.CurrentFinalizedHeader()
+}
+
+// ConfirmationStatus returns how final a block is within the hierarchy.
+func (c *Core) ConfirmationStatus(header *types.Header) (*ConfirmationStatus, error) {
+	return c.sl.hc.ConfirmationStatus(header)
+}
+
 func (c *Core) TotalLogS(header *types.Header) *big.Int {
 	return c.engine.TotalLogS(header)
 }
@@ -509,6 +595,26 @@ func (c *Core) GetTerminiByHash(hash common.Hash) []common.Hash {
 	return c.sl.hc.GetTerminiByHash(hash)
 }
 
+// InstallPivot sets a state synced pivot block as the head of the chain.
+func (c *Core) InstallPivot(blocks []*types.Block, termini [][]common.Hash, etxSet types.EtxSet) error {
+	return c.sl.hc.InstallPivot(blocks, termini, etxSet)
+}
+
+// GetEtxSet retrieves the EtxSet resulting from the given block.
+func (c *Core) GetEtxSet(hash common.Hash, number uint64) types.EtxSet {
+	return c.sl.hc.GetEtxSet(hash, number)
+}
+
+// GetEtxStatus returns the lifecycle of the given ETX as seen by this slice.
+func (c *Core) GetEtxStatus(hash common.Hash) *types.EtxStatus {
+	return c.sl.hc.GetEtxStatus(hash)
+}
+
+// SubscribeEtxStatusEvent registers a subscription of EtxStatusEvent.
+func (c *Core) SubscribeEtxStatusEvent(ch chan<- EtxStatusEvent) event.Subscription {
+	return c.sl.hc.SubscribeEtxStatusEvent(ch)
+}
+
 func (c *Core) SubscribeMissingPendingEtxsEvent(ch chan<- types.HashAndLocation) event.Subscription {
 	return c.sl.hc.SubscribeMissingPendingEtxsEvent(ch)
 }
@@ -522,6 +628,11 @@ func (c *Core) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Subscript
 	return c.sl.hc.SubscribeChainSideEvent(ch)
 }
 
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (c *Core) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return c.sl.hc.SubscribeReorgEvent(ch)
+}
+
 //--------------------//
 // BlockChain methods //
 //--------------------//
@@ -603,6 +714,21 @@ func (c *Core) SetGasCeil(ceil uint64) {
 	c.sl.miner.SetGasCeil(ceil)
 }
 
+// SetBlockBuilder sets the policy pending blocks are filled with.
+func (c *Core) SetBlockBuilder(builder BlockBuilder) {
+	c.sl.miner.SetBlockBuilder(builder)
+}
+
+// SendBundle queues a bundle for inclusion in its target block.
+func (c *Core) SendBundle(bundle *Bundle) error {
+	return c.sl.miner.SendBundle(bundle)
+}
+
+// CallBundle simulates a bundle at the top of the next block.
+func (c *Core) CallBundle(bundle *Bundle) (*BundleResult, error) {
+	return c.sl.miner.CallBundle(bundle)
+}
+
 // EnablePreseal turns on the preseal mining feature. It's enabled by default.
 // Note this function shouldn't be exposed to API, it's unnecessary for users
 // (miners) to actually know the underlying detail. It's only for outside project
@@ -746,7 +872,7 @@ func (c *Core) Get(hash common.Hash) *types.Transaction {
 }
 
 func (c *Core) Nonce(addr common.Address) uint64 {
-	internal, err := addr.InternalAddress()
+	internal, err := c.sl.config.InternalAddress(addr)
 	if err != nil {
 		return 0
 	}
@@ -762,7 +888,7 @@ func (c *Core) Content() (map[common.InternalAddress]types.Transactions, map[com
 }
 
 func (c *Core) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
-	internal, err := addr.InternalAddress()
+	internal, err := c.sl.config.InternalAddress(addr)
 	if err != nil {
 		return nil, nil
 	}
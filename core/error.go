@@ -70,6 +70,14 @@ var (
 
 	// ErrBadBlockHash is returned when block being appended is in the badBlockHashes list
 	ErrBadBlockHash = errors.New("block hash exists in bad block hashes list")
+
+	// ErrCheckpointMismatch is returned if a block to import conflicts with a
+	// trusted checkpoint.
+	ErrCheckpointMismatch = errors.New("block conflicts with checkpoint")
+
+	// ErrBadEtxSetRoot is returned when a zone block does not commit to the
+	// EtxSet it is executed against.
+	ErrBadEtxSetRoot = errors.New("invalid etx set root")
 )
 
 // List of evm-call-message pre-checking errors. All state transition messages will
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	interfaces "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/math"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/core/vm"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+var (
+	// errNoETXRecipient is returned when estimating an external transaction
+	// without a destination.
+	errNoETXRecipient = errors.New("external transaction without recipient")
+
+	// errNoETXRoute is returned when the link leading towards the destination
+	// of an external transaction is not attached.
+	errNoETXRoute = errors.New("no link towards the destination of the external transaction")
+)
+
+// EstimateExternalGas estimates the gas an external transaction needs when it
+// is executed in its destination zone. The destination zone simulates it
+// against its current state, any other chain forwards the request through the
+// dom or sub client leading towards the destination.
+//
+// The message describes the ETX as it arrives in the destination: From is the
+// sender in the origin zone, Data and AccessList are the etx data and access
+// list, and Gas caps the estimate.
+func (sl *Slice) EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error) {
+	if msg.To == nil {
+		return 0, errNoETXRecipient
+	}
+	// Addresses are decoded relative to the chain handling them
+	to := common.Bytes20ToAddress(msg.To.Bytes20())
+	msg.To = &to
+	msg.From = common.Bytes20ToAddress(msg.From.Bytes20())
+
+	nodeCtx := sl.NodeCtx()
+	dest := *to.Location(sl.config.GetTopology())
+	switch {
+	case nodeCtx == common.ZONE_CTX && sl.NodeLocation().Equal(dest):
+		return sl.estimateExternalGas(msg)
+
+	case nodeCtx != common.ZONE_CTX && sl.NodeLocation().InSameSliceAs(dest):
+		subClient := sl.subLink(dest.SubIndex(nodeCtx))
+		if subClient == nil {
+			return 0, errNoETXRoute
+		}
+		return subClient.EstimateExternalGas(ctx, msg)
+
+	default:
+		domClient := sl.domLink()
+		if domClient == nil {
+			return 0, errNoETXRoute
+		}
+		return domClient.EstimateExternalGas(ctx, msg)
+	}
+}
+
+// estimateExternalGas binary searches the lowest gas limit with which the
+// external transaction executes against the current state of this zone.
+func (sl *Slice) estimateExternalGas(msg interfaces.CallMsg) (uint64, error) {
+	head := sl.hc.CurrentHeader()
+	lo, hi := params.TxGas-1, head.GasLimit()
+	if msg.Gas >= params.TxGas {
+		hi = msg.Gas
+	}
+	cap := hi
+
+	value := msg.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	// Execute the ETX with a gas limit, reporting whether it failed for lack
+	// of gas and the result of any other failure
+	executable := func(gas uint64) (bool, *ExecutionResult, error) {
+		statedb, err := sl.hc.bc.processor.StateAt(head.Root())
+		if err != nil {
+			return true, nil, err
+		}
+		// The value of an ETX is paid out of the zero address, which the
+		// processor funds before applying it. The estimate ignores the fees.
+		statedb.SetBalance(common.ZeroInternal, value)
+
+		etx := types.NewExternalMessage(msg.From, msg.To, value, gas, new(big.Int), new(big.Int), new(big.Int), msg.Data, msg.AccessList)
+		blockContext := NewEVMBlockContext(head, sl.hc, nil)
+		evm := vm.NewEVM(blockContext, NewEVMTxContext(etx), statedb, sl.config, vm.Config{NoBaseFee: true})
+
+		result, err := ApplyMessage(evm, etx, new(GasPool).AddGas(math.MaxUint64))
+		if err != nil {
+			if errors.Is(err, ErrIntrinsicGas) {
+				return true, nil, nil // Special case, raise gas limit
+			}
+			return true, nil, err
+		}
+		return result.Failed(), result, nil
+	}
+	for lo+1 < hi {
+		mid := (hi + lo) / 2
+		failed, _, err := executable(mid)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	// The ETX failed even with the highest gas limit, report why
+	if hi == cap {
+		failed, result, err := executable(hi)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			if result != nil && result.Err != vm.ErrOutOfGas {
+				return 0, fmt.Errorf("external transaction fails in destination: %w", result.Err)
+			}
+			return 0, fmt.Errorf("external gas required exceeds allowance (%d)", cap)
+		}
+	}
+	return hi, nil
+}
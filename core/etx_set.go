@@ -0,0 +1,93 @@
+package core
+
+import (
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/ethdb"
+	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/trie"
+)
+
+const (
+	c_etxSetCacheLimit       = 32  // Number of recent EtxSets kept in memory
+	c_etxSetSnapshotInterval = 128 // Number of blocks between two full EtxSets stored on disk
+)
+
+// commitsEtxSet reports whether a zone block built on the given parent commits
+// in its header to the root of the EtxSet at the parent, which is the set the
+// block is executed against.
+func (hc *HeaderChain) commitsEtxSet(parent *types.Header) bool {
+	return hc.config.Rules(parent.Number(hc.NodeCtx()), parent.Number(common.PRIME_CTX)).IsEtxSet
+}
+
+// GetEtxSet returns the EtxSet at the given block. Only the diff applied by
+// each block is stored, plus a full set every c_etxSetSnapshotInterval blocks,
+// so the set is rebuilt by applying the diffs on top of the closest full set.
+// The root stored with each diff only guards against a corrupted database, the
+// set is verified against the headers by the blocks built on top of it.
+// The returned set is a copy which the caller is free to modify.
+func (hc *HeaderChain) GetEtxSet(hash common.Hash, number uint64) types.EtxSet {
+	nodeCtx := hc.NodeCtx()
+	if set, ok := hc.etxSetCache.Get(hash); ok {
+		return set.(types.EtxSet).Copy()
+	}
+	// Walk back to the closest block with a known set, collecting the diffs
+	var (
+		target = hash
+		diffs  []*types.EtxSetDiff
+		base   types.EtxSet
+	)
+	for {
+		if set, ok := hc.etxSetCache.Get(hash); ok {
+			base = set.(types.EtxSet).Copy()
+			break
+		}
+		if base = rawdb.ReadEtxSet(hc.headerDb, hash, number); base != nil {
+			break
+		}
+		diff := rawdb.ReadEtxSetDiff(hc.headerDb, hash, number)
+		if diff == nil || number == 0 {
+			return nil
+		}
+		diffs = append(diffs, diff)
+
+		header := hc.GetHeader(hash, number)
+		if header == nil {
+			return nil
+		}
+		hash, number = header.ParentHash(nodeCtx), number-1
+	}
+	// Replay the diffs on top of the base set
+	for i := len(diffs) - 1; i >= 0; i-- {
+		number++
+		base.Apply(diffs[i], number)
+	}
+	if len(diffs) > 0 {
+		if root := base.Root(trie.NewStackTrie(nil)); root != diffs[0].Root {
+			log.Error("Rebuilt etx set does not match root stored with the diff", "hash", target, "have", root, "want", diffs[0].Root)
+			return nil
+		}
+		hc.etxSetCache.Add(target, base.Copy())
+	}
+	return base
+}
+
+// WriteEtxSet stores the EtxSet resulting from the given block. The diff is
+// always stored along with the root of the set, and the full set is stored every c_etxSetSnapshotInterval
+// blocks to bound the number of diffs replayed by GetEtxSet.
+func (hc *HeaderChain) WriteEtxSet(db ethdb.KeyValueWriter, hash common.Hash, number uint64, set types.EtxSet, diff *types.EtxSetDiff) {
+	diff.Root = set.Root(trie.NewStackTrie(nil))
+	rawdb.WriteEtxSetDiff(db, hash, number, diff)
+	if number%c_etxSetSnapshotInterval == 0 {
+		rawdb.WriteEtxSet(db, hash, number, set)
+	}
+	hc.etxSetCache.Add(hash, set.Copy())
+}
+
+// DeleteEtxSet removes the EtxSet data of the given block.
+func (hc *HeaderChain) DeleteEtxSet(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	rawdb.DeleteEtxSetDiff(db, hash, number)
+	rawdb.DeleteEtxSet(db, hash, number)
+	hc.etxSetCache.Remove(hash)
+}
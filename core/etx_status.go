@@ -0,0 +1,148 @@
+package core
+
+import (
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/ethdb"
+	"github.com/dominant-strategies/go-quai/event"
+)
+
+// etxStatusBatch collects the ETX lifecycle updates made while appending a
+// block. An ETX can advance through several stages in one block (e.g. become
+// available and be executed right away), so all updates are merged in memory
+// and each status is written once.
+type etxStatusBatch struct {
+	hc       *HeaderChain
+	statuses map[common.Hash]*types.EtxStatus
+	order    []common.Hash
+}
+
+func (hc *HeaderChain) newEtxStatusBatch() *etxStatusBatch {
+	return &etxStatusBatch{hc: hc, statuses: make(map[common.Hash]*types.EtxStatus)}
+}
+
+// update applies the given change to the status of an ETX, for a stage
+// observed in the given block. A stage observed in a canonical block is kept
+// over the same stage observed in a competing block.
+func (b *etxStatusBatch) update(hash common.Hash, stage types.EtxStage, block *types.Block, change func(status *types.EtxStatus)) {
+	status, exists := b.statuses[hash]
+	if !exists {
+		status = rawdb.ReadEtxStatus(b.hc.headerDb, hash)
+		if status == nil {
+			status = new(types.EtxStatus)
+		}
+		b.statuses[hash] = status
+		b.order = append(b.order, hash)
+	}
+	if observed, number := status.Block(stage); observed != (common.Hash{}) && observed != block.Hash() && b.hc.GetCanonicalHash(number) == observed {
+		return
+	}
+	change(status)
+	status.Advance(stage)
+}
+
+// emitted records the ETXs emitted by a block of the origin zone.
+func (b *etxStatusBatch) emitted(block *types.Block) {
+	nodeCtx := b.hc.NodeCtx()
+	for _, etx := range block.ExtTransactions() {
+		b.update(etx.Hash(), types.EtxEmitted, block, func(status *types.EtxStatus) {
+			status.OriginBlock = block.Hash()
+			status.OriginNumber = block.NumberU64(nodeCtx)
+			status.OriginLocation = block.Location()
+		})
+	}
+}
+
+// rolledUp records the ETXs rolled up from the sub into a dom block.
+func (b *etxStatusBatch) rolledUp(block *types.Block, etxs types.Transactions) {
+	nodeCtx := b.hc.NodeCtx()
+	for _, etx := range etxs {
+		b.update(etx.Hash(), types.EtxRolledUp, block, func(status *types.EtxStatus) {
+			status.RollupBlock = block.Hash()
+			status.RollupNumber = block.NumberU64(nodeCtx)
+			status.RollupLocation = b.hc.NodeLocation()
+		})
+	}
+}
+
+// referenced records the ETXs confirmed by a coincident dom block.
+func (b *etxStatusBatch) referenced(block *types.Block, etxs types.Transactions) {
+	nodeCtx := b.hc.NodeCtx()
+	for _, etx := range etxs {
+		b.update(etx.Hash(), types.EtxReferenced, block, func(status *types.EtxStatus) {
+			status.DomBlock = block.Hash()
+			status.DomNumber = block.NumberU64(nodeCtx)
+			status.DomLocation = b.hc.NodeLocation()
+		})
+	}
+}
+
+// available records the ETXs added to the EtxSet of the destination zone.
+func (b *etxStatusBatch) available(block *types.Block, etxs types.Transactions) {
+	nodeCtx := b.hc.NodeCtx()
+	for _, etx := range etxs {
+		b.update(etx.Hash(), types.EtxAvailable, block, func(status *types.EtxStatus) {
+			status.AvailableBlock = block.Hash()
+			status.AvailableHeight = block.NumberU64(nodeCtx)
+		})
+	}
+}
+
+// executed records an ETX included in a block of the destination zone.
+func (b *etxStatusBatch) executed(block *types.Block, hash common.Hash) {
+	nodeCtx := b.hc.NodeCtx()
+	b.update(hash, types.EtxExecuted, block, func(status *types.EtxStatus) {
+		status.InclusionBlock = block.Hash()
+		status.InclusionNumber = block.NumberU64(nodeCtx)
+	})
+}
+
+// expired records the ETXs dropped from the destination EtxSet.
+func (b *etxStatusBatch) expired(block *types.Block, hashes []common.Hash) {
+	nodeCtx := b.hc.NodeCtx()
+	for _, hash := range hashes {
+		b.update(hash, types.EtxExpired, block, func(status *types.EtxStatus) {
+			status.ExpiryBlock = block.Hash()
+			status.ExpiryHeight = block.NumberU64(nodeCtx)
+		})
+	}
+}
+
+// write stores all the updated statuses into the batch.
+func (b *etxStatusBatch) write(batch ethdb.KeyValueWriter) {
+	for _, hash := range b.order {
+		rawdb.WriteEtxStatus(batch, hash, b.statuses[hash])
+	}
+}
+
+// notify announces all the updated statuses to the subscribers. It is only to
+// be called once the batch they were written to is flushed, so subscribers
+// never hear of statuses which are thrown away.
+func (b *etxStatusBatch) notify() {
+	for _, hash := range b.order {
+		b.hc.etxStatusFeed.Send(EtxStatusEvent{Hash: hash, Status: b.statuses[hash]})
+	}
+}
+
+// GetEtxStatus returns the lifecycle of the given ETX as seen by the canonical
+// chain of this slice, or nil if the ETX has never been observed there. The
+// stages observed in blocks which were reorged out are left out.
+func (hc *HeaderChain) GetEtxStatus(hash common.Hash) *types.EtxStatus {
+	status := rawdb.ReadEtxStatus(hc.headerDb, hash)
+	if status == nil {
+		return nil
+	}
+	status.Revert(func(stage types.EtxStage, block common.Hash, number uint64) bool {
+		return hc.GetCanonicalHash(number) != block
+	})
+	if status.Stage == types.EtxUnknown {
+		return nil
+	}
+	return status
+}
+
+// SubscribeEtxStatusEvent registers a subscription of EtxStatusEvent.
+func (hc *HeaderChain) SubscribeEtxStatusEvent(ch chan<- EtxStatusEvent) event.Subscription {
+	return hc.scope.Track(hc.etxStatusFeed.Subscribe(ch))
+}
@@ -41,3 +41,29 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// EtxStatusEvent is posted when the lifecycle of an ETX advances.
+type EtxStatusEvent struct {
+	Hash   common.Hash
+	Status *types.EtxStatus
+}
+
+// ReorgEvent is posted when the canonical chain of the slice switches to
+// another branch, reverting blocks of the previous one. Blocks are listed per
+// context they are coincident with, e.g. in a zone Removed[common.REGION_CTX]
+// holds the reverted blocks which were region (or prime) blocks, while
+// Removed[common.ZONE_CTX] holds all of them. Hashes are ordered by number.
+type ReorgEvent struct {
+	CommonAncestor *types.Header
+	OldHead        *types.Header
+	NewHead        *types.Header
+	Depth          uint64 // Number of reverted blocks
+
+	Removed [common.HierarchyDepth][]common.Hash
+	Added   [common.HierarchyDepth][]common.Hash
+
+	RevertedEtxs          []common.Hash // ETXs emitted by the old branch only
+	ReemittedEtxs         []common.Hash // ETXs emitted by both branches
+	RevertedInboundEtxs   []common.Hash // Inbound ETXs executed by the old branch only
+	ReincludedInboundEtxs []common.Hash // Inbound ETXs executed by both branches
+}
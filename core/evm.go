@@ -23,6 +23,7 @@ import (
 	"github.com/dominant-strategies/go-quai/consensus"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/core/vm"
+	"github.com/dominant-strategies/go-quai/params"
 )
 
 // ChainContext supports retrieving headers and consensus parameters from the
@@ -56,7 +57,8 @@ func NewEVMBlockContext(header *types.Header, chain ChainContext, author *common
 		Transfer:    Transfer,
 		GetHash:     GetHashFn(header, chain),
 		Coinbase:    beneficiary,
-		BlockNumber: new(big.Int).Set(header.Number()),
+		BlockNumber: new(big.Int).Set(header.Number(common.ZONE_CTX)),
+		PrimeNumber: new(big.Int).Set(header.Number(common.PRIME_CTX)),
 		Time:        new(big.Int).SetUint64(header.Time()),
 		Difficulty:  new(big.Int).Set(header.Difficulty()),
 		BaseFee:     baseFee,
@@ -89,23 +91,23 @@ func GetHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash
 	return func(n uint64) common.Hash {
 		// If there's no hash cache yet, make one
 		if len(cache) == 0 {
-			cache = append(cache, ref.ParentHash())
+			cache = append(cache, ref.ParentHash(common.ZONE_CTX))
 		}
-		if idx := ref.Number().Uint64() - n - 1; idx < uint64(len(cache)) {
+		if idx := ref.Number(common.ZONE_CTX).Uint64() - n - 1; idx < uint64(len(cache)) {
 			return cache[idx]
 		}
 		// No luck in the cache, but we can start iterating from the last element we already know
 		lastKnownHash := cache[len(cache)-1]
-		lastKnownNumber := ref.Number().Uint64() - uint64(len(cache))
+		lastKnownNumber := ref.Number(common.ZONE_CTX).Uint64() - uint64(len(cache))
 
 		for {
 			header := chain.GetHeader(lastKnownHash, lastKnownNumber)
 			if header == nil {
 				break
 			}
-			cache = append(cache, header.ParentHash())
-			lastKnownHash = header.ParentHash()
-			lastKnownNumber = header.Number().Uint64() - 1
+			cache = append(cache, header.ParentHash(common.ZONE_CTX))
+			lastKnownHash = header.ParentHash(common.ZONE_CTX)
+			lastKnownNumber = header.Number(common.ZONE_CTX).Uint64() - 1
 			if n == lastKnownNumber {
 				return lastKnownHash
 			}
@@ -116,8 +118,8 @@ func GetHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash
 
 // CanTransfer checks whether there are enough funds in the address' account to make a transfer.
 // This does not take the necessary gas in to account to make the transfer valid.
-func CanTransfer(db vm.StateDB, addr common.Address, amount *big.Int) bool {
-	internalAddr, err := addr.InternalAddress()
+func CanTransfer(db vm.StateDB, addr common.Address, amount *big.Int, config *params.ChainConfig) bool {
+	internalAddr, err := config.InternalAddress(addr)
 	if err != nil {
 		return false
 	}
@@ -125,12 +127,12 @@ func CanTransfer(db vm.StateDB, addr common.Address, amount *big.Int) bool {
 }
 
 // Transfer subtracts amount from sender and adds amount to recipient using the given Db
-func Transfer(db vm.StateDB, sender, recipient common.Address, amount *big.Int) error {
-	internalSender, err := sender.InternalAddress()
+func Transfer(db vm.StateDB, sender, recipient common.Address, amount *big.Int, config *params.ChainConfig) error {
+	internalSender, err := config.InternalAddress(sender)
 	if err != nil {
 		return err
 	}
-	internalRecipient, err := recipient.InternalAddress()
+	internalRecipient, err := config.InternalAddress(recipient)
 	if err != nil {
 		return err
 	}
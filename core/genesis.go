@@ -25,6 +25,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"reflect"
 
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/common/hexutil"
@@ -42,7 +43,11 @@ import (
 //go:generate gencodec -type Genesis -field-override genesisSpecMarshaling -out gen_genesis.go
 //go:generate gencodec -type GenesisAccount -field-override genesisAccountMarshaling -out gen_genesis_account.go
 
-var errGenesisNoConfig = errors.New("genesis has no chain configuration")
+var (
+	errGenesisNoConfig = errors.New("genesis has no chain configuration")
+	errTopologyChanged = errors.New("topology of the hierarchy differs from the stored chain config")
+	errRewardsChanged  = errors.New("rewards of the hierarchy differ from the stored chain config")
+)
 
 // Genesis specifies the header fields, state of a genesis block. It also defines hard
 // fork switch-over blocks through the chain configuration.
@@ -204,12 +209,24 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis) (*params
 	}
 	// Get the existing chain configuration.
 	newcfg := genesis.configOrDefault(stored)
+	if err := newcfg.CheckConfigForkOrder(); err != nil {
+		return newcfg, common.Hash{}, err
+	}
 	storedcfg := rawdb.ReadChainConfig(db, stored)
 	if storedcfg == nil {
 		log.Warn("Found genesis block without chain config")
 		rawdb.WriteChainConfig(db, stored, newcfg)
 		return newcfg, stored, nil
 	}
+	// The topology shapes the address space and the termini of every block,
+	// so it cannot change once the chain exists
+	if genesis != nil && !reflect.DeepEqual(storedcfg.GetTopology(), newcfg.GetTopology()) {
+		return newcfg, stored, errTopologyChanged
+	}
+	// Neither can the rewards, which were paid by every block since genesis
+	if genesis != nil && !reflect.DeepEqual(storedcfg.GetRewards(), newcfg.GetRewards()) {
+		return newcfg, stored, errRewardsChanged
+	}
 	// Special case: don't change the existing config of a non-mainnet chain if no new
 	// config is supplied. These chains would get AllProtocolChanges (and a compat error)
 	// if we just continued here.
@@ -218,10 +235,21 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis) (*params
 	}
 	// Check config compatibility and write the config. Compatibility errors
 	// are returned to the caller unless we're already at block zero.
-	height := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
+	headHash := rawdb.ReadHeadHeaderHash(db)
+	height := rawdb.ReadHeaderNumber(db, headHash)
 	if height == nil {
 		return newcfg, stored, fmt.Errorf("missing block number for head header hash")
 	}
+	// Forks cannot be rescheduled once the chain passed them, as the hierarchy
+	// cannot be rewound to reapply them
+	if compatErr := storedcfg.CheckCompatible(newcfg, *height); compatErr != nil {
+		return newcfg, stored, compatErr
+	}
+	if head := rawdb.ReadHeader(db, headHash, *height); head != nil {
+		if compatErr := storedcfg.CheckPrimeCompatible(newcfg, head.NumberU64(common.PRIME_CTX)); compatErr != nil {
+			return newcfg, stored, compatErr
+		}
+	}
 
 	rawdb.WriteChainConfig(db, stored, newcfg)
 	return newcfg, stored, nil
@@ -261,6 +289,10 @@ func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 // ToBlock creates the genesis block and writes state of a genesis specification
 // to the given database (or discards it if nil).
 func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
+	nodeCtx := common.ZONE_CTX
+	if g.Config != nil {
+		nodeCtx = g.Config.Location.Context()
+	}
 	head := types.EmptyHeader()
 	head.SetNonce(types.EncodeNonce(g.Nonce))
 	head.SetTime(g.Timestamp)
@@ -278,24 +310,34 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 		head.SetParentHash(common.Hash{}, i)
 	}
 
-	return types.NewBlock(head, nil, nil, nil, nil, nil, trie.NewStackTrie(nil))
+	return types.NewBlock(head, nil, nil, nil, nil, nil, trie.NewStackTrie(nil), nodeCtx)
 }
 
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
-	block := g.ToBlock(db)
-	if block.Number().Sign() != 0 {
-		return nil, fmt.Errorf("can't commit genesis block with number > 0")
-	}
 	config := g.Config
 	if config == nil {
 		config = params.AllProgpowProtocolChanges
 	}
+	nodeCtx := config.Location.Context()
+	block := g.ToBlock(db)
+	if block.Number(nodeCtx).Sign() != 0 {
+		return nil, fmt.Errorf("can't commit genesis block with number > 0")
+	}
+	if err := config.GetTopology().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid topology: %v", err)
+	}
+	if err := config.GetRewards().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rewards: %v", err)
+	}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return nil, err
+	}
 	rawdb.WriteTermini(db, block.Hash(), nil)
-	rawdb.WriteBlock(db, block)
-	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
-	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	rawdb.WriteBlock(db, block, nodeCtx)
+	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(nodeCtx), nil)
+	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64(nodeCtx))
 	rawdb.WriteHeadBlockHash(db, block.Hash())
 	rawdb.WriteHeadHeaderHash(db, block.Hash())
 	rawdb.WriteChainConfig(db, block.Hash(), config)
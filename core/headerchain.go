@@ -53,8 +53,11 @@ type HeaderChain struct {
 	pendingEtxsRollup            *lru.Cache
 	pendingEtxs                  *lru.Cache
 	blooms                       *lru.Cache
+	etxSetCache                  *lru.Cache
 	missingPendingEtxsFeed       event.Feed
 	missingPendingEtxsRollupFeed event.Feed
+	etxStatusFeed                event.Feed
+	reorgFeed                    event.Feed
 
 	wg            sync.WaitGroup // chain processing wait group for shutting down
 	running       int32          // 0 if chain is running, 1 when stopped
@@ -62,6 +65,8 @@ type HeaderChain struct {
 
 	headermu sync.RWMutex
 	heads    []*types.Header
+
+	ancientDepth uint64 // Number of blocks below the last prime coincident block not to freeze
 }
 
 // NewHeaderChain creates a new HeaderChain structure. ProcInterrupt points
@@ -76,6 +81,11 @@ func NewHeaderChain(db ethdb.Database, engine consensus.Engine, chainConfig *par
 		headerCache: headerCache,
 		numberCache: numberCache,
 		engine:      engine,
+
+		ancientDepth: params.FullImmutabilityThreshold,
+	}
+	if cacheConfig != nil && cacheConfig.AncientDepth != 0 {
+		hc.ancientDepth = cacheConfig.AncientDepth
 	}
 
 	pendingEtxsRollup, _ := lru.New(c_maxPendingEtxsRollup)
@@ -87,6 +97,9 @@ func NewHeaderChain(db ethdb.Database, engine consensus.Engine, chainConfig *par
 	blooms, _ := lru.New(c_maxBloomFilters)
 	hc.blooms = blooms
 
+	etxSetCache, _ := lru.New(c_etxSetCacheLimit)
+	hc.etxSetCache = etxSetCache
+
 	hc.genesisHeader = hc.GetHeaderByNumber(0)
 	if hc.genesisHeader.Hash() != chainConfig.GenesisHash {
 		return nil, fmt.Errorf("genesis block mismatch: have %x, want %x", hc.genesisHeader.Hash(), chainConfig.GenesisHash)
@@ -116,7 +129,7 @@ func NewHeaderChain(db ethdb.Database, engine consensus.Engine, chainConfig *par
 // CollectSubRollup collects the rollup of ETXs emitted from the subordinate
 // chain in the slice which emitted the given block.
 func (hc *HeaderChain) CollectSubRollup(b *types.Block) (types.Transactions, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := hc.NodeCtx()
 	subRollup := types.Transactions{}
 	if nodeCtx < common.ZONE_CTX {
 		// Since in prime the pending etxs are stored in 2 parts, pendingEtxsRollup
@@ -210,7 +223,7 @@ func (hc *HeaderChain) GetBloom(hash common.Hash) (*types.Bloom, error) {
 // given header. This is done by informing the fetcher of any pending ETXs we do
 // not have, so that they can be fetched from our peers.
 func (hc *HeaderChain) backfillPETXs(header *types.Header, subManifest types.BlockManifest) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := hc.NodeCtx()
 	for _, hash := range subManifest {
 		if nodeCtx == common.PRIME_CTX {
 			// In the case of prime, get the pendingEtxsRollup for each region block
@@ -237,10 +250,11 @@ func (hc *HeaderChain) backfillPETXs(header *types.Header, subManifest types.Blo
 // Collect all emmitted ETXs since the last coincident block, but excluding
 // those emitted in this block
 func (hc *HeaderChain) CollectEtxRollup(b *types.Block) (types.Transactions, error) {
-	if b.NumberU64() == 0 && b.Hash() == hc.config.GenesisHash {
+	nodeCtx := hc.NodeCtx()
+	if b.NumberU64(nodeCtx) == 0 && b.Hash() == hc.config.GenesisHash {
 		return b.ExtTransactions(), nil
 	}
-	parent := hc.GetBlock(b.ParentHash(), b.NumberU64()-1)
+	parent := hc.GetBlock(b.ParentHash(nodeCtx), b.NumberU64(nodeCtx)-1)
 	if parent == nil {
 		return nil, errors.New("parent not found")
 	}
@@ -248,10 +262,11 @@ func (hc *HeaderChain) CollectEtxRollup(b *types.Block) (types.Transactions, err
 }
 
 func (hc *HeaderChain) collectInclusiveEtxRollup(b *types.Block) (types.Transactions, error) {
+	nodeCtx := hc.NodeCtx()
 	// Initialize the rollup with ETXs emitted by this block
 	newEtxs := b.ExtTransactions()
 	// Terminate the search if we reached genesis
-	if b.NumberU64() == 0 {
+	if b.NumberU64(nodeCtx) == 0 {
 		if b.Hash() != hc.config.GenesisHash {
 			return nil, fmt.Errorf("manifest builds on incorrect genesis, block0 hash: %s", b.Hash().String())
 		} else {
@@ -263,7 +278,7 @@ func (hc *HeaderChain) collectInclusiveEtxRollup(b *types.Block) (types.Transact
 		return newEtxs, nil
 	}
 	// Recursively get the ancestor rollup, until a coincident ancestor is found
-	ancestor := hc.GetBlock(b.ParentHash(), b.NumberU64()-1)
+	ancestor := hc.GetBlock(b.ParentHash(nodeCtx), b.NumberU64(nodeCtx)-1)
 	if ancestor == nil {
 		return nil, errors.New("ancestor not found")
 	}
@@ -276,9 +291,9 @@ func (hc *HeaderChain) collectInclusiveEtxRollup(b *types.Block) (types.Transact
 }
 
 // Append
-func (hc *HeaderChain) Append(batch ethdb.Batch, block *types.Block, newInboundEtxs types.Transactions) error {
-	nodeCtx := common.NodeLocation.Context()
-	log.Debug("HeaderChain Append:", "Block information: Hash:", block.Hash(), "block header hash:", block.Header().Hash(), "Number:", block.NumberU64(), "Location:", block.Header().Location, "Parent:", block.ParentHash())
+func (hc *HeaderChain) Append(batch ethdb.Batch, block *types.Block, newInboundEtxs types.Transactions, etxStatuses *etxStatusBatch) error {
+	nodeCtx := hc.NodeCtx()
+	log.Debug("HeaderChain Append:", "Block information: Hash:", block.Hash(), "block header hash:", block.Header().Hash(), "Number:", block.NumberU64(nodeCtx), "Location:", block.Header().Location, "Parent:", block.ParentHash(nodeCtx))
 
 	err := hc.engine.VerifyHeader(hc, block.Header())
 	if err != nil {
@@ -292,7 +307,7 @@ func (hc *HeaderChain) Append(batch ethdb.Batch, block *types.Block, newInboundE
 	// coincident with a higher order chain. So, this check is skipped for prime
 	// nodes.
 	if nodeCtx > common.PRIME_CTX {
-		manifest := rawdb.ReadManifest(hc.headerDb, block.ParentHash())
+		manifest := rawdb.ReadManifest(hc.headerDb, block.ParentHash(nodeCtx))
 		if manifest == nil {
 			return errors.New("manifest not found for parent")
 		}
@@ -303,11 +318,11 @@ func (hc *HeaderChain) Append(batch ethdb.Batch, block *types.Block, newInboundE
 	elapsedCollectBlockManifest := common.PrettyDuration(time.Since(collectBlockManifest))
 
 	// Append header to the headerchain
-	rawdb.WriteHeader(batch, block.Header())
+	rawdb.WriteHeader(batch, block.Header(), nodeCtx)
 
 	blockappend := time.Now()
 	// Append block else revert header append
-	logs, err := hc.bc.Append(batch, block, newInboundEtxs)
+	logs, err := hc.bc.Append(batch, block, newInboundEtxs, etxStatuses)
 	if err != nil {
 		return err
 	}
@@ -321,9 +336,35 @@ func (hc *HeaderChain) Append(batch ethdb.Batch, block *types.Block, newInboundE
 	return nil
 }
 
+// updateAncientLimit lets the freezer move the blocks lying ancientDepth blocks
+// below the last prime coincident block into the ancient store. These blocks
+// are final, so no reorg will ever require them to be in leveldb.
+func (hc *HeaderChain) updateAncientLimit() {
+	nodeCtx := hc.NodeCtx()
+	finalized := hc.CurrentFinalizedHeader()
+	if finalized == nil || finalized.NumberU64(nodeCtx) < hc.ancientDepth {
+		return
+	}
+	limit := finalized.NumberU64(nodeCtx) - hc.ancientDepth
+	if prev := rawdb.ReadAncientLimit(hc.headerDb); prev != nil && *prev >= limit {
+		return
+	}
+	rawdb.WriteAncientLimit(hc.headerDb, limit)
+}
+
 // SetCurrentHeader sets the in-memory head header marker of the canonical chan
 // as the given header.
 func (hc *HeaderChain) SetCurrentHeader(head *types.Header) error {
+	nodeCtx := hc.NodeCtx()
+	// Announce a reorg once the lock is released, so subscribers may query the
+	// new chain right away
+	var reorg *ReorgEvent
+	defer func() {
+		hc.updateAncientLimit()
+		if reorg != nil {
+			hc.reorgFeed.Send(*reorg)
+		}
+	}()
 	hc.headermu.Lock()
 	defer hc.headermu.Unlock()
 
@@ -341,8 +382,8 @@ func (hc *HeaderChain) SetCurrentHeader(head *types.Header) error {
 	hc.currentHeader.Store(head)
 
 	// If head is the normal extension of canonical head, we can return by just wiring the canonical hash.
-	if prevHeader.Hash() == head.ParentHash() {
-		rawdb.WriteCanonicalHash(hc.headerDb, head.Hash(), head.NumberU64())
+	if prevHeader.Hash() == head.ParentHash(nodeCtx) {
+		rawdb.WriteCanonicalHash(hc.headerDb, head.Hash(), head.NumberU64(nodeCtx))
 		return nil
 	}
 
@@ -354,7 +395,7 @@ func (hc *HeaderChain) SetCurrentHeader(head *types.Header) error {
 			break
 		}
 		hashStack = append(hashStack, newHeader)
-		newHeader = hc.GetHeader(newHeader.ParentHash(), newHeader.NumberU64()-1)
+		newHeader = hc.GetHeader(newHeader.ParentHash(nodeCtx), newHeader.NumberU64(nodeCtx)-1)
 
 		// genesis check to not delete the genesis block
 		if newHeader.Hash() == hc.config.GenesisHash {
@@ -362,12 +403,15 @@ func (hc *HeaderChain) SetCurrentHeader(head *types.Header) error {
 		}
 	}
 
+	oldHead := prevHeader
+	var removed []*types.Header
 	for {
 		if prevHeader.Hash() == commonHeader.Hash() {
 			break
 		}
-		rawdb.DeleteCanonicalHash(hc.headerDb, prevHeader.NumberU64())
-		prevHeader = hc.GetHeader(prevHeader.ParentHash(), prevHeader.NumberU64()-1)
+		removed = append(removed, prevHeader)
+		rawdb.DeleteCanonicalHash(hc.headerDb, prevHeader.NumberU64(nodeCtx))
+		prevHeader = hc.GetHeader(prevHeader.ParentHash(nodeCtx), prevHeader.NumberU64(nodeCtx)-1)
 
 		// genesis check to not delete the genesis block
 		if prevHeader.Hash() == hc.config.GenesisHash {
@@ -377,22 +421,82 @@ func (hc *HeaderChain) SetCurrentHeader(head *types.Header) error {
 
 	// Run through the hash stack to update canonicalHash and forward state processor
 	for i := len(hashStack) - 1; i >= 0; i-- {
-		rawdb.WriteCanonicalHash(hc.headerDb, hashStack[i].Hash(), hashStack[i].NumberU64())
+		rawdb.WriteCanonicalHash(hc.headerDb, hashStack[i].Hash(), hashStack[i].NumberU64(nodeCtx))
+	}
+	if len(removed) > 0 {
+		ev := hc.newReorgEvent(commonHeader, oldHead, head, removed, hashStack)
+		reorg = &ev
+		log.Info("Chain reorg detected", "number", commonHeader.NumberU64(nodeCtx), "hash", commonHeader.Hash(), "drop", len(removed), "add", len(hashStack))
+	}
+	return nil
+}
+
+// InstallPivot writes a state synced pivot block and its closest ancestors,
+// pivot last, along with their termini and the EtxSet at the pivot, and sets
+// the pivot as the head of the chain. The EtxSet must have been verified
+// against the root committed to by the child of the pivot, which is checked
+// again once the child is appended.
+// The state of the pivot must already be present in the database, and the
+// chain must not have progressed past genesis.
+func (hc *HeaderChain) InstallPivot(blocks []*types.Block, termini [][]common.Hash, etxSet types.EtxSet) error {
+	nodeCtx := hc.NodeCtx()
+	if hc.NodeCtx() != common.ZONE_CTX {
+		return errors.New("state sync is only supported in zones")
+	}
+	if len(blocks) == 0 || len(blocks) != len(termini) {
+		return errors.New("pivot blocks and termini mismatch")
+	}
+	pivot := blocks[len(blocks)-1]
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].ParentHash(nodeCtx) != blocks[i-1].Hash() {
+			return errors.New("pivot blocks are not contiguous")
+		}
+	}
+	for _, t := range termini {
+		if len(t) != terminusIndex(hc.config)+1 {
+			return errors.New("invalid pivot termini")
+		}
 	}
+	if hc.CurrentHeader().Hash() != hc.config.GenesisHash {
+		return errors.New("cannot install a pivot on top of a synced chain")
+	}
+	if _, err := hc.bc.processor.StateAt(pivot.Root()); err != nil {
+		return fmt.Errorf("pivot state unavailable: %v", err)
+	}
+	hc.headermu.Lock()
+	defer hc.headermu.Unlock()
+
+	batch := hc.headerDb.NewBatch()
+	for i, block := range blocks {
+		rawdb.WriteTermini(batch, block.Hash(), termini[i])
+		rawdb.WriteBlock(batch, block, nodeCtx)
+		rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64(nodeCtx))
+	}
+	rawdb.WriteEtxSet(batch, pivot.Hash(), pivot.NumberU64(nodeCtx), etxSet)
+	rawdb.WriteHeadBlockHash(batch, pivot.Hash())
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	hc.etxSetCache.Add(pivot.Hash(), etxSet.Copy())
+	hc.currentHeader.Store(pivot.Header())
+
+	log.Info("Installed state synced pivot", "number", pivot.NumberU64(nodeCtx), "hash", pivot.Hash(), "etxs", len(etxSet))
+	hc.chainHeadFeed.Send(ChainHeadEvent{Block: pivot})
 	return nil
 }
 
 // findCommonAncestor
 func (hc *HeaderChain) findCommonAncestor(header *types.Header) *types.Header {
+	nodeCtx := hc.NodeCtx()
 	for {
 		if header == nil {
 			return nil
 		}
-		canonicalHash := rawdb.ReadCanonicalHash(hc.headerDb, header.NumberU64())
+		canonicalHash := rawdb.ReadCanonicalHash(hc.headerDb, header.NumberU64(nodeCtx))
 		if canonicalHash == header.Hash() {
 			return hc.GetHeaderByHash(canonicalHash)
 		}
-		header = hc.GetHeader(header.ParentHash(), header.NumberU64()-1)
+		header = hc.GetHeader(header.ParentHash(nodeCtx), header.NumberU64(nodeCtx)-1)
 	}
 
 }
@@ -467,7 +571,7 @@ func (hc *HeaderChain) Stop() {
 	hc.scope.Close()
 	hc.bc.scope.Close()
 	hc.wg.Wait()
-	if common.NodeLocation.Context() == common.ZONE_CTX {
+	if hc.NodeCtx() == common.ZONE_CTX {
 		hc.bc.processor.Stop()
 	}
 	log.Info("headerchain stopped")
@@ -506,6 +610,7 @@ func (hc *HeaderChain) GetTerminiByHash(hash common.Hash) []common.Hash {
 // GetBlockHashesFromHash retrieves a number of block hashes starting at a given
 // hash, fetching towards the genesis block.
 func (hc *HeaderChain) GetBlockHashesFromHash(hash common.Hash, max uint64) []common.Hash {
+	nodeCtx := hc.NodeCtx()
 	// Get the origin header from which to fetch
 	header := hc.GetHeaderByHash(hash)
 	if header == nil {
@@ -514,12 +619,12 @@ func (hc *HeaderChain) GetBlockHashesFromHash(hash common.Hash, max uint64) []co
 	// Iterate the headers until enough is collected or the genesis reached
 	chain := make([]common.Hash, 0, max)
 	for i := uint64(0); i < max; i++ {
-		next := header.ParentHash()
-		if header = hc.GetHeader(next, header.NumberU64()-1); header == nil {
+		next := header.ParentHash(nodeCtx)
+		if header = hc.GetHeader(next, header.NumberU64(nodeCtx)-1); header == nil {
 			break
 		}
 		chain = append(chain, next)
-		if header.Number().Sign() == 0 {
+		if header.Number(nodeCtx).Sign() == 0 {
 			break
 		}
 	}
@@ -532,13 +637,14 @@ func (hc *HeaderChain) GetBlockHashesFromHash(hash common.Hash, max uint64) []co
 //
 // Note: ancestor == 0 returns the same block, 1 returns its parent and so on.
 func (hc *HeaderChain) GetAncestor(hash common.Hash, number, ancestor uint64, maxNonCanonical *uint64) (common.Hash, uint64) {
+	nodeCtx := hc.NodeCtx()
 	if ancestor > number {
 		return common.Hash{}, 0
 	}
 	if ancestor == 1 {
 		// in this case it is cheaper to just read the header
 		if header := hc.GetHeader(hash, number); header != nil {
-			return header.ParentHash(), number - 1
+			return header.ParentHash(nodeCtx), number - 1
 		}
 		return common.Hash{}, 0
 	}
@@ -559,7 +665,7 @@ func (hc *HeaderChain) GetAncestor(hash common.Hash, number, ancestor uint64, ma
 		if header == nil {
 			return common.Hash{}, 0
 		}
-		hash = header.ParentHash()
+		hash = header.ParentHash(nodeCtx)
 		number--
 	}
 	return hash, number
@@ -675,6 +781,12 @@ func (hc *HeaderChain) SetGenesis(head *types.Header) {
 // Config retrieves the header chain's chain configuration.
 func (hc *HeaderChain) Config() *params.ChainConfig { return hc.config }
 
+// NodeLocation returns the location of the chain served by the header chain.
+func (hc *HeaderChain) NodeLocation() common.Location { return hc.config.Location }
+
+// NodeCtx returns the context of the chain served by the header chain.
+func (hc *HeaderChain) NodeCtx() int { return hc.config.Location.Context() }
+
 // GetBlock implements consensus.ChainReader, and returns nil for every input as
 // a header chain does not have blocks available for retrieval.
 func (hc *HeaderChain) GetBlock(hash common.Hash, number uint64) *types.Block {
@@ -691,10 +803,10 @@ func (hc *HeaderChain) CheckContext(context int) error {
 
 // CheckLocationRange checks to make sure the range of r and z are valid
 func (hc *HeaderChain) CheckLocationRange(location []byte) error {
-	if int(location[0]) < 1 || int(location[0]) > common.NumRegionsInPrime {
+	if int(location[0]) < 1 || int(location[0]) > hc.config.GetTopology().Regions {
 		return errors.New("the provided location is outside the allowable region range")
 	}
-	if int(location[1]) < 1 || int(location[1]) > common.NumZonesInRegion {
+	if int(location[1]) < 1 || int(location[1]) > hc.config.GetTopology().Zones {
 		return errors.New("the provided location is outside the allowable zone range")
 	}
 	return nil
@@ -708,10 +820,11 @@ func (hc *HeaderChain) GasLimit() uint64 {
 // GetUnclesInChain retrieves all the uncles from a given block backwards until
 // a specific distance is reached.
 func (hc *HeaderChain) GetUnclesInChain(block *types.Block, length int) []*types.Header {
+	nodeCtx := hc.NodeCtx()
 	uncles := []*types.Header{}
 	for i := 0; block != nil && i < length; i++ {
 		uncles = append(uncles, block.Uncles()...)
-		block = hc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+		block = hc.GetBlock(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
 	}
 	return uncles
 }
@@ -719,10 +832,11 @@ func (hc *HeaderChain) GetUnclesInChain(block *types.Block, length int) []*types
 // GetGasUsedInChain retrieves all the gas used from a given block backwards until
 // a specific distance is reached.
 func (hc *HeaderChain) GetGasUsedInChain(block *types.Block, length int) int64 {
+	nodeCtx := hc.NodeCtx()
 	gasUsed := 0
 	for i := 0; block != nil && i < length; i++ {
 		gasUsed += int(block.GasUsed())
-		block = hc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+		block = hc.GetBlock(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
 	}
 	return int64(gasUsed)
 }
@@ -735,11 +849,13 @@ func (hc *HeaderChain) CalculateBaseFee(header *types.Header) *big.Int {
 
 // Export writes the active chain to the given writer.
 func (hc *HeaderChain) Export(w io.Writer) error {
-	return hc.ExportN(w, uint64(0), hc.CurrentHeader().NumberU64())
+	nodeCtx := hc.NodeCtx()
+	return hc.ExportN(w, uint64(0), hc.CurrentHeader().NumberU64(nodeCtx))
 }
 
 // ExportN writes a subset of the active chain to the given writer.
 func (hc *HeaderChain) ExportN(w io.Writer, first uint64, last uint64) error {
+	nodeCtx := hc.NodeCtx()
 	hc.headermu.RLock()
 	defer hc.headermu.RUnlock()
 
@@ -758,7 +874,7 @@ func (hc *HeaderChain) ExportN(w io.Writer, first uint64, last uint64) error {
 			return err
 		}
 		if time.Since(reported) >= statsReportLimit {
-			log.Info("Exporting blocks", "exported", block.NumberU64()-first, "elapsed", common.PrettyDuration(time.Since(start)))
+			log.Info("Exporting blocks", "exported", block.NumberU64(nodeCtx)-first, "elapsed", common.PrettyDuration(time.Since(start)))
 			reported = time.Now()
 		}
 	}
@@ -847,6 +963,7 @@ func (hc *HeaderChain) GetBodyRLP(hash common.Hash) rlp.RawValue {
 // GetBlocksFromHash returns the block corresponding to hash and up to n-1 ancestors.
 // [deprecated by eth/62]
 func (hc *HeaderChain) GetBlocksFromHash(hash common.Hash, n int) (blocks []*types.Block) {
+	nodeCtx := hc.NodeCtx()
 	number := hc.GetBlockNumber(hash)
 	if number == nil {
 		return nil
@@ -857,7 +974,7 @@ func (hc *HeaderChain) GetBlocksFromHash(hash common.Hash, n int) (blocks []*typ
 			break
 		}
 		blocks = append(blocks, block)
-		hash = block.ParentHash()
+		hash = block.ParentHash(nodeCtx)
 		*number--
 	}
 	return
@@ -13,8 +13,7 @@ import (
 	"github.com/dominant-strategies/go-quai/trie"
 )
 
-func ReadKnot(chainfile string) []*types.Block {
-	nodeCtx := common.NodeLocation.Context()
+func ReadKnot(chainfile string, nodeCtx int) []*types.Block {
 	// Load chain.rlp.
 	fh, err := os.Open(chainfile)
 	if err != nil {
@@ -44,7 +43,7 @@ func ReadKnot(chainfile string) []*types.Block {
 		// manifest of subordinate blocks
 		if nodeCtx < common.ZONE_CTX {
 			subManifest := types.BlockManifest{h.ParentHash(nodeCtx + 1)}
-			b = types.NewBlock(h, nil, nil, nil, subManifest, nil, trie.NewStackTrie(nil))
+			b = types.NewBlock(h, nil, nil, nil, subManifest, nil, trie.NewStackTrie(nil), nodeCtx)
 		}
 		blocks = append(blocks, b)
 	}
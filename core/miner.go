@@ -171,6 +171,21 @@ func (miner *Miner) SetGasCeil(ceil uint64) {
 	miner.worker.setGasCeil(ceil)
 }
 
+// SetBlockBuilder sets the policy pending blocks are filled with.
+func (miner *Miner) SetBlockBuilder(builder BlockBuilder) {
+	miner.worker.setBlockBuilder(builder)
+}
+
+// SendBundle queues a bundle for inclusion in its target block.
+func (miner *Miner) SendBundle(bundle *Bundle) error {
+	return miner.worker.addBundle(bundle)
+}
+
+// CallBundle simulates a bundle at the top of the next block.
+func (miner *Miner) CallBundle(bundle *Bundle) (*BundleResult, error) {
+	return miner.worker.simulateBundle(bundle)
+}
+
 // EnablePreseal turns on the preseal mining feature. It's enabled by default.
 // Note this function shouldn't be exposed to API, it's unnecessary for users
 // (miners) to actually know the underlying detail. It's only for outside project
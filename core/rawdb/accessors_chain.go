@@ -25,7 +25,6 @@ import (
 
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/core/types"
-	"github.com/dominant-strategies/go-quai/crypto"
 	"github.com/dominant-strategies/go-quai/ethdb"
 	"github.com/dominant-strategies/go-quai/log"
 	"github.com/dominant-strategies/go-quai/params"
@@ -228,6 +227,59 @@ func WriteTxIndexTail(db ethdb.KeyValueWriter, number uint64) {
 	}
 }
 
+// ReadAncientLimit retrieves the number of the newest canonical block which
+// may be moved into the ancient store, or nil if none may be yet.
+func ReadAncientLimit(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(ancientLimitKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteAncientLimit stores the number of the newest canonical block which may
+// be moved into the ancient store.
+func WriteAncientLimit(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(ancientLimitKey, encodeBlockNumber(number)); err != nil {
+		log.Fatal("Failed to store the ancient limit", "err", err)
+	}
+}
+
+// ReadAppendJournal retrieves the block whose append was started in the
+// subordinate chain but not committed locally, or nil if none is in flight.
+func ReadAppendJournal(db ethdb.KeyValueReader) *types.HashAndNumber {
+	data, _ := db.Get(appendJournalKey)
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(types.HashAndNumber)
+	if err := rlp.Decode(bytes.NewReader(data), entry); err != nil {
+		log.Error("Invalid append journal RLP", "err", err)
+		return nil
+	}
+	return entry
+}
+
+// WriteAppendJournal stores the block whose append is started in the
+// subordinate chain.
+func WriteAppendJournal(db ethdb.KeyValueWriter, entry types.HashAndNumber) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Fatal("Failed to RLP encode append journal", "err", err)
+	}
+	if err := db.Put(appendJournalKey, data); err != nil {
+		log.Fatal("Failed to store append journal", "err", err)
+	}
+}
+
+// DeleteAppendJournal removes the append journal from the database.
+func DeleteAppendJournal(db ethdb.KeyValueWriter) {
+	if err := db.Delete(appendJournalKey); err != nil {
+		log.Fatal("Failed to delete append journal", "err", err)
+	}
+}
+
 // ReadFastTxLookupLimit retrieves the tx lookup limit used in fast sync.
 func ReadFastTxLookupLimit(db ethdb.KeyValueReader) *uint64 {
 	data, _ := db.Get(fastTxLookupLimitKey)
@@ -251,8 +303,11 @@ func ReadHeaderRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValu
 	// comparison is necessary since ancient database only maintains
 	// the canonical data.
 	data, _ := db.Ancient(freezerHeaderTable, number)
-	if len(data) > 0 && crypto.Keccak256Hash(data) == hash {
-		return data
+	if len(data) > 0 {
+		h, _ := db.Ancient(freezerHashTable, number)
+		if common.BytesToHash(h) == hash {
+			return data
+		}
 	}
 	// Then try to look up the data in leveldb.
 	data, _ = db.Get(headerKey(number, hash))
@@ -264,8 +319,11 @@ func ReadHeaderRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValu
 	// but when we reach into leveldb, the data was already moved. That would
 	// result in a not found error.
 	data, _ = db.Ancient(freezerHeaderTable, number)
-	if len(data) > 0 && crypto.Keccak256Hash(data) == hash {
-		return data
+	if len(data) > 0 {
+		h, _ := db.Ancient(freezerHashTable, number)
+		if common.BytesToHash(h) == hash {
+			return data
+		}
 	}
 	return nil // Can't find the data anywhere.
 }
@@ -297,10 +355,10 @@ func ReadHeader(db ethdb.Reader, hash common.Hash, number uint64) *types.Header
 
 // WriteHeader stores a block header into the database and also stores the hash-
 // to-number mapping.
-func WriteHeader(db ethdb.KeyValueWriter, header *types.Header) {
+func WriteHeader(db ethdb.KeyValueWriter, header *types.Header, nodeCtx int) {
 	var (
 		hash   = header.Hash()
-		number = header.NumberU64()
+		number = header.NumberU64(nodeCtx)
 	)
 	// Write the hash -> number mapping
 	WriteHeaderNumber(db, hash, number)
@@ -507,8 +565,7 @@ func DeleteAllPbBodyKeys(db ethdb.KeyValueWriter) {
 
 // ReadHeadsHashes retreive's the heads hashes of the blockchain.
 func ReadTermini(db ethdb.Reader, hash common.Hash) []common.Hash {
-	key := terminiKey(hash)
-	data, _ := db.Get(key)
+	data := ReadTerminiRLP(db, hash)
 	if len(data) == 0 {
 		return nil
 	}
@@ -519,6 +576,15 @@ func ReadTermini(db ethdb.Reader, hash common.Hash) []common.Hash {
 	return hashes
 }
 
+// ReadTerminiRLP retrieves the termini of the given block, in RLP encoding.
+func ReadTerminiRLP(db ethdb.Reader, hash common.Hash) rlp.RawValue {
+	data, _ := db.Get(terminiKey(hash))
+	if len(data) > 0 {
+		return data
+	}
+	return readAncientByHash(db, freezerTerminiTable, hash)
+}
+
 // WriteHeadsHashes writes the heads hashes of the blockchain.
 func WriteTermini(db ethdb.KeyValueWriter, index common.Hash, hashes []common.Hash) {
 	log.Debug("WriteTermini:", "hashes:", hashes, "index:", index)
@@ -664,6 +730,43 @@ func DeletePhCache(db ethdb.KeyValueWriter) {
 	}
 }
 
+// DeletePendingData removes the pending headers and bodies stored by hash,
+// which are only needed to restore the caches of the slice and the worker on
+// startup. Entries are left behind whenever the caches are discarded, so they
+// are swept once the caches have been loaded. It returns the number of entries
+// removed.
+func DeletePendingData(db ethdb.KeyValueStore) (int, error) {
+	batch := db.NewBatch()
+	count := 0
+	for _, prefix := range [][]byte{pendingHeaderPrefix, phTerminiPrefix, pbBodyPrefix, candidateBodyPrefix, phBodyPrefix} {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			// Skip the keys of other prefixes sharing this one, such as the
+			// pending header termini and the pending body keys
+			if len(it.Key()) != len(prefix)+common.HashLength {
+				continue
+			}
+			if err := batch.Delete(it.Key()); err != nil {
+				it.Release()
+				return count, err
+			}
+			count++
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return count, err
+				}
+				batch.Reset()
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return count, err
+		}
+	}
+	return count, batch.Write()
+}
+
 // ReadBestPhKey retreive's the bestPhKey of the blockchain
 func ReadBestPhKey(db ethdb.Reader) common.Hash {
 	data, _ := db.Get(phHeadKey)
@@ -810,7 +913,12 @@ func ReadReceipts(db ethdb.Reader, hash common.Hash, number uint64, config *para
 		log.Error("Missing body but have receipt", "hash", hash, "number", number)
 		return nil
 	}
-	if err := receipts.DeriveFields(config, hash, number, body.Transactions); err != nil {
+	// The signer depends on the prime number of the block, if it is known
+	var primeNumber *big.Int
+	if header := ReadHeader(db, hash, number); header != nil {
+		primeNumber = header.Number(common.PRIME_CTX)
+	}
+	if err := receipts.DeriveFields(config, hash, number, primeNumber, body.Transactions); err != nil {
 		log.Error("Failed to derive block receipts fields", "hash", hash, "number", number, "err", err)
 		return nil
 	}
@@ -940,9 +1048,9 @@ func ReadBlock(db ethdb.Reader, hash common.Hash, number uint64) *types.Block {
 }
 
 // WriteBlock serializes a block into the database, header and body separately.
-func WriteBlock(db ethdb.KeyValueWriter, block *types.Block) {
-	WriteBody(db, block.Hash(), block.NumberU64(), block.Body())
-	WriteHeader(db, block.Header())
+func WriteBlock(db ethdb.KeyValueWriter, block *types.Block, nodeCtx int) {
+	WriteBody(db, block.Hash(), block.NumberU64(nodeCtx), block.Body())
+	WriteHeader(db, block.Header(), nodeCtx)
 }
 
 // DeleteBlock removes all block data associated with a hash.
@@ -967,16 +1075,10 @@ type badBlock struct {
 	Body   *types.Body
 }
 
-// badBlockList implements the sort interface to allow sorting a list of
-// bad blocks by their number in the reverse order.
+// badBlockList is a list of bad blocks, sorted by their number in the reverse
+// order.
 type badBlockList []*badBlock
 
-func (s badBlockList) Len() int { return len(s) }
-func (s badBlockList) Less(i, j int) bool {
-	return s[i].Header.NumberU64() < s[j].Header.NumberU64()
-}
-func (s badBlockList) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
-
 // ReadBadBlock retrieves the bad block with the corresponding block hash.
 func ReadBadBlock(db ethdb.Reader, hash common.Hash) *types.Block {
 	blob, err := db.Get(badBlockKey)
@@ -1015,7 +1117,7 @@ func ReadAllBadBlocks(db ethdb.Reader) []*types.Block {
 
 // WriteBadBlock serializes the bad block into the database. If the cumulated
 // bad blocks exceeds the limitation, the oldest will be dropped.
-func WriteBadBlock(db ethdb.KeyValueStore, block *types.Block) {
+func WriteBadBlock(db ethdb.KeyValueStore, block *types.Block, nodeCtx int) {
 	blob, err := db.Get(badBlockKey)
 	if err != nil {
 		log.Warn("Failed to load old bad blocks", "error", err)
@@ -1027,8 +1129,8 @@ func WriteBadBlock(db ethdb.KeyValueStore, block *types.Block) {
 		}
 	}
 	for _, b := range badBlocks {
-		if b.Header.NumberU64() == block.NumberU64() && b.Header.Hash() == block.Hash() {
-			log.Info("Skip duplicated bad block", "number", block.NumberU64(), "hash", block.Hash())
+		if b.Header.NumberU64(nodeCtx) == block.NumberU64(nodeCtx) && b.Header.Hash() == block.Hash() {
+			log.Info("Skip duplicated bad block", "number", block.NumberU64(nodeCtx), "hash", block.Hash())
 			return
 		}
 	}
@@ -1036,7 +1138,9 @@ func WriteBadBlock(db ethdb.KeyValueStore, block *types.Block) {
 		Header: block.Header(),
 		Body:   block.Body(),
 	})
-	sort.Sort(sort.Reverse(badBlocks))
+	sort.SliceStable(badBlocks, func(i, j int) bool {
+		return badBlocks[i].Header.NumberU64(nodeCtx) > badBlocks[j].Header.NumberU64(nodeCtx)
+	})
 	if len(badBlocks) > badBlockToKeep {
 		badBlocks = badBlocks[:badBlockToKeep]
 	}
@@ -1057,25 +1161,25 @@ func DeleteBadBlocks(db ethdb.KeyValueWriter) {
 }
 
 // FindCommonAncestor returns the last common ancestor of two block headers
-func FindCommonAncestor(db ethdb.Reader, a, b *types.Header) *types.Header {
-	for bn := b.NumberU64(); a.NumberU64() > bn; {
-		a = ReadHeader(db, a.ParentHash(), a.NumberU64()-1)
+func FindCommonAncestor(db ethdb.Reader, a, b *types.Header, nodeCtx int) *types.Header {
+	for bn := b.NumberU64(nodeCtx); a.NumberU64(nodeCtx) > bn; {
+		a = ReadHeader(db, a.ParentHash(nodeCtx), a.NumberU64(nodeCtx)-1)
 		if a == nil {
 			return nil
 		}
 	}
-	for an := a.NumberU64(); an < b.NumberU64(); {
-		b = ReadHeader(db, b.ParentHash(), b.NumberU64()-1)
+	for an := a.NumberU64(nodeCtx); an < b.NumberU64(nodeCtx); {
+		b = ReadHeader(db, b.ParentHash(nodeCtx), b.NumberU64(nodeCtx)-1)
 		if b == nil {
 			return nil
 		}
 	}
 	for a.Hash() != b.Hash() {
-		a = ReadHeader(db, a.ParentHash(), a.NumberU64()-1)
+		a = ReadHeader(db, a.ParentHash(nodeCtx), a.NumberU64(nodeCtx)-1)
 		if a == nil {
 			return nil
 		}
-		b = ReadHeader(db, b.ParentHash(), b.NumberU64()-1)
+		b = ReadHeader(db, b.ParentHash(nodeCtx), b.NumberU64(nodeCtx)-1)
 		if b == nil {
 			return nil
 		}
@@ -1110,33 +1214,12 @@ func ReadHeadBlock(db ethdb.Reader) *types.Block {
 }
 
 // ReadEtxSetRLP retrieves the EtxSet corresponding to a given block, in RLP encoding.
+// Full sets are only stored for some blocks, the others only store an EtxSetDiff.
 func ReadEtxSetRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
-	// First try to look up the data in ancient database. Extra hash
-	// comparison is necessary since ancient database only maintains
-	// the canonical data.
-	data, _ := db.Ancient(freezerEtxSetsTable, number)
-	if len(data) > 0 {
-		h, _ := db.Ancient(freezerHashTable, number)
-		if common.BytesToHash(h) == hash {
-			return data
-		}
-	}
-	// Then try to look up the data in leveldb.
-	data, _ = db.Get(etxSetKey(number, hash))
+	data, _ := db.Get(etxSetKey(number, hash))
 	if len(data) > 0 {
 		return data
 	}
-	// In the background freezer is moving data from leveldb to flatten files.
-	// So during the first check for ancient db, the data is not yet in there,
-	// but when we reach into leveldb, the data was already moved. That would
-	// result in a not found error.
-	data, _ = db.Ancient(freezerEtxSetsTable, number)
-	if len(data) > 0 {
-		h, _ := db.Ancient(freezerHashTable, number)
-		if common.BytesToHash(h) == hash {
-			return data
-		}
-	}
 	return nil // Can't find the data anywhere.
 }
 
@@ -1192,14 +1275,78 @@ func DeleteEtxSet(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadEtxSetDiffRLP retrieves the EtxSetDiff applied by a given block, in RLP encoding.
+func ReadEtxSetDiffRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
+	// First try to look up the data in ancient database. Extra hash
+	// comparison is necessary since ancient database only maintains
+	// the canonical data.
+	data, _ := db.Ancient(freezerEtxSetsTable, number)
+	if len(data) > 0 {
+		h, _ := db.Ancient(freezerHashTable, number)
+		if common.BytesToHash(h) == hash {
+			return data
+		}
+	}
+	// Then try to look up the data in leveldb.
+	data, _ = db.Get(etxSetDiffKey(number, hash))
+	if len(data) > 0 {
+		return data
+	}
+	// In the background freezer is moving data from leveldb to flatten files.
+	// So during the first check for ancient db, the data is not yet in there,
+	// but when we reach into leveldb, the data was already moved. That would
+	// result in a not found error.
+	data, _ = db.Ancient(freezerEtxSetsTable, number)
+	if len(data) > 0 {
+		h, _ := db.Ancient(freezerHashTable, number)
+		if common.BytesToHash(h) == hash {
+			return data
+		}
+	}
+	return nil // Can't find the data anywhere.
+}
+
+// ReadEtxSetDiff retreives the EtxSetDiff applied by a given block
+func ReadEtxSetDiff(db ethdb.Reader, hash common.Hash, number uint64) *types.EtxSetDiff {
+	data := ReadEtxSetDiffRLP(db, hash, number)
+	if len(data) == 0 {
+		return nil
+	}
+	diff := new(types.EtxSetDiff)
+	if err := rlp.Decode(bytes.NewReader(data), diff); err != nil {
+		log.Error("Invalid etx set diff RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return diff
+}
+
+// WriteEtxSetDiff stores the EtxSetDiff applied by a given block
+func WriteEtxSetDiff(db ethdb.KeyValueWriter, hash common.Hash, number uint64, diff *types.EtxSetDiff) {
+	data, err := rlp.EncodeToBytes(diff)
+	if err != nil {
+		log.Fatal("Failed to RLP encode etx set diff", "err", err)
+	}
+	if err := db.Put(etxSetDiffKey(number, hash), data); err != nil {
+		log.Fatal("Failed to store etx set diff", "err", err)
+	}
+}
+
+// DeleteEtxSetDiff removes the EtxSetDiff applied by a given block.
+func DeleteEtxSetDiff(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(etxSetDiffKey(number, hash)); err != nil {
+		log.Fatal("Failed to delete etx set diff", "err", err)
+	}
+}
+
 // ReadPendingEtxsRLP retrieves the set of pending ETXs for the given block, in RLP encoding
 func ReadPendingEtxsRLP(db ethdb.Reader, hash common.Hash) rlp.RawValue {
-	// Try to look up the data in leveldb.
+	// Try to look up the data in leveldb first, as the data of the blocks of
+	// the subordinate chains is never frozen.
 	data, _ := db.Get(pendingEtxsKey(hash))
 	if len(data) > 0 {
 		return data
 	}
-	return nil // Can't find the data anywhere.
+	return readAncientByHash(db, freezerPendingEtxsTable, hash)
 }
 
 // WritePendingEtxsRLP stores the pending ETXs corresponding to a given block, in RLP encoding.
@@ -1241,8 +1388,7 @@ func DeletePendingEtxs(db ethdb.KeyValueWriter, hash common.Hash) {
 
 // ReadPendingEtxsRollup retreives the pending ETXs rollup corresponding to a given block
 func ReadPendingEtxsRollup(db ethdb.Reader, hash common.Hash) *types.PendingEtxsRollup {
-	// Try to look up the data in leveldb.
-	data, _ := db.Get(pendingEtxsRollupKey(hash))
+	data := ReadPendingEtxsRollupRLP(db, hash)
 	if len(data) == 0 {
 		return nil
 	}
@@ -1254,6 +1400,16 @@ func ReadPendingEtxsRollup(db ethdb.Reader, hash common.Hash) *types.PendingEtxs
 	return &pendingEtxsRollup
 }
 
+// ReadPendingEtxsRollupRLP retrieves the pending ETXs rollup corresponding to a
+// given block, in RLP encoding.
+func ReadPendingEtxsRollupRLP(db ethdb.Reader, hash common.Hash) rlp.RawValue {
+	data, _ := db.Get(pendingEtxsRollupKey(hash))
+	if len(data) > 0 {
+		return data
+	}
+	return readAncientByHash(db, freezerPendingEtxsRollupTable, hash)
+}
+
 // WritePendingEtxsRollup stores the pending ETXs rollup corresponding to a given block
 func WritePendingEtxsRollup(db ethdb.KeyValueWriter, pendingEtxsRollup types.PendingEtxsRollup) {
 	data, err := rlp.EncodeToBytes(pendingEtxsRollup)
@@ -1272,10 +1428,41 @@ func DeletePendingEtxsRollup(db ethdb.KeyValueWriter, hash common.Hash) {
 	}
 }
 
+// ReadEtxStatus retreives the lifecycle of the ETX with the given hash
+func ReadEtxStatus(db ethdb.Reader, hash common.Hash) *types.EtxStatus {
+	data, _ := db.Get(etxStatusKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	status := new(types.EtxStatus)
+	if err := rlp.Decode(bytes.NewReader(data), status); err != nil {
+		log.Error("Invalid etx status RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return status
+}
+
+// WriteEtxStatus stores the lifecycle of the ETX with the given hash
+func WriteEtxStatus(db ethdb.KeyValueWriter, hash common.Hash, status *types.EtxStatus) {
+	data, err := rlp.EncodeToBytes(status)
+	if err != nil {
+		log.Fatal("Failed to RLP encode etx status", "err", err)
+	}
+	if err := db.Put(etxStatusKey(hash), data); err != nil {
+		log.Fatal("Failed to store etx status", "err", err)
+	}
+}
+
+// DeleteEtxStatus removes the lifecycle of the ETX with the given hash
+func DeleteEtxStatus(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(etxStatusKey(hash)); err != nil {
+		log.Fatal("Failed to delete etx status", "err", err)
+	}
+}
+
 // ReadManifest retreives the manifest corresponding to a given block
 func ReadManifest(db ethdb.Reader, hash common.Hash) types.BlockManifest {
-	// Try to look up the data in leveldb.
-	data, _ := db.Get(manifestKey(hash))
+	data := ReadManifestRLP(db, hash)
 	if len(data) == 0 {
 		return nil
 	}
@@ -1287,6 +1474,16 @@ func ReadManifest(db ethdb.Reader, hash common.Hash) types.BlockManifest {
 	return manifest
 }
 
+// ReadManifestRLP retrieves the manifest corresponding to a given block, in RLP
+// encoding.
+func ReadManifestRLP(db ethdb.Reader, hash common.Hash) rlp.RawValue {
+	data, _ := db.Get(manifestKey(hash))
+	if len(data) > 0 {
+		return data
+	}
+	return readAncientByHash(db, freezerManifestTable, hash)
+}
+
 // WriteManifest stores the manifest corresponding to a given block
 func WriteManifest(db ethdb.KeyValueWriter, hash common.Hash, manifest types.BlockManifest) {
 	data, err := rlp.EncodeToBytes(manifest)
@@ -1307,12 +1504,11 @@ func DeleteManifest(db ethdb.KeyValueWriter, hash common.Hash) {
 
 // ReadBloomRLP retrieves the bloom for the given block, in RLP encoding
 func ReadBloomRLP(db ethdb.Reader, hash common.Hash) rlp.RawValue {
-	// Try to look up the data in leveldb.
 	data, _ := db.Get(bloomKey(hash))
 	if len(data) > 0 {
 		return data
 	}
-	return nil // Can't find the data anywhere.
+	return readAncientByHash(db, freezerBloomTable, hash)
 }
 
 // WriteBloomRLP stores the bloom corresponding to a given block, in RLP encoding.
@@ -1352,6 +1548,25 @@ func DeleteBloom(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// readAncientByHash retrieves the data of a canonical block from the given
+// ancient table, which only holds the data of the blocks of the chain itself.
+// The block is looked up through the hash to number mapping, which is kept in
+// leveldb when the block is frozen.
+func readAncientByHash(db ethdb.Reader, kind string, hash common.Hash) rlp.RawValue {
+	number := ReadHeaderNumber(db, hash)
+	if number == nil {
+		return nil
+	}
+	data, _ := db.Ancient(kind, *number)
+	if len(data) == 0 {
+		return nil
+	}
+	if h, _ := db.Ancient(freezerHashTable, *number); common.BytesToHash(h) != hash {
+		return nil
+	}
+	return data
+}
+
 // ReadBadHashesList retreives the bad hashes corresponding to the recent fork
 func ReadBadHashesList(db ethdb.Reader) types.BlockManifest {
 	// Try to look up the data in leveldb.
@@ -1378,6 +1593,58 @@ func WriteBadHashesList(db ethdb.KeyValueWriter, badHashes []common.Hash) {
 	}
 }
 
+// ReadCheckpoints retrieves the blocks trusted to be part of the chain.
+func ReadCheckpoints(db ethdb.KeyValueReader) []types.HashAndNumber {
+	data, _ := db.Get(checkpointsKey)
+	if len(data) == 0 {
+		return nil
+	}
+	checkpoints := []types.HashAndNumber{}
+	if err := rlp.Decode(bytes.NewReader(data), &checkpoints); err != nil {
+		log.Error("Invalid checkpoints RLP", "err", err)
+		return nil
+	}
+	return checkpoints
+}
+
+// WriteCheckpoints stores the blocks trusted to be part of the chain.
+func WriteCheckpoints(db ethdb.KeyValueWriter, checkpoints []types.HashAndNumber) {
+	data, err := rlp.EncodeToBytes(checkpoints)
+	if err != nil {
+		log.Fatal("Failed to RLP encode checkpoints", "err", err)
+	}
+	if err := db.Put(checkpointsKey, data); err != nil {
+		log.Fatal("Failed to store checkpoints", "err", err)
+	}
+}
+
+// ReadCheckpointConflicts retrieves the blocks listed as bad by the checkpoint
+// at their number.
+func ReadCheckpointConflicts(db ethdb.KeyValueReader) []types.HashAndNumber {
+	data, _ := db.Get(checkpointConflictsKey)
+	if len(data) == 0 {
+		return nil
+	}
+	conflicts := []types.HashAndNumber{}
+	if err := rlp.Decode(bytes.NewReader(data), &conflicts); err != nil {
+		log.Error("Invalid checkpoint conflicts RLP", "err", err)
+		return nil
+	}
+	return conflicts
+}
+
+// WriteCheckpointConflicts stores the blocks listed as bad by the checkpoint at
+// their number.
+func WriteCheckpointConflicts(db ethdb.KeyValueWriter, conflicts []types.HashAndNumber) {
+	data, err := rlp.EncodeToBytes(conflicts)
+	if err != nil {
+		log.Fatal("Failed to RLP encode checkpoint conflicts", "err", err)
+	}
+	if err := db.Put(checkpointConflictsKey, data); err != nil {
+		log.Fatal("Failed to store checkpoint conflicts", "err", err)
+	}
+}
+
 // DeleteBadHashesList removes badHashesList from the database
 func DeleteBadHashesList(db ethdb.KeyValueWriter) {
 	if err := db.Delete(badHashesListPrefix); err != nil {
@@ -34,37 +34,43 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
+// newTestHeader creates an empty header with the given number and extra data.
+func newTestHeader(number int64, extra string) *types.Header {
+	header := types.EmptyHeader()
+	header.SetNumber(big.NewInt(number), common.ZONE_CTX)
+	header.SetExtra([]byte(extra))
+	return header
+}
+
 // Tests block header storage and retrieval operations.
 func TestHeaderStorage(t *testing.T) {
 	db := NewMemoryDatabase()
 
 	// Create a test header to move around the database and make sure it's really new
 	header := types.EmptyHeader()
-	header.SetNumber(big.NewInt(42))
+	header.SetNumber(big.NewInt(42), common.ZONE_CTX)
 	header.SetExtra([]byte("test header"))
-	if entry := ReadHeader(db, header.Hash(), header.NumberU64()); entry != nil {
+	if entry := ReadHeader(db, header.Hash(), header.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Non existent header returned: %v", entry)
 	}
 	// Write and verify the header in the database
-	WriteHeader(db, header)
-	if entry := ReadHeader(db, header.Hash(), header.NumberU64()); entry == nil {
+	WriteHeader(db, header, common.ZONE_CTX)
+	if entry := ReadHeader(db, header.Hash(), header.NumberU64(common.ZONE_CTX)); entry == nil {
 		t.Fatalf("Stored header not found")
 	} else if entry.Hash() != header.Hash() {
 		t.Fatalf("Retrieved header mismatch: have %v, want %v", entry, header)
 	}
-	if entry := ReadHeaderRLP(db, header.Hash(), header.NumberU64()); entry == nil {
+	if entry := ReadHeaderRLP(db, header.Hash(), header.NumberU64(common.ZONE_CTX)); entry == nil {
 		t.Fatalf("Stored header RLP not found")
 	} else {
-		hasher := sha3.NewLegacyKeccak256()
-		hasher.Write(entry)
-
-		if hash := common.BytesToHash(hasher.Sum(nil)); hash != header.Hash() {
+		decoded := new(types.Header)
+		if err := rlp.DecodeBytes(entry, decoded); err != nil || decoded.Hash() != header.Hash() {
 			t.Fatalf("Retrieved RLP header mismatch: have %v, want %v", entry, header)
 		}
 	}
 	// Delete the header and verify the execution
-	DeleteHeader(db, header.Hash(), header.NumberU64())
-	if entry := ReadHeader(db, header.Hash(), header.NumberU64()); entry != nil {
+	DeleteHeader(db, header.Hash(), header.NumberU64(common.ZONE_CTX))
+	if entry := ReadHeader(db, header.Hash(), header.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Deleted header returned: %v", entry)
 	}
 }
@@ -114,47 +120,42 @@ func TestBlockStorage(t *testing.T) {
 	db := NewMemoryDatabase()
 
 	// Create a test block to move around the database and make sure it's really new
-	block := types.NewBlockWithHeader(&types.Header{
-		Extra:       []byte("test block"),
-		UncleHash:   types.EmptyUncleHash,
-		TxHash:      types.EmptyRootHash,
-		ReceiptHash: types.EmptyRootHash,
-	})
-	if entry := ReadBlock(db, block.Hash(), block.NumberU64()); entry != nil {
+	block := types.NewBlockWithHeader(newTestHeader(0, "test block"))
+	if entry := ReadBlock(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Non existent block returned: %v", entry)
 	}
-	if entry := ReadHeader(db, block.Hash(), block.NumberU64()); entry != nil {
+	if entry := ReadHeader(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Non existent header returned: %v", entry)
 	}
-	if entry := ReadBody(db, block.Hash(), block.NumberU64()); entry != nil {
+	if entry := ReadBody(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Non existent body returned: %v", entry)
 	}
 	// Write and verify the block in the database
-	WriteBlock(db, block)
-	if entry := ReadBlock(db, block.Hash(), block.NumberU64()); entry == nil {
+	WriteBlock(db, block, common.ZONE_CTX)
+	if entry := ReadBlock(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry == nil {
 		t.Fatalf("Stored block not found")
 	} else if entry.Hash() != block.Hash() {
 		t.Fatalf("Retrieved block mismatch: have %v, want %v", entry, block)
 	}
-	if entry := ReadHeader(db, block.Hash(), block.NumberU64()); entry == nil {
+	if entry := ReadHeader(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry == nil {
 		t.Fatalf("Stored header not found")
 	} else if entry.Hash() != block.Header().Hash() {
 		t.Fatalf("Retrieved header mismatch: have %v, want %v", entry, block.Header())
 	}
-	if entry := ReadBody(db, block.Hash(), block.NumberU64()); entry == nil {
+	if entry := ReadBody(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry == nil {
 		t.Fatalf("Stored body not found")
 	} else if types.DeriveSha(types.Transactions(entry.Transactions), newHasher()) != types.DeriveSha(block.Transactions(), newHasher()) || types.CalcUncleHash(entry.Uncles) != types.CalcUncleHash(block.Uncles()) {
 		t.Fatalf("Retrieved body mismatch: have %v, want %v", entry, block.Body())
 	}
 	// Delete the block and verify the execution
-	DeleteBlock(db, block.Hash(), block.NumberU64())
-	if entry := ReadBlock(db, block.Hash(), block.NumberU64()); entry != nil {
+	DeleteBlock(db, block.Hash(), block.NumberU64(common.ZONE_CTX))
+	if entry := ReadBlock(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Deleted block returned: %v", entry)
 	}
-	if entry := ReadHeader(db, block.Hash(), block.NumberU64()); entry != nil {
+	if entry := ReadHeader(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Deleted header returned: %v", entry)
 	}
-	if entry := ReadBody(db, block.Hash(), block.NumberU64()); entry != nil {
+	if entry := ReadBody(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Deleted body returned: %v", entry)
 	}
 }
@@ -162,31 +163,26 @@ func TestBlockStorage(t *testing.T) {
 // Tests that partial block contents don't get reassembled into full blocks.
 func TestPartialBlockStorage(t *testing.T) {
 	db := NewMemoryDatabase()
-	block := types.NewBlockWithHeader(&types.Header{
-		Extra:       []byte("test block"),
-		UncleHash:   types.EmptyUncleHash,
-		TxHash:      types.EmptyRootHash,
-		ReceiptHash: types.EmptyRootHash,
-	})
+	block := types.NewBlockWithHeader(newTestHeader(0, "test block"))
 	// Store a header and check that it's not recognized as a block
-	WriteHeader(db, block.Header())
-	if entry := ReadBlock(db, block.Hash(), block.NumberU64()); entry != nil {
+	WriteHeader(db, block.Header(), common.ZONE_CTX)
+	if entry := ReadBlock(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Non existent block returned: %v", entry)
 	}
-	DeleteHeader(db, block.Hash(), block.NumberU64())
+	DeleteHeader(db, block.Hash(), block.NumberU64(common.ZONE_CTX))
 
 	// Store a body and check that it's not recognized as a block
-	WriteBody(db, block.Hash(), block.NumberU64(), block.Body())
-	if entry := ReadBlock(db, block.Hash(), block.NumberU64()); entry != nil {
+	WriteBody(db, block.Hash(), block.NumberU64(common.ZONE_CTX), block.Body())
+	if entry := ReadBlock(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry != nil {
 		t.Fatalf("Non existent block returned: %v", entry)
 	}
-	DeleteBody(db, block.Hash(), block.NumberU64())
+	DeleteBody(db, block.Hash(), block.NumberU64(common.ZONE_CTX))
 
 	// Store a header and a body separately and check reassembly
-	WriteHeader(db, block.Header())
-	WriteBody(db, block.Hash(), block.NumberU64(), block.Body())
+	WriteHeader(db, block.Header(), common.ZONE_CTX)
+	WriteBody(db, block.Hash(), block.NumberU64(common.ZONE_CTX), block.Body())
 
-	if entry := ReadBlock(db, block.Hash(), block.NumberU64()); entry == nil {
+	if entry := ReadBlock(db, block.Hash(), block.NumberU64(common.ZONE_CTX)); entry == nil {
 		t.Fatalf("Stored block not found")
 	} else if entry.Hash() != block.Hash() {
 		t.Fatalf("Retrieved block mismatch: have %v, want %v", entry, block)
@@ -198,35 +194,23 @@ func TestBadBlockStorage(t *testing.T) {
 	db := NewMemoryDatabase()
 
 	// Create a test block to move around the database and make sure it's really new
-	block := types.NewBlockWithHeader(&types.Header{
-		Number:      big.NewInt(1),
-		Extra:       []byte("bad block"),
-		UncleHash:   types.EmptyUncleHash,
-		TxHash:      types.EmptyRootHash,
-		ReceiptHash: types.EmptyRootHash,
-	})
+	block := types.NewBlockWithHeader(newTestHeader(1, "bad block"))
 	if entry := ReadBadBlock(db, block.Hash()); entry != nil {
 		t.Fatalf("Non existent block returned: %v", entry)
 	}
 	// Write and verify the block in the database
-	WriteBadBlock(db, block)
+	WriteBadBlock(db, block, common.ZONE_CTX)
 	if entry := ReadBadBlock(db, block.Hash()); entry == nil {
 		t.Fatalf("Stored block not found")
 	} else if entry.Hash() != block.Hash() {
 		t.Fatalf("Retrieved block mismatch: have %v, want %v", entry, block)
 	}
 	// Write one more bad block
-	blockTwo := types.NewBlockWithHeader(&types.Header{
-		Number:      big.NewInt(2),
-		Extra:       []byte("bad block two"),
-		UncleHash:   types.EmptyUncleHash,
-		TxHash:      types.EmptyRootHash,
-		ReceiptHash: types.EmptyRootHash,
-	})
-	WriteBadBlock(db, blockTwo)
+	blockTwo := types.NewBlockWithHeader(newTestHeader(2, "bad block two"))
+	WriteBadBlock(db, blockTwo, common.ZONE_CTX)
 
 	// Write the block one again, should be filtered out.
-	WriteBadBlock(db, block)
+	WriteBadBlock(db, block, common.ZONE_CTX)
 	badBlocks := ReadAllBadBlocks(db)
 	if len(badBlocks) != 2 {
 		t.Fatalf("Failed to load all bad blocks")
@@ -235,22 +219,16 @@ func TestBadBlockStorage(t *testing.T) {
 	// Write a bunch of bad blocks, all the blocks are should sorted
 	// in reverse order. The extra blocks should be truncated.
 	for _, n := range rand.Perm(100) {
-		block := types.NewBlockWithHeader(&types.Header{
-			Number:      big.NewInt(int64(n)),
-			Extra:       []byte("bad block"),
-			UncleHash:   types.EmptyUncleHash,
-			TxHash:      types.EmptyRootHash,
-			ReceiptHash: types.EmptyRootHash,
-		})
-		WriteBadBlock(db, block)
+		block := types.NewBlockWithHeader(newTestHeader(int64(n), "bad block"))
+		WriteBadBlock(db, block, common.ZONE_CTX)
 	}
 	badBlocks = ReadAllBadBlocks(db)
 	if len(badBlocks) != badBlockToKeep {
 		t.Fatalf("The number of persised bad blocks in incorrect %d", len(badBlocks))
 	}
 	for i := 0; i < len(badBlocks)-1; i++ {
-		if badBlocks[i].NumberU64() < badBlocks[i+1].NumberU64() {
-			t.Fatalf("The bad blocks are not sorted #[%d](%d) < #[%d](%d)", i, i+1, badBlocks[i].NumberU64(), badBlocks[i+1].NumberU64())
+		if badBlocks[i].NumberU64(common.ZONE_CTX) < badBlocks[i+1].NumberU64(common.ZONE_CTX) {
+			t.Fatalf("The bad blocks are not sorted #[%d](%d) < #[%d](%d)", i, i+1, badBlocks[i].NumberU64(common.ZONE_CTX), badBlocks[i+1].NumberU64(common.ZONE_CTX))
 		}
 	}
 
@@ -262,29 +240,6 @@ func TestBadBlockStorage(t *testing.T) {
 	}
 }
 
-// Tests block total difficulty storage and retrieval operations.
-func TestTdStorage(t *testing.T) {
-	db := NewMemoryDatabase()
-
-	// Create a test TD to move around the database and make sure it's really new
-	hash, td := common.Hash{}, big.NewInt(314)
-	if entry := ReadTd(db, hash, 0); entry != nil {
-		t.Fatalf("Non existent TD returned: %v", entry)
-	}
-	// Write and verify the TD in the database
-	WriteTd(db, hash, 0, td)
-	if entry := ReadTd(db, hash, 0); entry == nil {
-		t.Fatalf("Stored TD not found")
-	} else if entry.Cmp(td) != 0 {
-		t.Fatalf("Retrieved TD mismatch: have %v, want %v", entry, td)
-	}
-	// Delete the TD and verify the execution
-	DeleteTd(db, hash, 0)
-	if entry := ReadTd(db, hash, 0); entry != nil {
-		t.Fatalf("Deleted TD returned: %v", entry)
-	}
-}
-
 // Tests that canonical numbers can be mapped to hashes and retrieved.
 func TestCanonicalMappingStorage(t *testing.T) {
 	db := NewMemoryDatabase()
@@ -312,9 +267,8 @@ func TestCanonicalMappingStorage(t *testing.T) {
 func TestHeadStorage(t *testing.T) {
 	db := NewMemoryDatabase()
 
-	blockHead := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block header")})
-	blockFull := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block full")})
-	blockFast := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block fast")})
+	blockHead := types.NewBlockWithHeader(newTestHeader(0, "test block header"))
+	blockFull := types.NewBlockWithHeader(newTestHeader(0, "test block full"))
 
 	// Check that no head entries are in a pristine database
 	if entry := ReadHeadHeaderHash(db); entry != (common.Hash{}) {
@@ -323,13 +277,9 @@ func TestHeadStorage(t *testing.T) {
 	if entry := ReadHeadBlockHash(db); entry != (common.Hash{}) {
 		t.Fatalf("Non head block entry returned: %v", entry)
 	}
-	if entry := ReadHeadFastBlockHash(db); entry != (common.Hash{}) {
-		t.Fatalf("Non fast head block entry returned: %v", entry)
-	}
 	// Assign separate entries for the head header and block
 	WriteHeadHeaderHash(db, blockHead.Hash())
 	WriteHeadBlockHash(db, blockFull.Hash())
-	WriteHeadFastBlockHash(db, blockFast.Hash())
 
 	// Check that both heads are present, and different (i.e. two heads maintained)
 	if entry := ReadHeadHeaderHash(db); entry != blockHead.Hash() {
@@ -338,9 +288,6 @@ func TestHeadStorage(t *testing.T) {
 	if entry := ReadHeadBlockHash(db); entry != blockFull.Hash() {
 		t.Fatalf("Head block hash mismatch: have %v, want %v", entry, blockFull.Hash())
 	}
-	if entry := ReadHeadFastBlockHash(db); entry != blockFast.Hash() {
-		t.Fatalf("Fast head block hash mismatch: have %v, want %v", entry, blockFast.Hash())
-	}
 }
 
 // Tests that receipts associated with a single block can be stored and retrieved.
@@ -348,8 +295,8 @@ func TestBlockReceiptStorage(t *testing.T) {
 	db := NewMemoryDatabase()
 
 	// Create a live block since we need metadata to reconstruct the receipt
-	tx1 := types.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil)
-	tx2 := types.NewTransaction(2, common.HexToAddress("0x2"), big.NewInt(2), 2, big.NewInt(2), nil)
+	tx1 := newTestTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil)
+	tx2 := newTestTransaction(2, common.HexToAddress("0x2"), big.NewInt(2), 2, big.NewInt(2), nil)
 
 	body := &types.Body{Transactions: types.Transactions{tx1, tx2}}
 
@@ -365,7 +312,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 		ContractAddress: common.BytesToAddress([]byte{0x01, 0x11, 0x11}),
 		GasUsed:         111111,
 	}
-	receipt1.Bloom() = types.CreateBloom(types.Receipts{receipt1})
+	receipt1.Bloom = types.CreateBloom(types.Receipts{receipt1})
 
 	receipt2 := &types.Receipt{
 		PostState:         common.Hash{2}.Bytes(),
@@ -378,7 +325,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 		ContractAddress: common.BytesToAddress([]byte{0x02, 0x22, 0x22}),
 		GasUsed:         222222,
 	}
-	receipt2.Bloom() = types.CreateBloom(types.Receipts{receipt2})
+	receipt2.Bloom = types.CreateBloom(types.Receipts{receipt2})
 	receipts := []*types.Receipt{receipt1, receipt2}
 
 	// Check that no receipt entries are in a pristine database
@@ -444,21 +391,15 @@ func TestAncientStorage(t *testing.T) {
 	}
 	defer os.Remove(frdir)
 
-	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), frdir, "", false)
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), frdir, "", false, common.ZONE_CTX)
 	if err != nil {
 		t.Fatalf("failed to create database with ancient backend")
 	}
 	defer db.Close()
 	// Create a test block
-	block := types.NewBlockWithHeader(&types.Header{
-		Number:      big.NewInt(0),
-		Extra:       []byte("test block"),
-		UncleHash:   types.EmptyUncleHash,
-		TxHash:      types.EmptyRootHash,
-		ReceiptHash: types.EmptyRootHash,
-	})
+	block := types.NewBlockWithHeader(newTestHeader(0, "test block"))
 	// Ensure nothing non-existent will be read
-	hash, number := block.Hash(), block.NumberU64()
+	hash, number := block.Hash(), block.NumberU64(common.ZONE_CTX)
 	if blob := ReadHeaderRLP(db, hash, number); len(blob) > 0 {
 		t.Fatalf("non existent header returned")
 	}
@@ -468,11 +409,13 @@ func TestAncientStorage(t *testing.T) {
 	if blob := ReadReceiptsRLP(db, hash, number); len(blob) > 0 {
 		t.Fatalf("non existent receipts returned")
 	}
-	if blob := ReadTdRLP(db, hash, number); len(blob) > 0 {
-		t.Fatalf("non existent td returned")
-	}
 	// Write and verify the header in the database
-	WriteAncientBlock(db, block, nil, big.NewInt(100))
+	header, _ := rlp.EncodeToBytes(block.Header())
+	body, _ := rlp.EncodeToBytes(block.Body())
+	receipts, _ := rlp.EncodeToBytes([]*types.ReceiptForStorage{})
+	if err := db.AppendAncient(number, hash[:], header, body, receipts, nil); err != nil {
+		t.Fatalf("failed to write ancient block: %v", err)
+	}
 	if blob := ReadHeaderRLP(db, hash, number); len(blob) == 0 {
 		t.Fatalf("no header returned")
 	}
@@ -482,9 +425,6 @@ func TestAncientStorage(t *testing.T) {
 	if blob := ReadReceiptsRLP(db, hash, number); len(blob) == 0 {
 		t.Fatalf("no receipts returned")
 	}
-	if blob := ReadTdRLP(db, hash, number); len(blob) == 0 {
-		t.Fatalf("no td returned")
-	}
 	// Use a fake hash for data retrieval, nothing should be returned.
 	fakeHash := common.BytesToHash([]byte{0x01, 0x02, 0x03})
 	if blob := ReadHeaderRLP(db, fakeHash, number); len(blob) != 0 {
@@ -496,9 +436,6 @@ func TestAncientStorage(t *testing.T) {
 	if blob := ReadReceiptsRLP(db, fakeHash, number); len(blob) != 0 {
 		t.Fatalf("invalid receipts returned")
 	}
-	if blob := ReadTdRLP(db, fakeHash, number); len(blob) != 0 {
-		t.Fatalf("invalid td returned")
-	}
 }
 
 func TestCanonicalHashIteration(t *testing.T) {
@@ -523,7 +460,7 @@ func TestCanonicalHashIteration(t *testing.T) {
 	// Fill database with testing data.
 	for i := uint64(1); i <= 8; i++ {
 		WriteCanonicalHash(db, common.Hash{}, i)
-		WriteTd(db, common.Hash{}, i, big.NewInt(10)) // Write some interferential data
+		WriteHeaderNumber(db, common.Hash{}, i) // Write some interferential data
 	}
 	for i, c := range cases {
 		numbers, _ := ReadAllCanonicalHashes(db, c.from, c.to, c.limit)
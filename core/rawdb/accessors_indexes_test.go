@@ -54,6 +54,20 @@ func (h *testHasher) Hash() common.Hash {
 	return common.BytesToHash(h.hasher.Sum(nil))
 }
 
+// newTestTransaction creates an unsigned internal transaction.
+func newTestTransaction(nonce uint64, to common.Address, value *big.Int, gas uint64, gasPrice *big.Int, data []byte) *types.Transaction {
+	return types.NewTx(&types.InternalTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     nonce,
+		GasTipCap: gasPrice,
+		GasFeeCap: gasPrice,
+		Gas:       gas,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	})
+}
+
 // Tests that positional lookup metadata can be stored and retrieved.
 func TestLookupStorage(t *testing.T) {
 	tests := []struct {
@@ -63,7 +77,7 @@ func TestLookupStorage(t *testing.T) {
 		{
 			"DatabaseV6",
 			func(db ethdb.Writer, block *types.Block) {
-				WriteTxLookupEntriesByBlock(db, block)
+				WriteTxLookupEntriesByBlock(db, block, common.ZONE_CTX)
 			},
 		},
 		{
@@ -80,7 +94,7 @@ func TestLookupStorage(t *testing.T) {
 				for index, tx := range block.Transactions() {
 					entry := LegacyTxLookupEntry{
 						BlockHash:  block.Hash(),
-						BlockIndex: block.NumberU64(),
+						BlockIndex: block.NumberU64(common.ZONE_CTX),
 						Index:      uint64(index),
 					}
 					data, _ := rlp.EncodeToBytes(entry)
@@ -94,12 +108,12 @@ func TestLookupStorage(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			db := NewMemoryDatabase()
 
-			tx1 := types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11})
-			tx2 := types.NewTransaction(2, common.BytesToAddress([]byte{0x22}), big.NewInt(222), 2222, big.NewInt(22222), []byte{0x22, 0x22, 0x22})
-			tx3 := types.NewTransaction(3, common.BytesToAddress([]byte{0x33}), big.NewInt(333), 3333, big.NewInt(33333), []byte{0x33, 0x33, 0x33})
+			tx1 := newTestTransaction(1, common.BytesToAddress([]byte{0x11}), big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11})
+			tx2 := newTestTransaction(2, common.BytesToAddress([]byte{0x22}), big.NewInt(222), 2222, big.NewInt(22222), []byte{0x22, 0x22, 0x22})
+			tx3 := newTestTransaction(3, common.BytesToAddress([]byte{0x33}), big.NewInt(333), 3333, big.NewInt(33333), []byte{0x33, 0x33, 0x33})
 			txs := []*types.Transaction{tx1, tx2, tx3}
 
-			block := types.NewBlock(&types.Header{Number: big.NewInt(314)}, txs, nil, nil, newHasher())
+			block := types.NewBlock(newTestHeader(314, ""), txs, nil, nil, nil, nil, newHasher(), common.ZONE_CTX)
 
 			// Check that no transactions entries are in a pristine database
 			for i, tx := range txs {
@@ -108,16 +122,16 @@ func TestLookupStorage(t *testing.T) {
 				}
 			}
 			// Insert all the transactions into the database, and verify contents
-			WriteCanonicalHash(db, block.Hash(), block.NumberU64())
-			WriteBlock(db, block)
+			WriteCanonicalHash(db, block.Hash(), block.NumberU64(common.ZONE_CTX))
+			WriteBlock(db, block, common.ZONE_CTX)
 			tc.writeTxLookupEntriesByBlock(db, block)
 
 			for i, tx := range txs {
 				if txn, hash, number, index := ReadTransaction(db, tx.Hash()); txn == nil {
 					t.Fatalf("tx #%d [%x]: transaction not found", i, tx.Hash())
 				} else {
-					if hash != block.Hash() || number != block.NumberU64() || index != uint64(i) {
-						t.Fatalf("tx #%d [%x]: positional metadata mismatch: have %x/%d/%d, want %x/%v/%v", i, tx.Hash(), hash, number, index, block.Hash(), block.NumberU64(), i)
+					if hash != block.Hash() || number != block.NumberU64(common.ZONE_CTX) || index != uint64(i) {
+						t.Fatalf("tx #%d [%x]: positional metadata mismatch: have %x/%d/%d, want %x/%v/%v", i, tx.Hash(), hash, number, index, block.Hash(), block.NumberU64(common.ZONE_CTX), i)
 					}
 					if tx.Hash() != txn.Hash() {
 						t.Fatalf("tx #%d [%x]: transaction mismatch: have %v, want %v", i, tx.Hash(), txn, tx)
@@ -140,8 +154,8 @@ func TestDeleteBloomBits(t *testing.T) {
 	db := NewMemoryDatabase()
 	for i := uint(0); i < 2; i++ {
 		for s := uint64(0); s < 2; s++ {
-			WriteBloomBits(db, i, s, params.ColosseumGenesisHash, []byte{0x01, 0x02})
-			WriteBloomBits(db, i, s, params.RinkebyGenesisHash, []byte{0x01, 0x02})
+			WriteBloomBits(db, i, s, params.ProgpowColosseumGenesisHash, []byte{0x01, 0x02})
+			WriteBloomBits(db, i, s, params.ProgpowGardenGenesisHash, []byte{0x01, 0x02})
 		}
 	}
 	check := func(bit uint, section uint64, head common.Hash, exist bool) {
@@ -154,26 +168,26 @@ func TestDeleteBloomBits(t *testing.T) {
 		}
 	}
 	// Check the existence of written data.
-	check(0, 0, params.ColosseumGenesisHash, true)
-	check(0, 0, params.RinkebyGenesisHash, true)
+	check(0, 0, params.ProgpowColosseumGenesisHash, true)
+	check(0, 0, params.ProgpowGardenGenesisHash, true)
 
 	// Check the existence of deleted data.
 	DeleteBloombits(db, 0, 0, 1)
-	check(0, 0, params.ColosseumGenesisHash, false)
-	check(0, 0, params.RinkebyGenesisHash, false)
-	check(0, 1, params.ColosseumGenesisHash, true)
-	check(0, 1, params.RinkebyGenesisHash, true)
+	check(0, 0, params.ProgpowColosseumGenesisHash, false)
+	check(0, 0, params.ProgpowGardenGenesisHash, false)
+	check(0, 1, params.ProgpowColosseumGenesisHash, true)
+	check(0, 1, params.ProgpowGardenGenesisHash, true)
 
 	// Check the existence of deleted data.
 	DeleteBloombits(db, 0, 0, 2)
-	check(0, 0, params.ColosseumGenesisHash, false)
-	check(0, 0, params.RinkebyGenesisHash, false)
-	check(0, 1, params.ColosseumGenesisHash, false)
-	check(0, 1, params.RinkebyGenesisHash, false)
+	check(0, 0, params.ProgpowColosseumGenesisHash, false)
+	check(0, 0, params.ProgpowGardenGenesisHash, false)
+	check(0, 1, params.ProgpowColosseumGenesisHash, false)
+	check(0, 1, params.ProgpowGardenGenesisHash, false)
 
 	// Bit1 shouldn't be affect.
-	check(1, 0, params.ColosseumGenesisHash, true)
-	check(1, 0, params.RinkebyGenesisHash, true)
-	check(1, 1, params.ColosseumGenesisHash, true)
-	check(1, 1, params.RinkebyGenesisHash, true)
+	check(1, 0, params.ProgpowColosseumGenesisHash, true)
+	check(1, 0, params.ProgpowGardenGenesisHash, true)
+	check(1, 1, params.ProgpowColosseumGenesisHash, true)
+	check(1, 1, params.ProgpowGardenGenesisHash, true)
 }
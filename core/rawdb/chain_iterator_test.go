@@ -34,35 +34,15 @@ func TestChainIterator(t *testing.T) {
 	var block *types.Block
 	var txs []*types.Transaction
 	to := common.BytesToAddress([]byte{0x11})
-	block = types.NewBlock(&types.Header{Number: big.NewInt(int64(0))}, nil, nil, nil, newHasher()) // Empty genesis block
-	WriteBlock(chainDb, block)
-	WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+	block = types.NewBlock(newTestHeader(int64(0), ""), nil, nil, nil, nil, nil, newHasher(), common.ZONE_CTX) // Empty genesis block
+	WriteBlock(chainDb, block, common.ZONE_CTX)
+	WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64(common.ZONE_CTX))
 	for i := uint64(1); i <= 10; i++ {
-		var tx *types.Transaction
-		if i%2 == 0 {
-			tx = types.NewTx(&types.LegacyTx{
-				Nonce:    i,
-				GasPrice: big.NewInt(11111),
-				Gas:      1111,
-				To:       &to,
-				Value:    big.NewInt(111),
-				Data:     []byte{0x11, 0x11, 0x11},
-			})
-		} else {
-			tx = types.NewTx(&types.AccessListTx{
-				ChainID:  big.NewInt(1337),
-				Nonce:    i,
-				GasPrice: big.NewInt(11111),
-				Gas:      1111,
-				To:       &to,
-				Value:    big.NewInt(111),
-				Data:     []byte{0x11, 0x11, 0x11},
-			})
-		}
+		tx := newTestTransaction(i, to, big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11})
 		txs = append(txs, tx)
-		block = types.NewBlock(&types.Header{Number: big.NewInt(int64(i))}, []*types.Transaction{tx}, nil, nil, newHasher())
-		WriteBlock(chainDb, block)
-		WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+		block = types.NewBlock(newTestHeader(int64(i), ""), []*types.Transaction{tx}, nil, nil, nil, nil, newHasher(), common.ZONE_CTX)
+		WriteBlock(chainDb, block, common.ZONE_CTX)
+		WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64(common.ZONE_CTX))
 	}
 
 	var cases = []struct {
@@ -111,36 +91,16 @@ func TestIndexTransactions(t *testing.T) {
 	to := common.BytesToAddress([]byte{0x11})
 
 	// Write empty genesis block
-	block = types.NewBlock(&types.Header{Number: big.NewInt(int64(0))}, nil, nil, nil, newHasher())
-	WriteBlock(chainDb, block)
-	WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+	block = types.NewBlock(newTestHeader(int64(0), ""), nil, nil, nil, nil, nil, newHasher(), common.ZONE_CTX)
+	WriteBlock(chainDb, block, common.ZONE_CTX)
+	WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64(common.ZONE_CTX))
 
 	for i := uint64(1); i <= 10; i++ {
-		var tx *types.Transaction
-		if i%2 == 0 {
-			tx = types.NewTx(&types.LegacyTx{
-				Nonce:    i,
-				GasPrice: big.NewInt(11111),
-				Gas:      1111,
-				To:       &to,
-				Value:    big.NewInt(111),
-				Data:     []byte{0x11, 0x11, 0x11},
-			})
-		} else {
-			tx = types.NewTx(&types.AccessListTx{
-				ChainID:  big.NewInt(1337),
-				Nonce:    i,
-				GasPrice: big.NewInt(11111),
-				Gas:      1111,
-				To:       &to,
-				Value:    big.NewInt(111),
-				Data:     []byte{0x11, 0x11, 0x11},
-			})
-		}
+		tx := newTestTransaction(i, to, big.NewInt(111), 1111, big.NewInt(11111), []byte{0x11, 0x11, 0x11})
 		txs = append(txs, tx)
-		block = types.NewBlock(&types.Header{Number: big.NewInt(int64(i))}, []*types.Transaction{tx}, nil, nil, newHasher())
-		WriteBlock(chainDb, block)
-		WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+		block = types.NewBlock(newTestHeader(int64(i), ""), []*types.Transaction{tx}, nil, nil, nil, nil, newHasher(), common.ZONE_CTX)
+		WriteBlock(chainDb, block, common.ZONE_CTX)
+		WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64(common.ZONE_CTX))
 	}
 	// verify checks whether the tx indices in the range [from, to)
 	// is expected.
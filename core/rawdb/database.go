@@ -125,10 +125,10 @@ func NewDatabase(db ethdb.KeyValueStore) ethdb.Database {
 
 // NewDatabaseWithFreezer creates a high level database on top of a given key-
 // value data store with a freezer moving immutable chain segments into cold
-// storage.
-func NewDatabaseWithFreezer(db ethdb.KeyValueStore, freezer string, namespace string, readonly bool) (ethdb.Database, error) {
+// storage. The context is the one of the chain stored in the database.
+func NewDatabaseWithFreezer(db ethdb.KeyValueStore, freezer string, namespace string, readonly bool, nodeCtx int) (ethdb.Database, error) {
 	// Create the idle freezer instance
-	frdb, err := newFreezer(freezer, namespace, readonly)
+	frdb, err := newFreezer(freezer, namespace, readonly, nodeCtx)
 	if err != nil {
 		return nil, err
 	}
@@ -265,6 +265,7 @@ type OpenOptions struct {
 	Cache             int    // the capacity(in megabytes) of the data caching
 	Handles           int    // number of files to be open simultaneously
 	ReadOnly          bool
+	NodeCtx           int // the context of the chain stored in the database
 }
 
 // openKeyValueDatabase opens a disk-based key-value database, e.g. leveldb or pebble.
@@ -307,7 +308,7 @@ func Open(o OpenOptions) (ethdb.Database, error) {
 	if len(o.AncientsDirectory) == 0 {
 		return kvdb, nil
 	}
-	frdb, err := NewDatabaseWithFreezer(kvdb, o.AncientsDirectory, o.Namespace, o.ReadOnly)
+	frdb, err := NewDatabaseWithFreezer(kvdb, o.AncientsDirectory, o.Namespace, o.ReadOnly, o.NodeCtx)
 	if err != nil {
 		kvdb.Close()
 		return nil, err
@@ -371,12 +372,18 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		preimages       stat
 		bloomBits       stat
 
-		// Ancient store statistics
-		ancientHeadersSize  common.StorageSize
-		ancientBodiesSize   common.StorageSize
-		ancientReceiptsSize common.StorageSize
-		ancientTdsSize      common.StorageSize
-		ancientHashesSize   common.StorageSize
+		// Hierarchy statistics
+		termini        stat
+		manifests      stat
+		pendingEtxs    stat
+		rollups        stat
+		blooms         stat
+		etxSets        stat
+		etxSetDiffs    stat
+		etxStatuses    stat
+		pendingHeaders stat
+		pendingBodies  stat
+		badHashes      stat
 
 		// Les statistic
 		chtTrieNodes   stat
@@ -403,6 +410,33 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bodies.Add(size)
 		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
 			receipts.Add(size)
+		case bytes.HasPrefix(key, terminiPrefix) && len(key) == (len(terminiPrefix)+common.HashLength):
+			termini.Add(size)
+		case bytes.HasPrefix(key, manifestPrefix) && len(key) == (len(manifestPrefix)+common.HashLength):
+			manifests.Add(size)
+		case bytes.HasPrefix(key, pendingEtxsPrefix) && len(key) == (len(pendingEtxsPrefix)+common.HashLength):
+			pendingEtxs.Add(size)
+		case bytes.HasPrefix(key, pendingEtxsRollupPrefix) && len(key) == (len(pendingEtxsRollupPrefix)+common.HashLength):
+			rollups.Add(size)
+		case bytes.HasPrefix(key, bloomPrefix) && len(key) == (len(bloomPrefix)+common.HashLength):
+			blooms.Add(size)
+		case bytes.HasPrefix(key, etxSetPrefix) && len(key) == (len(etxSetPrefix)+8+common.HashLength):
+			etxSets.Add(size)
+		case bytes.HasPrefix(key, etxSetDiffPrefix) && len(key) == (len(etxSetDiffPrefix)+8+common.HashLength):
+			etxSetDiffs.Add(size)
+		case bytes.HasPrefix(key, etxStatusPrefix) && len(key) == (len(etxStatusPrefix)+common.HashLength):
+			etxStatuses.Add(size)
+		case bytes.HasPrefix(key, pendingHeaderPrefix) && len(key) == (len(pendingHeaderPrefix)+common.HashLength),
+			bytes.HasPrefix(key, phTerminiPrefix) && len(key) == (len(phTerminiPrefix)+common.HashLength),
+			bytes.Equal(key, phCacheKey), bytes.Equal(key, phHeadKey):
+			pendingHeaders.Add(size)
+		case bytes.HasPrefix(key, pbBodyPrefix) && len(key) == (len(pbBodyPrefix)+common.HashLength),
+			bytes.HasPrefix(key, candidateBodyPrefix) && len(key) == (len(candidateBodyPrefix)+common.HashLength),
+			bytes.HasPrefix(key, phBodyPrefix) && len(key) == (len(phBodyPrefix)+common.HashLength),
+			bytes.Equal(key, pbBodyHashPrefix):
+			pendingBodies.Add(size)
+		case bytes.Equal(key, badHashesListPrefix):
+			badHashes.Add(size)
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
 			tds.Add(size)
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
@@ -441,7 +475,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				databaseVersionKey, headHeaderKey, headBlockKey, lastPivotKey,
 				fastTrieProgressKey, snapshotDisabledKey, snapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
-				uncleanShutdownKey, badBlockKey,
+				uncleanShutdownKey, badBlockKey, ancientLimitKey, appendJournalKey, headsHashesKey,
+				snapshotSyncStatusKey, checkpointsKey, checkpointConflictsKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -459,19 +494,35 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			logged = time.Now()
 		}
 	}
-	// Inspect append-only file store then.
-	ancientSizes := []*common.StorageSize{&ancientHeadersSize, &ancientBodiesSize, &ancientReceiptsSize, &ancientHashesSize, &ancientTdsSize}
-	for i, category := range []string{freezerHeaderTable, freezerBodiesTable, freezerReceiptTable, freezerHashTable, freezerDifficultyTable, freezerEtxSetsTable} {
-		if size, err := db.AncientSize(category); err == nil {
-			*ancientSizes[i] += common.StorageSize(size)
-			total += common.StorageSize(size)
-		}
-	}
 	// Get number of ancient rows inside the freezer
 	ancients := counter(0)
 	if count, err := db.Ancients(); err == nil {
 		ancients = counter(count)
 	}
+	// Inspect append-only file store then.
+	var ancientStats [][]string
+	for _, category := range []struct {
+		table string
+		name  string
+	}{
+		{freezerHeaderTable, "Headers"},
+		{freezerBodiesTable, "Bodies"},
+		{freezerReceiptTable, "Receipt lists"},
+		{freezerHashTable, "Block number->hash"},
+		{freezerEtxSetsTable, "Etx set diffs"},
+		{freezerTerminiTable, "Termini"},
+		{freezerManifestTable, "Manifests"},
+		{freezerPendingEtxsTable, "Pending etxs"},
+		{freezerPendingEtxsRollupTable, "Pending etxs rollups"},
+		{freezerBloomTable, "Blooms"},
+	} {
+		var size common.StorageSize
+		if n, err := db.AncientSize(category.table); err == nil {
+			size = common.StorageSize(n)
+			total += size
+		}
+		ancientStats = append(ancientStats, []string{"Ancient store", category.name, size.String(), ancients.String()})
+	}
 	// Display the database statistic.
 	stats := [][]string{
 		{"Key-Value store", "Headers", headers.Size(), headers.Count()},
@@ -488,14 +539,23 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
 		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
 		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
-		{"Ancient store", "Headers", ancientHeadersSize.String(), ancients.String()},
-		{"Ancient store", "Bodies", ancientBodiesSize.String(), ancients.String()},
-		{"Ancient store", "Receipt lists", ancientReceiptsSize.String(), ancients.String()},
-		{"Ancient store", "Difficulties", ancientTdsSize.String(), ancients.String()},
-		{"Ancient store", "Block number->hash", ancientHashesSize.String(), ancients.String()},
+		{"Key-Value store", "Termini", termini.Size(), termini.Count()},
+		{"Key-Value store", "Manifests", manifests.Size(), manifests.Count()},
+		{"Key-Value store", "Pending etxs", pendingEtxs.Size(), pendingEtxs.Count()},
+		{"Key-Value store", "Pending etxs rollups", rollups.Size(), rollups.Count()},
+		{"Key-Value store", "Blooms", blooms.Size(), blooms.Count()},
+		{"Key-Value store", "Etx sets", etxSets.Size(), etxSets.Count()},
+		{"Key-Value store", "Etx set diffs", etxSetDiffs.Size(), etxSetDiffs.Count()},
+		{"Key-Value store", "Etx statuses", etxStatuses.Size(), etxStatuses.Count()},
+		{"Key-Value store", "Pending headers", pendingHeaders.Size(), pendingHeaders.Count()},
+		{"Key-Value store", "Pending bodies", pendingBodies.Size(), pendingBodies.Count()},
+		{"Key-Value store", "Bad hashes list", badHashes.Size(), badHashes.Count()},
+	}
+	stats = append(stats, ancientStats...)
+	stats = append(stats, [][]string{
 		{"Light client", "CHT trie nodes", chtTrieNodes.Size(), chtTrieNodes.Count()},
 		{"Light client", "Bloom trie nodes", bloomTrieNodes.Size(), bloomTrieNodes.Count()},
-	}
+	}...)
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"Database", "Category", "Size", "Items"})
 	table.SetFooter([]string{"", "Total", total.String(), " "})
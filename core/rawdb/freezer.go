@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/ethdb"
 	"github.com/dominant-strategies/go-quai/log"
 	"github.com/dominant-strategies/go-quai/metrics"
@@ -63,6 +64,12 @@ const (
 	freezerBatchLimit = 30000
 )
 
+// frozenBlock identifies a block moved into the ancient store.
+type frozenBlock struct {
+	number uint64
+	hash   common.Hash
+}
+
 // freezer is an memory mapped append-only database to store immutable chain data
 // into flat files:
 //
@@ -78,11 +85,14 @@ type freezer struct {
 	threshold uint64 // Number of recent blocks not to freeze (params.FullImmutabilityThreshold apart from tests)
 
 	readonly     bool
+	nodeCtx      int                      // Context of the chain whose blocks are frozen
 	tables       map[string]*freezerTable // Data tables for storing everything
 	instanceLock fileutil.Releaser        // File-system lock to prevent double opens
 
 	trigger chan chan struct{} // Manual blocking freeze trigger, test determinism
 
+	etxSetBase *frozenBlock // Newest frozen block whose full etx set is kept in leveldb
+
 	quit      chan struct{}
 	wg        sync.WaitGroup
 	closeOnce sync.Once
@@ -90,7 +100,7 @@ type freezer struct {
 
 // newFreezer creates a chain freezer that moves ancient chain data into
 // append-only flat file containers.
-func newFreezer(datadir string, namespace string, readonly bool) (*freezer, error) {
+func newFreezer(datadir string, namespace string, readonly bool, nodeCtx int) (*freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -113,13 +123,16 @@ func newFreezer(datadir string, namespace string, readonly bool) (*freezer, erro
 	// Open all the supported data tables
 	freezer := &freezer{
 		readonly:     readonly,
+		nodeCtx:      nodeCtx,
 		threshold:    params.FullImmutabilityThreshold,
 		tables:       make(map[string]*freezerTable),
 		instanceLock: lock,
 		trigger:      make(chan chan struct{}),
 		quit:         make(chan struct{}),
 	}
+	created := make(map[string]bool)
 	for name, disableSnappy := range FreezerNoSnappy {
+		created[name] = !common.FileExist(filepath.Join(datadir, freezerIndexName(name, disableSnappy)))
 		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, disableSnappy)
 		if err != nil {
 			for _, table := range freezer.tables {
@@ -130,6 +143,13 @@ func newFreezer(datadir string, namespace string, readonly bool) (*freezer, erro
 		}
 		freezer.tables[name] = table
 	}
+	if err := freezer.backfill(created); err != nil {
+		for _, table := range freezer.tables {
+			table.Close()
+		}
+		lock.Release()
+		return nil, err
+	}
 	if err := freezer.repair(); err != nil {
 		for _, table := range freezer.tables {
 			table.Close()
@@ -194,12 +214,26 @@ func (f *freezer) AncientSize(kind string) (uint64, error) {
 }
 
 // AppendAncient injects all binary blobs belong to block at the end of the
-// append-only immutable table files.
+// append-only immutable table files. The hierarchy tables are appended empty
+// blobs.
 //
 // Notably, this function is lock free but kind of thread-safe. All out-of-order
 // injection will be rejected. But if two injections with same number happen at
 // the same time, we can get into the trouble.
 func (f *freezer) AppendAncient(number uint64, hash, header, body, receipts, etxSet []byte) (err error) {
+	return f.appendAncient(number, map[string][]byte{
+		freezerHashTable:    hash,
+		freezerHeaderTable:  header,
+		freezerBodiesTable:  body,
+		freezerReceiptTable: receipts,
+		freezerEtxSetsTable: etxSet,
+	})
+}
+
+// appendAncient injects the binary blobs of a block, keyed by table, at the end
+// of the append-only immutable table files. Tables without a blob are appended
+// an empty one, so that all the tables stay in sync.
+func (f *freezer) appendAncient(number uint64, blobs map[string][]byte) (err error) {
 	if f.readonly {
 		return errReadOnly
 	}
@@ -219,25 +253,11 @@ func (f *freezer) AppendAncient(number uint64, hash, header, body, receipts, etx
 		}
 	}()
 	// Inject all the components into the relevant data tables
-	if err := f.tables[freezerHashTable].Append(f.frozen, hash[:]); err != nil {
-		log.Error("Failed to append ancient hash", "number", f.frozen, "hash", hash, "err", err)
-		return err
-	}
-	if err := f.tables[freezerHeaderTable].Append(f.frozen, header); err != nil {
-		log.Error("Failed to append ancient header", "number", f.frozen, "hash", hash, "err", err)
-		return err
-	}
-	if err := f.tables[freezerBodiesTable].Append(f.frozen, body); err != nil {
-		log.Error("Failed to append ancient body", "number", f.frozen, "hash", hash, "err", err)
-		return err
-	}
-	if err := f.tables[freezerReceiptTable].Append(f.frozen, receipts); err != nil {
-		log.Error("Failed to append ancient receipts", "number", f.frozen, "hash", hash, "err", err)
-		return err
-	}
-	if err := f.tables[freezerEtxSetsTable].Append(f.frozen, etxSet); err != nil {
-		log.Error("Failed to append ancient etx set", "number", f.frozen, "hash", hash, "err", err)
-		return err
+	for name, table := range f.tables {
+		if err := table.Append(f.frozen, blobs[name]); err != nil {
+			log.Error("Failed to append ancient data", "table", name, "number", f.frozen, "hash", common.BytesToHash(blobs[freezerHashTable]), "err", err)
+			return err
+		}
 	}
 	atomic.AddUint64(&f.frozen, 1) // Only modify atomically
 	return nil
@@ -308,50 +328,66 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 				return
 			}
 		}
-		// Retrieve the freezing threshold.
-		hash := ReadHeadBlockHash(nfdb)
-		if hash == (common.Hash{}) {
-			log.Debug("Current full block hash unavailable") // new chain, empty database
-			backoff = true
-			continue
-		}
-		number := ReadHeaderNumber(nfdb, hash)
-		threshold := atomic.LoadUint64(&f.threshold)
+		// Retrieve the freezing threshold. The chain records how far its data
+		// is final, which is the only data safe to move into the ancient store
+		// as it is never reorganised. Without it, fall back to keeping the most
+		// recent blocks of the head in leveldb.
+		var limit uint64
+		if ancientLimit := ReadAncientLimit(nfdb); ancientLimit != nil {
+			if *ancientLimit < f.frozen {
+				log.Debug("Ancient blocks frozen already", "limit", *ancientLimit, "frozen", f.frozen)
+				backoff = true
+				continue
+			}
+			limit = *ancientLimit
+		} else {
+			hash := ReadHeadBlockHash(nfdb)
+			if hash == (common.Hash{}) {
+				log.Debug("Current full block hash unavailable") // new chain, empty database
+				backoff = true
+				continue
+			}
+			number := ReadHeaderNumber(nfdb, hash)
+			threshold := atomic.LoadUint64(&f.threshold)
 
-		switch {
-		case number == nil:
-			log.Error("Current full block number unavailable", "hash", hash)
-			backoff = true
-			continue
+			switch {
+			case number == nil:
+				log.Error("Current full block number unavailable", "hash", hash)
+				backoff = true
+				continue
 
-		case *number < threshold:
-			log.Debug("Current full block not old enough", "number", *number, "hash", hash, "delay", threshold)
-			backoff = true
-			continue
+			case *number < threshold:
+				log.Debug("Current full block not old enough", "number", *number, "hash", hash, "delay", threshold)
+				backoff = true
+				continue
 
-		case *number-threshold <= f.frozen:
-			log.Debug("Ancient blocks frozen already", "number", *number, "hash", hash, "frozen", f.frozen)
-			backoff = true
-			continue
-		}
-		head := ReadHeader(nfdb, hash, *number)
-		if head == nil {
-			log.Error("Current full block unavailable", "number", *number, "hash", hash)
-			backoff = true
-			continue
+			case *number-threshold <= f.frozen:
+				log.Debug("Ancient blocks frozen already", "number", *number, "hash", hash, "frozen", f.frozen)
+				backoff = true
+				continue
+			}
+			head := ReadHeader(nfdb, hash, *number)
+			if head == nil {
+				log.Error("Current full block unavailable", "number", *number, "hash", hash)
+				backoff = true
+				continue
+			}
+			limit = *number - threshold
 		}
 		// Seems we have data ready to be frozen, process in usable batches
-		limit := *number - threshold
 		if limit-f.frozen > freezerBatchLimit {
 			limit = f.frozen + freezerBatchLimit
 		}
 		var (
-			start    = time.Now()
-			first    = f.frozen
-			ancients = make([]common.Hash, 0, limit-f.frozen)
+			start        = time.Now()
+			first        = f.frozen
+			ancients     = make([]common.Hash, 0, limit-f.frozen+1)
+			subordinates []common.Hash
 		)
 		for f.frozen <= limit {
-			// Retrieves all the components of the canonical block
+			// Retrieves all the components of the canonical block. Only the
+			// header and the body are stored for every block, the rest of the
+			// data depends on the context of the chain.
 			hash := ReadCanonicalHash(nfdb, f.frozen)
 			if hash == (common.Hash{}) {
 				log.Error("Canonical hash missing, can't freeze", "number", f.frozen)
@@ -367,34 +403,62 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 				log.Error("Block body missing, can't freeze", "number", f.frozen, "hash", hash)
 				break
 			}
-			receipts := ReadReceiptsRLP(nfdb, hash, f.frozen)
-			if len(receipts) == 0 {
-				log.Error("Block receipts missing, can't freeze", "number", f.frozen, "hash", hash)
-				break
+			blobs := map[string][]byte{
+				freezerHashTable:              hash[:],
+				freezerHeaderTable:            header,
+				freezerBodiesTable:            body,
+				freezerReceiptTable:           ReadReceiptsRLP(nfdb, hash, f.frozen),
+				freezerEtxSetsTable:           ReadEtxSetDiffRLP(nfdb, hash, f.frozen),
+				freezerTerminiTable:           ReadTerminiRLP(nfdb, hash),
+				freezerManifestTable:          ReadManifestRLP(nfdb, hash),
+				freezerPendingEtxsTable:       ReadPendingEtxsRLP(nfdb, hash),
+				freezerPendingEtxsRollupTable: ReadPendingEtxsRollupRLP(nfdb, hash),
+				freezerBloomTable:             ReadBloomRLP(nfdb, hash),
 			}
-			etxSet := ReadEtxSetRLP(nfdb, hash, f.frozen)
-			if len(etxSet) == 0 {
-				log.Error("Total etxset missing, can't freeze", "number", f.frozen, "hash", hash)
-				break
+			// The genesis block, and so what it references, stays in leveldb
+			var manifest types.BlockManifest
+			if f.frozen != 0 {
+				manifest = ReadManifest(nfdb, hash)
 			}
 			log.Trace("Deep froze ancient block", "number", f.frozen, "hash", hash)
 			// Inject all the components into the relevant data tables
-			if err := f.AppendAncient(f.frozen, hash[:], header, body, receipts, etxSet); err != nil {
+			if err := f.appendAncient(f.frozen, blobs); err != nil {
 				break
 			}
 			ancients = append(ancients, hash)
+			subordinates = append(subordinates, manifest...)
 		}
 		// Batch of blocks have been frozen, flush them before wiping from leveldb
 		if err := f.Sync(); err != nil {
 			log.Fatal("Failed to flush frozen tables", "err", err)
 		}
-		// Wipe out all data from the active database
+		// Wipe out all data from the active database. The full etx set of the
+		// newest frozen snapshot is kept, as the sets of the following blocks
+		// are rebuilt from it.
 		batch := db.NewBatch()
 		for i := 0; i < len(ancients); i++ {
 			// Always keep the genesis block in active database
-			if first+uint64(i) != 0 {
-				DeleteBlockWithoutNumber(batch, ancients[i], first+uint64(i))
-				DeleteCanonicalHash(batch, first+uint64(i))
+			number := first + uint64(i)
+			if number != 0 {
+				DeleteBlockWithoutNumber(batch, ancients[i], number)
+				DeleteCanonicalHash(batch, number)
+				DeleteEtxSetDiff(batch, ancients[i], number)
+				deleteHierarchyData(batch, ancients[i], number)
+			}
+			if has, _ := db.Has(etxSetKey(number, ancients[i])); has {
+				if f.etxSetBase != nil && f.etxSetBase.number != 0 {
+					DeleteEtxSet(batch, f.etxSetBase.hash, f.etxSetBase.number)
+				}
+				f.etxSetBase = &frozenBlock{number: number, hash: ancients[i]}
+			}
+		}
+		// The pending etxs of the blocks of the subordinate chains are only
+		// needed to build the dom blocks referencing them, which are final.
+		genesis := ReadCanonicalHash(nfdb, 0)
+		for _, hash := range subordinates {
+			if hash != genesis {
+				DeletePendingEtxs(batch, hash)
+				DeletePendingEtxsRollup(batch, hash)
 			}
 		}
 		if err := batch.Write(); err != nil {
@@ -411,6 +475,9 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 				for _, hash := range dangling {
 					log.Trace("Deleting side chain", "number", number, "hash", hash)
 					DeleteBlock(batch, hash, number)
+					DeleteEtxSetDiff(batch, hash, number)
+					DeleteEtxSet(batch, hash, number)
+					deleteHierarchyData(batch, hash, number)
 				}
 			}
 		}
@@ -436,14 +503,17 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 						log.Error("Missing dangling header", "number", tip, "hash", children[i])
 						continue
 					}
-					if _, ok := drop[child.ParentHash()]; !ok {
+					if _, ok := drop[child.ParentHash(f.nodeCtx)]; !ok {
 						children = append(children[:i], children[i+1:]...)
 						i--
 						continue
 					}
 					// Delete all block data associated with the child
-					log.Debug("Deleting dangling block", "number", tip, "hash", children[i], "parent", child.ParentHash())
+					log.Debug("Deleting dangling block", "number", tip, "hash", children[i], "parent", child.ParentHash(f.nodeCtx))
 					DeleteBlock(batch, children[i], tip)
+					DeleteEtxSetDiff(batch, children[i], tip)
+					DeleteEtxSet(batch, children[i], tip)
+					deleteHierarchyData(batch, children[i], tip)
 				}
 				dangling = children
 				tip++
@@ -468,6 +538,39 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 	}
 }
 
+// backfill appends empty blobs to the hierarchy tables just created in an
+// ancient store which already holds blocks, up to the length of the other
+// tables. Ancient stores frozen before the hierarchy tables were added hold no
+// hierarchy data, which is still in leveldb, and repair would otherwise
+// truncate all the tables to the empty ones.
+func (f *freezer) backfill(created map[string]bool) error {
+	frozen := uint64(math.MaxUint64)
+	for name, table := range f.tables {
+		if items := atomic.LoadUint64(&table.items); !created[name] && items < frozen {
+			frozen = items
+		}
+	}
+	if frozen == math.MaxUint64 || frozen == 0 {
+		return nil
+	}
+	for _, name := range freezerHierarchyTables {
+		table := f.tables[name]
+		if !created[name] || atomic.LoadUint64(&table.items) != 0 {
+			continue
+		}
+		for number := uint64(0); number < frozen; number++ {
+			if err := table.Append(number, nil); err != nil {
+				return err
+			}
+		}
+		if err := table.Sync(); err != nil {
+			return err
+		}
+		log.Info("Back-filled ancient table", "table", name, "items", frozen)
+	}
+	return nil
+}
+
 // repair truncates all data tables to the same length.
 func (f *freezer) repair() error {
 	min := uint64(math.MaxUint64)
@@ -485,3 +588,12 @@ func (f *freezer) repair() error {
 	atomic.StoreUint64(&f.frozen, min)
 	return nil
 }
+
+// deleteHierarchyData removes the hierarchy data of a block from leveldb.
+func deleteHierarchyData(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	DeleteTermini(db, hash)
+	DeleteManifest(db, hash)
+	DeletePendingEtxs(db, hash)
+	DeletePendingEtxsRollup(db, hash)
+	DeleteBloom(db, hash, number)
+}
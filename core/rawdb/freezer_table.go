@@ -151,6 +151,16 @@ func truncateFreezerFile(file *os.File, size int64) error {
 	return nil
 }
 
+// freezerIndexName returns the name of the index file of a freezer table.
+func freezerIndexName(name string, noCompression bool) string {
+	if noCompression {
+		// Raw idx
+		return fmt.Sprintf("%s.ridx", name)
+	}
+	// Compressed idx
+	return fmt.Sprintf("%s.cidx", name)
+}
+
 // newCustomTable opens a freezer table, creating the data and index files if they are
 // non existent. Both files are truncated to the shortest common length to ensure
 // they don't go out of sync.
@@ -159,15 +169,7 @@ func newCustomTable(path string, name string, readMeter metrics.Meter, writeMete
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
 	}
-	var idxName string
-	if noCompression {
-		// Raw idx
-		idxName = fmt.Sprintf("%s.ridx", name)
-	} else {
-		// Compressed idx
-		idxName = fmt.Sprintf("%s.cidx", name)
-	}
-	offsets, err := openFreezerFileForAppend(filepath.Join(path, idxName))
+	offsets, err := openFreezerFileForAppend(filepath.Join(path, freezerIndexName(name, noCompression)))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,187 @@
+package rawdb
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/ethdb/memorydb"
+	"github.com/dominant-strategies/go-quai/rlp"
+)
+
+// Tests that the freezer moves the final blocks into the ancient store together
+// with their hierarchy data, which is still served transparently, and drops the
+// pending etxs of the subordinate blocks they reference.
+func TestFreezeHierarchyData(t *testing.T) {
+	kv := memorydb.New()
+	db, err := NewDatabaseWithFreezer(kv, t.TempDir(), "", false, common.ZONE_CTX)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	plain := NewDatabase(kv)
+
+	var (
+		hashes []common.Hash
+		subs   []common.Hash
+		parent common.Hash
+	)
+	for i := 0; i < 6; i++ {
+		header := types.EmptyHeader()
+		header.SetNumber(big.NewInt(int64(i)), common.ZONE_CTX)
+		header.SetParentHash(parent, common.ZONE_CTX)
+		block := types.NewBlockWithHeader(header)
+		hash := block.Hash()
+
+		sub := common.Hash{0xff, byte(i)}
+		WriteBlock(db, block, common.ZONE_CTX)
+		WriteCanonicalHash(db, hash, uint64(i))
+		WriteTermini(db, hash, []common.Hash{parent, parent})
+		WriteManifest(db, hash, types.BlockManifest{sub, hash})
+		WritePendingEtxsRLP(db, sub, []byte{0xc0})
+		WriteBloom(db, hash, types.Bloom{byte(i)})
+
+		hashes, subs, parent = append(hashes, hash), append(subs, sub), hash
+	}
+	WriteHeadBlockHash(db, parent)
+	WriteAncientLimit(db, 3)
+
+	if err := db.(interface{ Freeze(uint64) error }).Freeze(0); err != nil {
+		t.Fatalf("failed to freeze: %v", err)
+	}
+	if frozen, _ := db.Ancients(); frozen != 4 {
+		t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, 4)
+	}
+	for i, hash := range hashes {
+		// The genesis block and the blocks above the limit stay in leveldb
+		inLeveldb := i == 0 || i > 3
+		if have := ReadTermini(plain, hash) != nil; have != inLeveldb {
+			t.Errorf("block %d: termini in leveldb %v, want %v", i, have, inLeveldb)
+		}
+		if have := ReadBloom(plain, hash) != nil; have != inLeveldb {
+			t.Errorf("block %d: bloom in leveldb %v, want %v", i, have, inLeveldb)
+		}
+		if have := ReadPendingEtxsRLP(plain, subs[i]) != nil; have != inLeveldb {
+			t.Errorf("block %d: subordinate pending etxs in leveldb %v, want %v", i, have, inLeveldb)
+		}
+		// All of it is still served through the freezer
+		var parent common.Hash
+		if i > 0 {
+			parent = hashes[i-1]
+		}
+		if have, want := ReadTermini(db, hash), []common.Hash{parent, parent}; !reflect.DeepEqual(have, want) {
+			t.Errorf("block %d: termini mismatch: have %x, want %x", i, have, want)
+		}
+		if have, want := ReadManifest(db, hash), (types.BlockManifest{subs[i], hash}); !reflect.DeepEqual(have, want) {
+			t.Errorf("block %d: manifest mismatch: have %x, want %x", i, have, want)
+		}
+		if have := ReadBloom(db, hash); have == nil || *have != (types.Bloom{byte(i)}) {
+			t.Errorf("block %d: bloom mismatch: have %x", i, have)
+		}
+		if ReadBody(db, hash, uint64(i)) == nil {
+			t.Errorf("block %d: body missing", i)
+		}
+	}
+}
+
+// Tests that the pending headers and bodies left behind by the caches are
+// swept, without touching the list of pending body keys.
+func TestDeletePendingData(t *testing.T) {
+	db := NewMemoryDatabase()
+	hash := common.Hash{1}
+
+	WritePendingHeader(db, hash, types.EmptyHeader())
+	WritePhCacheTermini(db, hash, []common.Hash{hash})
+	WritePbCacheBody(db, hash, &types.Body{})
+	WritePbBodyKeys(db, []common.Hash{hash})
+
+	count, err := DeletePendingData(db)
+	if err != nil {
+		t.Fatalf("failed to delete pending data: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("deleted entries mismatch: have %d, want %d", count, 3)
+	}
+	if ReadPendingHeader(db, hash) != nil || ReadPhCacheTermini(db, hash) != nil {
+		t.Error("pending header not deleted")
+	}
+	if keys := ReadPbBodyKeys(db); len(keys) != 1 {
+		t.Errorf("pending body keys mismatch: have %x", keys)
+	}
+}
+
+// Tests that opening an ancient store frozen before the hierarchy tables were
+// added keeps its blocks, and back-fills the new tables to their length.
+func TestFreezerHierarchyTablesUpgrade(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		hashes  []common.Hash
+		legacy  = []string{freezerHashTable, freezerHeaderTable, freezerBodiesTable, freezerReceiptTable, freezerEtxSetsTable}
+		headers = make(map[common.Hash][]byte)
+	)
+	tables := make(map[string]*freezerTable)
+	for _, name := range legacy {
+		table, err := NewFreezerTable(dir, name, FreezerNoSnappy[name])
+		if err != nil {
+			t.Fatalf("failed to create table %s: %v", name, err)
+		}
+		tables[name] = table
+	}
+	for i := 0; i < 3; i++ {
+		header := types.EmptyHeader()
+		header.SetNumber(big.NewInt(int64(i)), common.ZONE_CTX)
+		block := types.NewBlockWithHeader(header)
+		headerRLP, _ := rlp.EncodeToBytes(block.Header())
+		bodyRLP, _ := rlp.EncodeToBytes(block.Body())
+
+		hash := block.Hash()
+		blobs := map[string][]byte{
+			freezerHashTable:    hash[:],
+			freezerHeaderTable:  headerRLP,
+			freezerBodiesTable:  bodyRLP,
+			freezerReceiptTable: []byte{0xc0},
+		}
+		for _, name := range legacy {
+			if err := tables[name].Append(uint64(i), blobs[name]); err != nil {
+				t.Fatalf("failed to append to table %s: %v", name, err)
+			}
+		}
+		hashes, headers[hash] = append(hashes, hash), headerRLP
+	}
+	for _, table := range tables {
+		table.Close()
+	}
+	// The hierarchy data of the frozen blocks is still in leveldb
+	kv := memorydb.New()
+	WriteHeaderNumber(kv, hashes[1], 1)
+	WriteTermini(kv, hashes[1], []common.Hash{hashes[0], hashes[0]})
+
+	db, err := NewDatabaseWithFreezer(kv, dir, "", false, common.ZONE_CTX)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if frozen, _ := db.Ancients(); frozen != 3 {
+		t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, 3)
+	}
+	for i, hash := range hashes {
+		if have := ReadHeaderRLP(db, hash, uint64(i)); !bytes.Equal(have, headers[hash]) {
+			t.Errorf("block %d: header mismatch: have %x, want %x", i, have, headers[hash])
+		}
+		for _, name := range freezerHierarchyTables {
+			if has, _ := db.HasAncient(name, uint64(i)); !has {
+				t.Errorf("block %d: table %s not back-filled", i, name)
+			}
+		}
+	}
+	if have, want := ReadTermini(db, hashes[1]), []common.Hash{hashes[0], hashes[0]}; !reflect.DeepEqual(have, want) {
+		t.Errorf("termini mismatch: have %x, want %x", have, want)
+	}
+	// Blocks are still appended to all the tables
+	if err := db.AppendAncient(3, common.Hash{3}.Bytes(), []byte{0xc0}, []byte{0xc0}, []byte{0xc0}, nil); err != nil {
+		t.Fatalf("failed to append block: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/ethdb"
+	"github.com/dominant-strategies/go-quai/log"
+	"golang.org/x/crypto/sha3"
+)
+
+// migrationProgressKey tracks the last key copied into the target store of a
+// database migration. It is only present while the copy is in progress.
+var migrationProgressKey = []byte("DatabaseMigrationProgress")
+
+// MigrationOptions contains the options of a database engine migration.
+type MigrationOptions struct {
+	Directory         string // the datadir of the key-value store
+	AncientsDirectory string // the ancients-dir, moved along if inside the datadir
+	Type              string // the engine to migrate to, "leveldb" | "pebble"
+	Cache             int    // the capacity(in megabytes) of the data caching
+	Handles           int    // number of files to be open simultaneously
+}
+
+// MigrateDatabase moves the key-value store in the given directory to another
+// database engine. The data is copied into a sibling directory, verified and
+// then swapped in place of the original store, which is kept next to it. The
+// ancient store is moved into the new directory if it lives in the original
+// one.
+//
+// The migration can be interrupted at any point and resumed by running it
+// again with the same options.
+func MigrateDatabase(o MigrationOptions) error {
+	var (
+		target = o.Directory + "." + o.Type
+		backup = o.Directory + ".old"
+	)
+	// Finish the swap of an interrupted migration if needed
+	if !common.FileExist(o.Directory) {
+		if !common.FileExist(target) || !common.FileExist(backup) {
+			return fmt.Errorf("database missing: %s", o.Directory)
+		}
+		return os.Rename(target, o.Directory)
+	}
+	source := hasPreexistingDb(o.Directory)
+	switch {
+	case source == "":
+		return fmt.Errorf("no database found in %s", o.Directory)
+	case source == o.Type:
+		return fmt.Errorf("database already uses %s", o.Type)
+	case o.Type == dbPebble && !PebbleEnabled:
+		return errors.New("db.engine 'pebble' not supported on this platform")
+	case o.Type != dbPebble && o.Type != dbLeveldb:
+		return fmt.Errorf("unknown db.engine %v", o.Type)
+	}
+	if common.FileExist(backup) {
+		return fmt.Errorf("previous database kept in %s, remove it first", backup)
+	}
+	if err := copyDatabase(o, source, target); err != nil {
+		return err
+	}
+	// Move the ancient store into the new directory, then swap the directories
+	ancients := filepath.Join(o.Directory, "ancient")
+	if filepath.Clean(o.AncientsDirectory) == ancients && common.FileExist(ancients) {
+		if err := os.Rename(ancients, filepath.Join(target, "ancient")); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(o.Directory, backup); err != nil {
+		return err
+	}
+	if err := os.Rename(target, o.Directory); err != nil {
+		return err
+	}
+	log.Info("Migrated database", "to", o.Type, "previous", backup)
+	return nil
+}
+
+// copyDatabase copies the key-value store of the given engine into the target
+// directory, resuming an interrupted copy, and verifies the copy.
+func copyDatabase(o MigrationOptions, source string, target string) error {
+	src, err := openKeyValueDatabase(OpenOptions{Type: source, Directory: o.Directory, Cache: o.Cache / 2, Handles: o.Handles / 2, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// A target directory without a progress marker holds a complete copy,
+	// which only needs verifying
+	resumed := common.FileExist(target)
+	dst, err := openKeyValueDatabase(OpenOptions{Type: o.Type, Directory: target, Cache: o.Cache / 2, Handles: o.Handles / 2})
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	start, err := dst.Get(migrationProgressKey)
+	copied := err != nil && resumed
+	switch {
+	case err == nil:
+		log.Info("Resuming database migration", "from", source, "to", o.Type, "key", fmt.Sprintf("%#x", start))
+	case copied:
+		log.Info("Database already copied", "from", source, "to", o.Type)
+	default:
+		log.Info("Migrating database", "from", source, "to", o.Type, "directory", target)
+		if err := dst.Put(migrationProgressKey, nil); err != nil {
+			return err
+		}
+	}
+	if !copied {
+		if err := CopyKeyValueStore(src, dst, start); err != nil {
+			return err
+		}
+	}
+	return VerifyKeyValueStoreCopy(src, dst)
+}
+
+// CopyKeyValueStore copies all the data of a key-value store into another,
+// starting from the given key. The progress is tracked in the target store, so
+// that an interrupted copy can be resumed from the last key written.
+func CopyKeyValueStore(src ethdb.KeyValueStore, dst ethdb.KeyValueStore, start []byte) error {
+	it := src.NewIterator(nil, start)
+	defer it.Release()
+
+	var (
+		batch  = dst.NewBatch()
+		count  int
+		logged = time.Now()
+	)
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return err
+		}
+		count++
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Put(migrationProgressKey, it.Key()); err != nil {
+				return err
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Copying database", "keys", count, "key", fmt.Sprintf("%#x", it.Key()))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Delete(migrationProgressKey); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Copied database", "keys", count)
+	return nil
+}
+
+// prefixChecksum is the digest of the entries of a store sharing the same
+// first key byte.
+type prefixChecksum struct {
+	count  uint64
+	hasher hash.Hash
+}
+
+// checksumKeyValueStore digests the entries of a key-value store per first
+// key byte.
+func checksumKeyValueStore(db ethdb.KeyValueStore) (map[byte]*prefixChecksum, error) {
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+
+	sums := make(map[byte]*prefixChecksum)
+	for it.Next() {
+		key := it.Key()
+		if len(key) == 0 || bytes.Equal(key, migrationProgressKey) {
+			continue
+		}
+		sum := sums[key[0]]
+		if sum == nil {
+			sum = &prefixChecksum{hasher: sha3.NewLegacyKeccak256()}
+			sums[key[0]] = sum
+		}
+		sum.count++
+		sum.hasher.Write(encodeBlockNumber(uint64(len(key))))
+		sum.hasher.Write(key)
+		sum.hasher.Write(encodeBlockNumber(uint64(len(it.Value()))))
+		sum.hasher.Write(it.Value())
+	}
+	return sums, it.Error()
+}
+
+// VerifyKeyValueStoreCopy checks that two key-value stores hold the same data,
+// comparing the checksums of their entries per key prefix.
+func VerifyKeyValueStoreCopy(src ethdb.KeyValueStore, dst ethdb.KeyValueStore) error {
+	have, err := checksumKeyValueStore(dst)
+	if err != nil {
+		return err
+	}
+	want, err := checksumKeyValueStore(src)
+	if err != nil {
+		return err
+	}
+	var mismatches int
+	for i := 0; i < 256; i++ {
+		prefix := byte(i)
+		h, w := have[prefix], want[prefix]
+		switch {
+		case h == nil && w == nil:
+			continue
+		case h == nil || w == nil || h.count != w.count || !bytes.Equal(h.hasher.Sum(nil), w.hasher.Sum(nil)):
+			var hc, wc uint64
+			if h != nil {
+				hc = h.count
+			}
+			if w != nil {
+				wc = w.count
+			}
+			log.Error("Database copy mismatch", "prefix", fmt.Sprintf("%#x", prefix), "have", hc, "want", wc)
+			mismatches++
+		default:
+			log.Debug("Database copy verified", "prefix", fmt.Sprintf("%#x", prefix), "keys", w.count)
+		}
+	}
+	if mismatches > 0 {
+		return fmt.Errorf("database copy differs for %d prefixes", mismatches)
+	}
+	return nil
+}
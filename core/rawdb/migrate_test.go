@@ -0,0 +1,96 @@
+package rawdb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/ethdb/memorydb"
+)
+
+// Tests that an interrupted migration of the key-value store to pebble is
+// resumed, and that the migrated store takes the place of the original one
+// together with its ancient store.
+func TestMigrateDatabase(t *testing.T) {
+	if !PebbleEnabled {
+		t.Skip("pebble not supported on this platform")
+	}
+	var (
+		dir     = filepath.Join(t.TempDir(), "chaindata")
+		ancient = filepath.Join(dir, "ancient")
+		keys    [][]byte
+	)
+	db, err := NewLevelDBDatabase(dir, 0, 0, "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	for _, prefix := range []string{"h", "tk", "pe", "LastBlock"} {
+		for i := 0; i < 10; i++ {
+			key := append([]byte(prefix), byte(i))
+			if err := db.Put(key, bytes.Repeat(key, 4)); err != nil {
+				t.Fatalf("failed to write key: %v", err)
+			}
+			keys = append(keys, key)
+		}
+	}
+	db.Close()
+	if err := os.MkdirAll(ancient, 0755); err != nil {
+		t.Fatalf("failed to create ancient store: %v", err)
+	}
+	// Leave a partial copy behind, as an interrupted migration would, holding
+	// the keys sorting before the progress marker
+	partial, err := NewPebbleDBDatabase(dir+".pebble", 0, 0, "", false)
+	if err != nil {
+		t.Fatalf("failed to create partial copy: %v", err)
+	}
+	src := memorydb.New()
+	for _, key := range append(keys[30:], keys[:5]...) {
+		src.Put(key, bytes.Repeat(key, 4))
+	}
+	if err := CopyKeyValueStore(src, partial, nil); err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	partial.Put(migrationProgressKey, keys[4])
+	partial.Close()
+
+	if err := MigrateDatabase(MigrationOptions{Directory: dir, AncientsDirectory: ancient, Type: "pebble"}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "OPTIONS*")); len(matches) == 0 {
+		t.Fatal("database not swapped")
+	}
+	if !common.FileExist(ancient) || !common.FileExist(dir+".old") {
+		t.Fatal("ancient store or previous database missing")
+	}
+	db, err = NewPebbleDBDatabase(dir, 0, 0, "", true)
+	if err != nil {
+		t.Fatalf("failed to open migrated database: %v", err)
+	}
+	defer db.Close()
+	for _, key := range keys {
+		if have, err := db.Get(key); err != nil || !bytes.Equal(have, bytes.Repeat(key, 4)) {
+			t.Errorf("key %x mismatch: have %x, err %v", key, have, err)
+		}
+	}
+	if has, _ := db.Has(migrationProgressKey); has {
+		t.Error("migration progress left in database")
+	}
+}
+
+// Tests that a copy differing from the original is detected.
+func TestVerifyKeyValueStoreCopy(t *testing.T) {
+	src, dst := memorydb.New(), memorydb.New()
+	for _, db := range []*memorydb.Database{src, dst} {
+		db.Put([]byte("tk1"), []byte{1})
+		db.Put([]byte("pe1"), []byte{2})
+	}
+	if err := VerifyKeyValueStoreCopy(src, dst); err != nil {
+		t.Fatalf("identical copy rejected: %v", err)
+	}
+	dst.Put([]byte("pe1"), []byte{3})
+	if err := VerifyKeyValueStoreCopy(src, dst); err == nil {
+		t.Fatal("differing copy accepted")
+	}
+}
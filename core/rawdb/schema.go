@@ -36,6 +36,20 @@ var (
 	// headBlockKey tracks the latest known full block's hash.
 	headBlockKey = []byte("LastBlock")
 
+	// ancientLimitKey tracks the number of the newest canonical block the
+	// freezer may move into the ancient store.
+	ancientLimitKey = []byte("AncientLimit")
+
+	// appendJournalKey tracks the dom coincident block whose append is in flight
+	// in the subordinate chain, until it is committed locally.
+	appendJournalKey = []byte("AppendJournal")
+
+	// checkpointsKey tracks the blocks trusted to be part of the chain.
+	checkpointsKey = []byte("Checkpoints")
+
+	// checkpointConflictsKey tracks the blocks listed as bad by checkpoints.
+	checkpointConflictsKey = []byte("CheckpointConflicts")
+
 	// headersHashKey tracks the latest known headers hash in Blockchain.
 	headsHashesKey = []byte("HeadersHash")
 
@@ -103,6 +117,8 @@ var (
 	pendingEtxsRollupPrefix = []byte("pr") // pendingEtxsRollupPrefix + hash -> PendingEtxsRollup at block
 	manifestPrefix          = []byte("ma") // manifestPrefix + hash -> Manifest at block
 	bloomPrefix             = []byte("bl") // bloomPrefix + hash -> bloom at block
+	etxStatusPrefix         = []byte("xs") // etxStatusPrefix + hash -> lifecycle of an ETX
+	etxSetDiffPrefix        = []byte("xd") // etxSetDiffPrefix + num (uint64 big endian) + hash -> EtxSetDiff applied by block
 
 	txLookupPrefix        = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix       = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
@@ -133,22 +149,49 @@ const (
 	// freezerReceiptTable indicates the name of the freezer receipts table.
 	freezerReceiptTable = "receipts"
 
-	// freezerDifficultyTable indicates the name of the freezer total difficulty table.
-	freezerDifficultyTable = "diffs"
-
-	// freezerEtxSetsTable indicates the name of the etx set table.
+	// freezerEtxSetsTable indicates the name of the etx set diff table.
 	freezerEtxSetsTable = "etxSets"
+
+	// freezerTerminiTable indicates the name of the freezer termini table.
+	freezerTerminiTable = "termini"
+
+	// freezerManifestTable indicates the name of the freezer manifest table.
+	freezerManifestTable = "manifests"
+
+	// freezerPendingEtxsTable indicates the name of the freezer pending etxs table.
+	freezerPendingEtxsTable = "pendingEtxs"
+
+	// freezerPendingEtxsRollupTable indicates the name of the freezer pending
+	// etxs rollup table.
+	freezerPendingEtxsRollupTable = "pendingEtxsRollups"
+
+	// freezerBloomTable indicates the name of the freezer bloom table.
+	freezerBloomTable = "blooms"
 )
 
 // FreezerNoSnappy configures whether compression is disabled for the ancient-tables.
-// Hashes and difficulties don't compress well.
+// Hashes don't compress well.
 var FreezerNoSnappy = map[string]bool{
-	freezerHeaderTable:     false,
-	freezerHashTable:       true,
-	freezerBodiesTable:     false,
-	freezerReceiptTable:    false,
-	freezerDifficultyTable: true,
-	freezerEtxSetsTable:    false,
+	freezerHeaderTable:            false,
+	freezerHashTable:              true,
+	freezerBodiesTable:            false,
+	freezerReceiptTable:           false,
+	freezerEtxSetsTable:           false,
+	freezerTerminiTable:           true,
+	freezerManifestTable:          true,
+	freezerPendingEtxsTable:       false,
+	freezerPendingEtxsRollupTable: false,
+	freezerBloomTable:             false,
+}
+
+// freezerHierarchyTables are the ancient tables of the hierarchy data, which
+// is stored by block hash in the key-value store.
+var freezerHierarchyTables = []string{
+	freezerTerminiTable,
+	freezerManifestTable,
+	freezerPendingEtxsTable,
+	freezerPendingEtxsRollupTable,
+	freezerBloomTable,
 }
 
 // LegacyTxLookupEntry is the legacy TxLookupEntry definition with some unnecessary
@@ -303,3 +346,13 @@ func manifestKey(hash common.Hash) []byte {
 func bloomKey(hash common.Hash) []byte {
 	return append(bloomPrefix, hash.Bytes()...)
 }
+
+// etxSetDiffKey = etxSetDiffPrefix + num (uint64 big endian) + hash
+func etxSetDiffKey(number uint64, hash common.Hash) []byte {
+	return append(append(etxSetDiffPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// etxStatusKey = etxStatusPrefix + hash
+func etxStatusKey(hash common.Hash) []byte {
+	return append(etxStatusPrefix, hash.Bytes()...)
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/event"
+)
+
+// newReorgEvent describes the switch of the canonical chain from oldHead to
+// newHead. The removed and added headers are ordered from the newest to the
+// oldest, as they are collected walking back to the common ancestor.
+func (hc *HeaderChain) newReorgEvent(ancestor, oldHead, newHead *types.Header, removed, added []*types.Header) ReorgEvent {
+	ev := ReorgEvent{
+		CommonAncestor: ancestor,
+		OldHead:        oldHead,
+		NewHead:        newHead,
+		Depth:          uint64(len(removed)),
+	}
+	removedBlocks := hc.reorgBlocks(removed, &ev.Removed)
+	addedBlocks := hc.reorgBlocks(added, &ev.Added)
+	ev.diffEtxs(removedBlocks, addedBlocks)
+	return ev
+}
+
+// reorgBlocks files the hashes of the given headers under the contexts they are
+// coincident with and returns their blocks, oldest first. Blocks whose body is
+// not available are left out.
+func (hc *HeaderChain) reorgBlocks(headers []*types.Header, hashes *[common.HierarchyDepth][]common.Hash) []*types.Block {
+	nodeCtx := hc.NodeCtx()
+	blocks := make([]*types.Block, 0, len(headers))
+	for i := len(headers) - 1; i >= 0; i-- {
+		header := headers[i]
+		order := nodeCtx
+		if _, blockOrder, err := hc.engine.CalcOrder(header); err == nil {
+			order = blockOrder
+		}
+		for ctx := order; ctx <= nodeCtx; ctx++ {
+			hashes[ctx] = append(hashes[ctx], header.Hash())
+		}
+		if block := hc.GetBlock(header.Hash(), header.NumberU64(nodeCtx)); block != nil {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// diffEtxs sorts the ETXs emitted and the inbound ETXs executed by the reverted
+// blocks into those the new branch includes again and those it does not.
+func (ev *ReorgEvent) diffEtxs(removed, added []*types.Block) {
+	emitted := make(map[common.Hash]struct{})
+	inbound := make(map[common.Hash]struct{})
+	for _, block := range added {
+		for _, etx := range block.ExtTransactions() {
+			emitted[etx.Hash()] = struct{}{}
+		}
+		for _, tx := range block.Transactions() {
+			if tx.Type() == types.ExternalTxType {
+				inbound[tx.Hash()] = struct{}{}
+			}
+		}
+	}
+	for _, block := range removed {
+		for _, etx := range block.ExtTransactions() {
+			if _, ok := emitted[etx.Hash()]; ok {
+				ev.ReemittedEtxs = append(ev.ReemittedEtxs, etx.Hash())
+			} else {
+				ev.RevertedEtxs = append(ev.RevertedEtxs, etx.Hash())
+			}
+		}
+		for _, tx := range block.Transactions() {
+			if tx.Type() != types.ExternalTxType {
+				continue
+			}
+			if _, ok := inbound[tx.Hash()]; ok {
+				ev.ReincludedInboundEtxs = append(ev.ReincludedInboundEtxs, tx.Hash())
+			} else {
+				ev.RevertedInboundEtxs = append(ev.RevertedInboundEtxs, tx.Hash())
+			}
+		}
+	}
+}
+
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (hc *HeaderChain) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return hc.scope.Track(hc.reorgFeed.Subscribe(ch))
+}
@@ -0,0 +1,247 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/trie"
+)
+
+const (
+	// c_maxLinkRepairDepth bounds how far the head is rewound for the dom or a
+	// sub to agree with it. A crash only leaves the last appends inconsistent,
+	// so a deeper disagreement means the other chain is still syncing.
+	c_maxLinkRepairDepth = 32
+)
+
+// repairAppendJournal handles the append of a dom coincident block which was
+// started in the subordinate chain but never committed locally, because the
+// node crashed or lost its sub in between. The block is appended again, and
+// the sub answers with the result of the append it committed, if any, see
+// knownAppendResult.
+func (sl *Slice) repairAppendJournal() {
+	entry := rawdb.ReadAppendJournal(sl.sliceDb)
+	if entry == nil {
+		return
+	}
+	defer rawdb.DeleteAppendJournal(sl.sliceDb)
+
+	// The journal is removed along with the append, so a committed block only
+	// means another append overwrote it
+	if sl.hc.GetTerminiByHash(entry.Hash) != nil {
+		return
+	}
+	header := sl.hc.GetHeaderOrCandidate(entry.Hash, entry.Number)
+	if header == nil {
+		log.Warn("Interrupted append of unknown block", "hash", entry.Hash, "number", entry.Number)
+		return
+	}
+	log.Warn("Repairing interrupted append", "hash", entry.Hash, "number", entry.Number)
+	sl.queueReplay(header)
+}
+
+// repairHead checks that the hierarchy data of the head block is complete, was
+// derived from the one of its parent and is known to the dom and the subs. If
+// it is not, the head is rewound to the last consistent block coincident with
+// the dom, at which the dom and the subs last agreed, and the blocks above it
+// are queued to be appended again.
+func (sl *Slice) repairHead() {
+	nodeCtx := sl.NodeCtx()
+	head := sl.hc.CurrentHeader()
+	err := sl.checkHierarchyData(head)
+	linkOnly := err == nil
+	if linkOnly {
+		err = sl.checkLinks(head)
+	}
+	if err == nil {
+		return
+	}
+	log.Warn("Inconsistent head block", "hash", head.Hash(), "number", head.NumberArray(), "err", err)
+
+	var (
+		rewound []*types.Header
+		target  = head
+	)
+	for target.Hash() != sl.config.GenesisHash {
+		if sl.isCoincident(target) && sl.checkHierarchyData(target) == nil && sl.checkLinks(target) == nil {
+			break
+		}
+		if linkOnly && len(rewound) >= c_maxLinkRepairDepth {
+			log.Warn("Head block too far ahead of the dom or a sub to repair, waiting for it to sync", "hash", head.Hash(), "number", head.NumberArray())
+			return
+		}
+		rewound = append(rewound, target)
+		parent := sl.hc.GetHeader(target.ParentHash(nodeCtx), target.NumberU64(nodeCtx)-1)
+		if parent == nil {
+			log.Error("Failed to repair head block, ancestor missing", "hash", target.ParentHash(nodeCtx), "number", target.NumberU64(nodeCtx)-1)
+			return
+		}
+		target = parent
+	}
+	sl.rewindHead(target, rewound)
+	for i := len(rewound) - 1; i >= 0; i-- {
+		sl.queueReplay(rewound[i])
+	}
+	log.Warn("Rewound head block", "hash", target.Hash(), "number", target.NumberArray(), "rewound", len(rewound), "replay", len(sl.replay))
+}
+
+// checkHierarchyData checks the termini, the parent manifest and, in zones,
+// the EtxSet and the state of the given canonical block.
+func (sl *Slice) checkHierarchyData(header *types.Header) error {
+	if header.Hash() == sl.config.GenesisHash {
+		return nil
+	}
+	var (
+		nodeCtx = sl.NodeCtx()
+		hash    = header.Hash()
+	)
+	parentTermini := sl.hc.GetTerminiByHash(header.ParentHash(nodeCtx))
+	if len(parentTermini) != terminusIndex(sl.config)+1 {
+		return errors.New("parent termini missing")
+	}
+	if err := verifyTermini(sl.config, header, sl.hc.GetTerminiByHash(hash), parentTermini); err != nil {
+		return err
+	}
+	if nodeCtx > common.PRIME_CTX {
+		manifest := rawdb.ReadManifest(sl.sliceDb, header.ParentHash(nodeCtx))
+		if manifest == nil {
+			return errors.New("parent manifest missing")
+		}
+		if types.DeriveSha(manifest, trie.NewStackTrie(nil)) != header.ManifestHash(nodeCtx) {
+			return errors.New("parent manifest does not match manifest hash")
+		}
+	}
+	if nodeCtx == common.ZONE_CTX {
+		if sl.hc.GetEtxSet(hash, header.NumberU64(nodeCtx)) == nil {
+			return errors.New("etx set missing")
+		}
+		if !sl.hc.bc.processor.HasState(header.Root()) {
+			return errors.New("state missing")
+		}
+	}
+	return nil
+}
+
+// checkLinks checks that the dom and the subs agree with the termini of the
+// given block. The dom must have appended the last block coincident with it,
+// and every sub the last block coincident with that sub. Chains which are not
+// linked or cannot be reached are not checked, they are never a reason to
+// rewind.
+func (sl *Slice) checkLinks(header *types.Header) error {
+	if header.Hash() == sl.config.GenesisHash {
+		return nil
+	}
+	nodeCtx := sl.NodeCtx()
+	termini := sl.hc.GetTerminiByHash(header.Hash())
+	if len(termini) != terminusIndex(sl.config)+1 {
+		return errors.New("termini missing")
+	}
+	if dom := sl.domLink(); dom != nil && nodeCtx != common.PRIME_CTX {
+		terminus := termini[terminusIndex(sl.config)]
+		domTermini, err := dom.GetTerminiByHash(context.Background(), terminus)
+		subIndex := sl.NodeLocation().SubIndex(nodeCtx - 1)
+		switch {
+		case err != nil:
+			log.Warn("Failed to check the head block against the dom", "err", err)
+		case domTermini == nil:
+			return fmt.Errorf("dom terminus %x unknown to the dom", terminus)
+		case len(domTermini) <= subIndex || domTermini[subIndex] != terminus:
+			return fmt.Errorf("dom terminus %x not appended to this chain by the dom", terminus)
+		}
+	}
+	if nodeCtx == common.ZONE_CTX {
+		return nil
+	}
+	for i, sub := range sl.subLinks() {
+		if sub == nil {
+			continue
+		}
+		subTermini, err := sub.GetTerminiByHash(context.Background(), termini[i])
+		switch {
+		case err != nil:
+			log.Warn("Failed to check the head block against the subordinate", "index", i, "err", err)
+		case subTermini == nil:
+			return fmt.Errorf("subordinate terminus %d %x unknown to the subordinate", i, termini[i])
+		case len(subTermini) != terminusIndex(sl.config)+1 || subTermini[terminusIndex(sl.config)] != termini[i]:
+			return fmt.Errorf("subordinate terminus %d %x not appended by this chain", i, termini[i])
+		}
+	}
+	return nil
+}
+
+// isCoincident reports whether the given block is coincident with the dom, and
+// so was appended by the dom together with this chain.
+func (sl *Slice) isCoincident(header *types.Header) bool {
+	nodeCtx := sl.NodeCtx()
+	if nodeCtx == common.PRIME_CTX {
+		return true
+	}
+	_, order, err := sl.engine.CalcOrder(header)
+	return err == nil && order < nodeCtx
+}
+
+// rewindHead moves the head back to the given block, removing the hierarchy
+// data of the rewound blocks. Their headers and bodies are kept, so they can be
+// appended again.
+func (sl *Slice) rewindHead(target *types.Header, rewound []*types.Header) {
+	nodeCtx := sl.NodeCtx()
+	// Keep the dom's part of the pending header on top of the target, the dom
+	// does not send it again before its next block
+	domPh, domPhExists := sl.readPhCache(target.Hash())
+	sl.purgeCaches()
+	for _, header := range rewound {
+		rawdb.DeleteCanonicalHash(sl.sliceDb, header.NumberU64(nodeCtx))
+		rawdb.DeleteTermini(sl.sliceDb, header.Hash())
+		sl.hc.DeleteEtxSet(sl.sliceDb, header.Hash(), header.NumberU64(nodeCtx))
+		if nodeCtx != common.ZONE_CTX {
+			rawdb.DeletePendingEtxs(sl.sliceDb, header.Hash())
+			rawdb.DeletePendingEtxsRollup(sl.sliceDb, header.Hash())
+		}
+	}
+	rawdb.WriteHeadBlockHash(sl.sliceDb, target.Hash())
+	sl.hc.currentHeader.Store(target)
+	sl.recoverSnapshots(target.Root())
+
+	if nodeCtx == common.PRIME_CTX {
+		sl.SetHeadBackToRecoveryState(nil, target.Hash())
+	} else if domPhExists {
+		sl.SetHeadBackToRecoveryState(domPh.Header, target.Hash())
+	}
+}
+
+// queueReplay queues a block to be appended again once the core is running.
+// Blocks coincident with the dom are appended again by the dom instead.
+func (sl *Slice) queueReplay(header *types.Header) {
+	nodeCtx := sl.NodeCtx()
+	if nodeCtx != common.PRIME_CTX && sl.isCoincident(header) {
+		return
+	}
+	sl.replay = append(sl.replay, types.HashAndNumber{Hash: header.Hash(), Number: header.NumberU64(nodeCtx)})
+}
+
+// knownAppendResult returns what the append of the given known block returned
+// to the dom, for the dom to replay an append it did not commit.
+func (sl *Slice) knownAppendResult(header *types.Header) (types.Transactions, bool) {
+	nodeCtx := sl.NodeCtx()
+	if nodeCtx == common.ZONE_CTX {
+		block := sl.hc.GetBlock(header.Hash(), header.NumberU64(nodeCtx))
+		if block == nil {
+			return nil, false
+		}
+		return block.ExtTransactions(), true
+	}
+	// Dom chains return the pending ETXs collected from their own sub
+	if sl.subLink(header.Location().SubIndex(nodeCtx)) == nil {
+		return nil, true
+	}
+	pEtxs, err := sl.hc.GetPendingEtxs(header.Hash())
+	if err != nil {
+		return nil, false
+	}
+	return pEtxs.Etxs, true
+}
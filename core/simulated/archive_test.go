@@ -0,0 +1,92 @@
+package simulated
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/rlp"
+)
+
+func archiveTestBlock(number int64) *core.ArchiveBlock {
+	header := types.EmptyHeader()
+	header.SetNumber(big.NewInt(number), common.ZONE_CTX)
+	return &core.ArchiveBlock{
+		Block:   types.NewBlockWithHeader(header),
+		Termini: []common.Hash{{byte(number)}, {}, {}, {}},
+	}
+}
+
+// Tests that archive sections can be read back, and that unread blocks are
+// skipped when moving to the next section.
+func TestArchiveRoundtrip(t *testing.T) {
+	var (
+		buf       bytes.Buffer
+		aw        = core.NewArchiveWriter(&buf)
+		locations = []common.Location{{}, {1}, {1, 2}}
+	)
+	for i, location := range locations {
+		if err := aw.WriteSection(&core.ArchiveSection{Location: location, Count: uint64(i + 1)}); err != nil {
+			t.Fatalf("failed to write section %d: %v", i, err)
+		}
+		for n := 0; n <= i; n++ {
+			if err := aw.WriteBlock(archiveTestBlock(int64(n))); err != nil {
+				t.Fatalf("failed to write block %d of section %d: %v", n, i, err)
+			}
+		}
+	}
+	ar := core.NewArchiveReader(&buf)
+	for i, location := range locations {
+		section, err := ar.NextSection()
+		if err != nil {
+			t.Fatalf("failed to read section %d: %v", i, err)
+		}
+		if !section.Location.Equal(location) || section.Count != uint64(i+1) || section.Version != core.ArchiveVersion {
+			t.Fatalf("section %d mismatch: have %v/%d/%d", i, section.Location, section.Count, section.Version)
+		}
+		// Only read the first block, the others have to be skipped
+		block, err := ar.NextBlock()
+		if err != nil {
+			t.Fatalf("failed to read block of section %d: %v", i, err)
+		}
+		if block.Block.NumberU64(common.ZONE_CTX) != 0 || block.Termini[0] != (common.Hash{}) {
+			t.Fatalf("block of section %d mismatch: have #%d", i, block.Block.NumberU64(common.ZONE_CTX))
+		}
+		if i == 0 {
+			if _, err := ar.NextBlock(); err != io.EOF {
+				t.Fatalf("reading past the section end: have %v, want %v", err, io.EOF)
+			}
+		}
+	}
+	if _, err := ar.NextSection(); err != io.EOF {
+		t.Fatalf("reading past the archive end: have %v, want %v", err, io.EOF)
+	}
+}
+
+// Tests that streams which are not archives, or are written by a newer format
+// version are rejected.
+func TestArchiveInvalid(t *testing.T) {
+	blob, _ := rlp.EncodeToBytes(archiveTestBlock(1).Block)
+	if _, err := core.NewArchiveReader(bytes.NewReader(blob)).NextSection(); !errors.Is(err, core.ErrArchiveMagic) {
+		t.Errorf("plain block export: have %v, want %v", err, core.ErrArchiveMagic)
+	}
+	// Bump the version of a section written by the current format
+	var buf bytes.Buffer
+	if err := core.NewArchiveWriter(&buf).WriteSection(new(core.ArchiveSection)); err != nil {
+		t.Fatalf("failed to write section: %v", err)
+	}
+	var section core.ArchiveSection
+	if err := rlp.DecodeBytes(buf.Bytes(), &section); err != nil {
+		t.Fatalf("failed to decode section: %v", err)
+	}
+	section.Version++
+	blob, _ = rlp.EncodeToBytes(&section)
+	if _, err := core.NewArchiveReader(bytes.NewReader(blob)).NextSection(); !errors.Is(err, core.ErrArchiveVersion) {
+		t.Errorf("future version: have %v, want %v", err, core.ErrArchiveVersion)
+	}
+}
@@ -0,0 +1,631 @@
+package simulated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	interfaces "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/dominant-strategies/go-quai/common/math"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/state"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/core/vm"
+	"github.com/dominant-strategies/go-quai/eth/abi"
+	"github.com/dominant-strategies/go-quai/event"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+var (
+	_ interfaces.ChainReader           = (*Client)(nil)
+	_ interfaces.TransactionReader     = (*Client)(nil)
+	_ interfaces.ChainStateReader      = (*Client)(nil)
+	_ interfaces.ContractCaller        = (*Client)(nil)
+	_ interfaces.PendingContractCaller = (*Client)(nil)
+	_ interfaces.PendingStateReader    = (*Client)(nil)
+	_ interfaces.LogFilterer           = (*Client)(nil)
+	_ interfaces.TransactionSender     = (*Client)(nil)
+	_ interfaces.GasPricer             = (*Client)(nil)
+	_ interfaces.GasEstimator          = (*Client)(nil)
+)
+
+// Client gives contract bindings access to a single zone of a hierarchy. It
+// implements the same interfaces as the RPC client, so code written against a
+// live node can run against the simulator unchanged.
+//
+// Transactions sent through the client are only included once the zone is
+// mined with Commit or Mine. The pending state of the client is the state of
+// the zone head, with nonces accounting for the transactions waiting in the
+// pool.
+type Client struct {
+	h    *Hierarchy
+	zone *node
+}
+
+// Client returns a client for the zone at the given location.
+func (h *Hierarchy) Client(zone common.Location) (*Client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n, err := h.zone(zone)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{h: h, zone: n}, nil
+}
+
+// lock serialises access to the hierarchy. The returned function releases it.
+func (c *Client) lock() func() {
+	c.h.mu.Lock()
+	return c.h.mu.Unlock
+}
+
+// internal decodes an address in the zone and rejects it if the zone does not
+// own it.
+func (c *Client) internal(addr common.Address) (common.InternalAddress, error) {
+	return c.zone.core.Config().InternalAddress(addr)
+}
+
+// pool returns the transaction pool of the zone, reset to the zone head. The
+// pool is only reset when the zone is mined otherwise.
+func (c *Client) pool() *core.TxPool {
+	pool := c.zone.core.TxPool()
+	pool.SyncHead(c.zone.core.CurrentHeader())
+	return pool
+}
+
+// headerByNumber returns the canonical header at a number, or the head if no
+// number is given.
+func (c *Client) headerByNumber(number *big.Int) (*types.Header, error) {
+	if number == nil {
+		return c.zone.core.CurrentHeader(), nil
+	}
+	if header := c.zone.core.GetHeaderByNumber(number.Uint64()); header != nil {
+		return header, nil
+	}
+	return nil, interfaces.NotFound
+}
+
+// stateAt returns the state after the block at a number, or at the head if no
+// number is given.
+func (c *Client) stateAt(number *big.Int) (*state.StateDB, *types.Header, error) {
+	header, err := c.headerByNumber(number)
+	if err != nil {
+		return nil, nil, err
+	}
+	statedb, err := c.zone.core.StateAt(header.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	return statedb, header, nil
+}
+
+// BlockByHash returns the block with the given hash.
+func (c *Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	defer c.lock()()
+
+	if block := c.zone.core.GetBlockByHash(hash); block != nil {
+		return block, nil
+	}
+	return nil, interfaces.NotFound
+}
+
+// BlockByNumber returns the canonical block at a number, or the head if no
+// number is given.
+func (c *Client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	defer c.lock()()
+
+	header, err := c.headerByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	if block := c.zone.core.GetBlock(header.Hash(), header.NumberU64(common.ZONE_CTX)); block != nil {
+		return block, nil
+	}
+	return nil, interfaces.NotFound
+}
+
+// HeaderByHash returns the header with the given hash.
+func (c *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	defer c.lock()()
+
+	if header := c.zone.core.GetHeaderByHash(hash); header != nil {
+		return types.CopyHeader(header), nil
+	}
+	return nil, interfaces.NotFound
+}
+
+// HeaderByNumber returns the canonical header at a number, or the head if no
+// number is given.
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	defer c.lock()()
+
+	header, err := c.headerByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return types.CopyHeader(header), nil
+}
+
+// TransactionCount returns the number of transactions in the given block.
+func (c *Client) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	block, err := c.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return 0, err
+	}
+	return uint(len(block.Transactions())), nil
+}
+
+// TransactionInBlock returns the transaction at an index of the given block.
+func (c *Client) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	block, err := c.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if index >= uint(len(block.Transactions())) {
+		return nil, interfaces.NotFound
+	}
+	return block.Transactions()[index], nil
+}
+
+// SubscribeNewHead subscribes to the new heads of the zone.
+func (c *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (interfaces.Subscription, error) {
+	defer c.lock()()
+
+	heads := make(chan core.ChainHeadEvent, 10)
+	sub := c.zone.core.SubscribeChainHeadEvent(heads)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case head := <-heads:
+				select {
+				case ch <- head.Block.Header():
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// TransactionByHash returns an included or pending transaction.
+func (c *Client) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	defer c.lock()()
+
+	if tx := c.zone.core.TxPool().Get(hash); tx != nil {
+		return tx, true, nil
+	}
+	if tx, _, _, _ := rawdb.ReadTransaction(c.zone.db, hash); tx != nil {
+		return tx, false, nil
+	}
+	return nil, false, interfaces.NotFound
+}
+
+// TransactionReceipt returns the receipt of an included transaction.
+func (c *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	defer c.lock()()
+
+	if receipt, _, _, _ := rawdb.ReadReceipt(c.zone.db, txHash, c.zone.core.Config()); receipt != nil {
+		return receipt, nil
+	}
+	return nil, interfaces.NotFound
+}
+
+// BalanceAt returns the balance of an account at a block number.
+func (c *Client) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	defer c.lock()()
+
+	addr, err := c.internal(account)
+	if err != nil {
+		return nil, err
+	}
+	statedb, _, err := c.stateAt(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetBalance(addr), nil
+}
+
+// StorageAt returns the value of a storage slot of an account at a block
+// number.
+func (c *Client) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	defer c.lock()()
+
+	addr, err := c.internal(account)
+	if err != nil {
+		return nil, err
+	}
+	statedb, _, err := c.stateAt(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	value := statedb.GetState(addr, key)
+	return value[:], nil
+}
+
+// CodeAt returns the code of an account at a block number.
+func (c *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	defer c.lock()()
+
+	addr, err := c.internal(account)
+	if err != nil {
+		return nil, err
+	}
+	statedb, _, err := c.stateAt(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetCode(addr), nil
+}
+
+// NonceAt returns the nonce of an account at a block number.
+func (c *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	defer c.lock()()
+
+	addr, err := c.internal(account)
+	if err != nil {
+		return 0, err
+	}
+	statedb, _, err := c.stateAt(blockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return statedb.GetNonce(addr), nil
+}
+
+// PendingBalanceAt returns the balance of an account at the zone head.
+func (c *Client) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	return c.BalanceAt(ctx, account, nil)
+}
+
+// PendingStorageAt returns the value of a storage slot at the zone head.
+func (c *Client) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
+	return c.StorageAt(ctx, account, key, nil)
+}
+
+// PendingCodeAt returns the code of an account at the zone head.
+func (c *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return c.CodeAt(ctx, account, nil)
+}
+
+// PendingNonceAt returns the next nonce of an account, including transactions
+// waiting in the pool.
+func (c *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	defer c.lock()()
+
+	addr, err := c.internal(account)
+	if err != nil {
+		return 0, err
+	}
+	return c.pool().Nonce(addr), nil
+}
+
+// PendingTransactionCount returns the number of executable transactions in
+// the pool.
+func (c *Client) PendingTransactionCount(ctx context.Context) (uint, error) {
+	defer c.lock()()
+
+	pending, _ := c.pool().Stats()
+	return uint(pending), nil
+}
+
+// CallContract executes a call at a block number without changing any state.
+func (c *Client) CallContract(ctx context.Context, call interfaces.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	defer c.lock()()
+
+	statedb, header, err := c.stateAt(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.call(call, header, statedb)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Revert()) > 0 {
+		return nil, newRevertError(result)
+	}
+	return result.Return(), result.Err
+}
+
+// PendingCallContract executes a call at the zone head.
+func (c *Client) PendingCallContract(ctx context.Context, call interfaces.CallMsg) ([]byte, error) {
+	return c.CallContract(ctx, call, nil)
+}
+
+// SuggestGasPrice returns the base fee of the next block.
+func (c *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	defer c.lock()()
+
+	return c.zone.core.CalculateBaseFee(c.zone.core.CurrentHeader()), nil
+}
+
+// SuggestGasTipCap returns the minimum tip accepted by the simulated miners.
+func (c *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+// EstimateExternalGas finds the lowest gas limit an ETX executes with at the
+// head of its destination zone. The request is routed through the dom and sub
+// chains of the zone, like a node does.
+func (c *Client) EstimateExternalGas(ctx context.Context, call interfaces.CallMsg) (uint64, error) {
+	defer c.lock()()
+	return c.zone.core.EstimateExternalGas(ctx, call)
+}
+
+// EstimateGas finds the lowest gas limit a call executes with at the zone head.
+func (c *Client) EstimateGas(ctx context.Context, call interfaces.CallMsg) (uint64, error) {
+	defer c.lock()()
+
+	head := c.zone.core.CurrentHeader()
+	lo, hi := params.TxGas-1, head.GasLimit()
+	if call.Gas >= params.TxGas {
+		hi = call.Gas
+	}
+	cap := hi
+
+	// Execute the call with a gas limit, reporting whether it failed for lack
+	// of gas and the result of any other failure
+	executable := func(gas uint64) (bool, *core.ExecutionResult, error) {
+		call.Gas = gas
+
+		statedb, err := c.zone.core.StateAt(head.Root())
+		if err != nil {
+			return true, nil, err
+		}
+		result, err := c.call(call, head, statedb)
+		if err != nil {
+			if errors.Is(err, core.ErrIntrinsicGas) {
+				return true, nil, nil
+			}
+			return true, nil, err
+		}
+		return result.Failed(), result, nil
+	}
+	for lo+1 < hi {
+		mid := (hi + lo) / 2
+		failed, _, err := executable(mid)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	// The call failed even with the highest gas limit, report why
+	if hi == cap {
+		failed, result, err := executable(hi)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			if result != nil && result.Err != vm.ErrOutOfGas {
+				if len(result.Revert()) > 0 {
+					return 0, newRevertError(result)
+				}
+				return 0, result.Err
+			}
+			return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
+		}
+	}
+	return hi, nil
+}
+
+// SendTransaction adds a signed transaction to the pool of the zone. It is
+// included by the next block mined in the zone.
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	defer c.lock()()
+
+	// Decode the transaction like the zone receives it over the wire
+	txs, err := recodeTransactions(types.Transactions{tx})
+	if err != nil {
+		return err
+	}
+	return c.pool().AddLocal(txs[0])
+}
+
+// SendBundle queues a bundle of signed transactions for inclusion at the top of
+// the zone block with the given number. It returns the hash of the bundle.
+func (c *Client) SendBundle(ctx context.Context, txs types.Transactions, blockNumber *big.Int) (common.Hash, error) {
+	defer c.lock()()
+
+	txs, err := recodeTransactions(txs)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	bundle := &core.Bundle{Txs: txs, BlockNumber: blockNumber}
+	if err := c.zone.core.SendBundle(bundle); err != nil {
+		return common.Hash{}, err
+	}
+	return bundle.Hash(), nil
+}
+
+// CallBundle simulates a bundle of signed transactions at the top of the next
+// block of the zone.
+func (c *Client) CallBundle(ctx context.Context, txs types.Transactions) (*core.BundleResult, error) {
+	defer c.lock()()
+
+	txs, err := recodeTransactions(txs)
+	if err != nil {
+		return nil, err
+	}
+	return c.zone.core.CallBundle(&core.Bundle{Txs: txs})
+}
+
+// FilterLogs returns the logs of canonical blocks matching a query.
+func (c *Client) FilterLogs(ctx context.Context, query interfaces.FilterQuery) ([]types.Log, error) {
+	defer c.lock()()
+
+	var hashes []common.Hash
+	if query.BlockHash != nil {
+		hashes = append(hashes, *query.BlockHash)
+	} else {
+		head := c.zone.core.CurrentHeader().NumberU64(common.ZONE_CTX)
+		from, to := uint64(0), head
+		if query.FromBlock != nil {
+			from = query.FromBlock.Uint64()
+		}
+		if query.ToBlock != nil && query.ToBlock.Uint64() < head {
+			to = query.ToBlock.Uint64()
+		}
+		for number := from; number <= to; number++ {
+			hashes = append(hashes, c.zone.core.GetCanonicalHash(number))
+		}
+	}
+	var logs []types.Log
+	for _, hash := range hashes {
+		for _, receipt := range c.zone.core.GetReceiptsByHash(hash) {
+			for _, log := range receipt.Logs {
+				if matchLog(log, query) {
+					logs = append(logs, *log)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+// SubscribeFilterLogs subscribes to the logs of new blocks matching a query.
+func (c *Client) SubscribeFilterLogs(ctx context.Context, query interfaces.FilterQuery, ch chan<- types.Log) (interfaces.Subscription, error) {
+	defer c.lock()()
+
+	logsCh := make(chan []*types.Log, 10)
+	sub := c.zone.core.SubscribeLogsEvent(logsCh)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case logs := <-logsCh:
+				for _, log := range logs {
+					if !matchLog(log, query) {
+						continue
+					}
+					select {
+					case ch <- *log:
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// matchLog reports whether a log matches the addresses and topics of a query.
+func matchLog(log *types.Log, query interfaces.FilterQuery) bool {
+	if len(query.Addresses) > 0 {
+		var found bool
+		for _, addr := range query.Addresses {
+			if addr.Equal(log.Address) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(query.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, sub := range query.Topics {
+		if len(sub) == 0 {
+			continue // empty rule set == wildcard
+		}
+		var match bool
+		for _, topic := range sub {
+			if log.Topics[i] == topic {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// call executes a message on the given state, which the call modifies.
+func (c *Client) call(call interfaces.CallMsg, header *types.Header, statedb *state.StateDB) (*core.ExecutionResult, error) {
+	from, err := c.internal(call.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender: %w", err)
+	}
+	// Calls are free, make sure the sender can pay for any value transferred
+	statedb.SetBalance(from, math.MaxBig256)
+
+	if call.Gas == 0 {
+		call.Gas = header.GasLimit()
+	}
+	gasPrice, gasFeeCap, gasTipCap := new(big.Int), new(big.Int), new(big.Int)
+	if call.GasPrice != nil {
+		gasPrice, gasFeeCap, gasTipCap = call.GasPrice, call.GasPrice, call.GasPrice
+	} else {
+		if call.GasFeeCap != nil {
+			gasFeeCap = call.GasFeeCap
+		}
+		if call.GasTipCap != nil {
+			gasTipCap = call.GasTipCap
+		}
+		if gasFeeCap.BitLen() > 0 || gasTipCap.BitLen() > 0 {
+			gasPrice = math.BigMin(new(big.Int).Add(gasTipCap, header.BaseFee()), gasFeeCap)
+		}
+	}
+	value := call.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	var to *common.Address
+	if call.To != nil {
+		addr := common.Bytes20ToAddress(call.To.Bytes20())
+		to = &addr
+	}
+	msg := types.NewMessage(common.Bytes20ToAddress(call.From.Bytes20()), to, 0, value, call.Gas, gasPrice, gasFeeCap, gasTipCap, call.Data, call.AccessList, false)
+
+	blockContext := core.NewEVMBlockContext(header, c.zone.core, nil)
+	evm := vm.NewEVM(blockContext, core.NewEVMTxContext(msg), statedb, c.zone.core.Config(), vm.Config{NoBaseFee: true})
+	return core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+}
+
+// revertError is returned by calls reverted by the EVM. The revert reason is
+// available as error data, like the RPC client reports it.
+type revertError struct {
+	error
+	reason string // revert reason hex encoded
+}
+
+func newRevertError(result *core.ExecutionResult) *revertError {
+	reason, errUnpack := abi.UnpackRevert(result.Revert())
+	err := errors.New("execution reverted")
+	if errUnpack == nil {
+		err = fmt.Errorf("execution reverted: %v", reason)
+	}
+	return &revertError{
+		error:  err,
+		reason: hexutil.Encode(result.Revert()),
+	}
+}
+
+// ErrorData returns the hex encoded revert reason.
+func (e *revertError) ErrorData() interface{} {
+	return e.reason
+}
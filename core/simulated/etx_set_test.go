@@ -0,0 +1,177 @@
+package simulated
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/trie"
+)
+
+// etxSetSnapshotInterval mirrors the number of blocks between two full EtxSets
+// stored by the headerchain.
+const etxSetSnapshotInterval = 128
+
+// testEtx returns an ETX told apart from the others by its nonce.
+func testEtx(nonce uint64) *types.Transaction {
+	to := common.HexToAddress("0x0100000000000000000000000000000000000001")
+	return types.NewTx(&types.ExternalTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     nonce,
+		GasTipCap: new(big.Int),
+		GasFeeCap: new(big.Int),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1),
+	})
+}
+
+// etxSetRoot returns the root of an EtxSet.
+func etxSetRoot(set types.EtxSet) common.Hash {
+	return set.Root(trie.NewStackTrie(nil))
+}
+
+// etxSetHashes returns the ETX hashes of an EtxSet along with their heights.
+func etxSetHashes(set types.EtxSet) map[common.Hash]uint64 {
+	hashes := make(map[common.Hash]uint64, len(set))
+	for hash, entry := range set {
+		hashes[hash] = entry.Height
+	}
+	return hashes
+}
+
+// Tests that applying a diff adds the new ETXs before removing the spent ones,
+// and that the root only depends on the ETXs and their heights.
+func TestEtxSetApply(t *testing.T) {
+	var (
+		first, second, third = testEtx(1), testEtx(2), testEtx(3)
+		set                  = types.NewEtxSet()
+	)
+	set.Apply(&types.EtxSetDiff{Added: types.Transactions{first, second}}, 1)
+	set.Apply(&types.EtxSetDiff{Added: types.Transactions{third}, Removed: []common.Hash{first.Hash(), third.Hash()}}, 2)
+
+	if want := map[common.Hash]uint64{second.Hash(): 1}; !reflect.DeepEqual(etxSetHashes(set), want) {
+		t.Fatalf("set mismatch: have %v, want %v", etxSetHashes(set), want)
+	}
+	// The root does not depend on the order the ETXs were added in
+	reversed := types.NewEtxSet()
+	reversed.Apply(&types.EtxSetDiff{Added: types.Transactions{third, first}}, 3)
+	ordered := types.NewEtxSet()
+	ordered.Apply(&types.EtxSetDiff{Added: types.Transactions{first, third}}, 3)
+	if etxSetRoot(reversed) != etxSetRoot(ordered) {
+		t.Errorf("root depends on the insertion order: %x != %x", etxSetRoot(reversed), etxSetRoot(ordered))
+	}
+	// But it does depend on the height the ETXs became available at
+	later := types.NewEtxSet()
+	later.Apply(&types.EtxSetDiff{Added: types.Transactions{first, third}}, 4)
+	if etxSetRoot(later) == etxSetRoot(ordered) {
+		t.Errorf("root does not depend on the availability height")
+	}
+	if etxSetRoot(types.NewEtxSet()) != types.EmptyRootHash {
+		t.Errorf("empty set root mismatch: have %x, want %x", etxSetRoot(types.NewEtxSet()), types.EmptyRootHash)
+	}
+	// Copies are independent of the original
+	cpy := set.Copy()
+	cpy.Apply(&types.EtxSetDiff{Removed: []common.Hash{second.Hash()}}, 5)
+	if len(set) != 1 || len(cpy) != 0 {
+		t.Errorf("copy not independent: have %d and %d entries", len(set), len(cpy))
+	}
+}
+
+// Tests that the EtxSet of a block is rebuilt from the stored diffs, on either
+// side of a full set and on competing branches, after the in-memory sets are
+// gone.
+func TestEtxSetRebuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the mining of a snapshot interval of blocks in short mode")
+	}
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	zone := common.Location{0, 0}
+	blocks := []*types.Block{h.Genesis()}
+	for i := 0; i < etxSetSnapshotInterval+8; i++ {
+		block, err := h.Commit(zone)
+		if err != nil {
+			t.Fatalf("failed to mine block %d: %v", i+1, err)
+		}
+		blocks = append(blocks, block)
+	}
+	n, err := h.node(zone)
+	if err != nil {
+		t.Fatalf("failed to get zone: %v", err)
+	}
+	// Replace the sets of the chain by ones holding every ETX for 3 blocks, so
+	// that the full set in between the diffs is not empty
+	var (
+		hc   = n.core.Slice().HeaderChain()
+		set  = types.NewEtxSet()
+		sets = []types.EtxSet{set.Copy()}
+	)
+	for number := uint64(1); number < uint64(len(blocks)); number++ {
+		diff := &types.EtxSetDiff{Added: types.Transactions{testEtx(number)}}
+		if number > 3 {
+			diff.Removed = []common.Hash{testEtx(number - 3).Hash()}
+		}
+		set.Apply(diff, number)
+		hc.WriteEtxSet(n.db, blocks[number].Hash(), number, set, diff)
+		sets = append(sets, set.Copy())
+	}
+	// A competing branch on the last block replaces its ETX by another one
+	var (
+		tip      = uint64(len(blocks) - 1)
+		side     = sibling(h, zone, blocks[tip])
+		sideDiff = &types.EtxSetDiff{Added: types.Transactions{testEtx(1000)}, Removed: []common.Hash{testEtx(tip - 3).Hash()}}
+		sideSet  = sets[tip-1].Copy()
+	)
+	sideSet.Apply(sideDiff, tip)
+	hc.WriteEtxSet(n.db, side.Hash(), tip, sideSet, sideDiff)
+
+	// Reopen the zone so that every set is rebuilt from the database
+	restart(t, h, zone, nil)
+	hc = n.core.Slice().HeaderChain()
+
+	check := func(name string, hash common.Hash, number uint64, want types.EtxSet) {
+		t.Helper()
+		have := hc.GetEtxSet(hash, number)
+		if have == nil {
+			t.Fatalf("%s: set %d not rebuilt", name, number)
+		}
+		if !reflect.DeepEqual(etxSetHashes(have), etxSetHashes(want)) {
+			t.Errorf("%s: set %d mismatch: have %v, want %v", name, number, etxSetHashes(have), etxSetHashes(want))
+		}
+	}
+	for _, number := range []uint64{1, 2, etxSetSnapshotInterval - 1, etxSetSnapshotInterval, etxSetSnapshotInterval + 1, tip} {
+		check("canonical", blocks[number].Hash(), number, sets[number])
+	}
+	check("side", side.Hash(), tip, sideSet)
+
+	// The side branch keeps its set once canonical, and so does the old tip
+	setHead(t, h, zone, side.Header())
+	check("reorged side", side.Hash(), tip, sideSet)
+	check("reorged canonical", blocks[tip].Hash(), tip, sets[tip])
+
+	// A diff which does not lead to its stored root is rejected. Its parent set
+	// is cached, so the diff is the only one replayed
+	number := uint64(etxSetSnapshotInterval + 2)
+	diff := rawdb.ReadEtxSetDiff(n.db, blocks[number].Hash(), number)
+	corrupted := *diff
+	corrupted.Removed = nil
+	rawdb.WriteEtxSetDiff(n.db, blocks[number].Hash(), number, &corrupted)
+	if set := hc.GetEtxSet(blocks[number].Hash(), number); set != nil {
+		t.Errorf("set rebuilt from a corrupted diff: %v", etxSetHashes(set))
+	}
+	rawdb.WriteEtxSetDiff(n.db, blocks[number].Hash(), number, diff)
+
+	// Without the full set, the diffs are replayed all the way from genesis
+	rawdb.DeleteEtxSet(n.db, blocks[etxSetSnapshotInterval].Hash(), etxSetSnapshotInterval)
+	restart(t, h, zone, nil)
+	hc = n.core.Slice().HeaderChain()
+	check("no full set", blocks[etxSetSnapshotInterval+1].Hash(), etxSetSnapshotInterval+1, sets[etxSetSnapshotInterval+1])
+}
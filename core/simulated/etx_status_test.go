@@ -0,0 +1,190 @@
+package simulated
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+// etxStatus returns the lifecycle of an ETX as seen by a chain of the hierarchy.
+func etxStatus(h *Hierarchy, location common.Location, hash common.Hash) *types.EtxStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.nodes[string(location)].core.GetEtxStatus(hash)
+}
+
+// Tests that every chain an ETX goes through indexes its part of the lifecycle:
+// the origin zone emits it, the region rolls it up and references it, and the
+// destination zone makes it available and either executes it or lets it expire.
+func TestEtxStatus(t *testing.T) {
+	var (
+		from, to    = common.Location{0, 0}, common.Location{0, 1}
+		region      = common.Location{0}
+		key, sender = zoneKey(t, from)
+		_, receiver = zoneKey(t, to)
+		ctx         = context.Background()
+	)
+	h, err := NewHierarchy([]common.Address{sender}, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	if _, err := h.Commit(from); err != nil {
+		t.Fatalf("failed to mine funding block: %v", err)
+	}
+	source, err := h.Client(from)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	dest, err := h.node(to)
+	if err != nil {
+		t.Fatalf("failed to get destination zone: %v", err)
+	}
+	statuses := make(chan core.EtxStatusEvent, 1024)
+	sub := dest.core.SubscribeEtxStatusEvent(statuses)
+	defer sub.Unsubscribe()
+
+	// An ETX needing more gas than a block has is never picked by the
+	// destination miner
+	gasPrice, err := source.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatalf("failed to get gas price: %v", err)
+	}
+	gasPrice.Mul(gasPrice, big.NewInt(2))
+	oversized := types.MustSignNewTx(key, types.LatestSigner(h.Config()), &types.InternalToExternalTx{
+		ChainID:     h.Config().ChainID,
+		GasTipCap:   big.NewInt(1),
+		GasFeeCap:   gasPrice,
+		Gas:         2 * params.TxGas,
+		To:          &receiver,
+		Value:       big.NewInt(1),
+		ETXGasLimit: 2 * h.Genesis().GasLimit(),
+		ETXGasPrice: new(big.Int).Mul(gasPrice, big.NewInt(int64(h.Config().GetTopology().Zones))),
+		ETXGasTip:   big.NewInt(int64(h.Config().GetTopology().Zones)),
+	})
+	if err := source.SendTransaction(ctx, oversized); err != nil {
+		t.Fatalf("failed to send oversized transaction: %v", err)
+	}
+	block, err := h.Commit(from)
+	if err != nil {
+		t.Fatalf("failed to mine oversized transaction: %v", err)
+	}
+	if len(block.ExtTransactions()) != 1 {
+		t.Fatalf("oversized ETX not emitted: %d etxs", len(block.ExtTransactions()))
+	}
+	expiring := block.ExtTransactions()[0].Hash()
+
+	// Only the ETXs of a coincident block are rolled up by the region, so emit
+	// one in every block until one of them is coincident
+	var (
+		emitter *types.Block
+		order   = common.ZONE_CTX
+	)
+	for nonce := uint64(1); order != common.REGION_CTX; nonce++ {
+		if nonce > maxFillerBlocks {
+			t.Fatalf("region order not reached after %d blocks", maxFillerBlocks)
+		}
+		if err := source.SendTransaction(ctx, crossZoneTx(t, h, source, key, nonce, receiver, big.NewInt(1))); err != nil {
+			t.Fatalf("failed to send transaction %d: %v", nonce, err)
+		}
+		h.mu.Lock()
+		emitter, order, err = h.mineBlock(h.nodes[string(from)], common.REGION_CTX)
+		h.mu.Unlock()
+		if err != nil {
+			t.Fatalf("failed to mine transaction %d: %v", nonce, err)
+		}
+		if len(emitter.ExtTransactions()) != 1 {
+			t.Fatalf("transaction %d not included: %d etxs", nonce, len(emitter.ExtTransactions()))
+		}
+	}
+	etx := emitter.ExtTransactions()[0].Hash()
+
+	status := etxStatus(h, from, etx)
+	if status == nil || status.Stage != types.EtxEmitted || status.OriginBlock != emitter.Hash() || !status.OriginLocation.Equal(from) {
+		t.Fatalf("origin status mismatch: have %+v, want emitted by %x", status, emitter.Hash())
+	}
+	status = etxStatus(h, region, etx)
+	if status == nil || status.Stage != types.EtxRolledUp || status.RollupBlock != emitter.Hash() || !status.RollupLocation.Equal(region) {
+		t.Fatalf("region status mismatch: have %+v, want rolled up by %x", status, emitter.Hash())
+	}
+	if status := etxStatus(h, to, etx); status != nil {
+		t.Fatalf("destination status before reference: %+v", status)
+	}
+	// The next region block of the origin carries the emitter in its manifest,
+	// and the region block after it confirms the ETXs of that manifest and
+	// hands them to the destination zone
+	if _, err := h.Mine(from, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine origin region block: %v", err)
+	}
+	if status := etxStatus(h, region, etx); status == nil || status.Stage != types.EtxRolledUp {
+		t.Fatalf("region status mismatch: have %+v, want rolled up", status)
+	}
+	coincident, err := h.Mine(to, common.REGION_CTX)
+	if err != nil {
+		t.Fatalf("failed to mine destination region block: %v", err)
+	}
+	status = etxStatus(h, region, etx)
+	if status == nil || status.Stage != types.EtxReferenced || status.DomBlock != coincident.Hash() || status.RollupBlock != emitter.Hash() {
+		t.Fatalf("region status mismatch: have %+v, want referenced by %x", status, coincident.Hash())
+	}
+	for _, hash := range []common.Hash{etx, expiring} {
+		status = etxStatus(h, to, hash)
+		if status == nil || status.Stage != types.EtxAvailable || status.AvailableBlock != coincident.Hash() || status.AvailableHeight != coincident.NumberU64(common.ZONE_CTX) {
+			t.Fatalf("destination status of %x mismatch: have %+v, want available at %x", hash, status, coincident.Hash())
+		}
+	}
+	// The destination executes the first ETX and leaves the other one
+	executor, err := h.Commit(to)
+	if err != nil {
+		t.Fatalf("failed to mine destination zone block: %v", err)
+	}
+	status = etxStatus(h, to, etx)
+	if status == nil || status.Stage != types.EtxExecuted || status.InclusionBlock != executor.Hash() || status.AvailableBlock != coincident.Hash() {
+		t.Fatalf("destination status mismatch: have %+v, want executed by %x", status, executor.Hash())
+	}
+	if status := etxStatus(h, to, expiring); status == nil || status.Stage != types.EtxAvailable {
+		t.Fatalf("oversized status mismatch: have %+v, want available", status)
+	}
+	// Subscribers are told about every stage the destination went through
+	var stages []types.EtxStage
+	for len(statuses) > 0 {
+		if ev := <-statuses; ev.Hash == etx {
+			stages = append(stages, ev.Status.Stage)
+		}
+	}
+	if len(stages) != 2 || stages[0] != types.EtxAvailable || stages[1] != types.EtxExecuted {
+		t.Errorf("announced stages mismatch: have %v, want [available executed]", stages)
+	}
+	// Once the executing block is reorged out, the ETX is only available again,
+	// and executed again once the block is back in the canonical chain
+	setHead(t, h, to, sibling(h, to, executor).Header())
+	status = etxStatus(h, to, etx)
+	if status == nil || status.Stage != types.EtxAvailable || status.InclusionBlock != (common.Hash{}) || status.AvailableBlock != coincident.Hash() {
+		t.Fatalf("reorged destination status mismatch: have %+v, want available at %x", status, coincident.Hash())
+	}
+	setHead(t, h, to, executor.Header())
+	if status := etxStatus(h, to, etx); status == nil || status.Stage != types.EtxExecuted || status.InclusionBlock != executor.Hash() {
+		t.Fatalf("restored destination status mismatch: have %+v, want executed by %x", status, executor.Hash())
+	}
+	if testing.Short() {
+		return
+	}
+	// The oversized ETX expires once it waited for too many blocks
+	available := coincident.NumberU64(common.ZONE_CTX)
+	for executor.NumberU64(common.ZONE_CTX) <= available+params.EtxExpirationAge {
+		if executor, err = h.Commit(to); err != nil {
+			t.Fatalf("failed to mine destination zone block: %v", err)
+		}
+	}
+	status = etxStatus(h, to, expiring)
+	if status == nil || status.Stage != types.EtxExpired || status.ExpiryBlock != executor.Hash() || status.ExpiryHeight != available+params.EtxExpirationAge+1 || status.InclusionBlock != (common.Hash{}) {
+		t.Fatalf("oversized status mismatch: have %+v, want expired at %d", status, available+params.EtxExpirationAge+1)
+	}
+}
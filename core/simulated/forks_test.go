@@ -0,0 +1,133 @@
+package simulated
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/consensus/misc"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+// Tests that the scope and rewards forks change the signer, the header checks
+// and the block reward of a zone from their activation block on, and that the
+// blocks built on top of an upgraded block commit to their EtxSet.
+func TestForkActivation(t *testing.T) {
+	var (
+		fork   = big.NewInt(2)
+		config = &params.ChainConfig{
+			Rewards: &params.RewardsConfig{
+				Reward:     big.NewInt(900),
+				Shares:     [common.HierarchyDepth]uint64{0, 0, 1},
+				TimeFactor: 1,
+			},
+			Forks: map[params.Fork]*params.ForkActivation{
+				params.Push0Fork:   {Zone: fork},
+				params.ScopeFork:   {Zone: fork},
+				params.RewardsFork: {Zone: fork},
+				params.EtxSetFork:  {Zone: fork},
+			},
+		}
+		zone     = common.Location{0, 0}
+		coinbase = defaultCoinbase(zone, common.DefaultTopology)
+		foreign  = defaultCoinbase(common.Location{0, 1}, common.DefaultTopology)
+	)
+	h, err := NewHierarchyWithConfig(config, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	n, err := h.node(zone)
+	if err != nil {
+		t.Fatalf("failed to get zone: %v", err)
+	}
+	var (
+		zoneConfig = n.core.Config()
+		hc         = n.core.Slice().HeaderChain()
+		blocks     []*types.Block
+	)
+	for i := 0; i < 3; i++ {
+		block, err := h.Commit(zone)
+		if err != nil {
+			t.Fatalf("failed to mine block %d: %v", i+1, err)
+		}
+		blocks = append(blocks, block)
+	}
+	// The signer only refuses senders of other zones once the fork is active
+	key, _ := zoneKey(t, common.Location{0, 1})
+	tx := types.MustSignNewTx(key, types.LatestSigner(zoneConfig), &types.InternalTx{
+		ChainID:   zoneConfig.ChainID,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+		Gas:       params.TxGas,
+		To:        &coinbase,
+		Value:     big.NewInt(1),
+	})
+	for _, block := range blocks {
+		signer := types.MakeSigner(zoneConfig, block.Number(common.ZONE_CTX), block.Number(common.PRIME_CTX))
+		_, err := signer.Sender(tx)
+		if active := block.Number(common.ZONE_CTX).Cmp(fork) >= 0; active != errors.Is(err, types.ErrSenderOutOfScope) {
+			t.Errorf("block %d: sender error mismatch: have %v, fork active %v", block.NumberU64(common.ZONE_CTX), err, active)
+		}
+	}
+	// Headers paying another zone are only refused once the fork is active
+	for _, block := range blocks {
+		header := types.CopyHeader(block.Header())
+		header.SetCoinbase(foreign)
+		if _, err := h.seal(n, header, common.ZONE_CTX); err != nil {
+			t.Fatalf("failed to seal header: %v", err)
+		}
+		err := n.core.Engine().VerifyHeader(hc, header)
+		if active := block.Number(common.ZONE_CTX).Cmp(fork) >= 0; active != (err != nil && strings.Contains(err.Error(), "coinbase out of scope")) {
+			t.Errorf("block %d: header error mismatch: have %v, fork active %v", block.NumberU64(common.ZONE_CTX), err, active)
+		}
+	}
+	// The launch rewards are paid until the fork, the configured ones after it
+	client, err := h.Client(zone)
+	if err != nil {
+		t.Fatalf("failed to get zone client: %v", err)
+	}
+	want := new(big.Int)
+	for _, block := range blocks {
+		reward := misc.CalculateReward(zoneConfig, block.Header())
+		if block.Number(common.ZONE_CTX).Cmp(fork) >= 0 && reward.Cmp(big.NewInt(100)) != 0 {
+			t.Errorf("block %d: reward mismatch: have %v, want 100", block.NumberU64(common.ZONE_CTX), reward)
+		}
+		want.Add(want, reward)
+		balance, err := client.BalanceAt(context.Background(), coinbase, block.Number(common.ZONE_CTX))
+		if err != nil || balance.Cmp(want) != 0 {
+			t.Errorf("block %d: coinbase balance mismatch: have %v, want %v (err %v)", block.NumberU64(common.ZONE_CTX), balance, want, err)
+		}
+	}
+	// Only the children of upgraded blocks commit to the set of their parent
+	for i, block := range blocks {
+		var want common.Hash
+		if i > 0 && blocks[i-1].Number(common.ZONE_CTX).Cmp(fork) >= 0 {
+			want = etxSetRoot(types.NewEtxSet())
+		}
+		if root := block.EtxSetRoot(); root != want {
+			t.Errorf("block %d: etx set root mismatch: have %x, want %x", block.NumberU64(common.ZONE_CTX), root, want)
+		}
+	}
+	// A block committing to another set is refused
+	header := types.CopyHeader(blocks[2].Header())
+	header.SetEtxSetRoot(common.Hash{0x01})
+	if _, err := h.seal(n, header, common.ZONE_CTX); err != nil {
+		t.Fatalf("failed to seal header: %v", err)
+	}
+	forged := types.NewBlockWithHeader(header).WithBody(blocks[2].Transactions(), blocks[2].Uncles(), blocks[2].ExtTransactions(), blocks[2].SubManifest())
+	n.core.WriteBlock(forged)
+	if _, _, err := n.core.Append(header, types.EmptyHeader(), common.Hash{}, false, nil); !errors.Is(err, core.ErrBadEtxSetRoot) {
+		t.Errorf("forged block append error mismatch: have %v, want %v", err, core.ErrBadEtxSetRoot)
+	}
+	launch := misc.CalculateReward(zoneConfig, blocks[0].Header())
+	if expect := new(big.Int).Div(params.DefaultRewards.Reward, big.NewInt(3*3*10*3*10)); launch.Cmp(expect) != 0 {
+		t.Errorf("launch reward mismatch: have %v, want %v", launch, expect)
+	}
+}
@@ -0,0 +1,536 @@
+// Package simulated runs a complete prime, region and zone hierarchy in memory,
+// so that blocks at every order, their manifests and the external transactions
+// routed between zones can be exercised from a regular go test.
+package simulated
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/consensus/blake3pow"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/core/vm"
+	"github.com/dominant-strategies/go-quai/ethdb"
+	"github.com/dominant-strategies/go-quai/params"
+	"github.com/dominant-strategies/go-quai/trie"
+)
+
+const (
+	// GenesisTime is the timestamp of the simulated genesis block. Every block
+	// is one second after its zone parent, so the chain stays in the past for
+	// as long as a test could reasonably run.
+	GenesisTime = 1672531200
+
+	grindWindow     = 1 << 14 // Nonces searched for the highest entropy filler block
+	grindCandidates = 16      // Highest entropy nonces whose order is checked
+	maxFillerBlocks = 1024    // Maximum blocks mined to reach a requested order
+)
+
+var (
+	// ErrUnknownLocation is returned if a location is not part of the hierarchy.
+	ErrUnknownLocation = errors.New("location is not part of the simulated hierarchy")
+
+	// ErrNotZone is returned if a zone operation is requested for a dom chain.
+	ErrNotZone = errors.New("location is not a zone")
+
+	// ErrInvalidOrder is returned if a block is requested at an unknown order.
+	ErrInvalidOrder = errors.New("invalid block order")
+)
+
+// node is a single chain of the hierarchy together with its backing database.
+type node struct {
+	location  common.Location
+	db        ethdb.Database
+	engine    *blake3pow.Blake3pow
+	core      *core.Core
+	etherbase common.Address
+	gasLimit  uint64
+}
+
+// Hierarchy is a full hierarchy of prime, regions and zones running in memory,
+// by default with the 3 regions and 9 zones of the Quai network.
+// Blocks are only mined on request and at the requested order, so tests can
+// build up any chain graph deterministically.
+//
+// Every chain is bound to its own location through its chain config. Calls
+// into the chains are still serialised, so that a test always observes the
+// hierarchy in between two blocks.
+type Hierarchy struct {
+	config  *params.ChainConfig
+	genesis *core.Genesis
+	nodes   map[string]*node
+
+	mu sync.Mutex
+}
+
+// NewHierarchy creates a hierarchy with a fresh genesis block. Each coinbase is
+// assigned to the zone owning its address and receives the block rewards of
+// that zone, which is how accounts get funded without a genesis allocation.
+// Zones without a coinbase reward a fixed address inside of the zone.
+func NewHierarchy(coinbases []common.Address, gasLimit uint64) (*Hierarchy, error) {
+	return NewHierarchyWithTopology(nil, coinbases, gasLimit)
+}
+
+// NewHierarchyWithTopology creates a hierarchy with the given topology, or the
+// default one if nil. The topology is part of the chain config, so hierarchies
+// of different topologies can run side by side.
+func NewHierarchyWithTopology(topology *common.Topology, coinbases []common.Address, gasLimit uint64) (*Hierarchy, error) {
+	return NewHierarchyWithConfig(&params.ChainConfig{Topology: topology}, coinbases, gasLimit)
+}
+
+// NewHierarchyWithConfig creates a hierarchy whose topology, rewards and fork
+// schedule are taken from the given chain config. The chain ID defaults to 1337
+// and every chain is sealed with blake3pow.
+func NewHierarchyWithConfig(config *params.ChainConfig, coinbases []common.Address, gasLimit uint64) (*Hierarchy, error) {
+	if err := config.GetTopology().Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.GetRewards().Validate(); err != nil {
+		return nil, err
+	}
+	if gasLimit == 0 {
+		gasLimit = params.GenesisGasLimit
+	}
+	h := &Hierarchy{
+		config: &params.ChainConfig{
+			ChainID:         config.ChainID,
+			ConsensusEngine: "blake3",
+			Blake3Pow:       new(params.Blake3powConfig),
+			Topology:        config.Topology,
+			Rewards:         config.Rewards,
+			Forks:           config.Forks,
+		},
+		nodes: make(map[string]*node),
+	}
+	if h.config.ChainID == nil {
+		h.config.ChainID = big.NewInt(1337)
+	}
+	h.genesis = &core.Genesis{
+		Config:     h.config,
+		Timestamp:  GenesisTime,
+		GasLimit:   gasLimit,
+		Difficulty: new(big.Int).Set(params.ZoneMinDifficulty),
+	}
+	h.config.GenesisHash = h.genesis.ToBlock(nil).Hash()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, location := range h.Locations() {
+		etherbase, err := h.etherbase(location, coinbases)
+		if err != nil {
+			h.close()
+			return nil, err
+		}
+		if err := h.newNode(location, etherbase, gasLimit); err != nil {
+			h.close()
+			return nil, err
+		}
+	}
+	// Link every chain to its dom and subordinates
+	for _, n := range h.nodes {
+		switch n.location.Context() {
+		case common.PRIME_CTX:
+			for r := 0; r < h.config.GetTopology().Regions; r++ {
+				n.core.SetSubClient(r, newLink(h.nodes[string(common.Location{byte(r)})]))
+			}
+		case common.REGION_CTX:
+			n.core.SetDomClient(newLink(h.nodes[string(n.location.DomLocation())]))
+			for z := 0; z < h.config.GetTopology().Zones; z++ {
+				n.core.SetSubClient(z, newLink(h.nodes[string(common.Location{n.location[0], byte(z)})]))
+			}
+		case common.ZONE_CTX:
+			n.core.SetDomClient(newLink(h.nodes[string(n.location.DomLocation())]))
+		}
+	}
+	// Miners start in the background, wait until all of them are running so
+	// that the very first pending headers carry a coinbase
+	for _, n := range h.nodes {
+		for !n.core.IsMining() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	prime := h.nodes[string(common.Location{})]
+	prime.core.NewGenesisPendigHeader(nil)
+
+	return h, nil
+}
+
+// Locations returns the locations of all chains in the hierarchy, starting
+// with prime and ending with the last zone.
+func (h *Hierarchy) Locations() []common.Location {
+	topology := h.config.GetTopology()
+	locations := []common.Location{{}}
+	for r := 0; r < topology.Regions; r++ {
+		locations = append(locations, common.Location{byte(r)})
+	}
+	for r := 0; r < topology.Regions; r++ {
+		for z := 0; z < topology.Zones; z++ {
+			locations = append(locations, common.Location{byte(r), byte(z)})
+		}
+	}
+	return locations
+}
+
+// etherbase picks the coinbase of a chain. Dom chains do not collect any
+// rewards, but still refuse to create pending headers without an etherbase.
+func (h *Hierarchy) etherbase(location common.Location, coinbases []common.Address) (common.Address, error) {
+	zone := location
+	if location.Context() != common.ZONE_CTX {
+		zone = append(append(common.Location{}, location...), 0, 0)[:common.HierarchyDepth-1]
+	}
+	var assigned *common.Address
+	for i := range coinbases {
+		if h.config.GetTopology().ContainsAddress(zone, coinbases[i]) {
+			if assigned != nil && location.Context() == common.ZONE_CTX {
+				return common.Address{}, fmt.Errorf("multiple coinbases for zone %s", zone.Name())
+			}
+			if assigned == nil {
+				assigned = &coinbases[i]
+			}
+		}
+	}
+	if assigned != nil {
+		return *assigned, nil
+	}
+	return defaultCoinbase(zone, h.config.GetTopology()), nil
+}
+
+// defaultCoinbase returns a fixed address inside of a zone of the topology.
+func defaultCoinbase(zone common.Location, topology *common.Topology) common.Address {
+	var raw common.AddressBytes
+	raw[len(raw)-1] = 0x01
+	for b := 0; b < 256; b++ {
+		raw[0] = byte(b)
+		if topology.ContainsAddress(zone, common.Bytes20ToAddress(raw)) {
+			break
+		}
+	}
+	return common.Bytes20ToAddress(raw)
+}
+
+// newNode creates the chain running at the given location.
+func (h *Hierarchy) newNode(location common.Location, etherbase common.Address, gasLimit uint64) error {
+	db := rawdb.NewMemoryDatabase()
+	if _, err := h.genesis.Commit(db); err != nil {
+		return err
+	}
+	engine := blake3pow.New(blake3pow.Config{
+		PowMode:       blake3pow.ModeFake,
+		DurationLimit: params.DurationLimit,
+		NodeLocation:  location,
+	}, nil, false)
+
+	n := &node{
+		location:  location,
+		db:        db,
+		engine:    engine,
+		etherbase: etherbase,
+		gasLimit:  gasLimit,
+	}
+	if err := h.openCore(n); err != nil {
+		engine.Close()
+		return err
+	}
+	h.nodes[string(location)] = n
+	return nil
+}
+
+// openCore creates the core of a chain on top of its database, which may
+// already hold the chain from a previous core.
+func (h *Hierarchy) openCore(n *node) error {
+	minerConfig := &core.Config{
+		Etherbase:   n.etherbase,
+		ExtraData:   []byte("simulated"), // Keep blocks independent of the build
+		GasCeil:     n.gasLimit,
+		GasPrice:    big.NewInt(1),
+		Recommit:    time.Second,
+		Synchronous: true,
+	}
+	txConfig := core.DefaultTxPoolConfig
+	txConfig.Journal = ""
+	txConfig.AccountSlots = 64
+	txConfig.AccountQueue = 64
+	txConfig.Synchronous = true
+
+	cacheConfig := &core.CacheConfig{
+		TrieCleanLimit:    256,
+		TrieDirtyDisabled: true, // Keep all historical state around for calls
+		TrieTimeLimit:     5 * time.Minute,
+	}
+	isLocalBlock := func(header *types.Header) bool { return false }
+
+	c, err := core.NewCore(n.db, minerConfig, isLocalBlock, &txConfig, nil, h.config.WithLocation(n.location), "", nil, nil, n.engine, cacheConfig, vm.Config{}, h.genesis)
+	if err != nil {
+		return err
+	}
+	n.core = c
+	return nil
+}
+
+// node returns the chain running at a location.
+func (h *Hierarchy) node(location common.Location) (*node, error) {
+	n, ok := h.nodes[string(location)]
+	if !ok {
+		return nil, ErrUnknownLocation
+	}
+	return n, nil
+}
+
+// zone returns the zone chain running at a location.
+func (h *Hierarchy) zone(location common.Location) (*node, error) {
+	n, err := h.node(location)
+	if err != nil {
+		return nil, err
+	}
+	if location.Context() != common.ZONE_CTX {
+		return nil, ErrNotZone
+	}
+	return n, nil
+}
+
+// Config returns the chain configuration shared by all chains.
+func (h *Hierarchy) Config() *params.ChainConfig {
+	return h.config
+}
+
+// Genesis returns the genesis block shared by all chains.
+func (h *Hierarchy) Genesis() *types.Block {
+	return h.genesis.ToBlock(nil)
+}
+
+// Head returns the current head of the chain at the given location.
+func (h *Hierarchy) Head(location common.Location) (*types.Header, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n, err := h.node(location)
+	if err != nil {
+		return nil, err
+	}
+	return types.CopyHeader(n.core.CurrentHeader()), nil
+}
+
+// Commit mines a zone block including the pending transactions of the zone.
+func (h *Hierarchy) Commit(zone common.Location) (*types.Block, error) {
+	return h.Mine(zone, common.ZONE_CTX)
+}
+
+// Mine mines a block in the given zone which is coincident with every chain
+// down from the requested order, i.e. a prime block is appended to prime, its
+// region and the zone. Dom blocks need entropy to be accumulated by the blocks
+// preceding them, so lower order blocks are mined first until the requested
+// order can be reached. The returned block is the zone view of the last block.
+func (h *Hierarchy) Mine(zone common.Location, order int) (*types.Block, error) {
+	if order < common.PRIME_CTX || order > common.ZONE_CTX {
+		return nil, ErrInvalidOrder
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n, err := h.zone(zone)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < maxFillerBlocks; i++ {
+		block, blockOrder, err := h.mineBlock(n, order)
+		if err != nil {
+			return nil, err
+		}
+		if blockOrder == order {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("%s order not reached after %d blocks", common.OrderToString(order), maxFillerBlocks)
+}
+
+// mineBlock seals the pending header of a zone, preferring the requested order
+// and otherwise maximising the entropy towards it, and appends the block.
+func (h *Hierarchy) mineBlock(zone *node, want int) (*types.Block, int, error) {
+	if err := zone.core.FillPendingHeader(); err != nil {
+		return nil, 0, err
+	}
+	pending, err := zone.core.GetPendingHeader()
+	if err != nil {
+		return nil, 0, err
+	}
+	header := types.CopyHeader(pending)
+	parent := zone.core.CurrentHeader()
+
+	if header.ParentHash(common.ZONE_CTX) != parent.Hash() {
+		return nil, 0, fmt.Errorf("pending header not on top of the zone head %x", parent.Hash())
+	}
+	// Blocks are one second apart, unless a dom parent is already further ahead
+	timestamp := parent.Time() + 1
+	for ctx := common.PRIME_CTX; ctx < common.ZONE_CTX; ctx++ {
+		dom := h.nodes[string(zone.location[:ctx])]
+		domParent := dom.core.GetHeaderByHash(header.ParentHash(ctx))
+		if domParent == nil {
+			return nil, 0, fmt.Errorf("unknown %s parent %x", common.OrderToString(ctx), header.ParentHash(ctx))
+		}
+		if domParent.Time() > timestamp {
+			timestamp = domParent.Time()
+		}
+	}
+	header.SetTime(timestamp)
+
+	order, err := h.seal(zone, header, want)
+	if err != nil {
+		return nil, 0, err
+	}
+	block, err := h.submit(zone, header, order)
+	if err != nil {
+		return nil, 0, err
+	}
+	return block, order, nil
+}
+
+// sealCandidate is a nonce and the entropy of the resulting seal.
+type sealCandidate struct {
+	nonce   uint64
+	entropy *big.Int
+}
+
+// seal picks the nonce of a header. The fake engine accepts any seal, but the
+// entropy of a block only advances the chain if its hash meets the difficulty
+// target, so only such nonces are considered. The first one yielding the
+// requested order is used. Otherwise the highest entropy nonce of a lower
+// order is used, so the next block gets closer to the requested order.
+func (h *Hierarchy) seal(zone *node, header *types.Header, want int) (int, error) {
+	target := new(big.Int).Div(new(big.Int).Lsh(common.Big1, 256), header.Difficulty())
+	meetsTarget := func() bool {
+		return new(big.Int).SetBytes(header.Hash().Bytes()).Cmp(target) <= 0
+	}
+	if want < common.ZONE_CTX {
+		var candidates []sealCandidate
+		for nonce := uint64(0); nonce < grindWindow; nonce++ {
+			header.SetNonce(types.EncodeNonce(nonce))
+			if meetsTarget() {
+				candidates = append(candidates, sealCandidate{nonce, zone.engine.IntrinsicLogS(header.Hash())})
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].entropy.Cmp(candidates[j].entropy) > 0
+		})
+		filler, fillerOrder := -1, 0
+		for i := 0; i < grindCandidates && i < len(candidates); i++ {
+			header.SetNonce(types.EncodeNonce(candidates[i].nonce))
+			_, order, err := zone.engine.CalcOrder(header)
+			if err != nil {
+				return 0, err
+			}
+			if order == want {
+				return order, nil
+			}
+			if order > want && filler < 0 {
+				filler, fillerOrder = i, order
+			}
+		}
+		if filler >= 0 {
+			header.SetNonce(types.EncodeNonce(candidates[filler].nonce))
+			return fillerOrder, nil
+		}
+	}
+	// Either a zone block was requested, or all the high entropy seals are of
+	// a higher order than requested. Take the first seal which is not.
+	for nonce := uint64(0); ; nonce++ {
+		header.SetNonce(types.EncodeNonce(nonce))
+		if !meetsTarget() {
+			continue
+		}
+		_, order, err := zone.engine.CalcOrder(header)
+		if err != nil {
+			return 0, err
+		}
+		if order >= want {
+			return order, nil
+		}
+	}
+}
+
+// submit hands a sealed header to every chain it is coincident with, and then
+// appends it starting from its dominant chain, just like a miner would.
+func (h *Hierarchy) submit(zone *node, header *types.Header, order int) (*types.Block, error) {
+	var blocks [common.HierarchyDepth]*types.Block
+	for ctx := common.ZONE_CTX; ctx >= order; ctx-- {
+		n := h.nodes[string(zone.location[:ctx])]
+		block, err := h.constructBlock(n, header)
+		if err == nil {
+			n.core.WriteBlock(block)
+			blocks[ctx] = block
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct %s block: %v", common.OrderToString(ctx), err)
+		}
+	}
+	n := h.nodes[string(zone.location[:order])]
+	_, err := n.core.InsertChain(types.Blocks{blocks[order]})
+	if err != nil {
+		return nil, err
+	}
+	// Failed appends are only logged, so make sure the block made it
+	if head := zone.core.CurrentHeader(); head.Hash() != header.Hash() {
+		return nil, fmt.Errorf("block %x was not appended, zone head is %x", header.Hash(), head.Hash())
+	}
+	return blocks[common.ZONE_CTX], nil
+}
+
+// constructBlock assembles the block of a sealed header on one of the chains
+// it is coincident with. Dom chains may need to reconstruct the manifest of
+// their subordinate, in the same way a mined header is received over RPC.
+func (h *Hierarchy) constructBlock(n *node, header *types.Header) (*types.Block, error) {
+	header, err := recodeHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	block, err := n.core.ConstructLocalMinedBlock(header)
+	if err == nil || !errors.Is(err, core.ErrBadSubManifest) {
+		return block, err
+	}
+	nodeCtx := n.location.Context()
+	subParentHash := block.ParentHash(nodeCtx + 1)
+
+	var subManifest types.BlockManifest
+	if subParent := n.core.GetBlockByHash(subParentHash); subParent != nil {
+		// The subordinate parent was coincident with us, so the manifest
+		// resets to just that block
+		subManifest = types.BlockManifest{subParentHash}
+	} else {
+		subManifest, err = n.core.GetSubManifest(block.Location(), subParentHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(subManifest) == 0 || block.ManifestHash(nodeCtx+1) != types.DeriveSha(subManifest, trie.NewStackTrie(nil)) {
+		return nil, errors.New("reconstructed sub manifest does not match manifest hash")
+	}
+	return types.NewBlockWithHeader(block.Header()).WithBody(block.Transactions(), block.Uncles(), block.ExtTransactions(), subManifest), nil
+}
+
+// Close stops all chains of the hierarchy.
+func (h *Hierarchy) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.close()
+}
+
+func (h *Hierarchy) close() {
+	for _, location := range h.Locations() {
+		n, ok := h.nodes[string(location)]
+		if !ok {
+			continue
+		}
+		n.core.Stop()
+		n.engine.Close()
+		n.db.Close()
+	}
+	h.nodes = make(map[string]*node)
+}
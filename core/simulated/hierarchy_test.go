@@ -0,0 +1,611 @@
+package simulated
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+
+	interfaces "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/core/vm"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+// zoneKey deterministically derives a key whose address belongs to a zone.
+func zoneKey(t *testing.T, zone common.Location) (*ecdsa.PrivateKey, common.Address) {
+	for i := uint64(0); ; i++ {
+		var seed [8]byte
+		binary.BigEndian.PutUint64(seed[:], i)
+		key, err := crypto.ToECDSA(crypto.Keccak256(seed[:]))
+		if err != nil {
+			t.Fatalf("failed to derive key: %v", err)
+		}
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		if common.DefaultTopology.ContainsAddress(zone, addr) {
+			return key, addr
+		}
+	}
+}
+
+// crossZoneTx signs a transaction of a key with the given nonce, sending value
+// to another zone with enough fees for the ETX to be executed there.
+func crossZoneTx(t *testing.T, h *Hierarchy, client *Client, key *ecdsa.PrivateKey, nonce uint64, to common.Address, value *big.Int) *types.Transaction {
+	gasPrice, err := client.SuggestGasPrice(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get gas price: %v", err)
+	}
+	gasPrice.Mul(gasPrice, big.NewInt(2))
+
+	// External transactions pay a multiple of the fees, one per zone of the
+	// region confirming them
+	etxGasPrice := new(big.Int).Mul(gasPrice, big.NewInt(int64(h.Config().GetTopology().Zones)))
+	etxGasTip := big.NewInt(int64(h.Config().GetTopology().Zones))
+
+	return types.MustSignNewTx(key, types.LatestSigner(h.Config()), &types.InternalToExternalTx{
+		ChainID:     h.Config().ChainID,
+		Nonce:       nonce,
+		GasTipCap:   big.NewInt(1),
+		GasFeeCap:   gasPrice,
+		Gas:         2 * params.TxGas,
+		To:          &to,
+		Value:       value,
+		ETXGasLimit: params.TxGas,
+		ETXGasPrice: etxGasPrice,
+		ETXGasTip:   etxGasTip,
+	})
+}
+
+// Tests that blocks are mined at the requested order and become the head of
+// every chain they are coincident with.
+func TestMineOrders(t *testing.T) {
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	orders := []int{common.ZONE_CTX, common.REGION_CTX}
+	if !testing.Short() {
+		orders = append(orders, common.PRIME_CTX)
+	}
+	zone := common.Location{1, 2}
+	for _, order := range orders {
+		block, err := h.Mine(zone, order)
+		if err != nil {
+			t.Fatalf("failed to mine %s block: %v", common.OrderToString(order), err)
+		}
+		for ctx := common.ZONE_CTX; ctx >= common.PRIME_CTX; ctx-- {
+			head, err := h.Head(zone[:ctx])
+			if err != nil {
+				t.Fatalf("failed to get %s head: %v", common.OrderToString(ctx), err)
+			}
+			if coincident := ctx >= order; coincident != (head.Hash() == block.Hash()) {
+				t.Errorf("%s block: %s head %x, coincident %v", common.OrderToString(order), common.OrderToString(ctx), head.Hash(), coincident)
+			}
+		}
+	}
+	if _, err := h.Mine(common.Location{1}, common.ZONE_CTX); err != ErrNotZone {
+		t.Errorf("mining in a region: have %v, want %v", err, ErrNotZone)
+	}
+	if _, err := h.Mine(zone, common.HierarchyDepth); err != ErrInvalidOrder {
+		t.Errorf("mining at invalid order: have %v, want %v", err, ErrInvalidOrder)
+	}
+}
+
+// Tests that an external transaction is routed through the region to another
+// zone and credited there.
+func TestCrossZoneTransfer(t *testing.T) {
+	var (
+		from, to        = common.Location{0, 0}, common.Location{0, 1}
+		key, sender     = zoneKey(t, from)
+		_, recipient    = zoneKey(t, to)
+		value           = big.NewInt(params.Ether)
+		ctx             = context.Background()
+		mined, received *big.Int
+	)
+	h, err := NewHierarchy([]common.Address{sender}, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	// Fund the sender with the rewards of a zone block
+	if _, err := h.Commit(from); err != nil {
+		t.Fatalf("failed to mine funding block: %v", err)
+	}
+	source, err := h.Client(from)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if mined, err = source.BalanceAt(ctx, sender, nil); err != nil || mined.Sign() == 0 {
+		t.Fatalf("sender not funded: balance %v, err %v", mined, err)
+	}
+	gas, err := source.EstimateGas(ctx, interfaces.CallMsg{From: sender, To: &sender, Value: value})
+	if err != nil || gas != params.TxGas {
+		t.Fatalf("transfer gas estimate mismatch: have %d, want %d, err %v", gas, params.TxGas, err)
+	}
+	tx := crossZoneTx(t, h, source, key, 0, recipient, value)
+	if err := source.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	block, err := h.Commit(from)
+	if err != nil {
+		t.Fatalf("failed to mine transaction: %v", err)
+	}
+	if len(block.Transactions()) != 1 || len(block.ExtTransactions()) != 1 {
+		t.Fatalf("transaction not included: %d txs, %d etxs", len(block.Transactions()), len(block.ExtTransactions()))
+	}
+	// Confirm the external transaction in the region, then reference that
+	// region block from the destination zone
+	if _, err := h.Mine(from, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine source region block: %v", err)
+	}
+	if _, err := h.Mine(to, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine destination region block: %v", err)
+	}
+	if _, err := h.Commit(to); err != nil {
+		t.Fatalf("failed to mine destination zone block: %v", err)
+	}
+	destination, err := h.Client(to)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if received, err = destination.BalanceAt(ctx, recipient, nil); err != nil {
+		t.Fatalf("failed to get recipient balance: %v", err)
+	}
+	if received.Cmp(value) != 0 {
+		t.Errorf("recipient balance mismatch: have %v, want %v", received, value)
+	}
+}
+
+// Tests that the gas an ETX needs is estimated by its destination zone, reached
+// through the region, and through prime when leaving the region.
+func TestEstimateExternalGas(t *testing.T) {
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	from := common.Location{0, 0}
+	_, sender := zoneKey(t, from)
+	client, err := h.Client(from)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	for _, to := range []common.Location{{0, 1}, {2, 2}} {
+		_, recipient := zoneKey(t, to)
+		gas, err := client.EstimateExternalGas(context.Background(), interfaces.CallMsg{From: sender, To: &recipient, Value: big.NewInt(params.Ether)})
+		if err != nil {
+			t.Fatalf("zone %v: failed to estimate etx gas: %v", to, err)
+		}
+		if gas != params.TxGas {
+			t.Errorf("zone %v: etx gas mismatch: have %d, want %d", to, gas, params.TxGas)
+		}
+	}
+}
+
+// Tests that bundles are simulated against the pending state and included all
+// or nothing, ahead of the transactions of the pool.
+func TestBundle(t *testing.T) {
+	var (
+		zone        = common.Location{0, 0}
+		key, sender = zoneKey(t, zone)
+		_, receiver = zoneKey(t, zone)
+		ctx         = context.Background()
+	)
+	h, err := NewHierarchy([]common.Address{sender}, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	if _, err := h.Commit(zone); err != nil {
+		t.Fatalf("failed to mine funding block: %v", err)
+	}
+	client, err := h.Client(zone)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatalf("failed to get gas price: %v", err)
+	}
+	gasPrice.Mul(gasPrice, big.NewInt(2))
+	transfer := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(key, types.LatestSigner(h.Config()), &types.InternalTx{
+			ChainID:   h.Config().ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: gasPrice,
+			Gas:       params.TxGas,
+			To:        &receiver,
+			Value:     big.NewInt(1),
+		})
+	}
+	good := types.Transactions{transfer(0), transfer(1)}
+	bad := types.Transactions{transfer(2), transfer(4)} // Nonce gap in the second transaction
+
+	result, err := client.CallBundle(ctx, good)
+	if err != nil {
+		t.Fatalf("failed to simulate bundle: %v", err)
+	}
+	if result.Err != nil || len(result.Receipts) != 2 || result.Receipts[1].Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("bundle simulation mismatch: err %v, receipts %d", result.Err, len(result.Receipts))
+	}
+	if result, err = client.CallBundle(ctx, types.Transactions{good[0], bad[1]}); err != nil {
+		t.Fatalf("failed to simulate bundle: %v", err)
+	}
+	if !errors.Is(result.Err, core.ErrNonceTooHigh) || len(result.Receipts) != 1 {
+		t.Fatalf("failing bundle simulation mismatch: err %v, receipts %d", result.Err, len(result.Receipts))
+	}
+	head, err := h.Head(zone)
+	if err != nil {
+		t.Fatalf("failed to get head: %v", err)
+	}
+	if _, err := client.SendBundle(ctx, good, head.Number(common.ZONE_CTX)); !errors.Is(err, core.ErrBundleStale) {
+		t.Fatalf("stale bundle error mismatch: have %v, want %v", err, core.ErrBundleStale)
+	}
+	next := new(big.Int).Add(head.Number(common.ZONE_CTX), common.Big1)
+	if _, err := client.SendBundle(ctx, good, next); err != nil {
+		t.Fatalf("failed to send bundle: %v", err)
+	}
+	if _, err := client.SendBundle(ctx, bad, next); err != nil {
+		t.Fatalf("failed to send bundle: %v", err)
+	}
+	block, err := h.Commit(zone)
+	if err != nil {
+		t.Fatalf("failed to mine bundles: %v", err)
+	}
+	if txs := block.Transactions(); len(txs) != 2 || txs[0].Hash() != good[0].Hash() || txs[1].Hash() != good[1].Hash() {
+		t.Fatalf("included transactions mismatch: have %d", len(txs))
+	}
+	if nonce, err := client.NonceAt(ctx, sender, nil); err != nil || nonce != 2 {
+		t.Errorf("sender nonce mismatch: have %d, want 2, err %v", nonce, err)
+	}
+	// Bundles may only target the next few blocks, and every sender may only
+	// have a limited number of them pending
+	next = new(big.Int).Add(block.Number(common.ZONE_CTX), common.Big1)
+	far := new(big.Int).Add(next, big.NewInt(1000))
+	if _, err := client.SendBundle(ctx, types.Transactions{transfer(2)}, far); !errors.Is(err, core.ErrBundleTooFar) {
+		t.Fatalf("far bundle error mismatch: have %v, want %v", err, core.ErrBundleTooFar)
+	}
+	var limited error
+	for nonce := uint64(2); limited == nil; nonce++ {
+		if nonce > maxFillerBlocks {
+			t.Fatalf("no sender limit after %d bundles", nonce)
+		}
+		_, limited = client.SendBundle(ctx, types.Transactions{transfer(nonce)}, next)
+	}
+	if !errors.Is(limited, core.ErrBundleSenderLimit) {
+		t.Fatalf("sender limit error mismatch: have %v, want %v", limited, core.ErrBundleSenderLimit)
+	}
+	var other *ecdsa.PrivateKey
+	for other == nil {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		if common.DefaultTopology.ContainsAddress(zone, crypto.PubkeyToAddress(key.PublicKey)) {
+			other = key
+		}
+	}
+	tx := types.MustSignNewTx(other, types.LatestSigner(h.Config()), &types.InternalTx{
+		ChainID:   h.Config().ChainID,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: gasPrice,
+		Gas:       params.TxGas,
+		To:        &receiver,
+		Value:     big.NewInt(1),
+	})
+	if _, err := client.SendBundle(ctx, types.Transactions{tx}, next); err != nil {
+		t.Fatalf("bundle of another sender rejected: %v", err)
+	}
+}
+
+// Tests that zone blocks are reported safe once a region block covers them and
+// finalized once a prime block does.
+func TestConfirmationStatus(t *testing.T) {
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	zone := common.Location{1, 1}
+	n, err := h.zone(zone)
+	if err != nil {
+		t.Fatalf("failed to get zone: %v", err)
+	}
+	status := func(block *types.Block) *core.ConfirmationStatus {
+		status, err := n.core.ConfirmationStatus(block.Header())
+		if err != nil {
+			t.Fatalf("failed to get confirmation status: %v", err)
+		}
+		return status
+	}
+	block, err := h.Commit(zone)
+	if err != nil {
+		t.Fatalf("failed to mine zone block: %v", err)
+	}
+	if s := status(block); !s.Canonical || s.Depth != 0 || s.Region || s.Prime || s.Entropy.Sign() != 0 {
+		t.Fatalf("zone block status mismatch: %+v", s)
+	}
+	region, err := h.Mine(zone, common.REGION_CTX)
+	if err != nil {
+		t.Fatalf("failed to mine region block: %v", err)
+	}
+	if s := status(block); !s.Region || s.Prime || s.Depth != region.NumberU64(common.ZONE_CTX)-block.NumberU64(common.ZONE_CTX) || s.Entropy.Sign() <= 0 {
+		t.Fatalf("region covered block status mismatch: %+v", s)
+	}
+	if s := status(region); s.Order != common.REGION_CTX || !s.Region || s.Prime || !s.Safe || s.Finalized {
+		t.Fatalf("region block status mismatch: %+v", s)
+	}
+	// A region node only considers blocks safe once prime covers them, as the
+	// safe tag does there
+	r, err := h.node(common.Location{1})
+	if err != nil {
+		t.Fatalf("failed to get region: %v", err)
+	}
+	if s, err := r.core.ConfirmationStatus(region.Header()); err != nil || !s.Canonical || !s.Region || s.Safe || s.Finalized {
+		t.Fatalf("region node status mismatch: %+v (err %v)", s, err)
+	}
+	safe, finalized := n.core.CurrentSafeHeader(), n.core.CurrentFinalizedHeader()
+	if safe.Hash() != region.Hash() {
+		t.Errorf("safe header mismatch: have %x, want %x", safe.Hash(), region.Hash())
+	}
+	if finalized.Hash() != h.Genesis().Hash() {
+		t.Errorf("finalized header mismatch: have %x, want genesis", finalized.Hash())
+	}
+	if testing.Short() {
+		return
+	}
+	prime, err := h.Mine(zone, common.PRIME_CTX)
+	if err != nil {
+		t.Fatalf("failed to mine prime block: %v", err)
+	}
+	if s := status(block); !s.Region || !s.Prime || !s.Safe || !s.Finalized {
+		t.Fatalf("prime covered block status mismatch: %+v", s)
+	}
+	if s, err := r.core.ConfirmationStatus(region.Header()); err != nil || !s.Safe || !s.Finalized {
+		t.Fatalf("prime covered region node status mismatch: %+v (err %v)", s, err)
+	}
+	safe, finalized = n.core.CurrentSafeHeader(), n.core.CurrentFinalizedHeader()
+	if safe.Hash() != prime.Hash() || finalized.Hash() != prime.Hash() {
+		t.Errorf("safe and finalized headers mismatch: have %x and %x, want %x", safe.Hash(), finalized.Hash(), prime.Hash())
+	}
+}
+
+// Tests that a hierarchy with a custom topology and rewards splits the address
+// space and the block reward between its zones, and mines blocks in all of
+// them, without affecting hierarchies of another topology.
+func TestTopology(t *testing.T) {
+	var (
+		coinbase = common.HexToAddress("0x0100000000000000000000000000000000000001")
+		config   = &params.ChainConfig{
+			Topology: &common.Topology{Regions: 1, Zones: 2},
+			Rewards: &params.RewardsConfig{
+				Reward:     big.NewInt(6000),
+				Shares:     [common.HierarchyDepth]uint64{1, 1, 4},
+				TimeFactor: 2,
+			},
+			Forks: map[params.Fork]*params.ForkActivation{
+				params.Push0Fork:   {PrimeBlock: common.Big0},
+				params.ScopeFork:   {PrimeBlock: common.Big0},
+				params.RewardsFork: {PrimeBlock: common.Big0},
+				params.EtxSetFork:  {PrimeBlock: common.Big0},
+			},
+		}
+	)
+	h, err := NewHierarchyWithConfig(config, []common.Address{coinbase}, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	if locations := h.Locations(); len(locations) != 4 {
+		t.Fatalf("location count mismatch: have %d, want 4", len(locations))
+	}
+	topology := h.Config().GetTopology()
+	spaces := []common.AddressSpace{{Lo: 0x00, Hi: 0x7f, Precompile: 0x00}, {Lo: 0x80, Hi: 0xff, Precompile: 0x80}}
+	for z, want := range spaces {
+		zone := common.Location{0, byte(z)}
+		if have, ok := topology.ZoneAddressSpace(zone); !ok || have != want {
+			t.Errorf("zone %d address space mismatch: have %+v, want %+v", z, have, want)
+		}
+		precompiles := vm.PrecompiledAddresses(zone, topology)
+		if len(precompiles) == 0 || precompiles[0].Bytes()[0] != want.Precompile {
+			t.Errorf("zone %d precompiles mismatch: %v", z, precompiles)
+		}
+	}
+	if _, ok := topology.ZoneAddressSpace(common.Location{1, 0}); ok {
+		t.Errorf("address space reported for a zone outside of the topology")
+	}
+	// The default topology is left untouched
+	want := common.AddressSpace{Lo: 0, Hi: 29, Precompile: 0x14}
+	if have, ok := common.DefaultTopology.ZoneAddressSpace(common.Location{0, 0}); !ok || have != want {
+		t.Errorf("default address space changed: have %+v, want %+v", have, want)
+	}
+	if precompiles := vm.PrecompiledAddresses(common.Location{0, 0}, common.DefaultTopology); len(precompiles) == 0 || precompiles[0].Bytes()[0] != want.Precompile {
+		t.Errorf("default precompiles changed: %v", precompiles)
+	}
+	// A zone block pays 4 of the 6 shares of the reward, split between the 2
+	// zones and the 2x2 zone blocks of every prime block
+	zone := common.Location{0, 0}
+	if _, err := h.Commit(zone); err != nil {
+		t.Fatalf("failed to mine zone block: %v", err)
+	}
+	client, err := h.Client(zone)
+	if err != nil {
+		t.Fatalf("failed to get zone client: %v", err)
+	}
+	if balance, err := client.BalanceAt(context.Background(), coinbase, nil); err != nil || balance.Cmp(big.NewInt(500)) != 0 {
+		t.Errorf("coinbase reward mismatch: have %v, want 500 (err %v)", balance, err)
+	}
+	order := common.REGION_CTX
+	if !testing.Short() {
+		order = common.PRIME_CTX
+	}
+	for z := range spaces {
+		zone := common.Location{0, byte(z)}
+		block, err := h.Mine(zone, order)
+		if err != nil {
+			t.Fatalf("failed to mine in zone %d: %v", z, err)
+		}
+		head, err := h.Head(zone[:order])
+		if err != nil {
+			t.Fatalf("failed to get %s head: %v", common.OrderToString(order), err)
+		}
+		if head.Hash() != block.Hash() {
+			t.Errorf("zone %d block is not the %s head", z, common.OrderToString(order))
+		}
+	}
+}
+
+// Tests that a sub answers the replayed append of a block it already appended
+// with the result of the original append, so a dom can recover an append it
+// did not commit.
+func TestReplayAppend(t *testing.T) {
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	zone := common.Location{1, 1}
+	block, err := h.Mine(zone, common.REGION_CTX)
+	if err != nil {
+		t.Fatalf("failed to mine region block: %v", err)
+	}
+	for ctx := common.ZONE_CTX; ctx >= common.REGION_CTX; ctx-- {
+		n, err := h.node(zone[:ctx])
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", common.OrderToString(ctx), err)
+		}
+		if entry := rawdb.ReadAppendJournal(n.db); entry != nil {
+			t.Errorf("%s: append journal left behind: %x", common.OrderToString(ctx), entry.Hash)
+		}
+		want := block.ExtTransactions()
+		if ctx == common.REGION_CTX {
+			want = n.core.GetPendingEtxs(block.Hash()).Etxs
+		}
+		header := n.core.GetHeaderByHash(block.Hash())
+		etxs, reorg, err := n.core.Append(header, types.EmptyHeader(), common.Hash{}, true, nil)
+		if err != nil || !reorg || len(etxs) != len(want) {
+			t.Errorf("%s: replayed append mismatch: have %d etxs, reorg %v, err %v, want %d etxs", common.OrderToString(ctx), len(etxs), reorg, err, len(want))
+		}
+		if _, _, err := n.core.Append(header, types.EmptyHeader(), common.Hash{}, false, nil); err != core.ErrKnownBlock {
+			t.Errorf("%s: known block append error mismatch: have %v, want %v", common.OrderToString(ctx), err, core.ErrKnownBlock)
+		}
+	}
+}
+
+// Tests that bad hashes and checkpoints sent to prime reach the zone they
+// target, which rewinds below the blocks they reject.
+func TestUpdateBlockLists(t *testing.T) {
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	zone := common.Location{0, 1}
+	var blocks []*types.Block
+	for i := 0; i < 3; i++ {
+		block, err := h.Commit(zone)
+		if err != nil {
+			t.Fatalf("failed to mine zone block: %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+	prime, err := h.node(common.Location{})
+	if err != nil {
+		t.Fatalf("failed to get prime: %v", err)
+	}
+	n, err := h.zone(zone)
+	if err != nil {
+		t.Fatalf("failed to get zone: %v", err)
+	}
+	update := func(update types.BlockListUpdate, propagate bool) error {
+		return prime.core.UpdateBlockLists(update, propagate)
+	}
+	check := func(head *types.Block, bad common.Hash) {
+		t.Helper()
+		if have := n.core.CurrentHeader().Hash(); have != head.Hash() {
+			t.Errorf("head mismatch: have %x, want %x", have, head.Hash())
+		}
+		if bad != (common.Hash{}) && !n.core.IsBlockHashABadHash(bad) {
+			t.Errorf("block %x not listed as bad", bad)
+		}
+	}
+	badHash := types.BlockListUpdate{AddBadHashes: []types.BadHash{{Location: zone, Hash: blocks[2].Hash()}}}
+	if err := update(badHash, false); err == nil {
+		t.Fatal("update of a zone applied without propagation")
+	}
+	if err := update(badHash, true); err != nil {
+		t.Fatalf("failed to add bad hash: %v", err)
+	}
+	check(blocks[1], blocks[2].Hash())
+	if bad := rawdb.ReadBadHashesList(n.db); len(bad) == 0 {
+		t.Error("bad hashes not stored")
+	}
+	// Trusting a block conflicting with the head rewinds below it
+	checkpoint := types.BlockListUpdate{AddCheckpoints: []types.Checkpoint{{Location: zone, Number: hexutil.Uint64(blocks[1].NumberU64(common.ZONE_CTX)), Hash: common.Hash{1}}}}
+	if err := update(checkpoint, true); err != nil {
+		t.Fatalf("failed to add checkpoint: %v", err)
+	}
+	check(blocks[0], blocks[1].Hash())
+	if checkpoints := rawdb.ReadCheckpoints(n.db); len(checkpoints) != 1 || checkpoints[0].Hash != (common.Hash{1}) {
+		t.Errorf("checkpoints mismatch: have %v", checkpoints)
+	}
+	unban := types.BlockListUpdate{RemoveBadHashes: badHash.AddBadHashes}
+	if err := update(unban, true); err != nil {
+		t.Fatalf("failed to remove bad hash: %v", err)
+	}
+	// Removing the checkpoint accepts the block it rejected again
+	uncheckpoint := types.BlockListUpdate{RemoveCheckpoints: checkpoint.AddCheckpoints}
+	if err := update(uncheckpoint, true); err != nil {
+		t.Fatalf("failed to remove checkpoint: %v", err)
+	}
+	if n.core.IsBlockHashABadHash(blocks[2].Hash()) {
+		t.Error("removed bad hash still listed")
+	}
+	if n.core.IsBlockHashABadHash(blocks[1].Hash()) {
+		t.Error("block rejected by removed checkpoint still listed")
+	}
+	if conflicts := rawdb.ReadCheckpointConflicts(n.db); len(conflicts) != 0 {
+		t.Errorf("checkpoint conflicts left: %v", conflicts)
+	}
+	// A block unlisted explicitly is no longer tied to the checkpoint, so
+	// listing it again outlives the removal of the checkpoint
+	checkpoint = types.BlockListUpdate{AddCheckpoints: []types.Checkpoint{{Location: zone, Number: hexutil.Uint64(blocks[0].NumberU64(common.ZONE_CTX)), Hash: common.Hash{2}}}}
+	if err := update(checkpoint, true); err != nil {
+		t.Fatalf("failed to add checkpoint on the head: %v", err)
+	}
+	check(h.Genesis(), blocks[0].Hash())
+	conflict := types.BadHash{Location: zone, Hash: blocks[0].Hash()}
+	if err := update(types.BlockListUpdate{RemoveBadHashes: []types.BadHash{conflict}}, true); err != nil {
+		t.Fatalf("failed to remove conflicting hash: %v", err)
+	}
+	if conflicts := rawdb.ReadCheckpointConflicts(n.db); len(conflicts) != 0 {
+		t.Errorf("unlisted block still stored as conflict: %v", conflicts)
+	}
+	if err := update(types.BlockListUpdate{AddBadHashes: []types.BadHash{conflict}}, true); err != nil {
+		t.Fatalf("failed to add conflicting hash: %v", err)
+	}
+	uncheckpoint = types.BlockListUpdate{RemoveCheckpoints: checkpoint.AddCheckpoints}
+	if err := update(uncheckpoint, true); err != nil {
+		t.Fatalf("failed to remove checkpoint on the head: %v", err)
+	}
+	if !n.core.IsBlockHashABadHash(blocks[0].Hash()) {
+		t.Error("explicitly listed block unlisted with the checkpoint")
+	}
+}
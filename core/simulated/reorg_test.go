@@ -0,0 +1,149 @@
+package simulated
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+// sibling stores a copy of a zone block sealed with another nonce, i.e. a
+// competing block on the same parent carrying the same transactions.
+func sibling(h *Hierarchy, zone common.Location, block *types.Block) *types.Block {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := h.nodes[string(zone)]
+
+	header := types.CopyHeader(block.Header())
+	header.SetNonce(types.EncodeNonce(block.NonceU64() + 1))
+	sibling := types.NewBlockWithHeader(header).WithBody(block.Transactions(), block.Uncles(), block.ExtTransactions(), block.SubManifest())
+
+	rawdb.WriteTermini(n.db, sibling.Hash(), rawdb.ReadTermini(n.db, block.Hash()))
+	n.core.WriteBlock(sibling)
+	return sibling
+}
+
+// headerByHash returns a header of a zone.
+func headerByHash(h *Hierarchy, zone common.Location, hash common.Hash) *types.Header {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.nodes[string(zone)].core.GetHeaderByHash(hash)
+}
+
+// setHead switches the canonical chain of a zone to the given head and returns
+// the reorg announced for it.
+func setHead(t *testing.T, h *Hierarchy, zone common.Location, head *types.Header) core.ReorgEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := h.nodes[string(zone)]
+
+	reorgs := make(chan core.ReorgEvent, 1)
+	sub := n.core.SubscribeReorgEvent(reorgs)
+	defer sub.Unsubscribe()
+
+	if err := n.core.Slice().HeaderChain().SetCurrentHeader(head); err != nil {
+		t.Fatalf("failed to set head: %v", err)
+	}
+	select {
+	case ev := <-reorgs:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatalf("no reorg announced switching to %x", head.Hash())
+	}
+	return core.ReorgEvent{}
+}
+
+// Tests that the ETXs of the reverted blocks are told apart by whether the new
+// branch includes them again.
+func TestReorgEtxDiff(t *testing.T) {
+	var (
+		from, to    = common.Location{0, 0}, common.Location{0, 1}
+		key, sender = zoneKey(t, from)
+		_, receiver = zoneKey(t, to)
+	)
+	h, err := NewHierarchy([]common.Address{sender}, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	if _, err := h.Commit(from); err != nil {
+		t.Fatalf("failed to mine funding block: %v", err)
+	}
+	source, err := h.Client(from)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if err := source.SendTransaction(context.Background(), crossZoneTx(t, h, source, key, 0, receiver, big.NewInt(params.Ether))); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	emitting, err := h.Commit(from)
+	if err != nil {
+		t.Fatalf("failed to mine transaction: %v", err)
+	}
+	if len(emitting.ExtTransactions()) != 1 {
+		t.Fatalf("etx not emitted: have %d etxs", len(emitting.ExtTransactions()))
+	}
+	etx := emitting.ExtTransactions()[0].Hash()
+
+	if _, err := h.Mine(from, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine source region block: %v", err)
+	}
+	if _, err := h.Mine(to, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine destination region block: %v", err)
+	}
+	executing, err := h.Commit(to)
+	if err != nil {
+		t.Fatalf("failed to mine destination zone block: %v", err)
+	}
+	if txs := executing.Transactions(); len(txs) != 1 || txs[0].Hash() != etx {
+		t.Fatalf("etx not executed: have %d txs", len(txs))
+	}
+	// Switching to a sibling of a block includes its ETXs again, reverting
+	// to the parent of the sibling drops them
+	tests := []struct {
+		zone           common.Location
+		block          *types.Block
+		kept, reverted func(ev core.ReorgEvent) []common.Hash
+	}{
+		{
+			zone:     to,
+			block:    executing,
+			kept:     func(ev core.ReorgEvent) []common.Hash { return ev.ReincludedInboundEtxs },
+			reverted: func(ev core.ReorgEvent) []common.Hash { return ev.RevertedInboundEtxs },
+		},
+		{
+			zone:     from,
+			block:    emitting,
+			kept:     func(ev core.ReorgEvent) []common.Hash { return ev.ReemittedEtxs },
+			reverted: func(ev core.ReorgEvent) []common.Hash { return ev.RevertedEtxs },
+		},
+	}
+	for _, tt := range tests {
+		block := sibling(h, tt.zone, tt.block)
+		ev := setHead(t, h, tt.zone, block.Header())
+		if added := ev.Added[common.ZONE_CTX]; !reflect.DeepEqual(added, []common.Hash{block.Hash()}) {
+			t.Errorf("zone %v: added blocks mismatch: have %x, want %x", tt.zone, added, block.Hash())
+		}
+		if have := tt.kept(ev); !reflect.DeepEqual(have, []common.Hash{etx}) {
+			t.Errorf("zone %v: kept ETXs mismatch: have %x, want %x", tt.zone, have, etx)
+		}
+		ev = setHead(t, h, tt.zone, headerByHash(h, tt.zone, block.ParentHash(common.ZONE_CTX)))
+		if removed := ev.Removed[common.ZONE_CTX]; !reflect.DeepEqual(removed, []common.Hash{block.Hash()}) {
+			t.Errorf("zone %v: removed blocks mismatch: have %x, want %x", tt.zone, removed, block.Hash())
+		}
+		if have := tt.reverted(ev); !reflect.DeepEqual(have, []common.Hash{etx}) {
+			t.Errorf("zone %v: reverted ETXs mismatch: have %x, want %x", tt.zone, have, etx)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package simulated
+
+import (
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/ethdb"
+)
+
+// restart stops the core of a chain, lets crash alter what it left in its
+// database, and creates the core again on top of it, linked to the same dom
+// and subs, just like a node restarting after a crash.
+func restart(t *testing.T, h *Hierarchy, location common.Location, crash func(db ethdb.Database)) {
+	n, err := h.node(location)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", location.Name(), err)
+	}
+	n.core.Stop()
+	if crash != nil {
+		crash(n.db)
+	}
+	if err := h.openCore(n); err != nil {
+		t.Fatalf("failed to reopen %s: %v", location.Name(), err)
+	}
+	nodeCtx := location.Context()
+	for _, other := range h.nodes {
+		switch {
+		case nodeCtx != common.PRIME_CTX && other.location.Equal(location.DomLocation()):
+			n.core.SetDomClient(newLink(other))
+			other.core.SetSubClient(location.SubIndex(nodeCtx-1), newLink(n))
+		case other.location.Context() == nodeCtx+1 && other.location.DomLocation().Equal(location):
+			n.core.SetSubClient(other.location.SubIndex(nodeCtx), newLink(other))
+			other.core.SetDomClient(newLink(n))
+		}
+	}
+	n.core.RepairHead()
+}
+
+// uncommit removes a block from the head of a chain, as if the chain crashed
+// before committing it, but after storing the block itself. The pending headers
+// only stored on a clean shutdown are lost as well.
+func uncommit(db ethdb.Database, block *types.Block, nodeCtx int) {
+	rawdb.DeleteTermini(db, block.Hash())
+	rawdb.DeleteCanonicalHash(db, block.NumberU64(nodeCtx))
+	rawdb.WriteHeadBlockHash(db, block.ParentHash(nodeCtx))
+	rawdb.DeletePhCache(db)
+	rawdb.DeleteBestPhKey(db)
+}
+
+// Tests that a dom which crashed in the middle of an append, after its sub
+// committed the block, appends the block again when it restarts.
+func TestRepairAppendJournal(t *testing.T) {
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	zone := common.Location{0, 0}
+	block, err := h.Mine(zone, common.REGION_CTX)
+	if err != nil {
+		t.Fatalf("failed to mine region block: %v", err)
+	}
+	restart(t, h, zone[:common.REGION_CTX], func(db ethdb.Database) {
+		uncommit(db, block, common.REGION_CTX)
+		rawdb.WriteAppendJournal(db, types.HashAndNumber{Hash: block.Hash(), Number: block.NumberU64(common.REGION_CTX)})
+	})
+	for ctx := common.REGION_CTX; ctx <= common.ZONE_CTX; ctx++ {
+		head, err := h.Head(zone[:ctx])
+		if err != nil {
+			t.Fatalf("failed to get %s head: %v", common.OrderToString(ctx), err)
+		}
+		if head.Hash() != block.Hash() {
+			t.Errorf("%s head mismatch after restart: have %x, want %x", common.OrderToString(ctx), head.Hash(), block.Hash())
+		}
+	}
+	n, _ := h.node(zone[:common.REGION_CTX])
+	if entry := rawdb.ReadAppendJournal(n.db); entry != nil {
+		t.Errorf("append journal left behind: %x", entry.Hash)
+	}
+	// The repaired chain keeps on growing
+	if _, err := h.Mine(zone, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine region block after restart: %v", err)
+	}
+}
+
+// Tests that a dom whose head is unknown to its sub, because the sub lost the
+// last block it appended, rewinds to where they agree and appends the lost
+// block again when it restarts.
+func TestRepairHeadAgainstSub(t *testing.T) {
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	zone := common.Location{2, 1}
+	var block *types.Block
+	for i := 0; i < 2; i++ {
+		if block, err = h.Mine(zone, common.REGION_CTX); err != nil {
+			t.Fatalf("failed to mine region block: %v", err)
+		}
+	}
+	restart(t, h, zone, func(db ethdb.Database) {
+		uncommit(db, block, common.ZONE_CTX)
+	})
+	head, err := h.Head(zone)
+	if err != nil {
+		t.Fatalf("failed to get zone head: %v", err)
+	}
+	if head.Hash() != block.ParentHash(common.ZONE_CTX) {
+		t.Fatalf("zone head mismatch after losing the block: have %x, want %x", head.Hash(), block.ParentHash(common.ZONE_CTX))
+	}
+	restart(t, h, zone[:common.REGION_CTX], nil)
+	for ctx := common.REGION_CTX; ctx <= common.ZONE_CTX; ctx++ {
+		head, err := h.Head(zone[:ctx])
+		if err != nil {
+			t.Fatalf("failed to get %s head: %v", common.OrderToString(ctx), err)
+		}
+		if head.Hash() != block.Hash() {
+			t.Errorf("%s head mismatch after restart: have %x, want %x", common.OrderToString(ctx), head.Hash(), block.Hash())
+		}
+	}
+	if _, err := h.Mine(zone, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine region block after restart: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package simulated
+
+import (
+	"context"
+
+	interfaces "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/rlp"
+)
+
+var (
+	_ core.DomClient = (*link)(nil)
+	_ core.SubClient = (*link)(nil)
+)
+
+// link connects a chain of the hierarchy to one of its dom or sub chains. The
+// headers and transactions crossing the link are re-encoded, so that the chains
+// never share any memory, just like they would over the wire.
+type link struct {
+	target *node
+	local  *core.LocalClient
+}
+
+func newLink(target *node) *link {
+	return &link{target: target, local: core.NewLocalClient(target.core)}
+}
+
+// recodeHeader copies a header by encoding and decoding it.
+func recodeHeader(header *types.Header) (*types.Header, error) {
+	if header == nil {
+		return nil, nil
+	}
+	blob, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	recoded := new(types.Header)
+	if err := rlp.DecodeBytes(blob, recoded); err != nil {
+		return nil, err
+	}
+	return recoded, nil
+}
+
+// recodeTransactions copies a list of transactions by encoding and decoding
+// it.
+func recodeTransactions(txs types.Transactions) (types.Transactions, error) {
+	if txs == nil {
+		return nil, nil
+	}
+	blob, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		return nil, err
+	}
+	var recoded types.Transactions
+	if err := rlp.DecodeBytes(blob, &recoded); err != nil {
+		return nil, err
+	}
+	return recoded, nil
+}
+
+func (l *link) Append(ctx context.Context, header *types.Header, domPendingHeader *types.Header, domTerminus common.Hash, domOrigin bool, newInboundEtxs types.Transactions) (types.Transactions, bool, error) {
+	header, err := recodeHeader(header)
+	if err != nil {
+		return nil, false, err
+	}
+	domPendingHeader, err = recodeHeader(domPendingHeader)
+	if err != nil {
+		return nil, false, err
+	}
+	newInboundEtxs, err = recodeTransactions(newInboundEtxs)
+	if err != nil {
+		return nil, false, err
+	}
+	pendingEtxs, subReorg, err := l.local.Append(ctx, header, domPendingHeader, domTerminus, domOrigin, newInboundEtxs)
+	if err != nil {
+		return nil, false, err
+	}
+	pendingEtxs, err = recodeTransactions(pendingEtxs)
+	return pendingEtxs, subReorg, err
+}
+
+func (l *link) SubRelayPendingHeader(ctx context.Context, pendingHeader types.PendingHeader, location common.Location) error {
+	header, err := recodeHeader(pendingHeader.Header)
+	if err != nil {
+		return err
+	}
+	return l.local.SubRelayPendingHeader(ctx, types.PendingHeader{Header: header, Termini: pendingHeader.Termini}, location)
+}
+
+func (l *link) NewGenesisPendingHeader(ctx context.Context, header *types.Header) error {
+	header, err := recodeHeader(header)
+	if err != nil {
+		return err
+	}
+	return l.local.NewGenesisPendingHeader(ctx, header)
+}
+
+func (l *link) GetManifest(ctx context.Context, blockHash common.Hash) (types.BlockManifest, error) {
+	return l.local.GetManifest(ctx, blockHash)
+}
+
+func (l *link) GetTerminiByHash(ctx context.Context, hash common.Hash) ([]common.Hash, error) {
+	return l.local.GetTerminiByHash(ctx, hash)
+}
+
+func (l *link) GenerateRecoveryPendingHeader(ctx context.Context, pendingHeader *types.Header, checkpointHashes []common.Hash) error {
+	header, err := recodeHeader(pendingHeader)
+	if err != nil {
+		return err
+	}
+	return l.local.GenerateRecoveryPendingHeader(ctx, header, checkpointHashes)
+}
+
+func (l *link) UpdateBlockLists(ctx context.Context, update types.BlockListUpdate) error {
+	return l.local.UpdateBlockLists(ctx, update)
+}
+
+func (l *link) SendPendingEtxsToDom(ctx context.Context, pEtxs types.PendingEtxs) error {
+	header, err := recodeHeader(pEtxs.Header)
+	if err != nil {
+		return err
+	}
+	etxs, err := recodeTransactions(pEtxs.Etxs)
+	if err != nil {
+		return err
+	}
+	return l.local.SendPendingEtxsToDom(ctx, types.PendingEtxs{Header: header, Etxs: etxs})
+}
+
+func (l *link) SendPendingEtxsRollupToDom(ctx context.Context, pEtxsRollup types.PendingEtxsRollup) error {
+	header, err := recodeHeader(pEtxsRollup.Header)
+	if err != nil {
+		return err
+	}
+	return l.local.SendPendingEtxsRollupToDom(ctx, types.PendingEtxsRollup{Header: header, Manifest: pEtxsRollup.Manifest})
+}
+
+func (l *link) EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error) {
+	return l.local.EstimateExternalGas(ctx, msg)
+}
@@ -0,0 +1,76 @@
+package simulated
+
+import (
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core"
+)
+
+// relinkLocal links every chain of a hierarchy to its dom and subordinates
+// with plain in-memory transports, instead of the re-encoding links.
+func relinkLocal(h *Hierarchy) {
+	for _, n := range h.nodes {
+		if n.location.Context() == common.PRIME_CTX {
+			continue
+		}
+		dom := h.nodes[string(n.location.DomLocation())]
+		n.core.SetDomClient(core.NewLocalClient(dom.core))
+		dom.core.SetSubClient(n.location.SubIndex(dom.location.Context()), core.NewLocalClient(n.core))
+	}
+}
+
+// Tests that a dom block is appended down the hierarchy over the in-memory
+// transport, and that the new pending header is relayed to every subordinate,
+// including the ones the block is not coincident with.
+func TestLocalClientAppend(t *testing.T) {
+	h, err := NewHierarchy(nil, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+	relinkLocal(h)
+
+	orders := []int{common.REGION_CTX}
+	if !testing.Short() {
+		orders = append(orders, common.PRIME_CTX)
+	}
+	zone := common.Location{0, 1}
+	for _, order := range orders {
+		block, err := h.Mine(zone, order)
+		if err != nil {
+			t.Fatalf("failed to mine %s block: %v", common.OrderToString(order), err)
+		}
+		// The block was only inserted into its dom chain, the subordinates
+		// must have received it over the transport
+		for ctx := order; ctx <= common.ZONE_CTX; ctx++ {
+			head, err := h.Head(zone[:ctx])
+			if err != nil {
+				t.Fatalf("failed to get %s head: %v", common.OrderToString(ctx), err)
+			}
+			if head.Hash() != block.Hash() {
+				t.Errorf("%s block not appended to %s: head %x, want %x", common.OrderToString(order), zone[:ctx].Name(), head.Hash(), block.Hash())
+			}
+		}
+		// The zones of the dom chain build on top of the block
+		for _, sibling := range h.Locations() {
+			if sibling.Context() != common.ZONE_CTX || !sibling[:order].Equal(zone[:order]) {
+				continue
+			}
+			pending, err := h.nodes[string(sibling)].core.GetPendingHeader()
+			if err != nil {
+				t.Fatalf("failed to get pending header of %s: %v", sibling.Name(), err)
+			}
+			if parent := pending.ParentHash(order); parent != block.Hash() {
+				t.Errorf("%s pending header of %s not relayed: parent %x, want %x", common.OrderToString(order), sibling.Name(), parent, block.Hash())
+			}
+		}
+		// The calls made it through the links of the dom chain
+		dom := h.nodes[string(zone[:order])]
+		health := dom.core.TransportHealth()
+		link := health.Subs[zone.SubIndex(order)]
+		if link == nil || !link.Healthy || link.LastSuccess.IsZero() {
+			t.Errorf("%s link to the subordinate not used: %+v", zone[:order].Name(), link)
+		}
+	}
+}
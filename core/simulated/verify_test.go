@@ -0,0 +1,97 @@
+package simulated
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/params"
+	"github.com/dominant-strategies/go-quai/rlp"
+)
+
+// Tests that the verification of the hierarchy data reports the blocks whose
+// records do not match their header, and only those.
+func TestVerifyDatabase(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+
+	genesis := types.EmptyHeader()
+	rawdb.WriteHeader(db, genesis, common.PRIME_CTX)
+	rawdb.WriteCanonicalHash(db, genesis.Hash(), 0)
+	config := &params.ChainConfig{GenesisHash: genesis.Hash()}
+
+	// The genesis block is the terminus of every chain
+	terminus := config.GetTopology().Width()
+	genesisTermini := make([]common.Hash, terminus+1)
+	for i := range genesisTermini {
+		genesisTermini[i] = genesis.Hash()
+	}
+	rawdb.WriteTermini(db, genesis.Hash(), genesisTermini)
+
+	var (
+		parent  = genesis
+		termini = genesisTermini
+		subs    []*types.Header
+	)
+	for i := 1; i <= 3; i++ {
+		header := types.EmptyHeader()
+		header.SetNumber(big.NewInt(int64(i)), common.PRIME_CTX)
+		header.SetParentHash(parent.Hash(), common.PRIME_CTX)
+		header.SetLocation(common.Location{byte(i % 3), 0})
+		hash := header.Hash()
+
+		termini = append([]common.Hash{}, termini...)
+		termini[i%3], termini[terminus] = hash, hash
+
+		sub := types.EmptyHeader()
+		sub.SetNumber(big.NewInt(int64(i)), common.REGION_CTX)
+
+		rawdb.WriteHeader(db, header, common.PRIME_CTX)
+		rawdb.WriteCanonicalHash(db, hash, uint64(i))
+		rawdb.WriteTermini(db, hash, termini)
+		rawdb.WriteManifest(db, hash, types.BlockManifest{sub.Hash()})
+		rawdb.WritePendingEtxs(db, types.PendingEtxs{Header: sub, Etxs: types.Transactions{}})
+
+		parent = header
+		subs = append(subs, sub)
+	}
+	rawdb.WriteHeadBlockHash(db, parent.Hash())
+
+	verify := func() []core.DatabaseFault {
+		var faults []core.DatabaseFault
+		checked, err := core.VerifyDatabase(db, config, func(fault core.DatabaseFault) error {
+			faults = append(faults, fault)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to verify: %v", err)
+		}
+		if checked != 3 {
+			t.Errorf("checked blocks mismatch: have %d, want %d", checked, 3)
+		}
+		return faults
+	}
+	if faults := verify(); len(faults) != 0 {
+		t.Fatalf("consistent database reported faults: %v", faults)
+	}
+	// Store the pending etxs of a sub block under the hash of another, and
+	// break the termini of the head
+	data, err := rlp.EncodeToBytes(types.PendingEtxs{Header: subs[0], Etxs: types.Transactions{}})
+	if err != nil {
+		t.Fatalf("failed to encode pending etxs: %v", err)
+	}
+	rawdb.WritePendingEtxsRLP(db, subs[1].Hash(), data)
+	rawdb.WriteTermini(db, parent.Hash(), genesisTermini)
+
+	faults := verify()
+	if len(faults) != 2 {
+		t.Fatalf("faults mismatch: have %v, want 2", faults)
+	}
+	for i, want := range []uint64{2, 3} {
+		if faults[i].Number != want {
+			t.Errorf("fault %d: block mismatch: have %d, want %d", i, faults[i].Number, want)
+		}
+	}
+}
@@ -31,7 +31,6 @@ const (
 	c_pendingHeaderCacheLimit         = 100
 	c_pendingHeaderChacheBufferFactor = 2
 	pendingHeaderGCTime               = 5
-	c_terminusIndex                   = 3
 	c_startingPrintLimit              = 10
 	c_regionRelayProc                 = 3
 	c_primeRelayProc                  = 10
@@ -51,9 +50,13 @@ type Slice struct {
 
 	quit chan struct{} // slice quit channel
 
-	domClient  *quaiclient.Client
+	domClient  DomClient
 	domUrl     string
-	subClients []*quaiclient.Client
+	subClients []SubClient
+
+	domHealth  *linkMonitor
+	subHealths []*linkMonitor
+	clientsMu  sync.RWMutex // Transports are attached while the slice is running
 
 	wg                    sync.WaitGroup
 	scope                 event.SubscriptionScope
@@ -72,10 +75,17 @@ type Slice struct {
 	phCacheMu sync.RWMutex
 
 	badHashesCache map[common.Hash]bool
+	checkpoints    map[uint64]common.Hash   // Trusted block of the chain at each number, see UpdateBlockLists
+	conflicts      map[uint64][]common.Hash // Blocks listed as bad by the checkpoint at each number
+	badHashesMu    sync.RWMutex
+
+	synchronous bool // Pending headers are only filled on request, see FillPendingHeader
+
+	replay []types.HashAndNumber // Blocks to append again after a startup repair, see repairHead
 }
 
-func NewSlice(db ethdb.Database, config *Config, txConfig *TxPoolConfig, txLookupLimit *uint64, isLocalBlock func(block *types.Header) bool, chainConfig *params.ChainConfig, domClientUrl string, subClientUrls []string, engine consensus.Engine, cacheConfig *CacheConfig, vmConfig vm.Config, genesis *Genesis) (*Slice, error) {
-	nodeCtx := common.NodeLocation.Context()
+func NewSlice(db ethdb.Database, config *Config, txConfig *TxPoolConfig, txLookupLimit *uint64, isLocalBlock func(block *types.Header) bool, chainConfig *params.ChainConfig, domClientUrl string, subClientUrls []string, jwtSecret []byte, engine consensus.Engine, cacheConfig *CacheConfig, vmConfig vm.Config, genesis *Genesis) (*Slice, error) {
+	nodeCtx := chainConfig.Location.Context()
 	sl := &Slice{
 		config:         chainConfig,
 		engine:         engine,
@@ -83,6 +93,9 @@ func NewSlice(db ethdb.Database, config *Config, txConfig *TxPoolConfig, txLooku
 		domUrl:         domClientUrl,
 		quit:           make(chan struct{}),
 		badHashesCache: make(map[common.Hash]bool),
+		checkpoints:    make(map[uint64]common.Hash),
+		conflicts:      make(map[uint64][]common.Hash),
+		synchronous:    config != nil && config.Synchronous,
 	}
 
 	var err error
@@ -103,15 +116,21 @@ func NewSlice(db ethdb.Database, config *Config, txConfig *TxPoolConfig, txLooku
 	sl.phCache, _ = lru.New(c_phCacheSize)
 
 	// only set the subClients if the chain is not Zone
-	sl.subClients = make([]*quaiclient.Client, 3)
+	sl.subClients = make([]SubClient, chainConfig.GetTopology().Width())
+	sl.subHealths = make([]*linkMonitor, chainConfig.GetTopology().Width())
 	if nodeCtx != common.ZONE_CTX {
-		sl.subClients = makeSubClients(subClientUrls)
+		for i, subClient := range makeSubClients(subClientUrls, jwtSecret, chainConfig.GetTopology()) {
+			if subClient != nil {
+				sl.SetSubClient(i, subClient)
+			}
+		}
 	}
 
-	// only set domClient if the chain is not Prime.
-	if nodeCtx != common.PRIME_CTX {
+	// only set domClient if the chain is not Prime. An empty url leaves the
+	// dom link unset, so an in-process transport can be attached instead.
+	if nodeCtx != common.PRIME_CTX && domClientUrl != "" {
 		go func() {
-			sl.domClient = makeDomClient(domClientUrl)
+			sl.SetDomClient(makeDomClient(domClientUrl, jwtSecret))
 		}()
 	}
 
@@ -119,9 +138,11 @@ func NewSlice(db ethdb.Database, config *Config, txConfig *TxPoolConfig, txLooku
 		return nil, err
 	}
 
+	sl.repairAppendJournal()
+	sl.repairHead()
 	sl.CheckForBadHashAndRecover()
 
-	if nodeCtx == common.ZONE_CTX {
+	if nodeCtx == common.ZONE_CTX && !sl.synchronous {
 		go sl.asyncPendingHeaderLoop()
 	}
 
@@ -131,13 +152,14 @@ func NewSlice(db ethdb.Database, config *Config, txConfig *TxPoolConfig, txLooku
 // Append takes a proposed header and constructs a local block and attempts to hierarchically append it to the block graph.
 // If this is called from a dominant context a domTerminus must be provided else a common.Hash{} should be used and domOrigin should be set to true.
 func (sl *Slice) Append(header *types.Header, domPendingHeader *types.Header, domTerminus common.Hash, domOrigin bool, newInboundEtxs types.Transactions) (types.Transactions, bool, error) {
+	nodeCtx := sl.NodeCtx()
 	start := time.Now()
 
 	// Only print in Info level if block is c_startingPrintLimit behind or less
 	if sl.CurrentInfo(header) {
-		log.Info("Starting slice append", "hash", header.Hash(), "number", header.NumberArray(), "location", header.Location(), "parent hash", header.ParentHash())
+		log.Info("Starting slice append", "hash", header.Hash(), "number", header.NumberArray(), "location", header.Location(), "parent hash", header.ParentHash(nodeCtx))
 	} else {
-		log.Debug("Starting slice append", "hash", header.Hash(), "number", header.NumberArray(), "location", header.Location(), "parent hash", header.ParentHash())
+		log.Debug("Starting slice append", "hash", header.Hash(), "number", header.NumberArray(), "location", header.Location(), "parent hash", header.ParentHash(nodeCtx))
 	}
 
 	time0_1 := common.PrettyDuration(time.Since(start))
@@ -145,23 +167,33 @@ func (sl *Slice) Append(header *types.Header, domPendingHeader *types.Header, do
 	if sl.IsBlockHashABadHash(header.Hash()) {
 		return nil, false, ErrBadBlockHash
 	}
+	if sl.conflictsWithCheckpoint(header) {
+		return nil, false, ErrCheckpointMismatch
+	}
 	time0_2 := common.PrettyDuration(time.Since(start))
 
-	nodeCtx := common.NodeLocation.Context()
 	location := header.Location()
 	_, order, err := sl.engine.CalcOrder(header)
 	if err != nil {
 		return nil, false, err
 	}
 	// Don't append the block which already exists in the database.
-	if sl.hc.HasHeader(header.Hash(), header.NumberU64()) && (sl.hc.GetTerminiByHash(header.Hash()) != nil) {
+	if sl.hc.HasHeader(header.Hash(), header.NumberU64(nodeCtx)) && (sl.hc.GetTerminiByHash(header.Hash()) != nil) {
+		// A dom replaying an append which it did not commit needs the result
+		// of the append again
+		if domOrigin {
+			if etxs, ok := sl.knownAppendResult(header); ok {
+				log.Info("Replayed append of known block", "hash", header.Hash(), "number", header.NumberArray())
+				return etxs, sl.hc.CurrentHeader().Hash() == header.Hash(), nil
+			}
+		}
 		log.Warn("Block has already been appended: ", "Hash: ", header.Hash())
 		return nil, false, ErrKnownBlock
 	}
 	time1 := common.PrettyDuration(time.Since(start))
 	// This is to prevent a crash when we try to insert blocks before domClient is on.
 	// Ideally this check should not exist here and should be fixed before we start the slice.
-	if sl.domClient == nil && nodeCtx != common.PRIME_CTX {
+	if sl.domLink() == nil && nodeCtx != common.PRIME_CTX {
 		return nil, false, ErrDomClientNotUp
 	}
 	time2 := common.PrettyDuration(time.Since(start))
@@ -193,8 +225,10 @@ func (sl *Slice) Append(header *types.Header, domPendingHeader *types.Header, do
 	}
 	time5 := common.PrettyDuration(time.Since(start))
 
-	// Append the new block
-	err = sl.hc.Append(batch, block, newInboundEtxs.FilterToLocation(common.NodeLocation))
+	// Append the new block, indexing the lifecycle of the ETXs it emits and
+	// receives, and of the ETXs confirmed by it or rolled up from the sub
+	etxStatuses := sl.hc.newEtxStatusBatch()
+	err = sl.hc.Append(batch, block, newInboundEtxs.FilterToLocation(sl.NodeLocation(), sl.config.GetTopology()), etxStatuses)
 	if err != nil {
 		return nil, false, err
 	}
@@ -211,12 +245,20 @@ func (sl *Slice) Append(header *types.Header, domPendingHeader *types.Header, do
 	var time8_1 common.PrettyDuration
 	var time8_2 common.PrettyDuration
 	var time8_3 common.PrettyDuration
+	if nodeCtx != common.ZONE_CTX {
+		etxStatuses.referenced(block, newInboundEtxs)
+	}
 	// Call my sub to append the block, and collect the rolled up ETXs from that sub
 	if nodeCtx != common.ZONE_CTX {
 		// How to get the sub pending etxs if not running the full node?.
-		if sl.subClients[location.SubIndex()] != nil {
-			subPendingEtxs, subReorg, err = sl.subClients[location.SubIndex()].Append(context.Background(), block.Header(), pendingHeaderWithTermini.Header, domTerminus, true, newInboundEtxs)
+		if subClient := sl.subLink(location.SubIndex(nodeCtx)); subClient != nil {
+			// Journal the append until the batch is written, so that a crash
+			// in between can be repaired on startup, see repairAppendJournal
+			rawdb.WriteAppendJournal(sl.sliceDb, types.HashAndNumber{Hash: block.Hash(), Number: block.NumberU64(nodeCtx)})
+			subPendingEtxs, subReorg, err = subClient.Append(context.Background(), block.Header(), pendingHeaderWithTermini.Header, domTerminus, true, newInboundEtxs)
 			if err != nil {
+				// The sub rejected the block, so there is nothing to repair
+				rawdb.DeleteAppendJournal(sl.sliceDb)
 				return nil, false, err
 			}
 			time8_1 = common.PrettyDuration(time.Since(start))
@@ -225,15 +267,18 @@ func (sl *Slice) Append(header *types.Header, domPendingHeader *types.Header, do
 			time8_2 = common.PrettyDuration(time.Since(start))
 			// Add the pending etx given by the sub in the rollup
 			sl.AddPendingEtxs(pEtxs)
+			etxStatuses.rolledUp(block, subPendingEtxs)
 			// Only region has the rollup hashes for pendingEtxs
 			if nodeCtx == common.REGION_CTX {
 				// We also need to store the pendingEtxRollup to the dom
 				pEtxRollup := types.PendingEtxsRollup{block.Header(), block.SubManifest()}
 				sl.AddPendingEtxsRollup(pEtxRollup)
 			}
+			rawdb.DeleteAppendJournal(batch)
 			time8_3 = common.PrettyDuration(time.Since(start))
 		}
 	}
+	etxStatuses.write(batch)
 	time9 := common.PrettyDuration(time.Since(start))
 
 	time10 := common.PrettyDuration(time.Since(start))
@@ -242,18 +287,19 @@ func (sl *Slice) Append(header *types.Header, domPendingHeader *types.Header, do
 	if err := batch.Write(); err != nil {
 		return nil, false, err
 	}
+	etxStatuses.notify()
 	appendFinished := time.Since(start)
 	time11 := common.PrettyDuration(appendFinished)
-	bestPh, exist := sl.readPhCache(sl.bestPhKey)
-	if !exist {
-		sl.bestPhKey = pendingHeaderWithTermini.Termini[c_terminusIndex]
+	// Without a best pending header, e.g. after a crash, any new one is better
+	oldBestPhEntropy := new(big.Int)
+	if bestPh, exist := sl.readPhCache(sl.bestPhKey); exist {
+		oldBestPhEntropy = sl.engine.TotalLogPhS(bestPh.Header)
+	} else {
+		sl.bestPhKey = pendingHeaderWithTermini.Termini[terminusIndex(sl.config)]
 		sl.writePhCache(block.Hash(), pendingHeaderWithTermini)
-		bestPh = pendingHeaderWithTermini
 		log.Error("BestPh Key does not exist for", "key", sl.bestPhKey)
 	}
 
-	oldBestPhEntropy := sl.engine.TotalLogPhS(bestPh.Header)
-
 	sl.updatePhCache(pendingHeaderWithTermini, true, nil)
 
 	if nodeCtx == common.ZONE_CTX {
@@ -270,7 +316,7 @@ func (sl *Slice) Append(header *types.Header, domPendingHeader *types.Header, do
 	time12 := common.PrettyDuration(time.Since(start))
 	log.Info("times during append:", "t0_1", time0_1, "t0_2", time0_2, "t1:", time1, "t2:", time2, "t3:", time3, "t4:", time4, "t5:", time5, "t6:", time6, "t7:", time7, "t8:", time8, "t9:", time9, "t10:", time10, "t11:", time11, "t12:", time12)
 	log.Info("times during sub append:", "t9_1:", time8_1, "t9_2:", time8_2, "t9_3:", time8_3)
-	log.Info("Appended new block", "number", block.Header().Number(), "hash", block.Hash(),
+	log.Info("Appended new block", "number", block.Header().Number(nodeCtx), "hash", block.Hash(),
 		"uncles", len(block.Uncles()), "txs", len(block.Transactions()), "etxs", len(block.ExtTransactions()), "gas", block.GasUsed(),
 		"root", block.Root(),
 		"order", order,
@@ -285,20 +331,20 @@ func (sl *Slice) Append(header *types.Header, domPendingHeader *types.Header, do
 
 // relayPh sends pendingHeaderWithTermini to subordinates
 func (sl *Slice) relayPh(block *types.Block, appendTime *time.Duration, reorg bool, pendingHeaderWithTermini types.PendingHeader, domOrigin bool, location common.Location) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 
 	if nodeCtx == common.ZONE_CTX {
 		// Send an empty header to miner
 		bestPh, exists := sl.readPhCache(sl.bestPhKey)
 		if exists {
-			bestPh.Header.SetLocation(common.NodeLocation)
+			bestPh.Header.SetLocation(sl.NodeLocation())
 			sl.miner.worker.pendingHeaderFeed.Send(bestPh.Header)
 			return
 		}
 	} else if !domOrigin {
-		for i := range sl.subClients {
-			if sl.subClients[i] != nil {
-				sl.subClients[i].SubRelayPendingHeader(context.Background(), pendingHeaderWithTermini, location)
+		for _, subClient := range sl.subLinks() {
+			if subClient != nil {
+				subClient.SubRelayPendingHeader(context.Background(), pendingHeaderWithTermini, location)
 			}
 		}
 	}
@@ -318,7 +364,7 @@ func (sl *Slice) asyncPendingHeaderLoop() {
 
 			bestPh, exists := sl.readPhCache(sl.bestPhKey)
 			if exists {
-				bestPh.Header.SetLocation(common.NodeLocation)
+				bestPh.Header.SetLocation(sl.NodeLocation())
 				sl.miner.worker.pendingHeaderFeed.Send(bestPh.Header)
 			}
 		case <-sl.asyncPhSub.Err():
@@ -362,7 +408,7 @@ func (sl *Slice) generateSlicePendingHeader(block *types.Block, newTermini []com
 
 // CollectNewlyConfirmedEtxs collects all newly confirmed ETXs since the last coincident with the given location
 func (sl *Slice) CollectNewlyConfirmedEtxs(block *types.Block, location common.Location) (types.Transactions, types.Transactions, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	// Collect rollup of ETXs from the subordinate node's manifest
 	subRollup := types.Transactions{}
 	var err error
@@ -374,7 +420,7 @@ func (sl *Slice) CollectNewlyConfirmedEtxs(block *types.Block, location common.L
 	}
 
 	// Filter for ETXs destined to this slice
-	newInboundEtxs := subRollup.FilterToSlice(location, nodeCtx)
+	newInboundEtxs := subRollup.FilterToSlice(location, nodeCtx, sl.config.GetTopology())
 
 	// Filter this list to exclude any ETX for which we are not the crossing
 	// context node. Such ETXs cannot be used by our subordinate for one of the
@@ -387,25 +433,25 @@ func (sl *Slice) CollectNewlyConfirmedEtxs(block *types.Block, location common.L
 	// Note: here "common dom" refers to the highes context chain which exists in
 	// both the origin & destination. See the definition of the `CommonDom()`
 	// method for more explanation.
-	newlyConfirmedEtxs := newInboundEtxs.FilterConfirmationCtx(nodeCtx)
+	newlyConfirmedEtxs := newInboundEtxs.FilterConfirmationCtx(nodeCtx, sl.config.GetTopology())
 
 	// Terminate the search if we reached genesis
-	if block.NumberU64() == 0 {
+	if block.NumberU64(nodeCtx) == 0 {
 		if block.Hash() != sl.config.GenesisHash {
 			return nil, nil, fmt.Errorf("terminated search on bad genesis, block0 hash: %s", block.Hash().String())
 		} else {
 			return newlyConfirmedEtxs, subRollup, nil
 		}
 	}
-	ancHash := block.ParentHash()
-	ancNum := block.NumberU64() - 1
+	ancHash := block.ParentHash(nodeCtx)
+	ancNum := block.NumberU64(nodeCtx) - 1
 	ancestor := sl.hc.GetBlock(ancHash, ancNum)
 	if ancestor == nil {
 		return nil, nil, fmt.Errorf("unable to find ancestor, hash: %s", ancHash.String())
 	}
 
 	// Terminate the search when we find a block produced by the same sub
-	if ancestor.Location().SubIndex() == location.SubIndex() {
+	if ancestor.Location().SubIndex(nodeCtx) == location.SubIndex(nodeCtx) {
 		return newlyConfirmedEtxs, subRollup, nil
 	}
 
@@ -420,13 +466,13 @@ func (sl *Slice) CollectNewlyConfirmedEtxs(block *types.Block, location common.L
 
 // PCRC previous coincidence reference check makes sure there are not any cyclic references in the graph and calculates new termini and the block terminus
 func (sl *Slice) pcrc(batch ethdb.Batch, header *types.Header, domTerminus common.Hash, domOrigin bool) (common.Hash, []common.Hash, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	location := header.Location()
 
-	log.Debug("PCRC:", "Parent Hash:", header.ParentHash(), "Number", header.Number, "Location:", header.Location())
-	termini := sl.hc.GetTerminiByHash(header.ParentHash())
+	log.Debug("PCRC:", "Parent Hash:", header.ParentHash(nodeCtx), "Number", header.Number, "Location:", header.Location())
+	termini := sl.hc.GetTerminiByHash(header.ParentHash(nodeCtx))
 
-	if len(termini) != 4 {
+	if len(termini) != terminusIndex(sl.config)+1 {
 		return common.Hash{}, []common.Hash{}, ErrSubNotSyncedToDom
 	}
 
@@ -437,19 +483,19 @@ func (sl *Slice) pcrc(batch ethdb.Batch, header *types.Header, domTerminus commo
 
 	// Set the subtermini
 	if nodeCtx != common.ZONE_CTX {
-		newTermini[location.SubIndex()] = header.Hash()
+		newTermini[location.SubIndex(nodeCtx)] = header.Hash()
 	}
 
 	// Set the terminus
 	if nodeCtx == common.PRIME_CTX || domOrigin {
-		newTermini[c_terminusIndex] = header.Hash()
+		newTermini[terminusIndex(sl.config)] = header.Hash()
 	} else {
-		newTermini[c_terminusIndex] = termini[c_terminusIndex]
+		newTermini[terminusIndex(sl.config)] = termini[terminusIndex(sl.config)]
 	}
 
 	// Check for a graph cyclic reference
 	if domOrigin {
-		if termini[c_terminusIndex] != domTerminus {
+		if termini[terminusIndex(sl.config)] != domTerminus {
 			log.Warn("Cyclic Block:", "block number", header.NumberArray(), "hash", header.Hash(), "terminus", domTerminus, "termini", termini)
 			return common.Hash{}, []common.Hash{}, errors.New("termini do not match, block rejected due to cyclic reference")
 		}
@@ -462,7 +508,7 @@ func (sl *Slice) pcrc(batch ethdb.Batch, header *types.Header, domTerminus commo
 		return common.Hash{}, newTermini, nil
 	}
 
-	return termini[location.SubIndex()], newTermini, nil
+	return termini[location.SubIndex(nodeCtx)], newTermini, nil
 }
 
 // POEM compares externS to the currentHead S and returns true if externS is greater
@@ -494,34 +540,38 @@ func (sl *Slice) GetManifest(blockHash common.Hash) (types.BlockManifest, error)
 // GetSubManifest gets the block manifest from the subordinate node which
 // produced this block
 func (sl *Slice) GetSubManifest(slice common.Location, blockHash common.Hash) (types.BlockManifest, error) {
-	subIdx := slice.SubIndex()
-	if sl.subClients[subIdx] == nil {
+	subClient := sl.subLink(slice.SubIndex(sl.NodeCtx()))
+	if subClient == nil {
 		return nil, errors.New("missing requested subordinate node")
 	}
-	return sl.subClients[subIdx].GetManifest(context.Background(), blockHash)
+	return subClient.GetManifest(context.Background(), blockHash)
 }
 
 // SendPendingEtxsToDom shares a set of pending ETXs with your dom, so he can reference them when a coincident block is found
 func (sl *Slice) SendPendingEtxsToDom(pEtxs types.PendingEtxs) error {
-	return sl.domClient.SendPendingEtxsToDom(context.Background(), pEtxs)
+	domClient := sl.domLink()
+	if domClient == nil {
+		return ErrDomClientNotUp
+	}
+	return domClient.SendPendingEtxsToDom(context.Background(), pEtxs)
 }
 
 // SubRelayPendingHeader takes a pending header from the sender (ie dominant), updates the phCache with a composited header and relays result to subordinates
 func (sl *Slice) SubRelayPendingHeader(pendingHeader types.PendingHeader, location common.Location) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 
 	if nodeCtx == common.REGION_CTX {
 		// Adding a guard on the region that was already updated in the synchronous path.
-		if location.Region() != common.NodeLocation.Region() {
-			err := sl.updatePhCacheFromDom(pendingHeader, common.NodeLocation.Region(), []int{common.PRIME_CTX})
+		if location.Region() != sl.NodeLocation().Region() {
+			err := sl.updatePhCacheFromDom(pendingHeader, sl.NodeLocation().Region(), []int{common.PRIME_CTX})
 			if err != nil {
 				return
 			}
 		}
-		for i := range sl.subClients {
-			if sl.subClients[i] != nil {
-				if ph, exists := sl.readPhCache(pendingHeader.Termini[common.NodeLocation.Region()]); exists {
-					sl.subClients[i].SubRelayPendingHeader(context.Background(), ph, location)
+		for _, subClient := range sl.subLinks() {
+			if subClient != nil {
+				if ph, exists := sl.readPhCache(pendingHeader.Termini[sl.NodeLocation().Region()]); exists {
+					subClient.SubRelayPendingHeader(context.Background(), ph, location)
 				}
 			}
 		}
@@ -529,14 +579,14 @@ func (sl *Slice) SubRelayPendingHeader(pendingHeader types.PendingHeader, locati
 		// This check prevents a double send to the miner.
 		// If the previous block on which the given pendingHeader was built is the same as the NodeLocation
 		// the pendingHeader update has already been sent to the miner for the given location in relayPh.
-		if !bytes.Equal(location, common.NodeLocation) {
-			err := sl.updatePhCacheFromDom(pendingHeader, common.NodeLocation.Zone(), []int{common.PRIME_CTX, common.REGION_CTX})
+		if !bytes.Equal(location, sl.NodeLocation()) {
+			err := sl.updatePhCacheFromDom(pendingHeader, sl.NodeLocation().Zone(), []int{common.PRIME_CTX, common.REGION_CTX})
 			if err != nil {
 				return
 			}
 			bestPh, exists := sl.readPhCache(sl.bestPhKey)
 			if exists {
-				bestPh.Header.SetLocation(common.NodeLocation)
+				bestPh.Header.SetLocation(sl.NodeLocation())
 				sl.miner.worker.pendingHeaderFeed.Send(bestPh.Header)
 			}
 		}
@@ -545,10 +595,10 @@ func (sl *Slice) SubRelayPendingHeader(pendingHeader types.PendingHeader, locati
 
 // computePendingHeader takes in an localPendingHeaderWithTermini and updates the pending header on the same terminus if the number is greater
 func (sl *Slice) computePendingHeader(localPendingHeaderWithTermini types.PendingHeader, domPendingHeader *types.Header, domOrigin bool) types.PendingHeader {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 
 	var cachedPendingHeaderWithTermini types.PendingHeader
-	hash := localPendingHeaderWithTermini.Termini[c_terminusIndex]
+	hash := localPendingHeaderWithTermini.Termini[terminusIndex(sl.config)]
 	cachedPendingHeaderWithTermini, exists := sl.readPhCache(hash)
 	log.Debug("computePendingHeader:", "hash:", hash, "pendingHeader:", cachedPendingHeaderWithTermini, "termini:", cachedPendingHeaderWithTermini.Termini)
 	var newPh *types.Header
@@ -567,6 +617,7 @@ func (sl *Slice) computePendingHeader(localPendingHeaderWithTermini types.Pendin
 
 // updatePhCacheFromDom combines the recieved pending header with the pending header stored locally at a given terminus for specified context
 func (sl *Slice) updatePhCacheFromDom(pendingHeader types.PendingHeader, terminiIndex int, indices []int) error {
+	nodeCtx := sl.NodeCtx()
 	hash := pendingHeader.Termini[terminiIndex]
 	localPendingHeader, exists := sl.readPhCache(hash)
 
@@ -577,8 +628,8 @@ func (sl *Slice) updatePhCacheFromDom(pendingHeader types.PendingHeader, termini
 		}
 		bestPh, exist := sl.readPhCache(sl.bestPhKey)
 		if !exist {
-			sl.bestPhKey = localPendingHeader.Termini[c_terminusIndex]
-			sl.writePhCache(localPendingHeader.Termini[c_terminusIndex], types.PendingHeader{Header: combinedPendingHeader, Termini: localPendingHeader.Termini})
+			sl.bestPhKey = localPendingHeader.Termini[terminusIndex(sl.config)]
+			sl.writePhCache(localPendingHeader.Termini[terminusIndex(sl.config)], types.PendingHeader{Header: combinedPendingHeader, Termini: localPendingHeader.Termini})
 			bestPh = types.PendingHeader{Header: combinedPendingHeader, Termini: localPendingHeader.Termini}
 			log.Error("BestPh Key does not exist for", "key", sl.bestPhKey)
 		}
@@ -588,30 +639,59 @@ func (sl *Slice) updatePhCacheFromDom(pendingHeader types.PendingHeader, termini
 		sl.pickPhHead(types.PendingHeader{Header: combinedPendingHeader, Termini: localPendingHeader.Termini}, oldBestPhEntropy)
 		return nil
 	}
-	log.Warn("no pending header found for", "terminus", hash, "pendingHeaderNumber", pendingHeader.Header.NumberArray(), "Hash", pendingHeader.Header.ParentHash(), "Termini index", terminiIndex, "indices", indices)
+	log.Warn("no pending header found for", "terminus", hash, "pendingHeaderNumber", pendingHeader.Header.NumberArray(), "Hash", pendingHeader.Header.ParentHash(nodeCtx), "Termini index", terminiIndex, "indices", indices)
 	return errors.New("no pending header found in cache")
 }
 
+// FillPendingHeader synchronously resets the transaction pool to the current
+// head and fills the pending header on top of it with transactions and state.
+// It stands in for the asynchronous pending header loop of synchronous zones.
+func (sl *Slice) FillPendingHeader() error {
+	if sl.NodeCtx() != common.ZONE_CTX {
+		return errors.New("pending headers can only be filled in a zone")
+	}
+	head := sl.hc.CurrentBlock()
+	if head == nil {
+		return ErrNoGenesis
+	}
+	if sl.synchronous {
+		sl.txPool.SyncHead(head.Header())
+	}
+	header, err := sl.miner.worker.GeneratePendingHeader(head, true)
+	if err != nil {
+		return err
+	}
+	sl.updatePhCache(types.PendingHeader{}, true, header)
+
+	bestPh, exists := sl.readPhCache(sl.bestPhKey)
+	if exists {
+		bestPh.Header.SetLocation(sl.NodeLocation())
+		sl.miner.worker.pendingHeaderFeed.Send(bestPh.Header)
+	}
+	return nil
+}
+
 // updatePhCache updates cache given a pendingHeaderWithTermini with the terminus used as the key.
 func (sl *Slice) updatePhCache(pendingHeaderWithTermini types.PendingHeader, inSlice bool, localHeader *types.Header) {
+	nodeCtx := sl.NodeCtx()
 	sl.phCacheMu.Lock()
 	defer sl.phCacheMu.Unlock()
 
 	var exists bool
 	if localHeader != nil {
-		termini := sl.hc.GetTerminiByHash(localHeader.ParentHash())
-		pendingHeaderWithTermini, exists = sl.readPhCache(termini[c_terminusIndex])
+		termini := sl.hc.GetTerminiByHash(localHeader.ParentHash(nodeCtx))
+		pendingHeaderWithTermini, exists = sl.readPhCache(termini[terminusIndex(sl.config)])
 		if exists {
 			pendingHeaderWithTermini.Header = sl.combinePendingHeader(localHeader, pendingHeaderWithTermini.Header, common.ZONE_CTX, true)
 		}
 	}
 
 	// Update the pendingHeader Cache
-	oldPh, exist := sl.readPhCache(pendingHeaderWithTermini.Termini[c_terminusIndex])
+	oldPh, exist := sl.readPhCache(pendingHeaderWithTermini.Termini[terminusIndex(sl.config)])
 	var deepCopyPendingHeaderWithTermini types.PendingHeader
 	newPhEntropy := sl.engine.TotalLogPhS(pendingHeaderWithTermini.Header)
 	deepCopyPendingHeaderWithTermini = types.PendingHeader{Header: types.CopyHeader(pendingHeaderWithTermini.Header), Termini: pendingHeaderWithTermini.Termini}
-	deepCopyPendingHeaderWithTermini.Header.SetLocation(common.NodeLocation)
+	deepCopyPendingHeaderWithTermini.Header.SetLocation(sl.NodeLocation())
 	deepCopyPendingHeaderWithTermini.Header.SetTime(uint64(time.Now().Unix()))
 
 	if exist {
@@ -619,14 +699,14 @@ func (sl *Slice) updatePhCache(pendingHeaderWithTermini types.PendingHeader, inS
 		// Simultaneously we have to allow for the state root update
 		// asynchronously, to do this equal check is added to the inSlice case
 		if (!inSlice && newPhEntropy.Cmp(sl.engine.TotalLogPhS(pendingHeaderWithTermini.Header)) >= 0) ||
-			(inSlice && pendingHeaderWithTermini.Header.ParentEntropy().Cmp(oldPh.Header.ParentEntropy()) >= 0) {
-			sl.writePhCache(pendingHeaderWithTermini.Termini[c_terminusIndex], deepCopyPendingHeaderWithTermini)
-			log.Info("PhCache update:", "inSlice:", inSlice, "Ph Number:", deepCopyPendingHeaderWithTermini.Header.NumberArray(), "Termini:", deepCopyPendingHeaderWithTermini.Termini[c_terminusIndex])
+			(inSlice && pendingHeaderWithTermini.Header.ParentEntropy(nodeCtx).Cmp(oldPh.Header.ParentEntropy(nodeCtx)) >= 0) {
+			sl.writePhCache(pendingHeaderWithTermini.Termini[terminusIndex(sl.config)], deepCopyPendingHeaderWithTermini)
+			log.Info("PhCache update:", "inSlice:", inSlice, "Ph Number:", deepCopyPendingHeaderWithTermini.Header.NumberArray(), "Termini:", deepCopyPendingHeaderWithTermini.Termini[terminusIndex(sl.config)])
 		}
 	} else {
 		if inSlice {
-			sl.writePhCache(pendingHeaderWithTermini.Termini[c_terminusIndex], deepCopyPendingHeaderWithTermini)
-			log.Info("PhCache new terminus inSlice ", "Ph Number:", deepCopyPendingHeaderWithTermini.Header.NumberArray(), "Termini:", deepCopyPendingHeaderWithTermini.Termini[c_terminusIndex])
+			sl.writePhCache(pendingHeaderWithTermini.Termini[terminusIndex(sl.config)], deepCopyPendingHeaderWithTermini)
+			log.Info("PhCache new terminus inSlice ", "Ph Number:", deepCopyPendingHeaderWithTermini.Header.NumberArray(), "Termini:", deepCopyPendingHeaderWithTermini.Termini[terminusIndex(sl.config)])
 		} else {
 			log.Info("phCache tried to create new entry from coord")
 		}
@@ -637,8 +717,8 @@ func (sl *Slice) pickPhHead(pendingHeaderWithTermini types.PendingHeader, oldBes
 	newPhEntropy := sl.engine.TotalLogPhS(pendingHeaderWithTermini.Header)
 	// Pick a phCache Head
 	if sl.poem(newPhEntropy, oldBestPhEntropy) {
-		sl.bestPhKey = pendingHeaderWithTermini.Termini[c_terminusIndex]
-		log.Info("Choosing new pending header", "Ph Number:", pendingHeaderWithTermini.Header.NumberArray(), "terminus:", pendingHeaderWithTermini.Termini[c_terminusIndex])
+		sl.bestPhKey = pendingHeaderWithTermini.Termini[terminusIndex(sl.config)]
+		log.Info("Choosing new pending header", "Ph Number:", pendingHeaderWithTermini.Header.NumberArray(), "terminus:", pendingHeaderWithTermini.Termini[terminusIndex(sl.config)])
 		return true
 	}
 	return false
@@ -659,11 +739,17 @@ func (sl *Slice) init(genesis *Genesis) error {
 	// Loading the badHashes from the data base and storing it in the cache
 	badHashes := rawdb.ReadBadHashesList(sl.sliceDb)
 	sl.AddToBadHashesList(badHashes)
+	for _, checkpoint := range rawdb.ReadCheckpoints(sl.sliceDb) {
+		sl.checkpoints[checkpoint.Number] = checkpoint.Hash
+	}
+	for _, conflict := range rawdb.ReadCheckpointConflicts(sl.sliceDb) {
+		sl.conflicts[conflict.Number] = append(sl.conflicts[conflict.Number], conflict.Hash)
+	}
 
 	// If the headerchain is empty start from genesis
 	if sl.hc.Empty() {
 		// Initialize slice state for genesis knot
-		genesisTermini := []common.Hash{genesisHash, genesisHash, genesisHash, genesisHash}
+		genesisTermini := genesisTermini(sl.config)
 		rawdb.WriteTermini(sl.sliceDb, genesisHash, genesisTermini)
 		rawdb.WriteManifest(sl.sliceDb, genesisHash, types.BlockManifest{genesisHash})
 
@@ -685,9 +771,9 @@ func (sl *Slice) init(genesis *Genesis) error {
 		if err != nil {
 			return err
 		}
-		rawdb.WriteEtxSet(sl.sliceDb, genesisHash, 0, types.NewEtxSet())
+		sl.hc.WriteEtxSet(sl.sliceDb, genesisHash, 0, types.NewEtxSet(), &types.EtxSetDiff{})
 
-		if common.NodeLocation.Context() == common.PRIME_CTX {
+		if sl.NodeCtx() == common.PRIME_CTX && !sl.synchronous {
 			go sl.NewGenesisPendingHeader(nil)
 		}
 	} else { // load the phCache and slice current pending header hash
@@ -702,14 +788,14 @@ func (sl *Slice) init(genesis *Genesis) error {
 // from the candidate body db. This method is used when peers give the block as a placeholder
 // for the body.
 func (sl *Slice) ConstructLocalBlock(header *types.Header) (*types.Block, error) {
-	nodeCtx := common.NodeLocation.Context()
-	if nodeCtx == common.ZONE_CTX && header.EmptyBody() {
+	nodeCtx := sl.NodeCtx()
+	if nodeCtx == common.ZONE_CTX && header.EmptyBody(nodeCtx) {
 		// This shortcut is only available to zone chains. Prime and region chains can
 		// never have an empty body, because they will always have at least one block
 		// in the subordinate manifest.
 		return types.NewBlockWithHeader(header), nil
 	}
-	pendingBlockBody := rawdb.ReadBody(sl.sliceDb, header.Hash(), header.NumberU64())
+	pendingBlockBody := rawdb.ReadBody(sl.sliceDb, header.Hash(), header.NumberU64(nodeCtx))
 	if pendingBlockBody == nil {
 		return nil, ErrBodyNotFound
 	}
@@ -743,8 +829,8 @@ func (sl *Slice) ConstructLocalBlock(header *types.Header) (*types.Block, error)
 // body from the workers pendingBlockBodyCache. This method is used when the miner sends in the
 // header.
 func (sl *Slice) ConstructLocalMinedBlock(header *types.Header) (*types.Block, error) {
-	nodeCtx := common.NodeLocation.Context()
-	if nodeCtx == common.ZONE_CTX && header.EmptyBody() {
+	nodeCtx := sl.NodeCtx()
+	if nodeCtx == common.ZONE_CTX && header.EmptyBody(nodeCtx) {
 		// This shortcut is only available to zone chains. Prime and region chains can
 		// never have an empty body, because they will always have at least one block
 		// in the subordinate manifest.
@@ -802,6 +888,7 @@ func (sl *Slice) combinePendingHeader(header *types.Header, slPendingHeader *typ
 		combinedPendingHeader.SetUncleHash(header.UncleHash())
 		combinedPendingHeader.SetTxHash(header.TxHash())
 		combinedPendingHeader.SetEtxHash(header.EtxHash())
+		combinedPendingHeader.SetEtxSetRoot(header.EtxSetRoot())
 		combinedPendingHeader.SetReceiptHash(header.ReceiptHash())
 		combinedPendingHeader.SetRoot(header.Root())
 		combinedPendingHeader.SetCoinbase(header.Coinbase())
@@ -816,7 +903,7 @@ func (sl *Slice) combinePendingHeader(header *types.Header, slPendingHeader *typ
 
 // NewGenesisPendingHeader creates a pending header on the genesis block
 func (sl *Slice) NewGenesisPendingHeader(domPendingHeader *types.Header) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	genesisHash := sl.config.GenesisHash
 	// Upate the local pending header
 	localPendingHeader, err := sl.miner.worker.GeneratePendingHeader(sl.hc.GetBlockByHash(genesisHash), false)
@@ -828,11 +915,11 @@ func (sl *Slice) NewGenesisPendingHeader(domPendingHeader *types.Header) {
 		domPendingHeader = types.CopyHeader(localPendingHeader)
 	} else {
 		domPendingHeader = sl.combinePendingHeader(localPendingHeader, domPendingHeader, nodeCtx, true)
-		domPendingHeader.SetLocation(common.NodeLocation)
+		domPendingHeader.SetLocation(sl.NodeLocation())
 	}
 
 	if nodeCtx != common.ZONE_CTX {
-		for _, client := range sl.subClients {
+		for _, client := range sl.subLinks() {
 			if client != nil {
 				client.NewGenesisPendingHeader(context.Background(), domPendingHeader)
 				if err != nil {
@@ -841,7 +928,7 @@ func (sl *Slice) NewGenesisPendingHeader(domPendingHeader *types.Header) {
 			}
 		}
 	}
-	genesisTermini := []common.Hash{genesisHash, genesisHash, genesisHash, genesisHash}
+	genesisTermini := genesisTermini(sl.config)
 	if sl.hc.Empty() {
 		sl.phCache.Add(sl.config.GenesisHash, types.PendingHeader{Header: domPendingHeader, Termini: genesisTermini})
 	}
@@ -855,12 +942,97 @@ func (sl *Slice) SubscribeMissingParentEvent(ch chan<- common.Hash) event.Subscr
 	return sl.scope.Track(sl.missingParentFeed.Subscribe(ch))
 }
 
+// SetDomClient attaches the transport used to reach the dominant chain, or
+// detaches it if nil. Every call made through it is tracked for health
+// reporting.
+func (sl *Slice) SetDomClient(client DomClient) {
+	sl.clientsMu.Lock()
+	defer sl.clientsMu.Unlock()
+
+	if client == nil {
+		sl.domClient, sl.domHealth = nil, nil
+		return
+	}
+	sl.domHealth = newLinkMonitor(isLocalTransport(client))
+	sl.domClient = &monitoredDomClient{client: client, monitor: sl.domHealth}
+}
+
+// SetSubClient attaches the transport used to reach the subordinate chain at
+// the given index, or detaches it if nil. Every call made through it is
+// tracked for health reporting.
+func (sl *Slice) SetSubClient(index int, client SubClient) {
+	sl.clientsMu.Lock()
+	defer sl.clientsMu.Unlock()
+
+	if client == nil {
+		sl.subClients[index], sl.subHealths[index] = nil, nil
+		return
+	}
+	sl.subHealths[index] = newLinkMonitor(isLocalTransport(client))
+	sl.subClients[index] = &monitoredSubClient{client: client, monitor: sl.subHealths[index]}
+}
+
+// domLink returns the transport to the dominant chain, or nil if none is
+// attached yet.
+func (sl *Slice) domLink() DomClient {
+	sl.clientsMu.RLock()
+	defer sl.clientsMu.RUnlock()
+	return sl.domClient
+}
+
+// subLink returns the transport to the subordinate chain at the given index,
+// or nil if none is attached.
+func (sl *Slice) subLink(index int) SubClient {
+	sl.clientsMu.RLock()
+	defer sl.clientsMu.RUnlock()
+
+	if index < 0 || index >= len(sl.subClients) {
+		return nil
+	}
+	return sl.subClients[index]
+}
+
+// subLinks returns the transports to the subordinate chains by index, nil
+// where none is attached.
+func (sl *Slice) subLinks() []SubClient {
+	sl.clientsMu.RLock()
+	defer sl.clientsMu.RUnlock()
+	return append([]SubClient{}, sl.subClients...)
+}
+
+// TransportHealth reports the state of the links to the dom and sub chains.
+func (sl *Slice) TransportHealth() TransportHealth {
+	sl.clientsMu.RLock()
+	defer sl.clientsMu.RUnlock()
+
+	nodeCtx := sl.NodeCtx()
+	var health TransportHealth
+	if nodeCtx != common.PRIME_CTX {
+		if monitor := sl.domHealth; monitor != nil {
+			dom := monitor.Health()
+			health.Dom = &dom
+		} else {
+			health.Dom = &LinkHealth{}
+		}
+	}
+	if nodeCtx != common.ZONE_CTX {
+		health.Subs = make([]*LinkHealth, len(sl.subHealths))
+		for i, monitor := range sl.subHealths {
+			if monitor != nil {
+				sub := monitor.Health()
+				health.Subs[i] = &sub
+			}
+		}
+	}
+	return health
+}
+
 // MakeDomClient creates the quaiclient for the given domurl
-func makeDomClient(domurl string) *quaiclient.Client {
+func makeDomClient(domurl string, jwtSecret []byte) *quaiclient.Client {
 	if domurl == "" {
 		log.Fatal("dom client url is empty")
 	}
-	domClient, err := quaiclient.Dial(domurl)
+	domClient, err := quaiclient.Dial(domurl, jwtSecret)
 	if err != nil {
 		log.Fatal("Error connecting to the dominant go-quai client", "err", err)
 	}
@@ -868,11 +1040,15 @@ func makeDomClient(domurl string) *quaiclient.Client {
 }
 
 // MakeSubClients creates the quaiclient for the given suburls
-func makeSubClients(suburls []string) []*quaiclient.Client {
-	subClients := make([]*quaiclient.Client, 3)
+func makeSubClients(suburls []string, jwtSecret []byte, topology *common.Topology) []*quaiclient.Client {
+	subClients := make([]*quaiclient.Client, topology.Width())
 	for i, suburl := range suburls {
+		if i >= len(subClients) {
+			log.Warn("Ignoring subordinate urls beyond the topology", "urls", suburls[i:])
+			break
+		}
 		if suburl != "" {
-			subClient, err := quaiclient.Dial(suburl)
+			subClient, err := quaiclient.Dial(suburl, jwtSecret)
 			if err != nil {
 				log.Fatal("Error connecting to the subordinate go-quai client for index", "index", i, " err ", err)
 			}
@@ -894,12 +1070,18 @@ func (sl *Slice) loadLastState() error {
 	rawdb.DeletePhCache(sl.sliceDb)
 	sl.bestPhKey = rawdb.ReadBestPhKey(sl.sliceDb)
 	sl.miner.worker.LoadPendingBlockBody()
+	// Sweep the entries of the caches which were not stored on shutdown
+	if count, err := rawdb.DeletePendingData(sl.sliceDb); err != nil {
+		return err
+	} else if count > 0 {
+		log.Info("Removed stale pending data", "entries", count)
+	}
 	return nil
 }
 
 // Stop stores the phCache and the sl.pendingHeader hash value to the db.
 func (sl *Slice) Stop() {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	// write the ph head hash to the db.
 	rawdb.WriteBestPhKey(sl.sliceDb, sl.bestPhKey)
 
@@ -913,11 +1095,7 @@ func (sl *Slice) Stop() {
 	// Write the ph cache to the dd.
 	rawdb.WritePhCache(sl.sliceDb, phCache)
 
-	var badHashes []common.Hash
-	for hash := range sl.badHashesCache {
-		badHashes = append(badHashes, hash)
-	}
-	rawdb.WriteBadHashesList(sl.sliceDb, badHashes)
+	rawdb.WriteBadHashesList(sl.sliceDb, sl.badHashes())
 	sl.miner.worker.StorePendingBlockBody()
 
 	sl.scope.Close()
@@ -925,7 +1103,9 @@ func (sl *Slice) Stop() {
 
 	sl.hc.Stop()
 	if nodeCtx == common.ZONE_CTX {
-		sl.asyncPhSub.Unsubscribe()
+		if sl.asyncPhSub != nil {
+			sl.asyncPhSub.Unsubscribe()
+		}
 		sl.txPool.Stop()
 	}
 	sl.miner.Stop()
@@ -933,8 +1113,29 @@ func (sl *Slice) Stop() {
 
 func (sl *Slice) Config() *params.ChainConfig { return sl.config }
 
+// NodeLocation returns the location of the chain served by this slice
+func (sl *Slice) NodeLocation() common.Location { return sl.config.Location }
+
+// NodeCtx returns the context of the chain served by this slice
+func (sl *Slice) NodeCtx() int { return sl.config.Location.Context() }
+
 func (sl *Slice) Engine() consensus.Engine { return sl.engine }
 
+// terminusIndex returns the index of the terminus of a block within its
+// termini, which follows the termini of the subordinate chains of the topology
+// of the chain config.
+func terminusIndex(config *params.ChainConfig) int { return config.GetTopology().Width() }
+
+// genesisTermini returns the termini of the genesis block, which is the
+// terminus of every chain.
+func genesisTermini(config *params.ChainConfig) []common.Hash {
+	termini := make([]common.Hash, terminusIndex(config)+1)
+	for i := range termini {
+		termini[i] = config.GenesisHash
+	}
+	return termini
+}
+
 func (sl *Slice) HeaderChain() *HeaderChain { return sl.hc }
 
 func (sl *Slice) TxPool() *TxPool { return sl.txPool }
@@ -962,14 +1163,14 @@ func (sl *Slice) WriteBlock(block *types.Block) {
 }
 
 func (sl *Slice) AddPendingEtxs(pEtxs types.PendingEtxs) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	if err := sl.hc.AddPendingEtxs(pEtxs); err == nil || err.Error() != ErrPendingEtxAlreadyKnown.Error() {
 		// Only in the region case we have to send the pendingEtxs to dom from the AddPendingEtxs
 		if nodeCtx == common.REGION_CTX {
 			// Also the first time when adding the pending etx broadcast it to the peers
 			sl.pendingEtxsFeed.Send(pEtxs)
-			if sl.domClient != nil {
-				sl.domClient.SendPendingEtxsToDom(context.Background(), pEtxs)
+			if domClient := sl.domLink(); domClient != nil {
+				domClient.SendPendingEtxsToDom(context.Background(), pEtxs)
 			}
 		}
 	} else if err.Error() == ErrPendingEtxAlreadyKnown.Error() {
@@ -984,7 +1185,7 @@ func (sl *Slice) AddPendingEtxsRollup(pEtxsRollup types.PendingEtxsRollup) error
 	if !pEtxsRollup.IsValid(trie.NewStackTrie(nil)) {
 		return ErrPendingEtxRollupNotValid
 	}
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	log.Debug("Received pending ETXs Rollup", "header: ", pEtxsRollup.Header.Hash(), "Len of Rollup", len(pEtxsRollup.Manifest))
 	// Only write the pending ETXs if we have not seen them before
 	if !sl.hc.pendingEtxsRollup.Contains(pEtxsRollup.Header.Hash()) {
@@ -999,43 +1200,55 @@ func (sl *Slice) AddPendingEtxsRollup(pEtxsRollup types.PendingEtxsRollup) error
 			sl.pendingEtxsRollupFeed.Send(pEtxsRollup)
 			// Only in the region case, send the pending etx rollup to the dom
 		} else if nodeCtx == common.REGION_CTX {
-			if sl.domClient != nil {
-				sl.domClient.SendPendingEtxsRollupToDom(context.Background(), pEtxsRollup)
+			if domClient := sl.domLink(); domClient != nil {
+				domClient.SendPendingEtxsRollupToDom(context.Background(), pEtxsRollup)
 			}
 		}
 	}
 	return nil
 }
 
+// CheckForBadHashAndRecover rewinds the chain below the oldest canonical block
+// which is listed as bad, either in BadHashes or at runtime.
 func (sl *Slice) CheckForBadHashAndRecover() {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	// Lookup the bad hashes list to see if we have it in the database
+	badHashes := sl.badHashes()
 	for _, fork := range BadHashes {
-		var badBlock *types.Block
-		var badHash common.Hash
 		switch nodeCtx {
 		case common.PRIME_CTX:
-			badHash = fork.PrimeContext
+			badHashes = append(badHashes, fork.PrimeContext)
 		case common.REGION_CTX:
-			badHash = fork.RegionContext[common.NodeLocation.Region()]
+			badHashes = append(badHashes, fork.RegionContext[sl.NodeLocation().Region()])
 		case common.ZONE_CTX:
-			badHash = fork.ZoneContext[common.NodeLocation.Region()][common.NodeLocation.Zone()]
+			badHashes = append(badHashes, fork.ZoneContext[sl.NodeLocation().Region()][sl.NodeLocation().Zone()])
 		}
-		badBlock = sl.hc.GetBlockByHash(badHash)
-		// Node has a bad block in the database
-		if badBlock != nil {
-			// Start from the current tip and delete every block from the database until this bad hash block
-			sl.cleanCacheAndDatabaseTillBlock(badBlock.ParentHash())
-			if nodeCtx == common.PRIME_CTX {
-				sl.SetHeadBackToRecoveryState(nil, badBlock.ParentHash())
-			}
+	}
+	// Rewinding below the oldest bad block removes the newer ones too. Bad
+	// blocks off the canonical chain are only kept from being appended again.
+	var badBlock *types.Block
+	for _, hash := range badHashes {
+		block := sl.hc.GetBlockByHash(hash)
+		if block == nil || sl.hc.GetCanonicalHash(block.NumberU64(nodeCtx)) != hash {
+			continue
+		}
+		if badBlock == nil || block.NumberU64(nodeCtx) < badBlock.NumberU64(nodeCtx) {
+			badBlock = block
+		}
+	}
+	// Node has a bad block in the database
+	if badBlock != nil {
+		// Start from the current tip and delete every block from the database until this bad hash block
+		sl.cleanCacheAndDatabaseTillBlock(badBlock.ParentHash(nodeCtx))
+		if nodeCtx == common.PRIME_CTX {
+			sl.SetHeadBackToRecoveryState(nil, badBlock.ParentHash(nodeCtx))
 		}
 	}
 }
 
 // SetHeadBackToRecoveryState sets the heads of the whole hierarchy to the recovery state
 func (sl *Slice) SetHeadBackToRecoveryState(pendingHeader *types.Header, hash common.Hash) types.PendingHeader {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	if nodeCtx == common.PRIME_CTX {
 		localPendingHeaderWithTermini := sl.ComputeRecoveryPendingHeader(hash)
 		sl.phCache.Add(hash, localPendingHeaderWithTermini)
@@ -1043,7 +1256,7 @@ func (sl *Slice) SetHeadBackToRecoveryState(pendingHeader *types.Header, hash co
 	} else {
 		localPendingHeaderWithTermini := sl.ComputeRecoveryPendingHeader(hash)
 		localPendingHeaderWithTermini.Header = sl.combinePendingHeader(localPendingHeaderWithTermini.Header, pendingHeader, nodeCtx, true)
-		localPendingHeaderWithTermini.Header.SetLocation(common.NodeLocation)
+		localPendingHeaderWithTermini.Header.SetLocation(sl.NodeLocation())
 		sl.phCache.Add(hash, localPendingHeaderWithTermini)
 		return localPendingHeaderWithTermini
 	}
@@ -1058,31 +1271,17 @@ func (sl *Slice) cleanCacheAndDatabaseTillBlock(hash common.Hash) {
 	if hash == currentHeader.Hash() {
 		return
 	}
-	nodeCtx := common.NodeLocation.Context()
-	// slice caches
-	sl.phCache.Purge()
-	sl.miner.worker.pendingBlockBody.Purge()
-	rawdb.DeletePhCache(sl.sliceDb)
-	rawdb.DeleteBestPhKey(sl.sliceDb)
-	// headerchain caches
-	sl.hc.headerCache.Purge()
-	sl.hc.numberCache.Purge()
-	sl.hc.pendingEtxsRollup.Purge()
-	sl.hc.pendingEtxs.Purge()
-	rawdb.DeleteAllHeadsHashes(sl.sliceDb)
-	// bodydb caches
-	sl.hc.bc.blockCache.Purge()
-	sl.hc.bc.bodyCache.Purge()
-	sl.hc.bc.bodyRLPCache.Purge()
+	nodeCtx := sl.NodeCtx()
+	sl.purgeCaches()
 
 	var badHashes []common.Hash
 	header := currentHeader
 	for {
-		rawdb.DeleteBlock(sl.sliceDb, header.Hash(), header.NumberU64())
-		rawdb.DeleteCanonicalHash(sl.sliceDb, header.NumberU64())
+		rawdb.DeleteBlock(sl.sliceDb, header.Hash(), header.NumberU64(nodeCtx))
+		rawdb.DeleteCanonicalHash(sl.sliceDb, header.NumberU64(nodeCtx))
 		rawdb.DeleteHeaderNumber(sl.sliceDb, header.Hash())
 		rawdb.DeleteTermini(sl.sliceDb, header.Hash())
-		rawdb.DeleteEtxSet(sl.sliceDb, header.Hash(), header.NumberU64())
+		sl.hc.DeleteEtxSet(sl.sliceDb, header.Hash(), header.NumberU64(nodeCtx))
 		if nodeCtx != common.ZONE_CTX {
 			pendingEtxsRollup := rawdb.ReadPendingEtxsRollup(sl.sliceDb, header.Hash())
 			// First hash in the manifest is always a dom block and it needs to be
@@ -1097,7 +1296,7 @@ func (sl *Slice) cleanCacheAndDatabaseTillBlock(hash common.Hash) {
 		// delete the trie node for a given root of the header
 		rawdb.DeleteTrieNode(sl.sliceDb, header.Root())
 		badHashes = append(badHashes, header.Hash())
-		parent := sl.hc.GetHeader(header.ParentHash(), header.NumberU64()-1)
+		parent := sl.hc.GetHeader(header.ParentHash(nodeCtx), header.NumberU64(nodeCtx)-1)
 		header = parent
 		if header.Hash() == hash || header.Hash() == sl.config.GenesisHash {
 			break
@@ -1109,29 +1308,59 @@ func (sl *Slice) cleanCacheAndDatabaseTillBlock(hash common.Hash) {
 	currentHeader = sl.hc.GetHeaderByHash(hash)
 	sl.hc.currentHeader.Store(currentHeader)
 
-	// Recover the snaps
-	if nodeCtx == common.ZONE_CTX {
-		sl.hc.bc.processor.snaps, _ = snapshot.New(sl.sliceDb, sl.hc.bc.processor.stateCache.TrieDB(), sl.hc.bc.processor.cacheConfig.SnapshotLimit, currentHeader.Root(), true, true)
+	sl.recoverSnapshots(currentHeader.Root())
+}
+
+// recoverSnapshots rebuilds the state snapshots of a zone at the given root
+// once the head is moved back.
+func (sl *Slice) recoverSnapshots(root common.Hash) {
+	processor := sl.hc.bc.processor
+	if sl.NodeCtx() != common.ZONE_CTX || processor.cacheConfig.SnapshotLimit <= 0 {
+		return
 	}
+	processor.snaps, _ = snapshot.New(sl.sliceDb, processor.stateCache.TrieDB(), processor.cacheConfig.SnapshotLimit, root, true, true)
+}
+
+// purgeCaches drops the cached chain data and the pending data of the slice,
+// which are stale once the head is moved back.
+func (sl *Slice) purgeCaches() {
+	// slice caches
+	sl.phCache.Purge()
+	sl.miner.worker.pendingBlockBody.Purge()
+	rawdb.DeletePhCache(sl.sliceDb)
+	rawdb.DeleteBestPhKey(sl.sliceDb)
+	if _, err := rawdb.DeletePendingData(sl.sliceDb); err != nil {
+		log.Error("Failed to remove pending data", "err", err)
+	}
+	// headerchain caches
+	sl.hc.headerCache.Purge()
+	sl.hc.numberCache.Purge()
+	sl.hc.pendingEtxsRollup.Purge()
+	sl.hc.pendingEtxs.Purge()
+	rawdb.DeleteAllHeadsHashes(sl.sliceDb)
+	// bodydb caches
+	sl.hc.bc.blockCache.Purge()
+	sl.hc.bc.bodyCache.Purge()
+	sl.hc.bc.bodyRLPCache.Purge()
 }
 
 func (sl *Slice) GenerateRecoveryPendingHeader(pendingHeader *types.Header, checkPointHashes []common.Hash) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	if nodeCtx == common.PRIME_CTX {
-		for i := 0; i < common.NumRegionsInPrime; i++ {
-			if sl.subClients[i] != nil {
-				sl.subClients[i].GenerateRecoveryPendingHeader(context.Background(), pendingHeader, checkPointHashes)
+		for _, subClient := range sl.subLinks() {
+			if subClient != nil {
+				subClient.GenerateRecoveryPendingHeader(context.Background(), pendingHeader, checkPointHashes)
 			}
 		}
 	} else if nodeCtx == common.REGION_CTX {
-		newPendingHeader := sl.SetHeadBackToRecoveryState(pendingHeader, checkPointHashes[common.NodeLocation.Region()])
-		for i := 0; i < common.NumZonesInRegion; i++ {
-			if sl.subClients[i] != nil {
-				sl.subClients[i].GenerateRecoveryPendingHeader(context.Background(), newPendingHeader.Header, newPendingHeader.Termini)
+		newPendingHeader := sl.SetHeadBackToRecoveryState(pendingHeader, checkPointHashes[sl.NodeLocation().Region()])
+		for _, subClient := range sl.subLinks() {
+			if subClient != nil {
+				subClient.GenerateRecoveryPendingHeader(context.Background(), newPendingHeader.Header, newPendingHeader.Termini)
 			}
 		}
 	} else {
-		sl.SetHeadBackToRecoveryState(pendingHeader, checkPointHashes[common.NodeLocation.Zone()])
+		sl.SetHeadBackToRecoveryState(pendingHeader, checkPointHashes[sl.NodeLocation().Zone()])
 	}
 	return nil
 }
@@ -1153,6 +1382,8 @@ func (sl *Slice) ComputeRecoveryPendingHeader(hash common.Hash) types.PendingHea
 
 // AddToBadHashesList adds a given set of badHashes to the BadHashesList
 func (sl *Slice) AddToBadHashesList(badHashes []common.Hash) {
+	sl.badHashesMu.Lock()
+	defer sl.badHashesMu.Unlock()
 	for _, hash := range badHashes {
 		sl.badHashesCache[hash] = true
 	}
@@ -1160,14 +1391,15 @@ func (sl *Slice) AddToBadHashesList(badHashes []common.Hash) {
 
 // HashExistsInBadHashesList checks if the given hash exists in the badHashesCache
 func (sl *Slice) HashExistsInBadHashesList(hash common.Hash) bool {
-	// Look for pending ETXs first in pending ETX map, then in database
+	sl.badHashesMu.RLock()
+	defer sl.badHashesMu.RUnlock()
 	_, ok := sl.badHashesCache[hash]
 	return ok
 }
 
 // IsBlockHashABadHash checks if the given hash exists in BadHashes List
 func (sl *Slice) IsBlockHashABadHash(hash common.Hash) bool {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := sl.NodeCtx()
 	switch nodeCtx {
 	case common.PRIME_CTX:
 		for _, fork := range BadHashes {
@@ -1177,13 +1409,13 @@ func (sl *Slice) IsBlockHashABadHash(hash common.Hash) bool {
 		}
 	case common.REGION_CTX:
 		for _, fork := range BadHashes {
-			if fork.RegionContext[common.NodeLocation.Region()] == hash {
+			if fork.RegionContext[sl.NodeLocation().Region()] == hash {
 				return true
 			}
 		}
 	case common.ZONE_CTX:
 		for _, fork := range BadHashes {
-			if fork.ZoneContext[common.NodeLocation.Region()][common.NodeLocation.Zone()] == hash {
+			if fork.ZoneContext[sl.NodeLocation().Region()][sl.NodeLocation().Zone()] == hash {
 				return true
 			}
 		}
@@ -160,11 +160,8 @@ func (s *StateDB) DumpToCollector(c DumpCollector, conf *DumpConfig) (nextKey []
 			}
 			account.SecureKey = it.Key
 		}
-		addr := common.BytesToAddress(addrBytes)
-		internal, err := addr.InternalAddress()
-		if err != nil {
-			continue
-		}
+		// Every account in the trie belongs to the chain of the state
+		internal := common.InternalAddress(common.BytesToAddress(addrBytes).Bytes20())
 		obj := newObject(s, internal, data)
 		if !conf.SkipCode {
 			account.Code = obj.Code(s.db)
@@ -85,7 +85,16 @@ const (
 	// - Version 8
 	//  The following incompatible database changes were added:
 	//    * New scheme for contract code in order to separate the codes and trie nodes
-	BlockChainVersion uint64 = 8
+	// - Version 9
+	//  The following incompatible database changes were added:
+	//    * The EtxSet is stored as a per block diff, with a full set every 128 blocks
+	//    * The ancient etxSets table holds the per block diffs instead of full sets
+	BlockChainVersion uint64 = 9
+
+	// MinBlockChainVersion is the oldest database version which can still be
+	// upgraded in place. Older ancient stores hold full EtxSets, which cannot
+	// be rewritten as diffs, so these databases have to be synced again.
+	MinBlockChainVersion uint64 = 9
 )
 
 // CacheConfig contains the configuration values for the trie caching/pruning
@@ -100,6 +109,7 @@ type CacheConfig struct {
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
+	AncientDepth        uint64        // Number of blocks below the last prime coincident block not to freeze (params.FullImmutabilityThreshold if 0)
 }
 
 // defaultCacheConfig are the default caching values if none are specified by the
@@ -199,17 +209,18 @@ func NewStateProcessor(config *params.ChainConfig, hc *HeaderChain, engine conse
 // returns the amount of gas that was used in the process. If any of the
 // transactions failed to execute due to insufficient gas it will return an error.
 func (p *StateProcessor) Process(block *types.Block, etxSet types.EtxSet) (types.Receipts, []*types.Log, *state.StateDB, uint64, error) {
+	nodeCtx := p.hc.NodeCtx()
 	var (
 		receipts    types.Receipts
 		usedGas     = new(uint64)
 		header      = block.Header()
 		blockHash   = block.Hash()
-		blockNumber = block.Number()
+		blockNumber = block.Number(nodeCtx)
 		allLogs     []*types.Log
 		gp          = new(GasPool).AddGas(block.GasLimit())
 	)
 
-	parent := p.hc.GetBlock(block.Header().ParentHash(), block.NumberU64()-1)
+	parent := p.hc.GetBlock(block.Header().ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
 	if parent == nil {
 		return types.Receipts{}, []*types.Log{}, nil, 0, errors.New("parent block is nil for the block given to process")
 	}
@@ -241,19 +252,12 @@ func (p *StateProcessor) Process(block *types.Block, etxSet types.EtxSet) (types
 	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, p.config, p.vmConfig)
 
 	// Iterate over and process the individual transactions.
-	etxRLimit := len(parent.Transactions()) / params.ETXRegionMaxFraction
-	if etxRLimit < params.ETXRLimitMin {
-		etxRLimit = params.ETXRLimitMin
-	}
-	etxPLimit := len(parent.Transactions()) / params.ETXPrimeMaxFraction
-	if etxPLimit < params.ETXPLimitMin {
-		etxPLimit = params.ETXPLimitMin
-	}
+	etxRLimit, etxPLimit := CalcETXLimits(parent, p.config.GetTopology())
 
 	var emittedEtxs types.Transactions
 	for i, tx := range block.Transactions() {
 		startProcess := time.Now()
-		msg, err := tx.AsMessageWithSender(types.MakeSigner(p.config, header.Number()), header.BaseFee(), senders[tx.Hash()])
+		msg, err := tx.AsMessageWithSender(types.MakeSigner(p.config, header.Number(nodeCtx), header.Number(common.PRIME_CTX)), header.BaseFee(), senders[tx.Hash()])
 		if err != nil {
 			return nil, nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
 		}
@@ -272,7 +276,7 @@ func (p *StateProcessor) Process(block *types.Block, etxSet types.EtxSet) (types
 			if !exists { // Verify that the ETX exists in the set
 				return nil, nil, nil, 0, fmt.Errorf("invalid external transaction: etx %x not found in unspent etx set", etxEntry.ETX.Hash())
 			}
-			prevZeroBal := prepareApplyETX(statedb, &etxEntry.ETX)
+			prevZeroBal := PrepareApplyETX(statedb, &etxEntry.ETX)
 			receipt, err = applyTransaction(msg, p.config, p.hc, nil, gp, statedb, blockNumber, blockHash, &etxEntry.ETX, usedGas, vmenv, &etxRLimit, &etxPLimit)
 			statedb.SetBalance(common.ZeroInternal, prevZeroBal) // Reset the balance to what it previously was. Residual balance will be lost
 
@@ -324,11 +328,11 @@ func applyTransaction(msg types.Message, config *params.ChainConfig, bc ChainCon
 	var ETXPCount int
 	for _, tx := range result.Etxs {
 		// Count which ETXs are cross-region
-		if tx.To().Location().CommonDom(common.NodeLocation).Context() == common.REGION_CTX {
+		if tx.To().Location(config.GetTopology()).CommonDom(config.Location).Context() == common.REGION_CTX {
 			ETXRCount++
 		}
 		// Count which ETXs are cross-prime
-		if tx.To().Location().CommonDom(common.NodeLocation).Context() == common.PRIME_CTX {
+		if tx.To().Location(config.GetTopology()).CommonDom(config.Location).Context() == common.PRIME_CTX {
 			ETXPCount++
 		}
 	}
@@ -375,17 +379,32 @@ func applyTransaction(msg types.Message, config *params.ChainConfig, bc ChainCon
 
 var lastWrite uint64
 
-// Apply State
-func (p *StateProcessor) Apply(batch ethdb.Batch, block *types.Block, newInboundEtxs types.Transactions) ([]*types.Log, error) {
+// Apply State. The lifecycle of the ETXs emitted and received by the block is
+// recorded in etxStatuses, which the caller writes along with the batch.
+func (p *StateProcessor) Apply(batch ethdb.Batch, block *types.Block, newInboundEtxs types.Transactions, etxStatuses *etxStatusBatch) ([]*types.Log, error) {
+	nodeCtx := p.hc.NodeCtx()
 	// Update the set of inbound ETXs which may be mined. This adds new inbound
 	// ETXs to the set and removes expired ETXs so they are no longer available
 	start := time.Now()
-	etxSet := rawdb.ReadEtxSet(p.hc.bc.db, block.ParentHash(), block.NumberU64()-1)
+	etxSet := p.hc.GetEtxSet(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
 	time1 := common.PrettyDuration(time.Since(start))
 	if etxSet == nil {
 		return nil, errors.New("failed to load etx set")
 	}
-	etxSet.Update(newInboundEtxs, block.NumberU64())
+	// Once the fork is active, the header commits to the set it is executed
+	// against, so the sets of the chain can be verified against the headers
+	parent := p.hc.GetHeader(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	var etxSetRoot common.Hash
+	if p.hc.commitsEtxSet(parent) {
+		etxSetRoot = etxSet.Root(trie.NewStackTrie(nil))
+	}
+	if block.EtxSetRoot() != etxSetRoot {
+		return nil, fmt.Errorf("%w: have %x, want %x", ErrBadEtxSetRoot, block.EtxSetRoot(), etxSetRoot)
+	}
+	expiredEtxs := etxSet.Update(newInboundEtxs, block.NumberU64(nodeCtx), p.hc.NodeLocation(), p.config.GetTopology())
 	time2 := common.PrettyDuration(time.Since(start))
 	// Process our block
 	receipts, logs, statedb, usedGas, err := p.Process(block, etxSet)
@@ -398,7 +417,7 @@ func (p *StateProcessor) Apply(batch ethdb.Batch, block *types.Block, newInbound
 		return nil, err
 	}
 	time4 := common.PrettyDuration(time.Since(start))
-	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(nodeCtx), receipts)
 	time4_5 := common.PrettyDuration(time.Since(start))
 	// Create bloom filter and write it to cache/db
 	bloom := types.CreateBloom(receipts)
@@ -426,9 +445,9 @@ func (p *StateProcessor) Apply(batch ethdb.Batch, block *types.Block, newInbound
 	} else {
 		// Full but not archive node, do proper garbage collection
 		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
-		p.triegc.Push(root, -int64(block.NumberU64()))
+		p.triegc.Push(root, -int64(block.NumberU64(nodeCtx)))
 		time8 = common.PrettyDuration(time.Since(start))
-		if current := block.NumberU64(); current > TriesInMemory {
+		if current := block.NumberU64(nodeCtx); current > TriesInMemory {
 			// If we exceeded our memory allowance, flush matured singleton nodes to disk
 			var (
 				nodes, imgs = triedb.Size()
@@ -472,9 +491,27 @@ func (p *StateProcessor) Apply(batch ethdb.Batch, block *types.Block, newInbound
 			time11 = common.PrettyDuration(time.Since(start))
 		}
 	}
-	rawdb.WriteEtxSet(p.hc.bc.db, block.Hash(), block.NumberU64(), etxSet)
+	// Only store what this block changed in the set: the ETXs which became
+	// available, and the ones which were spent or expired.
+	etxSetDiff := &types.EtxSetDiff{Added: newInboundEtxs, Removed: expiredEtxs}
+	for _, tx := range block.Transactions() {
+		if tx.Type() == types.ExternalTxType {
+			etxSetDiff.Removed = append(etxSetDiff.Removed, tx.Hash())
+		}
+	}
+	p.hc.WriteEtxSet(batch, block.Hash(), block.NumberU64(nodeCtx), etxSet, etxSetDiff)
 	time12 := common.PrettyDuration(time.Since(start))
 
+	// Index the lifecycle of the ETXs emitted and received by this block
+	etxStatuses.emitted(block)
+	etxStatuses.available(block, newInboundEtxs)
+	for _, tx := range block.Transactions() {
+		if tx.Type() == types.ExternalTxType {
+			etxStatuses.executed(block, tx.Hash())
+		}
+	}
+	etxStatuses.expired(block, expiredEtxs)
+
 	log.Info("times during state processor apply:", "t1:", time1, "t2:", time2, "t3:", time3, "t4:", time4, "t4.5:", time4_5, "t5:", time5, "t6:", time6, "t7:", time7, "t8:", time8, "t9:", time9, "t10:", time10, "t11:", time11, "t12:", time12)
 	return logs, nil
 }
@@ -484,7 +521,8 @@ func (p *StateProcessor) Apply(batch ethdb.Batch, block *types.Block, newInbound
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
 func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config, etxRLimit, etxPLimit *int) (*types.Receipt, error) {
-	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number()), header.BaseFee())
+	nodeCtx := config.Location.Context()
+	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number(nodeCtx), header.Number(common.PRIME_CTX)), header.BaseFee())
 	if err != nil {
 		return nil, err
 	}
@@ -492,12 +530,12 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 	blockContext := NewEVMBlockContext(header, bc, author)
 	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, config, cfg)
 	if tx.Type() == types.ExternalTxType {
-		prevZeroBal := prepareApplyETX(statedb, tx)
-		receipt, err := applyTransaction(msg, config, bc, author, gp, statedb, header.Number(), header.Hash(), tx, usedGas, vmenv, etxRLimit, etxPLimit)
+		prevZeroBal := PrepareApplyETX(statedb, tx)
+		receipt, err := applyTransaction(msg, config, bc, author, gp, statedb, header.Number(nodeCtx), header.Hash(), tx, usedGas, vmenv, etxRLimit, etxPLimit)
 		statedb.SetBalance(common.ZeroInternal, prevZeroBal) // Reset the balance to what it previously was (currently a failed external transaction removes all the sent coins from the supply and any residual balance is gone as well)
 		return receipt, err
 	}
-	return applyTransaction(msg, config, bc, author, gp, statedb, header.Number(), header.Hash(), tx, usedGas, vmenv, etxRLimit, etxPLimit)
+	return applyTransaction(msg, config, bc, author, gp, statedb, header.Number(nodeCtx), header.Hash(), tx, usedGas, vmenv, etxRLimit, etxPLimit)
 }
 
 // GetVMConfig returns the block chain VM config.
@@ -607,12 +645,12 @@ func (p *StateProcessor) ContractCodeWithPrefix(hash common.Hash) ([]byte, error
 //     perform Commit or other 'save-to-disk' changes, this should be set to false to avoid
 //     storing trash persistently
 func (p *StateProcessor) StateAtBlock(block *types.Block, reexec uint64, base *state.StateDB, checkLive bool) (statedb *state.StateDB, err error) {
+	nodeCtx := p.hc.NodeCtx()
 	var (
 		current  *types.Block
-		etxSet   types.EtxSet
 		database state.Database
 		report   = true
-		origin   = block.NumberU64()
+		origin   = block.NumberU64(nodeCtx)
 	)
 	// Check the live database first if we have the state fully available, use that.
 	if checkLive {
@@ -621,11 +659,10 @@ func (p *StateProcessor) StateAtBlock(block *types.Block, reexec uint64, base *s
 			return statedb, nil
 		}
 	}
-	etxSet = rawdb.ReadEtxSet(p.hc.bc.db, block.Hash(), block.NumberU64())
 	if base != nil {
 		// The optional base statedb is given, mark the start point as parent block
 		statedb, database, report = base, base.Database(), false
-		current = p.hc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+		current = p.hc.GetBlock(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
 	} else {
 		// Otherwise try to reexec blocks until we find a state or reach our limit
 		current = block
@@ -645,12 +682,12 @@ func (p *StateProcessor) StateAtBlock(block *types.Block, reexec uint64, base *s
 		}
 		// Database does not have the state for the given block, try to regenerate
 		for i := uint64(0); i < reexec; i++ {
-			if current.NumberU64() == 0 {
+			if current.NumberU64(nodeCtx) == 0 {
 				return nil, errors.New("genesis state is missing")
 			}
-			parent := p.hc.GetBlock(current.ParentHash(), current.NumberU64()-1)
+			parent := p.hc.GetBlock(current.ParentHash(nodeCtx), current.NumberU64(nodeCtx)-1)
 			if parent == nil {
-				return nil, fmt.Errorf("missing block %v %d", current.ParentHash(), current.NumberU64()-1)
+				return nil, fmt.Errorf("missing block %v %d", current.ParentHash(nodeCtx), current.NumberU64(nodeCtx)-1)
 			}
 			current = parent
 
@@ -674,30 +711,35 @@ func (p *StateProcessor) StateAtBlock(block *types.Block, reexec uint64, base *s
 		logged time.Time
 		parent common.Hash
 	)
-	for current.NumberU64() < origin {
+	for current.NumberU64(nodeCtx) < origin {
 		// Print progress logs if long enough time elapsed
 		if time.Since(logged) > 8*time.Second && report {
-			log.Info("Regenerating historical state", "block", current.NumberU64()+1, "target", origin, "remaining", origin-current.NumberU64()-1, "elapsed", time.Since(start))
+			log.Info("Regenerating historical state", "block", current.NumberU64(nodeCtx)+1, "target", origin, "remaining", origin-current.NumberU64(nodeCtx)-1, "elapsed", time.Since(start))
 			logged = time.Now()
 		}
 		// Retrieve the next block to regenerate and process it
-		next := current.NumberU64() + 1
+		next := current.NumberU64(nodeCtx) + 1
 		if current = p.hc.GetBlockByNumber(next); current == nil {
 			return nil, fmt.Errorf("block #%d not found", next)
 		}
+		// Every block spends the ETXs which were available after its parent
+		etxSet := p.hc.GetEtxSet(current.ParentHash(nodeCtx), current.NumberU64(nodeCtx)-1)
+		if etxSet == nil {
+			return nil, fmt.Errorf("etx set for block %d not found", current.NumberU64(nodeCtx)-1)
+		}
 		_, _, _, _, err := p.Process(current, etxSet)
 		if err != nil {
-			return nil, fmt.Errorf("processing block %d failed: %v", current.NumberU64(), err)
+			return nil, fmt.Errorf("processing block %d failed: %v", current.NumberU64(nodeCtx), err)
 		}
 		// Finalize the state so any modifications are written to the trie
 		root, err := statedb.Commit(true)
 		if err != nil {
 			return nil, fmt.Errorf("stateAtBlock commit failed, number %d root %v: %w",
-				current.NumberU64(), current.Root().Hex(), err)
+				current.NumberU64(nodeCtx), current.Root().Hex(), err)
 		}
 		statedb, err = state.New(root, database, nil)
 		if err != nil {
-			return nil, fmt.Errorf("state reset after block %d failed: %v", current.NumberU64(), err)
+			return nil, fmt.Errorf("state reset after block %d failed: %v", current.NumberU64(nodeCtx), err)
 		}
 		database.TrieDB().Reference(root, common.Hash{})
 		if parent != (common.Hash{}) {
@@ -707,21 +749,22 @@ func (p *StateProcessor) StateAtBlock(block *types.Block, reexec uint64, base *s
 	}
 	if report {
 		nodes, imgs := database.TrieDB().Size()
-		log.Info("Historical state regenerated", "block", current.NumberU64(), "elapsed", time.Since(start), "nodes", nodes, "preimages", imgs)
+		log.Info("Historical state regenerated", "block", current.NumberU64(nodeCtx), "elapsed", time.Since(start), "nodes", nodes, "preimages", imgs)
 	}
 	return statedb, nil
 }
 
 // stateAtTransaction returns the execution environment of a certain transaction.
 func (p *StateProcessor) StateAtTransaction(block *types.Block, txIndex int, reexec uint64) (Message, vm.BlockContext, *state.StateDB, error) {
+	nodeCtx := p.hc.NodeCtx()
 	// Short circuit if it's genesis block.
-	if block.NumberU64() == 0 {
+	if block.NumberU64(nodeCtx) == 0 {
 		return nil, vm.BlockContext{}, nil, errors.New("no transaction in genesis")
 	}
 	// Create the parent state database
-	parent := p.hc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	parent := p.hc.GetBlock(block.ParentHash(nodeCtx), block.NumberU64(nodeCtx)-1)
 	if parent == nil {
-		return nil, vm.BlockContext{}, nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+		return nil, vm.BlockContext{}, nil, fmt.Errorf("parent %#x not found", block.ParentHash(nodeCtx))
 	}
 	// Lookup the statedb of parent block from the live database,
 	// otherwise regenerate it on the flight.
@@ -733,7 +776,7 @@ func (p *StateProcessor) StateAtTransaction(block *types.Block, txIndex int, ree
 		return nil, vm.BlockContext{}, statedb, nil
 	}
 	// Recompute transactions up to the target index.
-	signer := types.MakeSigner(p.hc.Config(), block.Number())
+	signer := types.MakeSigner(p.hc.Config(), block.Number(nodeCtx), block.Number(common.PRIME_CTX))
 	for idx, tx := range block.Transactions() {
 		// Assemble the transaction call message and return if the requested offset
 		msg, _ := tx.AsMessage(signer, block.BaseFee())
@@ -745,7 +788,14 @@ func (p *StateProcessor) StateAtTransaction(block *types.Block, txIndex int, ree
 		// Not yet the searched for transaction, execute on top of the current state
 		vmenv := vm.NewEVM(context, txContext, statedb, p.hc.Config(), vm.Config{})
 		statedb.Prepare(tx.Hash(), idx)
-		if _, err := ApplyMessage(vmenv, msg, new(GasPool).AddGas(tx.Gas())); err != nil {
+		if tx.Type() == types.ExternalTxType {
+			prevZeroBal := PrepareApplyETX(statedb, tx)
+			_, err := ApplyMessage(vmenv, msg, new(GasPool).AddGas(tx.Gas()))
+			statedb.SetBalance(common.ZeroInternal, prevZeroBal)
+			if err != nil {
+				return nil, vm.BlockContext{}, nil, fmt.Errorf("external transaction %#x failed: %v", tx.Hash(), err)
+			}
+		} else if _, err := ApplyMessage(vmenv, msg, new(GasPool).AddGas(tx.Gas())); err != nil {
 			return nil, vm.BlockContext{}, nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
 		}
 		// Ensure any modifications are committed to the state
@@ -755,6 +805,7 @@ func (p *StateProcessor) StateAtTransaction(block *types.Block, txIndex int, ree
 }
 
 func (p *StateProcessor) Stop() {
+	nodeCtx := p.hc.NodeCtx()
 	// Ensure that the entirety of the state snapshot is journalled to disk.
 	var snapBase common.Hash
 	if p.snaps != nil {
@@ -772,10 +823,10 @@ func (p *StateProcessor) Stop() {
 		triedb := p.stateCache.TrieDB()
 
 		for _, offset := range []uint64{0, 1, TriesInMemory - 1} {
-			if number := p.hc.CurrentBlock().NumberU64(); number > offset {
+			if number := p.hc.CurrentBlock().NumberU64(nodeCtx); number > offset {
 				recent := p.hc.GetBlockByNumber(number - offset)
 
-				log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
+				log.Info("Writing cached state to disk", "block", recent.Number(nodeCtx), "hash", recent.Hash(), "root", recent.Root())
 				if err := triedb.Commit(recent.Root(), true, nil); err != nil {
 					log.Error("Failed to commit recent state trie", "err", err)
 				}
@@ -804,7 +855,11 @@ func (p *StateProcessor) Stop() {
 	log.Info("State Processor stopped")
 }
 
-func prepareApplyETX(statedb *state.StateDB, tx *types.Transaction) *big.Int {
+// PrepareApplyETX funds the zero address with the value and gas of an inbound
+// external transaction, which is the sender of the ETX in this chain. It
+// returns the previous balance of the zero address, which the caller must
+// restore once the ETX has been applied.
+func PrepareApplyETX(statedb *state.StateDB, tx *types.Transaction) *big.Int {
 	prevZeroBal := statedb.GetBalance(common.ZeroInternal)   // Get current zero address balance
 	fee := big.NewInt(0).Add(tx.GasFeeCap(), tx.GasTipCap()) // Add gas price cap to miner tip cap
 	fee.Mul(fee, big.NewInt(int64(tx.Gas())))                // Multiply gas price by gas limit (may need to check for int64 overflow)
@@ -74,8 +74,8 @@ func TestPrecompile(t *testing.T) {
 		gasLimit   = GasPool(params.GenesisGasLimit)
 	)
 	t.Log(addr)
-	common.NodeLocation = *addr.Location()
-	t.Log(common.NodeLocation.Name())
+	params.TestChainConfig.Location = *addr.Location()
+	t.Log(params.TestChainConfig.Location.Name())
 	vm.InitializePrecompiles()
 
 	toAddr := common.BytesToAddress([]byte{1})
@@ -83,7 +83,7 @@ func TestPrecompile(t *testing.T) {
 	t.Log(location.Name())
 	params.TestChainConfig.GenesisHash = genesis.Hash()
 	blocks, _ := GenerateChain(params.TestChainConfig, genesis, progpow.NewFaker(), testdb, 2, gen)
-	internal, _ := addr.InternalAddress()
+	internal, _ := addr.InternalAddress(params.TestChainConfig.Location)
 	statedb.AddBalance(internal, big.NewInt(params.Ether*2)) // give me 2 eth
 	mockContext := MockChainContext{blocks}
 
@@ -136,7 +136,7 @@ func TestCreateETX(t *testing.T) {
 		statedb = b.statedb
 	}
 	testKey, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
-	common.NodeLocation = *crypto.PubkeyToAddress(testKey.PublicKey).Location()
+	params.TestChainConfig.Location = *crypto.PubkeyToAddress(testKey.PublicKey).Location()
 	addr := crypto.PubkeyToAddress(testKey.PublicKey)
 	var (
 		testdb = rawdb.NewMemoryDatabase()
@@ -159,12 +159,12 @@ func TestCreateETX(t *testing.T) {
 	)
 	t.Log(addr)
 	addr = common.BytesToAddress(addr.Bytes()) // reset address because we changed location
-	internal, err := addr.InternalAddress()
+	internal, err := addr.InternalAddress(params.TestChainConfig.Location)
 	if err != nil {
 		t.Error(err.Error())
 		t.Fail()
 	}
-	t.Log(common.NodeLocation.Name())
+	t.Log(params.TestChainConfig.Location.Name())
 	toAddr := common.HexToAddress("0x3C97734DfD0376b0b1a57f48e2049A092fD89058")
 	location := toAddr.Location()
 	t.Log(location.Name())
@@ -201,7 +201,7 @@ func TestExternalTokenTransfer(t *testing.T) {
 		statedb = b.statedb
 	}
 	testKey, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
-	common.NodeLocation = *crypto.PubkeyToAddress(testKey.PublicKey).Location()
+	params.TestChainConfig.Location = *crypto.PubkeyToAddress(testKey.PublicKey).Location()
 	addr := crypto.PubkeyToAddress(testKey.PublicKey)
 	var (
 		testdb = rawdb.NewMemoryDatabase()
@@ -224,12 +224,12 @@ func TestExternalTokenTransfer(t *testing.T) {
 	)
 	t.Log(addr)
 	addr = common.BytesToAddress(addr.Bytes()) // reset address because we changed location
-	internal, err := addr.InternalAddress()
+	internal, err := addr.InternalAddress(params.TestChainConfig.Location)
 	if err != nil {
 		t.Error(err.Error())
 		t.Fail()
 	}
-	t.Log(common.NodeLocation.Name())
+	t.Log(params.TestChainConfig.Location.Name())
 	location := common.HexToAddress("0x3C97734DfD0376b0b1a57f48e2049A092fD89058").Location()
 	t.Log(location.Name())
 	params.TestChainConfig.GenesisHash = genesis.Hash()
@@ -249,7 +249,7 @@ func TestExternalTokenTransfer(t *testing.T) {
 	for {
 		contract[len(contract)-1] = i
 		contractAddr = crypto.CreateAddress(addr, nonce, contract)
-		if common.IsInChainScope(contractAddr.Bytes()) {
+		if common.IsInChainScope(contractAddr.Bytes(), params.TestChainConfig.Location) {
 			break
 		}
 		i++
@@ -407,9 +407,9 @@ func TestInboundETX(t *testing.T) {
 		gasLimit = GasPool(params.GenesisGasLimit)
 		zero     = uint64(0)
 	)
-	common.NodeLocation = *to.Location()
+	params.TestChainConfig.Location = *to.Location()
 	to = common.BytesToAddress(to.Bytes()) // reset address because we changed location
-	internal, err := to.InternalAddress()
+	internal, err := to.InternalAddress(params.TestChainConfig.Location)
 	if err != nil {
 		t.Error(err.Error())
 		t.Fail()
@@ -422,8 +422,8 @@ func TestInboundETX(t *testing.T) {
 	etx := types.ExternalTx{Nonce: 0, GasTipCap: common.Big1, GasFeeCap: common.Big1, Gas: 100000, To: &to, Value: big.NewInt(500000000000000000), Data: []byte{}, AccessList: nil, Sender: sender}
 	tx := types.NewTx(&etx)
 	transactions := types.Transactions{tx}
-	etxSet.Update(transactions, lastBlock.NumberU64())
-	rawdb.WriteEtxSet(testdb, lastBlock.Hash(), lastBlock.NumberU64(), etxSet)
+	etxSet.Update(transactions, lastBlock.NumberU64(common.ZONE_CTX))
+	rawdb.WriteEtxSet(testdb, lastBlock.Hash(), lastBlock.NumberU64(common.ZONE_CTX), etxSet)
 	receipt, err := ApplyTransaction(params.TestChainConfig, mockContext, &common.ZeroAddr, &gasLimit, statedb, lastBlock.Header(), tx, &zero, vm.Config{NoBaseFee: true})
 	if err != nil {
 		t.Error(err.Error())
@@ -470,7 +470,7 @@ func TestInvalidInboundETX(t *testing.T) {
 		gasLimit = GasPool(params.GenesisGasLimit)
 		zero     = uint64(0)
 	)
-	common.NodeLocation = *to.Location()
+	params.TestChainConfig.Location = *to.Location()
 	params.TestChainConfig.GenesisHash = genesis.Hash()
 	blocks, _ := GenerateChain(params.TestChainConfig, genesis, progpow.NewFaker(), testdb, 2, gen)
 	mockContext := MockChainContext{blocks}
@@ -492,7 +492,7 @@ func TestOpETX(t *testing.T) {
 		statedb = b.statedb
 	}
 	testKey, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
-	common.NodeLocation = *crypto.PubkeyToAddress(testKey.PublicKey).Location()
+	params.TestChainConfig.Location = *crypto.PubkeyToAddress(testKey.PublicKey).Location()
 	addr := crypto.PubkeyToAddress(testKey.PublicKey)
 	var (
 		testdb = rawdb.NewMemoryDatabase()
@@ -516,7 +516,7 @@ func TestOpETX(t *testing.T) {
 	t.Log(addr)
 
 	addr = common.BytesToAddress(addr.Bytes()) // reset address because we changed location
-	internal, err := addr.InternalAddress()
+	internal, err := addr.InternalAddress(params.TestChainConfig.Location)
 	if err != nil {
 		t.Error(err.Error())
 		t.Fail()
@@ -537,7 +537,7 @@ func TestOpETX(t *testing.T) {
 	for {
 		contract[len(contract)-1] = i
 		contractAddr := crypto.CreateAddress(addr, nonce, contract)
-		if common.IsInChainScope(contractAddr.Bytes()) {
+		if common.IsInChainScope(contractAddr.Bytes(), params.TestChainConfig.Location) {
 			break
 		}
 		i++
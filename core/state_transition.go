@@ -206,7 +206,7 @@ func (st *StateTransition) buyGas() error {
 		balanceCheck = balanceCheck.Mul(balanceCheck, st.gasFeeCap)
 		balanceCheck.Add(balanceCheck, st.value)
 	}
-	from, err := st.msg.From().InternalAddress()
+	from, err := st.evm.ChainConfig().InternalAddress(st.msg.From())
 	if err != nil {
 		return err
 	}
@@ -224,7 +224,7 @@ func (st *StateTransition) buyGas() error {
 }
 
 func (st *StateTransition) preCheck() error {
-	from, err := st.msg.From().InternalAddress()
+	from, err := st.evm.ChainConfig().InternalAddress(st.msg.From())
 	if err != nil {
 		return err
 	}
@@ -312,12 +312,12 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	st.gas -= gas
 
 	// Check clause 6
-	if msg.Value().Sign() > 0 && !st.evm.Context.CanTransfer(st.state, msg.From(), msg.Value()) {
+	if msg.Value().Sign() > 0 && !st.evm.Context.CanTransfer(st.state, msg.From(), msg.Value(), st.evm.ChainConfig()) {
 		return nil, fmt.Errorf("%w: address %v", ErrInsufficientFundsForTransfer, msg.From().Hex())
 	}
 
 	// Set up the initial access list.
-	rules := st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber)
+	rules := st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber, st.evm.Context.PrimeNumber)
 	st.state.PrepareAccessList(msg.From(), msg.To(), vm.ActivePrecompiles(rules), msg.AccessList())
 
 	var (
@@ -328,11 +328,11 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		ret, _, st.gas, vmerr = st.evm.Create(sender, st.data, st.gas, st.value)
 	} else {
 		// Increment the nonce for the next transaction
-		addr, err := sender.Address().InternalAddress()
+		addr, err := st.evm.ChainConfig().InternalAddress(sender.Address())
 		if err != nil {
 			return nil, err
 		}
-		from, err := msg.From().InternalAddress()
+		from, err := st.evm.ChainConfig().InternalAddress(msg.From())
 		if err != nil {
 			return nil, err
 		}
@@ -351,7 +351,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	st.refundGas(params.RefundQuotient)
 
 	effectiveTip := cmath.BigMin(st.gasTipCap, new(big.Int).Sub(st.gasFeeCap, st.evm.Context.BaseFee))
-	coinbase, err := st.evm.Context.Coinbase.InternalAddress()
+	coinbase, err := st.evm.ChainConfig().InternalAddress(st.evm.Context.Coinbase)
 	if err != nil {
 		return nil, err
 	}
@@ -375,7 +375,7 @@ func (st *StateTransition) refundGas(refundQuotient uint64) {
 
 	// Return ETH for remaining gas, exchanged at the original rate.
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
-	from, err := st.msg.From().InternalAddress()
+	from, err := st.evm.ChainConfig().InternalAddress(st.msg.From())
 	if err != nil {
 		return
 	}
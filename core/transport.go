@@ -0,0 +1,250 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	interfaces "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/quaiclient"
+	"github.com/dominant-strategies/go-quai/rpc"
+)
+
+const (
+	c_linkFailureThreshold = 3 // Number of consecutive failed calls before a link is reported unhealthy
+)
+
+// The websocket client is the default transport between dom and sub nodes.
+var (
+	_ DomClient = (*quaiclient.Client)(nil)
+	_ SubClient = (*quaiclient.Client)(nil)
+	_ DomClient = (*LocalClient)(nil)
+	_ SubClient = (*LocalClient)(nil)
+)
+
+// LinkHealth reports the state of the link between a slice and one of its dom
+// or sub chains. A link is connected until a call fails to reach the other
+// chain, and connected again once a call gets through.
+type LinkHealth struct {
+	Connected   bool      `json:"connected"`
+	Healthy     bool      `json:"healthy"`
+	Failures    uint64    `json:"failures"` // Number of consecutive failed calls
+	LastSuccess time.Time `json:"lastSuccess"`
+	LastFailure time.Time `json:"lastFailure"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// TransportHealth reports the state of all the links of a slice. Dom is nil in
+// prime and Subs is empty in a zone; a nil sub entry means no sub is attached
+// at that index.
+type TransportHealth struct {
+	Dom  *LinkHealth   `json:"dom,omitempty"`
+	Subs []*LinkHealth `json:"subs,omitempty"`
+}
+
+// linkMonitor records the outcome of the calls made over a single link.
+type linkMonitor struct {
+	health LinkHealth
+	local  bool // In-process links cannot disconnect
+	mu     sync.RWMutex
+}
+
+func newLinkMonitor(local bool) *linkMonitor {
+	return &linkMonitor{health: LinkHealth{Connected: true, Healthy: true}, local: local}
+}
+
+// isLocalTransport reports whether a transport calls into a chain of the same
+// process rather than going over the network.
+func isLocalTransport(client interface{}) bool {
+	switch client.(type) {
+	case *LocalClient, *archiveClient:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTransportError reports whether a call failed to reach the other chain, as
+// opposed to being answered with an error by it.
+func isTransportError(err error) bool {
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// report records the result of a call made over the link.
+func (m *linkMonitor) report(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		m.health.Connected = true
+		m.health.Failures = 0
+		m.health.LastSuccess = time.Now()
+	} else {
+		if !m.local && isTransportError(err) {
+			m.health.Connected = false
+		}
+		m.health.Failures++
+		m.health.LastFailure = time.Now()
+		m.health.LastError = err.Error()
+		if m.health.Failures == c_linkFailureThreshold {
+			log.Warn("Link to neighbouring chain is unhealthy", "failures", m.health.Failures, "err", err)
+		}
+	}
+	m.health.Healthy = m.health.Failures < c_linkFailureThreshold
+}
+
+// Health returns a snapshot of the link state.
+func (m *linkMonitor) Health() LinkHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.health
+}
+
+// monitoredDomClient wraps a DomClient and records the result of every call.
+type monitoredDomClient struct {
+	client  DomClient
+	monitor *linkMonitor
+}
+
+func (c *monitoredDomClient) SendPendingEtxsToDom(ctx context.Context, pEtxs types.PendingEtxs) error {
+	err := c.client.SendPendingEtxsToDom(ctx, pEtxs)
+	c.monitor.report(err)
+	return err
+}
+
+func (c *monitoredDomClient) SendPendingEtxsRollupToDom(ctx context.Context, pEtxsRollup types.PendingEtxsRollup) error {
+	err := c.client.SendPendingEtxsRollupToDom(ctx, pEtxsRollup)
+	c.monitor.report(err)
+	return err
+}
+
+func (c *monitoredDomClient) EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error) {
+	gas, err := c.client.EstimateExternalGas(ctx, msg)
+	c.monitor.report(err)
+	return gas, err
+}
+
+func (c *monitoredDomClient) GetTerminiByHash(ctx context.Context, hash common.Hash) ([]common.Hash, error) {
+	termini, err := c.client.GetTerminiByHash(ctx, hash)
+	c.monitor.report(err)
+	return termini, err
+}
+
+// monitoredSubClient wraps a SubClient and records the result of every call.
+type monitoredSubClient struct {
+	client  SubClient
+	monitor *linkMonitor
+}
+
+func (c *monitoredSubClient) Append(ctx context.Context, header *types.Header, domPendingHeader *types.Header, domTerminus common.Hash, domOrigin bool, newInboundEtxs types.Transactions) (types.Transactions, bool, error) {
+	pendingEtxs, subReorg, err := c.client.Append(ctx, header, domPendingHeader, domTerminus, domOrigin, newInboundEtxs)
+	c.monitor.report(err)
+	return pendingEtxs, subReorg, err
+}
+
+func (c *monitoredSubClient) SubRelayPendingHeader(ctx context.Context, pendingHeader types.PendingHeader, location common.Location) error {
+	err := c.client.SubRelayPendingHeader(ctx, pendingHeader, location)
+	c.monitor.report(err)
+	return err
+}
+
+func (c *monitoredSubClient) NewGenesisPendingHeader(ctx context.Context, header *types.Header) error {
+	err := c.client.NewGenesisPendingHeader(ctx, header)
+	c.monitor.report(err)
+	return err
+}
+
+func (c *monitoredSubClient) GetManifest(ctx context.Context, blockHash common.Hash) (types.BlockManifest, error) {
+	manifest, err := c.client.GetManifest(ctx, blockHash)
+	c.monitor.report(err)
+	return manifest, err
+}
+
+func (c *monitoredSubClient) GetTerminiByHash(ctx context.Context, hash common.Hash) ([]common.Hash, error) {
+	termini, err := c.client.GetTerminiByHash(ctx, hash)
+	c.monitor.report(err)
+	return termini, err
+}
+
+func (c *monitoredSubClient) GenerateRecoveryPendingHeader(ctx context.Context, pendingHeader *types.Header, checkpointHashes []common.Hash) error {
+	err := c.client.GenerateRecoveryPendingHeader(ctx, pendingHeader, checkpointHashes)
+	c.monitor.report(err)
+	return err
+}
+
+func (c *monitoredSubClient) UpdateBlockLists(ctx context.Context, update types.BlockListUpdate) error {
+	err := c.client.UpdateBlockLists(ctx, update)
+	c.monitor.report(err)
+	return err
+}
+
+func (c *monitoredSubClient) EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error) {
+	gas, err := c.client.EstimateExternalGas(ctx, msg)
+	c.monitor.report(err)
+	return gas, err
+}
+
+// LocalClient is an in-memory transport which links a slice directly to
+// another Core running in the same process, without going through RPC. It is
+// mostly useful to test the dom/sub flows without spinning up RPC servers.
+// Headers are copied before being handed over, so the two slices never share
+// mutable state, just like they would not over the wire.
+type LocalClient struct {
+	core *Core
+}
+
+// NewLocalClient creates a transport to the given core.
+func NewLocalClient(core *Core) *LocalClient {
+	return &LocalClient{core: core}
+}
+
+func (c *LocalClient) Append(ctx context.Context, header *types.Header, domPendingHeader *types.Header, domTerminus common.Hash, domOrigin bool, newInboundEtxs types.Transactions) (types.Transactions, bool, error) {
+	return c.core.Append(types.CopyHeader(header), types.CopyHeader(domPendingHeader), domTerminus, domOrigin, newInboundEtxs)
+}
+
+func (c *LocalClient) SubRelayPendingHeader(ctx context.Context, pendingHeader types.PendingHeader, location common.Location) error {
+	c.core.SubRelayPendingHeader(types.PendingHeader{Header: types.CopyHeader(pendingHeader.Header), Termini: append([]common.Hash{}, pendingHeader.Termini...)}, location)
+	return nil
+}
+
+func (c *LocalClient) NewGenesisPendingHeader(ctx context.Context, header *types.Header) error {
+	c.core.NewGenesisPendigHeader(types.CopyHeader(header))
+	return nil
+}
+
+func (c *LocalClient) GetManifest(ctx context.Context, blockHash common.Hash) (types.BlockManifest, error) {
+	return c.core.GetManifest(blockHash)
+}
+
+func (c *LocalClient) GetTerminiByHash(ctx context.Context, hash common.Hash) ([]common.Hash, error) {
+	termini := c.core.GetTerminiByHash(hash)
+	if termini == nil {
+		return nil, nil
+	}
+	return append([]common.Hash{}, termini...), nil
+}
+
+func (c *LocalClient) SendPendingEtxsToDom(ctx context.Context, pEtxs types.PendingEtxs) error {
+	return c.core.AddPendingEtxs(types.PendingEtxs{Header: types.CopyHeader(pEtxs.Header), Etxs: pEtxs.Etxs})
+}
+
+func (c *LocalClient) SendPendingEtxsRollupToDom(ctx context.Context, pEtxsRollup types.PendingEtxsRollup) error {
+	return c.core.AddPendingEtxsRollup(types.PendingEtxsRollup{Header: types.CopyHeader(pEtxsRollup.Header), Manifest: pEtxsRollup.Manifest})
+}
+
+func (c *LocalClient) GenerateRecoveryPendingHeader(ctx context.Context, pendingHeader *types.Header, checkpointHashes []common.Hash) error {
+	return c.core.GenerateRecoveryPendingHeader(types.CopyHeader(pendingHeader), checkpointHashes)
+}
+
+func (c *LocalClient) UpdateBlockLists(ctx context.Context, update types.BlockListUpdate) error {
+	return c.core.UpdateBlockLists(update, true)
+}
+
+func (c *LocalClient) EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error) {
+	return c.core.EstimateExternalGas(ctx, msg)
+}
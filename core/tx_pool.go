@@ -165,6 +165,8 @@ type TxPoolConfig struct {
 	GlobalQueue     uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Synchronous bool `toml:"-"` // Ignore chain head events, the pool is reset via SyncHead instead
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -262,6 +264,9 @@ type TxPool struct {
 
 	reOrgCounter int // keeps track of the number of times the runReorg is called, it is reset every c_reorgCounterThreshold times
 
+	syncHead *types.Header // Last head the pool was reset to by SyncHead
+	syncMu   sync.Mutex    // Serialises synchronous head resets
+
 	chainHeadCh     chan ChainHeadEvent
 	chainHeadSub    event.Subscription
 	reqResetCh      chan *txpoolResetRequest
@@ -310,13 +315,14 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		remoteTxsCount:  0,
 		reOrgCounter:    0,
 	}
-	pool.locals = newAccountSet(pool.signer)
+	pool.locals = newAccountSet(pool.signer, pool.chainconfig)
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
 	pool.priced = newTxPricedList(pool.all)
-	pool.reset(nil, chain.CurrentBlock().Header())
+	pool.syncHead = chain.CurrentBlock().Header()
+	pool.reset(nil, pool.syncHead)
 
 	// Start the reorg loop early so it can handle requests generated during journal loading.
 	pool.wg.Add(1)
@@ -364,7 +370,7 @@ func (pool *TxPool) loop() {
 		select {
 		// Handle ChainHeadEvent
 		case ev := <-pool.chainHeadCh:
-			if ev.Block != nil {
+			if ev.Block != nil && !pool.config.Synchronous {
 				pool.requestReset(head.Header(), ev.Block.Header())
 				head = ev.Block
 			}
@@ -432,6 +438,19 @@ func (pool *TxPool) Stop() {
 	log.Info("Transaction pool stopped")
 }
 
+// SyncHead resets the pool to a new chain head and waits until the reset has
+// been applied. It is used instead of chain head events by synchronous pools.
+func (pool *TxPool) SyncHead(head *types.Header) {
+	pool.syncMu.Lock()
+	defer pool.syncMu.Unlock()
+
+	if pool.syncHead.Hash() == head.Hash() {
+		return
+	}
+	<-pool.requestReset(pool.syncHead, head)
+	pool.syncHead = head
+}
+
 // SubscribeNewTxsEvent registers a subscription of NewTxsEvent and
 // starts sending event to the given channel.
 func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscription {
@@ -566,7 +585,7 @@ func (pool *TxPool) TxPoolPending(enforceTips bool, etxSet types.EtxSet) (map[co
 	for _, entry := range etxSet {
 		addr := entry.ETX.ETXSender()
 		tx := entry.ETX
-		if tx.ETXSender().Location().Equal(common.NodeLocation) { // Sanity check
+		if tx.ETXSender().Location(pool.chainconfig.GetTopology()).Equal(pool.chainconfig.Location) { // Sanity check
 			log.Error("ETX sender is in our location!", "tx", tx.Hash().String(), "sender", tx.ETXSender().String())
 			continue // skip this tx
 		}
@@ -635,7 +654,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	addToCache := true
 	if sender := tx.From(); sender != nil { // Check tx cache first
 		var err error
-		internal, err = sender.InternalAddress()
+		internal, err = pool.chainconfig.InternalAddress(*sender)
 		if err != nil {
 			return err
 		}
@@ -648,7 +667,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		if err != nil {
 			return ErrInvalidSender
 		}
-		internal, err = from.InternalAddress()
+		internal, err = pool.chainconfig.InternalAddress(from)
 		if err != nil {
 			return err
 		}
@@ -743,7 +762,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	}
 	// Try to replace an existing transaction in the pending pool
 	from, _ := types.Sender(pool.signer, tx) // already validated
-	internal, err := from.InternalAddress()
+	internal, err := pool.chainconfig.InternalAddress(from)
 	if err != nil {
 		return false, err
 	}
@@ -796,7 +815,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local bool, addAll bool) (bool, error) {
 	// Try to insert the transaction into the future queue
 	from, _ := types.Sender(pool.signer, tx) // already validated
-	internal, err := from.InternalAddress()
+	internal, err := pool.chainconfig.InternalAddress(from)
 	if err != nil {
 		return false, err
 	}
@@ -951,7 +970,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 		// obtaining lock
 		if sender := tx.From(); sender != nil {
 			var err error
-			_, err = sender.InternalAddress()
+			_, err = pool.chainconfig.InternalAddress(*sender)
 			if err != nil {
 				errs[i] = err
 				invalidTxMeter.Mark(1)
@@ -966,7 +985,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 				invalidTxMeter.Mark(1)
 				continue
 			}
-			_, err = from.InternalAddress()
+			_, err = pool.chainconfig.InternalAddress(from)
 			if err != nil {
 				errs[i] = ErrInvalidSender
 				invalidTxMeter.Mark(1)
@@ -1005,7 +1024,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 // addTxsLocked attempts to queue a batch of transactions if they are valid.
 // The transaction pool lock must be held.
 func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error, *accountSet) {
-	dirty := newAccountSet(pool.signer)
+	dirty := newAccountSet(pool.signer, pool.chainconfig)
 	errs := make([]error, len(txs))
 	for i, tx := range txs {
 		replaced, err := pool.add(tx, local)
@@ -1028,7 +1047,7 @@ func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
 			continue
 		}
 		from, _ := types.Sender(pool.signer, tx) // already validated
-		internal, err := from.InternalAddress()
+		internal, err := pool.chainconfig.InternalAddress(from)
 		if err != nil {
 			continue
 		}
@@ -1065,7 +1084,7 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 		return
 	}
 	addr, _ := types.Sender(pool.signer, tx) // already validated during insertion
-	internal, err := addr.InternalAddress()
+	internal, err := pool.chainconfig.InternalAddress(addr)
 	if err != nil {
 		return
 	}
@@ -1196,7 +1215,7 @@ func (pool *TxPool) scheduleReorgLoop() {
 			// Queue up the event, but don't schedule a reorg. It's up to the caller to
 			// request one later if they want the events sent.
 			addr, _ := types.Sender(pool.signer, tx)
-			internal, err := addr.InternalAddress()
+			internal, err := pool.chainconfig.InternalAddress(addr)
 			if err != nil {
 				log.Debug("Failed to queue transaction", "err", err)
 				continue
@@ -1287,7 +1306,7 @@ func (pool *TxPool) runReorg(done chan struct{}, cancel chan struct{}, reset *tx
 			// Notify subsystems for newly added transactions
 			for _, tx := range promoted {
 				addr, _ := types.Sender(pool.signer, tx)
-				internal, err := addr.InternalAddress()
+				internal, err := pool.chainconfig.InternalAddress(addr)
 				if err != nil {
 					log.Debug("Failed to add transaction event", "err", err)
 					continue
@@ -1316,6 +1335,7 @@ func (pool *TxPool) runReorg(done chan struct{}, cancel chan struct{}, reset *tx
 // reset retrieves the current state of the blockchain and ensures the content
 // of the transaction pool is valid with regard to the chain state.
 func (pool *TxPool) reset(oldHead, newHead *types.Header) {
+	nodeCtx := pool.chainconfig.Location.Context()
 	var start time.Time
 	if pool.reOrgCounter == c_reorgCounterThreshold {
 		start = time.Now()
@@ -1323,10 +1343,10 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// If we're reorging an old state, reinject all dropped transactions
 	var reinject types.Transactions
 
-	if oldHead != nil && oldHead.Hash() != newHead.ParentHash() {
+	if oldHead != nil && oldHead.Hash() != newHead.ParentHash(nodeCtx) {
 		// If the reorg is too deep, avoid doing it (will happen during fast sync)
-		oldNum := oldHead.Number().Uint64()
-		newNum := newHead.Number().Uint64()
+		oldNum := oldHead.Number(nodeCtx).Uint64()
+		newNum := newHead.Number(nodeCtx).Uint64()
 
 		if depth := uint64(math.Abs(float64(oldNum) - float64(newNum))); depth > 64 {
 			log.Debug("Skipping deep transaction reorg", "depth", depth)
@@ -1334,8 +1354,8 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 			// Reorg seems shallow enough to pull in all transactions into memory
 			var discarded, included types.Transactions
 			var (
-				rem = pool.chain.GetBlock(oldHead.Hash(), oldHead.Number().Uint64())
-				add = pool.chain.GetBlockFromCacheOrDb(newHead.Hash(), newHead.Number().Uint64())
+				rem = pool.chain.GetBlock(oldHead.Hash(), oldHead.Number(nodeCtx).Uint64())
+				add = pool.chain.GetBlockFromCacheOrDb(newHead.Hash(), newHead.Number(nodeCtx).Uint64())
 			)
 			if rem == nil {
 				// This can happen if a setHead is performed, where we simply discard the old
@@ -1353,29 +1373,29 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 					"old", oldHead.Hash(), "oldnum", oldNum, "new", newHead.Hash(), "newnum", newNum)
 				// We still need to update the current state s.th. the lost transactions can be readded by the user
 			} else {
-				for rem.NumberU64() > add.NumberU64() {
+				for rem.NumberU64(nodeCtx) > add.NumberU64(nodeCtx) {
 					discarded = append(discarded, rem.Transactions()...)
-					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
-						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number(), "hash", oldHead.Hash())
+					if rem = pool.chain.GetBlock(rem.ParentHash(nodeCtx), rem.NumberU64(nodeCtx)-1); rem == nil {
+						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number(nodeCtx), "hash", oldHead.Hash())
 						return
 					}
 				}
-				for add.NumberU64() > rem.NumberU64() {
+				for add.NumberU64(nodeCtx) > rem.NumberU64(nodeCtx) {
 					included = append(included, add.Transactions()...)
-					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
-						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number(), "hash", newHead.Hash())
+					if add = pool.chain.GetBlock(add.ParentHash(nodeCtx), add.NumberU64(nodeCtx)-1); add == nil {
+						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number(nodeCtx), "hash", newHead.Hash())
 						return
 					}
 				}
 				for rem.Hash() != add.Hash() {
 					discarded = append(discarded, rem.Transactions()...)
-					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
-						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number(), "hash", oldHead.Hash())
+					if rem = pool.chain.GetBlock(rem.ParentHash(nodeCtx), rem.NumberU64(nodeCtx)-1); rem == nil {
+						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number(nodeCtx), "hash", oldHead.Hash())
 						return
 					}
 					included = append(included, add.Transactions()...)
-					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
-						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number(), "hash", newHead.Hash())
+					if add = pool.chain.GetBlock(add.ParentHash(nodeCtx), add.NumberU64(nodeCtx)-1); add == nil {
+						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number(nodeCtx), "hash", newHead.Hash())
 						return
 					}
 				}
@@ -1748,15 +1768,17 @@ func (a addressesByHeartbeat) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 type accountSet struct {
 	accounts map[common.InternalAddress]struct{}
 	signer   types.Signer
+	config   *params.ChainConfig // config of the chain the accounts belong to
 	cache    *[]common.InternalAddress
 }
 
 // newAccountSet creates a new address set with an associated signer for sender
 // derivations.
-func newAccountSet(signer types.Signer, addrs ...common.InternalAddress) *accountSet {
+func newAccountSet(signer types.Signer, config *params.ChainConfig, addrs ...common.InternalAddress) *accountSet {
 	as := &accountSet{
 		accounts: make(map[common.InternalAddress]struct{}),
 		signer:   signer,
+		config:   config,
 	}
 	for _, addr := range addrs {
 		as.add(addr)
@@ -1778,7 +1800,7 @@ func (as *accountSet) empty() bool {
 // cannot be derived, this method returns false.
 func (as *accountSet) containsTx(tx *types.Transaction) bool {
 	if addr, err := types.Sender(as.signer, tx); err == nil {
-		internal, err := addr.InternalAddress()
+		internal, err := as.config.InternalAddress(addr)
 		if err != nil {
 			return false
 		}
@@ -1796,7 +1818,7 @@ func (as *accountSet) add(addr common.InternalAddress) {
 // addTx adds the sender of tx into the set.
 func (as *accountSet) addTx(tx *types.Transaction) {
 	if addr, err := types.Sender(as.signer, tx); err == nil {
-		internal, err := addr.InternalAddress()
+		internal, err := as.config.InternalAddress(addr)
 		if err != nil {
 			log.Debug("Failed to add tx to account set", "err", err)
 			return
@@ -17,6 +17,10 @@
 package core
 
 import (
+	"context"
+
+	interfaces "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/core/state"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/core/vm"
@@ -50,3 +54,55 @@ type Processor interface {
 	Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error)
 	Apply(block *types.Block) error
 }
+
+// DomClient is the transport a slice uses to talk to its dominant chain.
+type DomClient interface {
+	// SendPendingEtxsToDom shares the pending ETXs emitted by a block with the
+	// dominant chain, so they can be referenced by a coincident dom block.
+	SendPendingEtxsToDom(ctx context.Context, pEtxs types.PendingEtxs) error
+
+	// SendPendingEtxsRollupToDom shares the manifest of a block with the
+	// dominant chain, so it can roll up the pending ETXs of the sub chain.
+	SendPendingEtxsRollupToDom(ctx context.Context, pEtxsRollup types.PendingEtxsRollup) error
+
+	// EstimateExternalGas estimates the gas an external transaction needs in
+	// its destination zone, forwarding the request towards it.
+	EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error)
+
+	// GetTerminiByHash returns the termini the dominant chain stored for the
+	// block with the given hash, or nil if it never appended the block.
+	GetTerminiByHash(ctx context.Context, hash common.Hash) ([]common.Hash, error)
+}
+
+// SubClient is the transport a slice uses to talk to one of its subordinate
+// chains.
+type SubClient interface {
+	// Append appends a dom coincident block to the subordinate chain and returns
+	// the pending ETXs it emitted and whether the sub reorged.
+	Append(ctx context.Context, header *types.Header, domPendingHeader *types.Header, domTerminus common.Hash, domOrigin bool, newInboundEtxs types.Transactions) (types.Transactions, bool, error)
+
+	// SubRelayPendingHeader relays an updated pending header to the subordinate.
+	SubRelayPendingHeader(ctx context.Context, pendingHeader types.PendingHeader, location common.Location) error
+
+	// NewGenesisPendingHeader hands the genesis pending header to the subordinate.
+	NewGenesisPendingHeader(ctx context.Context, header *types.Header) error
+
+	// GetManifest returns the manifest of the subordinate block with the given hash.
+	GetManifest(ctx context.Context, blockHash common.Hash) (types.BlockManifest, error)
+
+	// GetTerminiByHash returns the termini the subordinate stored for the block
+	// with the given hash, or nil if it never appended the block.
+	GetTerminiByHash(ctx context.Context, hash common.Hash) ([]common.Hash, error)
+
+	// GenerateRecoveryPendingHeader asks the subordinate to rebuild its pending
+	// header from the given checkpoint hashes.
+	GenerateRecoveryPendingHeader(ctx context.Context, pendingHeader *types.Header, checkpointHashes []common.Hash) error
+
+	// UpdateBlockLists changes the bad hashes and the checkpoints of the
+	// subordinate, or of the chains below it.
+	UpdateBlockLists(ctx context.Context, update types.BlockListUpdate) error
+
+	// EstimateExternalGas estimates the gas an external transaction needs in
+	// its destination zone, forwarding the request towards it.
+	EstimateExternalGas(ctx context.Context, msg interfaces.CallMsg) (uint64, error)
+}
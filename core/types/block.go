@@ -101,6 +101,7 @@ type Header struct {
 	extra         []byte          `json:"extraData"            gencodec:"required"`
 	mixHash       common.Hash     `json:"mixHash"              gencodec:"required"`
 	nonce         BlockNonce      `json:"nonce"`
+	etxSetRoot    common.Hash     `json:"etxSetRoot"`
 
 	// caches
 	hash      atomic.Value
@@ -146,6 +147,7 @@ type extheader struct {
 	Extra         []byte
 	MixHash       common.Hash
 	Nonce         BlockNonce
+	EtxSetRoot    common.Hash `rlp:"optional"`
 }
 
 // Construct an empty header
@@ -201,6 +203,7 @@ func (h *Header) DecodeRLP(s *rlp.Stream) error {
 	h.extra = eh.Extra
 	h.mixHash = eh.MixHash
 	h.nonce = eh.Nonce
+	h.etxSetRoot = eh.EtxSetRoot
 
 	return nil
 }
@@ -229,6 +232,7 @@ func (h *Header) EncodeRLP(w io.Writer) error {
 		Extra:         h.extra,
 		MixHash:       h.mixHash,
 		Nonce:         h.nonce,
+		EtxSetRoot:    h.etxSetRoot,
 	})
 }
 
@@ -271,16 +275,15 @@ func (h *Header) RPCMarshalHeader() map[string]interface{} {
 	if h.BaseFee() != nil {
 		result["baseFeePerGas"] = (*hexutil.Big)(h.BaseFee())
 	}
+	if h.EtxSetRoot() != (common.Hash{}) {
+		result["etxSetRoot"] = h.EtxSetRoot()
+	}
 
 	return result
 }
 
 // Localized accessors
-func (h *Header) ParentHash(args ...int) common.Hash {
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
+func (h *Header) ParentHash(nodeCtx int) common.Hash {
 	return h.parentHash[nodeCtx]
 }
 func (h *Header) UncleHash() common.Hash {
@@ -301,25 +304,16 @@ func (h *Header) EtxHash() common.Hash {
 func (h *Header) EtxRollupHash() common.Hash {
 	return h.etxRollupHash
 }
-func (h *Header) ParentEntropy(args ...int) *big.Int {
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
+func (h *Header) EtxSetRoot() common.Hash {
+	return h.etxSetRoot
+}
+func (h *Header) ParentEntropy(nodeCtx int) *big.Int {
 	return h.parentEntropy[nodeCtx]
 }
-func (h *Header) ParentDeltaS(args ...int) *big.Int {
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
+func (h *Header) ParentDeltaS(nodeCtx int) *big.Int {
 	return h.parentDeltaS[nodeCtx]
 }
-func (h *Header) ManifestHash(args ...int) common.Hash {
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
+func (h *Header) ManifestHash(nodeCtx int) common.Hash {
 	return h.manifestHash[nodeCtx]
 }
 func (h *Header) ReceiptHash() common.Hash {
@@ -328,18 +322,10 @@ func (h *Header) ReceiptHash() common.Hash {
 func (h *Header) Difficulty() *big.Int {
 	return h.difficulty
 }
-func (h *Header) Number(args ...int) *big.Int {
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
+func (h *Header) Number(nodeCtx int) *big.Int {
 	return h.number[nodeCtx]
 }
-func (h *Header) NumberU64(args ...int) uint64 {
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
+func (h *Header) NumberU64(nodeCtx int) uint64 {
 	return h.number[nodeCtx].Uint64()
 }
 func (h *Header) GasLimit() uint64 {
@@ -358,13 +344,9 @@ func (h *Header) MixHash() common.Hash      { return h.mixHash }
 func (h *Header) Nonce() BlockNonce         { return h.nonce }
 func (h *Header) NonceU64() uint64          { return binary.BigEndian.Uint64(h.nonce[:]) }
 
-func (h *Header) SetParentHash(val common.Hash, args ...int) {
+func (h *Header) SetParentHash(val common.Hash, nodeCtx int) {
 	h.hash = atomic.Value{}     // clear hash cache
 	h.sealHash = atomic.Value{} // clear sealHash cache
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
 	h.parentHash[nodeCtx] = val
 }
 func (h *Header) SetUncleHash(val common.Hash) {
@@ -397,34 +379,27 @@ func (h *Header) SetEtxRollupHash(val common.Hash) {
 	h.sealHash = atomic.Value{} // clear sealHash cache
 	h.etxRollupHash = val
 }
+func (h *Header) SetEtxSetRoot(val common.Hash) {
+	h.hash = atomic.Value{}     // clear hash cache
+	h.sealHash = atomic.Value{} // clear sealHash cache
+	h.etxSetRoot = val
+}
 
-func (h *Header) SetParentEntropy(val *big.Int, args ...int) {
+func (h *Header) SetParentEntropy(val *big.Int, nodeCtx int) {
 	h.hash = atomic.Value{}     // clear hash cache
 	h.sealHash = atomic.Value{} // clear sealHash cache
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
 	h.parentEntropy[nodeCtx] = val
 }
 
-func (h *Header) SetParentDeltaS(val *big.Int, args ...int) {
+func (h *Header) SetParentDeltaS(val *big.Int, nodeCtx int) {
 	h.hash = atomic.Value{}     // clear hash cache
 	h.sealHash = atomic.Value{} // clear sealHash cache
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
 	h.parentDeltaS[nodeCtx] = val
 }
 
-func (h *Header) SetManifestHash(val common.Hash, args ...int) {
+func (h *Header) SetManifestHash(val common.Hash, nodeCtx int) {
 	h.hash = atomic.Value{}     // clear hash cache
 	h.sealHash = atomic.Value{} // clear sealHash cache
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
 	h.manifestHash[nodeCtx] = val
 }
 func (h *Header) SetReceiptHash(val common.Hash) {
@@ -437,13 +412,9 @@ func (h *Header) SetDifficulty(val *big.Int) {
 	h.sealHash = atomic.Value{} // clear sealHash cache
 	h.difficulty = new(big.Int).Set(val)
 }
-func (h *Header) SetNumber(val *big.Int, args ...int) {
+func (h *Header) SetNumber(val *big.Int, nodeCtx int) {
 	h.hash = atomic.Value{}     // clear hash cache
 	h.sealHash = atomic.Value{} // clear sealHash cache
-	nodeCtx := common.NodeLocation.Context()
-	if len(args) > 0 {
-		nodeCtx = args[0]
-	}
 	h.number[nodeCtx] = new(big.Int).Set(val)
 }
 func (h *Header) SetGasLimit(val uint64) {
@@ -514,6 +485,7 @@ type sealData struct {
 	Time          uint64
 	Extra         []byte
 	Nonce         BlockNonce
+	EtxSetRoot    common.Hash `rlp:"optional"`
 }
 
 // SealHash returns the hash of a block prior to it being sealed.
@@ -542,6 +514,7 @@ func (h *Header) SealHash() (hash common.Hash) {
 		Location:      h.Location(),
 		Time:          h.Time(),
 		Extra:         h.Extra(),
+		EtxSetRoot:    h.EtxSetRoot(),
 	}
 	for i := 0; i < common.HierarchyDepth; i++ {
 		hdata.ParentHash[i] = h.ParentHash(i)
@@ -640,8 +613,8 @@ func (h *Header) SanityCheck() error {
 
 // EmptyBody returns true if there is no additional 'body' to complete the header
 // that is: no transactions and no uncles.
-func (h *Header) EmptyBody() bool {
-	return h.EmptyTxs() && h.EmptyUncles() && h.EmptyEtxs() && h.EmptyManifest()
+func (h *Header) EmptyBody(nodeCtx int) bool {
+	return h.EmptyTxs() && h.EmptyUncles() && h.EmptyEtxs() && h.EmptyManifest(nodeCtx)
 }
 
 // EmptyTxs returns true if there are no txs for this header/block.
@@ -660,8 +633,8 @@ func (h *Header) EmptyEtxRollup() bool {
 }
 
 // EmptyTxs returns true if there are no txs for this header/block.
-func (h *Header) EmptyManifest() bool {
-	return h.ManifestHash() == EmptyRootHash
+func (h *Header) EmptyManifest(nodeCtx int) bool {
+	return h.ManifestHash(nodeCtx) == EmptyRootHash
 }
 
 // EmptyUncles returns true if there are no uncles for this header/block.
@@ -710,8 +683,7 @@ type extblock struct {
 	SubManifest BlockManifest
 }
 
-func NewBlock(header *Header, txs []*Transaction, uncles []*Header, etxs []*Transaction, subManifest BlockManifest, receipts []*Receipt, hasher TrieHasher) *Block {
-	nodeCtx := common.NodeLocation.Context()
+func NewBlock(header *Header, txs []*Transaction, uncles []*Header, etxs []*Transaction, subManifest BlockManifest, receipts []*Receipt, hasher TrieHasher, nodeCtx int) *Block {
 	b := &Block{header: CopyHeader(header)}
 
 	// TODO: panic if len(txs) != len(receipts)
@@ -793,6 +765,7 @@ func CopyHeader(h *Header) *Header {
 	cpy.SetTxHash(h.TxHash())
 	cpy.SetEtxHash(h.EtxHash())
 	cpy.SetEtxRollupHash(h.EtxRollupHash())
+	cpy.SetEtxSetRoot(h.EtxSetRoot())
 	cpy.SetReceiptHash(h.ReceiptHash())
 	if len(h.extra) > 0 {
 		cpy.extra = make([]byte, len(h.extra))
@@ -832,20 +805,21 @@ func (b *Block) EncodeRLP(w io.Writer) error {
 }
 
 // Wrapped header accessors
-func (b *Block) ParentHash(args ...int) common.Hash   { return b.header.ParentHash(args...) }
+func (b *Block) ParentHash(nodeCtx int) common.Hash   { return b.header.ParentHash(nodeCtx) }
 func (b *Block) UncleHash() common.Hash               { return b.header.UncleHash() }
 func (b *Block) Coinbase() common.Address             { return b.header.Coinbase() }
 func (b *Block) Root() common.Hash                    { return b.header.Root() }
 func (b *Block) TxHash() common.Hash                  { return b.header.TxHash() }
 func (b *Block) EtxHash() common.Hash                 { return b.header.EtxHash() }
 func (b *Block) EtxRollupHash() common.Hash           { return b.header.EtxRollupHash() }
-func (b *Block) ManifestHash(args ...int) common.Hash { return b.header.ManifestHash(args...) }
+func (b *Block) EtxSetRoot() common.Hash              { return b.header.EtxSetRoot() }
+func (b *Block) ManifestHash(nodeCtx int) common.Hash { return b.header.ManifestHash(nodeCtx) }
 func (b *Block) ReceiptHash() common.Hash             { return b.header.ReceiptHash() }
 func (b *Block) Difficulty(args ...int) *big.Int      { return b.header.Difficulty() }
-func (b *Block) ParentEntropy(args ...int) *big.Int   { return b.header.ParentEntropy(args...) }
-func (b *Block) ParentDeltaS(args ...int) *big.Int    { return b.header.ParentDeltaS(args...) }
-func (b *Block) Number(args ...int) *big.Int          { return b.header.Number(args...) }
-func (b *Block) NumberU64(args ...int) uint64         { return b.header.NumberU64(args...) }
+func (b *Block) ParentEntropy(nodeCtx int) *big.Int   { return b.header.ParentEntropy(nodeCtx) }
+func (b *Block) ParentDeltaS(nodeCtx int) *big.Int    { return b.header.ParentDeltaS(nodeCtx) }
+func (b *Block) Number(nodeCtx int) *big.Int          { return b.header.Number(nodeCtx) }
+func (b *Block) NumberU64(nodeCtx int) uint64         { return b.header.NumberU64(nodeCtx) }
 func (b *Block) GasLimit() uint64                     { return b.header.GasLimit() }
 func (b *Block) GasUsed() uint64                      { return b.header.GasUsed() }
 func (b *Block) BaseFee() *big.Int                    { return b.header.BaseFee() }
@@ -980,10 +954,8 @@ func CopyPendingHeader(ph *PendingHeader) *PendingHeader {
 	cpy := *ph
 	cpy.Header = CopyHeader(ph.Header)
 
-	cpy.Termini = make([]common.Hash, 4)
-	for i, termini := range ph.Termini {
-		cpy.Termini[i] = termini
-	}
+	cpy.Termini = make([]common.Hash, len(ph.Termini))
+	copy(cpy.Termini, ph.Termini)
 
 	return &cpy
 }
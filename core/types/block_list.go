@@ -0,0 +1,74 @@
+package types
+
+import (
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+)
+
+// BadHash is a block of the chain at the given location which must not be
+// part of it.
+type BadHash struct {
+	Location common.Location `json:"location"`
+	Hash     common.Hash     `json:"hash"`
+}
+
+// Checkpoint is a block trusted to be part of the chain at the given location.
+// Any other block of that chain at the same number is rejected.
+type Checkpoint struct {
+	Location common.Location `json:"location"`
+	Number   hexutil.Uint64  `json:"number"`
+	Hash     common.Hash     `json:"hash"`
+}
+
+// BlockListUpdate changes the bad hashes and the checkpoints of chains of the
+// hierarchy. Checkpoints are removed by number, regardless of their hash.
+type BlockListUpdate struct {
+	AddBadHashes      []BadHash    `json:"addBadHashes,omitempty"`
+	RemoveBadHashes   []BadHash    `json:"removeBadHashes,omitempty"`
+	AddCheckpoints    []Checkpoint `json:"addCheckpoints,omitempty"`
+	RemoveCheckpoints []Checkpoint `json:"removeCheckpoints,omitempty"`
+}
+
+// Empty reports whether the update changes nothing.
+func (u *BlockListUpdate) Empty() bool {
+	return len(u.AddBadHashes) == 0 && len(u.RemoveBadHashes) == 0 && len(u.AddCheckpoints) == 0 && len(u.RemoveCheckpoints) == 0
+}
+
+// Locations returns the locations of the chains the update targets.
+func (u *BlockListUpdate) Locations() []common.Location {
+	var locations []common.Location
+	for _, entry := range append(u.AddBadHashes, u.RemoveBadHashes...) {
+		locations = append(locations, entry.Location)
+	}
+	for _, entry := range append(u.AddCheckpoints, u.RemoveCheckpoints...) {
+		locations = append(locations, entry.Location)
+	}
+	return locations
+}
+
+// Filter returns the part of the update targeting the chains for which the
+// given function returns true.
+func (u *BlockListUpdate) Filter(include func(common.Location) bool) BlockListUpdate {
+	var filtered BlockListUpdate
+	for _, entry := range u.AddBadHashes {
+		if include(entry.Location) {
+			filtered.AddBadHashes = append(filtered.AddBadHashes, entry)
+		}
+	}
+	for _, entry := range u.RemoveBadHashes {
+		if include(entry.Location) {
+			filtered.RemoveBadHashes = append(filtered.RemoveBadHashes, entry)
+		}
+	}
+	for _, entry := range u.AddCheckpoints {
+		if include(entry.Location) {
+			filtered.AddCheckpoints = append(filtered.AddCheckpoints, entry)
+		}
+	}
+	for _, entry := range u.RemoveCheckpoints {
+		if include(entry.Location) {
+			filtered.RemoveCheckpoints = append(filtered.RemoveCheckpoints, entry)
+		}
+	}
+	return filtered
+}
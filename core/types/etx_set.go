@@ -1,8 +1,12 @@
 package types
 
 import (
+	"bytes"
+	"sort"
+
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/params"
+	"github.com/dominant-strategies/go-quai/rlp"
 )
 
 // The EtxSet maps an ETX hash to the ETX and block number in which it became available.
@@ -20,11 +24,11 @@ func NewEtxSet() EtxSet {
 
 // updateInboundEtxs updates the set of inbound ETXs available to be mined into
 // a block in this location. This method adds any new ETXs to the set and
-// removes expired ETXs.
-func (set *EtxSet) Update(newInboundEtxs Transactions, currentHeight uint64) {
+// removes expired ETXs, whose hashes are returned.
+func (set *EtxSet) Update(newInboundEtxs Transactions, currentHeight uint64, nodeLocation common.Location, topology *common.Topology) []common.Hash {
 	// Add new ETX entries to the inbound set
 	for _, etx := range newInboundEtxs {
-		if etx.To().Location().Equal(common.NodeLocation) {
+		if etx.To().Location(topology).Equal(nodeLocation) {
 			(*set)[etx.Hash()] = EtxSetEntry{currentHeight, *etx}
 		} else {
 			panic("cannot add ETX destined to other chain to our ETX set")
@@ -32,11 +36,64 @@ func (set *EtxSet) Update(newInboundEtxs Transactions, currentHeight uint64) {
 	}
 
 	// Remove expired ETXs
+	var expired []common.Hash
 	for txHash, entry := range *set {
 		availableAtBlock := entry.Height
 		etxExpirationHeight := availableAtBlock + params.EtxExpirationAge
 		if currentHeight > etxExpirationHeight {
 			delete(*set, txHash)
+			expired = append(expired, txHash)
 		}
 	}
+	return expired
+}
+
+// Copy returns a shallow copy of the set. Entries are values, so the copy can
+// be updated without affecting the original set.
+func (set EtxSet) Copy() EtxSet {
+	cpy := make(EtxSet, len(set))
+	for hash, entry := range set {
+		cpy[hash] = entry
+	}
+	return cpy
+}
+
+// Root computes the root of the trie mapping every ETX hash in the set to the
+// height at which it became available.
+func (set EtxSet) Root(hasher TrieHasher) common.Hash {
+	hashes := make([]common.Hash, 0, len(set))
+	for hash := range set {
+		hashes = append(hashes, hash)
+	}
+	// The stack trie needs the keys in increasing order
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+
+	hasher.Reset()
+	var value []byte
+	for _, hash := range hashes {
+		value = rlp.AppendUint64(value[:0], set[hash].Height)
+		hasher.Update(hash[:], value)
+	}
+	return hasher.Hash()
+}
+
+// EtxSetDiff is the change a block applies to the EtxSet of its parent. Only
+// the diff is stored for most blocks; the full set is rebuilt by applying the
+// diffs on top of the closest stored set. The root only lets the node check the
+// sets it rebuilds against the ones it wrote, it is not part of consensus.
+type EtxSetDiff struct {
+	Root    common.Hash   // Root of the set after applying the diff, not committed to by any header
+	Added   Transactions  // ETXs which became available in the block
+	Removed []common.Hash // ETXs which were spent or expired in the block
+}
+
+// Apply applies a block diff at the given height to the set. Additions are
+// applied first, as an ETX may become available and be spent in the same block.
+func (set EtxSet) Apply(diff *EtxSetDiff, height uint64) {
+	for _, etx := range diff.Added {
+		set[etx.Hash()] = EtxSetEntry{height, *etx}
+	}
+	for _, hash := range diff.Removed {
+		delete(set, hash)
+	}
 }
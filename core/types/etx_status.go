@@ -0,0 +1,125 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// EtxStage is the lifecycle stage of an external transaction on its way from
+// the origin zone to the destination zone. Stages only ever move forward along
+// a chain, but may be observed in blocks which end up on a side chain.
+type EtxStage uint8
+
+const (
+	EtxUnknown    EtxStage = iota // Not seen by this node
+	EtxEmitted                    // Emitted by a block in the origin zone
+	EtxRolledUp                   // Rolled up into the pending ETXs of a dom block
+	EtxReferenced                 // Confirmed by a coincident dom block manifest
+	EtxAvailable                  // Added to the EtxSet of the destination zone
+	EtxExecuted                   // Included in a block of the destination zone
+	EtxExpired                    // Dropped from the destination EtxSet without being included
+)
+
+var etxStageNames = []string{"unknown", "emitted", "rolledUp", "referenced", "available", "executed", "expired"}
+
+func (s EtxStage) String() string {
+	if int(s) < len(etxStageNames) {
+		return etxStageNames[s]
+	}
+	return fmt.Sprintf("EtxStage(%d)", s)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s EtxStage) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// EtxStatus is the lifecycle of an ETX as observed by a single slice. A slice
+// only witnesses the stages which happen in its own chain, so the origin zone
+// knows where the ETX was emitted, the dom chains where it was rolled up and
+// referenced, and the destination zone where it became available and where it
+// was executed or expired.
+type EtxStatus struct {
+	Stage EtxStage
+
+	OriginBlock    common.Hash // Block of the origin zone which emitted the ETX
+	OriginNumber   uint64
+	OriginLocation common.Location
+
+	RollupBlock    common.Hash // Dom block which rolled up the ETX from its sub
+	RollupNumber   uint64
+	RollupLocation common.Location
+
+	DomBlock    common.Hash // Coincident dom block which confirmed the ETX
+	DomNumber   uint64
+	DomLocation common.Location
+
+	AvailableBlock  common.Hash // Destination block whose EtxSet first held the ETX
+	AvailableHeight uint64
+
+	InclusionBlock  common.Hash // Destination block which executed the ETX
+	InclusionNumber uint64
+
+	ExpiryBlock  common.Hash // Destination block whose EtxSet dropped the ETX
+	ExpiryHeight uint64
+}
+
+// Advance moves the status forward to the given stage. Going back to an
+// earlier stage is a no-op, so observations can be recorded in any order.
+func (s *EtxStatus) Advance(stage EtxStage) {
+	if stage > s.Stage {
+		s.Stage = stage
+	}
+}
+
+// Block returns the block the given stage was observed in, or an empty hash if
+// this slice did not observe it.
+func (s *EtxStatus) Block(stage EtxStage) (common.Hash, uint64) {
+	switch stage {
+	case EtxEmitted:
+		return s.OriginBlock, s.OriginNumber
+	case EtxRolledUp:
+		return s.RollupBlock, s.RollupNumber
+	case EtxReferenced:
+		return s.DomBlock, s.DomNumber
+	case EtxAvailable:
+		return s.AvailableBlock, s.AvailableHeight
+	case EtxExecuted:
+		return s.InclusionBlock, s.InclusionNumber
+	case EtxExpired:
+		return s.ExpiryBlock, s.ExpiryHeight
+	}
+	return common.Hash{}, 0
+}
+
+// Revert forgets the observations accepted by the given filter and moves the
+// status back to the latest stage still observed, e.g. once the blocks of some
+// stages left the canonical chain.
+func (s *EtxStatus) Revert(revert func(stage EtxStage, block common.Hash, number uint64) bool) {
+	s.Stage = EtxUnknown
+	for stage := EtxEmitted; stage <= EtxExpired; stage++ {
+		block, number := s.Block(stage)
+		if block == (common.Hash{}) {
+			continue
+		}
+		if !revert(stage, block, number) {
+			s.Stage = stage
+			continue
+		}
+		switch stage {
+		case EtxEmitted:
+			s.OriginBlock, s.OriginNumber, s.OriginLocation = common.Hash{}, 0, nil
+		case EtxRolledUp:
+			s.RollupBlock, s.RollupNumber, s.RollupLocation = common.Hash{}, 0, nil
+		case EtxReferenced:
+			s.DomBlock, s.DomNumber, s.DomLocation = common.Hash{}, 0, nil
+		case EtxAvailable:
+			s.AvailableBlock, s.AvailableHeight = common.Hash{}, 0
+		case EtxExecuted:
+			s.InclusionBlock, s.InclusionNumber = common.Hash{}, 0
+		case EtxExpired:
+			s.ExpiryBlock, s.ExpiryHeight = common.Hash{}, 0
+		}
+	}
+}
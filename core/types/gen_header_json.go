@@ -37,6 +37,7 @@ func (h Header) MarshalJSON() ([]byte, error) {
 		Extra         hexutil.Bytes  `json:"extraData"           gencodec:"required"`
 		MixHash       common.Hash    `json:"mixHash"             gencodec:"required"`
 		Nonce         BlockNonce     `json:"nonce"`
+		EtxSetRoot    common.Hash    `json:"etxSetRoot"`
 		Hash          common.Hash    `json:"hash"`
 	}
 	// Initialize the enc struct
@@ -67,6 +68,7 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.Extra = hexutil.Bytes(h.Extra())
 	enc.MixHash = h.MixHash()
 	enc.Nonce = h.Nonce()
+	enc.EtxSetRoot = h.EtxSetRoot()
 	enc.Hash = h.Hash()
 	raw, err := json.Marshal(&enc)
 	return raw, err
@@ -96,6 +98,7 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		Extra         hexutil.Bytes   `json:"extraData"           gencodec:"required"`
 		MixHash       *common.Hash    `json:"MixHash"             gencodec:"required"`
 		Nonce         BlockNonce      `json:"nonce"`
+		EtxSetRoot    *common.Hash    `json:"etxSetRoot"`
 	}
 	if err := json.Unmarshal(input, &dec); err != nil {
 		return err
@@ -196,5 +199,8 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 	h.SetExtra(dec.Extra)
 	h.SetMixHash(*dec.MixHash)
 	h.SetNonce(dec.Nonce)
+	if dec.EtxSetRoot != nil {
+		h.SetEtxSetRoot(*dec.EtxSetRoot)
+	}
 	return nil
 }
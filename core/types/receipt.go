@@ -339,9 +339,10 @@ func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 }
 
 // DeriveFields fills the receipts with their computed fields based on consensus
-// data and contextual infos like containing block and transactions.
-func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, number uint64, txs Transactions) error {
-	signer := MakeSigner(config, new(big.Int).SetUint64(number))
+// data and contextual infos like containing block and transactions. The prime
+// number of the block selects the signer along with its number.
+func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, number uint64, primeNumber *big.Int, txs Transactions) error {
+	signer := MakeSigner(config, new(big.Int).SetUint64(number), primeNumber)
 
 	logIndex := uint(0)
 	if len(txs) != len(r) {
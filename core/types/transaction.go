@@ -357,8 +357,9 @@ func (tx *Transaction) Hash() common.Hash {
 	return h
 }
 
-// FromChain returns the chain location this transaction originated from
-func (tx *Transaction) FromChain() common.Location {
+// FromChain returns the chain location of the given topology this transaction
+// originated from
+func (tx *Transaction) FromChain(topology *common.Topology) common.Location {
 	if loc := tx.fromChain.Load(); loc != nil {
 		return loc.(common.Location)
 	}
@@ -367,7 +368,7 @@ func (tx *Transaction) FromChain() common.Location {
 	case ExternalTxType:
 		// External transactions do not have a signature, but instead store the
 		// sender explicitly. Use that sender to get the location.
-		loc = *tx.inner.(*ExternalTx).Sender.Location()
+		loc = *tx.inner.(*ExternalTx).Sender.Location(topology)
 	default:
 		// All other TX types are signed, and should use the signature to determine
 		// the sender location
@@ -376,7 +377,7 @@ func (tx *Transaction) FromChain() common.Location {
 		if err != nil {
 			panic("failed to get transaction sender!")
 		}
-		loc = *from.Location()
+		loc = *from.Location(topology)
 	}
 	tx.fromChain.Store(loc)
 	return loc
@@ -384,12 +385,12 @@ func (tx *Transaction) FromChain() common.Location {
 
 // ConfirmationCtx indicates the chain context at which this ETX becomes
 // confirmed and referencable to the destination chain
-func (tx *Transaction) ConfirmationCtx() int {
+func (tx *Transaction) ConfirmationCtx(topology *common.Topology) int {
 	if ctx := tx.confirmCtx.Load(); ctx != nil {
 		return ctx.(int)
 	}
 
-	ctx := tx.To().Location().CommonDom(tx.FromChain()).Context()
+	ctx := tx.To().Location(topology).CommonDom(tx.FromChain(topology)).Context()
 	tx.confirmCtx.Store(ctx)
 	return ctx
 }
@@ -433,11 +434,11 @@ func (s Transactions) EncodeIndex(i int, w *bytes.Buffer) {
 }
 
 // FilterByLocation returns the subset of transactions with a 'to' address which
-// belongs the given chain location
-func (s Transactions) FilterToLocation(l common.Location) Transactions {
+// belongs the given chain location of the topology
+func (s Transactions) FilterToLocation(l common.Location, topology *common.Topology) Transactions {
 	filteredList := Transactions{}
 	for _, tx := range s {
-		toChain := *tx.To().Location()
+		toChain := *tx.To().Location(topology)
 		if l.Equal(toChain) {
 			filteredList = append(filteredList, tx)
 		}
@@ -447,10 +448,10 @@ func (s Transactions) FilterToLocation(l common.Location) Transactions {
 
 // FilterToSlice returns the subset of transactions with a 'to' address which
 // belongs to the given slice location, at or above the given minimum context
-func (s Transactions) FilterToSlice(slice common.Location, minCtx int) Transactions {
+func (s Transactions) FilterToSlice(slice common.Location, minCtx int, topology *common.Topology) Transactions {
 	filteredList := Transactions{}
 	for _, tx := range s {
-		toChain := tx.To().Location()
+		toChain := tx.To().Location(topology)
 		if toChain.InSameSliceAs(slice) {
 			filteredList = append(filteredList, tx)
 		}
@@ -460,10 +461,10 @@ func (s Transactions) FilterToSlice(slice common.Location, minCtx int) Transacti
 
 // FilterConfirmationCtx returns the subset of transactions who can be confirmed
 // at the given context
-func (s Transactions) FilterConfirmationCtx(ctx int) Transactions {
+func (s Transactions) FilterConfirmationCtx(ctx int, topology *common.Topology) Transactions {
 	filteredList := Transactions{}
 	for _, tx := range s {
-		if tx.ConfirmationCtx() == ctx {
+		if tx.ConfirmationCtx(topology) == ctx {
 			filteredList = append(filteredList, tx)
 		}
 	}
@@ -663,6 +664,28 @@ func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *b
 	}
 }
 
+// NewInternalToExternalMessage creates a message which emits an external
+// transaction towards another chain, described by the etx fields.
+func NewInternalToExternalMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice, gasFeeCap, gasTipCap *big.Int, data []byte, accessList AccessList, etxGasLimit uint64, etxGasPrice, etxGasTip *big.Int, etxData []byte, etxAccessList AccessList, checkNonce bool) Message {
+	msg := NewMessage(from, to, nonce, amount, gasLimit, gasPrice, gasFeeCap, gasTipCap, data, accessList, checkNonce)
+	msg.txtype = InternalToExternalTxType
+	msg.etxGasLimit = etxGasLimit
+	msg.etxGasPrice = etxGasPrice
+	msg.etxGasTip = etxGasTip
+	msg.etxData = etxData
+	msg.etxAccessList = etxAccessList
+	return msg
+}
+
+// NewExternalMessage creates a message which executes an external transaction
+// of the given sender, as it is applied in its destination chain.
+func NewExternalMessage(sender common.Address, to *common.Address, amount *big.Int, gasLimit uint64, gasPrice, gasFeeCap, gasTipCap *big.Int, data []byte, accessList AccessList) Message {
+	msg := NewMessage(common.ZeroAddr, to, 0, amount, gasLimit, gasPrice, gasFeeCap, gasTipCap, data, accessList, false)
+	msg.txtype = ExternalTxType
+	msg.etxsender = sender
+	return msg
+}
+
 // AsMessage returns the transaction as a core.Message.
 func (tx *Transaction) AsMessage(s Signer, baseFee *big.Int) (Message, error) {
 	msg := Message{
@@ -30,6 +30,7 @@ import (
 var (
 	ErrUnsupportedTxType = errors.New("tx type is not supported by this signer")
 	ErrInvalidChainId    = errors.New("invalid chain id for signer")
+	ErrSenderOutOfScope  = errors.New("sender is not in the scope of the signer")
 )
 
 // sigCache is used to cache the derived sender and contains
@@ -39,8 +40,13 @@ type sigCache struct {
 	from   common.Address
 }
 
-// MakeSigner returns a Signer based on the given chain config and block number.
-func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
+// MakeSigner returns a Signer based on the given chain config and the number of
+// the block in the context of the chain and in prime.
+func MakeSigner(config *params.ChainConfig, blockNumber, primeNumber *big.Int) Signer {
+	rules := config.Rules(blockNumber, primeNumber)
+	if rules.IsScope && rules.Location.Context() == common.ZONE_CTX {
+		return NewSignerV2(config.ChainID, rules.Location, rules.Topology)
+	}
 	return NewSigner(config.ChainID)
 }
 
@@ -245,6 +251,40 @@ func (s SignerV1) ChainID() *big.Int {
 	return s.chainId
 }
 
+// SignerV2 is the signer of the scope fork. It only recovers senders owned by
+// the zone it signs for, so that a transaction cannot be included by a zone it
+// cannot be executed in.
+type SignerV2 struct {
+	SignerV1
+	location common.Location
+	topology *common.Topology
+}
+
+// NewSignerV2 instantiates a signer for the zone at the given location.
+func NewSignerV2(chainId *big.Int, location common.Location, topology *common.Topology) Signer {
+	return SignerV2{
+		SignerV1: NewSigner(chainId).(SignerV1),
+		location: location,
+		topology: topology,
+	}
+}
+
+func (s SignerV2) Sender(tx *Transaction) (common.Address, error) {
+	addr, err := s.SignerV1.Sender(tx)
+	if err != nil || tx.Type() == ExternalTxType {
+		return addr, err
+	}
+	if !s.topology.IsInChainScope(addr.Bytes(), s.location) {
+		return common.ZeroAddr, ErrSenderOutOfScope
+	}
+	return addr, nil
+}
+
+func (s SignerV2) Equal(s2 Signer) bool {
+	x, ok := s2.(SignerV2)
+	return ok && x.chainId.Cmp(s.chainId) == 0 && x.location.Equal(s.location) && x.topology == s.topology
+}
+
 func decodeSignature(sig []byte) (r, s, v *big.Int) {
 	if len(sig) != crypto.SignatureLength {
 		panic(fmt.Sprintf("wrong size for signature: got %d, want %d", len(sig), crypto.SignatureLength))
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/ethdb"
+	"github.com/dominant-strategies/go-quai/params"
+	"github.com/dominant-strategies/go-quai/trie"
+)
+
+// DatabaseFault is an inconsistency between a canonical block and the
+// hierarchy data stored for it.
+type DatabaseFault struct {
+	Number uint64
+	Hash   common.Hash
+	Err    error
+}
+
+func (f DatabaseFault) Error() string {
+	return fmt.Sprintf("block #%d [%x]: %v", f.Number, f.Hash, f.Err)
+}
+
+// VerifyDatabase walks the canonical chain of the given chain configuration up
+// to the head block, checking that the termini, manifest and pending etxs
+// records of every block match its header. Pending etxs records which are not
+// stored are not reported, as they are not kept for every block. Each fault is
+// passed to the callback, and the walk is aborted if the callback returns an
+// error. It returns the number of blocks checked.
+func VerifyDatabase(db ethdb.Reader, config *params.ChainConfig, fault func(DatabaseFault) error) (uint64, error) {
+	var (
+		nodeCtx = config.Location.Context()
+		hasher  = trie.NewStackTrie(nil)
+	)
+	head := rawdb.ReadHeadBlockHash(db)
+	number := rawdb.ReadHeaderNumber(db, head)
+	if number == nil {
+		return 0, errors.New("head block missing")
+	}
+	parentTermini := rawdb.ReadTermini(db, config.GenesisHash)
+	for n := uint64(1); n <= *number; n++ {
+		hash := rawdb.ReadCanonicalHash(db, n)
+		header := rawdb.ReadHeader(db, hash, n)
+		report := func(err error) error {
+			return fault(DatabaseFault{Number: n, Hash: hash, Err: err})
+		}
+		if header == nil {
+			if err := report(errors.New("canonical header missing")); err != nil {
+				return n - 1, err
+			}
+			parentTermini = nil
+			continue
+		}
+		termini := rawdb.ReadTermini(db, hash)
+		if err := verifyTermini(config, header, termini, parentTermini); err != nil {
+			if err := report(err); err != nil {
+				return n, err
+			}
+		}
+		parentTermini = termini
+
+		// The manifest of the parent lists the subordinate blocks the header
+		// commits to. Prime blocks have no manifest as they are never coincident
+		// with a dom chain.
+		if nodeCtx > common.PRIME_CTX {
+			manifest := rawdb.ReadManifest(db, header.ParentHash(nodeCtx))
+			if manifest == nil {
+				if err := report(errors.New("parent manifest missing")); err != nil {
+					return n, err
+				}
+			} else if types.DeriveSha(manifest, hasher) != header.ManifestHash(nodeCtx) {
+				if err := report(errors.New("parent manifest does not match manifest hash")); err != nil {
+					return n, err
+				}
+			}
+		}
+		// Check the pending etxs of the block and of the subordinate blocks it
+		// references
+		subs := []common.Hash{hash}
+		if nodeCtx < common.ZONE_CTX {
+			subs = append(subs, rawdb.ReadManifest(db, hash)...)
+		}
+		for _, sub := range subs {
+			if err := verifyPendingEtxs(db, sub, hasher); err != nil {
+				if err := report(err); err != nil {
+					return n, err
+				}
+			}
+		}
+	}
+	return *number, nil
+}
+
+// verifyTermini checks the termini of a block were derived from the termini of
+// its parent, as done by the slice when appending the block.
+func verifyTermini(config *params.ChainConfig, header *types.Header, termini, parentTermini []common.Hash) error {
+	nodeCtx := config.Location.Context()
+	if len(termini) != terminusIndex(config)+1 {
+		return fmt.Errorf("termini length mismatch: have %d, want %d", len(termini), terminusIndex(config)+1)
+	}
+	if len(parentTermini) != len(termini) {
+		// Nothing to compare against, the fault was reported for the parent
+		return nil
+	}
+	hash := header.Hash()
+	subIndex := header.Location().SubIndex(nodeCtx)
+	for i, terminus := range termini {
+		switch {
+		case i == subIndex:
+			if terminus != hash {
+				return fmt.Errorf("subordinate terminus %d mismatch: have %x, want %x", i, terminus, hash)
+			}
+		case i == terminusIndex(config):
+			if terminus != hash && (nodeCtx == common.PRIME_CTX || terminus != parentTermini[i]) {
+				return fmt.Errorf("terminus mismatch: have %x", terminus)
+			}
+		case terminus != parentTermini[i]:
+			return fmt.Errorf("terminus %d changed: have %x, want %x", i, terminus, parentTermini[i])
+		}
+	}
+	return nil
+}
+
+// verifyPendingEtxs checks the pending etxs and the pending etxs rollup stored
+// for a block, if any, are valid and belong to it.
+func verifyPendingEtxs(db ethdb.Reader, hash common.Hash, hasher types.TrieHasher) error {
+	if len(rawdb.ReadPendingEtxsRLP(db, hash)) > 0 {
+		pendingEtxs := rawdb.ReadPendingEtxs(db, hash)
+		if !pendingEtxs.IsValid(hasher) {
+			return fmt.Errorf("pending etxs of %x invalid", hash)
+		}
+		if pendingEtxs.Header.Hash() != hash {
+			return fmt.Errorf("pending etxs of %x stored for header %x", hash, pendingEtxs.Header.Hash())
+		}
+	}
+	if len(rawdb.ReadPendingEtxsRollupRLP(db, hash)) > 0 {
+		rollup := rawdb.ReadPendingEtxsRollup(db, hash)
+		if !rollup.IsValid(hasher) {
+			return fmt.Errorf("pending etxs rollup of %x invalid", hash)
+		}
+		if rollup.Header.Hash() != hash {
+			return fmt.Errorf("pending etxs rollup of %x stored for header %x", hash, rollup.Header.Hash())
+		}
+	}
+	return nil
+}
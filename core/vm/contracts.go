@@ -21,6 +21,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"math/big"
+	"sync"
 
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/common/math"
@@ -53,129 +54,69 @@ var TranslatedAddresses = map[common.AddressBytes]int{
 	common.AddressBytes([20]byte{9}): 8,
 }
 
-var (
-	PrecompiledContracts map[common.AddressBytes]PrecompiledContract = make(map[common.AddressBytes]PrecompiledContract)
-	PrecompiledAddresses map[string][]common.Address                 = make(map[string][]common.Address)
-)
+// precompiles are the precompiled contracts of every zone of a topology.
+type precompiles struct {
+	topology  *common.Topology
+	addresses map[string][]common.Address
+	contracts map[string]map[common.AddressBytes]PrecompiledContract
+}
 
-func InitializePrecompiles() {
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][0].Bytes20()] = &ecrecover{}
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][1].Bytes20()] = &sha256hash{}
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][2].Bytes20()] = &ripemd160hash{}
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][3].Bytes20()] = &dataCopy{}
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][4].Bytes20()] = &bigModExp{}
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][5].Bytes20()] = &bn256Add{}
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][6].Bytes20()] = &bn256ScalarMul{}
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][7].Bytes20()] = &bn256Pairing{}
-	PrecompiledContracts[PrecompiledAddresses[common.NodeLocation.Name()][8].Bytes20()] = &blake2F{}
-}
-
-func init() {
-
-	PrecompiledAddresses["cyprus1"] = []common.Address{
-		common.HexToAddress("0x1400000000000000000000000000000000000001"),
-		common.HexToAddress("0x1400000000000000000000000000000000000002"),
-		common.HexToAddress("0x1400000000000000000000000000000000000003"),
-		common.HexToAddress("0x1400000000000000000000000000000000000004"),
-		common.HexToAddress("0x1400000000000000000000000000000000000005"),
-		common.HexToAddress("0x1400000000000000000000000000000000000006"),
-		common.HexToAddress("0x1400000000000000000000000000000000000007"),
-		common.HexToAddress("0x1400000000000000000000000000000000000008"),
-		common.HexToAddress("0x1400000000000000000000000000000000000009"),
-	}
-	PrecompiledAddresses["cyprus2"] = []common.Address{
-		common.HexToAddress("0x2000000000000000000000000000000000000001"),
-		common.HexToAddress("0x2000000000000000000000000000000000000002"),
-		common.HexToAddress("0x2000000000000000000000000000000000000003"),
-		common.HexToAddress("0x2000000000000000000000000000000000000004"),
-		common.HexToAddress("0x2000000000000000000000000000000000000005"),
-		common.HexToAddress("0x2000000000000000000000000000000000000006"),
-		common.HexToAddress("0x2000000000000000000000000000000000000007"),
-		common.HexToAddress("0x2000000000000000000000000000000000000008"),
-		common.HexToAddress("0x2000000000000000000000000000000000000009"),
-	}
-	PrecompiledAddresses["cyprus3"] = []common.Address{
-		common.HexToAddress("0x3E00000000000000000000000000000000000001"),
-		common.HexToAddress("0x3E00000000000000000000000000000000000002"),
-		common.HexToAddress("0x3E00000000000000000000000000000000000003"),
-		common.HexToAddress("0x3E00000000000000000000000000000000000004"),
-		common.HexToAddress("0x3E00000000000000000000000000000000000005"),
-		common.HexToAddress("0x3E00000000000000000000000000000000000006"),
-		common.HexToAddress("0x3E00000000000000000000000000000000000007"),
-		common.HexToAddress("0x3E00000000000000000000000000000000000008"),
-		common.HexToAddress("0x3E00000000000000000000000000000000000009"),
-	}
-	PrecompiledAddresses["paxos1"] = []common.Address{
-		common.HexToAddress("0x5A00000000000000000000000000000000000001"),
-		common.HexToAddress("0x5A00000000000000000000000000000000000002"),
-		common.HexToAddress("0x5A00000000000000000000000000000000000003"),
-		common.HexToAddress("0x5A00000000000000000000000000000000000004"),
-		common.HexToAddress("0x5A00000000000000000000000000000000000005"),
-		common.HexToAddress("0x5A00000000000000000000000000000000000006"),
-		common.HexToAddress("0x5A00000000000000000000000000000000000007"),
-		common.HexToAddress("0x5A00000000000000000000000000000000000008"),
-		common.HexToAddress("0x5A00000000000000000000000000000000000009"),
-	}
-	PrecompiledAddresses["paxos2"] = []common.Address{
-		common.HexToAddress("0x7800000000000000000000000000000000000001"),
-		common.HexToAddress("0x7800000000000000000000000000000000000002"),
-		common.HexToAddress("0x7800000000000000000000000000000000000003"),
-		common.HexToAddress("0x7800000000000000000000000000000000000004"),
-		common.HexToAddress("0x7800000000000000000000000000000000000005"),
-		common.HexToAddress("0x7800000000000000000000000000000000000006"),
-		common.HexToAddress("0x7800000000000000000000000000000000000007"),
-		common.HexToAddress("0x7800000000000000000000000000000000000008"),
-		common.HexToAddress("0x7800000000000000000000000000000000000009"),
-	}
-	PrecompiledAddresses["paxos3"] = []common.Address{
-		common.HexToAddress("0x9600000000000000000000000000000000000001"),
-		common.HexToAddress("0x9600000000000000000000000000000000000002"),
-		common.HexToAddress("0x9600000000000000000000000000000000000003"),
-		common.HexToAddress("0x9600000000000000000000000000000000000004"),
-		common.HexToAddress("0x9600000000000000000000000000000000000005"),
-		common.HexToAddress("0x9600000000000000000000000000000000000006"),
-		common.HexToAddress("0x9600000000000000000000000000000000000007"),
-		common.HexToAddress("0x9600000000000000000000000000000000000008"),
-		common.HexToAddress("0x9600000000000000000000000000000000000009"),
-	}
-	PrecompiledAddresses["hydra1"] = []common.Address{
-		common.HexToAddress("0xB400000000000000000000000000000000000001"),
-		common.HexToAddress("0xB400000000000000000000000000000000000002"),
-		common.HexToAddress("0xB400000000000000000000000000000000000003"),
-		common.HexToAddress("0xB400000000000000000000000000000000000004"),
-		common.HexToAddress("0xB400000000000000000000000000000000000005"),
-		common.HexToAddress("0xB400000000000000000000000000000000000006"),
-		common.HexToAddress("0xB400000000000000000000000000000000000007"),
-		common.HexToAddress("0xB400000000000000000000000000000000000008"),
-		common.HexToAddress("0xB400000000000000000000000000000000000009"),
-	}
-	PrecompiledAddresses["hydra2"] = []common.Address{
-		common.HexToAddress("0xD200000000000000000000000000000000000001"),
-		common.HexToAddress("0xD200000000000000000000000000000000000002"),
-		common.HexToAddress("0xD200000000000000000000000000000000000003"),
-		common.HexToAddress("0xD200000000000000000000000000000000000004"),
-		common.HexToAddress("0xD200000000000000000000000000000000000005"),
-		common.HexToAddress("0xD200000000000000000000000000000000000006"),
-		common.HexToAddress("0xD200000000000000000000000000000000000007"),
-		common.HexToAddress("0xD200000000000000000000000000000000000008"),
-		common.HexToAddress("0xD200000000000000000000000000000000000009"),
-	}
-	PrecompiledAddresses["hydra3"] = []common.Address{
-		common.HexToAddress("0xF000000000000000000000000000000000000001"),
-		common.HexToAddress("0xF000000000000000000000000000000000000002"),
-		common.HexToAddress("0xF000000000000000000000000000000000000003"),
-		common.HexToAddress("0xF000000000000000000000000000000000000004"),
-		common.HexToAddress("0xF000000000000000000000000000000000000005"),
-		common.HexToAddress("0xF000000000000000000000000000000000000006"),
-		common.HexToAddress("0xF000000000000000000000000000000000000007"),
-		common.HexToAddress("0xF000000000000000000000000000000000000008"),
-		common.HexToAddress("0xF000000000000000000000000000000000000009"),
+// topologyPrecompiles caches the precompiles of every topology in use.
+var topologyPrecompiles sync.Map // *common.Topology -> *precompiles
+
+// loadPrecompiles returns the precompiles of the given topology. The addresses
+// of the precompiles of a zone start with the precompile byte of its address
+// space, so that an EVM running in any location can resolve its own
+// precompiles without consulting the node location.
+func loadPrecompiles(topology *common.Topology) *precompiles {
+	if p, ok := topologyPrecompiles.Load(topology); ok {
+		return p.(*precompiles)
+	}
+	p := &precompiles{
+		topology:  topology,
+		addresses: make(map[string][]common.Address),
+		contracts: make(map[string]map[common.AddressBytes]PrecompiledContract),
+	}
+	for r := 0; r < topology.Regions; r++ {
+		for z := 0; z < topology.Zones; z++ {
+			location := common.Location{byte(r), byte(z)}
+			space, ok := topology.ZoneAddressSpace(location)
+			if !ok {
+				continue
+			}
+			addresses := make([]common.Address, len(TranslatedAddresses))
+			for i := range addresses {
+				var addr common.ExternalAddress
+				addr[0], addr[len(addr)-1] = space.Precompile, byte(i+1)
+				addresses[i] = common.NewAddressFromData(&addr)
+			}
+			p.addresses[string(location)] = addresses
+			p.contracts[string(location)] = map[common.AddressBytes]PrecompiledContract{
+				addresses[0].Bytes20(): &ecrecover{},
+				addresses[1].Bytes20(): &sha256hash{},
+				addresses[2].Bytes20(): &ripemd160hash{},
+				addresses[3].Bytes20(): &dataCopy{},
+				addresses[4].Bytes20(): &bigModExp{},
+				addresses[5].Bytes20(): &bn256Add{},
+				addresses[6].Bytes20(): &bn256ScalarMul{},
+				addresses[7].Bytes20(): &bn256Pairing{},
+				addresses[8].Bytes20(): &blake2F{},
+			}
+		}
 	}
+	actual, _ := topologyPrecompiles.LoadOrStore(topology, p)
+	return actual.(*precompiles)
+}
+
+// PrecompiledAddresses returns the addresses of the precompiled contracts of
+// the given zone of the topology.
+func PrecompiledAddresses(location common.Location, topology *common.Topology) []common.Address {
+	return loadPrecompiles(topology).addresses[string(location)]
 }
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
-	return PrecompiledAddresses[common.NodeLocation.Name()]
+	return PrecompiledAddresses(rules.Location, rules.Topology)
 }
 
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
@@ -21,7 +21,7 @@ import (
 )
 
 func opSelfBalance(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
-	internalAddr, err := scope.Contract.Address().InternalAddress()
+	internalAddr, err := interpreter.evm.chainConfig.InternalAddress(scope.Contract.Address())
 	if err != nil {
 		return nil, err
 	}
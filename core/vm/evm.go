@@ -36,19 +36,25 @@ var emptyCodeHash = crypto.Keccak256Hash(nil)
 
 type (
 	// CanTransferFunc is the signature of a transfer guard function
-	CanTransferFunc func(StateDB, common.Address, *big.Int) bool
+	CanTransferFunc func(StateDB, common.Address, *big.Int, *params.ChainConfig) bool
 	// TransferFunc is the signature of a transfer function
-	TransferFunc func(StateDB, common.Address, common.Address, *big.Int) error
+	TransferFunc func(StateDB, common.Address, common.Address, *big.Int, *params.ChainConfig) error
 	// GetHashFunc returns the n'th block hash in the blockchain
 	// and is used by the BLOCKHASH EVM op code.
 	GetHashFunc func(uint64) common.Hash
 )
 
 func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool, common.Address) {
+	var (
+		precompiles = loadPrecompiles(evm.chainConfig.GetTopology())
+		location    = string(evm.chainConfig.Location)
+	)
 	if index, ok := TranslatedAddresses[addr.Bytes20()]; ok {
-		addr = PrecompiledAddresses[common.NodeLocation.Name()][index]
+		if addresses := precompiles.addresses[location]; index < len(addresses) {
+			addr = addresses[index]
+		}
 	}
-	p, ok := PrecompiledContracts[addr.Bytes20()]
+	p, ok := precompiles.contracts[location][addr.Bytes20()]
 	return p, ok, addr
 }
 
@@ -67,6 +73,7 @@ type BlockContext struct {
 	Coinbase    common.Address // Provides information for COINBASE
 	GasLimit    uint64         // Provides information for GASLIMIT
 	BlockNumber *big.Int       // Provides information for NUMBER
+	PrimeNumber *big.Int       // Number of the block in prime, for the forks scheduled by prime block
 	Time        *big.Int       // Provides information for TIME
 	Difficulty  *big.Int       // Provides information for DIFFICULTY
 	BaseFee     *big.Int       // Provides information for BASEFEE
@@ -137,7 +144,7 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		StateDB:     statedb,
 		Config:      config,
 		chainConfig: chainConfig,
-		chainRules:  chainConfig.Rules(blockCtx.BlockNumber),
+		chainRules:  chainConfig.Rules(blockCtx.BlockNumber, blockCtx.PrimeNumber),
 		ETXCache:    make([]*types.Transaction, 0),
 	}
 	evm.interpreter = NewEVMInterpreter(evm, config)
@@ -180,15 +187,21 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		return nil, gas, ErrDepth
 	}
 	// Fail if we're trying to transfer more than the available balance
-	if value.Sign() != 0 && !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
+	if value.Sign() != 0 && !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value, evm.chainConfig) {
 		return nil, gas, ErrInsufficientBalance
 	}
 	snapshot := evm.StateDB.Snapshot()
 	p, isPrecompile, addr := evm.precompile(addr)
 	if evm.TxType == types.InternalToExternalTxType {
+		if evm.Config.Debug && evm.depth == 0 {
+			evm.Config.Tracer.CaptureStart(evm, caller.Address(), addr, false, input, gas, value)
+			defer func(startGas uint64, startTime time.Time) { // Lazy evaluation of the parameters
+				evm.Config.Tracer.CaptureEnd(ret, startGas-leftOverGas, time.Since(startTime), err)
+			}(gas, time.Now())
+		}
 		return evm.CreateETX(addr, caller.Address(), evm.ETXGasLimit, evm.ETXGasPrice, evm.ETXGasTip, evm.ETXData, evm.ETXAccessList, gas, value)
 	}
-	internalAddr, err := addr.InternalAddress()
+	internalAddr, err := evm.chainConfig.InternalAddress(addr)
 	if err != nil {
 		// We might want to return zero leftOverGas here, but we're being nice
 		return nil, gas, err
@@ -196,24 +209,37 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	if !evm.StateDB.Exist(internalAddr) {
 		if !isPrecompile && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
-			if evm.Config.Debug && evm.depth == 0 {
-				evm.Config.Tracer.CaptureStart(evm, caller.Address(), addr, false, input, gas, value)
-				evm.Config.Tracer.CaptureEnd(ret, 0, 0, nil)
+			if evm.Config.Debug {
+				if evm.depth == 0 {
+					evm.Config.Tracer.CaptureStart(evm, caller.Address(), addr, false, input, gas, value)
+					evm.Config.Tracer.CaptureEnd(ret, 0, 0, nil)
+				} else {
+					evm.Config.Tracer.CaptureEnter(CALL, caller.Address(), addr, input, gas, value)
+					evm.Config.Tracer.CaptureExit(ret, 0, nil)
+				}
 			}
 			return nil, gas, nil
 		}
 		evm.StateDB.CreateAccount(internalAddr)
 	}
-	if err := evm.Context.Transfer(evm.StateDB, caller.Address(), addr, value); err != nil {
+	if err := evm.Context.Transfer(evm.StateDB, caller.Address(), addr, value, evm.chainConfig); err != nil {
 		return nil, gas, err
 	}
 
 	// Capture the tracer start/end events in debug mode
-	if evm.Config.Debug && evm.depth == 0 {
-		evm.Config.Tracer.CaptureStart(evm, caller.Address(), addr, false, input, gas, value)
-		defer func(startGas uint64, startTime time.Time) { // Lazy evaluation of the parameters
-			evm.Config.Tracer.CaptureEnd(ret, startGas-gas, time.Since(startTime), err)
-		}(gas, time.Now())
+	if evm.Config.Debug {
+		if evm.depth == 0 {
+			evm.Config.Tracer.CaptureStart(evm, caller.Address(), addr, false, input, gas, value)
+			defer func(startGas uint64, startTime time.Time) { // Lazy evaluation of the parameters
+				evm.Config.Tracer.CaptureEnd(ret, startGas-gas, time.Since(startTime), err)
+			}(gas, time.Now())
+		} else {
+			// Handle tracer events for entering and exiting a call frame
+			evm.Config.Tracer.CaptureEnter(CALL, caller.Address(), addr, input, gas, value)
+			defer func(startGas uint64) {
+				evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
+			}(gas)
+		}
 	}
 
 	if isPrecompile {
@@ -268,17 +294,25 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	// Note although it's noop to transfer X ether to caller itself. But
 	// if caller doesn't have enough balance, it would be an error to allow
 	// over-charging itself. So the check here is necessary.
-	if !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
+	if !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value, evm.chainConfig) {
 		return nil, gas, ErrInsufficientBalance
 	}
 	var snapshot = evm.StateDB.Snapshot()
 
+	// Invoke tracer hooks that signal entering/exiting a call frame
+	if evm.Config.Debug {
+		evm.Config.Tracer.CaptureEnter(CALLCODE, caller.Address(), addr, input, gas, value)
+		defer func(startGas uint64) {
+			evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
+		}(gas)
+	}
+
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile, addr := evm.precompile(addr); isPrecompile {
 		ret, gas, err = RunPrecompiledContract(p, input, gas)
 	} else {
 		addrCopy := addr
-		internalAddr, err := addrCopy.InternalAddress()
+		internalAddr, err := evm.chainConfig.InternalAddress(addrCopy)
 		if err != nil {
 			return nil, gas, err
 		}
@@ -313,12 +347,20 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	}
 	var snapshot = evm.StateDB.Snapshot()
 
+	// Invoke tracer hooks that signal entering/exiting a call frame
+	if evm.Config.Debug {
+		evm.Config.Tracer.CaptureEnter(DELEGATECALL, caller.Address(), addr, input, gas, nil)
+		defer func(startGas uint64) {
+			evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
+		}(gas)
+	}
+
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile, addr := evm.precompile(addr); isPrecompile {
 		ret, gas, err = RunPrecompiledContract(p, input, gas)
 	} else {
 		addrCopy := addr
-		internalAddr, err := addrCopy.InternalAddress()
+		internalAddr, err := evm.chainConfig.InternalAddress(addrCopy)
 		if err != nil {
 			return nil, gas, err
 		}
@@ -357,10 +399,18 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	// We could change this, but for now it's left for legacy reasons
 	var snapshot = evm.StateDB.Snapshot()
 
+	// Invoke tracer hooks that signal entering/exiting a call frame
+	if evm.Config.Debug {
+		evm.Config.Tracer.CaptureEnter(STATICCALL, caller.Address(), addr, input, gas, nil)
+		defer func(startGas uint64) {
+			evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
+		}(gas)
+	}
+
 	if p, isPrecompile, addr := evm.precompile(addr); isPrecompile {
 		ret, gas, err = RunPrecompiledContract(p, input, gas)
 	} else {
-		internalAddr, err := addr.InternalAddress()
+		internalAddr, err := evm.chainConfig.InternalAddress(addr)
 		if err != nil {
 			return nil, gas, err
 		}
@@ -400,8 +450,8 @@ func (c *codeAndHash) Hash() common.Hash {
 }
 
 // create creates a new contract using code as deployment code.
-func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *big.Int, address common.Address) ([]byte, common.Address, uint64, error) {
-	internalCallerAddr, err := caller.Address().InternalAddress()
+func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *big.Int, address common.Address, typ OpCode) ([]byte, common.Address, uint64, error) {
+	internalCallerAddr, err := evm.chainConfig.InternalAddress(caller.Address())
 	if err != nil {
 		return nil, common.ZeroAddr, 0, err
 	}
@@ -413,11 +463,11 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, common.ZeroAddr, gas, ErrDepth
 	}
-	if !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
+	if !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value, evm.chainConfig) {
 		return nil, common.ZeroAddr, gas, ErrInsufficientBalance
 	}
 
-	internalContractAddr, err := address.InternalAddress()
+	internalContractAddr, err := evm.chainConfig.InternalAddress(address)
 	if err != nil {
 		return nil, common.ZeroAddr, 0, err
 	}
@@ -437,7 +487,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 
 	evm.StateDB.SetNonce(internalContractAddr, 1)
 
-	if err := evm.Context.Transfer(evm.StateDB, caller.Address(), address, value); err != nil {
+	if err := evm.Context.Transfer(evm.StateDB, caller.Address(), address, value, evm.chainConfig); err != nil {
 		return nil, common.ZeroAddr, 0, err
 	}
 
@@ -450,8 +500,12 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		return nil, address, gas, nil
 	}
 
-	if evm.Config.Debug && evm.depth == 0 {
-		evm.Config.Tracer.CaptureStart(evm, caller.Address(), address, true, codeAndHash.code, gas, value)
+	if evm.Config.Debug {
+		if evm.depth == 0 {
+			evm.Config.Tracer.CaptureStart(evm, caller.Address(), address, true, codeAndHash.code, gas, value)
+		} else {
+			evm.Config.Tracer.CaptureEnter(typ, caller.Address(), address, codeAndHash.code, gas, value)
+		}
 	}
 	start := time.Now()
 
@@ -490,20 +544,24 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		}
 	}
 
-	if evm.Config.Debug && evm.depth == 0 {
-		evm.Config.Tracer.CaptureEnd(ret, gas-contract.Gas, time.Since(start), err)
+	if evm.Config.Debug {
+		if evm.depth == 0 {
+			evm.Config.Tracer.CaptureEnd(ret, gas-contract.Gas, time.Since(start), err)
+		} else {
+			evm.Config.Tracer.CaptureExit(ret, gas-contract.Gas, err)
+		}
 	}
 	return ret, address, contract.Gas, err
 }
 
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
-	internalAddr, err := caller.Address().InternalAddress()
+	internalAddr, err := evm.chainConfig.InternalAddress(caller.Address())
 	if err != nil {
 		return nil, common.ZeroAddr, 0, err
 	}
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(internalAddr), code)
-	return evm.create(caller, &codeAndHash{code: code}, gas, value, contractAddr)
+	return evm.create(caller, &codeAndHash{code: code}, gas, value, contractAddr, CREATE)
 }
 
 // Create2 creates a new contract using code as deployment code.
@@ -513,19 +571,19 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.I
 func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *big.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	codeAndHash := &codeAndHash{code: code}
 	contractAddr = crypto.CreateAddress2(caller.Address(), salt.Bytes32(), codeAndHash.Hash().Bytes())
-	return evm.create(caller, codeAndHash, gas, endowment, contractAddr)
+	return evm.create(caller, codeAndHash, gas, endowment, contractAddr, CREATE2)
 }
 
 func (evm *EVM) CreateETX(toAddr common.Address, fromAddr common.Address, etxGasLimit uint64, etxGasPrice *big.Int, etxGasTip *big.Int, etxData []byte, etxAccessList types.AccessList, gas uint64, value *big.Int) (ret []byte, leftOverGas uint64, err error) {
 
 	// Verify address is not in context
-	if common.IsInChainScope(toAddr.Bytes()) {
+	if evm.chainConfig.IsInChainScope(toAddr.Bytes()) {
 		return []byte{}, 0, fmt.Errorf("%x is in chain scope, but CreateETX was called", toAddr)
 	}
 	if gas < params.ETXGas {
 		return []byte{}, 0, fmt.Errorf("CreateETX error: %d is not sufficient gas, required amount: %d", gas, params.ETXGas)
 	}
-	fromInternal, err := fromAddr.InternalAddress()
+	fromInternal, err := evm.chainConfig.InternalAddress(fromAddr)
 	if err != nil {
 		return []byte{}, 0, fmt.Errorf("CreateETX error: %s", err.Error())
 	}
@@ -540,7 +598,7 @@ func (evm *EVM) CreateETX(toAddr common.Address, fromAddr common.Address, etxGas
 	total := big.NewInt(0)
 	total.Add(value, fee)
 	// Fail if we're trying to transfer more than the available balance
-	if total.Sign() == 0 || !evm.Context.CanTransfer(evm.StateDB, fromAddr, total) {
+	if total.Sign() == 0 || !evm.Context.CanTransfer(evm.StateDB, fromAddr, total, evm.chainConfig) {
 		return []byte{}, 0, fmt.Errorf("CreateETX: %x cannot transfer %d", fromAddr, total.Uint64())
 	}
 
@@ -555,17 +613,31 @@ func (evm *EVM) CreateETX(toAddr common.Address, fromAddr common.Address, etxGas
 	evm.ETXCacheLock.Lock()
 	evm.ETXCache = append(evm.ETXCache, etx)
 	evm.ETXCacheLock.Unlock()
+	evm.captureETX(fromAddr, toAddr, etxData, etxGasLimit, value)
 
 	return []byte{}, gas - params.ETXGas, nil
 }
 
-// Emitted ETXs must include some multiple of BaseFee as miner tip, to
-// encourage processing at the destination.
-func calcEtxFeeMultiplier(fromAddr, toAddr common.Address) *big.Int {
-	confirmationCtx := fromAddr.Location().CommonDom(*toAddr.Location()).Context()
-	multiplier := big.NewInt(common.NumZonesInRegion)
+// captureETX reports an emitted external transaction to the tracer as an ETX
+// frame. The ETX executes in its destination chain, so the frame carries the
+// gas limit given to the ETX and never has any output.
+func (evm *EVM) captureETX(from common.Address, to common.Address, data []byte, etxGasLimit uint64, value *big.Int) {
+	if !evm.Config.Debug {
+		return
+	}
+	evm.Config.Tracer.CaptureEnter(ETX, from, to, data, etxGasLimit, value)
+	evm.Config.Tracer.CaptureExit(nil, 0, nil)
+}
+
+// CalcEtxFeeMultiplier returns the multiple of the base fee and miner tip an
+// ETX between the given addresses of the topology must pay. Emitted ETXs must
+// include some multiple of BaseFee as miner tip, to encourage processing at the
+// destination.
+func CalcEtxFeeMultiplier(fromAddr, toAddr common.Address, topology *common.Topology) *big.Int {
+	confirmationCtx := fromAddr.Location(topology).CommonDom(*toAddr.Location(topology)).Context()
+	multiplier := big.NewInt(int64(topology.Zones))
 	if confirmationCtx == common.PRIME_CTX {
-		multiplier = big.NewInt(0).Mul(multiplier, big.NewInt(common.NumRegionsInPrime))
+		multiplier = big.NewInt(0).Mul(multiplier, big.NewInt(int64(topology.Regions)))
 	}
 	return multiplier
 }
@@ -586,7 +658,7 @@ func (evm *EVM) ValidateETXGasPriceAndTip(fromAddr, toAddr common.Address, etxGa
 	}
 	// This will panic if baseFee is nil, but basefee presence is verified
 	// as part of header validation.
-	feeMul := calcEtxFeeMultiplier(fromAddr, toAddr)
+	feeMul := CalcEtxFeeMultiplier(fromAddr, toAddr, evm.chainConfig.GetTopology())
 	mulBaseFee := new(big.Int).Mul(evm.Context.BaseFee, feeMul)
 	if etxGasPrice.Cmp(mulBaseFee) < 0 {
 		return fmt.Errorf("etx max fee per gas less than %dx block base fee: address %v, maxFeePerGas: %s baseFee: %s",
@@ -261,7 +261,7 @@ func opAddress(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]
 
 func opBalance(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	slot := scope.Stack.peek()
-	address, err := common.Bytes20ToAddress(slot.Bytes20()).InternalAddress()
+	address, err := interpreter.evm.chainConfig.InternalAddress(common.Bytes20ToAddress(slot.Bytes20()))
 	if err != nil { // if an ErrInvalidScope error is returned, the caller (usually interpreter.go/Run) will return the error to Call which will eventually set ReceiptStatusFailed in the tx receipt (state_processor.go/applyTransaction)
 		return nil, err
 	}
@@ -391,7 +391,7 @@ func opExtCodeCopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext)
 	if overflow {
 		uint64CodeOffset = 0xffffffffffffffff
 	}
-	addr, err := common.Bytes20ToAddress(a.Bytes20()).InternalAddress()
+	addr, err := interpreter.evm.chainConfig.InternalAddress(common.Bytes20ToAddress(a.Bytes20()))
 	if err != nil {
 		return nil, err
 	}
@@ -436,7 +436,7 @@ func opExtCodeCopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext)
 // this account should be regarded as a non-existent account and zero should be returned.
 func opExtCodeHash(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	slot := scope.Stack.peek()
-	address, err := common.Bytes20ToAddress(slot.Bytes20()).InternalAddress()
+	address, err := interpreter.evm.chainConfig.InternalAddress(common.Bytes20ToAddress(slot.Bytes20()))
 	if err != nil {
 		return nil, err
 	}
@@ -532,7 +532,7 @@ func opMstore8(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]
 func opSload(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	loc := scope.Stack.peek()
 	hash := common.Hash(loc.Bytes32())
-	addr, err := scope.Contract.Address().InternalAddress()
+	addr, err := interpreter.evm.chainConfig.InternalAddress(scope.Contract.Address())
 	if err != nil {
 		return nil, err
 	}
@@ -544,7 +544,7 @@ func opSload(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]by
 func opSstore(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	loc := scope.Stack.pop()
 	val := scope.Stack.pop()
-	addr, err := scope.Contract.Address().InternalAddress()
+	addr, err := interpreter.evm.chainConfig.InternalAddress(scope.Contract.Address())
 	if err != nil {
 		return nil, err
 	}
@@ -710,7 +710,7 @@ func opCallCode(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([
 	addr, value, inOffset, inSize, retOffset, retSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
 	toAddr := common.Bytes20ToAddress(addr.Bytes20())
 	// Check if address is in proper context
-	if !common.IsInChainScope(toAddr.Bytes()) { // checked here because the error returned from CallCode is not returned from this function
+	if !interpreter.evm.chainConfig.IsInChainScope(toAddr.Bytes()) { // checked here because the error returned from CallCode is not returned from this function
 		return nil, common.ErrInvalidScope
 	}
 	// Get arguments from the memory.
@@ -749,7 +749,7 @@ func opDelegateCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext
 	addr, inOffset, inSize, retOffset, retSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
 	toAddr := common.Bytes20ToAddress(addr.Bytes20())
 	// Check if address is in proper context
-	if !common.IsInChainScope(toAddr.Bytes()) {
+	if !interpreter.evm.chainConfig.IsInChainScope(toAddr.Bytes()) {
 		return nil, common.ErrInvalidScope
 	}
 	// Get arguments from the memory.
@@ -781,7 +781,7 @@ func opStaticCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext)
 	addr, inOffset, inSize, retOffset, retSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
 	toAddr := common.Bytes20ToAddress(addr.Bytes20())
 	// Check if address is in proper context
-	if !common.IsInChainScope(toAddr.Bytes()) {
+	if !interpreter.evm.chainConfig.IsInChainScope(toAddr.Bytes()) {
 		return nil, common.ErrInvalidScope
 	}
 	// Get arguments from the memory.
@@ -823,11 +823,11 @@ func opStop(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byt
 
 func opSuicide(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	beneficiary := scope.Stack.pop()
-	addr, err := scope.Contract.Address().InternalAddress()
+	addr, err := interpreter.evm.chainConfig.InternalAddress(scope.Contract.Address())
 	if err != nil {
 		return nil, err
 	}
-	beneficiaryAddr, err := common.Bytes20ToAddress(beneficiary.Bytes20()).InternalAddress()
+	beneficiaryAddr, err := interpreter.evm.chainConfig.InternalAddress(common.Bytes20ToAddress(beneficiary.Bytes20()))
 	if err != nil {
 		return nil, err
 	}
@@ -849,14 +849,14 @@ func opETX(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte
 	addr, value, etxGasLimit, gasTipCap, gasFeeCap, inOffset, inSize, accessListOffset, accessListSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
 	toAddr := common.Bytes20ToAddress(addr.Bytes20())
 	// Verify address is not in context
-	if common.IsInChainScope(toAddr.Bytes()) {
+	if interpreter.evm.chainConfig.IsInChainScope(toAddr.Bytes()) {
 		temp.Clear()
 		stack.push(&temp)
 		fmt.Printf("%x is in chain scope, but opETX was called\n", toAddr)
 		return nil, nil // following opCall protocol
 	}
 	sender := scope.Contract.self.Address()
-	internalSender, err := sender.InternalAddress()
+	internalSender, err := interpreter.evm.chainConfig.InternalAddress(sender)
 	if err != nil {
 		fmt.Printf("%x opETX error: %s\n", scope.Contract.self.Address(), err.Error())
 		return nil, nil
@@ -875,7 +875,7 @@ func opETX(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte
 	total := uint256.NewInt(0)
 	total.Add(&value, fee)
 	// Fail if we're trying to transfer more than the available balance
-	if total.Sign() == 0 || !interpreter.evm.Context.CanTransfer(interpreter.evm.StateDB, scope.Contract.self.Address(), total.ToBig()) {
+	if total.Sign() == 0 || !interpreter.evm.Context.CanTransfer(interpreter.evm.StateDB, scope.Contract.self.Address(), total.ToBig(), interpreter.evm.chainConfig) {
 		temp.Clear()
 		stack.push(&temp)
 		fmt.Printf("%x cannot transfer %d\n", scope.Contract.self.Address(), total.Uint64())
@@ -906,6 +906,7 @@ func opETX(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte
 	interpreter.evm.ETXCacheLock.Lock()
 	interpreter.evm.ETXCache = append(interpreter.evm.ETXCache, etx)
 	interpreter.evm.ETXCacheLock.Unlock()
+	interpreter.evm.captureETX(sender, toAddr, common.CopyBytes(data), etxGasLimit.Uint64(), value.ToBig())
 
 	interpreter.evm.StateDB.SetNonce(internalSender, nonce+1)
 
@@ -919,7 +920,7 @@ func opETX(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte
 func opIsAddressInternal(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	addr := scope.Stack.peek()
 	commonAddr := common.Bytes20ToAddress(addr.Bytes20())
-	if common.IsInChainScope(commonAddr.Bytes()) {
+	if interpreter.evm.chainConfig.IsInChainScope(commonAddr.Bytes()) {
 		addr.SetOne()
 	} else {
 		addr.Clear()
@@ -954,6 +955,12 @@ func makeLog(size int) executionFunc {
 	}
 }
 
+// opPush0 pushes a zero on the stack.
+func opPush0(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	scope.Stack.push(new(uint256.Int))
+	return nil, nil
+}
+
 // opPush1 is a specialized version of pushN
 func opPush1(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	var (
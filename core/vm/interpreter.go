@@ -69,7 +69,13 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	// the jump table was initialised. If it was not
 	// we'll set the default jump table.
 	if cfg.JumpTable[STOP] == nil {
-		jt := instructionSet
+		var jt JumpTable
+		switch {
+		case evm.chainRules.IsPush0:
+			jt = push0InstructionSet
+		default:
+			jt = instructionSet
+		}
 		cfg.JumpTable = jt
 	}
 
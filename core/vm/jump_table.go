@@ -49,7 +49,8 @@ type operation struct {
 }
 
 var (
-	instructionSet = NewInstructionSet()
+	instructionSet      = NewInstructionSet()
+	push0InstructionSet = newPush0InstructionSet()
 )
 
 // JumpTable contains the EVM opcodes supported at a given fork.
@@ -150,6 +151,19 @@ func NewInstructionSet() JumpTable {
 	return instructionSet
 }
 
+// newPush0InstructionSet returns the instructions of the push0 fork, which adds
+// PUSH0 to the base instruction set.
+func newPush0InstructionSet() JumpTable {
+	instructionSet := NewInstructionSet()
+	instructionSet[PUSH0] = &operation{
+		execute:     opPush0,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 1),
+		maxStack:    maxStack(0, 1),
+	}
+	return instructionSet
+}
+
 func newInstructionSet() JumpTable {
 	return JumpTable{
 		STOP: {
@@ -73,6 +73,14 @@ func (l *JSONLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint
 	l.encoder.Encode(log)
 }
 
+// CaptureEnter is not reported by the JSON logger, the depth of the steps
+// already conveys the call structure.
+func (l *JSONLogger) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+// CaptureExit is not reported by the JSON logger.
+func (l *JSONLogger) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
 // CaptureEnd is triggered at end of execution.
 func (l *JSONLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
 	type endLog struct {
@@ -53,7 +53,8 @@ type Config struct {
 func setDefaults(cfg *Config) {
 	if cfg.ChainConfig == nil {
 		cfg.ChainConfig = &params.ChainConfig{
-			ChainID: big.NewInt(1),
+			ChainID:  big.NewInt(1),
+			Location: common.Location{0, 0},
 		}
 	}
 
@@ -104,11 +105,11 @@ func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
 		vmenv   = NewEnv(cfg)
 		sender  = vm.AccountRef(cfg.Origin)
 	)
-	internal, err := address.InternalAddress()
+	internal, err := cfg.ChainConfig.InternalAddress(address)
 	if err != nil {
 		return []byte{}, nil, err
 	}
-	rules := cfg.ChainConfig.Rules(vmenv.Context.BlockNumber)
+	rules := cfg.ChainConfig.Rules(vmenv.Context.BlockNumber, vmenv.Context.PrimeNumber)
 	cfg.State.PrepareAccessList(cfg.Origin, &address, vm.ActivePrecompiles(rules), nil)
 
 	cfg.State.CreateAccount(internal)
@@ -140,7 +141,7 @@ func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
 		vmenv  = NewEnv(cfg)
 		sender = vm.AccountRef(cfg.Origin)
 	)
-	rules := cfg.ChainConfig.Rules(vmenv.Context.BlockNumber)
+	rules := cfg.ChainConfig.Rules(vmenv.Context.BlockNumber, vmenv.Context.PrimeNumber)
 	cfg.State.PrepareAccessList(cfg.Origin, nil, vm.ActivePrecompiles(rules), nil)
 
 	// Call the code with the given configuration.
@@ -162,14 +163,14 @@ func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, er
 	setDefaults(cfg)
 
 	vmenv := NewEnv(cfg)
-	_, err := cfg.Origin.InternalAddress()
+	_, err := cfg.ChainConfig.InternalAddress(cfg.Origin)
 	if err != nil {
 		return []byte{}, 0, err
 	}
 
 	statedb := cfg.State
 
-	rules := cfg.ChainConfig.Rules(vmenv.Context.BlockNumber)
+	rules := cfg.ChainConfig.Rules(vmenv.Context.BlockNumber, vmenv.Context.PrimeNumber)
 	statedb.PrepareAccessList(cfg.Origin, &address, vm.ActivePrecompiles(rules), nil)
 
 	// Call the code with the given configuration.
@@ -69,8 +69,7 @@ type environment struct {
 }
 
 // copy creates a deep copy of environment.
-func (env *environment) copy() *environment {
-	nodeCtx := common.NodeLocation.Context()
+func (env *environment) copy(nodeCtx int) *environment {
 	if nodeCtx == common.ZONE_CTX {
 		cpy := &environment{
 			signer:    env.signer,
@@ -159,6 +158,16 @@ type Config struct {
 	GasPrice   *big.Int       // Minimum gas price for mining a transaction
 	Recommit   time.Duration  // The time interval for miner to re-create mining work.
 	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
+
+	// Builder is the policy pending blocks are filled with. If nil, the
+	// DefaultBlockBuilder is used.
+	Builder BlockBuilder `toml:"-"`
+
+	// Synchronous disables the background routines reacting to new chain heads,
+	// such as pending header regeneration and the retry of blocks which could
+	// not be appended yet. Pending headers are then only filled on request, which
+	// in-process simulations rely on to get deterministic results.
+	Synchronous bool `toml:"-"`
 }
 
 // worker is the main object which takes care of submitting new work to consensus engine
@@ -196,9 +205,13 @@ type worker struct {
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	uncleMu      sync.RWMutex
 
-	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
+	mu       sync.RWMutex // The lock used to protect the coinbase, extra and builder fields
 	coinbase common.Address
 	extra    []byte
+	builder  BlockBuilder
+
+	bundleMu sync.Mutex // The lock used to protect the bundles
+	bundles  []*Bundle  // Bundles waiting for their target block
 
 	workerDb ethdb.Database
 
@@ -249,10 +262,17 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, db ethdb.Databas
 	// Set the GasFloor of the worker to the minGasLimit
 	worker.config.GasFloor = params.MinGasLimit
 
+	worker.builder = config.Builder
+	if worker.builder == nil {
+		worker.builder = DefaultBlockBuilder{}
+	}
+
 	phBodyCache, _ := lru.New(pendingBlockBodyLimit)
 	worker.pendingBlockBody = phBodyCache
 
-	worker.chainHeadSub = worker.hc.SubscribeChainHeadEvent(worker.chainHeadCh)
+	if !config.Synchronous {
+		worker.chainHeadSub = worker.hc.SubscribeChainHeadEvent(worker.chainHeadCh)
+	}
 
 	// Sanitize recommit interval if the user-specified one is too short.
 	recommit := worker.config.Recommit
@@ -261,8 +281,10 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, db ethdb.Databas
 		recommit = minRecommitInterval
 	}
 
-	worker.wg.Add(1)
-	go worker.asyncStateLoop()
+	if !config.Synchronous {
+		worker.wg.Add(1)
+		go worker.asyncStateLoop()
+	}
 
 	return worker
 }
@@ -287,6 +309,13 @@ func (w *worker) setExtra(extra []byte) {
 	w.extra = extra
 }
 
+// setBlockBuilder sets the policy pending blocks are filled with.
+func (w *worker) setBlockBuilder(builder BlockBuilder) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.builder = builder
+}
+
 // setRecommitInterval updates the interval for miner sealing work recommitting.
 func (w *worker) setRecommitInterval(interval time.Duration) {
 	select {
@@ -337,7 +366,9 @@ func (w *worker) start() {
 
 // stop sets the running status as 0.
 func (w *worker) stop() {
-	w.chainHeadSub.Unsubscribe()
+	if w.chainHeadSub != nil {
+		w.chainHeadSub.Unsubscribe()
+	}
 	atomic.StoreInt32(&w.running, 0)
 }
 
@@ -422,7 +453,7 @@ func (w *worker) asyncStateLoop() {
 
 // GeneratePendingBlock generates pending block given a commited block.
 func (w *worker) GeneratePendingHeader(block *types.Block, fill bool) (*types.Header, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := w.hc.NodeCtx()
 
 	w.interruptAsyncPhGen()
 
@@ -482,14 +513,15 @@ func (w *worker) GeneratePendingHeader(block *types.Block, fill bool) (*types.He
 
 // printPendingHeaderInfo logs the pending header information
 func (w *worker) printPendingHeaderInfo(work *environment, block *types.Block, start time.Time) {
+	nodeCtx := w.hc.NodeCtx()
 	work.uncleMu.RLock()
 	if w.CurrentInfo(block.Header()) {
-		log.Info("Commit new sealing work", "number", block.Number(), "sealhash", block.Header().SealHash(),
+		log.Info("Commit new sealing work", "number", block.Number(nodeCtx), "sealhash", block.Header().SealHash(),
 			"uncles", len(work.uncles), "txs", work.tcount, "etxs", len(block.ExtTransactions()),
 			"gas", block.GasUsed(), "fees", totalFees(block, work.receipts),
 			"elapsed", common.PrettyDuration(time.Since(start)))
 	} else {
-		log.Debug("Commit new sealing work", "number", block.Number(), "sealhash", block.Header().SealHash(),
+		log.Debug("Commit new sealing work", "number", block.Number(nodeCtx), "sealhash", block.Header().SealHash(),
 			"uncles", len(work.uncles), "txs", work.tcount, "etxs", len(block.ExtTransactions()),
 			"gas", block.GasUsed(), "fees", totalFees(block, work.receipts),
 			"elapsed", common.PrettyDuration(time.Since(start)))
@@ -516,6 +548,7 @@ func (w *worker) eventExitLoop() {
 
 // makeEnv creates a new environment for the sealing block.
 func (w *worker) makeEnv(parent *types.Block, header *types.Header, coinbase common.Address) (*environment, error) {
+	nodeCtx := w.hc.NodeCtx()
 	// Retrieve the parent state to execute on top and start a prefetcher for
 	// the miner to speed block sealing up a bit.
 	state, err := w.hc.bc.processor.StateAt(parent.Root())
@@ -533,17 +566,10 @@ func (w *worker) makeEnv(parent *types.Block, header *types.Header, coinbase com
 		return nil, err
 	}
 
-	etxRLimit := len(parent.Transactions()) / params.ETXRegionMaxFraction
-	if etxRLimit < params.ETXRLimitMin {
-		etxRLimit = params.ETXRLimitMin
-	}
-	etxPLimit := len(parent.Transactions()) / params.ETXPrimeMaxFraction
-	if etxPLimit < params.ETXPLimitMin {
-		etxPLimit = params.ETXPLimitMin
-	}
+	etxRLimit, etxPLimit := CalcETXLimits(parent, w.chainConfig.GetTopology())
 	// Note the passed coinbase may be different with header.Coinbase.
 	env := &environment{
-		signer:    types.MakeSigner(w.chainConfig, header.Number()),
+		signer:    types.MakeSigner(w.chainConfig, header.Number(nodeCtx), header.Number(common.PRIME_CTX)),
 		state:     state,
 		coinbase:  coinbase,
 		ancestors: mapset.NewSet(),
@@ -568,16 +594,17 @@ func (w *worker) makeEnv(parent *types.Block, header *types.Header, coinbase com
 
 // commitUncle adds the given block to uncle block set, returns error if failed to add.
 func (w *worker) commitUncle(env *environment, uncle *types.Header) error {
+	nodeCtx := w.hc.NodeCtx()
 	env.uncleMu.Lock()
 	defer env.uncleMu.Unlock()
 	hash := uncle.Hash()
 	if _, exist := env.uncles[hash]; exist {
 		return errors.New("uncle not unique")
 	}
-	if env.header.ParentHash() == uncle.ParentHash() {
+	if env.header.ParentHash(nodeCtx) == uncle.ParentHash(nodeCtx) {
 		return errors.New("uncle is sibling")
 	}
-	if !env.ancestors.Contains(uncle.ParentHash()) {
+	if !env.ancestors.Contains(uncle.ParentHash(nodeCtx)) {
 		return errors.New("uncle's parent unknown")
 	}
 	if env.family.Contains(hash) {
@@ -612,7 +639,7 @@ func (w *worker) commitTransaction(env *environment, tx *types.Transaction) ([]*
 	return nil, errors.New("error finding transaction")
 }
 
-func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByPriceAndNonce, interrupt *int32) bool {
+func (w *worker) commitTransactions(env *environment, txs TransactionSet, interrupt *int32) bool {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
 		env.gasPool = new(GasPool).AddGas(gasLimit())
@@ -730,7 +757,7 @@ type generateParams struct {
 func (w *worker) prepareWork(genParams *generateParams, block *types.Block) (*environment, error) {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := w.hc.NodeCtx()
 
 	// Find the parent block for sealing task
 	parent := block
@@ -744,10 +771,10 @@ func (w *worker) prepareWork(genParams *generateParams, block *types.Block) (*en
 		timestamp = parent.Time() + 1
 	}
 	// Construct the sealing block header, set the extra field if it's allowed
-	num := parent.Number()
+	num := parent.Number(nodeCtx)
 	header := types.EmptyHeader()
-	header.SetParentHash(block.Header().Hash())
-	header.SetNumber(big.NewInt(int64(num.Uint64()) + 1))
+	header.SetParentHash(block.Header().Hash(), nodeCtx)
+	header.SetNumber(big.NewInt(int64(num.Uint64())+1), nodeCtx)
 	header.SetTime(timestamp)
 
 	// Only calculate entropy if the parent is not the genesis block
@@ -764,7 +791,7 @@ func (w *worker) prepareWork(genParams *generateParams, block *types.Block) (*en
 				header.SetParentDeltaS(w.engine.DeltaLogS(parent.Header()), nodeCtx)
 			}
 		}
-		header.SetParentEntropy(w.engine.TotalLogS(parent.Header()))
+		header.SetParentEntropy(w.engine.TotalLogS(parent.Header()), nodeCtx)
 	}
 
 	// Only zone should calculate state
@@ -784,6 +811,13 @@ func (w *worker) prepareWork(genParams *generateParams, block *types.Block) (*en
 			log.Error("Failed to prepare header for sealing", "err", err)
 			return nil, err
 		}
+		if w.hc.commitsEtxSet(parent.Header()) {
+			etxSet := w.hc.GetEtxSet(parent.Hash(), parent.NumberU64(nodeCtx))
+			if etxSet == nil {
+				return nil, errors.New("failed to load etx set")
+			}
+			header.SetEtxSetRoot(etxSet.Root(trie.NewStackTrie(nil)))
+		}
 		env, err := w.makeEnv(parent, header, w.coinbase)
 		if err != nil {
 			log.Error("Failed to create sealing context", "err", err)
@@ -817,21 +851,39 @@ func (w *worker) prepareWork(genParams *generateParams, block *types.Block) (*en
 
 }
 
-// fillTransactions retrieves the pending transactions from the txpool and fills them
-// into the given sealing block. The transaction selection and ordering strategy can
-// be customized with the plugin in the future.
+// fillTransactions fills the given sealing block with the bundles targeting it
+// and the pending transactions of the txpool. The selection and ordering of the
+// transactions is decided by the block builder of the worker.
 func (w *worker) fillTransactions(interrupt *int32, env *environment, block *types.Block) {
+	nodeCtx := w.hc.NodeCtx()
 	// Split the pending transactions into locals and remotes
 	// Fill the block with all available pending transactions.
-	etxSet := rawdb.ReadEtxSet(w.hc.bc.db, block.Hash(), block.NumberU64())
+	etxSet := w.hc.GetEtxSet(block.Hash(), block.NumberU64(nodeCtx))
 	if etxSet == nil {
 		return
 	}
-	etxSet.Update(types.Transactions{}, block.NumberU64()) // Prune any expired ETXs
+	etxSet.Update(types.Transactions{}, block.NumberU64(nodeCtx), w.hc.NodeLocation(), w.chainConfig.GetTopology()) // Prune any expired ETXs
 	pending, err := w.txPool.TxPoolPending(true, etxSet)
 	if err != nil {
 		return
 	}
+	w.mu.RLock()
+	builder := w.builder
+	w.mu.RUnlock()
+
+	// Bundles go to the top of the block, ahead of any transaction of the pool
+	if env.gasPool == nil {
+		env.gasPool = new(GasPool).AddGas(env.header.GasLimit())
+	}
+	w.commitBundles(env)
+
+	// Inbound ETXs are sent from other zones and are not subject to the builder
+	for account := range pending {
+		addr := common.Bytes20ToAddress(account)
+		if w.chainConfig.GetTopology().ContainsAddress(w.hc.NodeLocation(), addr) && !builder.AllowSender(addr) {
+			delete(pending, account)
+		}
+	}
 	localTxs, remoteTxs := make(map[common.AddressBytes]types.Transactions), pending
 	for _, account := range w.txPool.Locals() {
 		if txs := remoteTxs[account.Bytes20()]; len(txs) > 0 {
@@ -839,14 +891,23 @@ func (w *worker) fillTransactions(interrupt *int32, env *environment, block *typ
 			localTxs[account.Bytes20()] = txs
 		}
 	}
-	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee(), false)
-		if w.commitTransactions(env, txs, interrupt) {
-			return
-		}
+	// Withhold the ETX capacity the builder does not grant to the pool
+	etxRLimit, etxPLimit := builder.ETXLimits(block, env.etxRLimit, env.etxPLimit)
+	reservedR, reservedP := 0, 0
+	if etxRLimit < env.etxRLimit {
+		reservedR = env.etxRLimit - etxRLimit
+	}
+	if etxPLimit < env.etxPLimit {
+		reservedP = env.etxPLimit - etxPLimit
 	}
-	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee(), false)
+	env.etxRLimit -= reservedR
+	env.etxPLimit -= reservedP
+	defer func() {
+		env.etxRLimit += reservedR
+		env.etxPLimit += reservedP
+	}()
+
+	for _, txs := range builder.Order(env.signer, env.header.BaseFee(), localTxs, remoteTxs) {
 		if w.commitTransactions(env, txs, interrupt) {
 			return
 		}
@@ -866,7 +927,7 @@ func (w *worker) adjustGasLimit(interrupt *int32, env *environment, parent *type
 }
 
 func (w *worker) FinalizeAssemble(chain consensus.ChainHeaderReader, header *types.Header, parent *types.Block, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, etxs []*types.Transaction, subManifest types.BlockManifest, receipts []*types.Receipt) (*types.Block, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := w.hc.NodeCtx()
 	block, err := w.engine.FinalizeAndAssemble(chain, header, state, txs, uncles, etxs, subManifest, receipts)
 	if err != nil {
 		return nil, err
@@ -880,11 +941,11 @@ func (w *worker) FinalizeAssemble(chain consensus.ChainHeaderReader, header *typ
 	} else if w.engine.IsDomCoincident(w.hc, parent.Header()) {
 		manifest = types.BlockManifest{parent.Hash()}
 	} else {
-		parentManifest := rawdb.ReadManifest(w.workerDb, parent.ParentHash())
+		parentManifest := rawdb.ReadManifest(w.workerDb, parent.ParentHash(nodeCtx))
 		manifest = append(parentManifest, parent.Hash())
 	}
 	manifestHash := types.DeriveSha(manifest, trie.NewStackTrie(nil))
-	block.Header().SetManifestHash(manifestHash)
+	block.Header().SetManifestHash(manifestHash, nodeCtx)
 
 	// write the manifest into the disk
 	rawdb.WriteManifest(w.workerDb, parent.Hash(), manifest)
@@ -915,13 +976,14 @@ func (w *worker) FinalizeAssemble(chain consensus.ChainHeaderReader, header *typ
 // Note the assumption is held that the mutation is allowed to the passed env, do
 // the deep copy first.
 func (w *worker) commit(env *environment, interval func(), update bool, start time.Time) error {
+	nodeCtx := w.hc.NodeCtx()
 	if w.isRunning() {
 		if interval != nil {
 			interval()
 		}
 		// Create a local environment copy, avoid the data race with snapshot state.
-		env := env.copy()
-		parent := w.hc.GetBlock(env.header.ParentHash(), env.header.NumberU64()-1)
+		env := env.copy(w.hc.NodeCtx())
+		parent := w.hc.GetBlock(env.header.ParentHash(nodeCtx), env.header.NumberU64(nodeCtx)-1)
 		block, err := w.FinalizeAssemble(w.hc, env.header, parent, env.state, env.txs, env.unclelist(), env.etxs, env.subManifest, env.receipts)
 		if err != nil {
 			return err
@@ -930,7 +992,7 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		select {
 		case w.taskCh <- &task{receipts: env.receipts, state: env.state, block: block, createdAt: time.Now()}:
 			env.uncleMu.RLock()
-			log.Info("Commit new sealing work", "number", block.Number(), "sealhash", block.Header().SealHash(),
+			log.Info("Commit new sealing work", "number", block.Number(nodeCtx), "sealhash", block.Header().SealHash(),
 				"uncles", len(env.uncles), "txs", env.tcount, "etxs", len(block.ExtTransactions()),
 				"gas", block.GasUsed(), "fees", totalFees(block, env.receipts),
 				"elapsed", common.PrettyDuration(time.Since(start)))
@@ -996,5 +1058,6 @@ func totalFees(block *types.Block, receipts []*types.Receipt) *big.Float {
 }
 
 func (w *worker) CurrentInfo(header *types.Header) bool {
-	return header.NumberU64()+c_startingPrintLimit > w.hc.CurrentHeader().NumberU64()
+	nodeCtx := w.hc.NodeCtx()
+	return header.NumberU64(nodeCtx)+c_startingPrintLimit > w.hc.CurrentHeader().NumberU64(nodeCtx)
 }
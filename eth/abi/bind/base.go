@@ -0,0 +1,579 @@
+package bind
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	quai "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/dominant-strategies/go-quai/eth/abi"
+	"github.com/dominant-strategies/go-quai/event"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+// SignerFn is a signer function callback when a contract requires a method to
+// sign the transaction before submission.
+type SignerFn func(common.Address, *types.Transaction) (*types.Transaction, error)
+
+// CallOpts is the collection of options to fine tune a contract call request.
+type CallOpts struct {
+	Pending     bool            // Whether to operate on the pending state or the last known one
+	From        common.Address  // Optional the sender address, otherwise the first account is used
+	BlockNumber *big.Int        // Optional the block number on which the call should be performed
+	Context     context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// TransactOpts is the collection of authorization data required to create a
+// valid Quai transaction.
+type TransactOpts struct {
+	From   common.Address // Quai account to send the transaction from
+	Nonce  *big.Int       // Nonce to use for the transaction execution (nil = use pending state)
+	Signer SignerFn       // Method to use for signing the transaction (mandatory)
+
+	Value     *big.Int // Funds to transfer along the transaction (nil = 0 = no funds)
+	GasPrice  *big.Int // Gas price to use for both the fee cap and the tip (nil = use the fee cap and tip)
+	GasFeeCap *big.Int // Gas fee cap to use for the transaction execution (nil = gas price oracle)
+	GasTipCap *big.Int // Gas priority fee cap to use for the transaction execution (nil = gas price oracle)
+	GasLimit  uint64   // Gas limit to set for the transaction execution (0 = estimate)
+
+	Topology *common.Topology // Topology of the hierarchy of the network (nil = common.DefaultTopology)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// topology returns the topology of the hierarchy the transaction is sent to.
+func (opts *TransactOpts) topology() *common.Topology {
+	if opts.Topology == nil {
+		return common.DefaultTopology
+	}
+	return opts.Topology
+}
+
+// ETXOpts is the collection of gas parameters of the external transaction
+// emitted by a transaction to a contract in another zone.
+type ETXOpts struct {
+	GasLimit   uint64           // Gas limit of the ETX in its destination (0 = estimate in the destination)
+	GasPrice   *big.Int         // Gas price of the ETX (nil = fee cap of the transaction times the etx fee multiplier)
+	GasTip     *big.Int         // Gas tip of the ETX (nil = tip of the transaction times the etx fee multiplier)
+	AccessList types.AccessList // Access list of the ETX in its destination
+}
+
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	Start uint64  // Start of the queried range
+	End   *uint64 // End of the range (nil = latest)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// WatchOpts is the collection of options to fine tune subscribing for events
+// within a bound contract.
+type WatchOpts struct {
+	Start   *uint64         // Start of the queried range (nil = latest)
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// BoundContract is the base wrapper object that reflects a contract on the
+// Quai network. It contains a collection of methods that are used by the
+// higher level contract bindings to operate.
+type BoundContract struct {
+	address    common.Address     // Deployment address of the contract on the Quai blockchain
+	abi        abi.ABI            // Reflect based ABI to access the correct Quai methods
+	caller     ContractCaller     // Read interface to interact with the blockchain
+	transactor ContractTransactor // Write interface to interact with the blockchain
+	filterer   ContractFilterer   // Event filtering to interact with the blockchain
+}
+
+// NewBoundContract creates a low level contract interface through which calls
+// and transactions may be made through.
+func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *BoundContract {
+	return &BoundContract{
+		address:    address,
+		abi:        abi,
+		caller:     caller,
+		transactor: transactor,
+		filterer:   filterer,
+	}
+}
+
+// DeployContract deploys a contract onto the Quai blockchain and binds the
+// deployment address with a Go wrapper. Contracts have to live in the zone of
+// their deployer, so a salt is appended to the deployment code until the
+// contract address falls into it.
+func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *BoundContract, error) {
+	c := NewBoundContract(common.Address{}, abi, backend, backend, backend)
+
+	input, err := c.abi.Pack("", params...)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	nonce, err := c.nonce(opts)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	zone, err := zoneOf(opts.From, opts.topology())
+	if err != nil {
+		return common.Address{}, nil, nil, fmt.Errorf("invalid deployer: %v", err)
+	}
+	code := append(bytecode, input...)
+	code, address := grindDeployment(opts.From, nonce, code, zone, opts.topology())
+
+	deployOpts := *opts
+	deployOpts.Nonce = new(big.Int).SetUint64(nonce)
+	tx, err := c.transact(&deployOpts, nil, code)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	c.address = address
+	return c.address, tx, c, nil
+}
+
+// grindDeployment appends a salt to the deployment code until the address of
+// the contract created by it lies in the given zone of the topology. Constructor
+// arguments are decoded from the end of the code they are appended to, so the
+// salt does not affect them.
+func grindDeployment(from common.Address, nonce uint64, code []byte, zone common.Location, topology *common.Topology) ([]byte, common.Address) {
+	salted := make([]byte, len(code)+8)
+	copy(salted, code)
+	for salt := uint64(0); ; salt++ {
+		binary.BigEndian.PutUint64(salted[len(code):], salt)
+		if address := crypto.CreateAddress(from, nonce, salted); topology.ContainsAddress(zone, address) {
+			return salted, address
+		}
+	}
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (c *BoundContract) Call(opts *CallOpts, results *[]interface{}, method string, params ...interface{}) error {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(CallOpts)
+	}
+	if results == nil {
+		results = new([]interface{})
+	}
+	// Pack the input, call and unpack the results
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	var (
+		msg    = quai.CallMsg{From: opts.From, To: &c.address, Data: input}
+		ctx    = ensureContext(opts.Context)
+		code   []byte
+		output []byte
+	)
+	if opts.Pending {
+		pb, ok := c.caller.(PendingContractCaller)
+		if !ok {
+			return ErrNoPendingState
+		}
+		output, err = pb.PendingCallContract(ctx, msg)
+		if err == nil && len(output) == 0 {
+			// Make sure we have a contract to operate on, and bail out otherwise.
+			if code, err = pb.PendingCodeAt(ctx, c.address); err != nil {
+				return err
+			} else if len(code) == 0 {
+				return ErrNoCode
+			}
+		}
+	} else {
+		output, err = c.caller.CallContract(ctx, msg, opts.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if len(output) == 0 {
+			// Make sure we have a contract to operate on, and bail out otherwise.
+			if code, err = c.caller.CodeAt(ctx, c.address, opts.BlockNumber); err != nil {
+				return err
+			} else if len(code) == 0 {
+				return ErrNoCode
+			}
+		}
+	}
+
+	if len(*results) == 0 {
+		res, err := c.abi.Unpack(method, output)
+		*results = res
+		return err
+	}
+	res := *results
+	return c.abi.UnpackIntoInterface(res[0], method, output)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.transact(opts, &c.address, input)
+}
+
+// RawTransact initiates a transaction with the given raw calldata as input.
+// It's usually used to initiate transactions for invoking **Fallback** function.
+func (c *BoundContract) RawTransact(opts *TransactOpts, calldata []byte) (*types.Transaction, error) {
+	// todo(rjl493456442) check the method is payable or not,
+	// reject invalid transaction at the first time
+	return c.transact(opts, &c.address, calldata)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (c *BoundContract) Transfer(opts *TransactOpts) (*types.Transaction, error) {
+	return c.transact(opts, &c.address, nil)
+}
+
+// TransactExternal invokes the (paid) contract method of a contract in another
+// zone. The transaction is sent as an InternalToExternalTx in the zone of the
+// sender, emitting an ETX which calls the method in the zone of the contract.
+func (c *BoundContract) TransactExternal(opts *TransactOpts, etx *ETXOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.transactExternal(opts, etx, input)
+}
+
+// RawTransactExternal initiates a transaction with the given raw calldata as
+// the input of the ETX sent to a contract in another zone.
+func (c *BoundContract) RawTransactExternal(opts *TransactOpts, etx *ETXOpts, calldata []byte) (*types.Transaction, error) {
+	return c.transactExternal(opts, etx, calldata)
+}
+
+// TransferExternal initiates a transaction moving funds to a contract in
+// another zone, calling its default method if one is available.
+func (c *BoundContract) TransferExternal(opts *TransactOpts, etx *ETXOpts) (*types.Transaction, error) {
+	return c.transactExternal(opts, etx, nil)
+}
+
+// nonce returns the nonce of the transaction, retrieving the pending nonce of
+// the sender unless one is given.
+func (c *BoundContract) nonce(opts *TransactOpts) (uint64, error) {
+	if opts.Nonce != nil {
+		return opts.Nonce.Uint64(), nil
+	}
+	nonce, err := c.transactor.PendingNonceAt(ensureContext(opts.Context), opts.From)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve account nonce: %v", err)
+	}
+	return nonce, nil
+}
+
+// fees returns the fee cap and tip of the transaction, suggesting any which
+// is not given.
+func (c *BoundContract) fees(opts *TransactOpts) (*big.Int, *big.Int, error) {
+	if opts.GasPrice != nil {
+		return opts.GasPrice, opts.GasPrice, nil
+	}
+	gasTipCap := opts.GasTipCap
+	if gasTipCap == nil {
+		tip, err := c.transactor.SuggestGasTipCap(ensureContext(opts.Context))
+		if err != nil {
+			return nil, nil, err
+		}
+		gasTipCap = tip
+	}
+	gasFeeCap := opts.GasFeeCap
+	if gasFeeCap == nil {
+		head, err := c.transactor.HeaderByNumber(ensureContext(opts.Context), nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		gasFeeCap = new(big.Int).Add(gasTipCap, new(big.Int).Mul(head.BaseFee(), big.NewInt(2)))
+	}
+	if gasFeeCap.Cmp(gasTipCap) < 0 {
+		return nil, nil, fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", gasFeeCap, gasTipCap)
+	}
+	return gasFeeCap, gasTipCap, nil
+}
+
+// transact executes an actual transaction invocation, first deriving any missing
+// authorization fields, and then scheduling the transaction for execution.
+func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, input []byte) (*types.Transaction, error) {
+	if opts.GasPrice != nil && (opts.GasFeeCap != nil || opts.GasTipCap != nil) {
+		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	// Ensure a valid value field and resolve the account nonce
+	value := opts.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	nonce, err := c.nonce(opts)
+	if err != nil {
+		return nil, err
+	}
+	gasFeeCap, gasTipCap, err := c.fees(opts)
+	if err != nil {
+		return nil, err
+	}
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		// Gas estimation cannot succeed without code for method invocations
+		if contract != nil {
+			if code, err := c.transactor.PendingCodeAt(ensureContext(opts.Context), c.address); err != nil {
+				return nil, err
+			} else if len(code) == 0 {
+				return nil, ErrNoCode
+			}
+		}
+		// If the contract surely has code (or code is not needed), estimate the transaction
+		msg := quai.CallMsg{From: opts.From, To: contract, GasFeeCap: gasFeeCap, GasTipCap: gasTipCap, Value: value, Data: input}
+		gasLimit, err = c.transactor.EstimateGas(ensureContext(opts.Context), msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
+		}
+	}
+	rawTx := types.NewTx(&types.InternalTx{
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gasLimit,
+		To:        contract,
+		Value:     value,
+		Data:      input,
+	})
+	return c.send(opts, rawTx)
+}
+
+// transactExternal executes a transaction to a contract in another zone,
+// deriving any missing authorization and etx fields, and then scheduling the
+// transaction for execution in the zone of the sender.
+func (c *BoundContract) transactExternal(opts *TransactOpts, etx *ETXOpts, input []byte) (*types.Transaction, error) {
+	if opts.GasPrice != nil && (opts.GasFeeCap != nil || opts.GasTipCap != nil) {
+		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	if etx == nil {
+		etx = new(ETXOpts)
+	}
+	from, err := zoneOf(opts.From, opts.topology())
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender: %v", err)
+	}
+	to, err := zoneOf(c.address, opts.topology())
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract: %v", err)
+	}
+	if from.Equal(to) {
+		return nil, fmt.Errorf("contract %v is in the zone of the sender", c.address)
+	}
+	value := opts.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	nonce, err := c.nonce(opts)
+	if err != nil {
+		return nil, err
+	}
+	gasFeeCap, gasTipCap, err := c.fees(opts)
+	if err != nil {
+		return nil, err
+	}
+	// The transaction itself only pays for emitting the ETX, whose gas is
+	// bought up front in the zone of the sender
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		gasLimit = params.TxGas + params.ETXGas
+	}
+	etxGasLimit := etx.GasLimit
+	if etxGasLimit == 0 {
+		estimator, ok := c.transactor.(ExternalGasEstimator)
+		if !ok {
+			return nil, ErrNoExternalGasEstimator
+		}
+		msg := quai.CallMsg{From: opts.From, To: &c.address, Value: value, Data: input, AccessList: etx.AccessList}
+		etxGasLimit, err = estimator.EstimateExternalGas(ensureContext(opts.Context), msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate etx gas needed: %v", err)
+		}
+	}
+	// The ETX pays a multiple of the fees, one for every chain confirming it
+	feeMul := etxFeeMultiplier(from, to, opts.topology())
+	etxGasPrice := etx.GasPrice
+	if etxGasPrice == nil {
+		etxGasPrice = new(big.Int).Mul(gasFeeCap, feeMul)
+	}
+	etxGasTip := etx.GasTip
+	if etxGasTip == nil {
+		etxGasTip = new(big.Int).Mul(gasTipCap, feeMul)
+	}
+	rawTx := types.NewTx(&types.InternalToExternalTx{
+		Nonce:         nonce,
+		GasTipCap:     gasTipCap,
+		GasFeeCap:     gasFeeCap,
+		Gas:           gasLimit,
+		To:            &c.address,
+		Value:         value,
+		ETXGasLimit:   etxGasLimit,
+		ETXGasPrice:   etxGasPrice,
+		ETXGasTip:     etxGasTip,
+		ETXData:       input,
+		ETXAccessList: etx.AccessList,
+	})
+	return c.send(opts, rawTx)
+}
+
+// send signs a transaction and schedules it for execution.
+func (c *BoundContract) send(opts *TransactOpts, rawTx *types.Transaction) (*types.Transaction, error) {
+	if opts.Signer == nil {
+		return nil, errors.New("no signer to authorize the transaction with")
+	}
+	signedTx, err := opts.Signer(opts.From, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.transactor.SendTransaction(ensureContext(opts.Context), signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// FilterLogs filters contract logs for past blocks, returning the necessary
+// channels to construct a strongly typed bound iterator on top of them.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(FilterOpts)
+	}
+	// Append the event selector to the query parameters and construct the topic set
+	query = append([][]interface{}{{c.abi.Events[name].ID}}, query...)
+
+	topics, err := abi.MakeTopics(query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Start the background filtering
+	logs := make(chan types.Log, 128)
+
+	config := quai.FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+		FromBlock: new(big.Int).SetUint64(opts.Start),
+	}
+	if opts.End != nil {
+		config.ToBlock = new(big.Int).SetUint64(*opts.End)
+	}
+	/* TODO(karalabe): Replace the rest of the method below with this when supported
+	sub, err := c.filterer.SubscribeFilterLogs(ensureContext(opts.Context), config, logs)
+	*/
+	buff, err := c.filterer.FilterLogs(ensureContext(opts.Context), config)
+	if err != nil {
+		return nil, nil, err
+	}
+	sub, err := event.NewSubscription(func(quit <-chan struct{}) error {
+		for _, log := range buff {
+			select {
+			case logs <- log:
+			case <-quit:
+				return nil
+			}
+		}
+		return nil
+	}), nil
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return logs, sub, nil
+}
+
+// WatchLogs filters subscribes to contract logs for future blocks, returning a
+// subscription object that can be used to tear down the watcher.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(WatchOpts)
+	}
+	// Append the event selector to the query parameters and construct the topic set
+	query = append([][]interface{}{{c.abi.Events[name].ID}}, query...)
+
+	topics, err := abi.MakeTopics(query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Start the background filtering
+	logs := make(chan types.Log, 128)
+
+	config := quai.FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+	}
+	if opts.Start != nil {
+		config.FromBlock = new(big.Int).SetUint64(*opts.Start)
+	}
+	sub, err := c.filterer.SubscribeFilterLogs(ensureContext(opts.Context), config, logs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logs, sub, nil
+}
+
+// UnpackLog unpacks a retrieved log into the provided output structure.
+func (c *BoundContract) UnpackLog(out interface{}, event string, log types.Log) error {
+	if len(log.Data) > 0 {
+		if err := c.abi.UnpackIntoInterface(out, event, log.Data); err != nil {
+			return err
+		}
+	}
+	var indexed abi.Arguments
+	for _, arg := range c.abi.Events[event].Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	return abi.ParseTopics(out, indexed, log.Topics[1:])
+}
+
+// UnpackLogIntoMap unpacks a retrieved log into the provided map.
+func (c *BoundContract) UnpackLogIntoMap(out map[string]interface{}, event string, log types.Log) error {
+	if len(log.Data) > 0 {
+		if err := c.abi.UnpackIntoMap(out, event, log.Data); err != nil {
+			return err
+		}
+	}
+	var indexed abi.Arguments
+	for _, arg := range c.abi.Events[event].Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	return abi.ParseTopicsIntoMap(out, indexed, log.Topics[1:])
+}
+
+// zoneOf returns the zone of the topology whose address space contains an
+// address.
+func zoneOf(address common.Address, topology *common.Topology) (common.Location, error) {
+	if location := address.Location(topology); location != nil {
+		return *location, nil
+	}
+	return nil, fmt.Errorf("address %v is not in any zone", address)
+}
+
+// etxFeeMultiplier returns the multiple of the transaction fees an ETX pays,
+// which is the number of zones confirming it: those of the region, or those of
+// every region when it leaves the region of the sender.
+func etxFeeMultiplier(from, to common.Location, topology *common.Topology) *big.Int {
+	multiplier := big.NewInt(int64(topology.Zones))
+	if from.CommonDom(to).Context() == common.PRIME_CTX {
+		multiplier.Mul(multiplier, big.NewInt(int64(topology.Regions)))
+	}
+	return multiplier
+}
+
+// ensureContext is a helper method to ensure a context is not nil, even if the
+// user specified it as such.
+func ensureContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
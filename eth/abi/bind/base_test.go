@@ -0,0 +1,143 @@
+package bind_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/simulated"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/dominant-strategies/go-quai/eth/abi"
+	"github.com/dominant-strategies/go-quai/eth/abi/bind"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+// storageABI is the interface of a contract storing a single number.
+const storageABI = `[
+	{"type":"function","name":"get","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"set","stateMutability":"nonpayable","inputs":[{"name":"value","type":"uint256"}],"outputs":[]}
+]`
+
+// storageBin is the hand assembled deploy code of storageABI. The runtime
+// dispatches on the selectors of get() and set(uint256), and stops on any
+// other call.
+const storageBin = "602e80600b6000396000f3" +
+	"60003560e01c80636d4ce63c14601a576360fe47b114602657005b60005460005260206000f35b60043560005500"
+
+// zoneKey derives a deterministic key whose address lies in the given zone.
+func zoneKey(t *testing.T, zone common.Location) (*ecdsa.PrivateKey, common.Address) {
+	for i := uint64(0); ; i++ {
+		var seed [8]byte
+		binary.BigEndian.PutUint64(seed[:], i)
+		key, err := crypto.ToECDSA(crypto.Keccak256(seed[:]))
+		if err != nil {
+			t.Fatalf("failed to derive key: %v", err)
+		}
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		if common.DefaultTopology.ContainsAddress(zone, addr) {
+			return key, addr
+		}
+	}
+}
+
+// Tests that a contract is deployed to an address in the zone of the deployer
+// and that its paid methods can be called from another zone through an ETX.
+func TestTransactExternal(t *testing.T) {
+	var (
+		from, to    = common.Location{0, 0}, common.Location{0, 1}
+		key, sender = zoneKey(t, from)
+		dkey, owner = zoneKey(t, to)
+		ctx         = context.Background()
+	)
+	h, err := simulated.NewHierarchy([]common.Address{sender, owner}, 0)
+	if err != nil {
+		t.Fatalf("failed to create hierarchy: %v", err)
+	}
+	defer h.Close()
+
+	// Fund both accounts with the rewards of a zone block
+	for _, zone := range []common.Location{from, to} {
+		if _, err := h.Commit(zone); err != nil {
+			t.Fatalf("failed to mine funding block in %v: %v", zone, err)
+		}
+	}
+	source, err := h.Client(from)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	destination, err := h.Client(to)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(storageABI))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	// Deploy the contract in the destination zone
+	deployer, err := bind.NewKeyedTransactorWithChainID(dkey, h.Config().ChainID)
+	if err != nil {
+		t.Fatalf("failed to create transactor: %v", err)
+	}
+	address, tx, _, err := bind.DeployContract(deployer, parsed, common.FromHex(storageBin), destination)
+	if err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	if !common.DefaultTopology.ContainsAddress(to, address) {
+		t.Fatalf("contract deployed out of zone: %v", address)
+	}
+	if _, err := h.Commit(to); err != nil {
+		t.Fatalf("failed to mine deployment: %v", err)
+	}
+	if deployed, err := bind.WaitDeployed(ctx, destination, tx); err != nil || !deployed.Equal(address) {
+		t.Fatalf("deployment mismatch: have %v, want %v, err %v", deployed, address, err)
+	}
+	// Calling the contract from its own zone as an ETX is rejected
+	local := bind.NewBoundContract(address, parsed, destination, destination, destination)
+	if _, err := local.TransactExternal(deployer, nil, "set", big.NewInt(1)); err == nil {
+		t.Fatalf("etx to the sender zone accepted")
+	}
+	// Send the call from the source zone and carry it into the destination
+	auth, err := bind.NewKeyedTransactorWithChainID(key, h.Config().ChainID)
+	if err != nil {
+		t.Fatalf("failed to create transactor: %v", err)
+	}
+	remote := bind.NewBoundContract(address, parsed, source, source, source)
+	tx, err = remote.TransactExternal(auth, nil, "set", big.NewInt(42))
+	if err != nil {
+		t.Fatalf("failed to send external transaction: %v", err)
+	}
+	if tx.Type() != types.InternalToExternalTxType {
+		t.Fatalf("transaction type mismatch: have %d, want %d", tx.Type(), types.InternalToExternalTxType)
+	}
+	if tx.Gas() != params.TxGas+params.ETXGas || tx.ETXGasLimit() <= params.TxGas {
+		t.Fatalf("gas mismatch: have %d/%d", tx.Gas(), tx.ETXGasLimit())
+	}
+	block, err := h.Commit(from)
+	if err != nil {
+		t.Fatalf("failed to mine transaction: %v", err)
+	}
+	if len(block.ExtTransactions()) != 1 {
+		t.Fatalf("etx not emitted: have %d", len(block.ExtTransactions()))
+	}
+	if _, err := h.Mine(from, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine source region block: %v", err)
+	}
+	if _, err := h.Mine(to, common.REGION_CTX); err != nil {
+		t.Fatalf("failed to mine destination region block: %v", err)
+	}
+	if _, err := h.Commit(to); err != nil {
+		t.Fatalf("failed to mine destination zone block: %v", err)
+	}
+	var out []interface{}
+	if err := local.Call(nil, &out, "get"); err != nil {
+		t.Fatalf("failed to call contract: %v", err)
+	}
+	if have := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int); have.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("stored value mismatch: have %v, want 42", have)
+	}
+}
@@ -0,0 +1,63 @@
+package bind
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// tokenABI is the interface of a minimal token with calls, transactions,
+// events and the special fallback and receive functions.
+const tokenABI = `[
+	{"type":"constructor","inputs":[{"name":"supply","type":"uint256"}],"stateMutability":"nonpayable"},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"info","stateMutability":"view","inputs":[],"outputs":[{"name":"name","type":"string"},{"name":"decimals","type":"uint8"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"fallback","stateMutability":"payable"},
+	{"type":"receive","stateMutability":"payable"}
+]`
+
+// Tests that the generated binding is valid Go and contains the regular and
+// the cross-zone accessors of every method.
+func TestBindToken(t *testing.T) {
+	code, err := Bind([]string{"Token"}, []string{tokenABI}, []string{"6000"}, nil, "token", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate binding: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "token.go", code, 0); err != nil {
+		t.Fatalf("generated binding is not valid Go: %v", err)
+	}
+	for _, want := range []string{
+		"package token",
+		"func DeployToken(auth *bind.TransactOpts, backend bind.ContractBackend, supply *big.Int) (common.Address, *types.Transaction, *Token, error)",
+		"func (_Token *TokenCaller) BalanceOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error)",
+		"func (_Token *TokenCaller) Info(opts *bind.CallOpts) (struct {",
+		"func (_Token *TokenTransactor) Transfer(opts *bind.TransactOpts, to common.Address, value *big.Int) (*types.Transaction, error)",
+		"func (_Token *TokenTransactor) TransferExternal(opts *bind.TransactOpts, etx *bind.ETXOpts, to common.Address, value *big.Int) (*types.Transaction, error)",
+		"func (_Token *TokenSession) TransferExternal(etx *bind.ETXOpts, to common.Address, value *big.Int) (*types.Transaction, error)",
+		"func (_Token *TokenTransactor) FallbackExternal(opts *bind.TransactOpts, etx *bind.ETXOpts, calldata []byte) (*types.Transaction, error)",
+		"func (_Token *TokenTransactor) ReceiveExternal(opts *bind.TransactOpts, etx *bind.ETXOpts) (*types.Transaction, error)",
+		"func (_Token *TokenRaw) TransactExternal(opts *bind.TransactOpts, etx *bind.ETXOpts, method string, params ...interface{}) (*types.Transaction, error)",
+		"func (_Token *TokenFilterer) FilterTransfer(opts *bind.FilterOpts, from []common.Address, to []common.Address) (*TokenTransferIterator, error)",
+		"func (_Token *TokenFilterer) WatchTransfer(opts *bind.WatchOpts, sink chan<- *TokenTransfer, from []common.Address, to []common.Address) (event.Subscription, error)",
+		"func (_Token *TokenFilterer) ParseTransfer(log types.Log) (*TokenTransfer, error)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("binding misses %q", want)
+		}
+	}
+}
+
+// Tests that methods whose cross-zone accessor would collide with another
+// method are rejected.
+func TestBindExternalCollision(t *testing.T) {
+	abi := `[
+		{"type":"function","name":"mint","inputs":[],"outputs":[]},
+		{"type":"function","name":"mintExternal","inputs":[],"outputs":[]}
+	]`
+	if _, err := Bind([]string{"Token"}, []string{abi}, []string{""}, nil, "token", nil, nil); err == nil {
+		t.Fatalf("colliding external accessor accepted")
+	}
+}
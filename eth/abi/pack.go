@@ -42,6 +42,9 @@ func packElement(t Type, reflectValue reflect.Value) ([]byte, error) {
 	case StringTy:
 		return packBytesSlice([]byte(reflectValue.String()), reflectValue.Len()), nil
 	case AddressTy:
+		if reflectValue.Type() == reflect.TypeOf(common.Address{}) {
+			return common.LeftPadBytes(reflectValue.Interface().(common.Address).Bytes(), 32), nil
+		}
 		if reflectValue.Kind() == reflect.Array {
 			reflectValue = mustArrayToByteSlice(reflectValue)
 		}
@@ -142,11 +142,12 @@ func NewPrivateAdminAPI(eth *Quai) *PrivateAdminAPI {
 // ExportChain exports the current blockchain into a local file,
 // or a range of blocks if first and last are non-nil
 func (api *PrivateAdminAPI) ExportChain(file string, first *uint64, last *uint64) (bool, error) {
+	nodeCtx := api.eth.core.NodeCtx()
 	if first == nil && last != nil {
 		return false, errors.New("last cannot be specified without first")
 	}
 	if first != nil && last == nil {
-		head := api.eth.Core().CurrentHeader().Number().Uint64()
+		head := api.eth.Core().CurrentHeader().Number(nodeCtx).Uint64()
 		last = &head
 	}
 	if _, err := os.Stat(file); err == nil {
@@ -180,7 +181,7 @@ func (api *PrivateAdminAPI) ExportChain(file string, first *uint64, last *uint64
 
 func hasAllBlocks(chain *core.Core, bs []*types.Block) bool {
 	for _, b := range bs {
-		if !chain.HasBlock(b.Hash(), b.NumberU64()) {
+		if !chain.HasBlock(b.Hash(), b.NumberU64(chain.NodeCtx())) {
 			return false
 		}
 	}
@@ -236,6 +237,55 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// AddBadHashes lists blocks of the chain at the given location as bad. The
+// chain is rewound below the oldest of them it contains, and rejects them from
+// then on. If propagate is set, chains below the node are updated through its
+// subordinates.
+func (api *PrivateAdminAPI) AddBadHashes(location hexutil.Bytes, hashes []common.Hash, propagate *bool) (bool, error) {
+	var update types.BlockListUpdate
+	for _, hash := range hashes {
+		update.AddBadHashes = append(update.AddBadHashes, types.BadHash{Location: common.Location(location), Hash: hash})
+	}
+	return api.updateBlockLists(update, propagate)
+}
+
+// RemoveBadHashes lets the chain at the given location accept the given blocks
+// again.
+func (api *PrivateAdminAPI) RemoveBadHashes(location hexutil.Bytes, hashes []common.Hash, propagate *bool) (bool, error) {
+	var update types.BlockListUpdate
+	for _, hash := range hashes {
+		update.RemoveBadHashes = append(update.RemoveBadHashes, types.BadHash{Location: common.Location(location), Hash: hash})
+	}
+	return api.updateBlockLists(update, propagate)
+}
+
+// AddCheckpoint trusts the given block of the chain at the given location. The
+// canonical block at its number, if another one, is listed as bad, and any
+// other block at that number is rejected from then on.
+func (api *PrivateAdminAPI) AddCheckpoint(location hexutil.Bytes, number hexutil.Uint64, hash common.Hash, propagate *bool) (bool, error) {
+	update := types.BlockListUpdate{
+		AddCheckpoints: []types.Checkpoint{{Location: common.Location(location), Number: number, Hash: hash}},
+	}
+	return api.updateBlockLists(update, propagate)
+}
+
+// RemoveCheckpoint removes the checkpoint at the given number of the chain at
+// the given location, and lets the chain accept the blocks it listed as bad
+// again.
+func (api *PrivateAdminAPI) RemoveCheckpoint(location hexutil.Bytes, number hexutil.Uint64, propagate *bool) (bool, error) {
+	update := types.BlockListUpdate{
+		RemoveCheckpoints: []types.Checkpoint{{Location: common.Location(location), Number: number}},
+	}
+	return api.updateBlockLists(update, propagate)
+}
+
+func (api *PrivateAdminAPI) updateBlockLists(update types.BlockListUpdate, propagate *bool) (bool, error) {
+	if err := api.eth.Core().UpdateBlockLists(update, propagate != nil && *propagate); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // PublicDebugAPI is the collection of Quai full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -318,7 +368,7 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 		} else {
 			blockRlp = fmt.Sprintf("0x%x", rlpBytes)
 		}
-		if blockJSON, err = quaiapi.RPCMarshalBlock(block, true, true); err != nil {
+		if blockJSON, err = quaiapi.RPCMarshalBlock(block, true, true, api.eth.core.NodeCtx()); err != nil {
 			blockJSON = map[string]interface{}{"error": err.Error()}
 		}
 		results = append(results, &BadBlockArgs{
@@ -409,7 +459,7 @@ func (api *PrivateDebugAPI) StorageRangeAt(blockHash common.Hash, txIndex int, c
 	if err != nil {
 		return StorageRangeResult{}, err
 	}
-	internal, err := contractAddress.InternalAddress()
+	internal, err := api.eth.core.Config().InternalAddress(contractAddress)
 	if err != nil {
 		return StorageRangeResult{}, err
 	}
@@ -449,6 +499,7 @@ func storageRangeAt(st state.Trie, start []byte, maxResult int) (StorageRangeRes
 //
 // With one parameter, returns the list of accounts modified in the specified block.
 func (api *PrivateDebugAPI) GetModifiedAccountsByNumber(startNum uint64, endNum *uint64) ([]common.Address, error) {
+	nodeCtx := api.eth.core.NodeCtx()
 	var startBlock, endBlock *types.Block
 
 	startBlock = api.eth.core.GetBlockByNumber(startNum)
@@ -458,9 +509,9 @@ func (api *PrivateDebugAPI) GetModifiedAccountsByNumber(startNum uint64, endNum
 
 	if endNum == nil {
 		endBlock = startBlock
-		startBlock = api.eth.core.GetBlockByHash(startBlock.ParentHash())
+		startBlock = api.eth.core.GetBlockByHash(startBlock.ParentHash(nodeCtx))
 		if startBlock == nil {
-			return nil, fmt.Errorf("block %x has no parent", endBlock.Number())
+			return nil, fmt.Errorf("block %x has no parent", endBlock.Number(nodeCtx))
 		}
 	} else {
 		endBlock = api.eth.core.GetBlockByNumber(*endNum)
@@ -477,6 +528,7 @@ func (api *PrivateDebugAPI) GetModifiedAccountsByNumber(startNum uint64, endNum
 //
 // With one parameter, returns the list of accounts modified in the specified block.
 func (api *PrivateDebugAPI) GetModifiedAccountsByHash(startHash common.Hash, endHash *common.Hash) ([]common.Address, error) {
+	nodeCtx := api.eth.core.NodeCtx()
 	var startBlock, endBlock *types.Block
 	startBlock = api.eth.core.GetBlockByHash(startHash)
 	if startBlock == nil {
@@ -485,9 +537,9 @@ func (api *PrivateDebugAPI) GetModifiedAccountsByHash(startHash common.Hash, end
 
 	if endHash == nil {
 		endBlock = startBlock
-		startBlock = api.eth.core.GetBlockByHash(startBlock.ParentHash())
+		startBlock = api.eth.core.GetBlockByHash(startBlock.ParentHash(nodeCtx))
 		if startBlock == nil {
-			return nil, fmt.Errorf("block %x has no parent", endBlock.Number())
+			return nil, fmt.Errorf("block %x has no parent", endBlock.Number(nodeCtx))
 		}
 	} else {
 		endBlock = api.eth.core.GetBlockByHash(*endHash)
@@ -499,8 +551,9 @@ func (api *PrivateDebugAPI) GetModifiedAccountsByHash(startHash common.Hash, end
 }
 
 func (api *PrivateDebugAPI) getModifiedAccounts(startBlock, endBlock *types.Block) ([]common.Address, error) {
-	if startBlock.Number().Uint64() >= endBlock.Number().Uint64() {
-		return nil, fmt.Errorf("start block height (%d) must be less than end block height (%d)", startBlock.Number().Uint64(), endBlock.Number().Uint64())
+	nodeCtx := api.eth.core.NodeCtx()
+	if startBlock.Number(nodeCtx).Uint64() >= endBlock.Number(nodeCtx).Uint64() {
+		return nil, fmt.Errorf("start block height (%d) must be less than end block height (%d)", startBlock.Number(nodeCtx).Uint64(), endBlock.Number(nodeCtx).Uint64())
 	}
 	triedb := api.eth.Core().StateCache().TrieDB()
 
@@ -23,6 +23,7 @@ import (
 
 	quai "github.com/dominant-strategies/go-quai"
 
+	"github.com/dominant-strategies/go-quai/accounts"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/consensus"
 	"github.com/dominant-strategies/go-quai/core"
@@ -52,7 +53,7 @@ func (b *QuaiAPIBackend) ChainConfig() *params.ChainConfig {
 }
 
 func (b *QuaiAPIBackend) TxPool() *core.TxPool {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil
 	}
@@ -88,10 +89,17 @@ func (b *QuaiAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNum
 	if number == rpc.LatestBlockNumber {
 		return b.eth.core.CurrentBlock().Header(), nil
 	}
+	if number == rpc.SafeBlockNumber {
+		return b.eth.core.CurrentSafeHeader(), nil
+	}
+	if number == rpc.FinalizedBlockNumber {
+		return b.eth.core.CurrentFinalizedHeader(), nil
+	}
 	return b.eth.core.GetHeaderByNumber(uint64(number)), nil
 }
 
 func (b *QuaiAPIBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	nodeCtx := b.eth.core.NodeCtx()
 	if blockNr, ok := blockNrOrHash.Number(); ok {
 		return b.HeaderByNumber(ctx, blockNr)
 	}
@@ -100,7 +108,7 @@ func (b *QuaiAPIBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash
 		if header == nil {
 			return nil, errors.New("header for hash not found")
 		}
-		if blockNrOrHash.RequireCanonical && b.eth.core.GetCanonicalHash(header.Number().Uint64()) != hash {
+		if blockNrOrHash.RequireCanonical && b.eth.core.GetCanonicalHash(header.Number(nodeCtx).Uint64()) != hash {
 			return nil, errors.New("hash is not currently canonical")
 		}
 		return header, nil
@@ -113,6 +121,7 @@ func (b *QuaiAPIBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*t
 }
 
 func (b *QuaiAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	nodeCtx := b.eth.core.NodeCtx()
 	// Pending block is only known by the miner
 	if number == rpc.PendingBlockNumber {
 		block := b.eth.core.PendingBlock()
@@ -122,6 +131,13 @@ func (b *QuaiAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumb
 	if number == rpc.LatestBlockNumber {
 		return b.eth.core.CurrentBlock(), nil
 	}
+	if number == rpc.SafeBlockNumber || number == rpc.FinalizedBlockNumber {
+		header, err := b.HeaderByNumber(ctx, number)
+		if err != nil || header == nil {
+			return nil, errors.New("block is nil api backend")
+		}
+		return b.eth.core.GetBlock(header.Hash(), header.NumberU64(nodeCtx)), nil
+	}
 	block := b.eth.core.GetBlockByNumber(uint64(number))
 	if block != nil {
 		return block, nil
@@ -134,6 +150,7 @@ func (b *QuaiAPIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*ty
 }
 
 func (b *QuaiAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	nodeCtx := b.eth.core.NodeCtx()
 	if blockNr, ok := blockNrOrHash.Number(); ok {
 		return b.BlockByNumber(ctx, blockNr)
 	}
@@ -142,10 +159,10 @@ func (b *QuaiAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash
 		if header == nil {
 			return nil, errors.New("header for hash not found")
 		}
-		if blockNrOrHash.RequireCanonical && b.eth.core.GetCanonicalHash(header.Number().Uint64()) != hash {
+		if blockNrOrHash.RequireCanonical && b.eth.core.GetCanonicalHash(header.Number(nodeCtx).Uint64()) != hash {
 			return nil, errors.New("hash is not currently canonical")
 		}
-		block := b.eth.core.GetBlock(hash, header.Number().Uint64())
+		block := b.eth.core.GetBlock(hash, header.Number(nodeCtx).Uint64())
 		if block == nil {
 			return nil, errors.New("header found, but block body is missing")
 		}
@@ -159,7 +176,7 @@ func (b *QuaiAPIBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts
 }
 
 func (b *QuaiAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, nil, errors.New("stateAndHeaderByNumber can only be called in zone chain")
 	}
@@ -181,7 +198,7 @@ func (b *QuaiAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.
 }
 
 func (b *QuaiAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, nil, errors.New("stateAndHeaderByNumberOrHash can only be called in zone chain")
 	}
@@ -196,7 +213,7 @@ func (b *QuaiAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, block
 		if header == nil {
 			return nil, nil, errors.New("header for hash not found")
 		}
-		if blockNrOrHash.RequireCanonical && b.eth.core.GetCanonicalHash(header.Number().Uint64()) != hash {
+		if blockNrOrHash.RequireCanonical && b.eth.core.GetCanonicalHash(header.Number(nodeCtx).Uint64()) != hash {
 			return nil, nil, errors.New("hash is not currently canonical")
 		}
 		stateDb, err := b.eth.Core().StateAt(header.Root())
@@ -206,7 +223,7 @@ func (b *QuaiAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, block
 }
 
 func (b *QuaiAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getReceipts can only be called in zone chain")
 	}
@@ -215,7 +232,7 @@ func (b *QuaiAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (typ
 
 // GetBloom returns the bloom for the given block hash
 func (b *QuaiAPIBackend) GetBloom(hash common.Hash) (*types.Bloom, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getBloom can only be called in zone chain")
 	}
@@ -223,7 +240,7 @@ func (b *QuaiAPIBackend) GetBloom(hash common.Hash) (*types.Bloom, error) {
 }
 
 func (b *QuaiAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getLogs can only be called in zone chain")
 	}
@@ -240,7 +257,7 @@ func (b *QuaiAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*ty
 
 func (b *QuaiAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
 	vmError := func() error { return nil }
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, vmError, errors.New("getEvm can only be called in zone chain")
 	}
@@ -253,7 +270,7 @@ func (b *QuaiAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *st
 }
 
 func (b *QuaiAPIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil
 	}
@@ -261,7 +278,7 @@ func (b *QuaiAPIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEve
 }
 
 func (b *QuaiAPIBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil
 	}
@@ -281,7 +298,7 @@ func (b *QuaiAPIBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent)
 }
 
 func (b *QuaiAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil
 	}
@@ -289,15 +306,31 @@ func (b *QuaiAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscr
 }
 
 func (b *QuaiAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return errors.New("sendTx can only be called in zone chain")
 	}
 	return b.eth.Core().AddLocal(signedTx)
 }
 
+func (b *QuaiAPIBackend) SendBundle(ctx context.Context, bundle *core.Bundle) error {
+	nodeCtx := b.eth.core.NodeCtx()
+	if nodeCtx != common.ZONE_CTX {
+		return errors.New("sendBundle can only be called in zone chain")
+	}
+	return b.eth.core.SendBundle(bundle)
+}
+
+func (b *QuaiAPIBackend) CallBundle(ctx context.Context, bundle *core.Bundle) (*core.BundleResult, error) {
+	nodeCtx := b.eth.core.NodeCtx()
+	if nodeCtx != common.ZONE_CTX {
+		return nil, errors.New("callBundle can only be called in zone chain")
+	}
+	return b.eth.core.CallBundle(bundle)
+}
+
 func (b *QuaiAPIBackend) GetPoolTransactions() (types.Transactions, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getPoolTransactions can only be called in zone chain")
 	}
@@ -313,7 +346,7 @@ func (b *QuaiAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 }
 
 func (b *QuaiAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil
 	}
@@ -321,7 +354,7 @@ func (b *QuaiAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction
 }
 
 func (b *QuaiAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, common.Hash{}, 0, 0, errors.New("getTransaction can only be called in zone chain")
 	}
@@ -330,7 +363,7 @@ func (b *QuaiAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 }
 
 func (b *QuaiAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return 0, errors.New("getPoolNonce can only be called in zone chain")
 	}
@@ -342,7 +375,7 @@ func (b *QuaiAPIBackend) Stats() (pending int, queued int) {
 }
 
 func (b *QuaiAPIBackend) TxPoolContent() (map[common.InternalAddress]types.Transactions, map[common.InternalAddress]types.Transactions) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, nil
 	}
@@ -350,7 +383,7 @@ func (b *QuaiAPIBackend) TxPoolContent() (map[common.InternalAddress]types.Trans
 }
 
 func (b *QuaiAPIBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, nil
 	}
@@ -358,7 +391,7 @@ func (b *QuaiAPIBackend) TxPoolContentFrom(addr common.Address) (types.Transacti
 }
 
 func (b *QuaiAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil
 	}
@@ -370,7 +403,7 @@ func (b *QuaiAPIBackend) Downloader() *downloader.Downloader {
 }
 
 func (b *QuaiAPIBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("suggestTipCap can only be called in zone chain")
 	}
@@ -389,12 +422,16 @@ func (b *QuaiAPIBackend) EventMux() *event.TypeMux {
 	return b.eth.EventMux()
 }
 
+func (b *QuaiAPIBackend) AccountManager() *accounts.Manager {
+	return b.eth.AccountManager()
+}
+
 func (b *QuaiAPIBackend) ExtRPCEnabled() bool {
 	return b.extRPCEnabled
 }
 
 func (b *QuaiAPIBackend) RPCGasCap() uint64 {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return 0
 	}
@@ -402,7 +439,7 @@ func (b *QuaiAPIBackend) RPCGasCap() uint64 {
 }
 
 func (b *QuaiAPIBackend) RPCTxFeeCap() float64 {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return 0
 	}
@@ -429,7 +466,7 @@ func (b *QuaiAPIBackend) CurrentHeader() *types.Header {
 }
 
 func (b *QuaiAPIBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, checkLive bool) (*state.StateDB, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("stateAtBlock can only be called in zone chain")
 	}
@@ -437,7 +474,7 @@ func (b *QuaiAPIBackend) StateAtBlock(ctx context.Context, block *types.Block, r
 }
 
 func (b *QuaiAPIBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (core.Message, vm.BlockContext, *state.StateDB, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.eth.core.NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, vm.BlockContext{}, nil, errors.New("stateAtTransaction can only be called in zone chain")
 	}
@@ -484,6 +521,10 @@ func (b *QuaiAPIBackend) GetManifest(blockHash common.Hash) (types.BlockManifest
 	return b.eth.core.GetManifest(blockHash)
 }
 
+func (b *QuaiAPIBackend) GetTerminiByHash(hash common.Hash) []common.Hash {
+	return b.eth.core.GetTerminiByHash(hash)
+}
+
 func (b *QuaiAPIBackend) GetSubManifest(slice common.Location, blockHash common.Hash) (types.BlockManifest, error) {
 	return b.eth.core.GetSubManifest(slice, blockHash)
 }
@@ -500,6 +541,34 @@ func (b *QuaiAPIBackend) SubscribePendingHeaderEvent(ch chan<- *types.Header) ev
 	return b.eth.core.SubscribePendingHeader(ch)
 }
 
+func (b *QuaiAPIBackend) UpdateBlockLists(update types.BlockListUpdate, propagate bool) error {
+	return b.eth.core.UpdateBlockLists(update, propagate)
+}
+
 func (b *QuaiAPIBackend) GenerateRecoveryPendingHeader(pendingHeader *types.Header, checkpointHashes []common.Hash) error {
 	return b.eth.core.GenerateRecoveryPendingHeader(pendingHeader, checkpointHashes)
 }
+
+func (b *QuaiAPIBackend) GetEtxStatus(hash common.Hash) *types.EtxStatus {
+	return b.eth.core.GetEtxStatus(hash)
+}
+
+func (b *QuaiAPIBackend) ConfirmationStatus(header *types.Header) (*core.ConfirmationStatus, error) {
+	return b.eth.core.ConfirmationStatus(header)
+}
+
+func (b *QuaiAPIBackend) SubscribeEtxStatusEvent(ch chan<- core.EtxStatusEvent) event.Subscription {
+	return b.eth.core.SubscribeEtxStatusEvent(ch)
+}
+
+func (b *QuaiAPIBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.eth.core.SubscribeReorgEvent(ch)
+}
+
+func (b *QuaiAPIBackend) TransportHealth() core.TransportHealth {
+	return b.eth.core.TransportHealth()
+}
+
+func (b *QuaiAPIBackend) EstimateExternalGas(ctx context.Context, msg quai.CallMsg) (uint64, error) {
+	return b.eth.core.EstimateExternalGas(ctx, msg)
+}
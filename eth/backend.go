@@ -24,6 +24,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/dominant-strategies/go-quai/accounts"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/consensus"
 	"github.com/dominant-strategies/go-quai/core"
@@ -66,8 +67,9 @@ type Quai struct {
 	// DB interfaces
 	chainDb ethdb.Database // Block chain database
 
-	eventMux *event.TypeMux
-	engine   consensus.Engine
+	eventMux       *event.TypeMux
+	engine         consensus.Engine
+	accountManager *accounts.Manager
 
 	bloomRequests     chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
@@ -89,7 +91,7 @@ type Quai struct {
 // New creates a new Quai object (including the
 // initialisation of the common Quai object)
 func New(stack *node.Node, config *ethconfig.Config) (*Quai, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := config.NodeLocation.Context()
 	// Ensure configuration values are compatible and sane
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
@@ -118,6 +120,13 @@ func New(stack *node.Node, config *ethconfig.Config) (*Quai, error) {
 	if genesisErr != nil {
 		return nil, genesisErr
 	}
+	if err := chainConfig.GetTopology().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid topology: %v", err)
+	}
+	if topology := chainConfig.GetTopology(); !topology.Contains(config.NodeLocation) {
+		return nil, fmt.Errorf("location %v is not part of the %dx%d hierarchy", config.NodeLocation, topology.Regions, topology.Zones)
+	}
+	chainConfig = chainConfig.WithLocation(config.NodeLocation)
 
 	if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
 		log.Error("Failed to recover state", "error", err)
@@ -126,12 +135,13 @@ func New(stack *node.Node, config *ethconfig.Config) (*Quai, error) {
 		config:            config,
 		chainDb:           chainDb,
 		eventMux:          stack.EventMux(),
+		accountManager:    stack.AccountManager(),
 		closeBloomHandler: make(chan struct{}),
 		networkID:         config.NetworkId,
 		gasPrice:          config.Miner.GasPrice,
 		etherbase:         config.Miner.Etherbase,
 		bloomRequests:     make(chan chan *bloombits.Retrieval),
-		bloomIndexer:      core.NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
+		bloomIndexer:      core.NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms, nodeCtx),
 		p2pServer:         stack.Server(),
 	}
 
@@ -157,6 +167,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Quai, error) {
 	if !config.SkipBcVersionCheck {
 		if bcVersion != nil && *bcVersion > core.BlockChainVersion {
 			return nil, fmt.Errorf("database version is v%d, Quai %s only supports v%d", *bcVersion, params.Version.Full(), core.BlockChainVersion)
+		} else if bcVersion != nil && *bcVersion < core.MinBlockChainVersion {
+			return nil, fmt.Errorf("database version is v%d, Quai %s cannot upgrade databases older than v%d, remove the chain data and sync again", *bcVersion, params.Version.Full(), core.MinBlockChainVersion)
 		} else if bcVersion == nil || *bcVersion < core.BlockChainVersion {
 			if bcVersion != nil { // only print warning on upgrade, not on init
 				log.Warn("Upgrade blockchain database version", "from", dbVer, "to", core.BlockChainVersion)
@@ -178,6 +190,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Quai, error) {
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
 			Preimages:           config.Preimages,
+			AncientDepth:        config.AncientDepth,
 		}
 	)
 
@@ -185,7 +198,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Quai, error) {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
 
-	eth.core, err = core.NewCore(chainDb, &config.Miner, eth.isLocalBlock, &config.TxPool, &config.TxLookupLimit, chainConfig, eth.config.DomUrl, eth.config.SubUrls, eth.engine, cacheConfig, vmConfig, config.Genesis)
+	eth.core, err = core.NewCore(chainDb, &config.Miner, eth.isLocalBlock, &config.TxPool, &config.TxLookupLimit, chainConfig, eth.config.DomUrl, eth.config.SubUrls, stack.JWTSecret(), eth.engine, cacheConfig, vmConfig, config.Genesis)
 	if err != nil {
 		return nil, err
 	}
@@ -253,6 +266,10 @@ func New(stack *node.Node, config *ethconfig.Config) (*Quai, error) {
 func (s *Quai) APIs() []rpc.API {
 	apis := quaiapi.GetAPIs(s.APIBackend)
 
+	// The filters are shared by both namespaces, so that a filter installed
+	// through one of them can be polled through the other
+	filterAPI := filters.NewPublicFilterAPI(s.APIBackend, false, 5*time.Minute)
+
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.Core())...)
 
@@ -281,7 +298,12 @@ func (s *Quai) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.APIBackend, false, 5*time.Minute),
+			Service:   filterAPI,
+			Public:    true,
+		}, {
+			Namespace: "quai",
+			Version:   "1.0",
+			Service:   filterAPI,
 			Public:    true,
 		}, {
 			Namespace: "admin",
@@ -323,9 +345,10 @@ func (s *Quai) Etherbase() (eb common.Address, err error) {
 // We regard two types of accounts as local miner account: etherbase
 // and accounts specified via `txpool.locals` flag.
 func (s *Quai) isLocalBlock(header *types.Header) bool {
+	nodeCtx := s.core.NodeCtx()
 	author, err := s.engine.Author(header)
 	if err != nil {
-		log.Warn("Failed to retrieve block author", "number", header.NumberU64(), "hash", header.Hash(), "err", err)
+		log.Warn("Failed to retrieve block author", "number", header.NumberU64(nodeCtx), "hash", header.Hash(), "err", err)
 		return false
 	}
 	// Check whether the given address is etherbase.
@@ -335,7 +358,7 @@ func (s *Quai) isLocalBlock(header *types.Header) bool {
 	if author.Equal(etherbase) {
 		return true
 	}
-	internal, err := author.InternalAddress()
+	internal, err := s.core.Config().InternalAddress(author)
 	if err != nil {
 		log.Error("Failed to retrieve author internal address", "err", err)
 	}
@@ -357,6 +380,7 @@ func (s *Quai) shouldPreserve(block *types.Block) bool {
 }
 
 func (s *Quai) Core() *core.Core                   { return s.core }
+func (s *Quai) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Quai) EventMux() *event.TypeMux           { return s.eventMux }
 func (s *Quai) Engine() consensus.Engine           { return s.engine }
 func (s *Quai) ChainDb() ethdb.Database            { return s.chainDb }
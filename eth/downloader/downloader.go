@@ -23,6 +23,7 @@ import (
 	quai "github.com/dominant-strategies/go-quai"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/consensus"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
 	"github.com/dominant-strategies/go-quai/core/state/snapshot"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/eth/protocols/eth"
@@ -30,6 +31,7 @@ import (
 	"github.com/dominant-strategies/go-quai/event"
 	"github.com/dominant-strategies/go-quai/log"
 	"github.com/dominant-strategies/go-quai/metrics"
+	"github.com/dominant-strategies/go-quai/params"
 	"math/big"
 	"sync"
 	"sync/atomic"
@@ -88,7 +90,8 @@ type Downloader struct {
 	syncStatsChainHeight uint64       // Highest block number known when syncing started
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
-	core Core
+	core    Core
+	nodeCtx int // Context of the local chain
 
 	headEntropy *big.Int
 	headNumber  uint64
@@ -105,6 +108,7 @@ type Downloader struct {
 	// Channels
 	headerCh     chan dataPack        // Channel receiving inbound block headers
 	bodyCh       chan dataPack        // Channel receiving inbound block bodies
+	stateCh      chan dataPack        // Channel receiving inbound state sync data
 	bodyWakeCh   chan bool            // Channel to signal the block body fetcher of new tasks
 	headerProcCh chan []*types.Header // Channel to feed the header processor new tasks
 
@@ -160,20 +164,36 @@ type Core interface {
 
 	// IsBlockHashABadHash returns true if block hash exists in the bad hashes list
 	IsBlockHashABadHash(hash common.Hash) bool
+
+	// NodeCtx returns the context of the local chain
+	NodeCtx() int
+
+	// NodeLocation returns the location of the local chain
+	NodeLocation() common.Location
+
+	// Config returns the chain config of the local chain
+	Config() *params.ChainConfig
+
+	// InstallPivot sets a state synced pivot block as the head of the chain
+	InstallPivot(blocks []*types.Block, termini [][]common.Hash, etxSet types.EtxSet) error
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(mux *event.TypeMux, core Core, dropPeer peerDropFn) *Downloader {
+func New(stateDb ethdb.Database, mux *event.TypeMux, core Core, dropPeer peerDropFn) *Downloader {
+	nodeCtx := core.NodeCtx()
 	dl := &Downloader{
+		stateDB:      stateDb,
 		mux:          mux,
-		queue:        newQueue(blockCacheMaxItems, blockCacheInitialItems),
+		queue:        newQueue(blockCacheMaxItems, blockCacheInitialItems, nodeCtx),
 		peers:        newPeerSet(),
 		core:         core,
-		headNumber:   core.CurrentBlock().NumberU64(),
+		nodeCtx:      nodeCtx,
+		headNumber:   core.CurrentBlock().NumberU64(nodeCtx),
 		headEntropy:  core.CurrentLogEntropy(),
 		dropPeer:     dropPeer,
 		headerCh:     make(chan dataPack, 1),
 		bodyCh:       make(chan dataPack, 1),
+		stateCh:      make(chan dataPack, 1),
 		bodyWakeCh:   make(chan bool, 1),
 		headerProcCh: make(chan []*types.Header, 10),
 		quitCh:       make(chan struct{}),
@@ -190,6 +210,7 @@ func New(mux *event.TypeMux, core Core, dropPeer peerDropFn) *Downloader {
 // of processed and the total number of known states are also returned. Otherwise
 // these are zero.
 func (d *Downloader) Progress() quai.SyncProgress {
+	nodeCtx := d.nodeCtx
 	// Lock the current stats and return the progress
 	d.syncStatsLock.RLock()
 	defer d.syncStatsLock.RUnlock()
@@ -197,8 +218,8 @@ func (d *Downloader) Progress() quai.SyncProgress {
 	current := uint64(0)
 	mode := d.getMode()
 	switch {
-	case d.core != nil && mode == FullSync:
-		current = d.core.CurrentBlock().NumberU64()
+	case d.core != nil && (mode == FullSync || mode == SnapSync):
+		current = d.core.CurrentBlock().NumberU64(nodeCtx)
 	default:
 		log.Error("Unknown downloader chain/mode combo", "light", "full", d.core != nil, "mode", mode)
 	}
@@ -219,7 +240,7 @@ func (d *Downloader) Synchronising() bool {
 func (d *Downloader) RegisterPeer(id string, version uint, peer Peer) error {
 	logger := log.Log
 	logger.Trace("Registering sync peer")
-	if err := d.peers.Register(newPeerConnection(id, version, peer, logger)); err != nil {
+	if err := d.peers.Register(newPeerConnection(id, version, peer, logger, d.nodeCtx)); err != nil {
 		logger.Error("Failed to register sync peer", "err", err)
 		return err
 	}
@@ -306,7 +327,7 @@ func (d *Downloader) synchronise(id string, hash common.Hash, entropy *big.Int,
 		default:
 		}
 	}
-	for _, ch := range []chan dataPack{d.headerCh, d.bodyCh} {
+	for _, ch := range []chan dataPack{d.headerCh, d.bodyCh, d.stateCh} {
 		for empty := false; !empty; {
 			select {
 			case <-ch:
@@ -359,6 +380,7 @@ func (d *Downloader) getMode() SyncMode {
 // syncWithPeer starts a block synchronization based on the hash chain from the
 // specified peer and head hash.
 func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, entropy *big.Int) (err error) {
+	nodeCtx := d.nodeCtx
 	d.mux.Post(StartEvent{})
 	defer func() {
 		// reset on error
@@ -386,9 +408,17 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, entropy *
 	}
 
 	// Height of the peer
-	peerHeight := latest.Number().Uint64()
+	peerHeight := latest.Number(nodeCtx).Uint64()
 	origin := peerHeight
 
+	// A fresh zone node may download the state at a recent pivot instead of
+	// executing every block since genesis, and full sync from there
+	if mode == SnapSync && d.snapSyncable(p, peerHeight) {
+		if err := d.snapSync(p, latest); err != nil {
+			return err
+		}
+	}
+
 	// TODO: display the correct sync stats
 	d.syncStatsLock.Lock()
 	if d.syncStatsChainHeight <= origin || d.syncStatsChainOrigin > origin {
@@ -483,6 +513,7 @@ func (d *Downloader) Terminate() {
 
 // fetchHead retrieves the head header from a remote peer.
 func (d *Downloader) fetchHead(p *peerConnection) (head *types.Header, err error) {
+	nodeCtx := d.nodeCtx
 	p.log.Debug("Retrieving remote chain head")
 
 	// Request the advertised remote head block and wait for the response
@@ -512,7 +543,7 @@ func (d *Downloader) fetchHead(p *peerConnection) (head *types.Header, err error
 			// and request.
 			head := headers[0]
 			if len(headers) == 1 {
-				p.log.Debug("Remote head identified", "number", head.Number(), "hash", head.Hash())
+				p.log.Debug("Remote head identified", "number", head.Number(nodeCtx), "hash", head.Hash())
 				return head, nil
 			}
 			return head, nil
@@ -548,7 +579,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64) error {
 
 	// peer height
 	peerHeight := from
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := d.nodeCtx
 
 	localHeight := d.headNumber
 
@@ -636,7 +667,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64) error {
 				// Only fill the skeleton between the headers we don't know about.
 				for i := 0; i < len(headers); i++ {
 					skeletonHeaders = append(skeletonHeaders, headers[i])
-					commonAncestor := d.core.HasBlock(headers[i].Hash(), headers[i].NumberU64()) && (d.core.GetTerminiByHash(headers[i].Hash()) != nil)
+					commonAncestor := d.core.HasBlock(headers[i].Hash(), headers[i].NumberU64(nodeCtx)) && (d.core.GetTerminiByHash(headers[i].Hash()) != nil)
 					if commonAncestor {
 						break
 					}
@@ -658,7 +689,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64) error {
 			// Prepare the resultStore to fill the skeleton.
 			// first bool is used to only set the offset on the first skeleton fetch.
 			if len(skeletonHeaders) > 0 && first {
-				d.queue.Prepare(skeletonHeaders[len(skeletonHeaders)-1].NumberU64(), FullSync)
+				d.queue.Prepare(skeletonHeaders[len(skeletonHeaders)-1].NumberU64(nodeCtx), FullSync)
 				first = false
 			}
 
@@ -668,7 +699,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64) error {
 			if skeleton && len(skeletonHeaders) == 1 {
 				skeleton = false
 				// get the headers directly from peer height
-				getHeaders(peerHeight, skeletonHeaders[0].NumberU64())
+				getHeaders(peerHeight, skeletonHeaders[0].NumberU64(nodeCtx))
 				continue
 			}
 
@@ -682,7 +713,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64) error {
 					return fmt.Errorf("%w: %v", errInvalidChain, err)
 				}
 				headers = filled[proced:]
-				localHeight = skeletonHeaders[0].NumberU64()
+				localHeight = skeletonHeaders[0].NumberU64(nodeCtx)
 
 				progressed = proced > 0
 				updateFetchPoint()
@@ -838,6 +869,7 @@ func (d *Downloader) fetchParts(deliveryCh chan dataPack, deliver func(dataPack)
 	expire func() map[string]int, pending func() int, inFlight func() bool, reserve func(*peerConnection, int) (*fetchRequest, bool, bool),
 	fetchHook func([]*types.Header), fetch func(*peerConnection, *fetchRequest) error, cancel func(*fetchRequest), capacity func(*peerConnection) int,
 	idle func() ([]*peerConnection, int), setIdle func(*peerConnection, int, time.Time), kind string) error {
+	nodeCtx := d.nodeCtx
 
 	// Create a ticker to detect expired retrieval tasks
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -982,7 +1014,7 @@ func (d *Downloader) fetchParts(deliveryCh chan dataPack, deliver func(dataPack)
 					peer.log.Trace("Requesting new batch of data", "type", kind, "from", request.From)
 				} else {
 					if len(request.Headers) != 0 {
-						peer.log.Trace("Requesting new batch of data", "type", kind, "count", len(request.Headers), "from", request.Headers[0].Number())
+						peer.log.Trace("Requesting new batch of data", "type", kind, "count", len(request.Headers), "from", request.Headers[0].Number(nodeCtx))
 					}
 				}
 				// Fetch the chunk and make sure any errors return the hashes to the queue
@@ -1012,6 +1044,7 @@ func (d *Downloader) fetchParts(deliveryCh chan dataPack, deliver func(dataPack)
 // keeps processing and scheduling them into the header chain and downloader's
 // queue until the stream ends or a failure occurs.
 func (d *Downloader) processHeaders(origin uint64) error {
+	nodeCtx := d.nodeCtx
 	// Keep a count of uncertain headers to roll back
 	var (
 		rollback    uint64 // Zero means no rollback (fine as you can't unroll the genesis)
@@ -1020,7 +1053,7 @@ func (d *Downloader) processHeaders(origin uint64) error {
 	)
 	defer func() {
 		if rollback > 0 {
-			curBlock := d.core.CurrentBlock().NumberU64()
+			curBlock := d.core.CurrentBlock().NumberU64(nodeCtx)
 			log.Warn("Rolled back chain segment",
 				"block", fmt.Sprintf("%d->%d", curBlock), "reason", rollbackErr)
 		}
@@ -1128,7 +1161,7 @@ func (d *Downloader) processFullSyncContent(peerHeight uint64) error {
 			if err := d.importBlockResults(results); err != nil {
 				return err
 			}
-			d.headNumber = results[len(results)-1].Header.NumberU64()
+			d.headNumber = results[len(results)-1].Header.NumberU64(d.nodeCtx)
 			d.headEntropy = d.core.TotalLogS(results[len(results)-1].Header)
 			// If all the blocks are fetched, we exit the sync process
 			if d.headNumber == peerHeight {
@@ -1139,6 +1172,7 @@ func (d *Downloader) processFullSyncContent(peerHeight uint64) error {
 }
 
 func (d *Downloader) importBlockResults(results []*fetchResult) error {
+	nodeCtx := d.nodeCtx
 	// Check for any early termination requests
 	if len(results) == 0 {
 		return nil
@@ -1153,8 +1187,8 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 	// Retrieve the a batch of results to import
 	first, last := results[0].Header, results[len(results)-1].Header
 	log.Debug("Inserting downloaded chain", "items", len(results),
-		"firstnum", first.Number(), "firsthash", first.Hash(),
-		"lastnum", last.Number(), "lasthash", last.Hash(),
+		"firstnum", first.Number(nodeCtx), "firsthash", first.Hash(),
+		"lastnum", last.Number(nodeCtx), "lasthash", last.Hash(),
 	)
 
 	for _, result := range results {
@@ -1178,6 +1212,31 @@ func (d *Downloader) DeliverBodies(id string, transactions [][]*types.Transactio
 	return d.deliver(d.bodyCh, &bodyPack{id, transactions, uncles, extTransactions, manifests}, bodyInMeter, bodyDropMeter)
 }
 
+// DeliverAccountRange injects a range of accounts received from a remote node.
+func (d *Downloader) DeliverAccountRange(id string, reqID uint64, hashes []common.Hash, accounts [][]byte, proof [][]byte) error {
+	return d.deliver(d.stateCh, &accountRangePack{id, reqID, hashes, accounts, proof}, stateInMeter, stateDropMeter)
+}
+
+// DeliverStorageRanges injects ranges of storage slots received from a remote node.
+func (d *Downloader) DeliverStorageRanges(id string, reqID uint64, hashes [][]common.Hash, slots [][][]byte, proof [][]byte) error {
+	return d.deliver(d.stateCh, &storageRangesPack{id, reqID, hashes, slots, proof}, stateInMeter, stateDropMeter)
+}
+
+// DeliverByteCodes injects a batch of contract bytecodes received from a remote node.
+func (d *Downloader) DeliverByteCodes(id string, reqID uint64, codes [][]byte) error {
+	return d.deliver(d.stateCh, &byteCodesPack{id, reqID, codes}, stateInMeter, stateDropMeter)
+}
+
+// DeliverTrieNodes injects a batch of state trie nodes received from a remote node.
+func (d *Downloader) DeliverTrieNodes(id string, reqID uint64, nodes [][]byte) error {
+	return d.deliver(d.stateCh, &trieNodesPack{id, reqID, nodes}, stateInMeter, stateDropMeter)
+}
+
+// DeliverPivotData injects the pivot termini and EtxSet received from a remote node.
+func (d *Downloader) DeliverPivotData(id string, reqID uint64, termini [][]common.Hash, etxSet []rawdb.EtxSetEntry) error {
+	return d.deliver(d.stateCh, &pivotDataPack{id, reqID, termini, etxSet}, stateInMeter, stateDropMeter)
+}
+
 // deliver injects a new batch of data received from a remote node.
 func (d *Downloader) deliver(destCh chan dataPack, packet dataPack, inMeter, dropMeter metrics.Meter) (err error) {
 	// Update the delivery metrics for both good and failed deliveries
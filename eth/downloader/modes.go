@@ -24,10 +24,11 @@ type SyncMode uint32
 
 const (
 	FullSync SyncMode = iota // Synchronise the entire blockchain history from full blocks
+	SnapSync                 // Download the state at a recent pivot block and full sync from there
 )
 
 func (mode SyncMode) IsValid() bool {
-	return mode == FullSync
+	return mode >= FullSync && mode <= SnapSync
 }
 
 // String implements the stringer interface.
@@ -35,6 +36,8 @@ func (mode SyncMode) String() string {
 	switch mode {
 	case FullSync:
 		return "full"
+	case SnapSync:
+		return "snap"
 	default:
 		return "unknown"
 	}
@@ -44,6 +47,8 @@ func (mode SyncMode) MarshalText() ([]byte, error) {
 	switch mode {
 	case FullSync:
 		return []byte("full"), nil
+	case SnapSync:
+		return []byte("snap"), nil
 	default:
 		return nil, fmt.Errorf("unknown sync mode %d", mode)
 	}
@@ -53,8 +58,10 @@ func (mode *SyncMode) UnmarshalText(text []byte) error {
 	switch string(text) {
 	case "full":
 		*mode = FullSync
+	case "snap":
+		*mode = SnapSync
 	default:
-		return fmt.Errorf(`unknown sync mode %q, want "full", "fast" or "light"`, text)
+		return fmt.Errorf(`unknown sync mode %q, want "full" or "snap"`, text)
 	}
 	return nil
 }
@@ -60,6 +60,7 @@ type peerConnection struct {
 	peer Peer
 
 	version uint       // Eth protocol version number to switch strategies
+	nodeCtx int        // Context of the local chain being synced
 	log     log.Logger // Contextual logger to add extra infos to peer logs
 	lock    sync.RWMutex
 }
@@ -77,13 +78,25 @@ type Peer interface {
 	RequestBodies([]common.Hash) error
 }
 
+// SnapPeer encapsulates the methods required to state sync from a remote peer
+// speaking eth/67.
+type SnapPeer interface {
+	Peer
+	RequestAccountRange(id uint64, root common.Hash, origin, limit common.Hash, bytes uint64) error
+	RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error
+	RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error
+	RequestTrieNodes(id uint64, hashes []common.Hash, bytes uint64) error
+	RequestPivotData(id uint64, hashes []common.Hash) error
+}
+
 // newPeerConnection creates a new downloader peer.
-func newPeerConnection(id string, version uint, peer Peer, logger log.Logger) *peerConnection {
+func newPeerConnection(id string, version uint, peer Peer, logger log.Logger, nodeCtx int) *peerConnection {
 	return &peerConnection{
 		id:      id,
 		lacking: make(map[common.Hash]struct{}),
 		peer:    peer,
 		version: version,
+		nodeCtx: nodeCtx,
 		log:     logger,
 	}
 }
@@ -124,7 +137,7 @@ func (p *peerConnection) FetchHeaders(from uint64, count int) error {
 
 	// In the case of prime the required amount is the PrimeSKeletonDist which is the
 	// distance between the skeleton headers.
-	if common.NodeLocation.Context() == common.PRIME_CTX {
+	if p.nodeCtx == common.PRIME_CTX {
 		// Issue the header retrieval request (absolute upwards without gaps)
 		go p.peer.RequestHeadersByNumber(from, PrimeSkeletonDist, 1, 0, false, true)
 	} else {
@@ -336,7 +349,7 @@ func (ps *peerSet) HeaderIdlePeers() ([]*peerConnection, int) {
 	throughput := func(p *peerConnection) int {
 		return p.rates.Capacity(eth.BlockHeadersMsg, time.Second)
 	}
-	return ps.idlePeers(eth.ETH65, eth.ETH66, idle, throughput)
+	return ps.idlePeers(eth.ETH65, eth.ETH67, idle, throughput)
 }
 
 // BodyIdlePeers retrieves a flat list of all the currently body-idle peers within
@@ -348,7 +361,7 @@ func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
 	throughput := func(p *peerConnection) int {
 		return p.rates.Capacity(eth.BlockBodiesMsg, time.Second)
 	}
-	return ps.idlePeers(eth.ETH65, eth.ETH66, idle, throughput)
+	return ps.idlePeers(eth.ETH65, eth.ETH67, idle, throughput)
 }
 
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
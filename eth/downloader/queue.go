@@ -73,11 +73,11 @@ type fetchResult struct {
 	Receipts        types.Receipts
 }
 
-func newFetchResult(header *types.Header) *fetchResult {
+func newFetchResult(header *types.Header, nodeCtx int) *fetchResult {
 	item := &fetchResult{
 		Header: header,
 	}
-	if !header.EmptyBody() {
+	if !header.EmptyBody(nodeCtx) {
 		item.pending |= (1 << bodyType)
 	}
 	return item
@@ -103,7 +103,8 @@ func (f *fetchResult) Done(kind uint) bool {
 
 // queue represents hashes that are either need fetching or are being fetched
 type queue struct {
-	mode SyncMode // Synchronisation mode to decide on the block parts to schedule for fetching
+	mode    SyncMode // Synchronisation mode to decide on the block parts to schedule for fetching
+	nodeCtx int      // Context of the local chain, selecting the header numbers to schedule by
 
 	// Headers are "special", they download in batches, supported by a skeleton chain
 	headerHead      common.Hash              // Hash of the last queued header to verify order
@@ -133,13 +134,14 @@ type queue struct {
 }
 
 // newQueue creates a new download queue for scheduling block retrieval.
-func newQueue(blockCacheLimit int, thresholdInitialSize int) *queue {
+func newQueue(blockCacheLimit int, thresholdInitialSize int, nodeCtx int) *queue {
 	lock := new(sync.RWMutex)
 	q := &queue{
 		headerContCh:   make(chan bool),
 		blockTaskQueue: prque.New(nil),
 		active:         sync.NewCond(lock),
 		lock:           lock,
+		nodeCtx:        nodeCtx,
 	}
 	q.Reset(blockCacheLimit, thresholdInitialSize)
 	return q
@@ -160,7 +162,7 @@ func (q *queue) Reset(blockCacheLimit int, thresholdInitialSize int) {
 	q.blockTaskQueue.Reset()
 	q.blockPendPool = make(map[string]*fetchRequest)
 
-	q.resultCache = newResultStore(blockCacheLimit)
+	q.resultCache = newResultStore(blockCacheLimit, q.nodeCtx)
 	q.resultCache.SetThrottleThreshold(uint64(thresholdInitialSize))
 }
 
@@ -221,6 +223,7 @@ func (q *queue) Idle() bool {
 // ScheduleSkeleton adds a batch of header retrieval tasks to the queue to fill
 // up an already retrieved header skeleton.
 func (q *queue) ScheduleSkeleton(from uint64, skeleton []*types.Header) {
+	nodeCtx := q.nodeCtx
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
@@ -234,16 +237,16 @@ func (q *queue) ScheduleSkeleton(from uint64, skeleton []*types.Header) {
 	q.headerToPool = make(map[uint64]uint64)
 	q.headerTaskQueue = prque.New(nil)
 	q.headerPeerMiss = make(map[string]map[uint64]struct{}) // Reset availability to correct invalid chains
-	q.headerResults = make([]*types.Header, skeleton[0].NumberU64()-skeleton[len(skeleton)-1].NumberU64())
+	q.headerResults = make([]*types.Header, skeleton[0].NumberU64(nodeCtx)-skeleton[len(skeleton)-1].NumberU64(nodeCtx))
 	q.headerProced = 0
-	q.headerOffset = skeleton[len(skeleton)-1].NumberU64() - 1
+	q.headerOffset = skeleton[len(skeleton)-1].NumberU64(nodeCtx) - 1
 	q.headerContCh = make(chan bool, 1)
 
 	for i, header := range skeleton {
 		if i < len(skeleton)-1 {
-			index := skeleton[i].NumberU64()
+			index := skeleton[i].NumberU64(nodeCtx)
 			q.headerTaskPool[index] = header
-			q.headerToPool[index] = skeleton[i+1].NumberU64()
+			q.headerToPool[index] = skeleton[i+1].NumberU64(nodeCtx)
 			q.headerTaskQueue.Push(index, -int64(index))
 		}
 	}
@@ -265,6 +268,7 @@ func (q *queue) RetrieveHeaders() ([]*types.Header, int) {
 // Schedule adds a set of headers for the download queue for scheduling, returning
 // the new headers encountered.
 func (q *queue) Schedule(headers []*types.Header) []*types.Header {
+	nodeCtx := q.nodeCtx
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
@@ -276,7 +280,7 @@ func (q *queue) Schedule(headers []*types.Header) []*types.Header {
 		if header == nil {
 			break
 		}
-		if header.Number() == nil {
+		if header.Number(nodeCtx) == nil {
 			log.Warn("Header broke chain ordering", "number is nil")
 			break
 		}
@@ -284,10 +288,10 @@ func (q *queue) Schedule(headers []*types.Header) []*types.Header {
 		// We cannot skip this, even if the block is empty, since this is
 		// what triggers the fetchResult creation.
 		if _, ok := q.blockTaskPool[hash]; ok {
-			log.Warn("Header already scheduled for block fetch", "number", header.Number(), "hash", hash)
+			log.Warn("Header already scheduled for block fetch", "number", header.Number(nodeCtx), "hash", hash)
 		} else {
 			q.blockTaskPool[hash] = header
-			q.blockTaskQueue.Push(header, -int64(header.Number().Uint64()))
+			q.blockTaskQueue.Push(header, -int64(header.Number(nodeCtx).Uint64()))
 		}
 		inserts = append(inserts, header)
 		q.headerHead = hash
@@ -437,6 +441,7 @@ func (q *queue) ReserveBodies(p *peerConnection, count int) (*fetchRequest, bool
 //	throttle - if the caller should throttle for a while
 func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common.Hash]*types.Header, taskQueue *prque.Prque,
 	pendPool map[string]*fetchRequest, kind uint) (*fetchRequest, bool, bool) {
+	nodeCtx := q.nodeCtx
 	// Short circuit if the pool has been depleted, or if the peer's already
 	// downloading something (sanity check not to corrupt state)
 	if taskQueue.Empty() {
@@ -467,7 +472,7 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 			progress = true
 			delete(taskPool, header.Hash())
 			proc = proc - 1
-			log.Error("Fetch reservation already delivered", "number", header.Number().Uint64())
+			log.Error("Fetch reservation already delivered", "number", header.Number(nodeCtx).Uint64())
 			continue
 		}
 		if throttle {
@@ -503,7 +508,7 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 	}
 	// Merge all the skipped headers back
 	for _, header := range skip {
-		taskQueue.Push(header, -int64(header.Number().Uint64()))
+		taskQueue.Push(header, -int64(header.Number(nodeCtx).Uint64()))
 	}
 	if q.resultCache.HasCompletedItems() {
 		// Wake Results, resultCache was modified
@@ -539,11 +544,12 @@ func (q *queue) CancelBodies(request *fetchRequest) {
 
 // Cancel aborts a fetch request, returning all pending hashes to the task queue.
 func (q *queue) cancel(request *fetchRequest, taskQueue *prque.Prque, pendPool map[string]*fetchRequest) {
+	nodeCtx := q.nodeCtx
 	if request.From > 0 {
 		taskQueue.Push(request.From, -int64(request.From))
 	}
 	for _, header := range request.Headers {
-		taskQueue.Push(header, -int64(header.Number().Uint64()))
+		taskQueue.Push(header, -int64(header.Number(nodeCtx).Uint64()))
 	}
 	delete(pendPool, request.Peer.id)
 }
@@ -552,12 +558,13 @@ func (q *queue) cancel(request *fetchRequest, taskQueue *prque.Prque, pendPool m
 // meant to be called during a peer drop to quickly reassign owned data fetches
 // to remaining nodes.
 func (q *queue) Revoke(peerID string) {
+	nodeCtx := q.nodeCtx
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
 	if request, ok := q.blockPendPool[peerID]; ok {
 		for _, header := range request.Headers {
-			q.blockTaskQueue.Push(header, -int64(header.Number().Uint64()))
+			q.blockTaskQueue.Push(header, -int64(header.Number(nodeCtx).Uint64()))
 		}
 		delete(q.blockPendPool, peerID)
 	}
@@ -588,6 +595,7 @@ func (q *queue) ExpireBodies(timeout time.Duration) map[string]int {
 // reason the lock is not obtained in here is because the parameters already need
 // to access the queue, so they already need a lock anyway.
 func (q *queue) expire(timeout time.Duration, pendPool map[string]*fetchRequest, taskQueue *prque.Prque, timeoutMeter metrics.Meter) map[string]int {
+	nodeCtx := q.nodeCtx
 	// Iterate over the expired requests and return each to the queue
 	expiries := make(map[string]int)
 	for id, request := range pendPool {
@@ -600,7 +608,7 @@ func (q *queue) expire(timeout time.Duration, pendPool map[string]*fetchRequest,
 				taskQueue.Push(request.From, -int64(request.From))
 			}
 			for _, header := range request.Headers {
-				taskQueue.Push(header, -int64(header.Number().Uint64()))
+				taskQueue.Push(header, -int64(header.Number(nodeCtx).Uint64()))
 			}
 			// Add the peer to the expiry report along the number of failed requests
 			expiries[id] = len(request.Headers)
@@ -620,6 +628,7 @@ func (q *queue) expire(timeout time.Duration, pendPool map[string]*fetchRequest,
 // of ready headers to the processor to keep the pipeline full. However it will
 // not block to prevent stalling other pending deliveries.
 func (q *queue) DeliverHeaders(id string, headers []*types.Header, headerProcCh chan []*types.Header) (int, error) {
+	nodeCtx := q.nodeCtx
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
@@ -643,7 +652,7 @@ func (q *queue) DeliverHeaders(id string, headers []*types.Header, headerProcCh
 
 	var accepted bool
 	requiredHeaderFetch := request.From - targetTo
-	if targetTo != 0 || common.NodeLocation.Context() == common.PRIME_CTX {
+	if targetTo != 0 || q.nodeCtx == common.PRIME_CTX {
 		requiredHeaderFetch += 1
 	}
 	accepted = len(headers) == int(requiredHeaderFetch)
@@ -654,12 +663,12 @@ func (q *queue) DeliverHeaders(id string, headers []*types.Header, headerProcCh
 	}
 
 	if accepted {
-		if headers[len(headers)-1].Number().Uint64() != request.From {
-			logger.Info("First header broke chain ordering", "number", headers[0].Number(), "hash", headers[0].Hash(), "expected", request.From)
+		if headers[len(headers)-1].Number(nodeCtx).Uint64() != request.From {
+			logger.Info("First header broke chain ordering", "number", headers[0].Number(nodeCtx), "hash", headers[0].Hash(), "expected", request.From)
 			accepted = false
-		} else if headers[0].NumberU64() != targetTo {
+		} else if headers[0].NumberU64(nodeCtx) != targetTo {
 			if targetTo != 0 {
-				logger.Info("Last header broke skeleton structure ", "number", headers[0].Number(), "expected", targetTo)
+				logger.Info("Last header broke skeleton structure ", "number", headers[0].Number(nodeCtx), "expected", targetTo)
 				accepted = false
 			}
 		}
@@ -669,8 +678,8 @@ func (q *queue) DeliverHeaders(id string, headers []*types.Header, headerProcCh
 		parentHash := headers[0].Hash()
 		for _, header := range headers[1:] {
 			hash := header.Hash()
-			if parentHash != header.ParentHash() {
-				logger.Warn("Header broke chain ancestry", "number", header.Number(), "hash", hash)
+			if parentHash != header.ParentHash(nodeCtx) {
+				logger.Warn("Header broke chain ancestry", "number", header.Number(nodeCtx), "hash", hash)
 				accepted = false
 				break
 			}
@@ -708,7 +717,7 @@ func (q *queue) DeliverHeaders(id string, headers []*types.Header, headerProcCh
 
 		select {
 		case headerProcCh <- process:
-			logger.Trace("Pre-scheduled new headers", "count", len(process), "from", process[0].Number())
+			logger.Trace("Pre-scheduled new headers", "count", len(process), "from", process[0].Number(nodeCtx))
 			q.headerProced += len(process)
 		default:
 		}
@@ -726,7 +735,7 @@ func (q *queue) DeliverHeaders(id string, headers []*types.Header, headerProcCh
 func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, uncleLists [][]*types.Header, etxLists [][]*types.Transaction, manifests []types.BlockManifest) (int, error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := q.nodeCtx
 	trieHasher := trie.NewStackTrie(nil)
 	validate := func(index int, header *types.Header) error {
 		if nodeCtx != common.ZONE_CTX {
@@ -770,6 +779,7 @@ func (q *queue) deliver(id string, taskPool map[common.Hash]*types.Header,
 	taskQueue *prque.Prque, pendPool map[string]*fetchRequest, reqTimer metrics.Timer,
 	results int, validate func(index int, header *types.Header) error,
 	reconstruct func(index int, result *fetchResult)) (int, error) {
+	nodeCtx := q.nodeCtx
 
 	// Short circuit if the data was never requested
 	request := pendPool[id]
@@ -807,13 +817,13 @@ func (q *queue) deliver(id string, taskPool map[common.Hash]*types.Header,
 	}
 
 	for _, header := range request.Headers[:i] {
-		if res, stale, err := q.resultCache.GetDeliverySlot(header.Number().Uint64()); err == nil {
+		if res, stale, err := q.resultCache.GetDeliverySlot(header.Number(nodeCtx).Uint64()); err == nil {
 			reconstruct(accepted, res)
 		} else {
 			// else: betweeen here and above, some other peer filled this result,
 			// or it was indeed a no-op. This should not happen, but if it does it's
 			// not something to panic about
-			log.Error("Delivery stale", "stale", stale, "number", header.Number().Uint64(), "err", err)
+			log.Error("Delivery stale", "stale", stale, "number", header.Number(nodeCtx).Uint64(), "err", err)
 			failure = errStaleDelivery
 		}
 		// Clean up a successful fetch
@@ -822,7 +832,7 @@ func (q *queue) deliver(id string, taskPool map[common.Hash]*types.Header,
 	}
 	// Return all failed or missing fetches to the queue
 	for _, header := range request.Headers[accepted:] {
-		taskQueue.Push(header, -int64(header.Number().Uint64()))
+		taskQueue.Push(header, -int64(header.Number(nodeCtx).Uint64()))
 	}
 	// Wake up Results
 	if accepted > 0 {
@@ -47,7 +47,7 @@ func makeChain(n int, seed byte, parent *types.Block, empty bool) []*types.Block
 		block.SetCoinbase(common.Address{seed})
 		// Add one tx to every secondblock
 		if !empty && i%2 == 0 {
-			signer := types.MakeSigner(params.TestChainConfig, block.Number())
+			signer := types.MakeSigner(params.TestChainConfig, block.Number(), block.Number())
 			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(testAddress), common.Address{seed}, big.NewInt(1000), params.TxGas, block.BaseFee(), nil), signer, testKey)
 			if err != nil {
 				panic(err)
@@ -99,7 +99,7 @@ func dummyPeer(id string) *peerConnection {
 func TestBasics(t *testing.T) {
 	numOfBlocks := len(emptyChain.blocks)
 
-	q := newQueue(10, 10)
+	q := newQueue(10, 10, common.ZONE_CTX)
 	if !q.Idle() {
 		t.Errorf("new queue should be idle")
 	}
@@ -170,7 +170,7 @@ func TestBasics(t *testing.T) {
 func TestEmptyBlocks(t *testing.T) {
 	numOfBlocks := len(emptyChain.blocks)
 
-	q := newQueue(10, 10)
+	q := newQueue(10, 10, common.ZONE_CTX)
 
 	q.Prepare(1, FastSync)
 	// Schedule a batch of headers
@@ -226,7 +226,7 @@ func XTestDelivery(t *testing.T) {
 		log.Root().SetHandler(log.StdoutHandler)
 
 	}
-	q := newQueue(10, 10)
+	q := newQueue(10, 10, common.ZONE_CTX)
 	var wg sync.WaitGroup
 	q.Prepare(1, FastSync)
 	wg.Add(1)
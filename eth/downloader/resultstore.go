@@ -29,6 +29,7 @@ import (
 // download-progress and delivering (finished) results.
 type resultStore struct {
 	items        []*fetchResult // Downloaded but not yet delivered fetch results
+	nodeCtx      int            // Context of the local chain, selecting the header numbers to index by
 	resultOffset uint64         // Offset of the first cached fetch result in the block chain
 
 	// Internal index of first non-completed entry, updated atomically when needed.
@@ -46,8 +47,9 @@ type resultStore struct {
 	lock sync.RWMutex
 }
 
-func newResultStore(size int) *resultStore {
+func newResultStore(size int, nodeCtx int) *resultStore {
 	return &resultStore{
+		nodeCtx:           nodeCtx,
 		resultOffset:      0,
 		items:             make([]*fetchResult, size),
 		throttleThreshold: uint64(size),
@@ -78,18 +80,19 @@ func (r *resultStore) SetThrottleThreshold(threshold uint64) uint64 {
 //	item      - the result to store data into
 //	err       - any error that occurred
 func (r *resultStore) AddFetch(header *types.Header) (stale, throttled bool, item *fetchResult, err error) {
+	nodeCtx := r.nodeCtx
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	var index int
-	item, index, stale, throttled, err = r.getFetchResult(header.Number().Uint64())
+	item, index, stale, throttled, err = r.getFetchResult(header.Number(nodeCtx).Uint64())
 	if err != nil || stale || throttled {
 		return stale, throttled, item, err
 	}
 	// If item is nil, it means that there was no entry for the given header number in the
 	// result store.
 	if item == nil {
-		item = newFetchResult(header)
+		item = newFetchResult(header, r.nodeCtx)
 		r.items[index] = item
 	}
 	return stale, throttled, item, err
@@ -110,7 +113,7 @@ func (r *resultStore) GetDeliverySlot(headerNumber uint64) (*fetchResult, bool,
 // getFetchResult returns the fetchResult corresponding to the given item, and
 // the index where the result is stored.
 func (r *resultStore) getFetchResult(headerNumber uint64) (item *fetchResult, index int, stale, throttle bool, err error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := r.nodeCtx
 	if nodeCtx != common.PRIME_CTX {
 		index = int(int64(headerNumber)-int64(r.resultOffset)) - 1
 	} else {
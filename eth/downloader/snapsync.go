@@ -0,0 +1,625 @@
+package downloader
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/state"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/dominant-strategies/go-quai/eth/protocols/eth"
+	"github.com/dominant-strategies/go-quai/ethdb"
+	"github.com/dominant-strategies/go-quai/ethdb/memorydb"
+	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/rlp"
+	"github.com/dominant-strategies/go-quai/trie"
+)
+
+const (
+	snapPivotDepth     = 64         // Number of blocks the pivot is kept behind the peer head
+	snapPivotAncestors = 8          // Number of pivot ancestors downloaded for the uncle and pcrc checks
+	snapRequestBytes   = 512 * 1024 // Soft limit of the data requested per state request
+	snapStorageBatch   = 64         // Number of accounts to request the storage of at once
+	snapCodeBatch      = 128        // Number of bytecodes to request at once
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+
+	// maxHash is the last hash of the key space, used as the account range limit.
+	maxHash = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+)
+
+// snapSyncable returns whether the state can be synced from the given peer
+// instead of executing the chain. Only a zone which hasn't progressed past
+// genesis state syncs, and only from a peer far enough ahead to pick a pivot.
+func (d *Downloader) snapSyncable(p *peerConnection, peerHeight uint64) bool {
+	nodeCtx := d.nodeCtx
+	if d.nodeCtx != common.ZONE_CTX || d.stateDB == nil {
+		return false
+	}
+	if _, ok := p.peer.(SnapPeer); !ok || p.version < eth.ETH67 {
+		return false
+	}
+	if d.core.CurrentBlock().NumberU64(nodeCtx) != 0 {
+		return false
+	}
+	return peerHeight > snapPivotDepth+snapPivotAncestors
+}
+
+// snapSync downloads the state of a pivot block snapPivotDepth blocks behind
+// the peer head, along with the pivot ancestors, termini and EtxSet needed to
+// append the following blocks, and installs the pivot as the local head. The
+// EtxSet is only committed to by the headers once the EtxSetFork is active, so
+// an earlier pivot is left to full sync.
+func (d *Downloader) snapSync(p *peerConnection, latest *types.Header) error {
+	nodeCtx := d.nodeCtx
+	peer := p.peer.(SnapPeer)
+	start := time.Now()
+
+	blocks, child, err := d.fetchPivotBlocks(p, latest.Number(nodeCtx).Uint64()-snapPivotDepth)
+	if err != nil {
+		return err
+	}
+	pivot := blocks[len(blocks)-1]
+	if !d.core.Config().Rules(pivot.Number(nodeCtx), pivot.Number(common.PRIME_CTX)).IsEtxSet {
+		log.Warn("Pivot etx set is not committed to, falling back to full sync", "pivot", pivot.NumberU64(nodeCtx), "hash", pivot.Hash())
+		return nil
+	}
+	log.Info("Starting state sync", "pivot", pivot.NumberU64(nodeCtx), "hash", pivot.Hash(), "root", pivot.Root())
+
+	if err := d.syncState(p, peer, pivot.Root()); err != nil {
+		return err
+	}
+	termini, etxSet, err := d.fetchPivotData(p, peer, blocks, child)
+	if err != nil {
+		return err
+	}
+	if err := d.core.InstallPivot(blocks, termini, etxSet); err != nil {
+		return err
+	}
+	d.headNumber = pivot.NumberU64(nodeCtx)
+	d.headEntropy = d.core.TotalLogS(pivot.Header())
+
+	log.Info("State sync completed", "pivot", pivot.NumberU64(nodeCtx), "hash", pivot.Hash(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// fetchPivotBlocks retrieves the canonical block at the pivot number along with
+// its closest ancestors, oldest first, and the header of its child which
+// commits to the EtxSet at the pivot. The headers are linked by hash to the
+// pivot and their seals are verified, as they won't be executed locally.
+func (d *Downloader) fetchPivotBlocks(p *peerConnection, number uint64) ([]*types.Block, *types.Header, error) {
+	nodeCtx := d.nodeCtx
+	go p.peer.RequestHeadersByNumber(number, 1, 1, 0, false, false)
+	headers, err := d.waitHeaders(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if headers[0].NumberU64(nodeCtx) != number {
+		return nil, nil, fmt.Errorf("%w: pivot number mismatch: have %d, want %d", errBadPeer, headers[0].NumberU64(nodeCtx), number)
+	}
+	chain := []*types.Header{headers[0]}
+
+	go p.peer.RequestHeadersByNumber(number+1, 1, 1, 0, false, false)
+	if headers, err = d.waitHeaders(p); err != nil {
+		return nil, nil, err
+	}
+	child := headers[0]
+	if child.ParentHash(nodeCtx) != chain[0].Hash() || child.NumberU64(nodeCtx) != number+1 {
+		return nil, nil, fmt.Errorf("%w: pivot child not linked", errInvalidChain)
+	}
+
+	// Walk back from the pivot, the peer stops at each dom block so keep asking
+	for len(chain) <= snapPivotAncestors {
+		last := chain[len(chain)-1]
+		go p.peer.RequestHeadersByHash(last.ParentHash(nodeCtx), snapPivotAncestors+1-len(chain), 1, false, true)
+		headers, err := d.waitHeaders(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, header := range headers {
+			last := chain[len(chain)-1]
+			if header.Hash() != last.ParentHash(nodeCtx) || header.NumberU64(nodeCtx)+1 != last.NumberU64(nodeCtx) {
+				return nil, nil, fmt.Errorf("%w: pivot ancestors not linked", errInvalidChain)
+			}
+			chain = append(chain, header)
+			if len(chain) > snapPivotAncestors {
+				break
+			}
+		}
+	}
+	// Flip the chain to be oldest first and verify the seals
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	for _, header := range append(chain, child) {
+		if d.core.IsBlockHashABadHash(header.Hash()) {
+			return nil, nil, errBadBlockFound
+		}
+		if _, _, err := d.core.Engine().CalcOrder(header); err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid pivot seal: %v", errInvalidChain, err)
+		}
+	}
+	blocks, err := d.fetchPivotBodies(p, chain)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blocks, child, nil
+}
+
+// waitHeaders waits for a header response from the given peer.
+func (d *Downloader) waitHeaders(p *peerConnection) ([]*types.Header, error) {
+	ttl := d.peers.rates.TargetTimeout()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			headers := packet.(*headerPack).headers
+			if len(headers) == 0 {
+				return nil, errEmptyHeaderSet
+			}
+			return headers, nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for pivot headers timed out", "elapsed", ttl)
+			return nil, errTimeout
+		}
+	}
+}
+
+// fetchPivotBodies retrieves and validates the bodies of the given headers.
+func (d *Downloader) fetchPivotBodies(p *peerConnection, headers []*types.Header) ([]*types.Block, error) {
+	hashes := make([]common.Hash, len(headers))
+	for i, header := range headers {
+		hashes[i] = header.Hash()
+	}
+	go p.peer.RequestBodies(hashes)
+
+	ttl := d.peers.rates.TargetTimeout()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+
+		case packet := <-d.bodyCh:
+			if packet.PeerId() != p.id {
+				log.Debug("Received bodies from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			bodies := packet.(*bodyPack)
+			if len(bodies.transactions) != len(headers) || len(bodies.uncles) != len(headers) ||
+				len(bodies.extTransactions) != len(headers) || len(bodies.manifest) != len(headers) {
+				return nil, fmt.Errorf("%w: returned bodies %d != requested %d", errBadPeer, len(bodies.transactions), len(headers))
+			}
+			hasher := trie.NewStackTrie(nil)
+			blocks := make([]*types.Block, len(headers))
+			for i, header := range headers {
+				if types.DeriveSha(types.Transactions(bodies.transactions[i]), hasher) != header.TxHash() ||
+					types.DeriveSha(types.Transactions(bodies.extTransactions[i]), hasher) != header.EtxHash() ||
+					types.CalcUncleHash(bodies.uncles[i]) != header.UncleHash() {
+					return nil, errInvalidBody
+				}
+				blocks[i] = types.NewBlockWithHeader(header).WithBody(bodies.transactions[i], bodies.uncles[i], bodies.extTransactions[i], bodies.manifest[i])
+			}
+			return blocks, nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for pivot bodies timed out", "elapsed", ttl)
+			return nil, errTimeout
+		}
+	}
+}
+
+// fetchPivotData retrieves the termini of the pivot blocks and the EtxSet at the
+// pivot. The termini are not committed to by the headers, so they are checked
+// for consistency with the blocks, but are otherwise taken from the peer. The
+// EtxSet must match the root committed to by the child of the pivot.
+func (d *Downloader) fetchPivotData(p *peerConnection, peer SnapPeer, blocks []*types.Block, child *types.Header) ([][]common.Hash, types.EtxSet, error) {
+	nodeCtx := d.nodeCtx
+	hashes := make([]common.Hash, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = block.Hash()
+	}
+	id := rand.Uint64()
+	packet, err := d.fetchState(p, id, func() error { return peer.RequestPivotData(id, hashes) })
+	if err != nil {
+		return nil, nil, err
+	}
+	res, ok := packet.(*pivotDataPack)
+	if !ok || len(res.termini) != len(blocks) {
+		return nil, nil, fmt.Errorf("%w: invalid pivot data", errBadPeer)
+	}
+	// Within a zone the termini only change when a dom block sets the terminus
+	terminus := d.core.Config().GetTopology().Width()
+	for i, termini := range res.termini {
+		if len(termini) != terminus+1 {
+			return nil, nil, fmt.Errorf("%w: invalid pivot termini", errBadPeer)
+		}
+		if i == 0 {
+			continue
+		}
+		parent := res.termini[i-1]
+		for j := 0; j < terminus; j++ {
+			if termini[j] != parent[j] {
+				return nil, nil, fmt.Errorf("%w: inconsistent pivot termini", errBadPeer)
+			}
+		}
+		if termini[terminus] != parent[terminus] && termini[terminus] != blocks[i].Hash() {
+			return nil, nil, fmt.Errorf("%w: inconsistent pivot termini", errBadPeer)
+		}
+	}
+	pivot := blocks[len(blocks)-1]
+	etxSet := types.NewEtxSet()
+	for _, entry := range res.etxSet {
+		etx := entry.Etx
+		if etx.Hash() != entry.EtxHash || entry.EtxHeight > pivot.NumberU64(nodeCtx) || etx.To() == nil ||
+			!etx.To().Location(d.core.Config().GetTopology()).Equal(d.core.NodeLocation()) {
+			return nil, nil, fmt.Errorf("%w: invalid pivot etx set entry %x", errBadPeer, entry.EtxHash)
+		}
+		etxSet[entry.EtxHash] = types.EtxSetEntry{Height: entry.EtxHeight, ETX: etx}
+	}
+	if root := etxSet.Root(trie.NewStackTrie(nil)); root != child.EtxSetRoot() {
+		return nil, nil, fmt.Errorf("%w: pivot etx set root mismatch: have %x, want %x", errBadPeer, root, child.EtxSetRoot())
+	}
+	return res.termini, etxSet, nil
+}
+
+// fetchState issues a state request to the given peer and waits for the
+// response to it.
+func (d *Downloader) fetchState(p *peerConnection, id uint64, request func() error) (dataPack, error) {
+	if err := request(); err != nil {
+		return nil, err
+	}
+	ttl := d.peers.rates.TargetTimeout()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+
+		case packet := <-d.stateCh:
+			// Discard anything not answering the request
+			if packet.PeerId() != p.id || packet.(statePack).RequestId() != id {
+				log.Debug("Received state data for unknown request", "peer", packet.PeerId())
+				break
+			}
+			return packet, nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for state data timed out", "elapsed", ttl)
+			return nil, errTimeout
+		}
+	}
+}
+
+// syncState downloads the state trie with the given root. The accounts and
+// storage slots are downloaded in consecutive ranges verified with range
+// proofs, and the tries are rebuilt from them. The state is then healed from
+// the root to fetch any trie node which is still missing.
+func (d *Downloader) syncState(p *peerConnection, peer SnapPeer, root common.Hash) error {
+	batch := d.stateDB.NewBatch()
+	accTrie := trie.NewStackTrie(batch)
+
+	var (
+		origin   common.Hash
+		accounts uint64
+		slots    uint64
+		codes    uint64
+	)
+	for {
+		id := rand.Uint64()
+		packet, err := d.fetchState(p, id, func() error {
+			return peer.RequestAccountRange(id, root, origin, maxHash, snapRequestBytes)
+		})
+		if err != nil {
+			return err
+		}
+		res, ok := packet.(*accountRangePack)
+		if !ok || len(res.hashes) != len(res.accounts) {
+			return fmt.Errorf("%w: invalid account range", errBadPeer)
+		}
+		keys := make([][]byte, len(res.hashes))
+		for i, hash := range res.hashes {
+			keys[i] = common.CopyBytes(hash[:])
+		}
+		var end []byte
+		if len(keys) > 0 {
+			end = keys[len(keys)-1]
+		}
+		cont, err := trie.VerifyRangeProof(root, origin[:], end, keys, res.accounts, proofDb(res.proof))
+		if err != nil {
+			return fmt.Errorf("%w: invalid account range proof: %v", errBadPeer, err)
+		}
+		// Insert the accounts and gather their storage tries and bytecodes
+		var (
+			storageAccounts []common.Hash
+			storageRoots    []common.Hash
+			codeHashes      []common.Hash
+		)
+		for i, blob := range res.accounts {
+			if err := accTrie.TryUpdate(keys[i], blob); err != nil {
+				return err
+			}
+			var acc state.Account
+			if err := rlp.DecodeBytes(blob, &acc); err != nil {
+				return fmt.Errorf("%w: invalid account: %v", errBadPeer, err)
+			}
+			if acc.Root != emptyRoot {
+				storageAccounts = append(storageAccounts, res.hashes[i])
+				storageRoots = append(storageRoots, acc.Root)
+			}
+			if codeHash := common.BytesToHash(acc.CodeHash); codeHash != emptyCode {
+				codeHashes = append(codeHashes, codeHash)
+			}
+		}
+		n, err := d.syncStorage(p, peer, root, storageAccounts, storageRoots, batch)
+		if err != nil {
+			return err
+		}
+		if err := d.syncByteCodes(p, peer, codeHashes, batch); err != nil {
+			return err
+		}
+		accounts += uint64(len(res.accounts))
+		slots += n
+		codes += uint64(len(codeHashes))
+
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		log.Info("Syncing state", "accounts", accounts, "slots", slots, "codes", codes)
+
+		if !cont {
+			break
+		}
+		origin = incHash(common.BytesToHash(end))
+	}
+	have, err := accTrie.Commit()
+	if err != nil {
+		return err
+	}
+	if have != root {
+		return fmt.Errorf("%w: state root mismatch: have %x, want %x", errBadPeer, have, root)
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	return d.healState(p, peer, root)
+}
+
+// syncStorage downloads the storage tries of the given accounts, returning the
+// number of slots retrieved.
+func (d *Downloader) syncStorage(p *peerConnection, peer SnapPeer, root common.Hash, accounts []common.Hash, roots []common.Hash, batch ethdb.Batch) (uint64, error) {
+	var slots uint64
+	for len(accounts) > 0 {
+		count := len(accounts)
+		if count > snapStorageBatch {
+			count = snapStorageBatch
+		}
+		id := rand.Uint64()
+		packet, err := d.fetchState(p, id, func() error {
+			return peer.RequestStorageRanges(id, root, accounts[:count], nil, nil, snapRequestBytes)
+		})
+		if err != nil {
+			return 0, err
+		}
+		res, ok := packet.(*storageRangesPack)
+		if !ok || len(res.slots) == 0 || len(res.slots) > count || len(res.hashes) != len(res.slots) {
+			return 0, fmt.Errorf("%w: invalid storage ranges", errBadPeer)
+		}
+		for i := range res.slots {
+			keys := make([][]byte, len(res.hashes[i]))
+			for j, hash := range res.hashes[i] {
+				keys[j] = common.CopyBytes(hash[:])
+			}
+			slots += uint64(len(keys))
+
+			// Only the last range may be incomplete, the others are proven in full
+			if i == len(res.slots)-1 && len(res.proof) > 0 {
+				n, err := d.syncLargeStorage(p, peer, root, accounts[i], roots[i], keys, res.slots[i], res.proof, batch)
+				if err != nil {
+					return 0, err
+				}
+				slots += n
+				continue
+			}
+			if _, err := trie.VerifyRangeProof(roots[i], nil, nil, keys, res.slots[i], nil); err != nil {
+				return 0, fmt.Errorf("%w: invalid storage range: %v", errBadPeer, err)
+			}
+			stTrie := trie.NewStackTrie(batch)
+			for j, key := range keys {
+				if err := stTrie.TryUpdate(key, res.slots[i][j]); err != nil {
+					return 0, err
+				}
+			}
+			if _, err := stTrie.Commit(); err != nil {
+				return 0, err
+			}
+		}
+		accounts, roots = accounts[len(res.slots):], roots[len(res.slots):]
+	}
+	return slots, nil
+}
+
+// syncLargeStorage downloads a storage trie too large for a single response,
+// continuing from a first proven range.
+func (d *Downloader) syncLargeStorage(p *peerConnection, peer SnapPeer, root common.Hash, account common.Hash, storageRoot common.Hash, keys [][]byte, values [][]byte, proof [][]byte, batch ethdb.Batch) (uint64, error) {
+	var (
+		stTrie = trie.NewStackTrie(batch)
+		origin common.Hash
+		slots  uint64
+	)
+	for {
+		var end []byte
+		if len(keys) > 0 {
+			end = keys[len(keys)-1]
+		}
+		cont, err := trie.VerifyRangeProof(storageRoot, origin[:], end, keys, values, proofDb(proof))
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid storage range proof: %v", errBadPeer, err)
+		}
+		for i, key := range keys {
+			if err := stTrie.TryUpdate(key, values[i]); err != nil {
+				return 0, err
+			}
+		}
+		if !cont {
+			break
+		}
+		origin = incHash(common.BytesToHash(end))
+
+		id := rand.Uint64()
+		packet, err := d.fetchState(p, id, func() error {
+			return peer.RequestStorageRanges(id, root, []common.Hash{account}, origin[:], nil, snapRequestBytes)
+		})
+		if err != nil {
+			return 0, err
+		}
+		res, ok := packet.(*storageRangesPack)
+		if !ok || len(res.slots) != 1 || len(res.hashes) != 1 {
+			return 0, fmt.Errorf("%w: invalid storage range", errBadPeer)
+		}
+		keys = make([][]byte, len(res.hashes[0]))
+		for j, hash := range res.hashes[0] {
+			keys[j] = common.CopyBytes(hash[:])
+		}
+		values, proof = res.slots[0], res.proof
+		slots += uint64(len(keys))
+	}
+	have, err := stTrie.Commit()
+	if err != nil {
+		return 0, err
+	}
+	if have != storageRoot {
+		return 0, fmt.Errorf("%w: storage root mismatch: have %x, want %x", errBadPeer, have, storageRoot)
+	}
+	return slots, nil
+}
+
+// syncByteCodes downloads the given contract bytecodes.
+func (d *Downloader) syncByteCodes(p *peerConnection, peer SnapPeer, hashes []common.Hash, batch ethdb.Batch) error {
+	for len(hashes) > 0 {
+		count := len(hashes)
+		if count > snapCodeBatch {
+			count = snapCodeBatch
+		}
+		id := rand.Uint64()
+		packet, err := d.fetchState(p, id, func() error {
+			return peer.RequestByteCodes(id, hashes[:count], snapRequestBytes)
+		})
+		if err != nil {
+			return err
+		}
+		res, ok := packet.(*byteCodesPack)
+		if !ok || len(res.codes) == 0 {
+			return fmt.Errorf("%w: invalid byte codes", errBadPeer)
+		}
+		// Codes are matched up by hash, anything unrequested is a bad response
+		pending := make(map[common.Hash]struct{}, count)
+		for _, hash := range hashes[:count] {
+			pending[hash] = struct{}{}
+		}
+		for _, code := range res.codes {
+			hash := crypto.Keccak256Hash(code)
+			if _, ok := pending[hash]; !ok {
+				return fmt.Errorf("%w: unrequested byte code %x", errBadPeer, hash)
+			}
+			rawdb.WriteCode(batch, hash, code)
+			delete(pending, hash)
+		}
+		// Re-request anything the peer couldn't fit in the response
+		var rest []common.Hash
+		for _, hash := range hashes[:count] {
+			if _, ok := pending[hash]; ok {
+				rest = append(rest, hash)
+			}
+		}
+		hashes = append(rest, hashes[count:]...)
+	}
+	return nil
+}
+
+// healState walks the downloaded state from the root and fetches any trie
+// node or bytecode which is still missing.
+func (d *Downloader) healState(p *peerConnection, peer SnapPeer, root common.Hash) error {
+	sched := state.NewStateSync(root, d.stateDB, nil, nil)
+	for sched.Pending() > 0 {
+		nodes, _, codes := sched.Missing(MaxStateFetch)
+		if len(nodes) > 0 {
+			id := rand.Uint64()
+			packet, err := d.fetchState(p, id, func() error { return peer.RequestTrieNodes(id, nodes, snapRequestBytes) })
+			if err != nil {
+				return err
+			}
+			res, ok := packet.(*trieNodesPack)
+			if !ok || len(res.nodes) == 0 {
+				return fmt.Errorf("%w: invalid trie nodes", errBadPeer)
+			}
+			for _, blob := range res.nodes {
+				if err := sched.Process(trie.SyncResult{Hash: crypto.Keccak256Hash(blob), Data: blob}); err != nil {
+					return fmt.Errorf("%w: invalid trie node: %v", errBadPeer, err)
+				}
+			}
+		}
+		if len(codes) > 0 {
+			id := rand.Uint64()
+			packet, err := d.fetchState(p, id, func() error { return peer.RequestByteCodes(id, codes, snapRequestBytes) })
+			if err != nil {
+				return err
+			}
+			res, ok := packet.(*byteCodesPack)
+			if !ok || len(res.codes) == 0 {
+				return fmt.Errorf("%w: invalid byte codes", errBadPeer)
+			}
+			for _, blob := range res.codes {
+				if err := sched.Process(trie.SyncResult{Hash: crypto.Keccak256Hash(blob), Data: blob}); err != nil {
+					return fmt.Errorf("%w: invalid byte code: %v", errBadPeer, err)
+				}
+			}
+		}
+		batch := d.stateDB.NewBatch()
+		if err := sched.Commit(batch); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// proofDb converts a list of proof nodes into a database keyed by node hash,
+// as expected by the range proof verification.
+func proofDb(proof [][]byte) ethdb.KeyValueReader {
+	db := memorydb.New()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// incHash returns the hash following the given one in the key space.
+func incHash(h common.Hash) common.Hash {
+	next := new(big.Int).Add(h.Big(), common.Big1)
+	return common.BigToHash(next)
+}
@@ -19,6 +19,8 @@ package downloader
 import (
 	"fmt"
 
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
 	"github.com/dominant-strategies/go-quai/core/types"
 )
 
@@ -59,3 +61,76 @@ func (p *bodyPack) Items() int {
 	return len(p.uncles)
 }
 func (p *bodyPack) Stats() string { return fmt.Sprintf("%d:%d", len(p.transactions), len(p.uncles)) }
+
+// statePack is a state sync response returned by a peer, matched to its
+// request by the request ID.
+type statePack interface {
+	dataPack
+	RequestId() uint64
+}
+
+// accountRangePack is a range of accounts returned by a peer.
+type accountRangePack struct {
+	peerID    string
+	requestID uint64
+	hashes    []common.Hash
+	accounts  [][]byte
+	proof     [][]byte
+}
+
+func (p *accountRangePack) PeerId() string    { return p.peerID }
+func (p *accountRangePack) RequestId() uint64 { return p.requestID }
+func (p *accountRangePack) Items() int        { return len(p.accounts) }
+func (p *accountRangePack) Stats() string     { return fmt.Sprintf("%d", len(p.accounts)) }
+
+// storageRangesPack is a batch of storage slot ranges returned by a peer.
+type storageRangesPack struct {
+	peerID    string
+	requestID uint64
+	hashes    [][]common.Hash
+	slots     [][][]byte
+	proof     [][]byte
+}
+
+func (p *storageRangesPack) PeerId() string    { return p.peerID }
+func (p *storageRangesPack) RequestId() uint64 { return p.requestID }
+func (p *storageRangesPack) Items() int        { return len(p.slots) }
+func (p *storageRangesPack) Stats() string     { return fmt.Sprintf("%d", len(p.slots)) }
+
+// byteCodesPack is a batch of contract bytecodes returned by a peer.
+type byteCodesPack struct {
+	peerID    string
+	requestID uint64
+	codes     [][]byte
+}
+
+func (p *byteCodesPack) PeerId() string    { return p.peerID }
+func (p *byteCodesPack) RequestId() uint64 { return p.requestID }
+func (p *byteCodesPack) Items() int        { return len(p.codes) }
+func (p *byteCodesPack) Stats() string     { return fmt.Sprintf("%d", len(p.codes)) }
+
+// trieNodesPack is a batch of state trie nodes returned by a peer.
+type trieNodesPack struct {
+	peerID    string
+	requestID uint64
+	nodes     [][]byte
+}
+
+func (p *trieNodesPack) PeerId() string    { return p.peerID }
+func (p *trieNodesPack) RequestId() uint64 { return p.requestID }
+func (p *trieNodesPack) Items() int        { return len(p.nodes) }
+func (p *trieNodesPack) Stats() string     { return fmt.Sprintf("%d", len(p.nodes)) }
+
+// pivotDataPack is the termini of the pivot blocks and the EtxSet at the pivot
+// returned by a peer.
+type pivotDataPack struct {
+	peerID    string
+	requestID uint64
+	termini   [][]common.Hash
+	etxSet    []rawdb.EtxSetEntry
+}
+
+func (p *pivotDataPack) PeerId() string    { return p.peerID }
+func (p *pivotDataPack) RequestId() uint64 { return p.requestID }
+func (p *pivotDataPack) Items() int        { return len(p.termini) }
+func (p *pivotDataPack) Stats() string     { return fmt.Sprintf("%d:%d", len(p.termini), len(p.etxSet)) }
@@ -60,6 +60,7 @@ var Defaults = Config{
 	Progpow:                 progpow.Config{},
 	NetworkId:               1,
 	TxLookupLimit:           2350000,
+	AncientDepth:            params.FullImmutabilityThreshold,
 	DatabaseCache:           512,
 	TrieCleanCache:          154,
 	TrieCleanCacheJournal:   "triecache",
@@ -110,6 +111,7 @@ type Config struct {
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
 	DatabaseFreezer    string
+	AncientDepth       uint64 // Number of blocks below the last prime coincident block kept out of the ancient store
 
 	TrieCleanCache          int
 	TrieCleanCacheJournal   string        `toml:",omitempty"` // Disk journal directory for trie cache to survive node restarts
@@ -164,6 +166,9 @@ type Config struct {
 
 	// Slices running on the node
 	SlicesRunning []common.Location
+
+	// Location of the slice served by this Quai instance
+	NodeLocation common.Location
 }
 
 // CreateProgpowConsensusEngine creates a progpow consensus engine for the given chain configuration.
@@ -181,6 +186,7 @@ func CreateProgpowConsensusEngine(stack *node.Node, chainConfig *params.ChainCon
 		PowMode:       config.PowMode,
 		NotifyFull:    config.NotifyFull,
 		DurationLimit: config.DurationLimit,
+		NodeLocation:  chainConfig.Location,
 	}, notify, noverify)
 	engine.SetThreads(-1) // Disable CPU mining
 	return engine
@@ -201,6 +207,7 @@ func CreateBlake3ConsensusEngine(stack *node.Node, chainConfig *params.ChainConf
 		PowMode:       config.PowMode,
 		NotifyFull:    config.NotifyFull,
 		DurationLimit: config.DurationLimit,
+		NodeLocation:  chainConfig.Location,
 	}, notify, noverify)
 	engine.SetThreads(-1) // Disable CPU mining
 	return engine
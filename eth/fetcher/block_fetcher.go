@@ -134,11 +134,11 @@ type blockOrHeaderInject struct {
 }
 
 // number returns the block number of the injected object.
-func (inject *blockOrHeaderInject) number() uint64 {
+func (inject *blockOrHeaderInject) number(nodeCtx int) uint64 {
 	if inject.header != nil {
-		return inject.header.Number().Uint64()
+		return inject.header.Number(nodeCtx).Uint64()
 	}
-	return inject.block.NumberU64()
+	return inject.block.NumberU64(nodeCtx)
 }
 
 // number returns the block hash of the injected object.
@@ -173,6 +173,8 @@ type BlockFetcher struct {
 	queue  *prque.Prque                         // Queue containing the import operations (block number sorted)
 	queued map[common.Hash]*blockOrHeaderInject // Set of already queued blocks (to dedup imports)
 
+	nodeCtx int // Context of the local chain, selecting the header numbers to track
+
 	// Callbacks
 	getBlock            blockRetrievalFn   // Retrieves a block from the local chain
 	writeBlock          blockWriteFn       // Writes the block to the DB
@@ -191,7 +193,7 @@ type BlockFetcher struct {
 }
 
 // NewBlockFetcher creates a block fetcher to retrieve blocks based on hash announcements.
-func NewBlockFetcher(getBlock blockRetrievalFn, writeBlock blockWriteFn, verifyHeader headerVerifierFn, broadcastBlock blockBroadcasterFn, chainHeight chainHeightFn, dropPeer peerDropFn, isBlockHashABadHash badHashCheckFn) *BlockFetcher {
+func NewBlockFetcher(getBlock blockRetrievalFn, writeBlock blockWriteFn, verifyHeader headerVerifierFn, broadcastBlock blockBroadcasterFn, chainHeight chainHeightFn, dropPeer peerDropFn, isBlockHashABadHash badHashCheckFn, nodeCtx int) *BlockFetcher {
 	return &BlockFetcher{
 		notify:              make(chan *blockAnnounce),
 		inject:              make(chan *blockOrHeaderInject),
@@ -213,6 +215,7 @@ func NewBlockFetcher(getBlock blockRetrievalFn, writeBlock blockWriteFn, verifyH
 		chainHeight:         chainHeight,
 		dropPeer:            dropPeer,
 		isBlockHashABadHash: isBlockHashABadHash,
+		nodeCtx:             nodeCtx,
 	}
 }
 
@@ -321,6 +324,7 @@ func (f *BlockFetcher) FilterBodies(peer string, transactions [][]*types.Transac
 // Loop is the main fetcher loop, checking and processing various notification
 // events.
 func (f *BlockFetcher) loop() {
+	nodeCtx := f.nodeCtx
 	// Iterate the block fetching until a quit is requested
 	var (
 		fetchTimer    = time.NewTimer(0)
@@ -492,8 +496,8 @@ func (f *BlockFetcher) loop() {
 				// Filter fetcher-requested headers from other synchronisation algorithms
 				if announce := f.fetching[hash]; announce != nil && announce.origin == task.peer && f.fetched[hash] == nil && f.completing[hash] == nil && f.queued[hash] == nil {
 					// If the delivered header does not match the promised number, drop the announcer
-					if header.Number().Uint64() != announce.number {
-						log.Trace("Invalid block number fetched", "peer", announce.origin, "hash", header.Hash(), "announced", announce.number, "provided", header.Number())
+					if header.Number(nodeCtx).Uint64() != announce.number {
+						log.Trace("Invalid block number fetched", "peer", announce.origin, "hash", header.Hash(), "announced", announce.number, "provided", header.Number(nodeCtx))
 						f.dropPeer(announce.origin)
 						f.forgetHash(hash)
 						continue
@@ -504,8 +508,8 @@ func (f *BlockFetcher) loop() {
 						announce.time = task.time
 
 						// If the block is empty (header only), short circuit into the final import queue
-						if header.TxHash() == types.EmptyRootHash && header.UncleHash() == types.EmptyUncleHash && header.EtxHash() == types.EmptyRootHash && header.ManifestHash() == types.EmptyRootHash {
-							log.Trace("Block empty, skipping body retrieval", "peer", announce.origin, "number", header.Number(), "hash", header.Hash())
+						if header.TxHash() == types.EmptyRootHash && header.UncleHash() == types.EmptyUncleHash && header.EtxHash() == types.EmptyRootHash && header.ManifestHash(nodeCtx) == types.EmptyRootHash {
+							log.Trace("Block empty, skipping body retrieval", "peer", announce.origin, "number", header.Number(nodeCtx), "hash", header.Hash())
 
 							block := types.NewBlockWithHeader(header)
 							block.ReceivedAt = task.time
@@ -517,7 +521,7 @@ func (f *BlockFetcher) loop() {
 						// Otherwise add to the list of blocks needing completion
 						incomplete = append(incomplete, announce)
 					} else {
-						log.Trace("Block already imported, discarding header", "peer", announce.origin, "number", header.Number(), "hash", header.Hash())
+						log.Trace("Block already imported, discarding header", "peer", announce.origin, "number", header.Number(nodeCtx), "hash", header.Hash())
 						f.forgetHash(hash)
 					}
 				} else {
@@ -599,7 +603,7 @@ func (f *BlockFetcher) loop() {
 						if manifestHash == (common.Hash{}) {
 							manifestHash = types.DeriveSha(task.subManifest[i], trie.NewStackTrie(nil))
 						}
-						if manifestHash != announce.header.ManifestHash() {
+						if manifestHash != announce.header.ManifestHash(nodeCtx) {
 							continue
 						}
 						// Mark the body matched, reassemble if still unknown
@@ -674,14 +678,15 @@ func (f *BlockFetcher) rescheduleComplete(complete *time.Timer) {
 // enqueue schedules a new header or block import operation, if the component
 // to be imported has not yet been seen.
 func (f *BlockFetcher) enqueue(peer string, header *types.Header, block *types.Block) {
+	nodeCtx := f.nodeCtx
 	var (
 		hash   common.Hash
 		number uint64
 	)
 	if header != nil {
-		hash, number = header.Hash(), header.Number().Uint64()
+		hash, number = header.Hash(), header.Number(nodeCtx).Uint64()
 	} else {
-		hash, number = block.Hash(), block.NumberU64()
+		hash, number = block.Hash(), block.NumberU64(nodeCtx)
 	}
 
 	// Schedule the block for future importing
@@ -705,10 +710,11 @@ func (f *BlockFetcher) enqueue(peer string, header *types.Header, block *types.B
 // block's number is at the same height as the current import phase, it updates
 // the phase states accordingly.
 func (f *BlockFetcher) importBlocks(peer string, block *types.Block) {
+	nodeCtx := f.nodeCtx
 	hash := block.Hash()
 
 	// Run the import on a new thread
-	log.Debug("Importing propagated block", "peer", peer, "number", block.Number(), "hash", hash)
+	log.Debug("Importing propagated block", "peer", peer, "number", block.Number(nodeCtx), "hash", hash)
 	go func() {
 		defer func() { f.done <- hash }()
 
@@ -732,7 +738,7 @@ func (f *BlockFetcher) importBlocks(peer string, block *types.Block) {
 			// Weird future block, don't fail, but neither propagate
 		} else {
 			// Something went very wrong, drop the peer
-			log.Debug("Propagated block verification failed", "peer", peer, "number", block.Number(), "hash", hash, "err", err)
+			log.Debug("Propagated block verification failed", "peer", peer, "number", block.Number(nodeCtx), "hash", hash, "err", err)
 			f.dropPeer(peer)
 			return
 		}
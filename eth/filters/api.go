@@ -22,15 +22,18 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 
 	quai "github.com/dominant-strategies/go-quai"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/dominant-strategies/go-quai/core"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/ethdb"
 	"github.com/dominant-strategies/go-quai/event"
+	"github.com/dominant-strategies/go-quai/internal/quaiapi"
 	"github.com/dominant-strategies/go-quai/rpc"
 )
 
@@ -615,3 +618,109 @@ func (api *PublicFilterAPI) PendingHeader(ctx context.Context) (*rpc.Subscriptio
 
 	return rpcSub, nil
 }
+
+// EtxStatus sends a notification each time the lifecycle of an ETX advances
+// in this slice. If hashes are given, only those ETXs are reported.
+func (api *PublicFilterAPI) EtxStatus(ctx context.Context, hashes []common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	watched := make(map[common.Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		watched[hash] = struct{}{}
+	}
+
+	go func() {
+		statuses := make(chan core.EtxStatusEvent, 10)
+		statusSub := api.backend.SubscribeEtxStatusEvent(statuses)
+
+		for {
+			select {
+			case ev := <-statuses:
+				if _, ok := watched[ev.Hash]; len(watched) > 0 && !ok {
+					continue
+				}
+				notifier.Notify(rpcSub.ID, quaiapi.RPCMarshalEtxStatus(ev.Hash, ev.Status))
+			case <-rpcSub.Err():
+				statusSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				statusSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// Reorgs sends a notification each time the canonical chain of this slice
+// switches to another branch, describing the reverted and the new blocks and
+// the ETXs affected by the switch.
+func (api *PublicFilterAPI) Reorgs(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		reorgs := make(chan core.ReorgEvent, 10)
+		reorgSub := api.backend.SubscribeReorgEvent(reorgs)
+
+		for {
+			select {
+			case ev := <-reorgs:
+				notifier.Notify(rpcSub.ID, rpcMarshalReorg(ev, api.backend.ChainConfig().Location.Context()))
+			case <-rpcSub.Err():
+				reorgSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				reorgSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// rpcMarshalReorg converts a reorg to the RPC output, listing the reverted and
+// the new blocks under the name of each context they are coincident with.
+func rpcMarshalReorg(ev core.ReorgEvent, nodeCtx int) map[string]interface{} {
+	removed := make(map[string][]common.Hash)
+	added := make(map[string][]common.Hash)
+	for ctx := 0; ctx < common.HierarchyDepth; ctx++ {
+		name := strings.ToLower(common.OrderToString(ctx))
+		if len(ev.Removed[ctx]) > 0 {
+			removed[name] = ev.Removed[ctx]
+		}
+		if len(ev.Added[ctx]) > 0 {
+			added[name] = ev.Added[ctx]
+		}
+	}
+	nonNil := func(hashes []common.Hash) []common.Hash {
+		if hashes == nil {
+			return []common.Hash{}
+		}
+		return hashes
+	}
+	return map[string]interface{}{
+		"commonAncestorHash":    ev.CommonAncestor.Hash(),
+		"commonAncestorNumber":  hexutil.Uint64(ev.CommonAncestor.NumberU64(nodeCtx)),
+		"oldHead":               ev.OldHead.Hash(),
+		"newHead":               ev.NewHead.Hash(),
+		"depth":                 hexutil.Uint64(ev.Depth),
+		"removed":               removed,
+		"added":                 added,
+		"revertedEtxs":          nonNil(ev.RevertedEtxs),
+		"reemittedEtxs":         nonNil(ev.ReemittedEtxs),
+		"revertedInboundEtxs":   nonNil(ev.RevertedInboundEtxs),
+		"reincludedInboundEtxs": nonNil(ev.ReincludedInboundEtxs),
+	}
+}
@@ -27,11 +27,13 @@ import (
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/ethdb"
 	"github.com/dominant-strategies/go-quai/event"
+	"github.com/dominant-strategies/go-quai/params"
 	"github.com/dominant-strategies/go-quai/rpc"
 )
 
 type Backend interface {
 	ChainDb() ethdb.Database
+	ChainConfig() *params.ChainConfig
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
 	HeaderByHash(ctx context.Context, blockHash common.Hash) (*types.Header, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
@@ -43,6 +45,8 @@ type Backend interface {
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribePendingHeaderEvent(ch chan<- *types.Header) event.Subscription
+	SubscribeEtxStatusEvent(ch chan<- core.EtxStatusEvent) event.Subscription
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
@@ -118,6 +122,7 @@ func newFilter(backend Backend, addresses []common.Address, topics [][]common.Ha
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	nodeCtx := f.backend.ChainConfig().Location.Context()
 	// If we're doing singleton block filtering, execute and return
 	if f.block != (common.Hash{}) {
 		header, err := f.backend.HeaderByHash(ctx, f.block)
@@ -134,8 +139,18 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if header == nil {
 		return nil, nil
 	}
-	head := header.Number().Uint64()
-
+	head := header.Number(nodeCtx).Uint64()
+
+	// Resolve the safe and finalized tags to the blocks they currently denote
+	for _, number := range []*int64{&f.begin, &f.end} {
+		if *number == rpc.SafeBlockNumber.Int64() || *number == rpc.FinalizedBlockNumber.Int64() {
+			header, _ := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(*number))
+			if header == nil {
+				return nil, nil
+			}
+			*number = int64(header.Number(nodeCtx).Uint64())
+		}
+	}
 	if f.begin == -1 {
 		f.begin = int64(head)
 	}
@@ -129,7 +129,7 @@ func NewEventSystem(backend Backend, lightMode bool) *EventSystem {
 		pendingHeaderCh: make(chan *types.Header),
 	}
 
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := backend.ChainConfig().Location.Context()
 	// Subscribe events
 	if nodeCtx == common.ZONE_CTX {
 		m.txsSub = m.backend.SubscribeNewTxsEvent(m.txsCh)
@@ -399,6 +399,7 @@ func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent)
 }
 
 func (es *EventSystem) lightFilterNewHead(newHeader *types.Header, callBack func(*types.Header, bool)) {
+	nodeCtx := es.backend.ChainConfig().Location.Context()
 	oldh := es.lastHead
 	es.lastHead = newHeader
 	if oldh == nil {
@@ -408,13 +409,13 @@ func (es *EventSystem) lightFilterNewHead(newHeader *types.Header, callBack func
 	// find common ancestor, create list of rolled back and new block hashes
 	var oldHeaders, newHeaders []*types.Header
 	for oldh.Hash() != newh.Hash() {
-		if oldh.Number().Uint64() >= newh.Number().Uint64() {
+		if oldh.Number(nodeCtx).Uint64() >= newh.Number(nodeCtx).Uint64() {
 			oldHeaders = append(oldHeaders, oldh)
-			oldh = rawdb.ReadHeader(es.backend.ChainDb(), oldh.ParentHash(), oldh.Number().Uint64()-1)
+			oldh = rawdb.ReadHeader(es.backend.ChainDb(), oldh.ParentHash(nodeCtx), oldh.Number(nodeCtx).Uint64()-1)
 		}
-		if oldh.Number().Uint64() < newh.Number().Uint64() {
+		if oldh.Number(nodeCtx).Uint64() < newh.Number(nodeCtx).Uint64() {
 			newHeaders = append(newHeaders, newh)
-			newh = rawdb.ReadHeader(es.backend.ChainDb(), newh.ParentHash(), newh.Number().Uint64()-1)
+			newh = rawdb.ReadHeader(es.backend.ChainDb(), newh.ParentHash(nodeCtx), newh.Number(nodeCtx).Uint64()-1)
 			if newh == nil {
 				// happens when CHT syncing, nothing to do
 				newh = oldh
@@ -483,7 +484,7 @@ func (es *EventSystem) handlePendingHeader(filters filterIndex, ev *types.Header
 
 // eventLoop (un)installs filters and processes mux events.
 func (es *EventSystem) eventLoop() {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := es.backend.ChainConfig().Location.Context()
 	// Ensure all subscriptions get cleaned up
 	defer func() {
 		if nodeCtx == common.ZONE_CTX {
@@ -114,6 +114,7 @@ type handler struct {
 	networkID     uint64
 	slicesRunning []common.Location // Slices running on the node
 
+	snapSync  uint32 // Flag whether snap sync is enabled (gets disabled if we already have blocks)
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	database ethdb.Database
@@ -157,7 +158,7 @@ type handler struct {
 
 // newHandler returns a handler for all Quai chain management protocol.
 func newHandler(config *handlerConfig) (*handler, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := config.Core.NodeCtx()
 	// Create the protocol manager with the base fields
 	if config.EventMux == nil {
 		config.EventMux = new(event.TypeMux) // Nicety initialization for tests
@@ -175,25 +176,34 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		quitSync:      make(chan struct{}),
 	}
 
-	h.downloader = downloader.New(h.eventMux, h.core, h.removePeer)
+	if config.Sync == downloader.SnapSync {
+		// Snap sync only downloads the state for a zone which hasn't executed
+		// any block yet, otherwise fall back to full sync
+		if nodeCtx == common.ZONE_CTX && h.core.CurrentBlock().NumberU64(nodeCtx) == 0 {
+			h.snapSync = uint32(1)
+		} else {
+			log.Warn("Switch sync mode from snap sync to full sync")
+		}
+	}
+	h.downloader = downloader.New(h.database, h.eventMux, h.core, h.removePeer)
 
 	// Construct the fetcher (short sync)
 	validator := func(header *types.Header) error {
 		return h.core.Engine().VerifyHeader(h.core, header)
 	}
 	heighter := func() uint64 {
-		return h.core.CurrentBlock().NumberU64()
+		return h.core.CurrentBlock().NumberU64(nodeCtx)
 	}
 	// writeBlock writes the block to the DB
 	writeBlock := func(block *types.Block) {
-		if nodeCtx == common.ZONE_CTX && block.NumberU64()-1 == h.core.CurrentHeader().NumberU64() {
+		if nodeCtx == common.ZONE_CTX && block.NumberU64(nodeCtx)-1 == h.core.CurrentHeader().NumberU64(nodeCtx) {
 			if atomic.LoadUint32(&h.acceptTxs) != 1 {
 				atomic.StoreUint32(&h.acceptTxs, 1)
 			}
 		}
 		h.core.WriteBlock(block)
 	}
-	h.blockFetcher = fetcher.NewBlockFetcher(h.core.GetBlockByHash, writeBlock, validator, h.BroadcastBlock, heighter, h.removePeer, h.core.IsBlockHashABadHash)
+	h.blockFetcher = fetcher.NewBlockFetcher(h.core.GetBlockByHash, writeBlock, validator, h.BroadcastBlock, heighter, h.removePeer, h.core.IsBlockHashABadHash, h.core.NodeCtx())
 
 	// Only initialize the Tx fetcher in zone
 	if nodeCtx == common.ZONE_CTX {
@@ -213,7 +223,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 // runEthPeer registers an eth peer into the joint eth peerset, adds it to
 // various subsystems and starts handling messages.
 func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := h.core.NodeCtx()
 	if !h.chainSync.handlePeerEvent(peer) {
 		return p2p.DiscQuitting
 	}
@@ -227,7 +237,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 		hash    = head.Hash()
 		entropy = h.core.CurrentLogEntropy()
 	)
-	if err := peer.Handshake(h.networkID, h.slicesRunning, entropy, hash, genesis.Hash()); err != nil {
+	if err := peer.Handshake(h.networkID, h.core.NodeLocation(), h.core.Config().GetTopology(), h.slicesRunning, entropy, hash, genesis.Hash()); err != nil {
 		peer.Log().Debug("Quai handshake failed", "err", err)
 		return err
 	}
@@ -301,7 +311,7 @@ func (h *handler) unregisterPeer(id string) {
 	}
 
 	h.downloader.UnregisterPeer(id)
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := h.core.NodeCtx()
 	if nodeCtx == common.ZONE_CTX {
 		h.txFetcher.Drop(id)
 	}
@@ -314,7 +324,7 @@ func (h *handler) unregisterPeer(id string) {
 func (h *handler) Start(maxPeers int) {
 	h.maxPeers = maxPeers
 
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := h.core.NodeCtx()
 	if nodeCtx == common.ZONE_CTX {
 		// broadcast transactions
 		h.wg.Add(1)
@@ -370,7 +380,7 @@ func (h *handler) Start(maxPeers int) {
 }
 
 func (h *handler) Stop() {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := h.core.NodeCtx()
 	if nodeCtx == common.ZONE_CTX {
 		h.txsSub.Unsubscribe() // quits txBroadcastLoop
 	}
@@ -400,6 +410,7 @@ func (h *handler) Stop() {
 // BroadcastBlock will either propagate a block to a subset of its peers, or
 // will only announce its availability (depending what's requested).
 func (h *handler) BroadcastBlock(block *types.Block, propagate bool) {
+	nodeCtx := h.core.NodeCtx()
 	hash := block.Hash()
 	peers := h.peers.peersWithoutBlock(hash)
 
@@ -421,7 +432,7 @@ func (h *handler) BroadcastBlock(block *types.Block, propagate bool) {
 		return
 	}
 	// Otherwise if the block is indeed in out own chain, announce it
-	if h.core.HasBlock(hash, block.NumberU64()) {
+	if h.core.HasBlock(hash, block.NumberU64(nodeCtx)) {
 		for _, peer := range peers {
 			peer.AsyncSendNewBlockHash(block)
 		}
@@ -509,14 +520,15 @@ func (h *handler) txBroadcastLoop() {
 
 // missingBodyLoop listens to the MissingBody event in Slice and calls the blockAnnounces.
 func (h *handler) missingBodyLoop() {
+	nodeCtx := h.core.NodeCtx()
 	defer h.wg.Done()
 	for {
 		select {
 		case header := <-h.missingBodyCh:
 			// Check if any of the peers have the body
 			for _, peer := range h.selectSomePeers() {
-				log.Debug("Fetching the missing body from", "peer", peer.ID(), "hash", header.Hash(), "number", header.NumberU64())
-				h.blockFetcher.Notify(peer.ID(), header.Hash(), header.NumberU64(), time.Now(), peer.RequestOneHeader, peer.RequestBodies)
+				log.Debug("Fetching the missing body from", "peer", peer.ID(), "hash", header.Hash(), "number", header.NumberU64(nodeCtx))
+				h.blockFetcher.Notify(peer.ID(), header.Hash(), header.NumberU64(nodeCtx), time.Now(), peer.RequestOneHeader, peer.RequestBodies)
 			}
 
 		case <-h.missingBodySub.Err():
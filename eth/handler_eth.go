@@ -99,6 +99,23 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 	case *eth.PendingEtxsRollupPacket:
 		return h.handlePendingEtxsRollup(peer, *&packet.PendingEtxsRollup)
 
+	case *eth.AccountRangePacket:
+		hashes, accounts := packet.Unpack()
+		return h.downloader.DeliverAccountRange(peer.ID(), packet.RequestId, hashes, accounts, packet.Proof)
+
+	case *eth.StorageRangesPacket:
+		hashset, slotset := packet.Unpack()
+		return h.downloader.DeliverStorageRanges(peer.ID(), packet.RequestId, hashset, slotset, packet.Proof)
+
+	case *eth.ByteCodesPacket:
+		return h.downloader.DeliverByteCodes(peer.ID(), packet.RequestId, packet.Codes)
+
+	case *eth.TrieNodesPacket:
+		return h.downloader.DeliverTrieNodes(peer.ID(), packet.RequestId, packet.Nodes)
+
+	case *eth.PivotDataPacket:
+		return h.downloader.DeliverPivotData(peer.ID(), packet.RequestId, packet.Termini, packet.EtxSet)
+
 	default:
 		return fmt.Errorf("unexpected eth packet type: %T", packet)
 	}
@@ -107,6 +124,7 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 // handleHeaders is invoked from a peer's message handler when it transmits a batch
 // of headers for the local node to process.
 func (h *ethHandler) handleHeaders(peer *eth.Peer, headers []*types.Header) error {
+	nodeCtx := h.core.NodeCtx()
 	p := h.peers.peer(peer.ID())
 	if p == nil {
 		return errors.New("unregistered during callback")
@@ -121,12 +139,12 @@ func (h *ethHandler) handleHeaders(peer *eth.Peer, headers []*types.Header) erro
 	filter := len(headers) == 1
 	if filter {
 		// Otherwise if it's a whitelisted block, validate against the set
-		if want, ok := h.whitelist[headers[0].Number().Uint64()]; ok {
+		if want, ok := h.whitelist[headers[0].Number(nodeCtx).Uint64()]; ok {
 			if hash := headers[0].Hash(); want != hash {
-				peer.Log().Info("Whitelist mismatch, dropping peer", "number", headers[0].Number().Uint64(), "hash", hash, "want", want)
+				peer.Log().Info("Whitelist mismatch, dropping peer", "number", headers[0].Number(nodeCtx).Uint64(), "hash", hash, "want", want)
 				return errors.New("whitelist block mismatch")
 			}
-			peer.Log().Debug("Whitelist block verified", "number", headers[0].Number().Uint64(), "hash", want)
+			peer.Log().Debug("Whitelist block verified", "number", headers[0].Number(nodeCtx).Uint64(), "hash", want)
 		}
 		// Irrelevant of the fork checks, send the header to the fetcher just in case
 		headers = h.blockFetcher.FilterHeaders(peer.ID(), headers, time.Now())
@@ -186,6 +204,7 @@ func (h *ethHandler) handleBlockAnnounces(peer *eth.Peer, hashes []common.Hash,
 // handleBlockBroadcast is invoked from a peer's message handler when it transmits a
 // block broadcast for the local node to process.
 func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, block *types.Block) error {
+	nodeCtx := h.core.NodeCtx()
 	// Do not handle any broadcast until we finish resetting from the bad state.
 	// This should be a very small time window
 	if h.Core().BadHashExistsInChain() {
@@ -199,7 +218,7 @@ func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, block *types.Block) er
 	_, _, peerEntropy, _ := peer.Head()
 	if blockS != nil && peerEntropy != nil {
 		if peerEntropy.Cmp(blockS) < 0 {
-			peer.SetHead(block.Hash(), block.Number(), blockS, block.ReceivedAt)
+			peer.SetHead(block.Hash(), block.Number(nodeCtx), blockS, block.ReceivedAt)
 			h.chainSync.handlePeerEvent(peer)
 		}
 	}
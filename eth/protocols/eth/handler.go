@@ -52,6 +52,14 @@ const (
 	// containing 200+ transactions nowadays, the practical limit will always
 	// be softResponseLimit.
 	maxReceiptsServe = 1024
+
+	// maxCodeLookups is the maximum number of bytecodes to serve. This number is
+	// there to limit the number of disk lookups.
+	maxCodeLookups = 1024
+
+	// maxTrieNodeLookups is the maximum number of state trie nodes to serve. This
+	// number is there to limit the number of disk lookups.
+	maxTrieNodeLookups = 1024
 )
 
 // Handler is a callback to invoke from an outside runner after the boilerplate
@@ -103,7 +111,7 @@ func MakeProtocols(backend Backend, network uint64, dnsdisc enode.Iterator) []p2
 			Version: version,
 			Length:  protocolLengths[version],
 			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
-				peer := NewPeer(version, p, rw, backend.TxPool())
+				peer := NewPeer(version, p, rw, backend.TxPool(), backend.Core().NodeCtx())
 				defer peer.Close()
 
 				return backend.RunPeer(peer, func(peer *Peer) error {
@@ -196,6 +204,36 @@ var eth66 = map[uint64]msgHandler{
 	GetBlockMsg:                handleGetBlock66,
 }
 
+var eth67 = map[uint64]msgHandler{
+	NewBlockHashesMsg:             handleNewBlockhashes,
+	NewBlockMsg:                   handleNewBlock,
+	TransactionsMsg:               handleTransactions,
+	NewPooledTransactionHashesMsg: handleNewPooledTransactionHashes,
+	// eth66 messages with request-id
+	GetBlockHeadersMsg:         handleGetBlockHeaders66,
+	BlockHeadersMsg:            handleBlockHeaders66,
+	GetBlockBodiesMsg:          handleGetBlockBodies66,
+	BlockBodiesMsg:             handleBlockBodies66,
+	GetPooledTransactionsMsg:   handleGetPooledTransactions66,
+	PendingEtxsMsg:             handlePendingEtxs,
+	PendingEtxsRollupMsg:       handlePendingEtxsRollup,
+	GetOnePendingEtxsRollupMsg: handleGetOnePendingEtxsRollup66,
+	GetOnePendingEtxsMsg:       handleGetOnePendingEtxs66,
+	PooledTransactionsMsg:      handlePooledTransactions66,
+	GetBlockMsg:                handleGetBlock66,
+	// eth67 state sync messages
+	GetAccountRangeMsg:  handleGetAccountRange,
+	AccountRangeMsg:     handleAccountRange,
+	GetStorageRangesMsg: handleGetStorageRanges,
+	StorageRangesMsg:    handleStorageRanges,
+	GetByteCodesMsg:     handleGetByteCodes,
+	ByteCodesMsg:        handleByteCodes,
+	GetTrieNodesMsg:     handleGetTrieNodes,
+	TrieNodesMsg:        handleTrieNodes,
+	GetPivotDataMsg:     handleGetPivotData,
+	PivotDataMsg:        handlePivotData,
+}
+
 // handleMessage is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
 func handleMessage(backend Backend, peer *Peer) error {
@@ -210,7 +248,9 @@ func handleMessage(backend Backend, peer *Peer) error {
 	defer msg.Discard()
 
 	var handlers = eth65
-	if peer.Version() >= ETH66 {
+	if peer.Version() >= ETH67 {
+		handlers = eth67
+	} else if peer.Version() >= ETH66 {
 		handlers = eth66
 	}
 	// Track the amount of time it takes to serve the request and run the handler
@@ -17,16 +17,24 @@
 package eth
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
+	"github.com/dominant-strategies/go-quai/core/state"
 	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
 	"github.com/dominant-strategies/go-quai/log"
 	"github.com/dominant-strategies/go-quai/rlp"
 	"github.com/dominant-strategies/go-quai/trie"
 )
 
+// emptyCode is the known hash of the empty EVM bytecode.
+var emptyCode = crypto.Keccak256Hash(nil)
+
 // handleGetBlockHeaders handles Block header query, collect the requested headers and reply
 func handleGetBlockHeaders(backend Backend, msg Decoder, peer *Peer) error {
 	// Decode the complex header query
@@ -50,6 +58,7 @@ func handleGetBlockHeaders66(backend Backend, msg Decoder, peer *Peer) error {
 }
 
 func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, peer *Peer) []*types.Header {
+	nodeCtx := backend.Core().NodeCtx()
 	hashMode := query.Origin.Hash != (common.Hash{})
 	first := true
 	maxNonCanonical := uint64(100)
@@ -69,7 +78,7 @@ func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, p
 				first = false
 				origin = backend.Core().GetHeaderOrCandidateByHash(query.Origin.Hash)
 				if origin != nil {
-					query.Origin.Number = origin.NumberU64()
+					query.Origin.Number = origin.NumberU64(nodeCtx)
 				}
 			} else {
 				origin = backend.Core().GetHeaderOrCandidate(query.Origin.Hash, query.Origin.Number)
@@ -257,7 +266,7 @@ func handleNewBlockhashes(backend Backend, msg Decoder, peer *Peer) error {
 }
 
 func handleNewBlock(backend Backend, msg Decoder, peer *Peer) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := backend.Core().NodeCtx()
 	// Retrieve and decode the propagated block
 	ann := new(NewBlockPacket)
 	if err := msg.Decode(ann); err != nil {
@@ -292,7 +301,7 @@ func handleNewBlock(backend Backend, msg Decoder, peer *Peer) error {
 		}
 		// Dom nodes need to validate the subordinate manifest against the subordinate's manifesthash
 		if hash := types.DeriveSha(ann.Block.SubManifest(), trie.NewStackTrie(nil)); hash != ann.Block.ManifestHash(nodeCtx+1) {
-			log.Warn("Propagated block has invalid subordinate manifest", "peer", peer.id, "block hash", ann.Block.Hash(), "have", hash, "exp", ann.Block.ManifestHash())
+			log.Warn("Propagated block has invalid subordinate manifest", "peer", peer.id, "block hash", ann.Block.Hash(), "have", hash, "exp", ann.Block.ManifestHash(nodeCtx))
 			return nil
 		}
 	}
@@ -346,7 +355,7 @@ func handleBlockBodies66(backend Backend, msg Decoder, peer *Peer) error {
 }
 
 func handleNewPooledTransactionHashes(backend Backend, msg Decoder, peer *Peer) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := backend.Core().NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return errors.New("transactions are only handled in zone")
 	}
@@ -367,7 +376,7 @@ func handleNewPooledTransactionHashes(backend Backend, msg Decoder, peer *Peer)
 }
 
 func handleGetPooledTransactions(backend Backend, msg Decoder, peer *Peer) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := backend.Core().NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return errors.New("transactions are only handled in zone")
 	}
@@ -419,7 +428,7 @@ func answerGetPooledTransactions(backend Backend, query GetPooledTransactionsPac
 }
 
 func handleTransactions(backend Backend, msg Decoder, peer *Peer) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := backend.Core().NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return errors.New("transactions are only handled in zone")
 	}
@@ -443,7 +452,7 @@ func handleTransactions(backend Backend, msg Decoder, peer *Peer) error {
 }
 
 func handlePooledTransactions(backend Backend, msg Decoder, peer *Peer) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := backend.Core().NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return errors.New("transactions are only handled in zone")
 	}
@@ -467,7 +476,7 @@ func handlePooledTransactions(backend Backend, msg Decoder, peer *Peer) error {
 }
 
 func handlePooledTransactions66(backend Backend, msg Decoder, peer *Peer) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := backend.Core().NodeCtx()
 	if nodeCtx != common.ZONE_CTX {
 		return errors.New("transactions are only handled in zone")
 	}
@@ -491,3 +500,325 @@ func handlePooledTransactions66(backend Backend, msg Decoder, peer *Peer) error
 
 	return backend.Handle(peer, &txs.PooledTransactionsPacket)
 }
+
+// proofList is a flat list of trie nodes, used to collect the nodes of range
+// proofs into a response.
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+func (n *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+func handleGetAccountRange(backend Backend, msg Decoder, peer *Peer) error {
+	// Decode the account retrieval request
+	var req GetAccountRangePacket
+	if err := msg.Decode(&req); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	accounts, proof := answerGetAccountRangeQuery(backend, &req)
+	return peer.ReplyAccountRange(req.RequestId, accounts, proof)
+}
+
+func answerGetAccountRangeQuery(backend Backend, req *GetAccountRangePacket) ([]*AccountData, [][]byte) {
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+	// Open the requested account trie, an unknown root yields an empty response
+	tr, err := trie.New(req.Root, backend.Core().Processor().StateCache().TrieDB())
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		accounts []*AccountData
+		size     uint64
+		last     common.Hash
+	)
+	it := trie.NewIterator(tr.NodeIterator(req.Origin[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		accounts = append(accounts, &AccountData{Hash: hash, Body: common.CopyBytes(it.Value)})
+		size += uint64(common.HashLength + len(it.Value))
+		last = hash
+
+		// The first account past the limit is included to prove the range
+		if bytes.Compare(hash[:], req.Limit[:]) >= 0 || size >= req.Bytes {
+			break
+		}
+	}
+	if it.Err != nil {
+		log.Debug("Failed to iterate account range", "root", req.Root, "err", it.Err)
+		return nil, nil
+	}
+	// Prove the boundaries of the returned range
+	var proof proofList
+	if err := tr.Prove(req.Origin[:], 0, &proof); err != nil {
+		return nil, nil
+	}
+	if len(accounts) > 0 {
+		if err := tr.Prove(last[:], 0, &proof); err != nil {
+			return nil, nil
+		}
+	}
+	return accounts, proof
+}
+
+func handleGetStorageRanges(backend Backend, msg Decoder, peer *Peer) error {
+	// Decode the storage retrieval request
+	var req GetStorageRangesPacket
+	if err := msg.Decode(&req); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	slots, proof := answerGetStorageRangesQuery(backend, &req)
+	return peer.ReplyStorageRanges(req.RequestId, slots, proof)
+}
+
+func answerGetStorageRangesQuery(backend Backend, req *GetStorageRangesPacket) ([][]*StorageData, [][]byte) {
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+	triedb := backend.Core().Processor().StateCache().TrieDB()
+	accTrie, err := trie.New(req.Root, triedb)
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		slots [][]*StorageData
+		proof proofList
+		size  uint64
+	)
+	for _, account := range req.Accounts {
+		// If we've exceeded the requested data limit, abort without opening
+		// a new storage range (that we'd need to prove due to exceeded size)
+		if size >= req.Bytes {
+			break
+		}
+		blob, err := accTrie.TryGet(account[:])
+		if err != nil || blob == nil {
+			return nil, nil
+		}
+		var acc state.Account
+		if err := rlp.DecodeBytes(blob, &acc); err != nil {
+			return nil, nil
+		}
+		stTrie, err := trie.New(acc.Root, triedb)
+		if err != nil {
+			return nil, nil
+		}
+		// The origin is only applied to the first account, the others are
+		// always retrieved in full
+		var origin []byte
+		if len(slots) == 0 {
+			origin = req.Origin
+		}
+		var (
+			storage []*StorageData
+			last    common.Hash
+			abort   bool
+		)
+		it := trie.NewIterator(stTrie.NodeIterator(origin))
+		for it.Next() {
+			if size >= req.Bytes {
+				abort = true
+				break
+			}
+			hash := common.BytesToHash(it.Key)
+			storage = append(storage, &StorageData{Hash: hash, Body: common.CopyBytes(it.Value)})
+			size += uint64(common.HashLength + len(it.Value))
+			last = hash
+
+			// The first slot past the limit is included to prove the range
+			if len(req.Limit) > 0 && bytes.Compare(hash[:], req.Limit) >= 0 {
+				break
+			}
+		}
+		if it.Err != nil {
+			return nil, nil
+		}
+		slots = append(slots, storage)
+
+		// A range which was started from an origin or was cut short has to be
+		// proven, and no more ranges may follow it
+		if len(origin) > 0 || abort {
+			if err := stTrie.Prove(common.BytesToHash(origin).Bytes(), 0, &proof); err != nil {
+				return nil, nil
+			}
+			if len(storage) > 0 {
+				if err := stTrie.Prove(last[:], 0, &proof); err != nil {
+					return nil, nil
+				}
+			}
+			break
+		}
+	}
+	return slots, proof
+}
+
+func handleGetByteCodes(backend Backend, msg Decoder, peer *Peer) error {
+	// Decode the bytecode retrieval request
+	var req GetByteCodesPacket
+	if err := msg.Decode(&req); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return peer.ReplyByteCodes(req.RequestId, answerGetByteCodesQuery(backend, &req))
+}
+
+func answerGetByteCodesQuery(backend Backend, req *GetByteCodesPacket) [][]byte {
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+	if len(req.Hashes) > maxCodeLookups {
+		req.Hashes = req.Hashes[:maxCodeLookups]
+	}
+	var (
+		codes [][]byte
+		bytes uint64
+	)
+	for _, hash := range req.Hashes {
+		if hash == emptyCode {
+			// Peers should not request the empty code, but if they do, at
+			// least sent them back a correct response without db lookups
+			codes = append(codes, []byte{})
+		} else if blob, err := backend.Core().Processor().ContractCode(hash); err == nil {
+			codes = append(codes, blob)
+			bytes += uint64(len(blob))
+		}
+		if bytes > req.Bytes {
+			break
+		}
+	}
+	return codes
+}
+
+func handleGetTrieNodes(backend Backend, msg Decoder, peer *Peer) error {
+	// Decode the trie node retrieval request
+	var req GetTrieNodesPacket
+	if err := msg.Decode(&req); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return peer.ReplyTrieNodes(req.RequestId, answerGetTrieNodesQuery(backend, &req))
+}
+
+func answerGetTrieNodesQuery(backend Backend, req *GetTrieNodesPacket) [][]byte {
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+	if len(req.Hashes) > maxTrieNodeLookups {
+		req.Hashes = req.Hashes[:maxTrieNodeLookups]
+	}
+	var (
+		nodes [][]byte
+		bytes uint64
+	)
+	for _, hash := range req.Hashes {
+		if blob, err := backend.Core().Processor().TrieNode(hash); err == nil && len(blob) > 0 {
+			nodes = append(nodes, blob)
+			bytes += uint64(len(blob))
+		}
+		if bytes > req.Bytes {
+			break
+		}
+	}
+	return nodes
+}
+
+func handleGetPivotData(backend Backend, msg Decoder, peer *Peer) error {
+	// Decode the pivot data retrieval request
+	var req GetPivotDataPacket
+	if err := msg.Decode(&req); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	termini, etxSet := answerGetPivotDataQuery(backend, &req)
+	return peer.ReplyPivotData(req.RequestId, termini, etxSet)
+}
+
+func answerGetPivotDataQuery(backend Backend, req *GetPivotDataPacket) ([][]common.Hash, []rawdb.EtxSetEntry) {
+	nodeCtx := backend.Core().NodeCtx()
+	if len(req.Hashes) == 0 || len(req.Hashes) > maxHeadersServe {
+		return nil, nil
+	}
+	termini := make([][]common.Hash, 0, len(req.Hashes))
+	for _, hash := range req.Hashes {
+		t := backend.Core().GetTerminiByHash(hash)
+		if t == nil {
+			return nil, nil
+		}
+		termini = append(termini, t)
+	}
+	pivot := backend.Core().GetHeaderByHash(req.Hashes[len(req.Hashes)-1])
+	if pivot == nil {
+		return nil, nil
+	}
+	set := backend.Core().GetEtxSet(pivot.Hash(), pivot.NumberU64(nodeCtx))
+	if set == nil {
+		return nil, nil
+	}
+	// Entries are sorted by hash to keep the response deterministic
+	etxSet := make([]rawdb.EtxSetEntry, 0, len(set))
+	for hash, entry := range set {
+		etxSet = append(etxSet, rawdb.EtxSetEntry{EtxHash: hash, EtxHeight: entry.Height, Etx: entry.ETX})
+	}
+	sort.Slice(etxSet, func(i, j int) bool {
+		return bytes.Compare(etxSet[i].EtxHash[:], etxSet[j].EtxHash[:]) < 0
+	})
+	return termini, etxSet
+}
+
+func handleAccountRange(backend Backend, msg Decoder, peer *Peer) error {
+	// A range of accounts arrived to one of our previous requests
+	res := new(AccountRangePacket)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	requestTracker.Fulfil(peer.id, peer.version, AccountRangeMsg, res.RequestId)
+
+	return backend.Handle(peer, res)
+}
+
+func handleStorageRanges(backend Backend, msg Decoder, peer *Peer) error {
+	// Ranges of storage slots arrived to one of our previous requests
+	res := new(StorageRangesPacket)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	requestTracker.Fulfil(peer.id, peer.version, StorageRangesMsg, res.RequestId)
+
+	return backend.Handle(peer, res)
+}
+
+func handleByteCodes(backend Backend, msg Decoder, peer *Peer) error {
+	// A batch of byte codes arrived to one of our previous requests
+	res := new(ByteCodesPacket)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	requestTracker.Fulfil(peer.id, peer.version, ByteCodesMsg, res.RequestId)
+
+	return backend.Handle(peer, res)
+}
+
+func handleTrieNodes(backend Backend, msg Decoder, peer *Peer) error {
+	// A batch of trie nodes arrived to one of our previous requests
+	res := new(TrieNodesPacket)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	requestTracker.Fulfil(peer.id, peer.version, TrieNodesMsg, res.RequestId)
+
+	return backend.Handle(peer, res)
+}
+
+func handlePivotData(backend Backend, msg Decoder, peer *Peer) error {
+	// The pivot data arrived to one of our previous requests
+	res := new(PivotDataPacket)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	requestTracker.Fulfil(peer.id, peer.version, PivotDataMsg, res.RequestId)
+
+	return backend.Handle(peer, res)
+}
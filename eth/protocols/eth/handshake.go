@@ -32,8 +32,9 @@ const (
 )
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *Peer) Handshake(network uint64, slices []common.Location, entropy *big.Int, head common.Hash, genesis common.Hash) error {
+// network IDs, difficulties, head and genesis blocks. The topology of the
+// hierarchy bounds the number of slices a peer may run.
+func (p *Peer) Handshake(network uint64, location common.Location, topology *common.Topology, slices []common.Location, entropy *big.Int, head common.Hash, genesis common.Hash) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
 
@@ -44,14 +45,14 @@ func (p *Peer) Handshake(network uint64, slices []common.Location, entropy *big.
 			ProtocolVersion: uint32(p.version),
 			NetworkID:       network,
 			SlicesRunning:   slices,
-			Location:        common.NodeLocation.Name(),
+			Location:        location.Name(),
 			Entropy:         entropy,
 			Head:            head,
 			Genesis:         genesis,
 		})
 	}()
 	go func() {
-		errc <- p.readStatus(network, &status, genesis)
+		errc <- p.readStatus(network, location, topology, &status, genesis)
 	}()
 	timeout := time.NewTimer(handshakeTimeout)
 	defer timeout.Stop()
@@ -72,7 +73,7 @@ func (p *Peer) Handshake(network uint64, slices []common.Location, entropy *big.
 }
 
 // readStatus reads the remote handshake message.
-func (p *Peer) readStatus(network uint64, status *StatusPacket, genesis common.Hash) error {
+func (p *Peer) readStatus(network uint64, location common.Location, topology *common.Topology, status *StatusPacket, genesis common.Hash) error {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
 		return err
@@ -93,14 +94,14 @@ func (p *Peer) readStatus(network uint64, status *StatusPacket, genesis common.H
 	if uint(status.ProtocolVersion) != p.version {
 		return fmt.Errorf("%w: %d (!= %d)", errProtocolVersionMismatch, status.ProtocolVersion, p.version)
 	}
-	if status.Location != common.NodeLocation.Name() {
-		return fmt.Errorf("%w: %s (!= %s)", errLocationMismatch, status.Location, common.NodeLocation.Name())
+	if status.Location != location.Name() {
+		return fmt.Errorf("%w: %s (!= %s)", errLocationMismatch, status.Location, location.Name())
 	}
 	if status.Genesis != genesis {
 		return fmt.Errorf("%w: %x (!= %x)", errGenesisMismatch, status.Genesis, genesis)
 	}
 	// sanity check slices running
-	if len(status.SlicesRunning) == 0 || len(status.SlicesRunning) > common.NumRegionsInPrime*common.NumZonesInRegion {
+	if len(status.SlicesRunning) == 0 || len(status.SlicesRunning) > topology.Regions*topology.Zones {
 		return fmt.Errorf("%w: %v", errSlicesRunningRejected, fmt.Errorf("slices running sanity check failed"))
 	}
 	return nil
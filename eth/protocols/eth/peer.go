@@ -25,6 +25,7 @@ import (
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/p2p"
 	"github.com/dominant-strategies/go-quai/rlp"
@@ -77,6 +78,7 @@ type Peer struct {
 	*p2p.Peer                       // The embedded P2P package peer
 	rw            p2p.MsgReadWriter // Input/output streams for snap
 	version       uint              // Protocol version negotiated
+	nodeCtx       int               // Context of the local chain the peer is served from
 	slicesRunning []common.Location // Slices run by the node
 
 	head           common.Hash // Latest advertised head block hash
@@ -101,12 +103,13 @@ type Peer struct {
 
 // NewPeer create a wrapper for a network connection and negotiated  protocol
 // version.
-func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter, txpool TxPool) *Peer {
+func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter, txpool TxPool, nodeCtx int) *Peer {
 	peer := &Peer{
 		id:               p.ID().String(),
 		Peer:             p,
 		rw:               rw,
 		version:          version,
+		nodeCtx:          nodeCtx,
 		knownTxs:         mapset.NewSet(),
 		knownBlocks:      mapset.NewSet(),
 		knownPendingEtxs: mapset.NewSet(),
@@ -348,7 +351,7 @@ func (p *Peer) AsyncSendNewBlockHash(block *types.Block) {
 		}
 		p.knownBlocks.Add(block.Hash())
 	default:
-		p.Log().Debug("Dropping block announcement", "number", block.NumberU64(), "hash", block.Hash())
+		p.Log().Debug("Dropping block announcement", "number", block.NumberU64(p.nodeCtx), "hash", block.Hash())
 	}
 }
 
@@ -375,7 +378,7 @@ func (p *Peer) AsyncSendNewBlock(block *types.Block) {
 		}
 		p.knownBlocks.Add(block.Hash())
 	default:
-		p.Log().Debug("Dropping block propagation", "number", block.NumberU64(), "hash", block.Hash())
+		p.Log().Debug("Dropping block propagation", "number", block.NumberU64(p.nodeCtx), "hash", block.Hash())
 	}
 }
 
@@ -599,3 +602,134 @@ func (p *Peer) SendPendingEtxsRollup(pEtxsRollup types.PendingEtxsRollup) error
 	}
 	return errors.New("eth65 not supported for sendPendingEtxsManifest call")
 }
+
+// errStateSyncUnsupported is returned when requesting state sync data from a
+// peer which doesn't speak eth/67.
+var errStateSyncUnsupported = errors.New("state sync requires eth/67")
+
+// RequestAccountRange fetches a batch of accounts rooted in a specific account
+// trie, starting with the origin.
+func (p *Peer) RequestAccountRange(id uint64, root common.Hash, origin, limit common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching range of accounts", "reqid", id, "root", root, "origin", origin, "limit", limit, "bytes", common.StorageSize(bytes))
+	if p.Version() < ETH67 {
+		return errStateSyncUnsupported
+	}
+	requestTracker.Track(p.id, p.version, GetAccountRangeMsg, AccountRangeMsg, id)
+	return p2p.Send(p.rw, GetAccountRangeMsg, &GetAccountRangePacket{
+		RequestId: id,
+		Root:      root,
+		Origin:    origin,
+		Limit:     limit,
+		Bytes:     bytes,
+	})
+}
+
+// RequestStorageRanges fetches a batch of storage slots belonging to one or more
+// accounts. If slots from only one accout is requested, an origin marker may also
+// be used to retrieve from there.
+func (p *Peer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	if len(accounts) == 1 && origin != nil {
+		p.Log().Debug("Fetching range of large storage slots", "reqid", id, "root", root, "account", accounts[0], "origin", common.BytesToHash(origin), "limit", common.BytesToHash(limit), "bytes", common.StorageSize(bytes))
+	} else {
+		p.Log().Debug("Fetching ranges of small storage slots", "reqid", id, "root", root, "accounts", len(accounts), "first", accounts[0], "bytes", common.StorageSize(bytes))
+	}
+	if p.Version() < ETH67 {
+		return errStateSyncUnsupported
+	}
+	requestTracker.Track(p.id, p.version, GetStorageRangesMsg, StorageRangesMsg, id)
+	return p2p.Send(p.rw, GetStorageRangesMsg, &GetStorageRangesPacket{
+		RequestId: id,
+		Root:      root,
+		Accounts:  accounts,
+		Origin:    origin,
+		Limit:     limit,
+		Bytes:     bytes,
+	})
+}
+
+// RequestByteCodes fetches a batch of bytecodes by hash.
+func (p *Peer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching set of byte codes", "reqid", id, "hashes", len(hashes), "bytes", common.StorageSize(bytes))
+	if p.Version() < ETH67 {
+		return errStateSyncUnsupported
+	}
+	requestTracker.Track(p.id, p.version, GetByteCodesMsg, ByteCodesMsg, id)
+	return p2p.Send(p.rw, GetByteCodesMsg, &GetByteCodesPacket{
+		RequestId: id,
+		Hashes:    hashes,
+		Bytes:     bytes,
+	})
+}
+
+// RequestTrieNodes fetches a batch of account or storage trie nodes by hash.
+func (p *Peer) RequestTrieNodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	p.Log().Debug("Fetching set of trie nodes", "reqid", id, "hashes", len(hashes), "bytes", common.StorageSize(bytes))
+	if p.Version() < ETH67 {
+		return errStateSyncUnsupported
+	}
+	requestTracker.Track(p.id, p.version, GetTrieNodesMsg, TrieNodesMsg, id)
+	return p2p.Send(p.rw, GetTrieNodesMsg, &GetTrieNodesPacket{
+		RequestId: id,
+		Hashes:    hashes,
+		Bytes:     bytes,
+	})
+}
+
+// RequestPivotData fetches the termini of a batch of blocks, along with the
+// EtxSet at the last one of them.
+func (p *Peer) RequestPivotData(id uint64, hashes []common.Hash) error {
+	p.Log().Debug("Fetching pivot data", "reqid", id, "hashes", len(hashes))
+	if p.Version() < ETH67 {
+		return errStateSyncUnsupported
+	}
+	requestTracker.Track(p.id, p.version, GetPivotDataMsg, PivotDataMsg, id)
+	return p2p.Send(p.rw, GetPivotDataMsg, &GetPivotDataPacket{
+		RequestId: id,
+		Hashes:    hashes,
+	})
+}
+
+// ReplyAccountRange sends a batch of accounts along with their range proof.
+func (p *Peer) ReplyAccountRange(id uint64, accounts []*AccountData, proof [][]byte) error {
+	return p2p.Send(p.rw, AccountRangeMsg, &AccountRangePacket{
+		RequestId: id,
+		Accounts:  accounts,
+		Proof:     proof,
+	})
+}
+
+// ReplyStorageRanges sends batches of storage slots along with the range proof
+// of the last one, if it is incomplete.
+func (p *Peer) ReplyStorageRanges(id uint64, slots [][]*StorageData, proof [][]byte) error {
+	return p2p.Send(p.rw, StorageRangesMsg, &StorageRangesPacket{
+		RequestId: id,
+		Slots:     slots,
+		Proof:     proof,
+	})
+}
+
+// ReplyByteCodes sends a batch of contract bytecodes.
+func (p *Peer) ReplyByteCodes(id uint64, codes [][]byte) error {
+	return p2p.Send(p.rw, ByteCodesMsg, &ByteCodesPacket{
+		RequestId: id,
+		Codes:     codes,
+	})
+}
+
+// ReplyTrieNodes sends a batch of state trie nodes.
+func (p *Peer) ReplyTrieNodes(id uint64, nodes [][]byte) error {
+	return p2p.Send(p.rw, TrieNodesMsg, &TrieNodesPacket{
+		RequestId: id,
+		Nodes:     nodes,
+	})
+}
+
+// ReplyPivotData sends the termini of a batch of blocks and the EtxSet at the
+// last one of them.
+func (p *Peer) ReplyPivotData(id uint64, termini [][]common.Hash, etxSet []rawdb.EtxSetEntry) error {
+	return p2p.Send(p.rw, PivotDataMsg, &PivotDataPacket{
+		RequestId: id,
+		Termini:   termini,
+		EtxSet:    etxSet,
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"math/big"
 
 	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/rawdb"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/rlp"
 )
@@ -31,6 +32,7 @@ import (
 const (
 	ETH65 = 65
 	ETH66 = 66
+	ETH67 = 67
 )
 
 // ProtocolName is the official short name of the `quai` protocol used during
@@ -39,11 +41,11 @@ const c_ProtocolName = "quai"
 
 // ProtocolVersions are the supported versions of the `eth` protocol (first
 // is primary).
-var ProtocolVersions = []uint{ETH66, ETH65}
+var ProtocolVersions = []uint{ETH67, ETH66, ETH65}
 
 // protocolLengths are the number of implemented message corresponding to
 // different protocol versions.
-var protocolLengths = map[uint]uint64{ETH66: 21, ETH65: 19}
+var protocolLengths = map[uint]uint64{ETH67: 31, ETH66: 21, ETH65: 19}
 
 // maxMessageSize is the maximum cap on the size of a protocol message.
 const maxMessageSize = 10 * 1024 * 1024
@@ -70,6 +72,18 @@ const (
 	GetOnePendingEtxsMsg       = 0x12
 	PendingEtxsRollupMsg       = 0x13
 	GetOnePendingEtxsRollupMsg = 0x14
+
+	// Protocol messages introduced in eth/67 for state sync
+	GetAccountRangeMsg  = 0x15
+	AccountRangeMsg     = 0x16
+	GetStorageRangesMsg = 0x17
+	StorageRangesMsg    = 0x18
+	GetByteCodesMsg     = 0x19
+	ByteCodesMsg        = 0x1a
+	GetTrieNodesMsg     = 0x1b
+	TrieNodesMsg        = 0x1c
+	GetPivotDataMsg     = 0x1d
+	PivotDataMsg        = 0x1e
 )
 
 var (
@@ -329,6 +343,126 @@ type PendingEtxsRollupPacket66 struct {
 	PendingEtxsRollupPacket
 }
 
+// GetAccountRangePacket represents an account query over eth/67.
+type GetAccountRangePacket struct {
+	RequestId uint64      // Request ID to match up responses with
+	Root      common.Hash // Root hash of the account trie to serve
+	Origin    common.Hash // Hash of the first account to retrieve
+	Limit     common.Hash // Hash of the last account to retrieve
+	Bytes     uint64      // Soft limit at which to stop returning data
+}
+
+// AccountRangePacket represents an account query response.
+type AccountRangePacket struct {
+	RequestId uint64         // ID of the request this is a response for
+	Accounts  []*AccountData // List of consecutive accounts from the trie
+	Proof     [][]byte       // List of trie nodes proving the account range
+}
+
+// AccountData represents a single account in a query response. The body is the
+// consensus RLP encoding of the account, as stored in the account trie.
+type AccountData struct {
+	Hash common.Hash  // Hash of the account
+	Body rlp.RawValue // Account body in consensus RLP encoding
+}
+
+// Unpack retrieves the accounts from the range packet and returns them in a
+// split flat format that's more consistent with the trie range proofs.
+func (p *AccountRangePacket) Unpack() ([]common.Hash, [][]byte) {
+	var (
+		hashes   = make([]common.Hash, len(p.Accounts))
+		accounts = make([][]byte, len(p.Accounts))
+	)
+	for i, acc := range p.Accounts {
+		hashes[i], accounts[i] = acc.Hash, acc.Body
+	}
+	return hashes, accounts
+}
+
+// GetStorageRangesPacket represents a storage slot query over eth/67.
+type GetStorageRangesPacket struct {
+	RequestId uint64        // Request ID to match up responses with
+	Root      common.Hash   // Root hash of the account trie to serve
+	Accounts  []common.Hash // Account hashes of the storage tries to serve
+	Origin    []byte        // Hash of the first storage slot to retrieve (large contract mode)
+	Limit     []byte        // Hash of the last storage slot to retrieve (large contract mode)
+	Bytes     uint64        // Soft limit at which to stop returning data
+}
+
+// StorageRangesPacket represents a storage slot query response.
+type StorageRangesPacket struct {
+	RequestId uint64           // ID of the request this is a response for
+	Slots     [][]*StorageData // Lists of consecutive storage slots for the requested accounts
+	Proof     [][]byte         // Merkle proofs for the *last* slot range, if it's incomplete
+}
+
+// StorageData represents a single storage slot in a query response. The body
+// is the value as stored in the storage trie.
+type StorageData struct {
+	Hash common.Hash // Hash of the storage slot
+	Body []byte      // Data content of the slot
+}
+
+// Unpack retrieves the storage slots from the range packet and returns them in
+// a split flat format that's more consistent with the trie range proofs.
+func (p *StorageRangesPacket) Unpack() ([][]common.Hash, [][][]byte) {
+	var (
+		hashset = make([][]common.Hash, len(p.Slots))
+		slotset = make([][][]byte, len(p.Slots))
+	)
+	for i, slots := range p.Slots {
+		hashset[i] = make([]common.Hash, len(slots))
+		slotset[i] = make([][]byte, len(slots))
+		for j, slot := range slots {
+			hashset[i][j] = slot.Hash
+			slotset[i][j] = slot.Body
+		}
+	}
+	return hashset, slotset
+}
+
+// GetByteCodesPacket represents a contract bytecode query over eth/67.
+type GetByteCodesPacket struct {
+	RequestId uint64        // Request ID to match up responses with
+	Hashes    []common.Hash // Code hashes to retrieve the code for
+	Bytes     uint64        // Soft limit at which to stop returning data
+}
+
+// ByteCodesPacket represents a contract bytecode query response.
+type ByteCodesPacket struct {
+	RequestId uint64   // ID of the request this is a response for
+	Codes     [][]byte // Requested contract bytecodes
+}
+
+// GetTrieNodesPacket represents a state trie node query over eth/67. Nodes
+// are requested by hash, as needed by the trie healing.
+type GetTrieNodesPacket struct {
+	RequestId uint64        // Request ID to match up responses with
+	Hashes    []common.Hash // Hashes of the trie nodes to retrieve
+	Bytes     uint64        // Soft limit at which to stop returning data
+}
+
+// TrieNodesPacket represents a state trie node query response.
+type TrieNodesPacket struct {
+	RequestId uint64   // ID of the request this is a response for
+	Nodes     [][]byte // Requested state trie nodes
+}
+
+// GetPivotDataPacket represents a query for the data needed to append on top
+// of a state synced pivot block, which isn't committed to by the header.
+type GetPivotDataPacket struct {
+	RequestId uint64        // Request ID to match up responses with
+	Hashes    []common.Hash // Hashes of the blocks to retrieve the termini for, pivot last
+}
+
+// PivotDataPacket represents a pivot data query response. The termini are
+// returned for every requested block, the EtxSet only for the last one.
+type PivotDataPacket struct {
+	RequestId uint64              // ID of the request this is a response for
+	Termini   [][]common.Hash     // Termini of the requested blocks
+	EtxSet    []rawdb.EtxSetEntry // EtxSet at the pivot block
+}
+
 func (*StatusPacket) Name() string { return "Status" }
 func (*StatusPacket) Kind() byte   { return StatusMsg }
 
@@ -373,3 +507,33 @@ func (*PendingEtxsPacket) Kind() byte   { return PendingEtxsMsg }
 
 func (*PendingEtxsRollupPacket) Name() string { return "PendingEtxsManifest" }
 func (*PendingEtxsRollupPacket) Kind() byte   { return PendingEtxsRollupMsg }
+
+func (*GetAccountRangePacket) Name() string { return "GetAccountRange" }
+func (*GetAccountRangePacket) Kind() byte   { return GetAccountRangeMsg }
+
+func (*AccountRangePacket) Name() string { return "AccountRange" }
+func (*AccountRangePacket) Kind() byte   { return AccountRangeMsg }
+
+func (*GetStorageRangesPacket) Name() string { return "GetStorageRanges" }
+func (*GetStorageRangesPacket) Kind() byte   { return GetStorageRangesMsg }
+
+func (*StorageRangesPacket) Name() string { return "StorageRanges" }
+func (*StorageRangesPacket) Kind() byte   { return StorageRangesMsg }
+
+func (*GetByteCodesPacket) Name() string { return "GetByteCodes" }
+func (*GetByteCodesPacket) Kind() byte   { return GetByteCodesMsg }
+
+func (*ByteCodesPacket) Name() string { return "ByteCodes" }
+func (*ByteCodesPacket) Kind() byte   { return ByteCodesMsg }
+
+func (*GetTrieNodesPacket) Name() string { return "GetTrieNodes" }
+func (*GetTrieNodesPacket) Kind() byte   { return GetTrieNodesMsg }
+
+func (*TrieNodesPacket) Name() string { return "TrieNodes" }
+func (*TrieNodesPacket) Kind() byte   { return TrieNodesMsg }
+
+func (*GetPivotDataPacket) Name() string { return "GetPivotData" }
+func (*GetPivotDataPacket) Kind() byte   { return GetPivotDataMsg }
+
+func (*PivotDataPacket) Name() string { return "PivotData" }
+func (*PivotDataPacket) Kind() byte   { return PivotDataMsg }
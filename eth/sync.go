@@ -19,12 +19,14 @@ package eth
 import (
 	"math/big"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/eth/downloader"
 	"github.com/dominant-strategies/go-quai/eth/protocols/eth"
+	"github.com/dominant-strategies/go-quai/log"
 	"github.com/dominant-strategies/go-quai/p2p/enode"
 )
 
@@ -189,7 +191,7 @@ func (cs *chainSyncer) handlePeerEvent(peer *eth.Peer) bool {
 
 // loop runs in its own goroutine and launches the sync when necessary.
 func (cs *chainSyncer) loop() {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := cs.handler.core.NodeCtx()
 	defer cs.handler.wg.Done()
 
 	cs.handler.blockFetcher.Start()
@@ -268,6 +270,9 @@ func peerToSyncOp(mode downloader.SyncMode, p *eth.Peer) *chainSyncOp {
 }
 
 func (cs *chainSyncer) modeAndLocalHead() (downloader.SyncMode, *big.Int) {
+	if atomic.LoadUint32(&cs.handler.snapSync) == 1 {
+		return downloader.SnapSync, cs.handler.downloader.HeadEntropy()
+	}
 	return downloader.FullSync, cs.handler.downloader.HeadEntropy()
 }
 
@@ -279,15 +284,20 @@ func (cs *chainSyncer) startSync(op *chainSyncOp) {
 
 // doSync synchronizes the local blockchain with a remote peer.
 func (h *handler) doSync(op *chainSyncOp) error {
+	nodeCtx := h.core.NodeCtx()
 	// Run the sync cycle, and disable fast sync if we're past the pivot block
 	err := h.downloader.Synchronise(op.peer.ID(), op.head, op.entropy, op.mode)
 	if err != nil {
 		return err
 	}
+	if atomic.LoadUint32(&h.snapSync) == 1 && h.core.CurrentBlock().NumberU64(nodeCtx) > 0 {
+		log.Info("Snap sync complete, auto disabling")
+		atomic.StoreUint32(&h.snapSync, 0)
+	}
 	// If we've successfully finished a sync cycle and passed any required checkpoint,
 	// enable accepting transactions from the network.
 	head := h.core.CurrentBlock()
-	if head.NumberU64() > 0 {
+	if head.NumberU64(nodeCtx) > 0 {
 		// We've completed a sync cycle, notify all peers of new state. This path is
 		// essential in star-topology networks where a gateway node needs to notify
 		// all its out-of-date peers of the availability of a new block. This failure
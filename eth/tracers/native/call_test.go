@@ -0,0 +1,76 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/core/vm"
+	"github.com/dominant-strategies/go-quai/eth/tracers"
+)
+
+var (
+	testCaller   = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	testContract = common.HexToAddress("0x0000000000000000000000000000000000000002")
+	testEtxDest  = common.HexToAddress("0x0000000000000000000000000000000000000003")
+)
+
+func traceCalls(t *testing.T, ctx *tracers.Context) callFrame {
+	tracer, err := tracers.New("callTracer", ctx)
+	if err != nil {
+		t.Fatalf("failed to create call tracer: %v", err)
+	}
+	tracer.CaptureStart(nil, testCaller, testContract, false, []byte{0x01}, 100000, big.NewInt(1))
+	tracer.CaptureEnter(vm.CALL, testContract, testCaller, nil, 50000, big.NewInt(0))
+	tracer.CaptureExit([]byte{0x02}, 700, nil)
+	tracer.CaptureEnter(vm.ETX, testContract, testEtxDest, []byte{0x03}, 21000, big.NewInt(5))
+	tracer.CaptureExit(nil, 0, nil)
+	tracer.CaptureEnd(nil, 30000, 0, nil)
+
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var frame callFrame
+	if err := json.Unmarshal(res, &frame); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	return frame
+}
+
+func TestCallTracerEmittedEtx(t *testing.T) {
+	frame := traceCalls(t, &tracers.Context{TxType: types.InternalTxType})
+
+	if frame.Type != "CALL" {
+		t.Errorf("root frame type mismatch: have %s, want CALL", frame.Type)
+	}
+	if frame.GasUsed != "0x7530" {
+		t.Errorf("root gas used mismatch: have %s, want 0x7530", frame.GasUsed)
+	}
+	if len(frame.Calls) != 2 {
+		t.Fatalf("sub call count mismatch: have %d, want 2", len(frame.Calls))
+	}
+	if call := frame.Calls[0]; call.Type != "CALL" || call.Output != "0x02" {
+		t.Errorf("sub call mismatch: have %s with output %s", call.Type, call.Output)
+	}
+	etx := frame.Calls[1]
+	if etx.Type != "ETX" {
+		t.Errorf("emitted etx frame type mismatch: have %s, want ETX", etx.Type)
+	}
+	if etx.To != addrToHex(testEtxDest) || etx.Value != "0x5" || etx.Gas != "0x5208" || etx.Input != "0x03" {
+		t.Errorf("emitted etx frame mismatch: %+v", etx)
+	}
+}
+
+func TestCallTracerInboundEtx(t *testing.T) {
+	frame := traceCalls(t, &tracers.Context{TxType: types.ExternalTxType, ETXSender: testEtxDest})
+
+	if frame.Type != "EXTERNAL" {
+		t.Errorf("root frame type mismatch: have %s, want EXTERNAL", frame.Type)
+	}
+	if frame.EtxSender != addrToHex(testEtxDest) {
+		t.Errorf("etx sender mismatch: have %s, want %s", frame.EtxSender, addrToHex(testEtxDest))
+	}
+}
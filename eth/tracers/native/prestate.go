@@ -0,0 +1,199 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/core/vm"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/dominant-strategies/go-quai/eth/tracers"
+)
+
+func init() {
+	tracers.Register("prestateTracer", newPrestateTracer)
+}
+
+// prestate is keyed by internal address, as only the accounts of this chain
+// are part of its state. The destinations of emitted ETXs are never touched.
+type prestate = map[common.InternalAddress]*account
+
+type account struct {
+	Balance string                      `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    string                      `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+type prestateTracer struct {
+	env       *vm.EVM
+	ctx       *tracers.Context
+	prestate  prestate
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newPrestateTracer returns a native go tracer which collects the state of
+// every account touched by a tx as it was before the tx was executed.
+func newPrestateTracer(ctx *tracers.Context) (tracers.Tracer, error) {
+	return &prestateTracer{ctx: ctx, prestate: prestate{}}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+
+	t.lookupAccount(from)
+	t.lookupAccount(to)
+	t.lookupAccount(env.Context.Coinbase)
+
+	// By the time the execution starts, the gas has been bought and the nonce
+	// of the sender bumped. Unless the tx emits an ETX, the value has been
+	// transferred as well. Undo those to get the pre-tx state.
+	var gasLimit uint64
+	if t.ctx != nil {
+		gasLimit = t.ctx.GasLimit
+	}
+	transferred := env.TxType != types.InternalToExternalTxType
+	if internal, err := env.ChainConfig().InternalAddress(from); err == nil {
+		fromBal := hexutil.MustDecodeBig(t.prestate[internal].Balance)
+		fromBal.Add(fromBal, new(big.Int).Mul(env.TxContext.GasPrice, new(big.Int).SetUint64(gasLimit)))
+		if transferred {
+			fromBal.Add(fromBal, value)
+		}
+		t.prestate[internal].Balance = hexutil.EncodeBig(fromBal)
+		t.prestate[internal].Nonce--
+	}
+	if internal, err := env.ChainConfig().InternalAddress(to); err == nil && transferred {
+		toBal := hexutil.MustDecodeBig(t.prestate[internal].Balance)
+		toBal.Sub(toBal, value)
+		t.prestate[internal].Balance = hexutil.EncodeBig(toBal)
+	}
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	// Skip if tracing was interrupted
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.env.Cancel()
+		return
+	}
+	stack := scope.Stack
+	stackData := stack.Data()
+	stackLen := len(stackData)
+	switch {
+	case stackLen >= 1 && (op == vm.SLOAD || op == vm.SSTORE):
+		slot := common.Hash(stackData[stackLen-1].Bytes32())
+		t.lookupStorage(scope.Contract.Address(), slot)
+	case stackLen >= 1 && (op == vm.EXTCODECOPY || op == vm.EXTCODEHASH || op == vm.EXTCODESIZE || op == vm.BALANCE || op == vm.SELFDESTRUCT):
+		addr := common.Bytes20ToAddress(stackData[stackLen-1].Bytes20())
+		t.lookupAccount(addr)
+	case stackLen >= 5 && (op == vm.DELEGATECALL || op == vm.CALL || op == vm.STATICCALL || op == vm.CALLCODE):
+		addr := common.Bytes20ToAddress(stackData[stackLen-2].Bytes20())
+		t.lookupAccount(addr)
+	case op == vm.CREATE:
+		internal, err := env.ChainConfig().InternalAddress(scope.Contract.Address())
+		if err != nil {
+			return
+		}
+		nonce := t.env.StateDB.GetNonce(internal)
+		addr := crypto.CreateAddress(scope.Contract.Address(), nonce, scope.Contract.Code)
+		t.lookupAccount(addr)
+	case stackLen >= 4 && op == vm.CREATE2:
+		offset := stackData[stackLen-2]
+		size := stackData[stackLen-3]
+		init := scope.Memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+		inithash := crypto.Keccak256(init)
+		salt := stackData[stackLen-4]
+		addr := crypto.CreateAddress2(scope.Contract.Address(), salt.Bytes32(), inithash)
+		t.lookupAccount(addr)
+	}
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *prestateTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *prestateTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+// GetResult returns the json-encoded prestate of the touched accounts, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.prestate)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// lookupAccount fetches details of an account and adds it to the prestate
+// if it doesn't exist there. Addresses outside of this chain are skipped.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	internal, err := t.env.ChainConfig().InternalAddress(addr)
+	if err != nil {
+		return
+	}
+	if _, ok := t.prestate[internal]; ok {
+		return
+	}
+	t.prestate[internal] = &account{
+		Balance: bigToHex(t.env.StateDB.GetBalance(internal)),
+		Nonce:   t.env.StateDB.GetNonce(internal),
+		Code:    bytesToHex(t.env.StateDB.GetCode(internal)),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage fetches the requested storage slot and adds
+// it to the prestate of the given contract. It assumes `lookupAccount`
+// has been performed on the contract before.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	internal, err := t.env.ChainConfig().InternalAddress(addr)
+	if err != nil {
+		return
+	}
+	if _, ok := t.prestate[internal]; !ok {
+		t.lookupAccount(addr)
+	}
+	if _, ok := t.prestate[internal].Storage[key]; ok {
+		return
+	}
+	t.prestate[internal].Storage[key] = t.env.StateDB.GetState(internal, key)
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracers is a manager for transaction tracing engines.
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/vm"
+)
+
+// Context contains some contextual infos for a transaction execution that is not
+// available from within the EVM object.
+type Context struct {
+	BlockHash common.Hash    // Hash of the block the tx is contained within (zero if dangling tx or call)
+	TxIndex   int            // Index of the transaction within a block (zero if dangling tx or call)
+	TxHash    common.Hash    // Hash of the transaction being traced (zero if dangling call)
+	TxType    byte           // Type of the transaction being traced
+	GasLimit  uint64         // Gas bought by the transaction being traced
+	ETXSender common.Address // Sender in the origin chain, if the traced transaction is an inbound ETX
+}
+
+// Tracer interface extends vm.Tracer and additionally
+// allows collecting the tracing result.
+type Tracer interface {
+	vm.Tracer
+	GetResult() (json.RawMessage, error)
+	// Stop terminates execution of the tracer at the first opportune moment.
+	Stop(err error)
+}
+
+// ctorFn is the constructor of a named tracer.
+type ctorFn func(ctx *Context) (Tracer, error)
+
+var (
+	ctors   = make(map[string]ctorFn)
+	ctorsMu sync.RWMutex
+
+	errTracerNotFound = errors.New("tracer not found")
+)
+
+// Register makes a tracer available under the given name. Built-in tracers
+// register themselves on init, so importing their package is enough to make
+// them available to the tracing API.
+func Register(name string, ctor func(ctx *Context) (Tracer, error)) {
+	ctorsMu.Lock()
+	defer ctorsMu.Unlock()
+
+	ctors[name] = ctor
+}
+
+// New returns a new instance of the tracer registered under the given name.
+func New(name string, ctx *Context) (Tracer, error) {
+	ctorsMu.RLock()
+	ctor, ok := ctors[name]
+	ctorsMu.RUnlock()
+
+	if !ok {
+		return nil, errTracerNotFound
+	}
+	return ctor(ctx)
+}
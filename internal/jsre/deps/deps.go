@@ -0,0 +1,9 @@
+// Package deps contains the console JavaScript dependencies.
+package deps
+
+import _ "embed"
+
+// QuaiJS is the web3-style library the console is built on.
+//
+//go:embed quai.js
+var QuaiJS string
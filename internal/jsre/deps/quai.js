@@ -0,0 +1,350 @@
+// quai.js is the web3-style library of the go-quai console. It turns the
+// method and property definitions of the RPC modules into JS functions which
+// send JSON-RPC requests through a provider.
+//
+// A provider implements send(payload), returning the response of a request or
+// a batch of requests, and optionally sendAsync(payload, callback).
+(function (global) {
+  'use strict';
+
+  // utils contains the conversion helpers shared by the formatters.
+  var utils = {
+    isString: function (value) {
+      return typeof value === 'string' || (value && value.constructor && value.constructor.name === 'String');
+    },
+    isFunction: function (value) {
+      return typeof value === 'function';
+    },
+    isObject: function (value) {
+      return value !== null && !Array.isArray(value) && typeof value === 'object';
+    },
+    isArray: function (value) {
+      return Array.isArray(value);
+    },
+    isHex: function (value) {
+      return utils.isString(value) && /^0x[0-9a-f]*$/i.test(value);
+    },
+
+    // toDecimalString converts a hex quantity into its decimal string, keeping the
+    // full precision of values beyond the safe integer range.
+    toDecimalString: function (value) {
+      if (value === null || value === undefined) {
+        return value;
+      }
+      if (!utils.isHex(value)) {
+        return String(value);
+      }
+      var digits = [0];
+      var hex = value.slice(2);
+      for (var i = 0; i < hex.length; i++) {
+        var carry = parseInt(hex[i], 16);
+        for (var j = 0; j < digits.length; j++) {
+          var d = digits[j] * 16 + carry;
+          digits[j] = d % 10;
+          carry = Math.floor(d / 10);
+        }
+        while (carry > 0) {
+          digits.push(carry % 10);
+          carry = Math.floor(carry / 10);
+        }
+      }
+      return digits.reverse().join('');
+    },
+
+    // toDecimal converts a hex quantity into a number. Use toDecimalString for
+    // values which may exceed the safe integer range.
+    toDecimal: function (value) {
+      if (value === null || value === undefined) {
+        return value;
+      }
+      return utils.isHex(value) ? parseInt(value, 16) : Number(value);
+    },
+
+    // toHex converts a number or a decimal string into a hex quantity, hex
+    // strings are returned as is.
+    toHex: function (value) {
+      if (value === null || value === undefined || utils.isHex(value)) {
+        return value;
+      }
+      if (typeof value === 'boolean') {
+        return value ? '0x1' : '0x0';
+      }
+      var str = String(value);
+      if (!/^\d+$/.test(str)) {
+        throw new Error('invalid quantity: ' + value);
+      }
+      var digits = [0];
+      for (var i = 0; i < str.length; i++) {
+        var carry = parseInt(str[i], 10);
+        for (var j = 0; j < digits.length; j++) {
+          var d = digits[j] * 10 + carry;
+          digits[j] = d % 16;
+          carry = Math.floor(d / 16);
+        }
+        while (carry > 0) {
+          digits.push(carry % 16);
+          carry = Math.floor(carry / 16);
+        }
+      }
+      var hex = '';
+      for (var k = digits.length - 1; k >= 0; k--) {
+        hex += digits[k].toString(16);
+      }
+      return '0x' + hex;
+    },
+
+    // fromWei converts an amount of wei into the given unit as a decimal string.
+    fromWei: function (value, unit) {
+      var decimals = utils.unitDecimals(unit);
+      var str = utils.toDecimalString(utils.isHex(value) ? value : utils.toHex(value));
+      while (str.length <= decimals) {
+        str = '0' + str;
+      }
+      var whole = str.slice(0, str.length - decimals);
+      var fraction = str.slice(str.length - decimals).replace(/0+$/, '');
+      return fraction.length > 0 ? whole + '.' + fraction : whole;
+    },
+
+    // toWei converts an amount in the given unit into a decimal string of wei.
+    toWei: function (value, unit) {
+      var decimals = utils.unitDecimals(unit);
+      var parts = String(value).split('.');
+      if (parts.length > 2 || !/^\d*$/.test(parts[0]) || (parts.length === 2 && !/^\d*$/.test(parts[1]))) {
+        throw new Error('invalid amount: ' + value);
+      }
+      var fraction = parts.length === 2 ? parts[1] : '';
+      if (fraction.length > decimals) {
+        throw new Error('too many decimal places: ' + value);
+      }
+      while (fraction.length < decimals) {
+        fraction += '0';
+      }
+      var wei = (parts[0] + fraction).replace(/^0+/, '');
+      return wei.length > 0 ? wei : '0';
+    },
+
+    unitDecimals: function (unit) {
+      var units = { wei: 0, kwei: 3, mwei: 6, gwei: 9, szabo: 12, finney: 15, quai: 18, ether: 18 };
+      var decimals = units[(unit || 'quai').toLowerCase()];
+      if (decimals === undefined) {
+        throw new Error('unknown unit: ' + unit);
+      }
+      return decimals;
+    }
+  };
+
+  // formatters convert the parameters and results of the RPC methods.
+  var formatters = {
+    inputDefaultBlockNumberFormatter: function (block) {
+      if (block === undefined || block === null) {
+        return 'latest';
+      }
+      return formatters.inputBlockNumberFormatter(block);
+    },
+    inputBlockNumberFormatter: function (block) {
+      if (block === undefined || block === null) {
+        return undefined;
+      }
+      if (block === 'latest' || block === 'pending' || block === 'earliest') {
+        return block;
+      }
+      if (utils.isHex(block) && block.length === 66) {
+        return block; // block hash
+      }
+      return utils.toHex(block);
+    },
+    inputTransactionFormatter: function (tx) {
+      var result = {};
+      for (var key in tx) {
+        result[key] = tx[key];
+      }
+      ['gas', 'gasPrice', 'maxFeePerGas', 'maxPriorityFeePerGas', 'value', 'nonce',
+        'etxGasLimit', 'etxGasPrice', 'etxGasTip'].forEach(function (key) {
+        if (result[key] !== undefined) {
+          result[key] = utils.toHex(result[key]);
+        }
+      });
+      return result;
+    },
+    outputBigNumberFormatter: function (value) {
+      return utils.toDecimalString(value);
+    },
+    outputNumberFormatter: function (value) {
+      return utils.toDecimal(value);
+    }
+  };
+
+  // RequestManager assigns ids to requests and sends them through the provider.
+  function RequestManager(provider) {
+    this.provider = provider;
+    this.id = 1;
+  }
+
+  RequestManager.prototype.payload = function (method, params) {
+    return { jsonrpc: '2.0', id: this.id++, method: method, params: params || [] };
+  };
+
+  RequestManager.prototype.send = function (method, params) {
+    var response = this.provider.send(this.payload(method, params));
+    return RequestManager.result(response);
+  };
+
+  RequestManager.prototype.sendAsync = function (method, params, callback) {
+    if (!utils.isFunction(this.provider.sendAsync)) {
+      var result, err;
+      try {
+        result = this.send(method, params);
+      } catch (e) {
+        err = e;
+      }
+      return callback(err, result);
+    }
+    this.provider.sendAsync(this.payload(method, params), function (err, response) {
+      if (err) {
+        return callback(err);
+      }
+      var result;
+      try {
+        result = RequestManager.result(response);
+      } catch (e) {
+        return callback(e);
+      }
+      callback(null, result);
+    });
+  };
+
+  RequestManager.result = function (response) {
+    if (!response) {
+      throw new Error('invalid response: ' + response);
+    }
+    if (response.error) {
+      var err = new Error(response.error.message);
+      err.code = response.error.code;
+      if (response.error.data !== undefined) {
+        err.data = response.error.data;
+      }
+      throw err;
+    }
+    return response.result;
+  };
+
+  // Method describes an RPC method: its name in the library, the RPC method it
+  // calls, the number of parameters and their formatters.
+  function Method(options) {
+    this.name = options.name;
+    this.call = options.call;
+    this.params = options.params || 0;
+    this.inputFormatter = options.inputFormatter;
+    this.outputFormatter = options.outputFormatter;
+  }
+
+  Method.prototype.formatInput = function (args) {
+    if (!this.inputFormatter) {
+      return args;
+    }
+    var formatters = this.inputFormatter;
+    return formatters.map(function (formatter, i) {
+      return formatter ? formatter(args[i]) : args[i];
+    });
+  };
+
+  Method.prototype.formatOutput = function (result) {
+    return this.outputFormatter && result !== null && result !== undefined ? this.outputFormatter(result) : result;
+  };
+
+  Method.prototype.validateArgs = function (args) {
+    if (args.length > this.params) {
+      throw new Error('Invalid number of parameters for "' + this.name + '". Got ' + args.length + ' expected ' + this.params + '!');
+    }
+  };
+
+  Method.prototype.attachToObject = function (obj, manager) {
+    var method = this;
+    var fn = function () {
+      var args = Array.prototype.slice.call(arguments);
+      var callback = utils.isFunction(args[args.length - 1]) ? args.pop() : null;
+      method.validateArgs(args);
+
+      var params = method.formatInput(args);
+      while (params.length > 0 && params[params.length - 1] === undefined) {
+        params.pop();
+      }
+      if (callback) {
+        return manager.sendAsync(method.call, params, function (err, result) {
+          callback(err, err ? undefined : method.formatOutput(result));
+        });
+      }
+      return method.formatOutput(manager.send(method.call, params));
+    };
+    fn.call = this.call;
+    obj[this.name] = fn;
+  };
+
+  // Property describes a value retrieved by a getter RPC method without
+  // parameters. It is also exposed as an asynchronous get<Name> method.
+  function Property(options) {
+    this.name = options.name;
+    this.getter = options.getter;
+    this.outputFormatter = options.outputFormatter;
+  }
+
+  Property.prototype.attachToObject = function (obj, manager) {
+    var property = this;
+    var format = function (result) {
+      return property.outputFormatter && result !== null && result !== undefined ? property.outputFormatter(result) : result;
+    };
+    Object.defineProperty(obj, this.name, {
+      get: function () {
+        return format(manager.send(property.getter, []));
+      },
+      enumerable: true
+    });
+    var asyncName = 'get' + this.name.charAt(0).toUpperCase() + this.name.slice(1);
+    obj[asyncName] = function (callback) {
+      manager.sendAsync(property.getter, [], function (err, result) {
+        callback(err, err ? undefined : format(result));
+      });
+    };
+  };
+
+  // Quai is the library instance. Namespaces are attached to it by _extend.
+  function Quai(provider) {
+    var manager = new RequestManager(provider);
+    var self = this;
+
+    this._requestManager = manager;
+    this.utils = utils;
+    this.formatters = formatters;
+
+    this._extend = function (extension) {
+      var obj = self;
+      if (extension.property) {
+        self[extension.property] = self[extension.property] || {};
+        obj = self[extension.property];
+      }
+      (extension.methods || []).forEach(function (method) {
+        method.attachToObject(obj, manager);
+      });
+      (extension.properties || []).forEach(function (property) {
+        property.attachToObject(obj, manager);
+      });
+      return obj;
+    };
+    this._extend.Method = Method;
+    this._extend.Property = Property;
+    this._extend.formatters = formatters;
+    this._extend.utils = utils;
+  }
+
+  // send sends a raw JSON-RPC request, for methods the library does not define.
+  Quai.prototype.send = function (method, params) {
+    return this._requestManager.send(method, params);
+  };
+
+  Quai.prototype.fromWei = utils.fromWei;
+  Quai.prototype.toWei = utils.toWei;
+  Quai.prototype.toHex = utils.toHex;
+  Quai.prototype.toDecimal = utils.toDecimal;
+
+  global.Quai = Quai;
+})(this);
@@ -0,0 +1,108 @@
+package jsre
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+func newWithTestJS(t *testing.T, testjs string) *JSRE {
+	dir := t.TempDir()
+	if testjs != "" {
+		if err := ioutil.WriteFile(path.Join(dir, "test.js"), []byte(testjs), os.ModePerm); err != nil {
+			t.Fatal("cannot create test.js:", err)
+		}
+	}
+	return New(dir, os.Stdout)
+}
+
+func TestExec(t *testing.T) {
+	jsre := newWithTestJS(t, `msg = "testMsg"`)
+
+	err := jsre.Exec("test.js")
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	val, err := jsre.Run("msg")
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if val.ExportType().Kind() != reflect.String {
+		t.Errorf("expected string value, got %v", val)
+	}
+	exp := "testMsg"
+	got := val.ToString().String()
+	if exp != got {
+		t.Errorf("expected '%v', got '%v'", exp, got)
+	}
+	jsre.Stop(false)
+}
+
+func TestNatto(t *testing.T) {
+	jsre := newWithTestJS(t, `setTimeout(function(){msg = "testMsg"}, 1);`)
+
+	err := jsre.Exec("test.js")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	val, err := jsre.Run("msg")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if val.ExportType().Kind() != reflect.String {
+		t.Fatalf("expected string value, got %v", val)
+	}
+	exp := "testMsg"
+	got := val.ToString().String()
+	if exp != got {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+	jsre.Stop(false)
+}
+
+func TestBind(t *testing.T) {
+	jsre := New("", os.Stdout)
+	defer jsre.Stop(false)
+
+	jsre.Do(func(vm *goja.Runtime) {
+		vm.Set("no", MakeCallback(vm, func(call Call) (goja.Value, error) {
+			return call.VM.ToValue(call.Argument(0).ToInteger() * 2), nil
+		}))
+	})
+
+	val, err := jsre.Run(`no(21)`)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if got := val.ToInteger(); got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+}
+
+func TestLoadScript(t *testing.T) {
+	jsre := newWithTestJS(t, `msg = 42;`)
+	defer jsre.Stop(false)
+
+	_, err := jsre.Run(`loadScript("test.js")`)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	val, err := jsre.Run("msg")
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if val.ExportType().Kind() != reflect.Int64 {
+		t.Errorf("expected int value, got %v", val)
+	}
+	exp := int64(42)
+	got := val.ToInteger()
+	if exp != got {
+		t.Errorf("expected '%v', got '%v'", exp, got)
+	}
+}
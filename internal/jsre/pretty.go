@@ -0,0 +1,141 @@
+package jsre
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+const (
+	maxPrettyPrintLevel = 3
+	indentString        = "  "
+)
+
+// prettyPrint writes value to the given output.
+func prettyPrint(vm *goja.Runtime, value goja.Value, w io.Writer) {
+	ppctx{vm: vm, w: w}.printValue(value, 0, false)
+}
+
+// prettyError writes err to the given output.
+func prettyError(vm *goja.Runtime, err error, w io.Writer) {
+	failure := err.Error()
+	if gojaErr, ok := err.(*goja.Exception); ok {
+		failure = gojaErr.String()
+	}
+	fmt.Fprint(w, failure)
+}
+
+// prettyPrintJS pretty prints its arguments, it is exposed to JS as inspect.
+func (re *JSRE) prettyPrintJS(call goja.FunctionCall) goja.Value {
+	for _, v := range call.Arguments {
+		prettyPrint(re.vm, v, re.output)
+		fmt.Fprintln(re.output)
+	}
+	return goja.Undefined()
+}
+
+type ppctx struct {
+	vm *goja.Runtime
+	w  io.Writer
+}
+
+func (ctx ppctx) indent(level int) string {
+	return strings.Repeat(indentString, level)
+}
+
+func (ctx ppctx) printValue(v goja.Value, level int, inArray bool) {
+	if goja.IsNull(v) || goja.IsUndefined(v) {
+		fmt.Fprint(ctx.w, v.String())
+		return
+	}
+	if obj, ok := v.(*goja.Object); ok {
+		ctx.printObject(obj, level, inArray)
+		return
+	}
+	switch kind := v.ExportType().Kind(); {
+	case kind == reflect.String:
+		fmt.Fprint(ctx.w, strconv.Quote(v.String()))
+	default:
+		fmt.Fprint(ctx.w, v.String())
+	}
+}
+
+func (ctx ppctx) printObject(obj *goja.Object, level int, inArray bool) {
+	switch obj.ClassName() {
+	case "Array", "GoArray":
+		length := obj.Get("length").ToInteger()
+		if length == 0 {
+			fmt.Fprint(ctx.w, "[]")
+			return
+		}
+		if level > maxPrettyPrintLevel {
+			fmt.Fprint(ctx.w, "[...]")
+			return
+		}
+		fmt.Fprint(ctx.w, "[")
+		for i := int64(0); i < length; i++ {
+			if el := obj.Get(strconv.FormatInt(i, 10)); el != nil {
+				ctx.printValue(el, level+1, true)
+			}
+			if i < length-1 {
+				fmt.Fprint(ctx.w, ", ")
+			}
+		}
+		fmt.Fprint(ctx.w, "]")
+
+	case "Object":
+		keys := ctx.fields(obj)
+		if len(keys) == 0 {
+			fmt.Fprint(ctx.w, "{}")
+			return
+		}
+		if level > maxPrettyPrintLevel {
+			fmt.Fprint(ctx.w, "{...}")
+			return
+		}
+		fmt.Fprintln(ctx.w, "{")
+		for i, k := range keys {
+			fmt.Fprintf(ctx.w, "%s%s: ", ctx.indent(level+1), k)
+			ctx.printValue(obj.Get(k), level+1, false)
+			if i < len(keys)-1 {
+				fmt.Fprint(ctx.w, ",")
+			}
+			fmt.Fprintln(ctx.w)
+		}
+		if inArray {
+			level--
+		}
+		fmt.Fprintf(ctx.w, "%s}", ctx.indent(level))
+
+	case "Function":
+		fmt.Fprint(ctx.w, "function()")
+
+	default:
+		fmt.Fprint(ctx.w, obj.String())
+	}
+}
+
+// fields returns the printable keys of an object, including the ones inherited
+// from the prototypes of objects created by the console library. Private keys
+// starting with an underscore are skipped.
+func (ctx ppctx) fields(obj *goja.Object) []string {
+	var (
+		keys []string
+		seen = make(map[string]bool)
+		root = ctx.vm.Get("Object").ToObject(ctx.vm).Get("prototype")
+	)
+	for o := obj; o != nil && o != root; o = o.Prototype() {
+		for _, k := range o.Keys() {
+			if seen[k] || k == "constructor" || strings.HasPrefix(k, "_") {
+				continue
+			}
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
@@ -25,6 +25,8 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/dominant-strategies/go-quai/accounts"
+	"github.com/dominant-strategies/go-quai/accounts/keystore"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/common/hexutil"
 	"github.com/dominant-strategies/go-quai/common/math"
@@ -237,6 +239,226 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// PrivateAccountAPI provides an API to access accounts managed by this node.
+// It offers methods to create, (un)lock en list accounts. Some methods accept
+// passwords and are therefore considered private by default.
+type PrivateAccountAPI struct {
+	am        *accounts.Manager
+	nonceLock *AddrLocker
+	b         Backend
+}
+
+// NewPrivateAccountAPI create a new PrivateAccountAPI.
+func NewPrivateAccountAPI(b Backend, nonceLock *AddrLocker) *PrivateAccountAPI {
+	return &PrivateAccountAPI{
+		am:        b.AccountManager(),
+		nonceLock: nonceLock,
+		b:         b,
+	}
+}
+
+// ListAccounts will return a list of addresses for accounts this node manages.
+func (s *PrivateAccountAPI) ListAccounts() []common.Address {
+	return s.am.Accounts()
+}
+
+// rawWallet is a JSON representation of an accounts.Wallet interface, with its
+// data contents extracted into plain fields.
+type rawWallet struct {
+	URL      string             `json:"url"`
+	Status   string             `json:"status"`
+	Failure  string             `json:"failure,omitempty"`
+	Accounts []accounts.Account `json:"accounts,omitempty"`
+}
+
+// ListWallets will return a list of wallets this node manages.
+func (s *PrivateAccountAPI) ListWallets() []rawWallet {
+	wallets := make([]rawWallet, 0) // return [] instead of nil if empty
+	for _, wallet := range s.am.Wallets() {
+		status, failure := wallet.Status()
+
+		raw := rawWallet{
+			URL:      wallet.URL().String(),
+			Status:   status,
+			Accounts: wallet.Accounts(),
+		}
+		if failure != nil {
+			raw.Failure = failure.Error()
+		}
+		wallets = append(wallets, raw)
+	}
+	return wallets
+}
+
+// NewAccount will create a new account and returns the address for the new account.
+func (s *PrivateAccountAPI) NewAccount(password string) (common.Address, error) {
+	ks, err := fetchKeystore(s.am)
+	if err != nil {
+		return common.Address{}, err
+	}
+	acc, err := ks.NewAccount(password)
+	if err == nil {
+		log.Info("Your new key was generated", "address", acc.Address)
+		log.Warn("Please backup your key file!", "path", acc.URL.Path)
+		log.Warn("Please remember your password!")
+		return acc.Address, nil
+	}
+	return common.Address{}, err
+}
+
+// fetchKeystore retrieves the encrypted keystore from the account manager.
+func fetchKeystore(am *accounts.Manager) (*keystore.KeyStore, error) {
+	if ks := am.Backends(keystore.KeyStoreType); len(ks) > 0 {
+		return ks[0].(*keystore.KeyStore), nil
+	}
+	return nil, errors.New("local keystore not used")
+}
+
+// ImportRawKey stores the given hex encoded ECDSA key into the key directory,
+// encrypting it with the passphrase.
+func (s *PrivateAccountAPI) ImportRawKey(privkey string, password string) (common.Address, error) {
+	key, err := crypto.HexToECDSA(privkey)
+	if err != nil {
+		return common.Address{}, err
+	}
+	ks, err := fetchKeystore(s.am)
+	if err != nil {
+		return common.Address{}, err
+	}
+	acc, err := ks.ImportECDSA(key, password)
+	return acc.Address, err
+}
+
+// UnlockAccount will unlock the account associated with the given address with
+// the given password for duration seconds. If duration is nil it will use a
+// default of 300 seconds. It returns an indication if the account was unlocked.
+func (s *PrivateAccountAPI) UnlockAccount(ctx context.Context, addr common.Address, password string, duration *uint64) (bool, error) {
+	// When the API is exposed by external RPC(http, ws etc), unless the user
+	// explicitly specifies to allow the insecure account unlocking, otherwise
+	// it is disabled.
+	if s.b.ExtRPCEnabled() && !s.b.AccountManager().Config().InsecureUnlockAllowed {
+		return false, errors.New("account unlock with HTTP access is forbidden")
+	}
+
+	const max = uint64(time.Duration(math.MaxInt64) / time.Second)
+	var d time.Duration
+	if duration == nil {
+		d = 300 * time.Second
+	} else if *duration > max {
+		return false, errors.New("unlock duration too large")
+	} else {
+		d = time.Duration(*duration) * time.Second
+	}
+	ks, err := fetchKeystore(s.am)
+	if err != nil {
+		return false, err
+	}
+	err = ks.TimedUnlock(accounts.Account{Address: addr}, password, d)
+	if err != nil {
+		log.Warn("Failed account unlock attempt", "address", addr, "err", err)
+	}
+	return err == nil, err
+}
+
+// LockAccount will lock the account associated with the given address when it's unlocked.
+func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
+	if ks, err := fetchKeystore(s.am); err == nil {
+		return ks.Lock(addr) == nil
+	}
+	return false
+}
+
+// signTransaction sets defaults and signs the given transaction. The sender
+// must be a key held by this node whose address lies in this zone, otherwise
+// the transaction could never be included here.
+// NOTE: the caller needs to ensure that the nonceLock is held, if applicable,
+// and release it after the transaction has been submitted to the tx pool
+func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args *TransactionArgs, passwd string) (*types.Transaction, error) {
+	if s.b.ChainConfig().Location.Context() != common.ZONE_CTX {
+		return nil, errors.New("transactions can only be signed in a zone chain")
+	}
+	if args.From == nil {
+		return nil, errors.New("missing transaction sender")
+	}
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: *args.From}
+	if err := accounts.ValidateLocation(account, s.b.ChainConfig().Location, s.b.ChainConfig().GetTopology()); err != nil {
+		return nil, err
+	}
+	wallet, err := s.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	// Set some sanity defaults and terminate on failure
+	if err := args.setDefaults(ctx, s.b); err != nil {
+		return nil, err
+	}
+	// Assemble the transaction and sign with the wallet
+	tx := args.toTransaction(s.b.ChainConfig().Location, s.b.ChainConfig().GetTopology())
+
+	return wallet.SignTxWithPassphrase(account, passwd, tx, s.b.ChainConfig().ChainID)
+}
+
+// SendTransaction will create a transaction from the given arguments and
+// tries to sign it with the key associated with args.From. If the given
+// passwd isn't able to decrypt the key it fails. A recipient outside of this
+// zone results in an InternalToExternalTx carrying the given etx fields.
+func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args TransactionArgs, passwd string) (common.Hash, error) {
+	if args.Nonce == nil {
+		// Hold the addresse's mutex around signing to prevent concurrent assignment of
+		// the same nonce to multiple accounts.
+		s.nonceLock.LockAddr(args.from())
+		defer s.nonceLock.UnlockAddr(args.from())
+	}
+	signed, err := s.signTransaction(ctx, &args, passwd)
+	if err != nil {
+		log.Warn("Failed transaction send attempt", "from", args.from(), "to", args.To, "value", args.Value.ToInt(), "err", err)
+		return common.Hash{}, err
+	}
+	return SubmitTransaction(ctx, s.b, signed)
+}
+
+// SignTransaction will create a transaction from the given arguments and
+// tries to sign it with the key associated with args.From. If the given passwd isn't
+// able to decrypt the key it fails. The transaction is returned in RLP-form, not broadcast
+// to other nodes
+func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args TransactionArgs, passwd string) (*SignTransactionResult, error) {
+	// No need to obtain the noncelock mutex, since we won't be sending this
+	// tx into the transaction pool, but right back to the user
+	if args.From == nil {
+		return nil, errors.New("sender not specified")
+	}
+	if args.Gas == nil {
+		return nil, errors.New("gas not specified")
+	}
+	if args.GasPrice == nil && (args.MaxFeePerGas == nil || args.MaxPriorityFeePerGas == nil) {
+		return nil, errors.New("missing gasPrice or maxFeePerGas/maxPriorityFeePerGas")
+	}
+	if args.Nonce == nil {
+		return nil, errors.New("nonce not specified")
+	}
+	signed, err := s.signTransaction(ctx, &args, passwd)
+	if err != nil {
+		log.Warn("Failed transaction sign attempt", "from", args.from(), "to", args.To, "value", args.Value.ToInt(), "err", err)
+		return nil, err
+	}
+	// Ensure the transaction fee is reasonable before handing it out.
+	if err := checkTxFee(signed.GasPrice(), signed.Gas(), s.b.RPCTxFeeCap()); err != nil {
+		return nil, err
+	}
+	data, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{data, signed}, nil
+}
+
+// SignTransactionResult represents a RLP encoded signed transaction.
+type SignTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
 // PublicBlockChainAPI provides an API to access the Quai blockchain.
 // It offers only methods that operate on public data that is freely available to anyone.
 type PublicBlockChainAPI struct {
@@ -255,8 +477,9 @@ func (api *PublicBlockChainAPI) ChainId() (*hexutil.Big, error) {
 
 // BlockNumber returns the block number of the chain head.
 func (s *PublicBlockChainAPI) BlockNumber() hexutil.Uint64 {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	header, _ := s.b.HeaderByNumber(context.Background(), rpc.LatestBlockNumber) // latest header should always be available
-	return hexutil.Uint64(header.NumberU64())
+	return hexutil.Uint64(header.NumberU64(nodeCtx))
 }
 
 // GetBalance returns the amount of wei for the given address in the state of the
@@ -267,7 +490,7 @@ func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Add
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -293,7 +516,7 @@ type StorageResult struct {
 
 // GetProof returns the Merkle-proof for a given account and optionally some storage keys.
 func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*AccountResult, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getProof can only be called in zone chain")
 	}
@@ -301,7 +524,7 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -352,9 +575,10 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 // * When blockNr is -1 the chain head is returned.
 // * When blockNr is -2 the pending chain head is returned.
 func (s *PublicBlockChainAPI) GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	header, err := s.b.HeaderByNumber(ctx, number)
 	if header != nil && err == nil {
-		response := RPCMarshalETHHeader(header)
+		response := RPCMarshalETHHeader(header, nodeCtx)
 		if number == rpc.PendingBlockNumber {
 			// Pending header need to nil out a few fields
 			for _, field := range []string{"hash", "nonce", "miner"} {
@@ -368,9 +592,10 @@ func (s *PublicBlockChainAPI) GetHeaderByNumber(ctx context.Context, number rpc.
 
 // GetHeaderByHash returns the requested header by hash.
 func (s *PublicBlockChainAPI) GetHeaderByHash(ctx context.Context, hash common.Hash) map[string]interface{} {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	header, _ := s.b.HeaderByHash(ctx, hash)
 	if header != nil {
-		return RPCMarshalETHHeader(header)
+		return RPCMarshalETHHeader(header, nodeCtx)
 	}
 	return nil
 }
@@ -424,11 +649,12 @@ func (s *PublicBlockChainAPI) GetUncleByBlockNumberAndIndex(ctx context.Context,
 // GetUncleByBlockHashAndIndex returns the uncle block for the given block hash and index. When fullTx is true
 // all transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetUncleByBlockHashAndIndex(ctx context.Context, blockHash common.Hash, index hexutil.Uint) (map[string]interface{}, error) {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	block, err := s.b.BlockByHash(ctx, blockHash)
 	if block != nil {
 		uncles := block.Uncles()
 		if index >= hexutil.Uint(len(uncles)) {
-			log.Debug("Requested uncle not found", "number", block.Number(), "hash", blockHash, "index", index)
+			log.Debug("Requested uncle not found", "number", block.Number(nodeCtx), "hash", blockHash, "index", index)
 			return nil, nil
 		}
 		block = types.NewBlockWithHeader(uncles[index])
@@ -457,7 +683,7 @@ func (s *PublicBlockChainAPI) GetUncleCountByBlockHash(ctx context.Context, bloc
 
 // GetCode returns the code stored at the given address in the state for the given block number.
 func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getCode can only be called in zone chain")
 	}
@@ -465,7 +691,7 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -477,7 +703,7 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
 func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getStorageAt can only be called in zone chain")
 	}
@@ -485,7 +711,7 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -511,8 +737,8 @@ type OverrideAccount struct {
 type StateOverride map[common.AddressBytes]OverrideAccount
 
 // Apply overrides the fields of specified accounts into the given state.
-func (diff *StateOverride) Apply(state *state.StateDB) error {
-	nodeCtx := common.NodeLocation.Context()
+func (diff *StateOverride) Apply(state *state.StateDB, config *params.ChainConfig) error {
+	nodeCtx := config.Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return errors.New("stateOverride Apply can only be called in zone chain")
 	}
@@ -520,7 +746,7 @@ func (diff *StateOverride) Apply(state *state.StateDB) error {
 		return nil
 	}
 	for addr, account := range *diff {
-		internal, err := common.Bytes20ToAddress(addr).InternalAddress()
+		internal, err := config.InternalAddress(common.Bytes20ToAddress(addr))
 		if err != nil {
 			return err
 		}
@@ -555,7 +781,7 @@ func (diff *StateOverride) Apply(state *state.StateDB) error {
 
 func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("doCall can only be called in zone chain")
 	}
@@ -563,7 +789,7 @@ func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash
 	if state == nil || err != nil {
 		return nil, err
 	}
-	if err := overrides.Apply(state); err != nil {
+	if err := overrides.Apply(state, b.ChainConfig()); err != nil {
 		return nil, err
 	}
 	// Setup context so it may be cancelled the call has completed
@@ -579,7 +805,7 @@ func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash
 	defer cancel()
 
 	// Get a new instance of the EVM.
-	msg, err := args.ToMessage(globalGasCap, header.BaseFee())
+	msg, err := args.ToMessage(globalGasCap, header.BaseFee(), b.ChainConfig().Location, b.ChainConfig().GetTopology())
 	if err != nil {
 		return nil, err
 	}
@@ -647,7 +873,7 @@ func (e *revertError) ErrorData() interface{} {
 // Note, this function doesn't make and changes in the state/blockchain and is
 // useful to execute and retrieve values.
 func (s *PublicBlockChainAPI) Call(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride) (hexutil.Bytes, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("call can only called in zone chain")
 	}
@@ -663,7 +889,7 @@ func (s *PublicBlockChainAPI) Call(ctx context.Context, args TransactionArgs, bl
 }
 
 func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap uint64) (hexutil.Uint64, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return 0, errors.New("doEstimateGas can only be called in zone chain")
 	}
@@ -708,7 +934,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		if err != nil {
 			return 0, err
 		}
-		internal, err := args.From.InternalAddress()
+		internal, err := b.ChainConfig().InternalAddress(*args.From)
 		if err != nil {
 			return 0, err
 		}
@@ -793,7 +1019,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return 0, errors.New("estimateGas can only be called in zone chain")
 	}
@@ -866,9 +1092,9 @@ func FormatLogs(logs []vm.StructLog) []StructLogRes {
 }
 
 // RPCMarshalHeader converts the given header to the RPC output .
-func RPCMarshalETHHeader(head *types.Header) map[string]interface{} {
+func RPCMarshalETHHeader(head *types.Header, nodeCtx int) map[string]interface{} {
 	result := map[string]interface{}{
-		"number":           (*hexutil.Big)(head.Number()),
+		"number":           (*hexutil.Big)(head.Number(nodeCtx)),
 		"hash":             head.Hash(),
 		"parentHash":       head.ParentHash,
 		"nonce":            head.Nonce,
@@ -893,8 +1119,8 @@ func RPCMarshalETHHeader(head *types.Header) map[string]interface{} {
 // RPCMarshalBlock converts the given block to the RPC output which depends on fullTx. If inclTx is true transactions are
 // returned. When fullTx is true the returned block contains full transaction details, otherwise it will only contain
 // transaction hashes.
-func RPCMarshalETHBlock(block *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
-	fields := RPCMarshalETHHeader(block.Header())
+func RPCMarshalETHBlock(block *types.Block, inclTx bool, fullTx bool, nodeCtx int) (map[string]interface{}, error) {
+	fields := RPCMarshalETHHeader(block.Header(), nodeCtx)
 	fields["size"] = hexutil.Uint64(block.Size())
 
 	if inclTx {
@@ -903,7 +1129,7 @@ func RPCMarshalETHBlock(block *types.Block, inclTx bool, fullTx bool) (map[strin
 		}
 		if fullTx {
 			formatTx = func(tx *types.Transaction) (interface{}, error) {
-				return newRPCTransactionFromBlockHash(block, tx.Hash(), false), nil
+				return newRPCTransactionFromBlockHash(block, tx.Hash(), false, nodeCtx), nil
 			}
 		}
 		txs := block.Transactions()
@@ -929,7 +1155,8 @@ func RPCMarshalETHBlock(block *types.Block, inclTx bool, fullTx bool) (map[strin
 // rpcMarshalHeader uses the generalized output filler, then adds the total difficulty field, which requires
 // a `PublicBlockchainAPI`.
 func (s *PublicBlockChainAPI) rpcMarshalHeader(ctx context.Context, header *types.Header) map[string]interface{} {
-	fields := RPCMarshalETHHeader(header)
+	nodeCtx := s.b.ChainConfig().Location.Context()
+	fields := RPCMarshalETHHeader(header, nodeCtx)
 	fields["totalEntropy"] = (*hexutil.Big)(s.b.TotalLogS(header))
 	return fields
 }
@@ -937,7 +1164,7 @@ func (s *PublicBlockChainAPI) rpcMarshalHeader(ctx context.Context, header *type
 // rpcMarshalBlock uses the generalized output filler, then adds the total difficulty field, which requires
 // a `PublicBlockchainAPI`.
 func (s *PublicBlockChainAPI) rpcMarshalBlock(ctx context.Context, b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
-	fields, err := RPCMarshalBlock(b, inclTx, fullTx)
+	fields, err := RPCMarshalBlock(b, inclTx, fullTx, s.b.ChainConfig().Location.Context())
 	if err != nil {
 		return nil, err
 	}
@@ -977,8 +1204,7 @@ type RPCTransaction struct {
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
 // representation, with the given location metadata set (if available).
-func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
-	nodeCtx := common.NodeLocation.Context()
+func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int, nodeCtx int) *RPCTransaction {
 	if nodeCtx != common.ZONE_CTX {
 		return nil
 	}
@@ -1064,11 +1290,11 @@ func newRPCPendingTransaction(tx *types.Transaction, current *types.Header, conf
 	if current != nil {
 		baseFee = misc.CalcBaseFee(config, current)
 	}
-	return newRPCTransaction(tx, common.Hash{}, 0, 0, baseFee)
+	return newRPCTransaction(tx, common.Hash{}, 0, 0, baseFee, config.Location.Context())
 }
 
 // newRPCTransactionFromBlockIndex returns a transaction that will serialize to the RPC representation.
-func newRPCTransactionFromBlockIndex(b *types.Block, index uint64, etxs bool) *RPCTransaction {
+func newRPCTransactionFromBlockIndex(b *types.Block, index uint64, etxs bool, nodeCtx int) *RPCTransaction {
 	var txs types.Transactions
 	if etxs {
 		txs = b.ExtTransactions()
@@ -1078,7 +1304,7 @@ func newRPCTransactionFromBlockIndex(b *types.Block, index uint64, etxs bool) *R
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(), index, b.BaseFee())
+	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(nodeCtx), index, b.BaseFee(), nodeCtx)
 }
 
 // newRPCRawTransactionFromBlockIndex returns the bytes of a transaction given a block and a transaction index.
@@ -1092,17 +1318,17 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 }
 
 // newRPCTransactionFromBlockHash returns a transaction that will serialize to the RPC representation.
-func newRPCTransactionFromBlockHash(b *types.Block, hash common.Hash, etxs bool) *RPCTransaction {
+func newRPCTransactionFromBlockHash(b *types.Block, hash common.Hash, etxs bool, nodeCtx int) *RPCTransaction {
 	if etxs {
 		for idx, tx := range b.ExtTransactions() {
 			if tx.Hash() == hash {
-				return newRPCTransactionFromBlockIndex(b, uint64(idx), true)
+				return newRPCTransactionFromBlockIndex(b, uint64(idx), true, nodeCtx)
 			}
 		}
 	}
 	for idx, tx := range b.Transactions() {
 		if tx.Hash() == hash {
-			return newRPCTransactionFromBlockIndex(b, uint64(idx), false)
+			return newRPCTransactionFromBlockIndex(b, uint64(idx), false, nodeCtx)
 		}
 	}
 	return nil
@@ -1120,7 +1346,7 @@ type accessListResult struct {
 // CreateAccessList creates an AccessList for the given transaction.
 // Reexec and BlockNrOrHash can be specified to create the accessList on top of a certain state.
 func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*accessListResult, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("createAccessList can only be called in zone chain")
 	}
@@ -1143,7 +1369,7 @@ func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args Transac
 // If the accesslist creation fails an error is returned.
 // If the transaction itself fails, an vmErr is returned.
 func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, args TransactionArgs) (acl types.AccessList, gasUsed uint64, vmErr error, err error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, 0, nil, errors.New("AccessList can only be called in zone chain")
 	}
@@ -1167,7 +1393,7 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 		to = crypto.CreateAddress(args.from(), uint64(*args.Nonce), *args.Data)
 	}
 	// Retrieve the precompiles since they don't need to be added to the access list
-	precompiles := vm.ActivePrecompiles(b.ChainConfig().Rules(header.Number()))
+	precompiles := vm.ActivePrecompiles(b.ChainConfig().Rules(header.Number(nodeCtx), header.Number(common.PRIME_CTX)))
 
 	// Create an initial tracer
 	prevTracer := vm.NewAccessListTracer(nil, args.from(), to, precompiles)
@@ -1192,7 +1418,7 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 		statedb := db.Copy()
 		// Set the accesslist to the last al
 		args.AccessList = &accessList
-		msg, err := args.ToMessage(b.RPCGasCap(), header.BaseFee())
+		msg, err := args.ToMessage(b.RPCGasCap(), header.BaseFee(), b.ChainConfig().Location, b.ChainConfig().GetTopology())
 		if err != nil {
 			return nil, 0, nil, err
 		}
@@ -1250,16 +1476,18 @@ func (s *PublicTransactionPoolAPI) GetBlockTransactionCountByHash(ctx context.Co
 
 // GetTransactionByBlockNumberAndIndex returns the transaction for the given block number and index.
 func (s *PublicTransactionPoolAPI) GetTransactionByBlockNumberAndIndex(ctx context.Context, blockNr rpc.BlockNumber, index hexutil.Uint) *RPCTransaction {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if block, _ := s.b.BlockByNumber(ctx, blockNr); block != nil {
-		return newRPCTransactionFromBlockIndex(block, uint64(index), false)
+		return newRPCTransactionFromBlockIndex(block, uint64(index), false, nodeCtx)
 	}
 	return nil
 }
 
 // GetTransactionByBlockHashAndIndex returns the transaction for the given block hash and index.
 func (s *PublicTransactionPoolAPI) GetTransactionByBlockHashAndIndex(ctx context.Context, blockHash common.Hash, index hexutil.Uint) *RPCTransaction {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if block, _ := s.b.BlockByHash(ctx, blockHash); block != nil {
-		return newRPCTransactionFromBlockIndex(block, uint64(index), false)
+		return newRPCTransactionFromBlockIndex(block, uint64(index), false, nodeCtx)
 	}
 	return nil
 }
@@ -1295,7 +1523,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -1315,7 +1543,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 		if err != nil {
 			return nil, err
 		}
-		return newRPCTransaction(tx, blockHash, blockNumber, index, header.BaseFee()), nil
+		return newRPCTransaction(tx, blockHash, blockNumber, index, header.BaseFee(), s.b.ChainConfig().Location.Context()), nil
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
@@ -1358,9 +1586,13 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	}
 	receipt := receipts[index]
 
+	header, err := s.b.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
 	// Derive the sender.
 	bigblock := new(big.Int).SetUint64(blockNumber)
-	signer := types.MakeSigner(s.b.ChainConfig(), bigblock)
+	signer := types.MakeSigner(s.b.ChainConfig(), bigblock, header.Number(common.PRIME_CTX))
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
@@ -1378,10 +1610,6 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"type":              hexutil.Uint(tx.Type()),
 	}
 	// Assign the effective gas price paid
-	header, err := s.b.HeaderByHash(ctx, blockHash)
-	if err != nil {
-		return nil, err
-	}
 	gasPrice := new(big.Int).Add(header.BaseFee(), tx.EffectiveGasTipValue(header.BaseFee()))
 	fields["effectiveGasPrice"] = hexutil.Uint64(gasPrice.Uint64())
 
@@ -1403,7 +1631,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
 func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return common.Hash{}, errors.New("submitTransaction can only be called in zone chain")
 	}
@@ -1416,7 +1644,8 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		return common.Hash{}, err
 	}
 	// Print a log with full tx details for manual investigations and interventions
-	signer := types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number())
+	head := b.CurrentBlock()
+	signer := types.MakeSigner(b.ChainConfig(), head.Number(nodeCtx), head.Number(common.PRIME_CTX))
 	from, err := types.Sender(signer, tx)
 	if err != nil {
 		return common.Hash{}, err
@@ -1441,6 +1670,109 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, input
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// BundleArgs represents the arguments to submit or simulate a bundle, an
+// ordered list of signed transactions which is included all or nothing.
+type BundleArgs struct {
+	Txs          []hexutil.Bytes `json:"txs"`
+	BlockNumber  *hexutil.Big    `json:"blockNumber"`
+	MinTimestamp *hexutil.Uint64 `json:"minTimestamp"`
+	MaxTimestamp *hexutil.Uint64 `json:"maxTimestamp"`
+}
+
+// toBundle decodes the transactions of the bundle, checking their fees like
+// for the transactions sent to the pool.
+func (args *BundleArgs) toBundle(b Backend) (*core.Bundle, error) {
+	bundle := new(core.Bundle)
+	for i, input := range args.Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(input); err != nil {
+			return nil, fmt.Errorf("bundle transaction %d: %v", i, err)
+		}
+		if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
+			return nil, fmt.Errorf("bundle transaction %d: %v", i, err)
+		}
+		bundle.Txs = append(bundle.Txs, tx)
+	}
+	if args.BlockNumber != nil {
+		bundle.BlockNumber = (*big.Int)(args.BlockNumber)
+	}
+	if args.MinTimestamp != nil {
+		bundle.MinTimestamp = uint64(*args.MinTimestamp)
+	}
+	if args.MaxTimestamp != nil {
+		bundle.MaxTimestamp = uint64(*args.MaxTimestamp)
+	}
+	return bundle, nil
+}
+
+// SendBundle queues a bundle for inclusion at the top of the zone block with
+// the given number, which has to be within a few blocks of the head. Either all
+// of its transactions are included, in order, or none of them is. It returns
+// the hash identifying the bundle.
+func (s *PublicTransactionPoolAPI) SendBundle(ctx context.Context, args BundleArgs) (common.Hash, error) {
+	bundle, err := args.toBundle(s.b)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := s.b.SendBundle(ctx, bundle); err != nil {
+		return common.Hash{}, err
+	}
+	log.Debug("Submitted bundle", "hash", bundle.Hash(), "txs", len(bundle.Txs), "number", bundle.BlockNumber)
+	return bundle.Hash(), nil
+}
+
+// CallBundle simulates a bundle at the top of the next block against the
+// pending state. The results hold the receipts of the executed transactions,
+// up to and including the one which failed, if any. The target block and the
+// timestamps of the bundle are not checked.
+func (s *PublicTransactionPoolAPI) CallBundle(ctx context.Context, args BundleArgs) (map[string]interface{}, error) {
+	bundle, err := args.toBundle(s.b)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.b.CallBundle(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.MakeSigner(s.b.ChainConfig(), result.BlockNumber, result.PrimeNumber)
+
+	var (
+		gasUsed uint64
+		results = make([]map[string]interface{}, 0, len(result.Receipts))
+	)
+	for i, receipt := range result.Receipts {
+		tx := bundle.Txs[i]
+		from, _ := types.Sender(signer, tx)
+		fields := map[string]interface{}{
+			"transactionHash": tx.Hash(),
+			"from":            from,
+			"to":              tx.To(),
+			"gasUsed":         hexutil.Uint64(receipt.GasUsed),
+			"status":          hexutil.Uint(receipt.Status),
+			"logs":            receipt.Logs,
+			"etxs":            receipt.Etxs,
+		}
+		if receipt.Logs == nil {
+			fields["logs"] = []*types.Log{}
+		}
+		if !receipt.ContractAddress.Equal(common.ZeroAddr) {
+			fields["contractAddress"] = receipt.ContractAddress
+		}
+		gasUsed += receipt.GasUsed
+		results = append(results, fields)
+	}
+	fields := map[string]interface{}{
+		"bundleHash":  bundle.Hash(),
+		"blockNumber": (*hexutil.Big)(result.BlockNumber),
+		"gasUsed":     hexutil.Uint64(gasUsed),
+		"results":     results,
+	}
+	if result.Err != nil {
+		fields["error"] = result.Err.Error()
+	}
+	return fields, nil
+}
+
 // PublicDebugAPI is the collection of Quai APIs exposed over the public
 // debugging endpoint.
 type PublicDebugAPI struct {
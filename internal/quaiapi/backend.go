@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	quai "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/accounts"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/consensus"
 	"github.com/dominant-strategies/go-quai/core"
@@ -48,6 +49,7 @@ type Backend interface {
 	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
 	FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error)
 	ChainDb() ethdb.Database
+	AccountManager() *accounts.Manager
 	ExtRPCEnabled() bool
 	RPCGasCap() uint64    // global gas cap for eth_call over rpc: DoS protection
 	RPCTxFeeCap() float64 // global tx fee cap for all transaction related APIs
@@ -80,14 +82,22 @@ type Backend interface {
 	NewGenesisPendingHeader(pendingHeader *types.Header)
 	GetPendingHeader() (*types.Header, error)
 	GetManifest(blockHash common.Hash) (types.BlockManifest, error)
+	GetTerminiByHash(hash common.Hash) []common.Hash
 	GetSubManifest(slice common.Location, blockHash common.Hash) (types.BlockManifest, error)
 	AddPendingEtxs(pEtxs types.PendingEtxs) error
 	AddPendingEtxsRollup(pEtxsRollup types.PendingEtxsRollup) error
 	PendingBlockAndReceipts() (*types.Block, types.Receipts)
 	GenerateRecoveryPendingHeader(pendingHeader *types.Header, checkpointHashes []common.Hash) error
+	UpdateBlockLists(update types.BlockListUpdate, propagate bool) error
+	TransportHealth() core.TransportHealth
+	EstimateExternalGas(ctx context.Context, msg quai.CallMsg) (uint64, error)
+	GetEtxStatus(hash common.Hash) *types.EtxStatus
+	ConfirmationStatus(header *types.Header) (*core.ConfirmationStatus, error)
 
 	// Transaction pool API
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
+	SendBundle(ctx context.Context, bundle *core.Bundle) error
+	CallBundle(ctx context.Context, bundle *core.Bundle) (*core.BundleResult, error)
 	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
 	GetPoolTransactions() (types.Transactions, error)
 	GetPoolTransaction(txHash common.Hash) *types.Transaction
@@ -111,7 +121,7 @@ type Backend interface {
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := apiBackend.ChainConfig().Location.Context()
 	nonceLock := new(AddrLocker)
 	apis := []rpc.API{
 		{
@@ -134,6 +144,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicBlockChainQuaiAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace:     "quai",
+			Version:       "1.0",
+			Service:       NewPrivateCoordinationAPI(apiBackend),
+			Authenticated: true,
 		}, {
 			Namespace: "debug",
 			Version:   "1.0",
@@ -143,6 +158,10 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Namespace: "debug",
 			Version:   "1.0",
 			Service:   NewPrivateDebugAPI(apiBackend),
+		}, {
+			Namespace: "personal",
+			Version:   "1.0",
+			Service:   NewPrivateAccountAPI(apiBackend, nonceLock),
 		},
 	}
 	if nodeCtx == common.ZONE_CTX {
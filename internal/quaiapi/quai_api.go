@@ -20,14 +20,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
+	quai "github.com/dominant-strategies/go-quai"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/common/hexutil"
 	"github.com/dominant-strategies/go-quai/core"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/crypto"
 	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/params"
 	"github.com/dominant-strategies/go-quai/rpc"
 	"github.com/dominant-strategies/go-quai/trie"
 )
@@ -133,13 +136,62 @@ func (api *PublicBlockChainQuaiAPI) ChainId() (*hexutil.Big, error) {
 
 // NodeLocation is the access call to the location of the node.
 func (api *PublicBlockChainQuaiAPI) NodeLocation() []hexutil.Uint64 {
-	return common.NodeLocation.RPCMarshal()
+	return api.b.ChainConfig().Location.RPCMarshal()
+}
+
+// Topology returns the shape of the hierarchy the node is part of, with the
+// address space of every zone.
+func (api *PublicBlockChainQuaiAPI) Topology() map[string]interface{} {
+	topology := api.b.ChainConfig().GetTopology()
+	spaces := make([][]common.AddressSpace, topology.Regions)
+	for r := range spaces {
+		spaces[r] = make([]common.AddressSpace, topology.Zones)
+		for z := range spaces[r] {
+			spaces[r][z], _ = topology.ZoneAddressSpace(common.Location{byte(r), byte(z)})
+		}
+	}
+	return map[string]interface{}{
+		"regions":       topology.Regions,
+		"zones":         topology.Zones,
+		"addressSpaces": spaces,
+	}
+}
+
+// GetForkSchedule returns the protocol upgrades known to the node in the order
+// they activate, with their schedule and whether they are active at the head.
+func (api *PublicBlockChainQuaiAPI) GetForkSchedule() []map[string]interface{} {
+	var (
+		config   = api.b.ChainConfig()
+		head     = api.b.CurrentHeader()
+		schedule = make([]map[string]interface{}, 0, len(params.Forks))
+	)
+	for _, fork := range params.Forks {
+		activation := config.Forks[fork]
+		entry := map[string]interface{}{
+			"name":      fork,
+			"scheduled": activation != nil,
+			"active":    config.IsActive(fork, head.Number(config.Location.Context()), head.Number(common.PRIME_CTX)),
+		}
+		if activation != nil {
+			if activation.PrimeBlock != nil {
+				entry["primeBlock"] = (*hexutil.Big)(activation.PrimeBlock)
+			}
+			for ctx := common.PRIME_CTX; ctx < common.HierarchyDepth; ctx++ {
+				if number := activation.Number(ctx); number != nil {
+					entry[strings.ToLower(common.OrderToString(ctx))] = (*hexutil.Big)(number)
+				}
+			}
+		}
+		schedule = append(schedule, entry)
+	}
+	return schedule
 }
 
 // BlockNumber returns the block number of the chain head.
 func (s *PublicBlockChainQuaiAPI) BlockNumber() hexutil.Uint64 {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	header, _ := s.b.HeaderByNumber(context.Background(), rpc.LatestBlockNumber) // latest header should always be available
-	return hexutil.Uint64(header.NumberU64())
+	return hexutil.Uint64(header.NumberU64(nodeCtx))
 
 }
 
@@ -147,7 +199,7 @@ func (s *PublicBlockChainQuaiAPI) BlockNumber() hexutil.Uint64 {
 // given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
 // block numbers are also allowed.
 func (s *PublicBlockChainQuaiAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getBalance call can only be made in zone chain")
 	}
@@ -155,7 +207,7 @@ func (s *PublicBlockChainQuaiAPI) GetBalance(ctx context.Context, address common
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -164,7 +216,7 @@ func (s *PublicBlockChainQuaiAPI) GetBalance(ctx context.Context, address common
 
 // GetProof returns the Merkle-proof for a given account and optionally some storage keys.
 func (s *PublicBlockChainQuaiAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*AccountResult, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getProof call can only be made in zone chain")
 	}
@@ -172,7 +224,7 @@ func (s *PublicBlockChainQuaiAPI) GetProof(ctx context.Context, address common.A
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -305,11 +357,12 @@ func (s *PublicBlockChainQuaiAPI) GetUncleByBlockNumberAndIndex(ctx context.Cont
 // GetUncleByBlockHashAndIndex returns the uncle block for the given block hash and index. When fullTx is true
 // all transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainQuaiAPI) GetUncleByBlockHashAndIndex(ctx context.Context, blockHash common.Hash, index hexutil.Uint) (map[string]interface{}, error) {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	block, err := s.b.BlockByHash(ctx, blockHash)
 	if block != nil {
 		uncles := block.Uncles()
 		if index >= hexutil.Uint(len(uncles)) {
-			log.Debug("Requested uncle not found", "number", block.Number(), "hash", blockHash, "index", index)
+			log.Debug("Requested uncle not found", "number", block.Number(nodeCtx), "hash", blockHash, "index", index)
 			return nil, nil
 		}
 		block = types.NewBlockWithHeader(uncles[index])
@@ -319,7 +372,7 @@ func (s *PublicBlockChainQuaiAPI) GetUncleByBlockHashAndIndex(ctx context.Contex
 	if pendBlock != nil && pendBlock.Hash() == blockHash {
 		uncles := pendBlock.Uncles()
 		if index >= hexutil.Uint(len(uncles)) {
-			log.Debug("Requested uncle not found in pending block", "number", block.Number(), "hash", blockHash, "index", index)
+			log.Debug("Requested uncle not found in pending block", "number", block.Number(nodeCtx), "hash", blockHash, "index", index)
 			return nil, nil
 		}
 		block = types.NewBlockWithHeader(uncles[index])
@@ -348,7 +401,7 @@ func (s *PublicBlockChainQuaiAPI) GetUncleCountByBlockHash(ctx context.Context,
 
 // GetCode returns the code stored at the given address in the state for the given block number.
 func (s *PublicBlockChainQuaiAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getCode can only called in a zone chain")
 	}
@@ -356,7 +409,7 @@ func (s *PublicBlockChainQuaiAPI) GetCode(ctx context.Context, address common.Ad
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -368,7 +421,7 @@ func (s *PublicBlockChainQuaiAPI) GetCode(ctx context.Context, address common.Ad
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
 func (s *PublicBlockChainQuaiAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getStorageAt can only called in a zone chain")
 	}
@@ -376,7 +429,7 @@ func (s *PublicBlockChainQuaiAPI) GetStorageAt(ctx context.Context, address comm
 	if state == nil || err != nil {
 		return nil, err
 	}
-	internal, err := address.InternalAddress()
+	internal, err := s.b.ChainConfig().InternalAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -402,24 +455,64 @@ func (s *PublicBlockChainQuaiAPI) Call(ctx context.Context, args TransactionArgs
 	return result.Return(), result.Err
 }
 
+// EstimateGasResult is the result of quai_estimateEtxGas. For a transaction to
+// another zone it also carries the gas limit the emitted ETX needs in its
+// destination.
+type EstimateGasResult struct {
+	Gas         hexutil.Uint64  `json:"gas"`
+	ETXGasLimit *hexutil.Uint64 `json:"etxGasLimit,omitempty"`
+}
+
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
 func (s *PublicBlockChainQuaiAPI) EstimateGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
-	nodeCtx := common.NodeLocation.Context()
+	result, err := s.estimateGas(ctx, args, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.Gas, nil
+}
+
+// EstimateEtxGas is like EstimateGas, but also returns the gas limit the ETX
+// needs if the recipient is in another zone. The ETX is simulated against the
+// state of the destination zone, reached through the dom and sub chains, unless
+// its gas limit is given.
+func (s *PublicBlockChainQuaiAPI) EstimateEtxGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*EstimateGasResult, error) {
+	return s.estimateGas(ctx, args, blockNrOrHash)
+}
+
+func (s *PublicBlockChainQuaiAPI) estimateGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*EstimateGasResult, error) {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
-		return 0, errors.New("estimateGas can only called in a zone chain")
+		return nil, errors.New("estimateGas can only called in a zone chain")
 	}
 	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
 	if blockNrOrHash != nil {
 		bNrOrHash = *blockNrOrHash
 	}
-	return DoEstimateGas(ctx, s.b, args, bNrOrHash, s.b.RPCGasCap())
+	result := new(EstimateGasResult)
+	if args.isETX(s.b.ChainConfig().Location, s.b.ChainConfig().GetTopology()) {
+		if args.ETXGasLimit == nil {
+			etxGas, err := s.b.EstimateExternalGas(ctx, args.etxCallMsg())
+			if err != nil {
+				return nil, err
+			}
+			args.ETXGasLimit = (*hexutil.Uint64)(&etxGas)
+		}
+		result.ETXGasLimit = args.ETXGasLimit
+	}
+	gas, err := DoEstimateGas(ctx, s.b, args, bNrOrHash, s.b.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	result.Gas = gas
+	return result, nil
 }
 
 // RPCMarshalBlock converts the given block to the RPC output which depends on fullTx. If inclTx is true transactions are
 // returned. When fullTx is true the returned block contains full transaction details, otherwise it will only contain
 // transaction hashes.
-func RPCMarshalBlock(block *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
+func RPCMarshalBlock(block *types.Block, inclTx bool, fullTx bool, nodeCtx int) (map[string]interface{}, error) {
 	fields := block.Header().RPCMarshalHeader()
 	fields["size"] = hexutil.Uint64(block.Size())
 
@@ -430,10 +523,10 @@ func RPCMarshalBlock(block *types.Block, inclTx bool, fullTx bool) (map[string]i
 		formatEtx := formatTx
 		if fullTx {
 			formatTx = func(tx *types.Transaction) (interface{}, error) {
-				return newRPCTransactionFromBlockHash(block, tx.Hash(), false), nil
+				return newRPCTransactionFromBlockHash(block, tx.Hash(), false, nodeCtx), nil
 			}
 			formatEtx = func(tx *types.Transaction) (interface{}, error) {
-				return newRPCTransactionFromBlockHash(block, tx.Hash(), true), nil
+				return newRPCTransactionFromBlockHash(block, tx.Hash(), true, nodeCtx), nil
 			}
 		}
 		txs := block.Transactions()
@@ -497,7 +590,7 @@ func (s *PublicBlockChainQuaiAPI) rpcMarshalHeader(ctx context.Context, header *
 // rpcMarshalBlock uses the generalized output filler, then adds the total difficulty field, which requires
 // a `PublicBlockchainAPI`.
 func (s *PublicBlockChainQuaiAPI) rpcMarshalBlock(ctx context.Context, b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
-	fields, err := RPCMarshalBlock(b, inclTx, fullTx)
+	fields, err := RPCMarshalBlock(b, inclTx, fullTx, s.b.ChainConfig().Location.Context())
 	if err != nil {
 		return nil, err
 	}
@@ -513,7 +606,7 @@ func (s *PublicBlockChainQuaiAPI) rpcMarshalBlock(ctx context.Context, b *types.
 // CreateAccessList creates an AccessList for the given transaction.
 // Reexec and BlockNrOrHash can be specified to create the accessList on top of a certain state.
 func (s *PublicBlockChainQuaiAPI) CreateAccessList(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*accessListResult, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("createAccessList can only be called in zone chain")
 	}
@@ -532,8 +625,20 @@ func (s *PublicBlockChainQuaiAPI) CreateAccessList(ctx context.Context, args Tra
 	return result, nil
 }
 
-func (s *PublicBlockChainQuaiAPI) fillSubordinateManifest(b *types.Block) (*types.Block, error) {
-	nodeCtx := common.NodeLocation.Context()
+// PrivateCoordinationAPI provides the methods through which the chains of the
+// hierarchy coordinate with their dom and sub chains. They mutate the chain, so
+// they are only served by the authenticated endpoint.
+type PrivateCoordinationAPI struct {
+	b Backend
+}
+
+// NewPrivateCoordinationAPI creates a new coordination API.
+func NewPrivateCoordinationAPI(b Backend) *PrivateCoordinationAPI {
+	return &PrivateCoordinationAPI{b}
+}
+
+func (s *PrivateCoordinationAPI) fillSubordinateManifest(b *types.Block) (*types.Block, error) {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if b.ManifestHash(nodeCtx+1) == types.EmptyRootHash {
 		return nil, errors.New("cannot fill empty subordinate manifest")
 	} else if subManifestHash := types.DeriveSha(b.SubManifest(), trie.NewStackTrie(nil)); subManifestHash == b.ManifestHash(nodeCtx+1) {
@@ -566,13 +671,24 @@ func (s *PublicBlockChainQuaiAPI) fillSubordinateManifest(b *types.Block) (*type
 }
 
 // ReceiveMinedHeader will run checks on the block and add to canonical chain if valid.
-func (s *PublicBlockChainQuaiAPI) ReceiveMinedHeader(ctx context.Context, raw json.RawMessage) error {
-	nodeCtx := common.NodeLocation.Context()
+func (s *PrivateCoordinationAPI) ReceiveMinedHeader(ctx context.Context, raw json.RawMessage) error {
 	// Decode header and transactions.
 	var header *types.Header
 	if err := json.Unmarshal(raw, &header); err != nil {
 		return err
 	}
+	return s.receiveMinedHeader(header)
+}
+
+// ReceiveMinedHeader constructs the block of a header mined on top of the
+// pending header of the node, writes it and announces it to the network, in
+// the same way the coordination API does for external miners.
+func ReceiveMinedHeader(b Backend, header *types.Header) error {
+	return NewPrivateCoordinationAPI(b).receiveMinedHeader(header)
+}
+
+func (s *PrivateCoordinationAPI) receiveMinedHeader(header *types.Header) error {
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	block, err := s.b.ConstructLocalMinedBlock(header)
 	if err != nil && err.Error() == core.ErrBadSubManifest.Error() && nodeCtx < common.ZONE_CTX {
 		log.Info("filling sub manifest")
@@ -592,7 +708,7 @@ func (s *PublicBlockChainQuaiAPI) ReceiveMinedHeader(ctx context.Context, raw js
 	if block.Header() != nil {
 		s.b.EventMux().Post(core.NewMinedBlockEvent{Block: block})
 	}
-	log.Info("Retrieved mined block", "number", header.Number(), "location", header.Location())
+	log.Info("Retrieved mined block", "number", header.Number(nodeCtx), "location", header.Location())
 
 	return nil
 }
@@ -605,7 +721,7 @@ type tdBlock struct {
 	NewInboundEtxs   types.Transactions `json:"newInboundEtxs"`
 }
 
-func (s *PublicBlockChainQuaiAPI) Append(ctx context.Context, raw json.RawMessage) (map[string]interface{}, error) {
+func (s *PrivateCoordinationAPI) Append(ctx context.Context, raw json.RawMessage) (map[string]interface{}, error) {
 	// Decode header and transactions.
 	var body tdBlock
 
@@ -633,7 +749,7 @@ type SubRelay struct {
 	Location common.Location
 }
 
-func (s *PublicBlockChainQuaiAPI) SubRelayPendingHeader(ctx context.Context, raw json.RawMessage) {
+func (s *PrivateCoordinationAPI) SubRelayPendingHeader(ctx context.Context, raw json.RawMessage) {
 	var subRelay SubRelay
 	if err := json.Unmarshal(raw, &subRelay); err != nil {
 		return
@@ -642,7 +758,7 @@ func (s *PublicBlockChainQuaiAPI) SubRelayPendingHeader(ctx context.Context, raw
 	s.b.SubRelayPendingHeader(pendingHeader, subRelay.Location)
 }
 
-func (s *PublicBlockChainQuaiAPI) NewGenesisPendingHeader(ctx context.Context, raw json.RawMessage) {
+func (s *PrivateCoordinationAPI) NewGenesisPendingHeader(ctx context.Context, raw json.RawMessage) {
 	var pendingHeader *types.Header
 	if err := json.Unmarshal(raw, &pendingHeader); err != nil {
 		return
@@ -651,7 +767,7 @@ func (s *PublicBlockChainQuaiAPI) NewGenesisPendingHeader(ctx context.Context, r
 }
 
 func (s *PublicBlockChainQuaiAPI) GetPendingHeader(ctx context.Context) (map[string]interface{}, error) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.b.ChainConfig().Location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return nil, errors.New("getPendingHeader can only be called in zone chain")
 	}
@@ -678,12 +794,19 @@ func (s *PublicBlockChainQuaiAPI) GetManifest(ctx context.Context, raw json.RawM
 	return manifest, nil
 }
 
+// GetTerminiByHash returns the termini stored for the block with the given
+// hash, or nil if this chain never appended it. The dom and subs use it to
+// check that they agree with this chain when they start.
+func (s *PrivateCoordinationAPI) GetTerminiByHash(ctx context.Context, hash common.Hash) []common.Hash {
+	return s.b.GetTerminiByHash(hash)
+}
+
 type SendPendingEtxsToDomArgs struct {
 	Header         types.Header         `json:"header"`
 	NewPendingEtxs []types.Transactions `json:"newPendingEtxs"`
 }
 
-func (s *PublicBlockChainQuaiAPI) SendPendingEtxsToDom(ctx context.Context, raw json.RawMessage) error {
+func (s *PrivateCoordinationAPI) SendPendingEtxsToDom(ctx context.Context, raw json.RawMessage) error {
 	var pEtxs types.PendingEtxs
 	if err := json.Unmarshal(raw, &pEtxs); err != nil {
 		return err
@@ -696,7 +819,7 @@ type SendPendingEtxsRollupToDomArgs struct {
 	Manifest types.BlockManifest `json:"manifest"`
 }
 
-func (s *PublicBlockChainQuaiAPI) SendPendingEtxsRollupToDom(ctx context.Context, raw json.RawMessage) error {
+func (s *PrivateCoordinationAPI) SendPendingEtxsRollupToDom(ctx context.Context, raw json.RawMessage) error {
 	var pEtxsRollup SendPendingEtxsRollupToDomArgs
 	if err := json.Unmarshal(raw, &pEtxsRollup); err != nil {
 		return err
@@ -709,10 +832,157 @@ type GenerateRecoveryPendingHeaderArgs struct {
 	CheckpointHashes []common.Hash `json:"checkpointHashes"`
 }
 
-func (s *PublicBlockChainQuaiAPI) GenerateRecoveryPendingHeader(ctx context.Context, raw json.RawMessage) error {
+func (s *PrivateCoordinationAPI) GenerateRecoveryPendingHeader(ctx context.Context, raw json.RawMessage) error {
 	var pHandcheckPointHashes GenerateRecoveryPendingHeaderArgs
 	if err := json.Unmarshal(raw, &pHandcheckPointHashes); err != nil {
 		return err
 	}
 	return s.b.GenerateRecoveryPendingHeader(pHandcheckPointHashes.PendingHeader, pHandcheckPointHashes.CheckpointHashes)
 }
+
+// UpdateBlockLists changes the bad hashes and the checkpoints of this chain, or
+// of the chains below it, as requested by the dom.
+func (s *PrivateCoordinationAPI) UpdateBlockLists(ctx context.Context, update types.BlockListUpdate) error {
+	return s.b.UpdateBlockLists(update, true)
+}
+
+// EstimateExternalGas estimates the gas an ETX needs in its destination zone.
+// The request is forwarded through the dom and sub chains until it reaches the
+// destination, which simulates the ETX against its current state.
+func (s *PrivateCoordinationAPI) EstimateExternalGas(ctx context.Context, args TransactionArgs) (hexutil.Uint64, error) {
+	msg := quai.CallMsg{
+		From: args.from(),
+		To:   args.To,
+		Data: args.data(),
+	}
+	if args.Value != nil {
+		msg.Value = args.Value.ToInt()
+	}
+	if args.Gas != nil {
+		msg.Gas = uint64(*args.Gas)
+	}
+	if args.AccessList != nil {
+		msg.AccessList = *args.AccessList
+	}
+	gas, err := s.b.EstimateExternalGas(ctx, msg)
+	return hexutil.Uint64(gas), err
+}
+
+// TransportHealth returns the state of the links to the dom and sub chains.
+func (s *PublicBlockChainQuaiAPI) TransportHealth(ctx context.Context) core.TransportHealth {
+	return s.b.TransportHealth()
+}
+
+// GetEtxStatus returns the lifecycle of the given ETX as seen by this node.
+// Each slice only witnesses the stages of the lifecycle which happen in its own
+// chain: the origin zone reports where the ETX was emitted, the dom chains
+// where it was rolled up and confirmed, and the destination zone where it
+// became available and where it was executed or expired.
+func (s *PublicBlockChainQuaiAPI) GetEtxStatus(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	status := s.b.GetEtxStatus(hash)
+	if status == nil {
+		return nil, nil
+	}
+	fields := RPCMarshalEtxStatus(hash, status)
+	if status.Stage == types.EtxExecuted {
+		receipts, err := s.b.GetReceipts(ctx, status.InclusionBlock)
+		if err != nil {
+			return nil, err
+		}
+		for _, receipt := range receipts {
+			if receipt.TxHash == hash {
+				fields["receiptStatus"] = hexutil.Uint(receipt.Status)
+				fields["gasUsed"] = hexutil.Uint64(receipt.GasUsed)
+				break
+			}
+		}
+	}
+	return fields, nil
+}
+
+// GetConfirmationStatus returns how final a block is within the hierarchy. The
+// hash is either the hash of a block or of a transaction included in one. A
+// block is "safe" and "finalized" once it is at or below the blocks resolved
+// by the safe and finalized block tags respectively.
+func (s *PublicBlockChainQuaiAPI) GetConfirmationStatus(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	header, err := s.b.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	var txHash common.Hash
+	if header == nil {
+		tx, blockHash, _, _, err := s.b.GetTransaction(ctx, hash)
+		if err != nil || tx == nil {
+			return nil, err
+		}
+		if header, err = s.b.HeaderByHash(ctx, blockHash); err != nil || header == nil {
+			return nil, err
+		}
+		txHash = hash
+	}
+	status, err := s.b.ConfirmationStatus(header)
+	if err != nil {
+		return nil, err
+	}
+	level := "unconfirmed"
+	switch {
+	case status.Finalized:
+		level = "finalized"
+	case status.Safe:
+		level = "safe"
+	case status.Canonical:
+		level = "latest"
+	}
+	fields := map[string]interface{}{
+		"blockHash":   status.Hash,
+		"blockNumber": hexutil.Uint64(status.Number),
+		"order":       common.OrderToString(status.Order),
+		"canonical":   status.Canonical,
+		"depth":       hexutil.Uint64(status.Depth),
+		"region":      status.Region,
+		"prime":       status.Prime,
+		"entropy":     (*hexutil.Big)(status.Entropy),
+		"level":       level,
+	}
+	if txHash != (common.Hash{}) {
+		fields["transactionHash"] = txHash
+	}
+	return fields, nil
+}
+
+// RPCMarshalEtxStatus converts the given ETX lifecycle to the RPC output,
+// leaving out the stages which were not observed.
+func RPCMarshalEtxStatus(hash common.Hash, status *types.EtxStatus) map[string]interface{} {
+	fields := map[string]interface{}{
+		"hash":  hash,
+		"stage": status.Stage,
+	}
+	if status.OriginBlock != (common.Hash{}) {
+		fields["originBlockHash"] = status.OriginBlock
+		fields["originBlockNumber"] = hexutil.Uint64(status.OriginNumber)
+		fields["originLocation"] = status.OriginLocation
+	}
+	if status.RollupBlock != (common.Hash{}) {
+		fields["rollupBlockHash"] = status.RollupBlock
+		fields["rollupBlockNumber"] = hexutil.Uint64(status.RollupNumber)
+		fields["rollupLocation"] = status.RollupLocation
+	}
+	if status.DomBlock != (common.Hash{}) {
+		fields["domBlockHash"] = status.DomBlock
+		fields["domBlockNumber"] = hexutil.Uint64(status.DomNumber)
+		fields["domLocation"] = status.DomLocation
+	}
+	if status.AvailableBlock != (common.Hash{}) {
+		fields["availableBlockHash"] = status.AvailableBlock
+		fields["availableHeight"] = hexutil.Uint64(status.AvailableHeight)
+		fields["expirationHeight"] = hexutil.Uint64(status.AvailableHeight + params.EtxExpirationAge)
+	}
+	if status.InclusionBlock != (common.Hash{}) {
+		fields["inclusionBlockHash"] = status.InclusionBlock
+		fields["inclusionBlockNumber"] = hexutil.Uint64(status.InclusionNumber)
+	}
+	if status.Stage == types.EtxExpired {
+		fields["expiredAtHeight"] = hexutil.Uint64(status.ExpiryHeight)
+	}
+	return fields
+}
@@ -23,10 +23,12 @@ import (
 	"fmt"
 	"math/big"
 
+	quai "github.com/dominant-strategies/go-quai"
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/common/hexutil"
 	"github.com/dominant-strategies/go-quai/common/math"
 	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/core/vm"
 	"github.com/dominant-strategies/go-quai/log"
 	"github.com/dominant-strategies/go-quai/rpc"
 )
@@ -51,6 +53,15 @@ type TransactionArgs struct {
 	// Introduced by AccessListTxType transaction.
 	AccessList *types.AccessList `json:"accessList,omitempty"`
 	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+
+	// When the recipient lives outside of this zone the etx fields describe
+	// the external transaction emitted towards it, and an InternalToExternalTx
+	// is built instead of an InternalTx.
+	ETXGasLimit   *hexutil.Uint64   `json:"etxGasLimit,omitempty"`
+	ETXGasPrice   *hexutil.Big      `json:"etxGasPrice,omitempty"`
+	ETXGasTip     *hexutil.Big      `json:"etxGasTip,omitempty"`
+	ETXData       *hexutil.Bytes    `json:"etxData,omitempty"`
+	ETXAccessList *types.AccessList `json:"etxAccessList,omitempty"`
 }
 
 // from retrieves the transaction sender address.
@@ -72,6 +83,46 @@ func (arg *TransactionArgs) data() []byte {
 	return nil
 }
 
+// isETX reports whether the recipient of the transaction is outside of the
+// zone at the given location of the topology.
+func (args *TransactionArgs) isETX(location common.Location, topology *common.Topology) bool {
+	return args.To != nil && !topology.ContainsAddress(location, *args.To)
+}
+
+// etxData retrieves the calldata of the external transaction.
+func (args *TransactionArgs) etxData() []byte {
+	if args.ETXData != nil {
+		return *args.ETXData
+	}
+	return nil
+}
+
+// etxAccessList retrieves the access list of the external transaction.
+func (args *TransactionArgs) etxAccessList() types.AccessList {
+	if args.ETXAccessList != nil {
+		return *args.ETXAccessList
+	}
+	return nil
+}
+
+// etxCallMsg describes the external transaction as it is executed in its
+// destination zone, capped at the given etx gas limit if any.
+func (args *TransactionArgs) etxCallMsg() quai.CallMsg {
+	msg := quai.CallMsg{
+		From:       args.from(),
+		To:         args.To,
+		Data:       args.etxData(),
+		AccessList: args.etxAccessList(),
+	}
+	if args.Value != nil {
+		msg.Value = args.Value.ToInt()
+	}
+	if args.ETXGasLimit != nil {
+		msg.Gas = uint64(*args.ETXGasLimit)
+	}
+	return msg
+}
+
 // setDefaults fills in default values for unspecified tx fields.
 func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
@@ -139,6 +190,9 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 	if args.To == nil && len(args.data()) == 0 {
 		return errors.New(`contract creation without any data provided`)
 	}
+	if err := args.setETXDefaults(ctx, b); err != nil {
+		return err
+	}
 	// Estimate the gas usage if necessary.
 	if args.Gas == nil {
 		// These fields are immutable during the estimation, safe to
@@ -152,6 +206,11 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 			Value:                args.Value,
 			Data:                 args.Data,
 			AccessList:           args.AccessList,
+			ETXGasLimit:          args.ETXGasLimit,
+			ETXGasPrice:          args.ETXGasPrice,
+			ETXGasTip:            args.ETXGasTip,
+			ETXData:              args.ETXData,
+			ETXAccessList:        args.ETXAccessList,
 		}
 		pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 		estimated, err := DoEstimateGas(ctx, b, callArgs, pendingBlockNr, b.RPCGasCap())
@@ -168,11 +227,44 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 	return nil
 }
 
+// setETXDefaults fills in default values for the unspecified etx fields of a
+// transaction towards another zone. The etx fees default to the minimum
+// multiple of the transaction fees, and the etx gas limit is estimated by the
+// destination zone. This assumes the transaction fees have been defaulted.
+func (args *TransactionArgs) setETXDefaults(ctx context.Context, b Backend) error {
+	if !args.isETX(b.ChainConfig().Location, b.ChainConfig().GetTopology()) {
+		if args.ETXGasLimit != nil || args.ETXGasPrice != nil || args.ETXGasTip != nil || args.ETXData != nil || args.ETXAccessList != nil {
+			return errors.New("etx fields set for a transaction within this zone")
+		}
+		return nil
+	}
+	feeMul := vm.CalcEtxFeeMultiplier(args.from(), *args.To, b.ChainConfig().GetTopology())
+	gasFeeCap, gasTipCap := args.MaxFeePerGas, args.MaxPriorityFeePerGas
+	if args.GasPrice != nil {
+		gasFeeCap, gasTipCap = args.GasPrice, args.GasPrice
+	}
+	if args.ETXGasPrice == nil {
+		args.ETXGasPrice = (*hexutil.Big)(new(big.Int).Mul(gasFeeCap.ToInt(), feeMul))
+	}
+	if args.ETXGasTip == nil {
+		args.ETXGasTip = (*hexutil.Big)(new(big.Int).Mul(gasTipCap.ToInt(), feeMul))
+	}
+	if args.ETXGasLimit == nil {
+		estimated, err := b.EstimateExternalGas(ctx, args.etxCallMsg())
+		if err != nil {
+			return err
+		}
+		args.ETXGasLimit = (*hexutil.Uint64)(&estimated)
+		log.Trace("Estimate etx gas usage automatically", "gas", args.ETXGasLimit)
+	}
+	return nil
+}
+
 // ToMessage converts th transaction arguments to the Message type used by the
 // core evm. This method is used in calls and traces that do not require a real
 // live transaction.
-func (args *TransactionArgs) ToMessage(globalGasCap uint64, baseFee *big.Int) (types.Message, error) {
-	nodeCtx := common.NodeLocation.Context()
+func (args *TransactionArgs) ToMessage(globalGasCap uint64, baseFee *big.Int, location common.Location, topology *common.Topology) (types.Message, error) {
+	nodeCtx := location.Context()
 	if nodeCtx != common.ZONE_CTX {
 		return types.Message{}, errors.New("toMessage can only called in zone chain")
 	}
@@ -238,6 +330,67 @@ func (args *TransactionArgs) ToMessage(globalGasCap uint64, baseFee *big.Int) (t
 	if args.AccessList != nil {
 		accessList = *args.AccessList
 	}
-	msg := types.NewMessage(addr, args.To, 0, value, gas, gasPrice, gasFeeCap, gasTipCap, data, accessList, false)
+	if !args.isETX(location, topology) {
+		return types.NewMessage(addr, args.To, 0, value, gas, gasPrice, gasFeeCap, gasTipCap, data, accessList, false), nil
+	}
+	// Unless given, the etx pays the minimum fees accepted for it
+	var etxGasLimit uint64
+	if args.ETXGasLimit != nil {
+		etxGasLimit = uint64(*args.ETXGasLimit)
+	}
+	feeMul := vm.CalcEtxFeeMultiplier(addr, *args.To, topology)
+	etxGasPrice := new(big.Int)
+	if args.ETXGasPrice != nil {
+		etxGasPrice = args.ETXGasPrice.ToInt()
+	} else if baseFee != nil {
+		etxGasPrice.Mul(baseFee, feeMul)
+	}
+	etxGasTip := new(big.Int).Mul(gasTipCap, feeMul)
+	if args.ETXGasTip != nil {
+		etxGasTip = args.ETXGasTip.ToInt()
+	}
+	msg := types.NewInternalToExternalMessage(addr, args.To, 0, value, gas, gasPrice, gasFeeCap, gasTipCap, data, accessList, etxGasLimit, etxGasPrice, etxGasTip, args.etxData(), args.etxAccessList(), false)
 	return msg, nil
 }
+
+// toTransaction converts the arguments to a transaction. This assumes that
+// setDefaults has been called.
+func (args *TransactionArgs) toTransaction(location common.Location, topology *common.Topology) *types.Transaction {
+	gasFeeCap, gasTipCap := (*big.Int)(args.MaxFeePerGas), (*big.Int)(args.MaxPriorityFeePerGas)
+	if args.GasPrice != nil {
+		gasFeeCap, gasTipCap = (*big.Int)(args.GasPrice), (*big.Int)(args.GasPrice)
+	}
+	var al types.AccessList
+	if args.AccessList != nil {
+		al = *args.AccessList
+	}
+	if !args.isETX(location, topology) {
+		return types.NewTx(&types.InternalTx{
+			To:         args.To,
+			ChainID:    (*big.Int)(args.ChainID),
+			Nonce:      uint64(*args.Nonce),
+			Gas:        uint64(*args.Gas),
+			GasFeeCap:  gasFeeCap,
+			GasTipCap:  gasTipCap,
+			Value:      (*big.Int)(args.Value),
+			Data:       args.data(),
+			AccessList: al,
+		})
+	}
+	return types.NewTx(&types.InternalToExternalTx{
+		To:            args.To,
+		ChainID:       (*big.Int)(args.ChainID),
+		Nonce:         uint64(*args.Nonce),
+		Gas:           uint64(*args.Gas),
+		GasFeeCap:     gasFeeCap,
+		GasTipCap:     gasTipCap,
+		Value:         (*big.Int)(args.Value),
+		Data:          args.data(),
+		AccessList:    al,
+		ETXGasLimit:   uint64(*args.ETXGasLimit),
+		ETXGasPrice:   (*big.Int)(args.ETXGasPrice),
+		ETXGasTip:     (*big.Int)(args.ETXGasTip),
+		ETXData:       args.etxData(),
+		ETXAccessList: args.etxAccessList(),
+	})
+}
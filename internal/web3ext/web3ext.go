@@ -0,0 +1,816 @@
+// Package web3ext contains the console JavaScript definitions of the RPC
+// modules served by go-quai.
+package web3ext
+
+// Modules maps the RPC namespaces to the JavaScript attaching them to the
+// console library. A module is only loaded when the node serves its namespace.
+var Modules = map[string]string{
+	"admin":  AdminJs,
+	"debug":  DebugJs,
+	"miner":  MinerJs,
+	"net":    NetJs,
+	"quai":   QuaiJs,
+	"txpool": TxpoolJs,
+}
+
+const AdminJs = `
+web3._extend({
+	property: 'admin',
+	methods: [
+		new web3._extend.Method({
+			name: 'addPeer',
+			call: 'admin_addPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removePeer',
+			call: 'admin_removePeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addTrustedPeer',
+			call: 'admin_addTrustedPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeTrustedPeer',
+			call: 'admin_removeTrustedPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'exportChain',
+			call: 'admin_exportChain',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'importChain',
+			call: 'admin_importChain',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addBadHashes',
+			call: 'admin_addBadHashes',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'removeBadHashes',
+			call: 'admin_removeBadHashes',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'addCheckpoint',
+			call: 'admin_addCheckpoint',
+			params: 4,
+			inputFormatter: [null, web3._extend.utils.toHex, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'removeCheckpoint',
+			call: 'admin_removeCheckpoint',
+			params: 3,
+			inputFormatter: [null, web3._extend.utils.toHex, null]
+		}),
+		new web3._extend.Method({
+			name: 'startHTTP',
+			call: 'admin_startHTTP',
+			params: 5,
+			inputFormatter: [null, null, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'stopHTTP',
+			call: 'admin_stopHTTP'
+		}),
+		new web3._extend.Method({
+			name: 'startWS',
+			call: 'admin_startWS',
+			params: 4,
+			inputFormatter: [null, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'stopWS',
+			call: 'admin_stopWS'
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'nodeInfo',
+			getter: 'admin_nodeInfo'
+		}),
+		new web3._extend.Property({
+			name: 'peers',
+			getter: 'admin_peers'
+		}),
+		new web3._extend.Property({
+			name: 'datadir',
+			getter: 'admin_datadir'
+		}),
+	]
+});
+`
+
+const DebugJs = `
+web3._extend({
+	property: 'debug',
+	methods: [
+		new web3._extend.Method({
+			name: 'accountRange',
+			call: 'debug_accountRange',
+			params: 6
+		}),
+		new web3._extend.Method({
+			name: 'printBlock',
+			call: 'debug_printBlock',
+			params: 1,
+			outputFormatter: console.log
+		}),
+		new web3._extend.Method({
+			name: 'getBlockRlp',
+			call: 'debug_getBlockRlp',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'seedHash',
+			call: 'debug_seedHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dumpBlock',
+			call: 'debug_dumpBlock',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'chaindbProperty',
+			call: 'debug_chaindbProperty',
+			params: 1,
+			outputFormatter: console.log
+		}),
+		new web3._extend.Method({
+			name: 'chaindbCompact',
+			call: 'debug_chaindbCompact',
+		}),
+		new web3._extend.Method({
+			name: 'verbosity',
+			call: 'debug_verbosity',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stacks',
+			call: 'debug_stacks',
+			params: 0,
+			outputFormatter: console.log
+		}),
+		new web3._extend.Method({
+			name: 'freeOSMemory',
+			call: 'debug_freeOSMemory',
+			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'setGCPercent',
+			call: 'debug_setGCPercent',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'memStats',
+			call: 'debug_memStats',
+			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'gcStats',
+			call: 'debug_gcStats',
+			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'cpuProfile',
+			call: 'debug_cpuProfile',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'startCPUProfile',
+			call: 'debug_startCPUProfile',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stopCPUProfile',
+			call: 'debug_stopCPUProfile',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'goTrace',
+			call: 'debug_goTrace',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'startGoTrace',
+			call: 'debug_startGoTrace',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stopGoTrace',
+			call: 'debug_stopGoTrace',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'blockProfile',
+			call: 'debug_blockProfile',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'setBlockProfileRate',
+			call: 'debug_setBlockProfileRate',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'writeBlockProfile',
+			call: 'debug_writeBlockProfile',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'mutexProfile',
+			call: 'debug_mutexProfile',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'setMutexProfileFraction',
+			call: 'debug_setMutexProfileFraction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'writeMutexProfile',
+			call: 'debug_writeMutexProfile',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'writeMemProfile',
+			call: 'debug_writeMemProfile',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'traceBlockByNumber',
+			call: 'debug_traceBlockByNumber',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'traceBlockByHash',
+			call: 'debug_traceBlockByHash',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'traceBlock',
+			call: 'debug_traceBlock',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'traceTransaction',
+			call: 'debug_traceTransaction',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'traceCall',
+			call: 'debug_traceCall',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'preimage',
+			call: 'debug_preimage',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getBadBlocks',
+			call: 'debug_getBadBlocks',
+			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'storageRangeAt',
+			call: 'debug_storageRangeAt',
+			params: 5,
+		}),
+		new web3._extend.Method({
+			name: 'getModifiedAccountsByNumber',
+			call: 'debug_getModifiedAccountsByNumber',
+			params: 2,
+			inputFormatter: [null, null],
+		}),
+		new web3._extend.Method({
+			name: 'getModifiedAccountsByHash',
+			call: 'debug_getModifiedAccountsByHash',
+			params: 2,
+			inputFormatter:[null, null],
+		}),
+	],
+	properties: []
+});
+`
+
+const MinerJs = `
+web3._extend({
+	property: 'miner',
+	methods: [
+		new web3._extend.Method({
+			name: 'setEtherbase',
+			call: 'miner_setEtherbase',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setExtra',
+			call: 'miner_setExtra',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setGasPrice',
+			call: 'miner_setGasPrice',
+			params: 1,
+			inputFormatter: [web3._extend.utils.toHex]
+		}),
+		new web3._extend.Method({
+			name: 'setGasLimit',
+			call: 'miner_setGasLimit',
+			params: 1,
+			inputFormatter: [web3._extend.utils.toHex]
+		}),
+		new web3._extend.Method({
+			name: 'setRecommitInterval',
+			call: 'miner_setRecommitInterval',
+			params: 1,
+		}),
+	],
+	properties: []
+});
+`
+
+const NetJs = `
+web3._extend({
+	property: 'net',
+	methods: [],
+	properties: [
+		new web3._extend.Property({
+			name: 'listening',
+			getter: 'net_listening'
+		}),
+		new web3._extend.Property({
+			name: 'peerCount',
+			getter: 'net_peerCount',
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Property({
+			name: 'version',
+			getter: 'net_version'
+		}),
+	]
+});
+`
+
+const TxpoolJs = `
+web3._extend({
+	property: 'txpool',
+	methods: [
+		new web3._extend.Method({
+			name: 'contentFrom',
+			call: 'txpool_contentFrom',
+			params: 1,
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'content',
+			getter: 'txpool_content'
+		}),
+		new web3._extend.Property({
+			name: 'inspect',
+			getter: 'txpool_inspect'
+		}),
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'txpool_status',
+			outputFormatter: function(status) {
+				status.pending = web3._extend.utils.toDecimal(status.pending);
+				status.queued = web3._extend.utils.toDecimal(status.queued);
+				return status;
+			}
+		}),
+	]
+});
+`
+
+// QuaiJs defines the quai namespace, together with the helpers decoding the
+// locations and per context header fields of the hierarchy.
+const QuaiJs = `
+(function() {
+	var utils = web3._extend.utils;
+	var formatters = web3._extend.formatters;
+
+	// The contexts of the hierarchy, indexing the per context header fields.
+	var contexts = { prime: 0, region: 1, zone: 2 };
+	var contextNames = ['prime', 'region', 'zone'];
+	var regionNames = ['cyprus', 'paxos', 'hydra'];
+
+	// The ranges of the first address byte owned by every zone of the default
+	// topology, used if the attached node does not report its own.
+	var zonePrefixes = [
+		[[0, 29], [30, 58], [59, 87]],
+		[[88, 115], [116, 143], [144, 171]],
+		[[172, 199], [200, 227], [228, 255]],
+	];
+
+	// Header fields holding one value per context, and how to decode them.
+	var contextFields = {
+		parentHash: null,
+		manifestHash: null,
+		number: utils.toDecimal,
+		parentEntropy: utils.toDecimalString,
+		parentDeltaS: utils.toDecimalString,
+	};
+
+	// Header fields holding a single quantity, and how to decode them.
+	var quantityFields = {
+		difficulty: utils.toDecimalString,
+		baseFeePerGas: utils.toDecimalString,
+		gasLimit: utils.toDecimal,
+		gasUsed: utils.toDecimal,
+		size: utils.toDecimal,
+		timestamp: utils.toDecimal,
+	};
+
+	// decodeLocation turns a location, given as the array of indices returned
+	// by quai_nodeLocation or as the bytes of a header, into an object naming
+	// it and its context.
+	var decodeLocation = function(location) {
+		var indices = [];
+		if (utils.isArray(location)) {
+			indices = location.map(utils.toDecimal);
+		} else if (utils.isHex(location)) {
+			for (var i = 2; i < location.length; i += 2) {
+				indices.push(parseInt(location.slice(i, i + 2), 16));
+			}
+		} else {
+			throw new Error('invalid location: ' + location);
+		}
+		var context = indices.length > 2 ? contexts.zone : contexts.prime + indices.length;
+		var name = 'prime';
+		if (indices.length > 0) {
+			name = regionNames[indices[0]] || 'region' + indices[0];
+		}
+		if (indices.length > 1) {
+			name += (indices[1] + 1);
+		}
+		return {
+			region: indices.length > 0 ? indices[0] : -1,
+			zone: indices.length > 1 ? indices[1] : -1,
+			context: context,
+			contextName: contextNames[context],
+			name: name,
+			toString: function() { return name; }
+		};
+	};
+
+	// resolveContext accepts a context index or name, defaulting to the
+	// context of the attached node.
+	var resolveContext = function(context) {
+		if (context === undefined || context === null) {
+			return web3.quai.location.context;
+		}
+		if (utils.isString(context)) {
+			if (contexts[context.toLowerCase()] === undefined) {
+				throw new Error('unknown context: ' + context);
+			}
+			return contexts[context.toLowerCase()];
+		}
+		if (context < contexts.prime || context > contexts.zone) {
+			throw new Error('invalid context: ' + context);
+		}
+		return context;
+	};
+
+	web3._extend({
+		property: 'quai',
+		methods: [
+			new web3._extend.Method({
+				name: 'getBalance',
+				call: 'quai_getBalance',
+				params: 2,
+				inputFormatter: [null, formatters.inputDefaultBlockNumberFormatter],
+				outputFormatter: formatters.outputBigNumberFormatter
+			}),
+			new web3._extend.Method({
+				name: 'getStorageAt',
+				call: 'quai_getStorageAt',
+				params: 3,
+				inputFormatter: [null, utils.toHex, formatters.inputDefaultBlockNumberFormatter]
+			}),
+			new web3._extend.Method({
+				name: 'getCode',
+				call: 'quai_getCode',
+				params: 2,
+				inputFormatter: [null, formatters.inputDefaultBlockNumberFormatter]
+			}),
+			new web3._extend.Method({
+				name: 'getProof',
+				call: 'quai_getProof',
+				params: 3,
+				inputFormatter: [null, null, formatters.inputDefaultBlockNumberFormatter]
+			}),
+			new web3._extend.Method({
+				name: 'getBlockByNumber',
+				call: 'quai_getBlockByNumber',
+				params: 2,
+				inputFormatter: [formatters.inputDefaultBlockNumberFormatter, function(full) { return !!full; }]
+			}),
+			new web3._extend.Method({
+				name: 'getBlockByHash',
+				call: 'quai_getBlockByHash',
+				params: 2,
+				inputFormatter: [null, function(full) { return !!full; }]
+			}),
+			new web3._extend.Method({
+				name: 'getHeaderByNumber',
+				call: 'quai_getHeaderByNumber',
+				params: 1,
+				inputFormatter: [formatters.inputDefaultBlockNumberFormatter]
+			}),
+			new web3._extend.Method({
+				name: 'getHeaderByHash',
+				call: 'quai_getHeaderByHash',
+				params: 1
+			}),
+			new web3._extend.Method({
+				name: 'getHeaderHashByNumber',
+				call: 'quai_getHeaderHashByNumber',
+				params: 1,
+				inputFormatter: [formatters.inputDefaultBlockNumberFormatter]
+			}),
+			new web3._extend.Method({
+				name: 'getUncleByBlockNumberAndIndex',
+				call: 'quai_getUncleByBlockNumberAndIndex',
+				params: 2,
+				inputFormatter: [formatters.inputDefaultBlockNumberFormatter, utils.toHex]
+			}),
+			new web3._extend.Method({
+				name: 'getUncleByBlockHashAndIndex',
+				call: 'quai_getUncleByBlockHashAndIndex',
+				params: 2,
+				inputFormatter: [null, utils.toHex]
+			}),
+			new web3._extend.Method({
+				name: 'getUncleCountByBlockNumber',
+				call: 'quai_getUncleCountByBlockNumber',
+				params: 1,
+				inputFormatter: [formatters.inputDefaultBlockNumberFormatter],
+				outputFormatter: utils.toDecimal
+			}),
+			new web3._extend.Method({
+				name: 'getUncleCountByBlockHash',
+				call: 'quai_getUncleCountByBlockHash',
+				params: 1,
+				outputFormatter: utils.toDecimal
+			}),
+			new web3._extend.Method({
+				name: 'getBlockTransactionCountByNumber',
+				call: 'quai_getBlockTransactionCountByNumber',
+				params: 1,
+				inputFormatter: [formatters.inputDefaultBlockNumberFormatter],
+				outputFormatter: utils.toDecimal
+			}),
+			new web3._extend.Method({
+				name: 'getBlockTransactionCountByHash',
+				call: 'quai_getBlockTransactionCountByHash',
+				params: 1,
+				outputFormatter: utils.toDecimal
+			}),
+			new web3._extend.Method({
+				name: 'getTransactionByHash',
+				call: 'quai_getTransactionByHash',
+				params: 1
+			}),
+			new web3._extend.Method({
+				name: 'getTransactionByBlockNumberAndIndex',
+				call: 'quai_getTransactionByBlockNumberAndIndex',
+				params: 2,
+				inputFormatter: [formatters.inputDefaultBlockNumberFormatter, utils.toHex]
+			}),
+			new web3._extend.Method({
+				name: 'getTransactionByBlockHashAndIndex',
+				call: 'quai_getTransactionByBlockHashAndIndex',
+				params: 2,
+				inputFormatter: [null, utils.toHex]
+			}),
+			new web3._extend.Method({
+				name: 'getRawTransactionByHash',
+				call: 'quai_getRawTransactionByHash',
+				params: 1
+			}),
+			new web3._extend.Method({
+				name: 'getRawTransactionByBlockNumberAndIndex',
+				call: 'quai_getRawTransactionByBlockNumberAndIndex',
+				params: 2,
+				inputFormatter: [formatters.inputDefaultBlockNumberFormatter, utils.toHex]
+			}),
+			new web3._extend.Method({
+				name: 'getRawTransactionByBlockHashAndIndex',
+				call: 'quai_getRawTransactionByBlockHashAndIndex',
+				params: 2,
+				inputFormatter: [null, utils.toHex]
+			}),
+			new web3._extend.Method({
+				name: 'getTransactionCount',
+				call: 'quai_getTransactionCount',
+				params: 2,
+				inputFormatter: [null, formatters.inputDefaultBlockNumberFormatter],
+				outputFormatter: utils.toDecimal
+			}),
+			new web3._extend.Method({
+				name: 'getTransactionReceipt',
+				call: 'quai_getTransactionReceipt',
+				params: 1
+			}),
+			new web3._extend.Method({
+				name: 'sendRawTransaction',
+				call: 'quai_sendRawTransaction',
+				params: 1
+			}),
+			new web3._extend.Method({
+				name: 'call',
+				call: 'quai_call',
+				params: 2,
+				inputFormatter: [formatters.inputTransactionFormatter, formatters.inputDefaultBlockNumberFormatter]
+			}),
+			new web3._extend.Method({
+				name: 'estimateGas',
+				call: 'quai_estimateGas',
+				params: 2,
+				inputFormatter: [formatters.inputTransactionFormatter, formatters.inputBlockNumberFormatter],
+				outputFormatter: utils.toDecimal
+			}),
+			new web3._extend.Method({
+				name: 'estimateEtxGas',
+				call: 'quai_estimateEtxGas',
+				params: 2,
+				inputFormatter: [formatters.inputTransactionFormatter, formatters.inputBlockNumberFormatter],
+				outputFormatter: function(result) {
+					var estimate = { gas: utils.toDecimal(result.gas) };
+					if (result.etxGasLimit !== undefined) {
+						estimate.etxGasLimit = utils.toDecimal(result.etxGasLimit);
+					}
+					return estimate;
+				}
+			}),
+			new web3._extend.Method({
+				name: 'createAccessList',
+				call: 'quai_createAccessList',
+				params: 2,
+				inputFormatter: [formatters.inputTransactionFormatter, formatters.inputDefaultBlockNumberFormatter]
+			}),
+			new web3._extend.Method({
+				name: 'feeHistory',
+				call: 'quai_feeHistory',
+				params: 3,
+				inputFormatter: [null, formatters.inputDefaultBlockNumberFormatter, null]
+			}),
+			new web3._extend.Method({
+				name: 'getManifest',
+				call: 'quai_getManifest',
+				params: 1
+			}),
+			new web3._extend.Method({
+				name: 'getEtxStatus',
+				call: 'quai_getEtxStatus',
+				params: 1
+			}),
+		],
+		properties: [
+			new web3._extend.Property({
+				name: 'location',
+				getter: 'quai_nodeLocation',
+				outputFormatter: decodeLocation
+			}),
+			new web3._extend.Property({
+				name: 'chainId',
+				getter: 'quai_chainId',
+				outputFormatter: utils.toDecimal
+			}),
+			new web3._extend.Property({
+				name: 'blockNumber',
+				getter: 'quai_blockNumber',
+				outputFormatter: utils.toDecimal
+			}),
+			new web3._extend.Property({
+				name: 'gasPrice',
+				getter: 'quai_gasPrice',
+				outputFormatter: formatters.outputBigNumberFormatter
+			}),
+			new web3._extend.Property({
+				name: 'maxPriorityFeePerGas',
+				getter: 'quai_maxPriorityFeePerGas',
+				outputFormatter: formatters.outputBigNumberFormatter
+			}),
+			new web3._extend.Property({
+				name: 'syncing',
+				getter: 'quai_syncing'
+			}),
+			new web3._extend.Property({
+				name: 'pendingHeader',
+				getter: 'quai_getPendingHeader'
+			}),
+			new web3._extend.Property({
+				name: 'transportHealth',
+				getter: 'quai_transportHealth'
+			}),
+			new web3._extend.Property({
+				name: 'topology',
+				getter: 'quai_topology'
+			}),
+			new web3._extend.Property({
+				name: 'forkSchedule',
+				getter: 'quai_getForkSchedule'
+			}),
+		]
+	});
+
+	web3.quai.contexts = contexts;
+	web3.quai.decodeLocation = decodeLocation;
+
+	// topologyPrefixes returns the address prefix ranges of the zones of the
+	// attached node, fetched once, or those of the default topology if the node
+	// does not report them.
+	var nodePrefixes = null;
+	var topologyPrefixes = function() {
+		if (nodePrefixes !== null) {
+			return nodePrefixes;
+		}
+		try {
+			var spaces = web3.quai.topology.addressSpaces;
+			nodePrefixes = spaces.map(function(zones) {
+				return zones.map(function(space) { return [space.lo, space.hi]; });
+			});
+		} catch (err) {
+			return zonePrefixes;
+		}
+		return nodePrefixes;
+	};
+
+	// addressLocation returns the location of the zone owning an address.
+	web3.quai.addressLocation = function(address) {
+		if (!utils.isHex(address) || address.length !== 42) {
+			throw new Error('invalid address: ' + address);
+		}
+		var prefix = parseInt(address.slice(2, 4), 16);
+		var prefixes = topologyPrefixes();
+		for (var region = 0; region < prefixes.length; region++) {
+			for (var zone = 0; zone < prefixes[region].length; zone++) {
+				var bounds = prefixes[region][zone];
+				if (prefix >= bounds[0] && prefix <= bounds[1]) {
+					return decodeLocation([region, zone]);
+				}
+			}
+		}
+		throw new Error('address outside of every zone: ' + address);
+	};
+
+	// decodeHeader returns a copy of a header or block with the per context
+	// fields reduced to the value of the given context, which defaults to the
+	// context of the attached node. Quantities are decoded to numbers, or to
+	// decimal strings where they may exceed the safe integer range.
+	web3.quai.decodeHeader = function(header, context) {
+		if (!utils.isObject(header)) {
+			return header;
+		}
+		context = resolveContext(context);
+
+		var decoded = {};
+		for (var key in header) {
+			var value = header[key];
+			if (contextFields.hasOwnProperty(key) && utils.isArray(value)) {
+				value = value[context];
+				if (contextFields[key] && value !== undefined && value !== null) {
+					value = contextFields[key](value);
+				}
+			} else if (quantityFields.hasOwnProperty(key) && value !== null) {
+				value = quantityFields[key](value);
+			} else if (key === 'location') {
+				value = decodeLocation(value);
+			}
+			decoded[key] = value;
+		}
+		decoded.context = contextNames[context];
+		return decoded;
+	};
+
+	// getHeader retrieves a header by number or hash, decoded for a context.
+	web3.quai.getHeader = function(block, context) {
+		var header;
+		if (utils.isHex(block) && block.length === 66) {
+			header = web3.quai.getHeaderByHash(block);
+		} else {
+			header = web3.quai.getHeaderByNumber(block);
+		}
+		return web3.quai.decodeHeader(header, context);
+	};
+})();
+`
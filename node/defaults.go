@@ -32,8 +32,14 @@ const (
 	DefaultHTTPPort = 8545        // Default TCP port for the HTTP RPC server
 	DefaultWSHost   = "localhost" // Default host interface for the websocket RPC server
 	DefaultWSPort   = 8546        // Default TCP port for the websocket RPC server
+	DefaultAuthHost = "localhost" // Default host interface for the authenticated RPC server
+	DefaultAuthPort = 8551        // Default TCP port for the authenticated RPC server
 )
 
+// DefaultAuthModules are the API namespaces served by the authenticated RPC
+// server.
+var DefaultAuthModules = []string{"quai"}
+
 // DefaultConfig contains reasonable default settings.
 var DefaultConfig = Config{
 	DataDir:          DefaultDataDir(),
@@ -43,6 +49,9 @@ var DefaultConfig = Config{
 	HTTPTimeouts:     rpc.DefaultHTTPTimeouts,
 	WSPort:           DefaultWSPort,
 	WSModules:        []string{"net", "web3"},
+	AuthAddr:         DefaultAuthHost,
+	AuthPort:         DefaultAuthPort,
+	AuthVirtualHosts: []string{"localhost"},
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
 		MaxPeers:   50,
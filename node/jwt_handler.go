@@ -0,0 +1,30 @@
+package node
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/rpc"
+)
+
+// jwtHandler rejects requests which don't carry a valid token signed with the
+// shared secret.
+type jwtHandler struct {
+	secret []byte
+	next   http.Handler
+}
+
+// newJWTHandler wraps the given handler so it is only reachable by peers which
+// know the shared secret.
+func newJWTHandler(secret []byte, next http.Handler) http.Handler {
+	return &jwtHandler{secret: secret, next: next}
+}
+
+// ServeHTTP implements http.Handler
+func (handler *jwtHandler) ServeHTTP(out http.ResponseWriter, r *http.Request) {
+	if err := rpc.VerifyJWT(handler.secret, r, time.Now()); err != nil {
+		http.Error(out, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	handler.next.ServeHTTP(out, r)
+}
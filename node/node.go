@@ -17,6 +17,7 @@
 package node
 
 import (
+	crand "crypto/rand"
 	"errors"
 	"fmt"
 	"net/http"
@@ -26,6 +27,9 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/dominant-strategies/go-quai/accounts"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
 	"github.com/dominant-strategies/go-quai/core/rawdb"
 	"github.com/dominant-strategies/go-quai/ethdb"
 	"github.com/dominant-strategies/go-quai/event"
@@ -39,7 +43,9 @@ import (
 type Node struct {
 	eventmux      *event.TypeMux
 	config        *Config
+	accman        *accounts.Manager
 	log           log.Logger
+	ephemKeystore string            // if non-empty, the key directory that will be removed by Stop
 	dirLock       fileutil.Releaser // prevents concurrent use of instance directory
 	stop          chan struct{}     // Channel to wait for termination notifications
 	server        *p2p.Server       // Currently running P2P networking layer
@@ -51,6 +57,9 @@ type Node struct {
 	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
 	http          *httpServer //
 	ws            *httpServer //
+	httpAuth      *httpServer // Authenticated server for the coordination APIs
+	ipc           *ipcServer  // Stores information about the ipc http server
+	jwtSecret     []byte      // Shared secret authenticating the coordination APIs
 	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
 
 	databases map[*closeTrackingDB]struct{} // All open databases
@@ -106,6 +115,22 @@ func New(conf *Config) (*Node, error) {
 		return nil, err
 	}
 
+	// Load the secret shared with the dom and sub chains.
+	secret, err := node.obtainJWTSecret(conf.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	node.jwtSecret = secret
+
+	// Ensure that the AccountManager method works before the node has started. We rely on
+	// this in cmd/go-quai.
+	am, ephemeralKeystore, err := makeAccountManager(conf)
+	if err != nil {
+		return nil, err
+	}
+	node.accman = am
+	node.ephemKeystore = ephemeralKeystore
+
 	// Initialize the p2p server. This creates the node key and discovery databases.
 	node.server.Config.PrivateKey = node.config.NodeKey()
 	node.server.Config.Name = node.config.NodeName()
@@ -131,6 +156,8 @@ func New(conf *Config) (*Node, error) {
 	// Configure RPC servers.
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
+	node.httpAuth = newHTTPServer(node.log, conf.HTTPTimeouts)
+	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
 
 	return node, nil
 }
@@ -214,6 +241,15 @@ func (n *Node) doClose(errs []error) error {
 	errs = append(errs, n.closeDatabases()...)
 	n.lock.Unlock()
 
+	if err := n.accman.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if n.ephemKeystore != "" {
+		if err := os.RemoveAll(n.ephemKeystore); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// Release instance directory lock.
 	n.closeDataDir()
 
@@ -298,6 +334,76 @@ func (n *Node) openDataDir() error {
 	return nil
 }
 
+// obtainJWTSecret loads the secret authenticating the coordination APIs from the
+// given file, falling back to the one in the data directory. If no secret can
+// be found, a new one is generated and persisted.
+func (n *Node) obtainJWTSecret(fileName string) ([]byte, error) {
+	if fileName == "" {
+		if n.config.DataDir == "" {
+			// Generate an ephemeral secret if no datadir is being used.
+			secret := make([]byte, 32)
+			if _, err := crand.Read(secret); err != nil {
+				return nil, err
+			}
+			return secret, nil
+		}
+		fileName = n.config.ResolvePath(datadirJWTKey)
+	}
+	if _, err := os.Stat(fileName); err == nil {
+		return n.loadJWTSecret(fileName)
+	}
+	// No secret found, generate and store a new one. The secret is shared by
+	// all the chains of a node which may start concurrently, so it is written
+	// aside and linked into place, the first chain to do so winning.
+	secret := make([]byte, 32)
+	if _, err := crand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(hexutil.Encode(secret)); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Link(tmp.Name(), fileName); err != nil {
+		if os.IsExist(err) {
+			return n.loadJWTSecret(fileName)
+		}
+		return nil, err
+	}
+	n.log.Info("Generated JWT secret", "path", fileName)
+	return secret, nil
+}
+
+// loadJWTSecret reads a hex-encoded secret from the given file.
+func (n *Node) loadJWTSecret(fileName string) ([]byte, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid JWT secret in %s: want 32 bytes, have %d", fileName, len(secret))
+	}
+	n.log.Info("Loaded JWT secret file", "path", fileName)
+	return secret, nil
+}
+
+// JWTSecret returns the secret authenticating the coordination APIs, which is
+// shared with the dom and sub chains of the node.
+func (n *Node) JWTSecret() []byte {
+	return n.jwtSecret
+}
+
 func (n *Node) closeDataDir() {
 	// Release instance directory lock.
 	if n.dirLock != nil {
@@ -316,6 +422,21 @@ func (n *Node) startRPC() error {
 		return err
 	}
 
+	// Configure IPC, which serves every API to local users.
+	if n.ipc.endpoint != "" {
+		if err := n.ipc.start(n.rpcAPIs); err != nil {
+			return err
+		}
+	}
+
+	// The coordination APIs are only served by the authenticated server.
+	var openAPIs []rpc.API
+	for _, api := range n.rpcAPIs {
+		if !api.Authenticated {
+			openAPIs = append(openAPIs, api)
+		}
+	}
+
 	// Configure HTTP.
 	if n.config.HTTPHost != "" {
 		config := httpConfig{
@@ -327,7 +448,7 @@ func (n *Node) startRPC() error {
 		if err := n.http.setListenAddr(n.config.HTTPHost, n.config.HTTPPort); err != nil {
 			return err
 		}
-		if err := n.http.enableRPC(n.rpcAPIs, config); err != nil {
+		if err := n.http.enableRPC(openAPIs, config); err != nil {
 			return err
 		}
 	}
@@ -343,7 +464,29 @@ func (n *Node) startRPC() error {
 		if err := server.setListenAddr(n.config.WSHost, n.config.WSPort); err != nil {
 			return err
 		}
-		if err := server.enableWS(n.rpcAPIs, config); err != nil {
+		if err := server.enableWS(openAPIs, config); err != nil {
+			return err
+		}
+	}
+
+	// Configure the authenticated server, serving both HTTP and WebSocket.
+	if n.config.AuthAddr != "" {
+		if err := n.httpAuth.setListenAddr(n.config.AuthAddr, n.config.AuthPort); err != nil {
+			return err
+		}
+		httpConfig := httpConfig{
+			Vhosts:    n.config.AuthVirtualHosts,
+			Modules:   DefaultAuthModules,
+			jwtSecret: n.jwtSecret,
+		}
+		if err := n.httpAuth.enableRPC(n.rpcAPIs, httpConfig); err != nil {
+			return err
+		}
+		wsConfig := wsConfig{
+			Modules:   DefaultAuthModules,
+			jwtSecret: n.jwtSecret,
+		}
+		if err := n.httpAuth.enableWS(n.rpcAPIs, wsConfig); err != nil {
 			return err
 		}
 	}
@@ -351,7 +494,10 @@ func (n *Node) startRPC() error {
 	if err := n.http.start(); err != nil {
 		return err
 	}
-	return n.ws.start()
+	if err := n.ws.start(); err != nil {
+		return err
+	}
+	return n.httpAuth.start()
 }
 
 func (n *Node) wsServerForPort(port int) *httpServer {
@@ -364,6 +510,8 @@ func (n *Node) wsServerForPort(port int) *httpServer {
 func (n *Node) stopRPC() {
 	n.http.stop()
 	n.ws.stop()
+	n.httpAuth.stop()
+	n.ipc.stop()
 	n.stopInProc()
 }
 
@@ -460,6 +608,11 @@ func (n *Node) Config() *Config {
 	return n.config
 }
 
+// AccountManager retrieves the account manager used by the protocol stack.
+func (n *Node) AccountManager() *accounts.Manager {
+	return n.accman
+}
+
 // Server retrieves the currently running P2P network layer. This method is meant
 // only to inspect fields of the currently running server. Callers should not
 // start or stop the returned server.
@@ -487,6 +640,11 @@ func (n *Node) HTTPEndpoint() string {
 	return "http://" + n.http.listenAddr()
 }
 
+// IPCEndpoint retrieves the current IPC endpoint used by the protocol stack.
+func (n *Node) IPCEndpoint() string {
+	return n.ipc.endpoint
+}
+
 // WSEndpoint returns the current JSON-RPC over WebSocket endpoint.
 func (n *Node) WSEndpoint() string {
 	if n.http.wsAllowed() {
@@ -56,8 +56,8 @@ var V5Bootnodes = []string{}
 const dnsPrefix = "enrtree://ALE24Z2TEZV2XK46RXVB6IIN5HB5WTI4F4SMAVLYCAQIUPU53RSUU@"
 
 // KnownDNSNetwork returns the address of a public DNS-based node list for the given
-// genesis hash and protocol.
-func KnownDNSNetwork(genesis common.Hash, protocol string) string {
+// genesis hash, chain location and protocol.
+func KnownDNSNetwork(genesis common.Hash, location common.Location, protocol string) string {
 	var net string
 	switch genesis {
 	case ProgpowColosseumGenesisHash:
@@ -79,5 +79,5 @@ func KnownDNSNetwork(genesis common.Hash, protocol string) string {
 	default:
 		return ""
 	}
-	return dnsPrefix + common.NodeLocation.Name() + "." + net + ".quainodes.io"
+	return dnsPrefix + location.Name() + "." + net + ".quainodes.io"
 }
@@ -112,10 +112,10 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllProgpowProtocolChanges = &ChainConfig{big.NewInt(1337), "progpow", new(Blake3powConfig), new(ProgpowConfig), common.Hash{}}
+	AllProgpowProtocolChanges = &ChainConfig{big.NewInt(1337), "progpow", new(Blake3powConfig), new(ProgpowConfig), common.Hash{}, common.Location{}, nil, nil, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), "progpow", new(Blake3powConfig), new(ProgpowConfig), common.Hash{}}
-	TestRules       = TestChainConfig.Rules(new(big.Int))
+	TestChainConfig = &ChainConfig{big.NewInt(1), "progpow", new(Blake3powConfig), new(ProgpowConfig), common.Hash{}, common.Location{}, nil, nil, nil}
+	TestRules       = TestChainConfig.Rules(new(big.Int), new(big.Int))
 )
 
 // ChainConfig is the core config which determines the blockchain settings.
@@ -130,6 +130,27 @@ type ChainConfig struct {
 	Blake3Pow       *Blake3powConfig `json:"blake3pow,omitempty"`
 	Progpow         *ProgpowConfig   `json:"progpow,omitempty"`
 	GenesisHash     common.Hash
+	Location        common.Location  `json:"location,omitempty"` // location of the chain within the hierarchy
+	Topology        *common.Topology `json:"topology,omitempty"` // shape of the hierarchy, nil for the default 3x3
+	Rewards         *RewardsConfig   `json:"rewards,omitempty"`  // block rewards of the hierarchy, nil for DefaultRewards
+
+	Forks map[Fork]*ForkActivation `json:"forks,omitempty"` // activation schedule of the protocol upgrades
+}
+
+// GetTopology returns the topology of the hierarchy of the chain.
+func (c *ChainConfig) GetTopology() *common.Topology {
+	if c.Topology == nil {
+		return common.DefaultTopology
+	}
+	return c.Topology
+}
+
+// GetRewards returns the block rewards of the hierarchy of the chain.
+func (c *ChainConfig) GetRewards() *RewardsConfig {
+	if c.Rewards == nil {
+		return DefaultRewards
+	}
+	return c.Rewards
 }
 
 // Blake3powConfig is the consensus engine configs for proof-of-work based sealing.
@@ -159,12 +180,34 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v, Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v, Engine: %v, Location: %v}",
 		c.ChainID,
 		engine,
+		c.Location,
 	)
 }
 
+// WithLocation returns a copy of the chain config bound to the given location.
+// The shared network configs must never be mutated in place, because every
+// slice running in the process starts from the same genesis config.
+func (c *ChainConfig) WithLocation(location common.Location) *ChainConfig {
+	cpy := *c
+	cpy.Location = common.Location(common.CopyBytes(location))
+	return &cpy
+}
+
+// IsInChainScope reports whether the given address belongs to the chain of the
+// config.
+func (c *ChainConfig) IsInChainScope(b []byte) bool {
+	return c.GetTopology().IsInChainScope(b, c.Location)
+}
+
+// InternalAddress returns the given address as an account of the chain of the
+// config, or common.ErrInvalidScope if it belongs to another chain.
+func (c *ChainConfig) InternalAddress(a common.Address) (common.InternalAddress, error) {
+	return a.InternalAddress(c.Location, c.GetTopology())
+}
+
 func configNumEqual(x, y *big.Int) bool {
 	if x == nil {
 		return y == nil
@@ -212,16 +255,30 @@ func (err *ConfigCompatError) Error() string {
 // Rules is a one time interface meaning that it shouldn't be used in between transition
 // phases.
 type Rules struct {
-	ChainID *big.Int
+	ChainID   *big.Int
+	Location  common.Location
+	Topology  *common.Topology
+	IsPush0   bool
+	IsScope   bool
+	IsRewards bool
+	IsEtxSet  bool
 }
 
-// Rules ensures c's ChainID is not nil.
-func (c *ChainConfig) Rules(num *big.Int) Rules {
+// Rules ensures c's ChainID is not nil. The number is the one of the block in
+// the context of the chain and primeNum its number in prime, which decide the
+// active forks.
+func (c *ChainConfig) Rules(num *big.Int, primeNum *big.Int) Rules {
 	chainID := c.ChainID
 	if chainID == nil {
 		chainID = new(big.Int)
 	}
 	return Rules{
-		ChainID: new(big.Int).Set(chainID),
+		ChainID:   new(big.Int).Set(chainID),
+		Location:  c.Location,
+		Topology:  c.GetTopology(),
+		IsPush0:   c.IsActive(Push0Fork, num, primeNum),
+		IsScope:   c.IsActive(ScopeFork, num, primeNum),
+		IsRewards: c.IsActive(RewardsFork, num, primeNum),
+		IsEtxSet:  c.IsActive(EtxSetFork, num, primeNum),
 	}
 }
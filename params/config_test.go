@@ -17,8 +17,11 @@
 package params
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -43,3 +46,83 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestForkActivation(t *testing.T) {
+	zone := &ChainConfig{
+		Location: common.Location{0, 1},
+		Forks:    map[Fork]*ForkActivation{Push0Fork: {Zone: big.NewInt(10)}},
+	}
+	prime := &ChainConfig{
+		Location: common.Location{0, 1},
+		Forks:    map[Fork]*ForkActivation{Push0Fork: {PrimeBlock: big.NewInt(5)}},
+	}
+	tests := []struct {
+		config        *ChainConfig
+		num, primeNum int64
+		want          bool
+	}{
+		{zone, 9, 100, false},
+		{zone, 10, 0, true},
+		{prime, 100, 4, false},
+		{prime, 0, 5, true},
+		{&ChainConfig{Location: common.Location{0, 1}}, 100, 100, false},
+	}
+	for i, test := range tests {
+		if have := test.config.IsActive(Push0Fork, big.NewInt(test.num), big.NewInt(test.primeNum)); have != test.want {
+			t.Errorf("test %d: active mismatch: have %v, want %v", i, have, test.want)
+		}
+		if have := test.config.Rules(big.NewInt(test.num), big.NewInt(test.primeNum)).IsPush0; have != test.want {
+			t.Errorf("test %d: rules mismatch: have %v, want %v", i, have, test.want)
+		}
+	}
+}
+
+func TestCheckConfigForkOrder(t *testing.T) {
+	defer func(forks []Fork) { Forks = forks }(Forks)
+	Forks = []Fork{"first", "second"}
+
+	tests := []struct {
+		forks map[Fork]*ForkActivation
+		ok    bool
+	}{
+		{nil, true},
+		{map[Fork]*ForkActivation{"first": {Zone: big.NewInt(1)}, "second": {Zone: big.NewInt(1), Region: big.NewInt(2)}}, false},
+		{map[Fork]*ForkActivation{"first": {Zone: big.NewInt(1), Region: big.NewInt(2)}, "second": {Zone: big.NewInt(1)}}, true},
+		{map[Fork]*ForkActivation{"first": {Zone: big.NewInt(2)}, "second": {Zone: big.NewInt(1)}}, false},
+		{map[Fork]*ForkActivation{"second": {Zone: big.NewInt(1)}}, false},
+		{map[Fork]*ForkActivation{"first": {PrimeBlock: big.NewInt(1)}, "second": {PrimeBlock: big.NewInt(1)}}, true},
+		{map[Fork]*ForkActivation{"first": {PrimeBlock: big.NewInt(1)}, "second": {Zone: big.NewInt(1)}}, false},
+		{map[Fork]*ForkActivation{"first": {PrimeBlock: big.NewInt(1), Zone: big.NewInt(1)}}, false},
+		{map[Fork]*ForkActivation{"third": {Zone: big.NewInt(1)}}, false},
+	}
+	for i, test := range tests {
+		err := (&ChainConfig{Forks: test.forks}).CheckConfigForkOrder()
+		if (err == nil) != test.ok {
+			t.Errorf("test %d: error mismatch: have %v, want ok %v", i, err, test.ok)
+		}
+	}
+}
+
+func TestCheckForkCompatible(t *testing.T) {
+	var (
+		stored  = &ChainConfig{Forks: map[Fork]*ForkActivation{Push0Fork: {Prime: big.NewInt(10)}}}
+		later   = &ChainConfig{Forks: map[Fork]*ForkActivation{Push0Fork: {Prime: big.NewInt(20)}}}
+		byPrime = &ChainConfig{Forks: map[Fork]*ForkActivation{Push0Fork: {PrimeBlock: big.NewInt(20)}}}
+	)
+	if err := stored.CheckCompatible(later, 9); err != nil {
+		t.Errorf("rescheduling a future fork: %v", err)
+	}
+	want := &ConfigCompatError{What: "push0 fork prime block", StoredConfig: big.NewInt(10), NewConfig: big.NewInt(20), RewindTo: 9}
+	if err := stored.CheckCompatible(later, 10); !reflect.DeepEqual(err, want) {
+		t.Errorf("rescheduling a past fork: have %v, want %v", err, want)
+	}
+	if err := stored.CheckCompatible(byPrime, 10); err == nil {
+		t.Errorf("moving a past fork to a prime block succeeded")
+	}
+	if err := stored.CheckPrimeCompatible(byPrime, 19); err != nil {
+		t.Errorf("scheduling a future prime block fork: %v", err)
+	}
+	if err := stored.CheckPrimeCompatible(byPrime, 20); err == nil {
+		t.Errorf("scheduling a past prime block fork succeeded")
+	}
+}
@@ -0,0 +1,207 @@
+package params
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// Fork names a protocol upgrade.
+type Fork string
+
+const (
+	// Push0Fork adds the PUSH0 instruction to the EVM (EIP-3855).
+	Push0Fork Fork = "push0"
+
+	// ScopeFork binds transactions and blocks to the zone they are included
+	// in: the signer no longer recovers senders outside of the zone, and zone
+	// headers must pay their coinbase inside of the zone.
+	ScopeFork Fork = "scope"
+
+	// RewardsFork pays the block rewards of the chain config instead of the
+	// launch rewards.
+	RewardsFork Fork = "rewards"
+
+	// EtxSetFork commits zone headers to the root of the EtxSet their block is
+	// executed against, so a node can verify a set it was given by a peer. As
+	// the set is the one at the parent, the fork applies to the blocks built
+	// on top of an upgraded block.
+	EtxSetFork Fork = "etxset"
+)
+
+// Forks are the protocol upgrades known to the node, in the order they must
+// activate.
+var Forks = []Fork{
+	Push0Fork,
+	ScopeFork,
+	RewardsFork,
+	EtxSetFork,
+}
+
+// ForkActivation schedules a fork. A chain activates the fork at the block
+// number given for its context, so each chain of a context upgrades at its own
+// height. Alternatively, the fork activates on every chain of the hierarchy at
+// once from a prime block: a block is upgraded if the prime number it carries
+// is at least PrimeBlock, which is the case for the prime block and all blocks
+// of the hierarchy built after its parent.
+type ForkActivation struct {
+	Prime      *big.Int `json:"prime,omitempty"`
+	Region     *big.Int `json:"region,omitempty"`
+	Zone       *big.Int `json:"zone,omitempty"`
+	PrimeBlock *big.Int `json:"primeBlock,omitempty"`
+}
+
+// Number returns the block number the fork activates at on the chains of the
+// given context, or nil if it is not scheduled by number for that context.
+func (a *ForkActivation) Number(ctx int) *big.Int {
+	if a == nil {
+		return nil
+	}
+	switch ctx {
+	case common.PRIME_CTX:
+		return a.Prime
+	case common.REGION_CTX:
+		return a.Region
+	case common.ZONE_CTX:
+		return a.Zone
+	}
+	return nil
+}
+
+// Active reports whether the fork is active for a block of a chain of the given
+// context, with the given number in that context and in prime.
+func (a *ForkActivation) Active(ctx int, num, primeNum *big.Int) bool {
+	if a == nil {
+		return false
+	}
+	if a.PrimeBlock != nil {
+		return isForked(a.PrimeBlock, primeNum)
+	}
+	return isForked(a.Number(ctx), num)
+}
+
+// validate checks the activation is scheduled either by number or by prime
+// block, but not both.
+func (a *ForkActivation) validate() error {
+	if a.PrimeBlock == nil {
+		return nil
+	}
+	if a.Prime != nil || a.Region != nil || a.Zone != nil {
+		return errors.New("scheduled both by prime block and by number")
+	}
+	return nil
+}
+
+// activation returns the activation of a fork, or nil if it is not scheduled.
+func (c *ChainConfig) activation(fork Fork) *ForkActivation {
+	if c == nil {
+		return nil
+	}
+	return c.Forks[fork]
+}
+
+// IsActive reports whether a fork is active for a block of the chain, given its
+// number in the context of the chain and in prime.
+func (c *ChainConfig) IsActive(fork Fork, num, primeNum *big.Int) bool {
+	return c.activation(fork).Active(c.Location.Context(), num, primeNum)
+}
+
+// CheckConfigForkOrder checks that every scheduled fork is known to the node
+// and that no fork activates before the ones preceding it.
+func (c *ChainConfig) CheckConfigForkOrder() error {
+	known := make(map[Fork]bool, len(Forks))
+	for _, fork := range Forks {
+		known[fork] = true
+	}
+	for fork, activation := range c.Forks {
+		if !known[fork] {
+			return fmt.Errorf("unknown fork %q", fork)
+		}
+		if err := activation.validate(); err != nil {
+			return fmt.Errorf("fork %q %v", fork, err)
+		}
+	}
+	var last Fork
+	for _, fork := range Forks {
+		cur := c.activation(fork)
+		if cur == nil {
+			last = fork
+			continue
+		}
+		if last != "" {
+			prev := c.activation(last)
+			if prev == nil {
+				return fmt.Errorf("fork %q scheduled while %q is not", fork, last)
+			}
+			if (prev.PrimeBlock == nil) != (cur.PrimeBlock == nil) {
+				return fmt.Errorf("fork %q scheduled differently than %q", fork, last)
+			}
+			if cur.PrimeBlock != nil && prev.PrimeBlock.Cmp(cur.PrimeBlock) > 0 {
+				return fmt.Errorf("fork %q prime block %v before %q prime block %v", fork, cur.PrimeBlock, last, prev.PrimeBlock)
+			}
+			for ctx := common.PRIME_CTX; ctx < common.HierarchyDepth; ctx++ {
+				if next := cur.Number(ctx); next != nil && (prev.Number(ctx) == nil || prev.Number(ctx).Cmp(next) > 0) {
+					return fmt.Errorf("fork %q %s block %v before %q %s block %v", fork, strings.ToLower(common.OrderToString(ctx)), next, last, strings.ToLower(common.OrderToString(ctx)), prev.Number(ctx))
+				}
+			}
+		}
+		last = fork
+	}
+	return nil
+}
+
+// CheckCompatible checks whether scheduled fork transitions have been imported
+// with a mismatching chain configuration. The height is the number of the head
+// of the chain in the context of the node.
+func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
+	ctx := common.PRIME_CTX
+	if c != nil {
+		ctx = c.Location.Context()
+	}
+	head := new(big.Int).SetUint64(height)
+	for _, fork := range Forks {
+		stored, next := c.activation(fork).Number(ctx), newcfg.activation(fork).Number(ctx)
+		if isForkIncompatible(stored, next, head) {
+			return newCompatError(fmt.Sprintf("%s fork %s block", fork, strings.ToLower(common.OrderToString(ctx))), stored, next)
+		}
+	}
+	return nil
+}
+
+// CheckPrimeCompatible checks whether fork transitions scheduled by prime block
+// have been imported with a mismatching chain configuration. The height is the
+// prime number of the head of the chain.
+func (c *ChainConfig) CheckPrimeCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
+	head := new(big.Int).SetUint64(height)
+	for _, fork := range Forks {
+		var stored, next *big.Int
+		if a := c.activation(fork); a != nil {
+			stored = a.PrimeBlock
+		}
+		if a := newcfg.activation(fork); a != nil {
+			next = a.PrimeBlock
+		}
+		if isForkIncompatible(stored, next, head) {
+			return newCompatError(fmt.Sprintf("%s fork prime block", fork), stored, next)
+		}
+	}
+	return nil
+}
+
+// isForkIncompatible returns true if a fork scheduled at s1 cannot be
+// rescheduled to block s2 because head is already past the fork.
+func isForkIncompatible(s1, s2, head *big.Int) bool {
+	return (isForked(s1, head) || isForked(s2, head)) && !configNumEqual(s1, s2)
+}
+
+// isForked returns whether a fork scheduled at block s is active at the given
+// head block.
+func isForked(s, head *big.Int) bool {
+	if s == nil || head == nil {
+		return false
+	}
+	return s.Cmp(head) <= 0
+}
@@ -28,22 +28,20 @@ const (
 	MinGasLimit             uint64 = 5000000 // Minimum the gas limit may ever be.
 	GenesisGasLimit         uint64 = 5000000 // Gas limit of the Genesis block.
 
-	MaximumExtraDataSize  uint64 = 32                                                       // Maximum size extra data may be after Genesis.
-	ExpByteGas            uint64 = 10                                                       // Times ceil(log256(exponent)) for the EXP instruction.
-	CallValueTransferGas  uint64 = 9000                                                     // Paid for CALL when the value transfer is non-zero.
-	CallNewAccountGas     uint64 = 25000                                                    // Paid for CALL when the destination address didn't exist prior.
-	TxGas                 uint64 = 21000                                                    // Per transaction not creating a contract. NOTE: Not payable on data of calls between transactions.
-	TxGasContractCreation uint64 = 53000                                                    // Per transaction that creates a contract. NOTE: Not payable on data of calls between transactions.
-	TxDataZeroGas         uint64 = 4                                                        // Per byte of data attached to a transaction that equals zero. NOTE: Not payable on data of calls between transactions.
-	QuadCoeffDiv          uint64 = 512                                                      // Divisor for the quadratic particle of the memory cost equation.
-	LogDataGas            uint64 = 8                                                        // Per byte in a LOG* operation's data.
-	CallStipend           uint64 = 2300                                                     // Free gas given at beginning of call.
-	ETXGas                uint64 = 21000                                                    // Per ETX generated by opETX or normal cross-chain transfer.
-	ETXRegionMaxFraction  int    = common.NumRegionsInPrime * (common.NumZonesInRegion - 1) // The maximum fraction of transactions for cross-region ETXs
-	ETXPrimeMaxFraction   int    = common.NumRegionsInPrime * common.NumZonesInRegion       // The maximum fraction of transactions for cross-prime ETXs
-	ETXRLimitMin          int    = 10                                                       // Minimum possible cross-region ETX limit
-	ETXPLimitMin          int    = 10                                                       // Minimum possible cross-prime ETX limit
-	EtxExpirationAge      uint64 = 100                                                      // Number of blocks an ETX may wait for inclusion at the destination
+	MaximumExtraDataSize  uint64 = 32    // Maximum size extra data may be after Genesis.
+	ExpByteGas            uint64 = 10    // Times ceil(log256(exponent)) for the EXP instruction.
+	CallValueTransferGas  uint64 = 9000  // Paid for CALL when the value transfer is non-zero.
+	CallNewAccountGas     uint64 = 25000 // Paid for CALL when the destination address didn't exist prior.
+	TxGas                 uint64 = 21000 // Per transaction not creating a contract. NOTE: Not payable on data of calls between transactions.
+	TxGasContractCreation uint64 = 53000 // Per transaction that creates a contract. NOTE: Not payable on data of calls between transactions.
+	TxDataZeroGas         uint64 = 4     // Per byte of data attached to a transaction that equals zero. NOTE: Not payable on data of calls between transactions.
+	QuadCoeffDiv          uint64 = 512   // Divisor for the quadratic particle of the memory cost equation.
+	LogDataGas            uint64 = 8     // Per byte in a LOG* operation's data.
+	CallStipend           uint64 = 2300  // Free gas given at beginning of call.
+	ETXGas                uint64 = 21000 // Per ETX generated by opETX or normal cross-chain transfer.
+	ETXRLimitMin          int    = 10    // Minimum possible cross-region ETX limit
+	ETXPLimitMin          int    = 10    // Minimum possible cross-prime ETX limit
+	EtxExpirationAge      uint64 = 100   // Number of blocks an ETX may wait for inclusion at the destination
 
 	Sha3Gas     uint64 = 30 // Once per SHA3 operation.
 	Sha3WordGas uint64 = 6  // Once per word of the SHA3 operation's data.
@@ -154,3 +152,18 @@ var (
 	LocalDurationLimit            = big.NewInt(2)     // The decision boundary on the blocktime duration used to determine whether difficulty should go up or not.
 	TimeFactor                    = big.NewInt(7)
 )
+
+// ETXRegionMaxFraction returns the maximum fraction of transactions for
+// cross-region ETXs, which depends on the topology of the hierarchy.
+func ETXRegionMaxFraction(topology *common.Topology) int {
+	if fraction := topology.Regions * (topology.Zones - 1); fraction > 0 {
+		return fraction
+	}
+	return 1
+}
+
+// ETXPrimeMaxFraction returns the maximum fraction of transactions for
+// cross-prime ETXs, which depends on the topology of the hierarchy.
+func ETXPrimeMaxFraction(topology *common.Topology) int {
+	return topology.Regions * topology.Zones
+}
@@ -0,0 +1,46 @@
+package params
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// RewardsConfig is the block reward of the hierarchy and how it is split
+// between the levels of the hierarchy. Within a level, the share of the reward
+// is split evenly between its chains and the blocks they mine for every block
+// of their dominant chain.
+type RewardsConfig struct {
+	Reward     *big.Int                      `json:"reward"`     // Reward of the hierarchy for a block of prime
+	Shares     [common.HierarchyDepth]uint64 `json:"shares"`     // Shares of the reward of prime, the regions and the zones
+	TimeFactor uint64                        `json:"timeFactor"` // Number of blocks of a chain for every block of its dominant chain
+}
+
+// DefaultRewards split a reward of 5000 Quai evenly between the levels.
+var DefaultRewards = &RewardsConfig{
+	Reward:     new(big.Int).Mul(big.NewInt(5000), big.NewInt(Ether)),
+	Shares:     [common.HierarchyDepth]uint64{1, 1, 1},
+	TimeFactor: 10,
+}
+
+// Validate checks that the rewards can be split.
+func (r *RewardsConfig) Validate() error {
+	if r.Reward == nil || r.Reward.Sign() < 0 {
+		return errors.New("rewards need a non-negative reward")
+	}
+	var total uint64
+	for _, share := range r.Shares {
+		if total+share < total {
+			return errors.New("rewards shares overflow")
+		}
+		total += share
+	}
+	if total == 0 {
+		return errors.New("rewards need at least one non-zero share")
+	}
+	if r.TimeFactor == 0 {
+		return errors.New("rewards need a non-zero time factor")
+	}
+	return nil
+}
@@ -138,10 +138,10 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 	if head.EtxHash() != types.EmptyRootHash && len(body.ExtTransactions) == 0 {
 		return nil, fmt.Errorf("server returned empty external transaction list but block header indicates transactions")
 	}
-	if head.ManifestHash() == types.EmptyRootHash && len(body.SubManifest) > 0 {
+	if head.ManifestHash(common.PRIME_CTX) == types.EmptyRootHash && len(body.SubManifest) > 0 {
 		return nil, fmt.Errorf("server returned non-empty subordinate manifest but block header indicates no transactions")
 	}
-	if head.ManifestHash() != types.EmptyRootHash && len(body.SubManifest) == 0 {
+	if head.ManifestHash(common.PRIME_CTX) != types.EmptyRootHash && len(body.SubManifest) == 0 {
 		return nil, fmt.Errorf("server returned empty subordinate manifest but block header indicates transactions")
 	}
 	// Load uncles because they are not included in the block response.
@@ -493,7 +493,9 @@ func (ec *Client) GetPendingHeader(ctx context.Context) (*types.Header, error) {
 	return pendingHeader, nil
 }
 
-// ReceiveMinedHeader sends a mined block back to the node
+// ReceiveMinedHeader sends a mined block back to the node. The method is only
+// served by the authenticated endpoint, so the client must be created from an
+// rpc.Client dialed with rpc.DialContextWithAuth.
 func (ec *Client) ReceiveMinedHeader(ctx context.Context, header *types.Header) error {
 	data := header.RPCMarshalHeader()
 	return ec.c.CallContext(ctx, nil, "quai_receiveMinedHeader", data)
@@ -560,6 +562,36 @@ func (ec *Client) EstimateGas(ctx context.Context, msg quai.CallMsg) (uint64, er
 	return uint64(hex), nil
 }
 
+// EstimateExternalGas estimates the gas an external transaction needs in its
+// destination zone. The calldata in msg is executed against the recipient in
+// the other zone.
+func (ec *Client) EstimateExternalGas(ctx context.Context, msg quai.CallMsg) (uint64, error) {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["etxData"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.AccessList != nil {
+		arg["etxAccessList"] = msg.AccessList
+	}
+	var result struct {
+		Gas         hexutil.Uint64  `json:"gas"`
+		ETXGasLimit *hexutil.Uint64 `json:"etxGasLimit"`
+	}
+	if err := ec.c.CallContext(ctx, &result, "quai_estimateEtxGas", arg); err != nil {
+		return 0, err
+	}
+	if result.ETXGasLimit == nil {
+		return 0, errors.New("recipient is not in another zone")
+	}
+	return uint64(*result.ETXGasLimit), nil
+}
+
 // SendTransaction injects a signed transaction into the pending pool for execution.
 //
 // If the transaction was a contract creation use the TransactionReceipt method to get the
@@ -580,6 +612,14 @@ func toBlockNumArg(number *big.Int) string {
 	if number.Cmp(pending) == 0 {
 		return "pending"
 	}
+	if number.IsInt64() {
+		switch rpc.BlockNumber(number.Int64()) {
+		case rpc.SafeBlockNumber:
+			return "safe"
+		case rpc.FinalizedBlockNumber:
+			return "finalized"
+		}
+	}
 	return hexutil.EncodeBig(number)
 }
 
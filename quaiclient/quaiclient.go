@@ -22,7 +22,9 @@ import (
 	"encoding/json"
 	"time"
 
+	quai "github.com/dominant-strategies/go-quai"
 	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/log"
 	"github.com/dominant-strategies/go-quai/rpc"
@@ -35,38 +37,46 @@ type Client struct {
 	c *rpc.Client
 }
 
-// Dial connects a client to the given URL.
-func Dial(rawurl string) (*Client, error) {
-	return DialContext(context.Background(), rawurl)
+// Dial connects a client to the given URL. If a secret is given, every request
+// is authenticated with a JWT signed by it, as required by the coordination
+// APIs of the dom and sub chains.
+func Dial(rawurl string, jwtSecret []byte) (*Client, error) {
+	return DialContext(context.Background(), rawurl, jwtSecret)
 }
 
-func DialContext(ctx context.Context, rawurl string) (*Client, error) {
-	connectStatus := false
+// DialContext connects a client to the given URL, retrying with an exponential
+// back-off until the connection succeeds or the context is cancelled.
+func DialContext(ctx context.Context, rawurl string, jwtSecret []byte) (*Client, error) {
 	attempts := 0
-
-	var c *rpc.Client
-	var err error
-	for !connectStatus {
-		c, err = rpc.DialContext(ctx, rawurl)
+	delaySecs := int64(1)
+	for {
+		var (
+			c   *rpc.Client
+			err error
+		)
+		if jwtSecret != nil {
+			c, err = rpc.DialContextWithAuth(ctx, rawurl, rpc.NewJWTAuth(jwtSecret))
+		} else {
+			c, err = rpc.DialContext(ctx, rawurl)
+		}
 		if err == nil {
-			break
+			return NewClient(c), nil
 		}
-
 		attempts += 1
-		// exponential back-off implemented
-		// delaySecs := int64(math.Floor((math.Pow(2, float64(attempts)) - 1) * 0.5))
-		delaySecs := int64(1)
-		if delaySecs > exponentialBackoffCeilingSecs {
-			return nil, err
-		}
 
-		// should only get here if the ffmpeg record stream process dies
-		log.Warn("Attempting to connect to go-quai node. Waiting and retrying...", "attempts", attempts, "delay", delaySecs, "url", rawurl)
+		log.Warn("Attempting to connect to go-quai node. Waiting and retrying...", "attempts", attempts, "delay", delaySecs, "url", rawurl, "err", err)
 
-		time.Sleep(time.Duration(delaySecs) * time.Second)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(delaySecs) * time.Second):
+		}
+		// exponential back-off, capped at the ceiling
+		delaySecs *= 2
+		if delaySecs > exponentialBackoffCeilingSecs {
+			delaySecs = exponentialBackoffCeilingSecs
+		}
 	}
-
-	return NewClient(c), nil
 }
 
 // NewClient creates a client that uses the given RPC client.
@@ -111,16 +121,22 @@ func (ec *Client) Append(ctx context.Context, header *types.Header, domPendingHe
 	return aReturns.Etxs, aReturns.SubReorg, nil
 }
 
-func (ec *Client) SubRelayPendingHeader(ctx context.Context, pendingHeader types.PendingHeader, location common.Location) {
+func (ec *Client) SubRelayPendingHeader(ctx context.Context, pendingHeader types.PendingHeader, location common.Location) error {
 	data := map[string]interface{}{"Header": pendingHeader.Header.RPCMarshalHeader()}
 	data["Termini"] = pendingHeader.Termini
 	data["Location"] = location
 
-	ec.c.CallContext(ctx, nil, "quai_subRelayPendingHeader", data)
+	return ec.c.CallContext(ctx, nil, "quai_subRelayPendingHeader", data)
+}
+
+// ReceiveMinedHeader hands a header mined on top of the pending header of a
+// subordinate chain to the node, if the header is coincident with its chain.
+func (ec *Client) ReceiveMinedHeader(ctx context.Context, header *types.Header) error {
+	return ec.c.CallContext(ctx, nil, "quai_receiveMinedHeader", header.RPCMarshalHeader())
 }
 
-func (ec *Client) NewGenesisPendingHeader(ctx context.Context, header *types.Header) {
-	ec.c.CallContext(ctx, nil, "quai_newGenesisPendingHeader", header.RPCMarshalHeader())
+func (ec *Client) NewGenesisPendingHeader(ctx context.Context, header *types.Header) error {
+	return ec.c.CallContext(ctx, nil, "quai_newGenesisPendingHeader", header.RPCMarshalHeader())
 }
 
 // GetManifest will get the block manifest ending with the parent hash
@@ -137,6 +153,16 @@ func (ec *Client) GetManifest(ctx context.Context, blockHash common.Hash) (types
 	return manifest, nil
 }
 
+// GetTerminiByHash returns the termini stored for the block with the given
+// hash, or nil if the node never appended it
+func (ec *Client) GetTerminiByHash(ctx context.Context, hash common.Hash) ([]common.Hash, error) {
+	var termini []common.Hash
+	if err := ec.c.CallContext(ctx, &termini, "quai_getTerminiByHash", hash); err != nil {
+		return nil, err
+	}
+	return termini, nil
+}
+
 func (ec *Client) SendPendingEtxsToDom(ctx context.Context, pEtxs types.PendingEtxs) error {
 	fields := make(map[string]interface{})
 	fields["header"] = pEtxs.Header.RPCMarshalHeader()
@@ -164,6 +190,36 @@ func (ec *Client) GenerateRecoveryPendingHeader(ctx context.Context, pendingHead
 	return ec.c.CallContext(ctx, nil, "quai_generateRecoveryPendingHeader", fields)
 }
 
+func (ec *Client) UpdateBlockLists(ctx context.Context, update types.BlockListUpdate) error {
+	return ec.c.CallContext(ctx, nil, "quai_updateBlockLists", update)
+}
+
+// EstimateExternalGas estimates the gas an external transaction needs in its
+// destination zone. The node forwards the request towards the destination.
+func (ec *Client) EstimateExternalGas(ctx context.Context, msg quai.CallMsg) (uint64, error) {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
+	var hex hexutil.Uint64
+	if err := ec.c.CallContext(ctx, &hex, "quai_estimateExternalGas", arg); err != nil {
+		return 0, err
+	}
+	return uint64(hex), nil
+}
+
 func (ec *Client) HeaderByHash(ctx context.Context, hash common.Hash) *types.Header {
 	var raw json.RawMessage
 	ec.c.CallContext(ctx, &raw, "quai_getHeaderByHash", hash)
@@ -240,7 +240,7 @@ func (s *Service) Stop() error {
 // loop keeps trying to connect to the netstats server, reporting chain events
 // until termination.
 func (s *Service) loop(chainHeadCh chan core.ChainHeadEvent, chainSideCh chan core.ChainSideEvent) {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.backend.ChainConfig().Location.Context()
 	// Start a goroutine that exhausts the subscriptions to avoid events piling up
 	var (
 		quitCh = make(chan struct{})
@@ -506,7 +506,7 @@ func (s *Service) login(conn *connWrapper) error {
 			OsVer:    runtime.GOARCH,
 			Client:   "0.1.1",
 			History:  true,
-			Chain:    common.NodeLocation.Name(),
+			Chain:    s.backend.ChainConfig().Location.Name(),
 			ChainID:  s.chainID.Uint64(),
 		},
 		Secret: s.pass,
@@ -529,7 +529,7 @@ func (s *Service) login(conn *connWrapper) error {
 // This should only be used on reconnects or rarely to avoid overloading the
 // server. Use the individual methods for reporting subscribed events.
 func (s *Service) report(conn *connWrapper) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.backend.ChainConfig().Location.Context()
 	if nodeCtx == common.ZONE_CTX {
 		if err := s.reportPending(conn); err != nil {
 			return err
@@ -643,17 +643,18 @@ func (s uncleStats) MarshalJSON() ([]byte, error) {
 }
 
 func (s *Service) computeAvgGasPerSec(block *types.Block) int64 {
+	nodeCtx := s.backend.ChainConfig().Location.Context()
 	var parentTime, parentGasPerSec int64
-	if parentCached, ok := s.gasLookupCache.Get(block.ParentHash()); ok {
+	if parentCached, ok := s.gasLookupCache.Get(block.ParentHash(nodeCtx)); ok {
 		parentTime = parentCached.(blockAvgGasPerSecCacheDto).Time
 		parentGasPerSec = parentCached.(blockAvgGasPerSecCacheDto).AvgGasPerSec
 	} else {
-		log.Warn(fmt.Sprintf("computeGas: block %d's parent not found in cache: %s", block.Number(), block.ParentHash().String()))
+		log.Warn(fmt.Sprintf("computeGas: block %d's parent not found in cache: %s", block.Number(nodeCtx), block.ParentHash(nodeCtx).String()))
 		fullBackend, ok := s.backend.(fullNodeBackend)
 		if ok {
-			parent, err := fullBackend.BlockByNumber(context.Background(), rpc.BlockNumber(block.NumberU64()-1))
+			parent, err := fullBackend.BlockByNumber(context.Background(), rpc.BlockNumber(block.NumberU64(nodeCtx)-1))
 			if err != nil {
-				log.Error("error getting parent block %s: %s", block.ParentHash().String(), err.Error())
+				log.Error("error getting parent block %s: %s", block.ParentHash(nodeCtx).String(), err.Error())
 				return c_statsErrorValue
 			}
 			parentTime = int64(parent.Time())
@@ -684,18 +685,19 @@ func (s *Service) computeAvgGasPerSec(block *types.Block) int64 {
 }
 
 func (s *Service) computeTps(block *types.Block) int64 {
+	nodeCtx := s.backend.ChainConfig().Location.Context()
 	var parentTime, parentTps int64
 
-	if parentCached, ok := s.tpsLookupCache.Get(block.ParentHash()); ok {
+	if parentCached, ok := s.tpsLookupCache.Get(block.ParentHash(nodeCtx)); ok {
 		parentTime = parentCached.(blockTpsCacheDto).Time
 		parentTps = parentCached.(blockTpsCacheDto).Tps
 	} else {
-		log.Warn(fmt.Sprintf("block %d's parent not found in cache: %s", block.Number(), block.ParentHash().String()))
+		log.Warn(fmt.Sprintf("block %d's parent not found in cache: %s", block.Number(nodeCtx), block.ParentHash(nodeCtx).String()))
 		fullBackend, ok := s.backend.(fullNodeBackend)
 		if ok {
-			parent, err := fullBackend.BlockByNumber(context.Background(), rpc.BlockNumber(block.NumberU64()-1))
+			parent, err := fullBackend.BlockByNumber(context.Background(), rpc.BlockNumber(block.NumberU64(nodeCtx)-1))
 			if err != nil {
-				log.Error("error getting parent block %s: %s", block.ParentHash().String(), err.Error())
+				log.Error("error getting parent block %s: %s", block.ParentHash(nodeCtx).String(), err.Error())
 				return c_statsErrorValue
 			}
 			parentTime = int64(parent.Time())
@@ -728,7 +730,8 @@ func (s *Service) computeTps(block *types.Block) int64 {
 
 // reportSideBlock retrieves the current chain side event and reports it to the stats server.
 func (s *Service) reportSideBlock(conn *connWrapper, block *types.Block) error {
-	log.Trace("Sending new side block to quaistats", "number", block.Number(), "hash", block.Hash())
+	nodeCtx := s.backend.ChainConfig().Location.Context()
+	log.Trace("Sending new side block to quaistats", "number", block.Number(nodeCtx), "hash", block.Hash())
 
 	stats := map[string]interface{}{
 		"id":        s.node,
@@ -761,6 +764,7 @@ func (s *Service) reportBlock(conn *connWrapper, block *types.Block) error {
 // assembleBlockStats retrieves any required metadata to report a single block
 // and assembles the block stats. If block is nil, the current head is processed.
 func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
+	nodeCtx := s.backend.ChainConfig().Location.Context()
 	// Gather the block infos from the local blockchain
 	var (
 		header  *types.Header
@@ -786,9 +790,9 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 	appendTime := block.GetAppendTime()
 
 	return &blockStats{
-		Number:        header.Number(),
+		Number:        header.Number(nodeCtx),
 		Hash:          header.Hash(),
-		ParentHash:    header.ParentHash(),
+		ParentHash:    header.ParentHash(nodeCtx),
 		Timestamp:     new(big.Int).SetUint64(header.Time()),
 		Miner:         author,
 		GasUsed:       header.GasUsed(),
@@ -799,10 +803,10 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 		TxHash:        header.TxHash(),
 		EtxHash:       header.EtxHash(),
 		EtxRollupHash: header.EtxRollupHash(),
-		ManifestHash:  header.ManifestHash(),
+		ManifestHash:  header.ManifestHash(nodeCtx),
 		Root:          header.Root(),
 		Uncles:        uncles,
-		Chain:         common.NodeLocation.Name(),
+		Chain:         s.backend.ChainConfig().Location.Name(),
 		ChainID:       s.chainID.Uint64(),
 		Tps:           tps,
 		AppendTime:    appendTime,
@@ -813,6 +817,7 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 // reportHistory retrieves the most recent batch of blocks and reports it to the
 // stats server.
 func (s *Service) reportHistory(conn *connWrapper, list []uint64) error {
+	nodeCtx := s.backend.ChainConfig().Location.Context()
 	// Figure out the indexes that need reporting
 	indexes := make([]uint64, 0, historyUpdateRange)
 	if len(list) > 0 {
@@ -820,7 +825,7 @@ func (s *Service) reportHistory(conn *connWrapper, list []uint64) error {
 		indexes = append(indexes, list...)
 	} else {
 		// No indexes requested, send back the top ones
-		head := s.backend.CurrentHeader().Number().Int64()
+		head := s.backend.CurrentHeader().Number(nodeCtx).Int64()
 		start := head - historyUpdateRange + 1
 		if start < 0 {
 			start = 0
@@ -918,7 +923,7 @@ type nodeStats struct {
 // reportStats retrieves various stats about the node at the networking and
 // mining layer and reports it to the stats server.
 func (s *Service) reportStats(conn *connWrapper) error {
-	nodeCtx := common.NodeLocation.Context()
+	nodeCtx := s.backend.ChainConfig().Location.Context()
 	// Gather the syncing and mining infos from the local miner instance
 	var (
 		mining   bool
@@ -931,7 +936,7 @@ func (s *Service) reportStats(conn *connWrapper) error {
 	header := s.backend.CurrentHeader()
 	if ok {
 		sync := fullBackend.Downloader().Progress()
-		syncing = fullBackend.CurrentHeader().Number().Uint64() >= sync.HighestBlock
+		syncing = fullBackend.CurrentHeader().Number(nodeCtx).Uint64() >= sync.HighestBlock
 
 		if nodeCtx == common.ZONE_CTX {
 			price, _ := fullBackend.SuggestGasTipCap(context.Background())
@@ -942,7 +947,7 @@ func (s *Service) reportStats(conn *connWrapper) error {
 		}
 	} else {
 		sync := s.backend.Downloader().Progress()
-		syncing = header.Number().Uint64() >= sync.HighestBlock
+		syncing = header.Number(nodeCtx).Uint64() >= sync.HighestBlock
 	}
 
 	var cpuPercentUsed float32
@@ -1007,9 +1012,9 @@ func (s *Service) reportStats(conn *connWrapper) error {
 			GasPrice:         gasprice,
 			Syncing:          syncing,
 			Uptime:           100,
-			Chain:            common.NodeLocation.Name(),
+			Chain:            s.backend.ChainConfig().Location.Name(),
 			ChainID:          s.chainID.Uint64(),
-			LatestHeight:     header.Number().Uint64(),
+			LatestHeight:     header.Number(nodeCtx).Uint64(),
 			LatestHash:       header.Hash().String(),
 			CPUPercentUsage:  cpuPercentUsed,
 			CPUUsage:         cpuUsed,
@@ -1068,7 +1073,7 @@ func (s *Service) reportPeers(conn *connWrapper) error {
 			SoftwareName:  peer.Fullname(),
 			LocalAddress:  peer.LocalAddr().String(),
 			RemoteAddress: peer.RemoteAddr().String(),
-			Chain:         common.NodeLocation.Name(),
+			Chain:         s.backend.ChainConfig().Location.Name(),
 			ConnectedTime: peer.ConnectedTime(),
 		}
 
@@ -1092,7 +1097,7 @@ func (s *Service) reportPeers(conn *connWrapper) error {
 	peers := map[string]interface{}{
 		"id": s.node,
 		"peers": &peerStats{
-			Chain:    common.NodeLocation.Name(),
+			Chain:    s.backend.ChainConfig().Location.Name(),
 			ChainID:  s.chainID.Uint64(),
 			Count:    len(srvPeers),
 			PeerData: allPeerData,
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -152,7 +153,7 @@ func (op *requestOp) wait(ctx context.Context, c *Client) (*jsonrpcMessage, erro
 //
 // The currently supported URL schemes are "http", "https", "ws" and "wss". If rawurl is a
 // file name with no URL scheme, a local socket connection is established using UNIX
-// domain sockets on supported platforms and named pipes on Windows. If you want to
+// domain sockets on supported platforms. If you want to
 // configure transport options, use DialHTTP, DialWebsocket.
 //
 // For websocket connections, the origin is set to the local host name.
@@ -178,11 +179,30 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 		return DialWebsocket(ctx, rawurl, "")
 	case "stdio":
 		return DialStdIO(ctx)
+	case "":
+		return DialIPC(ctx, rawurl)
 	default:
 		return nil, fmt.Errorf("no known transport for URL scheme %q", u.Scheme)
 	}
 }
 
+// DialContextWithAuth creates a new RPC client, just like DialContext, which
+// authenticates itself with every HTTP request or websocket handshake.
+func DialContextWithAuth(ctx context.Context, rawurl string, auth HTTPAuth) (*Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return dialHTTP(rawurl, new(http.Client), auth)
+	case "ws", "wss":
+		return dialWebsocket(ctx, rawurl, "", defaultWebsocketDialer(), auth)
+	default:
+		return nil, fmt.Errorf("no known authenticated transport for URL scheme %q", u.Scheme)
+	}
+}
+
 // Client retrieves the client from the context, if any. This can be used to perform
 // 'reverse calls' in a handler method.
 func ClientFromContext(ctx context.Context) (*Client, bool) {
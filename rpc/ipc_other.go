@@ -0,0 +1,19 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package rpc
+
+import (
+	"context"
+	"net"
+)
+
+// ipcListen reports that IPC is not available.
+func ipcListen(endpoint string) (net.Listener, error) {
+	return nil, ErrIPCUnsupported
+}
+
+// newIPCConnection reports that IPC is not available.
+func newIPCConnection(ctx context.Context, endpoint string) (net.Conn, error) {
+	return nil, ErrIPCUnsupported
+}
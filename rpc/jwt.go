@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// jwtExpiryTimeout is how far the issuance time of a token may be off the local
+// clock before it is rejected.
+const jwtExpiryTimeout = 60 * time.Second
+
+var (
+	errMissingToken    = errors.New("missing token")
+	errMalformedToken  = errors.New("malformed token")
+	errInvalidTokenAlg = errors.New("unsupported token algorithm")
+	errInvalidTokenSig = errors.New("invalid token signature")
+	errMissingIssuedAt = errors.New("missing issued-at")
+	errStaleToken      = errors.New("stale token")
+	errFutureToken     = errors.New("future token")
+)
+
+// HTTPAuth is a function which sets the authentication headers of a request.
+// It is called for every HTTP request, and for every websocket handshake.
+type HTTPAuth func(h http.Header) error
+
+// jwtHeader is the fixed header of the HS256 tokens used for authentication.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// jwtClaims are the claims of an authentication token. Only the issuance time
+// is required, which limits the lifetime of a token.
+type jwtClaims struct {
+	IssuedAt *int64 `json:"iat"`
+}
+
+// NewJWTAuth creates an HTTPAuth which sends a freshly signed token with every
+// request, using the given shared secret.
+func NewJWTAuth(secret []byte) HTTPAuth {
+	return func(h http.Header) error {
+		token, err := signJWT(secret, time.Now())
+		if err != nil {
+			return err
+		}
+		h.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// signJWT creates an HS256 token issued at the given time.
+func signJWT(secret []byte, now time.Time) (string, error) {
+	iat := now.Unix()
+	claims, err := json.Marshal(&jwtClaims{IssuedAt: &iat})
+	if err != nil {
+		return "", err
+	}
+	payload := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return payload + "." + jwtSignature(secret, payload), nil
+}
+
+// jwtSignature returns the encoded HS256 signature of a token payload.
+func jwtSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyJWT checks that a request carries a bearer token signed with the given
+// secret, and that the token was issued recently.
+func VerifyJWT(secret []byte, r *http.Request, now time.Time) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return errMissingToken
+	}
+	return verifyJWT(secret, strings.TrimPrefix(auth, "Bearer "), now)
+}
+
+func verifyJWT(secret []byte, token string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errMalformedToken
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errMalformedToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return errMalformedToken
+	}
+	if header.Alg != "HS256" {
+		return errInvalidTokenAlg
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(jwtSignature(secret, parts[0]+"."+parts[1]))) {
+		return errInvalidTokenSig
+	}
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errMalformedToken
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return errMalformedToken
+	}
+	if claims.IssuedAt == nil {
+		return errMissingIssuedAt
+	}
+	issued := time.Unix(*claims.IssuedAt, 0)
+	if issued.Before(now.Add(-jwtExpiryTimeout)) {
+		return errStaleToken
+	}
+	if issued.After(now.Add(jwtExpiryTimeout)) {
+		return errFutureToken
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWTVerify(t *testing.T) {
+	var (
+		secret = []byte("0123456789abcdef0123456789abcdef")
+		now    = time.Unix(1700000000, 0)
+	)
+	sign := func(secret []byte, issued time.Time) string {
+		token, err := signJWT(secret, issued)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"iat":1700000000}`))
+	noIssuedAt := jwtHeader + "." + base64.RawURLEncoding.EncodeToString([]byte(`{}`))
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"valid", sign(secret, now), nil},
+		{"valid-early", sign(secret, now.Add(-jwtExpiryTimeout)), nil},
+		{"valid-late", sign(secret, now.Add(jwtExpiryTimeout)), nil},
+		{"stale", sign(secret, now.Add(-jwtExpiryTimeout-time.Second)), errStaleToken},
+		{"future", sign(secret, now.Add(jwtExpiryTimeout+time.Second)), errFutureToken},
+		{"wrong-secret", sign([]byte("fedcba9876543210fedcba9876543210"), now), errInvalidTokenSig},
+		{"unsigned", unsigned + ".", errInvalidTokenAlg},
+		{"no-issued-at", noIssuedAt + "." + jwtSignature(secret, noIssuedAt), errMissingIssuedAt},
+		{"malformed", "not-a-token", errMalformedToken},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, "http://url.com", nil)
+		r.Header.Set("Authorization", "Bearer "+test.token)
+		if err := VerifyJWT(secret, r, now); err != test.err {
+			t.Errorf("%s: error mismatch: have %v, want %v", test.name, err, test.err)
+		}
+	}
+	r := httptest.NewRequest(http.MethodPost, "http://url.com", nil)
+	if err := VerifyJWT(secret, r, now); err != errMissingToken {
+		t.Errorf("no token: error mismatch: have %v, want %v", err, errMissingToken)
+	}
+}
+
+// Tests that clients dialed with authentication are accepted by a server
+// requiring it over both HTTP and WebSocket, and that other clients are not.
+func TestJWTClientAuth(t *testing.T) {
+	var (
+		secret = []byte("0123456789abcdef0123456789abcdef")
+		srv    = newTestServer()
+	)
+	defer srv.Stop()
+
+	handler := srv.WebsocketHandler([]string{"*"})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := VerifyJWT(secret, r, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	for _, url := range []string{ts.URL, "ws" + strings.TrimPrefix(ts.URL, "http")} {
+		client, err := DialContextWithAuth(context.Background(), url, NewJWTAuth(secret))
+		if err != nil {
+			t.Fatalf("%s: failed to dial: %v", url, err)
+		}
+		var result string
+		if err := client.Call(&result, "test_rets"); err != nil {
+			t.Errorf("%s: authenticated call failed: %v", url, err)
+		}
+		client.Close()
+
+		client, err = DialContextWithAuth(context.Background(), url, NewJWTAuth([]byte("wrong")))
+		if err == nil {
+			if err := client.Call(&result, "test_rets"); err == nil {
+				t.Errorf("%s: call with wrong secret succeeded", url)
+			}
+			client.Close()
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package stratum
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+)
+
+// share is the record of an accepted share, for hashrate estimation.
+type share struct {
+	time       time.Time
+	difficulty uint64
+}
+
+// worker tracks the shares submitted by a named worker, across all of its
+// connections.
+type worker struct {
+	sessions  int       // Number of connections authorized as the worker, guarded by the server lock
+	idleSince time.Time // Time the last connection of the worker closed, guarded by the server lock
+
+	lock      sync.Mutex
+	firstSeen time.Time
+	lastShare time.Time
+	shares    []share // Accepted shares within the hashrate window
+
+	accepted uint64
+	stale    uint64
+	invalid  uint64
+	blocks   uint64
+}
+
+// acceptShare records a valid share of the given difficulty.
+func (w *worker) acceptShare(difficulty uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := time.Now()
+	w.seen(now)
+	w.lastShare = now
+	w.accepted++
+	w.shares = append(w.shares, share{time: now, difficulty: difficulty})
+	w.prune(now)
+}
+
+// staleShare records a share for a job which is no longer mined.
+func (w *worker) staleShare() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.seen(time.Now())
+	w.stale++
+}
+
+// invalidShare records a malformed, duplicate or low difficulty share.
+func (w *worker) invalidShare() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.seen(time.Now())
+	w.invalid++
+}
+
+// foundBlock records a share which was a full solution.
+func (w *worker) foundBlock() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.blocks++
+}
+
+// release detaches a connection from the worker. The server lock must be held.
+func (w *worker) release(now time.Time) {
+	w.sessions--
+	if w.sessions == 0 {
+		w.idleSince = now
+	}
+}
+
+// seen starts the hashrate window of a new worker. The lock must be held.
+func (w *worker) seen(now time.Time) {
+	if w.firstSeen.IsZero() {
+		w.firstSeen = now
+	}
+}
+
+// prune drops the shares which fell out of the hashrate window. The lock must
+// be held.
+func (w *worker) prune(now time.Time) {
+	i := 0
+	for i < len(w.shares) && now.Sub(w.shares[i].time) > hashrateWindow {
+		i++
+	}
+	w.shares = w.shares[i:]
+}
+
+// hashrate estimates the hashes per second of the worker from the difficulty
+// of its accepted shares within the hashrate window.
+func (w *worker) hashrate(now time.Time) uint64 {
+	w.prune(now)
+
+	window := hashrateWindow
+	if since := now.Sub(w.firstSeen); since < window {
+		window = since
+	}
+	if window < time.Second {
+		window = time.Second
+	}
+	var work uint64
+	for _, share := range w.shares {
+		work += share.difficulty
+	}
+	return uint64(float64(work) / window.Seconds())
+}
+
+// WorkerStats are the statistics of a worker of the Stratum server.
+type WorkerStats struct {
+	Hashrate  hexutil.Uint64 `json:"hashrate"`
+	Accepted  hexutil.Uint64 `json:"accepted"`
+	Stale     hexutil.Uint64 `json:"stale"`
+	Invalid   hexutil.Uint64 `json:"invalid"`
+	Blocks    hexutil.Uint64 `json:"blocks"`
+	LastShare hexutil.Uint64 `json:"lastShare"` // Unix time of the last accepted share
+}
+
+// stats returns a snapshot of the statistics of the worker.
+func (w *worker) stats(now time.Time) WorkerStats {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	stats := WorkerStats{
+		Hashrate: hexutil.Uint64(w.hashrate(now)),
+		Accepted: hexutil.Uint64(w.accepted),
+		Stale:    hexutil.Uint64(w.stale),
+		Invalid:  hexutil.Uint64(w.invalid),
+		Blocks:   hexutil.Uint64(w.blocks),
+	}
+	if !w.lastShare.IsZero() {
+		stats.LastShare = hexutil.Uint64(w.lastShare.Unix())
+	}
+	return stats
+}
+
+// PublicStratumAPI exposes the statistics of the Stratum server.
+type PublicStratumAPI struct {
+	s *Server
+}
+
+// Workers returns the statistics of every worker which submitted shares.
+func (api *PublicStratumAPI) Workers() map[string]WorkerStats {
+	api.s.lock.RLock()
+	defer api.s.lock.RUnlock()
+
+	now := time.Now()
+	stats := make(map[string]WorkerStats, len(api.s.workers))
+	for name, worker := range api.s.workers {
+		stats[name] = worker.stats(now)
+	}
+	return stats
+}
+
+// Hashrate returns the combined hashrate of all the workers.
+func (api *PublicStratumAPI) Hashrate() hexutil.Uint64 {
+	var total uint64
+	for _, stats := range api.Workers() {
+		total += uint64(stats.Hashrate)
+	}
+	return hexutil.Uint64(total)
+}
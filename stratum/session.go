@@ -0,0 +1,345 @@
+package stratum
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/log"
+)
+
+const (
+	// protocolVersion is the Stratum flavour announced to the miners.
+	protocolVersion = "EthereumStratum/1.0.0"
+
+	// extranonceSize is the number of leading nonce bytes assigned by the
+	// server to every connection.
+	extranonceSize = 2
+
+	// maxRequestSize is the maximum size of a request line.
+	maxRequestSize = 4 * 1024
+
+	// idleTimeout is how long a connection may stay silent before it is
+	// dropped.
+	idleTimeout = 10 * time.Minute
+
+	// writeTimeout bounds sending a message to a miner.
+	writeTimeout = 10 * time.Second
+)
+
+// stratumError is an error as reported to Stratum miners, a code and message.
+type stratumError struct {
+	code    int
+	message string
+}
+
+func (e *stratumError) Error() string { return e.message }
+
+// MarshalJSON encodes the error as the [code, message, traceback] triple of
+// the Stratum protocol.
+func (e *stratumError) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.code, e.message, nil})
+}
+
+var (
+	errJobNotFound   = &stratumError{21, "Job not found"}
+	errDuplicate     = &stratumError{22, "Duplicate share"}
+	errLowDifficulty = &stratumError{23, "Low difficulty share"}
+	errUnauthorized  = &stratumError{24, "Unauthorized worker"}
+	errNotSubscribed = &stratumError{25, "Not subscribed"}
+	errInvalidParams = &stratumError{20, "Invalid parameters"}
+	errUnknownMethod = &stratumError{20, "Unsupported method"}
+)
+
+// request is a Stratum request sent by a miner.
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// response is the reply to a Stratum request.
+type response struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  *stratumError   `json:"error"`
+}
+
+// notification is a Stratum message pushed to a miner.
+type notification struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// session is a connection to a remote miner.
+type session struct {
+	server     *Server
+	conn       net.Conn
+	extranonce [extranonceSize]byte
+
+	writeLock  sync.Mutex
+	lock       sync.Mutex
+	subscribed bool
+	worker     string  // Name of the authorized worker, empty until authorized
+	workerStat *worker // Statistics of the authorized worker
+	closeOnce  sync.Once
+}
+
+func newSession(server *Server, conn net.Conn, extranonce uint16) *session {
+	s := &session{
+		server: server,
+		conn:   conn,
+	}
+	binary.BigEndian.PutUint16(s.extranonce[:], extranonce)
+	return s
+}
+
+// extranonceID returns the extranonce of the connection as reserved by the
+// server.
+func (s *session) extranonceID() uint16 {
+	return binary.BigEndian.Uint16(s.extranonce[:])
+}
+
+// stats returns the statistics of the authorized worker, if any.
+func (s *session) stats() *worker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.workerStat
+}
+
+// serve reads and handles the requests of the miner until the connection is
+// closed.
+func (s *session) serve() {
+	defer s.close()
+
+	scanner := bufio.NewScanner(s.conn)
+	scanner.Buffer(make([]byte, maxRequestSize), maxRequestSize)
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				log.Debug("Stratum connection failed", "remote", s.conn.RemoteAddr(), "err", err)
+			}
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			log.Debug("Stratum received malformed request", "remote", s.conn.RemoteAddr(), "err", err)
+			return
+		}
+		result, serr := s.handle(&req)
+		if err := s.write(&response{ID: req.ID, Result: result, Error: serr}); err != nil {
+			return
+		}
+		if req.Method == "mining.authorize" && serr == nil {
+			s.start()
+		}
+	}
+}
+
+// close terminates the connection of the session.
+func (s *session) close() {
+	s.closeOnce.Do(func() { s.conn.Close() })
+}
+
+// handle dispatches a request to its handler, returning the result or the
+// Stratum error to reply with.
+func (s *session) handle(req *request) (interface{}, *stratumError) {
+	switch req.Method {
+	case "mining.subscribe":
+		s.lock.Lock()
+		s.subscribed = true
+		s.lock.Unlock()
+
+		extranonce := hex.EncodeToString(s.extranonce[:])
+		return []interface{}{[]string{"mining.notify", extranonce, protocolVersion}, extranonce}, nil
+
+	case "mining.extranonce.subscribe":
+		// The extranonce of a connection never changes
+		return true, nil
+
+	case "mining.authorize":
+		params, err := stringParams(req.Params, 1)
+		if err != nil {
+			return nil, err
+		}
+		if params[0] == "" {
+			return nil, errUnauthorized
+		}
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if !s.subscribed {
+			return nil, errNotSubscribed
+		}
+		s.workerStat = s.server.authorize(params[0], s.workerStat)
+		s.worker = params[0]
+		log.Debug("Stratum worker authorized", "worker", s.worker, "remote", s.conn.RemoteAddr())
+		return true, nil
+
+	case "mining.submit":
+		params, err := stringParams(req.Params, 3)
+		if err != nil {
+			return nil, err
+		}
+		s.lock.Lock()
+		worker, stats := s.worker, s.workerStat
+		s.lock.Unlock()
+		if worker == "" {
+			return nil, errUnauthorized
+		}
+		if err := s.submit(worker, stats, params[1], params[2]); err != nil {
+			return nil, err
+		}
+		return true, nil
+
+	default:
+		return nil, errUnknownMethod
+	}
+}
+
+// start sends the share difficulty and the current job to a newly authorized
+// miner.
+func (s *session) start() {
+	difficulty := float64(s.server.config.ShareDifficulty) / float64(1<<32)
+	if err := s.write(&notification{Method: "mining.set_difficulty", Params: []interface{}{difficulty}}); err != nil {
+		return
+	}
+	s.server.lock.RLock()
+	work := s.server.currentJob()
+	s.server.lock.RUnlock()
+	if work != nil {
+		s.notify(work, true)
+	}
+}
+
+// notify hands a job to the miner if it is authorized. The work package is the
+// seal hash and, like the GetWork API, the number of the header in place of
+// the seed hash.
+func (s *session) notify(work *job, clean bool) {
+	s.lock.Lock()
+	authorized := s.worker != ""
+	s.lock.Unlock()
+	if !authorized {
+		return
+	}
+	s.write(&notification{
+		Method: "mining.notify",
+		Params: []interface{}{work.id, hexutil.EncodeBig(work.header.Number(common.ZONE_CTX)), work.header.SealHash().Hex(), clean},
+	})
+}
+
+// submit validates a share of the worker and forwards it to the node if it is
+// a full solution.
+func (s *session) submit(worker string, stats *worker, id string, nonceHex string) *stratumError {
+	nonce, err := s.parseNonce(nonceHex)
+	if err != nil {
+		stats.invalidShare()
+		invalidMeter.Mark(1)
+		return err
+	}
+	work := s.server.findJob(id)
+	if work == nil {
+		stats.staleShare()
+		staleMeter.Mark(1)
+		return errJobNotFound
+	}
+	work.lock.Lock()
+	if _, ok := work.nonces[nonce]; ok {
+		work.lock.Unlock()
+		stats.invalidShare()
+		invalidMeter.Mark(1)
+		return errDuplicate
+	}
+	work.nonces[nonce] = struct{}{}
+	work.lock.Unlock()
+
+	header := types.CopyHeader(work.header)
+	header.SetNonce(nonce)
+	powHash := s.server.powHash(header).Big()
+	if powHash.Cmp(s.server.shareTarget) > 0 {
+		stats.invalidShare()
+		invalidMeter.Mark(1)
+		return errLowDifficulty
+	}
+	stats.acceptShare(s.server.config.ShareDifficulty)
+	acceptedMeter.Mark(1)
+
+	if powHash.Cmp(work.target) <= 0 {
+		if err := s.server.submitBlock(header); err != nil {
+			log.Warn("Stratum failed to submit block", "worker", worker, "hash", header.Hash(), "err", err)
+			return nil
+		}
+		stats.foundBlock()
+		blocksMeter.Mark(1)
+	}
+	return nil
+}
+
+// parseNonce decodes a submitted nonce, which is either the part searched by
+// the miner or the full nonce including the extranonce of the connection.
+func (s *session) parseNonce(nonceHex string) (types.BlockNonce, *stratumError) {
+	var nonce types.BlockNonce
+
+	blob, err := hex.DecodeString(strings.TrimPrefix(nonceHex, "0x"))
+	if err != nil {
+		return nonce, errInvalidParams
+	}
+	switch len(blob) {
+	case len(nonce) - extranonceSize:
+		copy(nonce[:], s.extranonce[:])
+		copy(nonce[extranonceSize:], blob)
+	case len(nonce):
+		if string(blob[:extranonceSize]) != string(s.extranonce[:]) {
+			return nonce, errInvalidParams
+		}
+		copy(nonce[:], blob)
+	default:
+		return nonce, errInvalidParams
+	}
+	return nonce, nil
+}
+
+// write sends a message to the miner.
+func (s *session) write(msg interface{}) error {
+	blob, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := s.conn.Write(append(blob, '\n')); err != nil {
+		log.Debug("Stratum failed to write to miner", "remote", s.conn.RemoteAddr(), "err", err)
+		s.close()
+		return err
+	}
+	return nil
+}
+
+// stringParams decodes the leading string parameters of a request.
+func stringParams(raw []json.RawMessage, n int) ([]string, *stratumError) {
+	if len(raw) < n {
+		return nil, errInvalidParams
+	}
+	params := make([]string, n)
+	for i := 0; i < n; i++ {
+		if err := json.Unmarshal(raw[i], &params[i]); err != nil {
+			return nil, errInvalidParams
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,475 @@
+// Package stratum implements a Stratum mining server, which hands out the
+// pending header of the node to remote miners and forwards their solutions.
+//
+// The server speaks Stratum v1 in its EthereumStratum/1.0.0 flavour: every
+// connection is given an extranonce, which is the prefix of the nonces the
+// miner may search, and shares are validated against a share difficulty that
+// is lower than the difficulty of the header.
+package stratum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/consensus"
+	"github.com/dominant-strategies/go-quai/consensus/progpow"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/event"
+	"github.com/dominant-strategies/go-quai/log"
+	"github.com/dominant-strategies/go-quai/metrics"
+	"github.com/dominant-strategies/go-quai/params"
+	"github.com/dominant-strategies/go-quai/quaiclient"
+	"github.com/dominant-strategies/go-quai/rpc"
+)
+
+const (
+	// maxJobs is the number of most recent jobs shares are accepted for.
+	maxJobs = 8
+
+	// recommitInterval is how often the pending header is polled for changes
+	// in between chain head events, e.g. for newly arrived transactions.
+	recommitInterval = 2 * time.Second
+
+	// hashrateWindow is the period over which the hashrate of a worker is
+	// estimated from its accepted shares.
+	hashrateWindow = 10 * time.Minute
+
+	// workerExpiry is how long the statistics of a worker are kept after its
+	// last connection closed.
+	workerExpiry = hashrateWindow
+
+	// maxExtranonces is the number of distinct extranonces, which bounds the
+	// number of connections served at once.
+	maxExtranonces = 1 << (8 * extranonceSize)
+
+	// submitTimeout bounds the forwarding of a solution to a dom node.
+	submitTimeout = 5 * time.Second
+
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+)
+
+var (
+	big2e256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0)) // 2^256
+
+	acceptedMeter = metrics.NewRegisteredMeter("stratum/shares/accepted", nil)
+	staleMeter    = metrics.NewRegisteredMeter("stratum/shares/stale", nil)
+	invalidMeter  = metrics.NewRegisteredMeter("stratum/shares/invalid", nil)
+	blocksMeter   = metrics.NewRegisteredMeter("stratum/blocks", nil)
+)
+
+// Config are the configuration parameters of the Stratum server.
+type Config struct {
+	Enabled bool   `toml:",omitempty"`
+	Host    string `toml:",omitempty"`
+	Port    int    `toml:",omitempty"`
+
+	// ShareDifficulty is the difficulty a share has to meet to be accepted.
+	ShareDifficulty uint64 `toml:",omitempty"`
+
+	// MaxSessions is the maximum number of miner connections served at once.
+	// Zero allows as many as there are extranonces.
+	MaxSessions int `toml:",omitempty"`
+
+	// DomURLs are the authenticated endpoints of the region and the prime
+	// node, in that order. Solutions coincident with a dom chain are also
+	// handed to the dom nodes, like an external miner does.
+	DomURLs []string `toml:",omitempty"`
+}
+
+// DefaultConfig contains the default settings of the Stratum server.
+var DefaultConfig = Config{
+	Host:            "localhost",
+	Port:            3333,
+	ShareDifficulty: 1 << 32,
+	MaxSessions:     1024,
+}
+
+// Backend is the interface of the node the Stratum server mines for.
+type Backend interface {
+	// GetPendingHeader returns the header to be mined on top of the current
+	// head of the node.
+	GetPendingHeader() (*types.Header, error)
+
+	// ReceiveMinedHeader constructs, writes and announces the block of a
+	// header mined on top of a pending header of the node.
+	ReceiveMinedHeader(header *types.Header) error
+
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+
+	// ChainConfig returns the config of the chain served by the node.
+	ChainConfig() *params.ChainConfig
+}
+
+// domClient is the transport solutions are handed to a dom node with.
+type domClient interface {
+	ReceiveMinedHeader(ctx context.Context, header *types.Header) error
+	Close()
+}
+
+// job is a unit of work handed out to the miners.
+type job struct {
+	id     string
+	header *types.Header
+	target *big.Int // Boundary of a full solution, 2^256/difficulty
+
+	lock   sync.Mutex
+	nonces map[types.BlockNonce]struct{} // Submitted nonces, to reject duplicates
+}
+
+// Server is a Stratum mining server.
+type Server struct {
+	config  Config
+	backend Backend
+	engine  consensus.Engine
+	doms    []domClient
+
+	shareTarget *big.Int // Boundary of a share, 2^256/share difficulty
+
+	listener net.Listener
+	quit     chan struct{}
+	wg       sync.WaitGroup
+
+	lock        sync.RWMutex
+	jobs        []*job // Most recent jobs, the last one is the current
+	jobSeq      uint64
+	extranonce  uint16              // Last extranonce handed out
+	extranonces map[uint16]struct{} // Extranonces of the open connections
+	sessions    map[*session]struct{}
+	workers     map[string]*worker
+}
+
+// New creates a Stratum server for the given backend. Dom nodes are dialed
+// with the secret authenticating the coordination APIs. The server is to be
+// registered as a lifecycle of the node, which starts and stops it.
+func New(backend Backend, engine consensus.Engine, config Config, jwtSecret []byte) (*Server, error) {
+	if config.ShareDifficulty == 0 {
+		return nil, errors.New("share difficulty must be positive")
+	}
+	if config.MaxSessions < 0 || config.MaxSessions > maxExtranonces {
+		return nil, fmt.Errorf("max sessions must be between 0 and %d", maxExtranonces)
+	}
+	if nodeCtx := backend.ChainConfig().Location.Context(); nodeCtx != common.ZONE_CTX {
+		return nil, fmt.Errorf("stratum can only be served by a zone node, not %s", common.OrderToString(nodeCtx))
+	}
+	s := newServer(backend, engine, config)
+	for _, url := range config.DomURLs {
+		client, err := quaiclient.Dial(url, jwtSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to dom node %s: %v", url, err)
+		}
+		s.doms = append(s.doms, client)
+	}
+	return s, nil
+}
+
+// newServer creates a Stratum server which is not attached to a node.
+func newServer(backend Backend, engine consensus.Engine, config Config) *Server {
+	return &Server{
+		config:      config,
+		backend:     backend,
+		engine:      engine,
+		shareTarget: new(big.Int).Div(big2e256, new(big.Int).SetUint64(config.ShareDifficulty)),
+		extranonces: make(map[uint16]struct{}),
+		sessions:    make(map[*session]struct{}),
+		workers:     make(map[string]*worker),
+	}
+}
+
+// Start implements node.Lifecycle, opening the Stratum listener and starting
+// to track the pending header of the node.
+func (s *Server) Start() error {
+	addr := net.JoinHostPort(s.config.Host, fmt.Sprintf("%d", s.config.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.quit = make(chan struct{})
+
+	s.wg.Add(2)
+	go s.workLoop()
+	go s.acceptLoop()
+
+	log.Info("Stratum server started", "addr", listener.Addr(), "sharediff", s.config.ShareDifficulty)
+	return nil
+}
+
+// Stop implements node.Lifecycle, closing the listener and all the miner
+// connections.
+func (s *Server) Stop() error {
+	if s.listener == nil {
+		return nil
+	}
+	close(s.quit)
+	s.listener.Close()
+
+	s.lock.Lock()
+	for session := range s.sessions {
+		session.close()
+	}
+	s.lock.Unlock()
+	s.wg.Wait()
+
+	for _, dom := range s.doms {
+		dom.Close()
+	}
+	log.Info("Stratum server stopped")
+	return nil
+}
+
+// APIs returns the RPC APIs the Stratum server offers.
+func (s *Server) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "stratum",
+		Version:   "1.0",
+		Service:   &PublicStratumAPI{s},
+		Public:    true,
+	}}
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// acceptLoop serves the incoming miner connections, dropping the ones beyond
+// the session limit.
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+			log.Warn("Stratum failed to accept connection", "err", err)
+			continue
+		}
+		s.lock.Lock()
+		if len(s.sessions) >= s.maxSessions() {
+			s.lock.Unlock()
+			log.Debug("Stratum session limit reached", "remote", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		session := newSession(s, conn, s.nextExtranonce())
+		s.sessions[session] = struct{}{}
+		s.lock.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			session.serve()
+
+			stats := session.stats()
+			s.lock.Lock()
+			delete(s.sessions, session)
+			delete(s.extranonces, session.extranonceID())
+			if stats != nil {
+				stats.release(time.Now())
+			}
+			s.lock.Unlock()
+		}()
+	}
+}
+
+// maxSessions returns the number of connections served at once.
+func (s *Server) maxSessions() int {
+	if s.config.MaxSessions == 0 {
+		return maxExtranonces
+	}
+	return s.config.MaxSessions
+}
+
+// nextExtranonce reserves the next extranonce which no open connection uses,
+// so miners never share a nonce space. There is always one, as the number of
+// connections is bounded by the number of extranonces. The lock must be held.
+func (s *Server) nextExtranonce() uint16 {
+	for {
+		s.extranonce++
+		if _, ok := s.extranonces[s.extranonce]; !ok {
+			s.extranonces[s.extranonce] = struct{}{}
+			return s.extranonce
+		}
+	}
+}
+
+// workLoop tracks the pending header of the node and hands every new one out
+// to the miners as a job.
+func (s *Server) workLoop() {
+	defer s.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	headSub := s.backend.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	ticker := time.NewTicker(recommitInterval)
+	defer ticker.Stop()
+
+	s.updateWork()
+	for {
+		select {
+		case <-headCh:
+			s.updateWork()
+		case <-ticker.C:
+			s.updateWork()
+			s.expireWorkers(time.Now())
+		case <-headSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// updateWork creates a new job if the pending header of the node changed, and
+// notifies all the subscribed miners of it. Jobs on top of another parent are
+// announced as clean, so miners drop their previous work.
+func (s *Server) updateWork() {
+	header, err := s.backend.GetPendingHeader()
+	if err != nil || header == nil {
+		log.Debug("Stratum failed to retrieve pending header", "err", err)
+		return
+	}
+	if header.Difficulty().Sign() <= 0 {
+		return
+	}
+	s.lock.Lock()
+	var clean bool
+	if current := s.currentJob(); current != nil {
+		if current.header.SealHash() == header.SealHash() {
+			s.lock.Unlock()
+			return
+		}
+		clean = current.header.ParentHash(common.ZONE_CTX) != header.ParentHash(common.ZONE_CTX)
+	}
+	s.jobSeq++
+	work := &job{
+		id:     fmt.Sprintf("%x", s.jobSeq),
+		header: types.CopyHeader(header),
+		target: new(big.Int).Div(big2e256, header.Difficulty()),
+		nonces: make(map[types.BlockNonce]struct{}),
+	}
+	if clean {
+		s.jobs = s.jobs[:0]
+	}
+	s.jobs = append(s.jobs, work)
+	if len(s.jobs) > maxJobs {
+		s.jobs = s.jobs[len(s.jobs)-maxJobs:]
+	}
+	sessions := make([]*session, 0, len(s.sessions))
+	for session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.lock.Unlock()
+
+	log.Debug("Stratum sending new job", "job", work.id, "number", header.NumberArray(), "sealhash", header.SealHash(), "clean", clean)
+	for _, session := range sessions {
+		session.notify(work, clean)
+	}
+}
+
+// currentJob returns the most recent job. The lock must be held.
+func (s *Server) currentJob() *job {
+	if len(s.jobs) == 0 {
+		return nil
+	}
+	return s.jobs[len(s.jobs)-1]
+}
+
+// findJob returns the job with the given id, if it is still mineable.
+func (s *Server) findJob(id string) *job {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, job := range s.jobs {
+		if job.id == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// authorize attaches a connection to the statistics of the named worker,
+// creating them if needed, and detaches it from the worker it was previously
+// authorized as.
+func (s *Server) authorize(name string, previous *worker) *worker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	if previous != nil {
+		previous.release(now)
+	}
+	w := s.workers[name]
+	if w == nil {
+		w = &worker{}
+		s.workers[name] = w
+	}
+	w.sessions++
+	return w
+}
+
+// expireWorkers drops the statistics of the workers which had no connection
+// for longer than the worker expiry.
+func (s *Server) expireWorkers(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for name, w := range s.workers {
+		if w.sessions == 0 && now.Sub(w.idleSince) > workerExpiry {
+			delete(s.workers, name)
+		}
+	}
+}
+
+// powHash computes the proof-of-work hash of a sealed header, filling in the
+// mix digest for engines which have one.
+func (s *Server) powHash(header *types.Header) common.Hash {
+	if _, ok := s.engine.(*progpow.Progpow); ok {
+		mixHash, powHash := s.engine.ComputePowLight(header)
+		header.SetMixHash(mixHash)
+		return powHash
+	}
+	return header.Hash()
+}
+
+// submitBlock hands a full solution to the node and, if it is coincident with
+// a dom chain, to the dom nodes up to the order of the block.
+func (s *Server) submitBlock(header *types.Header) error {
+	_, order, err := s.engine.CalcOrder(header)
+	if err != nil {
+		return err
+	}
+	if err := s.backend.ReceiveMinedHeader(header); err != nil {
+		return err
+	}
+	// The first dom is the region, the second is prime
+	for i, dom := range s.doms {
+		if common.REGION_CTX-i < order {
+			break
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), submitTimeout)
+		err := dom.ReceiveMinedHeader(ctx, header)
+		cancel()
+		if err != nil {
+			log.Error("Stratum failed to forward block to dom", "order", common.OrderToString(common.REGION_CTX-i), "hash", header.Hash(), "err", err)
+		}
+	}
+	log.Info("Stratum block found", "number", header.NumberArray(), "hash", header.Hash(), "order", common.OrderToString(order))
+	return nil
+}
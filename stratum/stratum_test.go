@@ -0,0 +1,312 @@
+package stratum
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/consensus/blake3pow"
+	"github.com/dominant-strategies/go-quai/core"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/event"
+	"github.com/dominant-strategies/go-quai/params"
+)
+
+// testBackend is a node serving a fixed pending header and collecting the
+// headers mined on top of it.
+type testBackend struct {
+	lock    sync.Mutex
+	pending *types.Header
+	mined   []*types.Header
+	feed    event.Feed
+}
+
+func (b *testBackend) GetPendingHeader() (*types.Header, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return types.CopyHeader(b.pending), nil
+}
+
+func (b *testBackend) ReceiveMinedHeader(header *types.Header) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.mined = append(b.mined, header)
+	return nil
+}
+
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.feed.Subscribe(ch)
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig.WithLocation(common.Location{0, 0})
+}
+
+// setPending replaces the pending header and announces a new chain head.
+func (b *testBackend) setPending(header *types.Header) {
+	b.lock.Lock()
+	b.pending = header
+	b.lock.Unlock()
+	b.feed.Send(core.ChainHeadEvent{})
+}
+
+func (b *testBackend) minedCount() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.mined)
+}
+
+// testHeader creates a pending header on top of the given parent.
+func testHeader(parent common.Hash, difficulty int64) *types.Header {
+	header := types.EmptyHeader()
+	for ctx := 0; ctx < common.HierarchyDepth; ctx++ {
+		header.SetNumber(big.NewInt(1), ctx)
+		header.SetParentHash(parent, ctx)
+	}
+	header.SetDifficulty(big.NewInt(difficulty))
+	header.SetLocation(common.Location{0, 0})
+	return header
+}
+
+// testMiner is a Stratum client.
+type testMiner struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int
+	notes  []map[string]interface{}
+}
+
+func newTestMiner(t *testing.T, addr net.Addr) *testMiner {
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	return &testMiner{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// read returns the next message sent by the server.
+func (m *testMiner) read() map[string]interface{} {
+	m.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := m.reader.ReadBytes('\n')
+	if err != nil {
+		m.t.Fatalf("failed to read message: %v", err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(line, &msg); err != nil {
+		m.t.Fatalf("failed to decode message %q: %v", line, err)
+	}
+	return msg
+}
+
+// call sends a request and waits for its response, queueing notifications.
+func (m *testMiner) call(method string, params ...interface{}) (interface{}, []interface{}) {
+	m.nextID++
+	blob, _ := json.Marshal(map[string]interface{}{"id": m.nextID, "method": method, "params": params})
+	if _, err := m.conn.Write(append(blob, '\n')); err != nil {
+		m.t.Fatalf("failed to send %s: %v", method, err)
+	}
+	for {
+		msg := m.read()
+		if id, ok := msg["id"].(float64); ok && int(id) == m.nextID {
+			errs, _ := msg["error"].([]interface{})
+			return msg["result"], errs
+		}
+		m.notes = append(m.notes, msg)
+	}
+}
+
+// notification returns the next notification of the given method.
+func (m *testMiner) notification(method string) []interface{} {
+	for {
+		var msg map[string]interface{}
+		if len(m.notes) > 0 {
+			msg, m.notes = m.notes[0], m.notes[1:]
+		} else {
+			msg = m.read()
+		}
+		if msg["method"] == method {
+			return msg["params"].([]interface{})
+		}
+	}
+}
+
+// Tests the share lifecycle of a miner: subscription, work notifications and
+// the classification of submitted shares, including full solutions.
+func TestSubmitShares(t *testing.T) {
+	backend := &testBackend{pending: testHeader(common.Hash{1}, 8)}
+	server := newServer(backend, blake3pow.New(blake3pow.Config{PowMode: blake3pow.ModeNormal}, nil, false), Config{
+		Host:            "127.0.0.1",
+		ShareDifficulty: 2,
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer server.Stop()
+
+	miner := newTestMiner(t, server.Addr())
+	defer miner.conn.Close()
+
+	if _, errs := miner.call("mining.authorize", "worker", "x"); errs == nil || errs[0].(float64) != 25 {
+		t.Fatalf("unsubscribed authorization accepted: %v", errs)
+	}
+	result, errs := miner.call("mining.subscribe", "test", protocolVersion)
+	if errs != nil {
+		t.Fatalf("failed to subscribe: %v", errs)
+	}
+	extranonce, _ := hex.DecodeString(result.([]interface{})[1].(string))
+	if len(extranonce) != extranonceSize {
+		t.Fatalf("extranonce size mismatch: have %d, want %d", len(extranonce), extranonceSize)
+	}
+	if _, errs := miner.call("mining.submit", "worker", "1", "000000000000"); errs == nil || errs[0].(float64) != 24 {
+		t.Fatalf("unauthorized share accepted: %v", errs)
+	}
+	if _, errs := miner.call("mining.authorize", "worker", "x"); errs != nil {
+		t.Fatalf("failed to authorize: %v", errs)
+	}
+	if diff := miner.notification("mining.set_difficulty")[0].(float64); diff != 2.0/(1<<32) {
+		t.Fatalf("share difficulty mismatch: have %v", diff)
+	}
+	work := miner.notification("mining.notify")
+	jobID, sealHash := work[0].(string), work[2].(string)
+	if sealHash != backend.pending.SealHash().Hex() {
+		t.Fatalf("seal hash mismatch: have %s, want %s", sealHash, backend.pending.SealHash().Hex())
+	}
+	// Classify the nonces of the connection by the target they meet
+	var low, share, block string
+	for i := uint64(0); low == "" || share == "" || block == ""; i++ {
+		var nonce types.BlockNonce
+		copy(nonce[:], extranonce)
+		binary.BigEndian.PutUint32(nonce[4:], uint32(i))
+
+		header := types.CopyHeader(backend.pending)
+		header.SetNonce(nonce)
+		pow := header.Hash().Big()
+		suffix := hex.EncodeToString(nonce[extranonceSize:])
+		switch {
+		case pow.Cmp(server.shareTarget) > 0:
+			low = suffix
+		case pow.Cmp(new(big.Int).Div(big2e256, big.NewInt(8))) > 0:
+			share = suffix
+		default:
+			block = suffix
+		}
+	}
+	if _, errs := miner.call("mining.submit", "worker", jobID, low); errs == nil || errs[0].(float64) != 23 {
+		t.Errorf("low difficulty share accepted: %v", errs)
+	}
+	if _, errs := miner.call("mining.submit", "worker", jobID, share); errs != nil {
+		t.Errorf("valid share rejected: %v", errs)
+	}
+	if _, errs := miner.call("mining.submit", "worker", jobID, share); errs == nil || errs[0].(float64) != 22 {
+		t.Errorf("duplicate share accepted: %v", errs)
+	}
+	if _, errs := miner.call("mining.submit", "worker", jobID, hex.EncodeToString(extranonce)+block); errs != nil {
+		t.Errorf("full solution rejected: %v", errs)
+	}
+	if mined := backend.minedCount(); mined != 1 {
+		t.Fatalf("mined block count mismatch: have %d, want 1", mined)
+	}
+	// A new head makes the previous job stale
+	backend.setPending(testHeader(common.Hash{2}, 8))
+	work = miner.notification("mining.notify")
+	if work[0].(string) == jobID || work[3] != true {
+		t.Fatalf("new job not clean: %v", work)
+	}
+	if _, errs := miner.call("mining.submit", "worker", jobID, "00000000ffff"); errs == nil || errs[0].(float64) != 21 {
+		t.Errorf("stale share accepted: %v", errs)
+	}
+	stats := (&PublicStratumAPI{server}).Workers()["worker"]
+	if stats.Accepted != 2 || stats.Stale != 1 || stats.Invalid != 2 || stats.Blocks != 1 {
+		t.Errorf("worker stats mismatch: %+v", stats)
+	}
+	if stats.Hashrate == 0 {
+		t.Errorf("worker hashrate not tracked")
+	}
+}
+
+// Tests that connections beyond the session limit are dropped, that the
+// extranonces of open connections are never handed out twice and that the
+// statistics of workers without connections expire.
+func TestSessionLimits(t *testing.T) {
+	backend := &testBackend{pending: testHeader(common.Hash{1}, 8)}
+	server := newServer(backend, blake3pow.New(blake3pow.Config{PowMode: blake3pow.ModeNormal}, nil, false), Config{
+		Host:            "127.0.0.1",
+		ShareDifficulty: 2,
+		MaxSessions:     2,
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer server.Stop()
+
+	subscribe := func(miner *testMiner) string {
+		result, errs := miner.call("mining.subscribe", "test", protocolVersion)
+		if errs != nil {
+			t.Fatalf("failed to subscribe: %v", errs)
+		}
+		return result.([]interface{})[1].(string)
+	}
+	sessions := func() int {
+		server.lock.RLock()
+		defer server.lock.RUnlock()
+		return len(server.sessions)
+	}
+	first, second := newTestMiner(t, server.Addr()), newTestMiner(t, server.Addr())
+	defer second.conn.Close()
+	if subscribe(first) != "0001" || subscribe(second) != "0002" {
+		t.Fatalf("extranonces not handed out in order")
+	}
+	if _, errs := second.call("mining.authorize", "old", "x"); errs != nil {
+		t.Fatalf("failed to authorize: %v", errs)
+	}
+	if _, errs := second.call("mining.authorize", "new", "x"); errs != nil {
+		t.Fatalf("failed to authorize: %v", errs)
+	}
+	// A connection beyond the limit is closed right away
+	rejected := newTestMiner(t, server.Addr())
+	defer rejected.conn.Close()
+	rejected.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := rejected.reader.ReadByte(); err == nil {
+		t.Fatalf("connection beyond the session limit served")
+	}
+	// Once a connection closes, its slot is free again, but the extranonces
+	// still in use are skipped when wrapping around
+	first.conn.Close()
+	for sessions() != 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	server.lock.Lock()
+	server.extranonce = 1
+	server.lock.Unlock()
+
+	third := newTestMiner(t, server.Addr())
+	defer third.conn.Close()
+	if extranonce := subscribe(third); extranonce != "0003" {
+		t.Fatalf("extranonce in use handed out: have %s, want 0003", extranonce)
+	}
+	// Only the workers without a connection expire
+	server.expireWorkers(time.Now().Add(workerExpiry + time.Second))
+	workers := (&PublicStratumAPI{server}).Workers()
+	if _, ok := workers["old"]; ok {
+		t.Errorf("idle worker not expired")
+	}
+	if _, ok := workers["new"]; !ok {
+		t.Errorf("connected worker expired")
+	}
+	second.conn.Close()
+	for sessions() != 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	server.expireWorkers(time.Now().Add(workerExpiry + time.Second))
+	if workers := (&PublicStratumAPI{server}).Workers(); len(workers) != 0 {
+		t.Errorf("workers of closed connections not expired: %v", workers)
+	}
+}